@@ -0,0 +1,244 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	defaultAdaptiveInitialLimit = 10
+	defaultAdaptiveMaxLimit     = 200
+	defaultAdaptiveBackoffRatio = 0.9
+	defaultAdaptiveLatency      = 5 * time.Second
+)
+
+// NewAdaptiveConcurrencyWrapper returns a TransportWrapper which
+// limits the number of requests in flight using a limit adjusted by
+// additive-increase/multiplicative-decrease (AIMD). The limit grows by
+// one following successful responses received while at least half of
+// the limit is in use and shrinks by the configured ratio following
+// 429 and 503 responses, transport errors and responses slower than
+// the latency threshold. Requests exceeding the limit wait until a
+// request completes, which happens once its response body is closed.
+// This smooths load against backends which do not publish rate limits.
+func NewAdaptiveConcurrencyWrapper(opts ...AdaptiveConcurrencyOption) *AdaptiveConcurrencyWrapper {
+	var cfg AdaptiveConcurrencyConfig
+
+	cfg.Option(opts...)
+	cfg.Default()
+
+	return &AdaptiveConcurrencyWrapper{
+		cfg:     cfg,
+		limit:   float64(cfg.InitialLimit),
+		changed: make(chan struct{}),
+	}
+}
+
+type AdaptiveConcurrencyWrapper struct {
+	cfg AdaptiveConcurrencyConfig
+	rt  http.RoundTripper
+
+	mu       sync.Mutex
+	limit    float64
+	inFlight int
+	// changed is closed and replaced whenever
+	// a request completes or the limit changes.
+	changed chan struct{}
+}
+
+func (w *AdaptiveConcurrencyWrapper) Wrap(rt http.RoundTripper) http.RoundTripper {
+	w.rt = rt
+
+	return w
+}
+
+// Limit returns the current concurrency limit.
+func (w *AdaptiveConcurrencyWrapper) Limit() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return int(w.limit)
+}
+
+func (w *AdaptiveConcurrencyWrapper) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+
+	if err := w.acquire(ctx); err != nil {
+		if req.Body != nil {
+			req.Body.Close()
+		}
+
+		return nil, err
+	}
+
+	var once sync.Once
+
+	release := func() { once.Do(w.release) }
+
+	start := time.Now()
+
+	res, err := w.rt.RoundTrip(req)
+
+	w.adjust(res, err, time.Since(start), ctx.Err() != nil)
+
+	if err != nil {
+		release()
+
+		return nil, err
+	}
+
+	// the request remains in flight until the body is consumed
+	res.Body = newCancelOnCloseBody(ctx, release, res.Body)
+
+	return res, nil
+}
+
+// acquire waits until the number of requests
+// in flight is below the current limit.
+func (w *AdaptiveConcurrencyWrapper) acquire(ctx context.Context) error {
+	for {
+		w.mu.Lock()
+
+		if w.inFlight < int(w.limit) {
+			w.inFlight++
+			w.mu.Unlock()
+
+			return nil
+		}
+
+		changed := w.changed
+		w.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-changed:
+		}
+	}
+}
+
+func (w *AdaptiveConcurrencyWrapper) release() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.inFlight--
+	w.notify()
+}
+
+// adjust updates the limit based on the outcome of a request.
+// Requests abandoned by the caller do not affect the limit.
+func (w *AdaptiveConcurrencyWrapper) adjust(res *http.Response, err error, latency time.Duration, canceled bool) {
+	if canceled {
+		return
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	overloaded := err != nil ||
+		res.StatusCode == http.StatusTooManyRequests ||
+		res.StatusCode == http.StatusServiceUnavailable ||
+		latency > w.cfg.LatencyThreshold
+
+	switch {
+	case overloaded:
+		w.limit = max(w.limit*w.cfg.BackoffRatio, float64(w.cfg.MinLimit))
+	case float64(w.inFlight*2) >= w.limit:
+		// only grow while the limit is actually being used
+		w.limit = min(w.limit+1, float64(w.cfg.MaxLimit))
+		w.notify()
+	}
+}
+
+func (w *AdaptiveConcurrencyWrapper) notify() {
+	close(w.changed)
+	w.changed = make(chan struct{})
+}
+
+type AdaptiveConcurrencyConfig struct {
+	// InitialLimit is the concurrency limit
+	// before any adjustment. Defaults to 10.
+	InitialLimit int
+	// MinLimit is the lowest the limit may
+	// shrink to. Defaults to 1.
+	MinLimit int
+	// MaxLimit is the highest the limit may
+	// grow to. Defaults to 200.
+	MaxLimit int
+	// BackoffRatio multiplies the limit when overload
+	// is detected. Defaults to 0.9.
+	BackoffRatio float64
+	// LatencyThreshold is the time to receive a response
+	// above which the backend is considered overloaded.
+	// Defaults to 5 seconds.
+	LatencyThreshold time.Duration
+}
+
+func (c *AdaptiveConcurrencyConfig) Option(opts ...AdaptiveConcurrencyOption) {
+	for _, opt := range opts {
+		opt.ConfigureAdaptiveConcurrency(c)
+	}
+}
+
+func (c *AdaptiveConcurrencyConfig) Default() {
+	if c.MinLimit <= 0 {
+		c.MinLimit = 1
+	}
+
+	if c.MaxLimit <= 0 {
+		c.MaxLimit = defaultAdaptiveMaxLimit
+	}
+
+	c.MaxLimit = max(c.MaxLimit, c.MinLimit)
+
+	if c.InitialLimit <= 0 {
+		c.InitialLimit = defaultAdaptiveInitialLimit
+	}
+
+	c.InitialLimit = min(max(c.InitialLimit, c.MinLimit), c.MaxLimit)
+
+	if c.BackoffRatio <= 0 || c.BackoffRatio >= 1 {
+		c.BackoffRatio = defaultAdaptiveBackoffRatio
+	}
+
+	if c.LatencyThreshold <= 0 {
+		c.LatencyThreshold = defaultAdaptiveLatency
+	}
+}
+
+type AdaptiveConcurrencyOption interface {
+	ConfigureAdaptiveConcurrency(*AdaptiveConcurrencyConfig)
+}
+
+// WithConcurrencyLimits sets the initial, minimum and maximum
+// concurrency limits of an AdaptiveConcurrencyWrapper.
+type WithConcurrencyLimits struct {
+	Initial int
+	Min     int
+	Max     int
+}
+
+func (l WithConcurrencyLimits) ConfigureAdaptiveConcurrency(c *AdaptiveConcurrencyConfig) {
+	c.InitialLimit = l.Initial
+	c.MinLimit = l.Min
+	c.MaxLimit = l.Max
+}
+
+// WithConcurrencyBackoffRatio sets the factor applied to the
+// concurrency limit when overload is detected. Must be between
+// zero and one exclusive.
+type WithConcurrencyBackoffRatio float64
+
+func (r WithConcurrencyBackoffRatio) ConfigureAdaptiveConcurrency(c *AdaptiveConcurrencyConfig) {
+	c.BackoffRatio = float64(r)
+}
+
+// WithLatencyThreshold sets the time to receive a response
+// above which the backend is considered overloaded.
+type WithLatencyThreshold time.Duration
+
+func (t WithLatencyThreshold) ConfigureAdaptiveConcurrency(c *AdaptiveConcurrencyConfig) {
+	c.LatencyThreshold = time.Duration(t)
+}
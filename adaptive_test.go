@@ -0,0 +1,147 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/mt-sre/client/internal/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdaptiveConcurrencyWrapperInterfaces(t *testing.T) {
+	t.Parallel()
+
+	require.Implements(t, new(http.RoundTripper), new(AdaptiveConcurrencyWrapper))
+
+	require.Implements(t, new(TransportWrapper), new(AdaptiveConcurrencyWrapper))
+}
+
+func TestAdaptiveConcurrencyWrapperLimit(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		Options       []AdaptiveConcurrencyOption
+		StatusCode    int
+		Err           error
+		ExpectedLimit int
+	}{
+		"success grows limit in use": {
+			Options:       []AdaptiveConcurrencyOption{WithConcurrencyLimits{Initial: 2}},
+			StatusCode:    http.StatusOK,
+			ExpectedLimit: 3,
+		},
+		"success keeps unused limit": {
+			Options:       []AdaptiveConcurrencyOption{WithConcurrencyLimits{Initial: 10}},
+			StatusCode:    http.StatusOK,
+			ExpectedLimit: 10,
+		},
+		"success capped by max": {
+			Options:       []AdaptiveConcurrencyOption{WithConcurrencyLimits{Initial: 2, Max: 2}},
+			StatusCode:    http.StatusOK,
+			ExpectedLimit: 2,
+		},
+		"too many requests": {
+			Options:       []AdaptiveConcurrencyOption{WithConcurrencyLimits{Initial: 10}},
+			StatusCode:    http.StatusTooManyRequests,
+			ExpectedLimit: 9,
+		},
+		"service unavailable": {
+			Options: []AdaptiveConcurrencyOption{
+				WithConcurrencyLimits{Initial: 10},
+				WithConcurrencyBackoffRatio(0.5),
+			},
+			StatusCode:    http.StatusServiceUnavailable,
+			ExpectedLimit: 5,
+		},
+		"transport error": {
+			Options:       []AdaptiveConcurrencyOption{WithConcurrencyLimits{Initial: 10}},
+			Err:           errors.New("connection reset"),
+			ExpectedLimit: 9,
+		},
+		"slow response": {
+			Options: []AdaptiveConcurrencyOption{
+				WithConcurrencyLimits{Initial: 2},
+				WithLatencyThreshold(time.Nanosecond),
+			},
+			StatusCode:    http.StatusOK,
+			ExpectedLimit: 1,
+		},
+		"shrink capped by min": {
+			Options:       []AdaptiveConcurrencyOption{WithConcurrencyLimits{Initial: 3, Min: 3}},
+			StatusCode:    http.StatusTooManyRequests,
+			ExpectedLimit: 3,
+		},
+	} {
+		tc := tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			next := Handler(func(req *http.Request) (*http.Response, error) {
+				time.Sleep(time.Millisecond)
+
+				if tc.Err != nil {
+					return nil, tc.Err
+				}
+
+				return &http.Response{StatusCode: tc.StatusCode, Body: http.NoBody, Request: req}, nil
+			})
+
+			w := NewAdaptiveConcurrencyWrapper(tc.Options...)
+			rt := w.Wrap(next)
+
+			res, err := rt.RoundTrip(testutils.MockRequest(t, http.MethodGet, nil))
+			if err == nil {
+				require.NoError(t, res.Body.Close())
+			}
+
+			assert.Equal(t, tc.ExpectedLimit, w.Limit())
+			assert.Equal(t, 0, w.inFlight)
+		})
+	}
+}
+
+func TestAdaptiveConcurrencyWrapperWaits(t *testing.T) {
+	t.Parallel()
+
+	next := Handler(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody, Request: req}, nil
+	})
+
+	w := NewAdaptiveConcurrencyWrapper(WithConcurrencyLimits{Initial: 1, Max: 1})
+	rt := w.Wrap(next)
+
+	first, err := rt.RoundTrip(testutils.MockRequest(t, http.MethodGet, nil))
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	_, err = rt.RoundTrip(testutils.MockRequest(t, http.MethodGet, nil).WithContext(ctx))
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		res, err := rt.RoundTrip(testutils.MockRequest(t, http.MethodGet, nil))
+		if assert.NoError(t, err) {
+			res.Body.Close()
+		}
+	}()
+
+	select {
+	case <-done:
+		t.Fatal("request exceeded concurrency limit")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	require.NoError(t, first.Body.Close())
+
+	<-done
+}
@@ -0,0 +1,204 @@
+package client
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptrace"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+)
+
+// defaultMaxArtifactBodySize limits the portion of a response
+// body included in a failure artifact.
+const defaultMaxArtifactBodySize = 4 << 10
+
+// WithFailureArtifacts configures a Client instance to write a
+// diagnostic bundle to Dir for every request which ultimately fails.
+// Bundles are JSON documents describing the request, the outcome of
+// each retry attempt, connection timings and a snippet of the final
+// response body if one was captured by WithErrorOnNon2xx. Credentials
+// in headers and query parameters are redacted so that bundles can be
+// attached to incident tickets.
+type WithFailureArtifacts struct {
+	// Dir is the directory bundles are written to.
+	// It is created if it does not exist.
+	Dir string
+	// MaxBodySize limits the portion of the response body
+	// included in a bundle. Defaults to 4 KiB.
+	MaxBodySize int64
+	// Logger receives errors encountered writing bundles.
+	Logger logr.Logger
+}
+
+func (fa WithFailureArtifacts) ConfigureClient(c *ClientConfig) {
+	c.FailureArtifactDir = fa.Dir
+	c.MaxArtifactBodySize = fa.MaxBodySize
+	c.artifactLogger = fa.Logger
+}
+
+// FailureArtifact is the diagnostic bundle written
+// for requests which ultimately fail.
+type FailureArtifact struct {
+	Time     time.Time         `json:"time"`
+	Duration string            `json:"duration"`
+	Method   string            `json:"method"`
+	URL      string            `json:"url"`
+	Header   http.Header       `json:"header,omitempty"`
+	Error    string            `json:"error"`
+	Attempts []ArtifactAttempt `json:"attempts,omitempty"`
+	// Timings holds the time elapsed since the start of the
+	// request at which each connection phase last completed.
+	Timings  map[string]string `json:"timings,omitempty"`
+	Response *ArtifactResponse `json:"response,omitempty"`
+}
+
+// ArtifactAttempt describes a single failed attempt.
+type ArtifactAttempt struct {
+	Attempt    int    `json:"attempt"`
+	StatusCode int    `json:"statusCode,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+// ArtifactResponse describes the final response received.
+type ArtifactResponse struct {
+	StatusCode int         `json:"statusCode"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       string      `json:"body,omitempty"`
+	Truncated  bool        `json:"truncated,omitempty"`
+}
+
+// artifactRecorder collects connection timings of
+// a request for inclusion in a FailureArtifact.
+type artifactRecorder struct {
+	start time.Time
+
+	mu      sync.Mutex
+	timings map[string]time.Duration
+}
+
+func newArtifactRecorder(ctx context.Context) (context.Context, *artifactRecorder) {
+	r := &artifactRecorder{
+		start:   time.Now(),
+		timings: make(map[string]time.Duration),
+	}
+
+	return httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+		DNSDone: func(httptrace.DNSDoneInfo) {
+			r.record("dns")
+		},
+		ConnectDone: func(string, string, error) {
+			r.record("connect")
+		},
+		TLSHandshakeDone: func(tls.ConnectionState, error) {
+			r.record("tlsHandshake")
+		},
+		WroteRequest: func(httptrace.WroteRequestInfo) {
+			r.record("wroteRequest")
+		},
+		GotFirstResponseByte: func() {
+			r.record("firstResponseByte")
+		},
+	}), r
+}
+
+func (r *artifactRecorder) record(phase string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.timings[phase] = time.Since(r.start)
+}
+
+// writeFailureArtifact writes a FailureArtifact describing the
+// failure of req with err. Errors are reported to the configured
+// logger since they must not mask the original failure.
+func (c *Client) writeFailureArtifact(req *http.Request, rec *artifactRecorder, err error) {
+	url := redactURL(req.Context(), req.URL)
+
+	artifact := FailureArtifact{
+		Time:     rec.start.UTC(),
+		Duration: time.Since(rec.start).String(),
+		Method:   req.Method,
+		URL:      url,
+		Header:   redactHeader(req.Context(), req.Header),
+		// errors such as *url.Error embed the unredacted URL
+		Error:   strings.ReplaceAll(err.Error(), req.URL.String(), url),
+		Timings: make(map[string]string),
+	}
+
+	rec.mu.Lock()
+	for phase, d := range rec.timings {
+		artifact.Timings[phase] = d.String()
+	}
+	rec.mu.Unlock()
+
+	var exhausted *RetriesExhaustedError
+
+	if errors.As(err, &exhausted) {
+		for _, attempt := range exhausted.Attempts {
+			a := ArtifactAttempt{
+				Attempt:    attempt.Attempt,
+				StatusCode: attempt.StatusCode,
+			}
+
+			if attempt.Err != nil {
+				a.Error = attempt.Err.Error()
+			}
+
+			artifact.Attempts = append(artifact.Attempts, a)
+		}
+	}
+
+	var httpErr *HTTPError
+
+	if errors.As(err, &httpErr) {
+		body := httpErr.Body
+		truncated := httpErr.Truncated
+
+		if int64(len(body)) > c.cfg.MaxArtifactBodySize {
+			body = body[:c.cfg.MaxArtifactBodySize]
+			truncated = true
+		}
+
+		artifact.Response = &ArtifactResponse{
+			StatusCode: httpErr.StatusCode,
+			Header:     redactHeader(req.Context(), httpErr.Header),
+			Body:       string(body),
+			Truncated:  truncated,
+		}
+	}
+
+	if err := c.saveFailureArtifact(artifact); err != nil {
+		c.cfg.artifactLogger.Error(err, "unable to write failure artifact", "dir", c.cfg.FailureArtifactDir)
+	}
+}
+
+func (c *Client) saveFailureArtifact(artifact FailureArtifact) error {
+	if err := os.MkdirAll(c.cfg.FailureArtifactDir, 0o700); err != nil {
+		return fmt.Errorf("creating artifact directory: %w", err)
+	}
+
+	prefix := fmt.Sprintf("%s-%s-*.json", artifact.Time.Format("20060102T150405Z"), strings.ToLower(artifact.Method))
+
+	f, err := os.CreateTemp(c.cfg.FailureArtifactDir, prefix)
+	if err != nil {
+		return fmt.Errorf("creating artifact: %w", err)
+	}
+	defer f.Close()
+
+	enc := json.NewEncoder(f)
+	enc.SetIndent("", "  ")
+
+	if err := enc.Encode(artifact); err != nil {
+		return fmt.Errorf("encoding artifact: %w", err)
+	}
+
+	return f.Close()
+}
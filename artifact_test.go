@@ -0,0 +1,122 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithFailureArtifacts(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: "secret"})
+		w.WriteHeader(http.StatusBadGateway)
+
+		_, err := w.Write([]byte(strings.Repeat("x", 32)))
+		assert.NoError(t, err)
+	}))
+	t.Cleanup(srv.Close)
+
+	for name, tc := range map[string]struct {
+		Options          []ClientOption
+		ExpectedAttempts int
+		ExpectedResponse *ArtifactResponse
+	}{
+		"error on non-2xx": {
+			Options: []ClientOption{WithErrorOnNon2xx{}},
+			ExpectedResponse: &ArtifactResponse{
+				StatusCode: http.StatusBadGateway,
+				Body:       strings.Repeat("x", 16),
+				Truncated:  true,
+			},
+		},
+		"retries exhausted": {
+			Options: []ClientOption{
+				WithWrapper{TransportWrapper: NewRetryWrapper(
+					WithBackoffGenerator(NoBackoffGenerator()),
+					WithMaxRetries(2),
+					WithErrorOnExhaustion{},
+				)},
+			},
+			ExpectedAttempts: 3,
+		},
+	} {
+		tc := tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			dir := filepath.Join(t.TempDir(), "artifacts")
+
+			client := NewClient(append(tc.Options, WithFailureArtifacts{
+				Dir:         dir,
+				MaxBodySize: 16,
+			})...)
+
+			_, reqErr := client.Get(context.Background(), srv.URL+"/path?access_token=abc&page=2", WithRequestHeaders{
+				"Authorization": []string{"Bearer abc"},
+			})
+			require.Error(t, reqErr)
+
+			entries, err := os.ReadDir(dir)
+			require.NoError(t, err)
+			require.Len(t, entries, 1)
+
+			data, err := os.ReadFile(filepath.Join(dir, entries[0].Name()))
+			require.NoError(t, err)
+
+			assert.NotContains(t, string(data), "Bearer abc")
+			assert.NotContains(t, string(data), "access_token=abc")
+
+			var artifact FailureArtifact
+			require.NoError(t, json.Unmarshal(data, &artifact))
+
+			assert.Equal(t, http.MethodGet, artifact.Method)
+			assert.Contains(t, artifact.URL, "page=2")
+			assert.Equal(t, redacted, artifact.Header.Get("Authorization"))
+			assert.Contains(t, artifact.Error, "access_token=REDACTED")
+			assert.Contains(t, artifact.Timings, "firstResponseByte")
+			assert.Len(t, artifact.Attempts, tc.ExpectedAttempts)
+
+			if tc.ExpectedResponse == nil {
+				assert.Nil(t, artifact.Response)
+
+				return
+			}
+
+			require.NotNil(t, artifact.Response)
+			assert.Equal(t, tc.ExpectedResponse.StatusCode, artifact.Response.StatusCode)
+			assert.Equal(t, tc.ExpectedResponse.Body, artifact.Response.Body)
+			assert.Equal(t, tc.ExpectedResponse.Truncated, artifact.Response.Truncated)
+			assert.Equal(t, redacted, artifact.Response.Header.Get("Set-Cookie"))
+		})
+	}
+}
+
+func TestWithFailureArtifactsSuccess(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	t.Cleanup(srv.Close)
+
+	dir := t.TempDir()
+
+	client := NewClient(WithFailureArtifacts{Dir: dir})
+
+	res, err := client.Get(context.Background(), srv.URL)
+	require.NoError(t, err)
+	require.NoError(t, res.Body.Close())
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
@@ -1,41 +1,308 @@
 package client
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"net/http"
+	"sync"
+	"time"
 
 	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/clientcredentials"
 )
 
+const (
+	// defaultRefreshSkew matches the expiry delta of oauth2.ReuseTokenSource.
+	defaultRefreshSkew = 10 * time.Second
+	// refreshRetryDelay is the minimum delay between proactive
+	// refreshes after a proactive refresh has failed.
+	refreshRetryDelay = 5 * time.Second
+	// defaultTokenFetchTimeout bounds obtaining a token
+	// from the token source.
+	defaultTokenFetchTimeout = 30 * time.Second
+)
+
+var (
+	errNoTokenSource = errors.New("no OAUTH token source configured")
+	errNoToken       = errors.New("OAUTH token source returned no token")
+)
+
+// TokenRefreshError is returned by an OAUTHWrapper for requests
+// made while no valid token is cached and obtaining a new one
+// failed. Err is the error returned by the token source, e.g.
+// an *oauth2.RetrieveError holding the response of the token
+// endpoint.
+type TokenRefreshError struct {
+	Err error
+}
+
+func (e *TokenRefreshError) Error() string {
+	return "refreshing OAUTH token: " + e.Err.Error()
+}
+
+func (e *TokenRefreshError) Unwrap() error {
+	return e.Err
+}
+
 // NewOAUTHWrapper returns a TransportWrapper which adds
-// OAUTH2 authentication to a HTTP transport.
+// OAUTH2 authentication to a HTTP transport. Tokens are cached
+// and refreshed in the background by a single goroutine once
+// they are about to expire so that concurrent requests neither
+// wait for nor trigger simultaneous token fetches. Requests
+// only wait for a token if none is cached or it has expired.
 func NewOAUTHWrapper(opts ...OAUTHOption) *OAUTHWrapper {
 	var cfg OAUTHConfig
 
 	cfg.Option(opts...)
+	cfg.Default()
 
 	return &OAUTHWrapper{
-		transport: oauth2.Transport{
-			Source: cfg.source,
+		cache: &tokenCache{
+			src:     cfg.source,
+			skew:    cfg.RefreshSkew,
+			timeout: cfg.FetchTimeout,
+			clock:   cfg.Clock,
 		},
 	}
 }
 
 type OAUTHWrapper struct {
-	transport oauth2.Transport
+	cache *tokenCache
+	rt    http.RoundTripper
 }
 
 func (w *OAUTHWrapper) RoundTrip(req *http.Request) (*http.Response, error) {
-	return w.transport.RoundTrip(req)
+	if isCrossHostRedirect(req) {
+		return w.rt.RoundTrip(req)
+	}
+
+	token, refreshed, err := w.cache.Token(req.Context())
+	if refreshed {
+		emitEvent(req.Context(), &TokenRefreshed{Source: "oauth"})
+	}
+
+	if err != nil {
+		if req.Body != nil {
+			req.Body.Close()
+		}
+
+		return nil, err
+	}
+
+	out := req.Clone(req.Context())
+	token.SetAuthHeader(out)
+
+	res, err := w.rt.RoundTrip(out)
+	if err == nil && res.StatusCode == http.StatusUnauthorized {
+		w.cache.invalidate(token)
+	}
+
+	return res, err
 }
 
 func (w *OAUTHWrapper) Wrap(rt http.RoundTripper) http.RoundTripper {
-	w.transport.Base = rt
+	w.rt = rt
 
 	return w
 }
 
+// tokenCache caches the token of src refreshing
+// it at most once at a time.
+type tokenCache struct {
+	src     oauth2.TokenSource
+	skew    time.Duration
+	timeout time.Duration
+	clock   Clock
+
+	mu        sync.Mutex
+	token     *oauth2.Token
+	refreshAt time.Time
+	failedAt  time.Time
+	inflight  *tokenRefresh
+	// refreshes and reported count the obtained tokens and
+	// those reported as refreshed by Token respectively.
+	refreshes uint64
+	reported  uint64
+}
+
+type tokenRefresh struct {
+	done  chan struct{}
+	token *oauth2.Token
+	err   error
+}
+
+// Token returns the cached token waiting for it to be refreshed if it
+// has expired and reports whether it was obtained since the last call.
+// Tokens within the refresh skew of their expiry are returned while
+// being refreshed in the background.
+func (c *tokenCache) Token(ctx context.Context) (*oauth2.Token, bool, error) {
+	c.mu.Lock()
+
+	now := c.clock.Now()
+
+	if c.token != nil && (c.token.Expiry.IsZero() || now.Before(c.token.Expiry)) {
+		if !c.token.Expiry.IsZero() && !now.Before(c.refreshAt) &&
+			c.inflight == nil && now.Sub(c.failedAt) >= refreshRetryDelay {
+			c.refresh()
+		}
+
+		token, refreshed := c.token, c.report()
+		c.mu.Unlock()
+
+		return token, refreshed, nil
+	}
+
+	r := c.inflight
+	if r == nil {
+		r = c.refresh()
+	}
+
+	c.mu.Unlock()
+
+	select {
+	case <-r.done:
+	case <-ctx.Done():
+		return nil, false, ctx.Err()
+	}
+
+	if r.err != nil {
+		return nil, false, r.err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return r.token, c.report(), nil
+}
+
+// refresh starts obtaining a new token.
+// The caller must hold c.mu.
+func (c *tokenCache) refresh() *tokenRefresh {
+	r := &tokenRefresh{done: make(chan struct{})}
+	c.inflight = r
+
+	go func() {
+		defer close(r.done)
+
+		// fetch returns once the fetch timeout has passed
+		// so inflight is cleared even if src hangs
+		token, err := c.fetch()
+
+		c.mu.Lock()
+		defer c.mu.Unlock()
+
+		c.inflight = nil
+		c.store(r, token, err)
+	}()
+
+	return r
+}
+
+// store caches token or records err as the result of r.
+// The caller must hold c.mu.
+func (c *tokenCache) store(r *tokenRefresh, token *oauth2.Token, err error) {
+	if err == nil && token == nil {
+		err = errNoToken
+	}
+
+	if err != nil {
+		r.err = &TokenRefreshError{Err: err}
+		c.failedAt = c.clock.Now()
+
+		return
+	}
+
+	now := c.clock.Now()
+
+	r.token = token
+	c.token = token
+	// refresh at half of the lifetime of short-lived
+	// tokens rather than continuously
+	c.refreshAt = token.Expiry.Add(-min(c.skew, token.Expiry.Sub(now)/2))
+	c.failedAt = time.Time{}
+	c.refreshes++
+}
+
+// fetch obtains a token from src giving up after the fetch
+// timeout. Sources which do not accept a context are left
+// to finish in the background once the timeout has passed.
+func (c *tokenCache) fetch() (*oauth2.Token, error) {
+	if c.src == nil {
+		return nil, errNoTokenSource
+	}
+
+	timeout := c.timeout
+	if timeout <= 0 {
+		timeout = defaultTokenFetchTimeout
+	}
+
+	ctx, cancel := withClockTimeout(context.Background(), c.clock, timeout)
+	defer cancel()
+
+	if src, ok := c.src.(contextTokenSource); ok {
+		return src.TokenContext(ctx)
+	}
+
+	type result struct {
+		token *oauth2.Token
+		err   error
+	}
+
+	results := make(chan result, 1)
+
+	go func() {
+		token, err := c.src.Token()
+
+		results <- result{token: token, err: err}
+	}()
+
+	select {
+	case res := <-results:
+		return res.token, res.err
+	case <-ctx.Done():
+		return nil, fmt.Errorf("obtaining token: %w", context.Cause(ctx))
+	}
+}
+
+// invalidate discards token if it is still cached so that the
+// next request waits for a new one, e.g. after it was rejected.
+func (c *tokenCache) invalidate(token *oauth2.Token) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.token == token {
+		c.token = nil
+	}
+}
+
+// report returns whether a token was obtained since the
+// last call. The caller must hold c.mu.
+func (c *tokenCache) report() bool {
+	if c.reported == c.refreshes {
+		return false
+	}
+
+	c.reported = c.refreshes
+
+	return true
+}
+
 type OAUTHConfig struct {
+	// RefreshSkew is the time before the expiry of a token at which
+	// it is refreshed. Defaults to 10 seconds.
+	RefreshSkew time.Duration
+	// FetchTimeout bounds obtaining a token from the token
+	// source. Defaults to 30 seconds.
+	FetchTimeout time.Duration
+	// Clock provides the time at which tokens expire
+	// and are refreshed. Defaults to RealClock.
+	Clock  Clock
 	source oauth2.TokenSource
+	cache  *WithTokenCache
+	// defaultSkew overrides the default RefreshSkew
+	// for the configured source.
+	defaultSkew time.Duration
 }
 
 func (c *OAUTHConfig) Option(opts ...OAUTHOption) {
@@ -44,10 +311,49 @@ func (c *OAUTHConfig) Option(opts ...OAUTHOption) {
 	}
 }
 
+func (c *OAUTHConfig) Default() {
+	if c.RefreshSkew <= 0 {
+		c.RefreshSkew = defaultRefreshSkew
+
+		if c.defaultSkew > 0 {
+			c.RefreshSkew = c.defaultSkew
+		}
+	}
+
+	if c.Clock == nil {
+		c.Clock = RealClock{}
+	}
+
+	if src, ok := c.source.(clockedTokenSource); ok {
+		src.useClock(c.Clock)
+	}
+
+	if c.cache != nil && c.cache.Store != nil && c.source != nil {
+		c.source = newStoredTokenSource(c.source, *c.cache, c.RefreshSkew, c.Clock)
+	}
+}
+
 type OAUTHOption interface {
 	ConfigureOAUTH(*OAUTHConfig)
 }
 
+// WithRefreshSkew configures an OAUTHWrapper to refresh tokens the
+// given duration before they expire. Tokens are refreshed no earlier
+// than halfway through their lifetime. Defaults to 10 seconds.
+type WithRefreshSkew time.Duration
+
+func (s WithRefreshSkew) ConfigureOAUTH(c *OAUTHConfig) {
+	c.RefreshSkew = time.Duration(s)
+}
+
+// WithTokenFetchTimeout configures an OAUTHWrapper to give up
+// obtaining a token after the given duration. Defaults to 30 seconds.
+type WithTokenFetchTimeout time.Duration
+
+func (t WithTokenFetchTimeout) ConfigureOAUTH(c *OAUTHConfig) {
+	c.FetchTimeout = time.Duration(t)
+}
+
 // WithAccessToken configures a OAUTHWrapper with an OAUTH2 token
 // used when making requests.
 type WithAccessToken string
@@ -57,3 +363,47 @@ func (at WithAccessToken) ConfigureOAUTH(c *OAUTHConfig) {
 		AccessToken: string(at),
 	})
 }
+
+// WithClientCredentials configures a OAUTHWrapper to obtain tokens
+// from TokenURL using the OAUTH2 client credentials flow.
+type WithClientCredentials struct {
+	ClientID     string
+	ClientSecret string
+	TokenURL     string
+	Scopes       []string
+}
+
+func (cc WithClientCredentials) ConfigureOAUTH(c *OAUTHConfig) {
+	cfg := clientcredentials.Config{
+		ClientID:     cc.ClientID,
+		ClientSecret: cc.ClientSecret,
+		TokenURL:     cc.TokenURL,
+		Scopes:       cc.Scopes,
+	}
+
+	// the wrapper caches tokens itself so a source fetching
+	// a new token on every call is used
+	c.source = tokenSourceFunc(cfg.Token)
+}
+
+// clockedTokenSource is implemented by token sources which
+// depend on the time and use the Clock of the OAUTHConfig.
+type clockedTokenSource interface {
+	useClock(Clock)
+}
+
+// contextTokenSource is implemented by token sources
+// whose fetches can be cancelled.
+type contextTokenSource interface {
+	TokenContext(ctx context.Context) (*oauth2.Token, error)
+}
+
+type tokenSourceFunc func(context.Context) (*oauth2.Token, error)
+
+func (f tokenSourceFunc) Token() (*oauth2.Token, error) {
+	return f(context.Background())
+}
+
+func (f tokenSourceFunc) TokenContext(ctx context.Context) (*oauth2.Token, error) {
+	return f(ctx)
+}
@@ -0,0 +1,118 @@
+package client_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mt-sre/client"
+	"github.com/mt-sre/client/clienttest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+)
+
+// clockTokenSource issues tokens numbered by the call which
+// obtained them expiring after ttl on clock. Calls block
+// until release is closed if set.
+type clockTokenSource struct {
+	clock   client.Clock
+	ttl     time.Duration
+	release chan struct{}
+	calls   atomic.Int32
+}
+
+func (s *clockTokenSource) Token() (*oauth2.Token, error) {
+	n := s.calls.Add(1)
+
+	if s.release != nil {
+		<-s.release
+	}
+
+	return &oauth2.Token{
+		AccessToken: fmt.Sprintf("token-%d", n),
+		Expiry:      s.clock.Now().Add(s.ttl),
+	}, nil
+}
+
+// TestOAUTHWrapperClock ensures that tokens expire
+// according to the configured clock.
+func TestOAUTHWrapperClock(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, r.Header.Get("Authorization"))
+	}))
+	defer srv.Close()
+
+	clock := clienttest.NewFakeClock(time.Date(2026, time.October, 16, 8, 0, 0, 0, time.UTC))
+	src := &clockTokenSource{clock: clock, ttl: time.Hour}
+
+	c := client.NewClient(client.WithWrapper{TransportWrapper: client.NewOAUTHWrapper(
+		client.WithTokenSource{TokenSource: src},
+		client.WithClock{Clock: clock},
+	)})
+	defer c.Close()
+
+	get := func() string {
+		t.Helper()
+
+		res, err := c.Get(context.Background(), srv.URL)
+		require.NoError(t, err)
+		defer res.Body.Close()
+
+		body, err := io.ReadAll(res.Body)
+		require.NoError(t, err)
+
+		return string(body)
+	}
+
+	assert.Equal(t, "Bearer token-1", get())
+
+	clock.Advance(30 * time.Minute)
+	assert.Equal(t, "Bearer token-1", get(), "the token must be cached until it is about to expire")
+
+	clock.Advance(31 * time.Minute)
+	assert.Equal(t, "Bearer token-2", get(), "an expired token must be replaced")
+	assert.EqualValues(t, 2, src.calls.Load())
+}
+
+// TestOAUTHWrapperClockFetchTimeout ensures that token
+// fetches time out according to the configured clock.
+func TestOAUTHWrapperClockFetchTimeout(t *testing.T) {
+	t.Parallel()
+
+	clock := clienttest.NewFakeClock(time.Date(2026, time.October, 16, 8, 0, 0, 0, time.UTC))
+	src := &clockTokenSource{clock: clock, ttl: time.Hour, release: make(chan struct{})}
+	defer close(src.release)
+
+	c := client.NewClient(client.WithWrapper{TransportWrapper: client.NewOAUTHWrapper(
+		client.WithTokenSource{TokenSource: src},
+		client.WithClock{Clock: clock},
+		client.WithTokenFetchTimeout(time.Minute),
+	)})
+	defer c.Close()
+
+	errs := make(chan error)
+
+	go func() {
+		_, err := c.Get(context.Background(), "http://127.0.0.1")
+
+		errs <- err
+	}()
+
+	clock.BlockUntil(1)
+	clock.Advance(time.Minute)
+
+	err := <-errs
+
+	var refreshErr *client.TokenRefreshError
+
+	require.ErrorAs(t, err, &refreshErr)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
@@ -0,0 +1,243 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+)
+
+func TestWithClientCredentials(t *testing.T) {
+	t.Parallel()
+
+	var issued atomic.Int32
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			id, secret, _ := r.BasicAuth()
+
+			if id != "id" || secret != "secret" || r.FormValue("grant_type") != "client_credentials" {
+				w.WriteHeader(http.StatusUnauthorized)
+
+				return
+			}
+
+			issued.Add(1)
+
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = io.WriteString(w, `{"access_token":"token","token_type":"Bearer","expires_in":3600}`)
+
+			return
+		}
+
+		_, _ = io.WriteString(w, r.Header.Get("Authorization"))
+	}))
+	defer srv.Close()
+
+	c := NewClient(WithWrapper{TransportWrapper: NewOAUTHWrapper(WithClientCredentials{
+		ClientID:     "id",
+		ClientSecret: "secret",
+		TokenURL:     srv.URL + "/token",
+	})})
+	defer c.Close()
+
+	for range 2 {
+		res, err := c.Get(context.Background(), srv.URL+"/resource")
+		require.NoError(t, err)
+
+		body, err := io.ReadAll(res.Body)
+		require.NoError(t, err)
+		require.NoError(t, res.Body.Close())
+
+		assert.Equal(t, "Bearer token", string(body))
+	}
+
+	assert.Equal(t, int32(1), issued.Load())
+}
+
+// funcClock is a Clock whose current time is returned by now.
+type funcClock struct {
+	RealClock
+	now func() time.Time
+}
+
+func (c funcClock) Now() time.Time { return c.now() }
+
+// countingTokenSource issues tokens expiring after
+// ttl numbered by the call which obtained them.
+type countingTokenSource struct {
+	calls atomic.Int32
+	ttl   time.Duration
+	delay time.Duration
+	err   error
+}
+
+func (s *countingTokenSource) Token() (*oauth2.Token, error) {
+	n := s.calls.Add(1)
+
+	time.Sleep(s.delay)
+
+	if s.err != nil {
+		return nil, s.err
+	}
+
+	return &oauth2.Token{
+		AccessToken: fmt.Sprintf("token-%d", n),
+		Expiry:      time.Now().Add(s.ttl),
+	}, nil
+}
+
+func TestTokenCacheConcurrentRefresh(t *testing.T) {
+	t.Parallel()
+
+	src := &countingTokenSource{ttl: time.Hour, delay: 50 * time.Millisecond}
+	cache := &tokenCache{src: src, skew: defaultRefreshSkew, clock: RealClock{}}
+
+	var (
+		wg        sync.WaitGroup
+		refreshed atomic.Int32
+	)
+
+	for range 20 {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			token, ok, err := cache.Token(context.Background())
+			assert.NoError(t, err)
+			assert.Equal(t, "token-1", token.AccessToken)
+
+			if ok {
+				refreshed.Add(1)
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	assert.EqualValues(t, 1, src.calls.Load(), "concurrent requests must share a single refresh")
+	assert.EqualValues(t, 1, refreshed.Load(), "a refresh must be reported once")
+}
+
+func TestTokenCacheProactiveRefresh(t *testing.T) {
+	t.Parallel()
+
+	var offset atomic.Int64
+
+	src := &countingTokenSource{ttl: time.Minute}
+	cache := &tokenCache{
+		src:   src,
+		skew:  10 * time.Second,
+		clock: funcClock{now: func() time.Time { return time.Now().Add(time.Duration(offset.Load())) }},
+	}
+
+	token, _, err := cache.Token(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, "token-1", token.AccessToken)
+
+	offset.Store(int64(55 * time.Second))
+
+	token, _, err = cache.Token(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "token-1", token.AccessToken, "the cached token must be used while refreshing")
+
+	require.Eventually(t, func() bool {
+		token, _, err := cache.Token(context.Background())
+
+		return err == nil && token.AccessToken == "token-2"
+	}, 5*time.Second, time.Millisecond)
+
+	assert.EqualValues(t, 2, src.calls.Load())
+}
+
+func TestOAUTHWrapperRefreshError(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	c := NewClient(WithWrapper{TransportWrapper: NewOAUTHWrapper(WithClientCredentials{
+		ClientID:     "id",
+		ClientSecret: "secret",
+		TokenURL:     srv.URL + "/token",
+	}, WithRefreshSkew(time.Minute))})
+	defer c.Close()
+
+	_, err := c.Get(context.Background(), srv.URL+"/resource")
+
+	var (
+		refreshErr  *TokenRefreshError
+		retrieveErr *oauth2.RetrieveError
+	)
+
+	require.True(t, errors.As(err, &refreshErr))
+	require.True(t, errors.As(err, &retrieveErr))
+	assert.Equal(t, http.StatusServiceUnavailable, retrieveErr.Response.StatusCode)
+}
+
+func TestTokenCacheFetchTimeout(t *testing.T) {
+	t.Parallel()
+
+	src := &countingTokenSource{ttl: time.Hour, delay: time.Second}
+	cache := &tokenCache{
+		src:     src,
+		skew:    defaultRefreshSkew,
+		timeout: 10 * time.Millisecond,
+		clock:   RealClock{},
+	}
+
+	for range 2 {
+		_, _, err := cache.Token(context.Background())
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+	}
+
+	assert.EqualValues(t, 2, src.calls.Load(), "a timed out refresh must not block later ones")
+}
+
+func TestOAUTHWrapperInvalidatesRejectedToken(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") == "Bearer token-1" {
+			w.WriteHeader(http.StatusUnauthorized)
+
+			return
+		}
+
+		fmt.Fprint(w, r.Header.Get("Authorization"))
+	}))
+	defer srv.Close()
+
+	src := &countingTokenSource{ttl: time.Hour}
+
+	c := NewClient(WithWrapper{TransportWrapper: NewOAUTHWrapper(WithTokenSource{TokenSource: src})})
+	defer c.Close()
+
+	res, err := c.Get(context.Background(), srv.URL)
+	require.NoError(t, err)
+	require.NoError(t, res.Body.Close())
+	assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+
+	res, err = c.Get(context.Background(), srv.URL)
+	require.NoError(t, err)
+
+	body, err := io.ReadAll(res.Body)
+	require.NoError(t, err)
+	require.NoError(t, res.Body.Close())
+
+	assert.Equal(t, "Bearer token-2", string(body))
+	assert.EqualValues(t, 2, src.calls.Load())
+}
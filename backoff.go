@@ -1,6 +1,8 @@
 package client
 
 import (
+	"context"
+	"math/rand/v2"
 	"time"
 
 	"github.com/cenkalti/backoff/v4"
@@ -37,6 +39,13 @@ func (w WithInitialInterval) ConfigureExponentialBackoff(bo *backoff.Exponential
 	bo.InitialInterval = time.Duration(w)
 }
 
+// WithMaxInterval caps the wait time between successive request attempts.
+type WithMaxInterval time.Duration
+
+func (w WithMaxInterval) ConfigureExponentialBackoff(bo *backoff.ExponentialBackOff) {
+	bo.MaxInterval = time.Duration(w)
+}
+
 // WithMaxElapsedTime sets the maximum cumulative time after which retries are no longer
 // performed.
 type WithMaxElapsedTime time.Duration
@@ -73,3 +82,214 @@ func NoBackoffGenerator() func() backoff.BackOff {
 		return &backoff.ZeroBackOff{}
 	}
 }
+
+// FullJitterBackoffGenerator returns a backoff which waits a random
+// duration between zero and an exponentially growing ceiling, starting
+// at base and capped at max, as described in "Exponential Backoff And
+// Jitter" from the AWS Architecture Blog. Spreading waits over the
+// full range keeps clients which failed together from retrying together.
+func FullJitterBackoffGenerator(base, max time.Duration) func() backoff.BackOff {
+	return func() backoff.BackOff {
+		return &fullJitterBackOff{base: base, max: max}
+	}
+}
+
+type fullJitterBackOff struct {
+	base    time.Duration
+	max     time.Duration
+	ceiling time.Duration
+}
+
+func (b *fullJitterBackOff) NextBackOff() time.Duration {
+	if b.ceiling == 0 {
+		b.ceiling = min(b.base, b.max)
+	}
+
+	wait := randomDuration(0, b.ceiling)
+
+	if b.ceiling > b.max/2 {
+		b.ceiling = b.max
+	} else {
+		b.ceiling *= 2
+	}
+
+	return wait
+}
+
+func (b *fullJitterBackOff) Reset() {
+	b.ceiling = 0
+}
+
+// DecorrelatedJitterBackoffGenerator returns a backoff which waits a
+// random duration between base and three times its previous wait,
+// capped at max, as described in "Exponential Backoff And Jitter" from
+// the AWS Architecture Blog.
+func DecorrelatedJitterBackoffGenerator(base, max time.Duration) func() backoff.BackOff {
+	return func() backoff.BackOff {
+		return &decorrelatedJitterBackOff{base: base, max: max, prev: base}
+	}
+}
+
+type decorrelatedJitterBackOff struct {
+	base time.Duration
+	max  time.Duration
+	prev time.Duration
+}
+
+func (b *decorrelatedJitterBackOff) NextBackOff() time.Duration {
+	upper := b.prev * 3
+	if upper < b.prev || upper > b.max {
+		upper = b.max
+	}
+
+	b.prev = randomDuration(b.base, upper)
+
+	return b.prev
+}
+
+func (b *decorrelatedJitterBackOff) Reset() {
+	b.prev = b.base
+}
+
+// LinearBackoffGenerator returns a backoff whose wait grows by step
+// after every attempt, starting at step and capped at max.
+func LinearBackoffGenerator(step, max time.Duration) func() backoff.BackOff {
+	return func() backoff.BackOff {
+		return &linearBackOff{step: step, max: max}
+	}
+}
+
+type linearBackOff struct {
+	step time.Duration
+	max  time.Duration
+	wait time.Duration
+}
+
+func (b *linearBackOff) NextBackOff() time.Duration {
+	if b.wait > b.max-b.step {
+		b.wait = b.max
+	} else {
+		b.wait += b.step
+	}
+
+	return b.wait
+}
+
+func (b *linearBackOff) Reset() {
+	b.wait = 0
+}
+
+// FibonacciBackoffGenerator returns a backoff whose waits follow the
+// Fibonacci sequence in multiples of base, i.e. base, base, 2*base,
+// 3*base, 5*base and so on, capped at max. The waits grow more slowly
+// than with exponential backoff.
+func FibonacciBackoffGenerator(base, max time.Duration) func() backoff.BackOff {
+	return func() backoff.BackOff {
+		bo := &fibonacciBackOff{base: base, max: max}
+		bo.Reset()
+
+		return bo
+	}
+}
+
+type fibonacciBackOff struct {
+	base time.Duration
+	max  time.Duration
+	prev time.Duration
+	next time.Duration
+}
+
+func (b *fibonacciBackOff) NextBackOff() time.Duration {
+	wait := min(b.next, b.max)
+
+	if b.next > b.max-b.prev {
+		b.prev, b.next = b.max, b.max
+	} else {
+		b.prev, b.next = b.next, b.prev+b.next
+	}
+
+	return wait
+}
+
+func (b *fibonacciBackOff) Reset() {
+	b.prev, b.next = 0, b.base
+}
+
+// DeadlineAwareBackoffGenerator wraps the backoffs returned by generate
+// so that no wait extends past the deadline of the request context less
+// reserve, the time set aside for the final attempt. Waits which would
+// are shortened and retries stop once less than reserve remains, so that
+// the last response is returned rather than the request being cut off
+// while waiting. Requests without a deadline are unaffected.
+func DeadlineAwareBackoffGenerator(generate func() backoff.BackOff, reserve time.Duration) func() backoff.BackOff {
+	return func() backoff.BackOff {
+		return &deadlineBackOff{
+			BackOff: generate(),
+			reserve: reserve,
+		}
+	}
+}
+
+type deadlineBackOff struct {
+	backoff.BackOff
+	reserve  time.Duration
+	deadline time.Time
+	clock    Clock
+}
+
+func (b *deadlineBackOff) NextBackOff() time.Duration {
+	next := b.BackOff.NextBackOff()
+	if next == backoff.Stop || b.deadline.IsZero() {
+		return next
+	}
+
+	remaining := b.deadline.Sub(b.clock.Now()) - b.reserve
+	if remaining <= 0 {
+		return backoff.Stop
+	}
+
+	return min(next, remaining)
+}
+
+func (b *deadlineBackOff) bind(ctx context.Context, clock Clock) {
+	b.deadline, _ = ctx.Deadline()
+	b.clock = clock
+
+	bindBackOff(ctx, clock, b.BackOff)
+}
+
+// backOffBinder is implemented by backoffs which
+// depend on the request context or the clock.
+type backOffBinder interface {
+	bind(ctx context.Context, clock Clock)
+}
+
+// generateBackOff returns a backoff from generate
+// bound to ctx and clock if the backoff depends on them.
+func generateBackOff(ctx context.Context, clock Clock, generate func() backoff.BackOff) backoff.BackOff {
+	bo := generate()
+
+	bindBackOff(ctx, clock, bo)
+
+	return bo
+}
+
+func bindBackOff(ctx context.Context, clock Clock, bo backoff.BackOff) {
+	switch bo := bo.(type) {
+	case backOffBinder:
+		bo.bind(ctx, clock)
+	case *backoff.ExponentialBackOff:
+		// the elapsed time limit is measured from the last reset
+		bo.Clock = clock
+		bo.Reset()
+	}
+}
+
+// randomDuration returns a random duration in [lo, hi].
+func randomDuration(lo, hi time.Duration) time.Duration {
+	if hi <= lo {
+		return hi
+	}
+
+	return lo + rand.N(hi-lo+1)
+}
@@ -1,7 +1,9 @@
 package client
 
 import (
+	"context"
 	"testing"
+	"time"
 
 	"github.com/cenkalti/backoff/v4"
 	"github.com/stretchr/testify/assert"
@@ -33,3 +35,127 @@ func TestWithMultiplierConfigureExponentialBackoff(t *testing.T) {
 
 	assert.Equal(t, 2.0, bo.Multiplier, "Multiplier not set properly")
 }
+
+// TestFullJitterBackoffGenerator ensures that waits lie between zero
+// and a ceiling which doubles from base up to max.
+func TestFullJitterBackoffGenerator(t *testing.T) {
+	t.Parallel()
+
+	bo := FullJitterBackoffGenerator(100*time.Millisecond, time.Second)()
+
+	for range 100 {
+		ceilings := []time.Duration{100, 200, 400, 800, 1000, 1000}
+
+		for _, ceiling := range ceilings {
+			wait := bo.NextBackOff()
+
+			assert.GreaterOrEqual(t, wait, time.Duration(0))
+			assert.LessOrEqual(t, wait, ceiling*time.Millisecond)
+		}
+
+		bo.Reset()
+	}
+}
+
+// TestDecorrelatedJitterBackoffGenerator ensures that waits lie
+// between base and three times the previous wait capped at max.
+func TestDecorrelatedJitterBackoffGenerator(t *testing.T) {
+	t.Parallel()
+
+	const (
+		base = 100 * time.Millisecond
+		max  = time.Second
+	)
+
+	bo := DecorrelatedJitterBackoffGenerator(base, max)()
+
+	prev := base
+
+	for range 1000 {
+		wait := bo.NextBackOff()
+
+		assert.GreaterOrEqual(t, wait, base)
+		assert.LessOrEqual(t, wait, min(3*prev, max))
+
+		prev = wait
+	}
+
+	bo.Reset()
+	assert.LessOrEqual(t, bo.NextBackOff(), 3*base)
+}
+
+// TestLinearBackoffGenerator ensures that waits grow
+// by step up to max and start over once reset.
+func TestLinearBackoffGenerator(t *testing.T) {
+	t.Parallel()
+
+	bo := LinearBackoffGenerator(time.Second, 3500*time.Millisecond)()
+
+	var waits []time.Duration
+	for range 5 {
+		waits = append(waits, bo.NextBackOff())
+	}
+
+	assert.Equal(t, []time.Duration{
+		time.Second, 2 * time.Second, 3 * time.Second, 3500 * time.Millisecond, 3500 * time.Millisecond,
+	}, waits)
+
+	bo.Reset()
+	assert.Equal(t, time.Second, bo.NextBackOff())
+}
+
+// TestFibonacciBackoffGenerator ensures that waits follow the
+// Fibonacci sequence up to max and start over once reset.
+func TestFibonacciBackoffGenerator(t *testing.T) {
+	t.Parallel()
+
+	bo := FibonacciBackoffGenerator(time.Second, 10*time.Second)()
+
+	var waits []time.Duration
+	for range 8 {
+		waits = append(waits, bo.NextBackOff()/time.Second)
+	}
+
+	assert.Equal(t, []time.Duration{1, 1, 2, 3, 5, 8, 10, 10}, waits)
+
+	bo.Reset()
+	assert.Equal(t, time.Second, bo.NextBackOff())
+}
+
+// TestDeadlineAwareBackoffGenerator ensures that waits are shortened
+// to fit the context deadline less the reserve and that retries stop
+// once only the reserve remains.
+func TestDeadlineAwareBackoffGenerator(t *testing.T) {
+	t.Parallel()
+
+	generate := DeadlineAwareBackoffGenerator(
+		LinearBackoffGenerator(time.Hour, time.Hour), 10*time.Second,
+	)
+
+	t.Run("no deadline", func(t *testing.T) {
+		t.Parallel()
+
+		bo := generateBackOff(context.Background(), RealClock{}, generate)
+		assert.Equal(t, time.Hour, bo.NextBackOff())
+	})
+
+	t.Run("shortened", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+
+		next := generateBackOff(ctx, RealClock{}, generate).NextBackOff()
+		assert.Greater(t, next, 40*time.Second)
+		assert.LessOrEqual(t, next, 50*time.Second)
+	})
+
+	t.Run("only reserve remains", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		assert.Equal(t, backoff.Stop, generateBackOff(ctx, RealClock{}, generate).NextBackOff())
+	})
+}
@@ -0,0 +1,249 @@
+package client
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+var errNoEndpoints = errors.New("no endpoints configured")
+
+// BalancingStrategy selects the endpoint used for a request.
+type BalancingStrategy int
+
+const (
+	// WeightedRoundRobin distributes requests across endpoints in
+	// proportion to their weights.
+	WeightedRoundRobin BalancingStrategy = iota
+	// LeastPending sends requests to the endpoint with the fewest
+	// in-flight requests relative to its weight.
+	LeastPending
+)
+
+// NewLoadBalancerWrapper returns a TransportWrapper which distributes
+// requests across equivalent replicas of an API. The scheme and host
+// of each request are replaced with those of the selected endpoint and
+// the endpoint's path, if any, is prepended to the request path. When
+// combined with a RetryWrapper, the RetryWrapper should be applied
+// after the LoadBalancerWrapper so that every attempt selects an
+// endpoint anew.
+func NewLoadBalancerWrapper(opts ...LoadBalancerOption) *LoadBalancerWrapper {
+	var cfg LoadBalancerConfig
+
+	cfg.Option(opts...)
+
+	endpoints := make([]*endpoint, 0, len(cfg.Endpoints))
+
+	for _, ep := range cfg.Endpoints {
+		weight := ep.Weight
+		if weight <= 0 {
+			weight = 1
+		}
+
+		endpoints = append(endpoints, &endpoint{
+			url:    ep.URL,
+			weight: weight,
+		})
+	}
+
+	return &LoadBalancerWrapper{
+		cfg:       cfg,
+		endpoints: endpoints,
+	}
+}
+
+type LoadBalancerWrapper struct {
+	cfg       LoadBalancerConfig
+	rt        http.RoundTripper
+	endpoints []*endpoint
+
+	mu   sync.Mutex
+	next int
+}
+
+type endpoint struct {
+	url    *url.URL
+	weight int
+
+	// current is the running weight used by the smooth
+	// weighted round-robin algorithm. Guarded by the
+	// LoadBalancerWrapper's mutex.
+	current int
+
+	pending  atomic.Int64
+	requests atomic.Uint64
+	failures atomic.Uint64
+}
+
+func (w *LoadBalancerWrapper) Wrap(rt http.RoundTripper) http.RoundTripper {
+	w.rt = rt
+
+	return w
+}
+
+func (w *LoadBalancerWrapper) RoundTrip(req *http.Request) (*http.Response, error) {
+	ep := w.pick()
+	if ep == nil {
+		return nil, errNoEndpoints
+	}
+
+	ep.pending.Add(1)
+	defer ep.pending.Add(-1)
+
+	ep.requests.Add(1)
+
+	res, err := w.rt.RoundTrip(rewriteRequest(req, ep.url))
+	if err != nil || res.StatusCode >= http.StatusInternalServerError {
+		ep.failures.Add(1)
+	}
+
+	return res, err
+}
+
+func (w *LoadBalancerWrapper) pick() *endpoint {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if len(w.endpoints) == 0 {
+		return nil
+	}
+
+	switch w.cfg.Strategy {
+	case LeastPending:
+		return w.pickLeastPending()
+	default:
+		return w.pickWeightedRoundRobin()
+	}
+}
+
+// pickWeightedRoundRobin implements the smooth weighted round-robin
+// algorithm which avoids sending bursts to heavily weighted endpoints.
+func (w *LoadBalancerWrapper) pickWeightedRoundRobin() *endpoint {
+	var (
+		best  *endpoint
+		total int
+	)
+
+	for _, ep := range w.endpoints {
+		ep.current += ep.weight
+		total += ep.weight
+
+		if best == nil || ep.current > best.current {
+			best = ep
+		}
+	}
+
+	best.current -= total
+
+	return best
+}
+
+func (w *LoadBalancerWrapper) pickLeastPending() *endpoint {
+	var best *endpoint
+
+	// start at a rotating offset so that ties are spread evenly
+	for i := range w.endpoints {
+		ep := w.endpoints[(w.next+i)%len(w.endpoints)]
+
+		if best == nil || ep.pending.Load()*int64(best.weight) < best.pending.Load()*int64(ep.weight) {
+			best = ep
+		}
+	}
+
+	w.next = (w.next + 1) % len(w.endpoints)
+
+	return best
+}
+
+// EndpointStats is a point in time snapshot of the
+// statistics of a single endpoint.
+type EndpointStats struct {
+	URL    string
+	Weight int
+	// Pending is the number of requests in flight.
+	Pending int64
+	// Requests is the total number of requests sent.
+	Requests uint64
+	// Failures is the number of requests which failed
+	// or received a 5xx response.
+	Failures uint64
+}
+
+// Stats returns a snapshot of the statistics of all endpoints
+// in the order they were configured.
+func (w *LoadBalancerWrapper) Stats() []EndpointStats {
+	stats := make([]EndpointStats, 0, len(w.endpoints))
+
+	for _, ep := range w.endpoints {
+		stats = append(stats, EndpointStats{
+			URL:      ep.url.String(),
+			Weight:   ep.weight,
+			Pending:  ep.pending.Load(),
+			Requests: ep.requests.Load(),
+			Failures: ep.failures.Load(),
+		})
+	}
+
+	return stats
+}
+
+// rewriteRequest returns a shallow copy of req directed at base.
+func rewriteRequest(req *http.Request, base *url.URL) *http.Request {
+	out := req.Clone(req.Context())
+
+	out.URL.Scheme = base.Scheme
+	out.URL.Host = base.Host
+	out.Host = ""
+
+	if prefix := strings.TrimSuffix(base.Path, "/"); prefix != "" {
+		out.URL.Path = prefix + "/" + strings.TrimPrefix(req.URL.Path, "/")
+
+		if req.URL.RawPath != "" {
+			out.URL.RawPath = strings.TrimSuffix(base.EscapedPath(), "/") + "/" + strings.TrimPrefix(req.URL.RawPath, "/")
+		}
+	}
+
+	return out
+}
+
+type LoadBalancerConfig struct {
+	Endpoints []Endpoint
+	Strategy  BalancingStrategy
+}
+
+func (c *LoadBalancerConfig) Option(opts ...LoadBalancerOption) {
+	for _, opt := range opts {
+		opt.ConfigureLoadBalancer(c)
+	}
+}
+
+type LoadBalancerOption interface {
+	ConfigureLoadBalancer(*LoadBalancerConfig)
+}
+
+// Endpoint is a replica of an API identified by its base URL.
+type Endpoint struct {
+	URL *url.URL
+	// Weight determines the share of requests sent to the
+	// endpoint relative to other endpoints. Defaults to 1.
+	Weight int
+}
+
+// WithEndpoints adds the given endpoints to a LoadBalancerWrapper.
+// This option can be provided multiple times.
+type WithEndpoints []Endpoint
+
+func (e WithEndpoints) ConfigureLoadBalancer(c *LoadBalancerConfig) {
+	c.Endpoints = append(c.Endpoints, e...)
+}
+
+// WithBalancingStrategy configures the strategy a LoadBalancerWrapper
+// uses to select endpoints. Defaults to WeightedRoundRobin.
+type WithBalancingStrategy BalancingStrategy
+
+func (s WithBalancingStrategy) ConfigureLoadBalancer(c *LoadBalancerConfig) {
+	c.Strategy = BalancingStrategy(s)
+}
@@ -0,0 +1,178 @@
+package client
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/mt-sre/client/internal/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadBalancerWrapperInterfaces(t *testing.T) {
+	t.Parallel()
+
+	require.Implements(t, new(http.RoundTripper), new(LoadBalancerWrapper))
+
+	require.Implements(t, new(TransportWrapper), new(LoadBalancerWrapper))
+}
+
+func TestLoadBalancerWrapperWeightedRoundRobin(t *testing.T) {
+	t.Parallel()
+
+	hosts := recordHosts(t, 6, http.StatusOK)
+
+	lb := NewLoadBalancerWrapper(
+		WithEndpoints{
+			{URL: mustParseURL(t, "https://a.example.com"), Weight: 2},
+			{URL: mustParseURL(t, "https://b.example.com")},
+		},
+	)
+
+	rt := lb.Wrap(hosts.rt)
+
+	for i := 0; i < 6; i++ {
+		req := testutils.MockRequest(t, http.MethodGet, nil)
+		req.URL = mustParseURL(t, "http://original/path")
+
+		res, err := rt.RoundTrip(req)
+		require.NoError(t, err)
+		res.Body.Close()
+	}
+
+	assert.Equal(t, []string{
+		"a.example.com",
+		"b.example.com",
+		"a.example.com",
+		"a.example.com",
+		"b.example.com",
+		"a.example.com",
+	}, *hosts.seen)
+
+	stats := lb.Stats()
+	require.Len(t, stats, 2)
+
+	assert.Equal(t, uint64(4), stats[0].Requests)
+	assert.Equal(t, uint64(2), stats[1].Requests)
+	assert.Equal(t, int64(0), stats[0].Pending)
+}
+
+func TestLoadBalancerWrapperLeastPending(t *testing.T) {
+	t.Parallel()
+
+	lb := NewLoadBalancerWrapper(
+		WithEndpoints{
+			{URL: mustParseURL(t, "https://a.example.com")},
+			{URL: mustParseURL(t, "https://b.example.com")},
+			{URL: mustParseURL(t, "https://c.example.com"), Weight: 2},
+		},
+		WithBalancingStrategy(LeastPending),
+	)
+
+	lb.endpoints[0].pending.Store(1)
+	lb.endpoints[1].pending.Store(3)
+	lb.endpoints[2].pending.Store(3)
+
+	assert.Equal(t, "a.example.com", lb.pick().url.Host)
+
+	lb.endpoints[0].pending.Store(2)
+
+	// c has the same relative load as a but ties rotate
+	assert.Equal(t, "c.example.com", lb.pick().url.Host)
+}
+
+func TestLoadBalancerWrapperFailures(t *testing.T) {
+	t.Parallel()
+
+	hosts := recordHosts(t, 1, http.StatusServiceUnavailable)
+
+	lb := NewLoadBalancerWrapper(
+		WithEndpoints{{URL: mustParseURL(t, "https://a.example.com")}},
+	)
+
+	res, err := lb.Wrap(hosts.rt).RoundTrip(testutils.MockRequest(t, http.MethodGet, nil))
+	require.NoError(t, err)
+	res.Body.Close()
+
+	assert.Equal(t, uint64(1), lb.Stats()[0].Failures)
+}
+
+func TestLoadBalancerWrapperNoEndpoints(t *testing.T) {
+	t.Parallel()
+
+	_, err := NewLoadBalancerWrapper().Wrap(http.DefaultTransport).RoundTrip(testutils.MockRequest(t, http.MethodGet, nil))
+	require.Error(t, err)
+}
+
+func TestRewriteRequest(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		Base     string
+		Request  string
+		Expected string
+	}{
+		"host only":        {Base: "https://a.example.com", Request: "http://orig/v1/items?x=1", Expected: "https://a.example.com/v1/items?x=1"},
+		"path prefix":      {Base: "https://a.example.com/api/", Request: "http://orig/v1/items", Expected: "https://a.example.com/api/v1/items"},
+		"escaped path":     {Base: "https://a.example.com/api", Request: "http://orig/a%2Fb", Expected: "https://a.example.com/api/a%2Fb"},
+		"relative request": {Base: "https://a.example.com/api", Request: "/v1", Expected: "https://a.example.com/api/v1"},
+	} {
+		tc := tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			req := testutils.MockRequest(t, http.MethodGet, nil)
+			req.URL = mustParseURL(t, tc.Request)
+			req.Host = req.URL.Host
+
+			out := rewriteRequest(req, mustParseURL(t, tc.Base))
+
+			assert.Equal(t, tc.Expected, out.URL.String())
+			assert.Empty(t, out.Host)
+			assert.Equal(t, tc.Request, req.URL.String(), "original request should be unchanged")
+		})
+	}
+}
+
+type hostRecorder struct {
+	rt   *testutils.MockRoundTripper
+	seen *[]string
+}
+
+func recordHosts(t *testing.T, calls int, code int) hostRecorder {
+	t.Helper()
+
+	var (
+		mrt  testutils.MockRoundTripper
+		seen []string
+	)
+
+	mrt.
+		On("RoundTrip", mock.Anything).
+		Run(func(args mock.Arguments) {
+			seen = append(seen, args.Get(0).(*http.Request).URL.Host)
+		}).
+		Return(&http.Response{
+			StatusCode: code,
+			Body:       io.NopCloser(bytes.NewBuffer(nil)),
+		}, nil).
+		Times(calls)
+
+	t.Cleanup(func() { mrt.AssertExpectations(t) })
+
+	return hostRecorder{rt: &mrt, seen: &seen}
+}
+
+func mustParseURL(t *testing.T, raw string) *url.URL {
+	t.Helper()
+
+	u, err := url.Parse(raw)
+	require.NoError(t, err)
+
+	return u
+}
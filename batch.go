@@ -0,0 +1,287 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+)
+
+// ErrBatchAborted is reported for requests of a fail-fast
+// batch which were abandoned after another request failed.
+var ErrBatchAborted = errors.New("batch aborted")
+
+// BatchRequest describes a single request performed by Batch.
+type BatchRequest struct {
+	Method string
+	URL    string
+	// Header is added to the request's headers. Requests with
+	// non-idempotent methods are only retried following retryable
+	// statuses if Header carries an Idempotency-Key.
+	Header http.Header
+	// Body is sent with every attempt of the request.
+	Body []byte
+	// Options are applied to every attempt of the request.
+	Options []RequestOption
+}
+
+// BatchResult holds the outcome of a single request performed by
+// Batch. Responses outside of the 2xx range are reported as
+// *HTTPError through Err.
+type BatchResult struct {
+	StatusCode int
+	Header     http.Header
+	// Body is the complete response body.
+	Body []byte
+	// Attempts is the number of times the request was sent.
+	Attempts int
+	Err      error
+}
+
+// Batch performs the given requests concurrently using a bounded pool
+// of workers and returns their results in the order of requests.
+// Requests failing with transport errors or retryable statuses are
+// retried individually using the configured backoff. Response bodies
+// are read completely so that results remain valid once Batch returns.
+// By default every request is attempted and the returned error joins
+// the errors of all failed requests. Fail-fast batches instead abandon
+// outstanding requests after the first failure, reporting them with
+// ErrBatchAborted, and return only that failure.
+func (c *Client) Batch(ctx context.Context, requests []BatchRequest, opts ...BatchOption) ([]BatchResult, error) {
+	var cfg BatchConfig
+
+	cfg.Option(opts...)
+	cfg.Default()
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	var (
+		results  = make([]BatchResult, len(requests))
+		indices  = make(chan int)
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+	)
+
+	for range min(cfg.Concurrency, len(requests)) {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for i := range indices {
+				if ctx.Err() != nil {
+					results[i].Err = context.Cause(ctx)
+
+					continue
+				}
+
+				results[i] = c.batchOne(ctx, requests[i], cfg)
+
+				if err := results[i].Err; err != nil && cfg.FailFast {
+					mu.Lock()
+					if firstErr == nil {
+						firstErr = fmt.Errorf("request %d: %w", i, err)
+					}
+					mu.Unlock()
+
+					cancel(ErrBatchAborted)
+				}
+			}
+		}()
+	}
+
+feed:
+	for i := range requests {
+		select {
+		case indices <- i:
+		case <-ctx.Done():
+			for j := i; j < len(requests); j++ {
+				results[j].Err = context.Cause(ctx)
+			}
+
+			break feed
+		}
+	}
+
+	close(indices)
+	wg.Wait()
+
+	if firstErr != nil {
+		return results, firstErr
+	}
+
+	var errs []error
+
+	for i, res := range results {
+		if res.Err != nil {
+			errs = append(errs, fmt.Errorf("request %d: %w", i, res.Err))
+		}
+	}
+
+	return results, errors.Join(errs...)
+}
+
+// batchOne performs breq retrying failed attempts
+// until the backoff gives up.
+func (c *Client) batchOne(ctx context.Context, breq BatchRequest, cfg BatchConfig) BatchResult {
+	bo := backoff.WithMaxRetries(generateBackOff(ctx, RealClock{}, cfg.Backoff), uint64(cfg.MaxRetries))
+
+	var result BatchResult
+
+	for {
+		result.Attempts++
+
+		retryable := c.batchAttempt(ctx, breq, &result)
+		if result.Err == nil || !retryable || ctx.Err() != nil {
+			return result
+		}
+
+		delay := bo.NextBackOff()
+		if delay == backoff.Stop {
+			return result
+		}
+
+		if after, ok := retryAfter(result.Header, time.Now()); ok {
+			delay = max(delay, after)
+		}
+
+		if err := sleepCtx(ctx, delay); err != nil {
+			return result
+		}
+	}
+}
+
+// batchAttempt sends breq once recording the outcome in
+// result and reports whether a failure may be retried.
+func (c *Client) batchAttempt(ctx context.Context, breq BatchRequest, result *BatchResult) bool {
+	*result = BatchResult{Attempts: result.Attempts}
+
+	var body io.Reader
+	if breq.Body != nil {
+		body = bytes.NewReader(breq.Body)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, breq.Method, breq.URL, body)
+	if err != nil {
+		result.Err = fmt.Errorf("constructing request: %w", err)
+
+		return false
+	}
+
+	for key, vals := range breq.Header {
+		for _, val := range vals {
+			req.Header.Add(key, val)
+		}
+	}
+
+	res, err := c.do(req, breq.Options...)
+	if err == nil && (res.StatusCode < 200 || res.StatusCode > 299) {
+		err = NewHTTPError(res)
+		res = nil
+	}
+
+	if err != nil {
+		result.Err = err
+		result.StatusCode, result.Header = pollStatus(nil, err)
+
+		if result.StatusCode != 0 {
+			return isStatusRetryable(result.StatusCode, isRequestIdempotent(req))
+		}
+
+		return NewDefaultRetryPolicy().IsErrorRetryable(err)
+	}
+
+	defer res.Body.Close()
+
+	result.StatusCode = res.StatusCode
+	result.Header = res.Header
+
+	if result.Body, err = io.ReadAll(res.Body); err != nil {
+		result.Err = fmt.Errorf("reading response body: %w", err)
+
+		return true
+	}
+
+	return false
+}
+
+type BatchConfig struct {
+	// Concurrency is the maximum number of requests
+	// performed at once. Defaults to 8.
+	Concurrency int
+	// MaxRetries is the maximum number of times each
+	// request is retried. Defaults to 3.
+	MaxRetries int
+	// Backoff generates the delays between attempts of
+	// a request. Defaults to ExponentialBackoffGenerator().
+	Backoff BackoffGenerator
+	// FailFast abandons outstanding requests
+	// after the first failure.
+	FailFast bool
+}
+
+func (c *BatchConfig) Option(opts ...BatchOption) {
+	for _, opt := range opts {
+		opt.ConfigureBatch(c)
+	}
+}
+
+func (c *BatchConfig) Default() {
+	if c.Concurrency <= 0 {
+		c.Concurrency = 8
+	}
+
+	if c.MaxRetries < 0 {
+		c.MaxRetries = 0
+	} else if c.MaxRetries == 0 {
+		c.MaxRetries = 3
+	}
+
+	if c.Backoff == nil {
+		c.Backoff = ExponentialBackoffGenerator()
+	}
+}
+
+type BatchOption interface {
+	ConfigureBatch(*BatchConfig)
+}
+
+// WithBatchConcurrency sets the maximum number
+// of requests performed at once.
+type WithBatchConcurrency int
+
+func (n WithBatchConcurrency) ConfigureBatch(c *BatchConfig) {
+	c.Concurrency = int(n)
+}
+
+// WithBatchRetries sets the maximum number of times each request
+// is retried. A negative value disables retries.
+type WithBatchRetries int
+
+func (n WithBatchRetries) ConfigureBatch(c *BatchConfig) {
+	c.MaxRetries = int(n)
+}
+
+// WithBatchBackoff sets the BackoffGenerator used to
+// delay attempts of failed requests.
+type WithBatchBackoff BackoffGenerator
+
+func (b WithBatchBackoff) ConfigureBatch(c *BatchConfig) {
+	c.Backoff = BackoffGenerator(b)
+}
+
+// WithFailFast abandons outstanding requests of a
+// batch once any request has failed.
+type WithFailFast bool
+
+func (f WithFailFast) ConfigureBatch(c *BatchConfig) {
+	c.FailFast = bool(f)
+}
@@ -0,0 +1,194 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientBatch(t *testing.T) {
+	t.Parallel()
+
+	var (
+		mu       sync.Mutex
+		attempts = make(map[string]int)
+	)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		attempts[r.URL.Path]++
+		n := attempts[r.URL.Path]
+		mu.Unlock()
+
+		switch r.URL.Path {
+		case "/flaky":
+			if n == 1 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+
+				return
+			}
+		case "/missing":
+			w.WriteHeader(http.StatusNotFound)
+
+			return
+		case "/post":
+			if n == 1 {
+				w.WriteHeader(http.StatusBadGateway)
+
+				return
+			}
+		}
+
+		body, _ := io.ReadAll(r.Body)
+
+		fmt.Fprintf(w, "%s %s %s", r.Method, r.URL.Path, body)
+	}))
+	defer srv.Close()
+
+	requests := []BatchRequest{
+		{Method: http.MethodGet, URL: srv.URL + "/ok"},
+		{Method: http.MethodGet, URL: srv.URL + "/flaky"},
+		{Method: http.MethodGet, URL: srv.URL + "/missing"},
+		{Method: http.MethodPut, URL: srv.URL + "/put", Body: []byte("data")},
+		{Method: http.MethodPost, URL: srv.URL + "/post"},
+	}
+
+	results, err := NewClient().Batch(context.Background(), requests,
+		WithBatchConcurrency(2),
+		WithBatchBackoff(NoBackoffGenerator()),
+	)
+	require.Len(t, results, len(requests))
+
+	assert.Equal(t, "GET /ok ", string(results[0].Body))
+	assert.Equal(t, 1, results[0].Attempts)
+
+	assert.Equal(t, "GET /flaky ", string(results[1].Body))
+	assert.Equal(t, 2, results[1].Attempts)
+
+	assert.True(t, IsStatus(results[2].Err, http.StatusNotFound))
+	assert.Equal(t, http.StatusNotFound, results[2].StatusCode)
+	assert.Equal(t, 1, results[2].Attempts)
+
+	assert.Equal(t, "PUT /put data", string(results[3].Body))
+
+	// non-idempotent requests are not retried
+	assert.True(t, IsStatus(results[4].Err, http.StatusBadGateway))
+	assert.Equal(t, 1, results[4].Attempts)
+
+	require.Error(t, err)
+	assert.ErrorIs(t, err, results[2].Err)
+	assert.ErrorIs(t, err, results[4].Err)
+	assert.True(t, strings.Contains(err.Error(), "request 2"))
+}
+
+func TestClientBatchConcurrency(t *testing.T) {
+	t.Parallel()
+
+	var active, peak atomic.Int32
+
+	release := make(chan struct{})
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		n := active.Add(1)
+		defer active.Add(-1)
+
+		for {
+			old := peak.Load()
+			if n <= old || peak.CompareAndSwap(old, n) {
+				break
+			}
+		}
+
+		<-release
+	}))
+	defer srv.Close()
+
+	requests := make([]BatchRequest, 10)
+	for i := range requests {
+		requests[i] = BatchRequest{Method: http.MethodGet, URL: srv.URL}
+	}
+
+	done := make(chan error)
+
+	go func() {
+		_, err := NewClient().Batch(context.Background(), requests, WithBatchConcurrency(3))
+
+		done <- err
+	}()
+
+	require.Eventually(t, func() bool { return active.Load() == 3 }, time.Second, time.Millisecond)
+
+	close(release)
+
+	require.NoError(t, <-done)
+	assert.Equal(t, int32(3), peak.Load())
+}
+
+func TestClientBatchFailFast(t *testing.T) {
+	t.Parallel()
+
+	var served atomic.Int32
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		served.Add(1)
+
+		if r.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer srv.Close()
+
+	requests := []BatchRequest{{Method: http.MethodGet, URL: srv.URL + "/fail"}}
+	for range 10 {
+		requests = append(requests, BatchRequest{Method: http.MethodGet, URL: srv.URL})
+	}
+
+	results, err := NewClient().Batch(context.Background(), requests,
+		WithBatchConcurrency(1),
+		WithFailFast(true),
+	)
+
+	require.Error(t, err)
+	assert.True(t, IsStatus(err, http.StatusBadRequest))
+	assert.False(t, errors.Is(err, ErrBatchAborted))
+
+	assert.Equal(t, int32(1), served.Load())
+
+	for _, res := range results[1:] {
+		assert.ErrorIs(t, res.Err, ErrBatchAborted)
+	}
+}
+
+func TestClientBatchRetriesExhausted(t *testing.T) {
+	t.Parallel()
+
+	var served atomic.Int32
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		served.Add(1)
+
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	results, err := NewClient().Batch(context.Background(),
+		[]BatchRequest{{Method: http.MethodGet, URL: srv.URL}},
+		WithBatchRetries(2),
+		WithBatchBackoff(NoBackoffGenerator()),
+	)
+
+	assert.True(t, IsStatus(err, http.StatusServiceUnavailable))
+	assert.Equal(t, 3, results[0].Attempts)
+	assert.Equal(t, int32(3), served.Load())
+}
@@ -0,0 +1,149 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// The benchmarks in this file measure the per-request overhead of
+// common wrapper stacks relative to a bare http.Client. Results use
+// the standard Go benchmark format and can be compared across
+// revisions with benchstat:
+//
+//	go test -run '^$' -bench . -benchmem -count 10 . | tee new.txt
+//	benchstat old.txt new.txt
+
+const benchBody = `{"status":"ok"}`
+
+// benchStacks are the wrapper stacks measured by each benchmark.
+var benchStacks = []struct {
+	Name     string
+	Wrappers func() []TransportWrapper
+}{
+	{
+		Name:     "client",
+		Wrappers: func() []TransportWrapper { return nil },
+	},
+	{
+		Name: "retry",
+		Wrappers: func() []TransportWrapper {
+			return []TransportWrapper{NewRetryWrapper()}
+		},
+	},
+	{
+		Name: "common",
+		Wrappers: func() []TransportWrapper {
+			return []TransportWrapper{
+				NewUserAgentWrapper(),
+				NewSanitizeWrapper(),
+				NewRetryWrapper(),
+			}
+		},
+	},
+	{
+		Name: "hedged",
+		Wrappers: func() []TransportWrapper {
+			return []TransportWrapper{
+				NewRetryWrapper(),
+				NewHedgeWrapper(),
+			}
+		},
+	},
+}
+
+// BenchmarkWrapperOverhead measures wrapper stacks against an in-memory
+// transport so that results reflect only the cost of the client itself.
+func BenchmarkWrapperOverhead(b *testing.B) {
+	runWrapperBenchmarks(b, benchTransport{}, "http://bench.test/resource")
+}
+
+// BenchmarkWrapperLoopback measures wrapper stacks against a loopback
+// server to put the overhead in proportion to a real round trip.
+func BenchmarkWrapperLoopback(b *testing.B) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, benchBody)
+	}))
+	defer srv.Close()
+
+	runWrapperBenchmarks(b, srv.Client().Transport, srv.URL+"/resource")
+}
+
+func runWrapperBenchmarks(b *testing.B, base http.RoundTripper, url string) {
+	b.Helper()
+
+	b.Run("net/http", func(b *testing.B) {
+		client := &http.Client{Transport: base}
+
+		benchmarkRequests(b, func(ctx context.Context) (*http.Response, error) {
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+			if err != nil {
+				return nil, err
+			}
+
+			return client.Do(req)
+		})
+	})
+
+	for _, stack := range benchStacks {
+		stack := stack
+
+		b.Run(stack.Name, func(b *testing.B) {
+			opts := []ClientOption{WithTransport{RoundTripper: base}}
+
+			for _, w := range stack.Wrappers() {
+				opts = append(opts, WithWrapper{TransportWrapper: w})
+			}
+
+			client := NewClient(opts...)
+			defer client.Close()
+
+			benchmarkRequests(b, func(ctx context.Context) (*http.Response, error) {
+				return client.Get(ctx, url)
+			})
+		})
+	}
+}
+
+func benchmarkRequests(b *testing.B, do func(context.Context) (*http.Response, error)) {
+	b.Helper()
+	b.ReportAllocs()
+
+	ctx := context.Background()
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		res, err := do(ctx)
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		if _, err := io.Copy(io.Discard, res.Body); err != nil {
+			b.Fatal(err)
+		}
+
+		res.Body.Close()
+	}
+}
+
+// benchTransport answers every request with a small JSON document.
+type benchTransport struct{}
+
+func (benchTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return &http.Response{
+		Status:        "200 OK",
+		StatusCode:    http.StatusOK,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{"Content-Type": []string{"application/json"}},
+		Body:          io.NopCloser(strings.NewReader(benchBody)),
+		ContentLength: int64(len(benchBody)),
+		Request:       req,
+	}, nil
+}
@@ -0,0 +1,35 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrRequestTimeout is the cancellation cause recorded when a
+// request exceeds the timeout configured with WithRequestTimeout.
+// It wraps context.DeadlineExceeded.
+var ErrRequestTimeout = fmt.Errorf("request timeout exceeded: %w", context.DeadlineExceeded)
+
+// ErrAttemptTimeout is the cancellation cause recorded when a single
+// attempt of a RetryWrapper exceeds the timeout configured with
+// WithPerAttemptTimeout. It wraps context.DeadlineExceeded.
+var ErrAttemptTimeout = fmt.Errorf("attempt timeout exceeded: %w", context.DeadlineExceeded)
+
+// withCancelCause annotates err with the cancellation cause of ctx
+// so that causes provided through context.WithCancelCause and
+// related functions are preserved in returned errors. The error
+// is returned unchanged if ctx has not been cancelled or if the
+// cause is already part of the error chain.
+func withCancelCause(ctx context.Context, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	cause := context.Cause(ctx)
+	if cause == nil || errors.Is(err, cause) {
+		return err
+	}
+
+	return fmt.Errorf("%w: %w", err, cause)
+}
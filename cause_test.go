@@ -0,0 +1,89 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/mt-sre/client/internal/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// TestRequestTimeoutCause ensures that requests exceeding their
+// timeout report ErrRequestTimeout as the cause.
+func TestRequestTimeoutCause(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer srv.Close()
+
+	_, err := NewClient().Get(context.Background(), srv.URL, WithRequestTimeout(10*time.Millisecond))
+	require.Error(t, err)
+
+	assert.ErrorIs(t, err, ErrRequestTimeout)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+// TestCancelCausePropagation ensures that the cause given when
+// cancelling a request's context is preserved by the RetryWrapper.
+func TestCancelCausePropagation(t *testing.T) {
+	t.Parallel()
+
+	errInterrupted := errors.New("user interrupt")
+
+	ctx, cancel := context.WithCancelCause(context.Background())
+	defer cancel(nil)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://example.com", nil)
+	require.NoError(t, err)
+
+	var mrt testutils.MockRoundTripper
+	mrt.
+		On("RoundTrip", mock.Anything).
+		Return((*http.Response)(nil), errors.New("connection refused")).
+		Once()
+
+	retry := NewRetryWrapper(
+		WithBackoffGenerator(ConstantBackoffGenerator(time.Hour)),
+		WithOnResponse(func(int, *http.Request, *http.Response, error) {
+			cancel(errInterrupted)
+		}),
+	)
+
+	_, err = retry.Wrap(&mrt).RoundTrip(req)
+	require.Error(t, err)
+
+	assert.ErrorIs(t, err, errInterrupted)
+	assert.ErrorIs(t, err, context.Canceled)
+
+	mrt.AssertExpectations(t)
+}
+
+func TestWithCancelCause(t *testing.T) {
+	t.Parallel()
+
+	errCause := errors.New("cause")
+
+	ctx, cancel := context.WithCancelCause(context.Background())
+
+	assert.NoError(t, withCancelCause(ctx, nil))
+
+	errOther := errors.New("other")
+	assert.Same(t, errOther, withCancelCause(ctx, errOther), "error should be unchanged before cancellation")
+
+	cancel(errCause)
+
+	err := withCancelCause(ctx, context.Canceled)
+	assert.ErrorIs(t, err, errCause)
+	assert.ErrorIs(t, err, context.Canceled)
+
+	wrapped := withCancelCause(ctx, err)
+	assert.Equal(t, err, wrapped, "cause should not be added twice")
+}
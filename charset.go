@@ -0,0 +1,134 @@
+package client
+
+import (
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/htmlindex"
+	"golang.org/x/text/encoding/unicode"
+)
+
+// NewCharsetWrapper returns a TransportWrapper which transcodes
+// response bodies to UTF-8 based on the charset parameter of the
+// response's Content-Type header. A variadic slice of options can
+// be provided to configure the charset handling from default.
+func NewCharsetWrapper(opts ...CharsetOption) *CharsetWrapper {
+	var cfg CharsetConfig
+
+	cfg.Option(opts...)
+	cfg.Default()
+
+	return &CharsetWrapper{
+		cfg: cfg,
+	}
+}
+
+type CharsetWrapper struct {
+	cfg CharsetConfig
+	rt  http.RoundTripper
+}
+
+func (w *CharsetWrapper) Wrap(rt http.RoundTripper) http.RoundTripper {
+	w.rt = rt
+
+	return w
+}
+
+func (w *CharsetWrapper) RoundTrip(req *http.Request) (*http.Response, error) {
+	res, err := w.rt.RoundTrip(req)
+	if err != nil || res.Body == nil || res.Body == http.NoBody {
+		return res, err
+	}
+
+	mediaType, params, err := mime.ParseMediaType(res.Header.Get("Content-Type"))
+	if err != nil {
+		// leave responses with missing or malformed Content-Type untouched
+		return res, nil
+	}
+
+	charset, ok := params["charset"]
+	if !ok {
+		if !strings.HasPrefix(mediaType, "text/") || w.cfg.DefaultCharset == "" {
+			return res, nil
+		}
+
+		charset = w.cfg.DefaultCharset
+	}
+
+	enc, err := w.cfg.Lookup(charset)
+	if err != nil {
+		res.Body.Close()
+
+		return nil, fmt.Errorf("looking up charset %q: %w", charset, err)
+	}
+
+	if enc == unicode.UTF8 || enc == encoding.Nop {
+		return res, nil
+	}
+
+	res.Body = &transcodedBody{
+		Reader: enc.NewDecoder().Reader(res.Body),
+		Closer: res.Body,
+	}
+
+	params["charset"] = "utf-8"
+	res.Header.Set("Content-Type", mime.FormatMediaType(mediaType, params))
+
+	// the transcoded length is not known in advance
+	res.Header.Del("Content-Length")
+	res.ContentLength = -1
+
+	return res, nil
+}
+
+type transcodedBody struct {
+	io.Reader
+	io.Closer
+}
+
+type CharsetConfig struct {
+	// DefaultCharset is assumed for "text/*" responses
+	// which do not specify a charset.
+	DefaultCharset string
+	// Lookup resolves a charset name to an encoding.
+	Lookup func(string) (encoding.Encoding, error)
+}
+
+func (c *CharsetConfig) Option(opts ...CharsetOption) {
+	for _, opt := range opts {
+		opt.ConfigureCharset(c)
+	}
+}
+
+func (c *CharsetConfig) Default() {
+	if c.Lookup == nil {
+		c.Lookup = htmlindex.Get
+	}
+}
+
+type CharsetOption interface {
+	ConfigureCharset(*CharsetConfig)
+}
+
+// WithDefaultCharset configures a CharsetWrapper to transcode
+// "text/*" responses which do not specify a charset from the
+// given charset.
+type WithDefaultCharset string
+
+func (dc WithDefaultCharset) ConfigureCharset(c *CharsetConfig) {
+	c.DefaultCharset = string(dc)
+}
+
+// WithCharsetLookup configures a CharsetWrapper with a function
+// used to resolve charset names to encodings. This may be used
+// to support charsets which are not part of the WHATWG Encoding
+// Standard.
+type WithCharsetLookup func(string) (encoding.Encoding, error)
+
+func (cl WithCharsetLookup) ConfigureCharset(c *CharsetConfig) {
+	c.Lookup = cl
+}
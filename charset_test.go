@@ -0,0 +1,123 @@
+package client
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/mt-sre/client/internal/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCharsetWrapperInterfaces(t *testing.T) {
+	t.Parallel()
+
+	require.Implements(t, new(http.RoundTripper), new(CharsetWrapper))
+
+	require.Implements(t, new(TransportWrapper), new(CharsetWrapper))
+}
+
+func TestCharsetWrapper(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		Options             []CharsetOption
+		ContentType         string
+		Body                []byte
+		ExpectedBody        string
+		ExpectedContentType string
+	}{
+		"iso-8859-1": {
+			ContentType:         "text/plain; charset=ISO-8859-1",
+			Body:                []byte{'c', 'a', 'f', 0xe9},
+			ExpectedBody:        "café",
+			ExpectedContentType: "text/plain; charset=utf-8",
+		},
+		"utf-8": {
+			ContentType:         "application/json; charset=utf-8",
+			Body:                []byte(`{"name":"café"}`),
+			ExpectedBody:        `{"name":"café"}`,
+			ExpectedContentType: "application/json; charset=utf-8",
+		},
+		"no charset": {
+			ContentType:         "text/plain",
+			Body:                []byte{'c', 'a', 'f', 0xe9},
+			ExpectedBody:        string([]byte{'c', 'a', 'f', 0xe9}),
+			ExpectedContentType: "text/plain",
+		},
+		"no charset with default": {
+			Options:             []CharsetOption{WithDefaultCharset("latin1")},
+			ContentType:         "text/plain",
+			Body:                []byte{'c', 'a', 'f', 0xe9},
+			ExpectedBody:        "café",
+			ExpectedContentType: "text/plain; charset=utf-8",
+		},
+		"missing content type": {
+			Options:      []CharsetOption{WithDefaultCharset("latin1")},
+			Body:         []byte("test"),
+			ExpectedBody: "test",
+		},
+	} {
+		tc := tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			req := testutils.MockRequest(t, http.MethodGet, nil)
+
+			header := make(http.Header)
+			if tc.ContentType != "" {
+				header.Set("Content-Type", tc.ContentType)
+			}
+
+			var mrt testutils.MockRoundTripper
+			mrt.
+				On("RoundTrip", req).
+				Return(&http.Response{
+					StatusCode: http.StatusOK,
+					Header:     header,
+					Body:       io.NopCloser(bytes.NewBuffer(tc.Body)),
+				}, nil)
+
+			var client http.Client
+			client.Transport = NewCharsetWrapper(tc.Options...).Wrap(&mrt)
+
+			res, err := client.Do(req)
+			require.NoError(t, err)
+			defer res.Body.Close()
+
+			body, err := io.ReadAll(res.Body)
+			require.NoError(t, err)
+
+			assert.Equal(t, tc.ExpectedBody, string(body))
+			assert.Equal(t, tc.ExpectedContentType, res.Header.Get("Content-Type"))
+
+			mrt.AssertExpectations(t)
+		})
+	}
+}
+
+// TestCharsetWrapper_UnknownCharset ensures that responses with
+// an unsupported charset result in an error.
+func TestCharsetWrapper_UnknownCharset(t *testing.T) {
+	t.Parallel()
+
+	req := testutils.MockRequest(t, http.MethodGet, nil)
+
+	var mrt testutils.MockRoundTripper
+	mrt.
+		On("RoundTrip", req).
+		Return(&http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": []string{"text/plain; charset=unknown"}},
+			Body:       io.NopCloser(bytes.NewBuffer(nil)),
+		}, nil)
+
+	var client http.Client
+	client.Transport = NewCharsetWrapper().Wrap(&mrt)
+
+	_, err := client.Do(req)
+	require.Error(t, err)
+}
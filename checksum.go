@@ -0,0 +1,191 @@
+package client
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"net/http"
+	"strings"
+)
+
+// ErrNoChecksum is returned when a download is verified against
+// a digest advertised by the server but the response carries none.
+var ErrNoChecksum = errors.New("response carries no checksum")
+
+// ChecksumAlgorithm names a digest algorithm using
+// the identifiers of the HTTP digest fields.
+type ChecksumAlgorithm string
+
+const (
+	ChecksumSHA256 ChecksumAlgorithm = "sha-256"
+	ChecksumSHA512 ChecksumAlgorithm = "sha-512"
+)
+
+func (a ChecksumAlgorithm) new() (hash.Hash, error) {
+	switch a {
+	case ChecksumSHA256:
+		return sha256.New(), nil
+	case ChecksumSHA512:
+		return sha512.New(), nil
+	default:
+		return nil, fmt.Errorf("unsupported checksum algorithm %q", a)
+	}
+}
+
+// ChecksumMismatchError is returned when downloaded
+// content does not match the expected digest.
+type ChecksumMismatchError struct {
+	Algorithm ChecksumAlgorithm
+	// Expected and Actual are hex encoded digests.
+	Expected string
+	Actual   string
+}
+
+func (e *ChecksumMismatchError) Error() string {
+	return fmt.Sprintf("checksum mismatch: expected %s %s, got %s", e.Algorithm, e.Expected, e.Actual)
+}
+
+// downloadDigest computes the digest of downloaded content
+// across resumed requests.
+type downloadDigest struct {
+	algorithm  ChecksumAlgorithm
+	hash       hash.Hash
+	expected   []byte
+	fromHeader bool
+}
+
+func newDownloadDigest(cfg DownloadConfig) (*downloadDigest, error) {
+	if cfg.ChecksumAlgorithm == "" {
+		return nil, nil
+	}
+
+	h, err := cfg.ChecksumAlgorithm.new()
+	if err != nil {
+		return nil, err
+	}
+
+	d := &downloadDigest{
+		algorithm:  cfg.ChecksumAlgorithm,
+		hash:       h,
+		fromHeader: cfg.ChecksumFromHeader,
+	}
+
+	if !d.fromHeader {
+		if d.expected, err = hex.DecodeString(cfg.Checksum); err != nil {
+			return nil, fmt.Errorf("decoding checksum: %w", err)
+		}
+	}
+
+	return d, nil
+}
+
+// observe records the digest advertised by the
+// given headers if verifying against headers.
+func (d *downloadDigest) observe(header http.Header) error {
+	if d == nil || !d.fromHeader {
+		return nil
+	}
+
+	if sum, ok := headerDigest(header, d.algorithm, d.hash.Size()); ok {
+		d.expected = sum
+
+		return nil
+	}
+
+	if d.expected == nil {
+		return fmt.Errorf("%w for %s", ErrNoChecksum, d.algorithm)
+	}
+
+	return nil
+}
+
+func (d *downloadDigest) verify() error {
+	actual := d.hash.Sum(nil)
+
+	if !bytes.Equal(actual, d.expected) {
+		return &ChecksumMismatchError{
+			Algorithm: d.algorithm,
+			Expected:  hex.EncodeToString(d.expected),
+			Actual:    hex.EncodeToString(actual),
+		}
+	}
+
+	return nil
+}
+
+// headerDigest returns the digest of the complete representation
+// computed with alg as advertised by the Repr-Digest (RFC 9530) or
+// Digest (RFC 3230) header or a strong ETag holding a hex encoded
+// digest of the given size.
+func headerDigest(header http.Header, alg ChecksumAlgorithm, size int) ([]byte, bool) {
+	if dict, err := ParseStructuredDictionary(header.Values("Repr-Digest")...); err == nil {
+		if member, ok := dict.Get(string(alg)); ok {
+			if item, ok := member.(StructuredItem); ok {
+				if sum, ok := item.Value.([]byte); ok {
+					return sum, true
+				}
+			}
+		}
+	}
+
+	for _, val := range header.Values("Digest") {
+		for _, elem := range strings.Split(val, ",") {
+			name, value, ok := strings.Cut(strings.TrimSpace(elem), "=")
+			if !ok || !strings.EqualFold(name, string(alg)) {
+				continue
+			}
+
+			if sum, err := base64.StdEncoding.DecodeString(value); err == nil {
+				return sum, true
+			}
+		}
+	}
+
+	if etag := header.Get("ETag"); !strings.HasPrefix(etag, "W/") {
+		if sum, err := hex.DecodeString(strings.Trim(etag, `"`)); err == nil && len(sum) == size {
+			return sum, true
+		}
+	}
+
+	return nil, false
+}
+
+// WithChecksum verifies downloaded content against the given hex
+// encoded digest. A mismatch is reported as *ChecksumMismatchError.
+type WithChecksum struct {
+	Algorithm ChecksumAlgorithm
+	Expected  string
+}
+
+func (c WithChecksum) ConfigureDownload(cfg *DownloadConfig) {
+	cfg.ChecksumAlgorithm = c.Algorithm
+	cfg.Checksum = c.Expected
+	cfg.ChecksumFromHeader = false
+}
+
+// WithChecksumFromHeader verifies downloaded content against the
+// digest computed with the given algorithm which is advertised by
+// the server through the Repr-Digest or Digest header or an ETag
+// holding a hex encoded digest. Downloads fail with ErrNoChecksum
+// if no such digest is found.
+type WithChecksumFromHeader ChecksumAlgorithm
+
+func (a WithChecksumFromHeader) ConfigureDownload(cfg *DownloadConfig) {
+	cfg.ChecksumAlgorithm = ChecksumAlgorithm(a)
+	cfg.Checksum = ""
+	cfg.ChecksumFromHeader = true
+}
+
+// WithChecksumRetries restarts downloads failing checksum
+// verification from the beginning at most the given number
+// of times. Only DownloadFile is able to restart downloads.
+type WithChecksumRetries int
+
+func (r WithChecksumRetries) ConfigureDownload(cfg *DownloadConfig) {
+	cfg.ChecksumRetries = int(r)
+}
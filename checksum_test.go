@@ -0,0 +1,190 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientDownloadChecksum(t *testing.T) {
+	t.Parallel()
+
+	content := strings.Repeat("0123456789", 100)
+	sha256Sum := sha256.Sum256([]byte(content))
+	sha512Sum := sha512.Sum512([]byte(content))
+
+	for name, tc := range map[string]struct {
+		Header           http.Header
+		Options          []DownloadOption
+		ExpectedMismatch bool
+		ExpectedErr      error
+	}{
+		"sha-256": {
+			Options: []DownloadOption{WithChecksum{Algorithm: ChecksumSHA256, Expected: hex.EncodeToString(sha256Sum[:])}},
+		},
+		"sha-512": {
+			Options: []DownloadOption{WithChecksum{Algorithm: ChecksumSHA512, Expected: hex.EncodeToString(sha512Sum[:])}},
+		},
+		"mismatch": {
+			Options:          []DownloadOption{WithChecksum{Algorithm: ChecksumSHA256, Expected: hex.EncodeToString(sha512Sum[:32])}},
+			ExpectedMismatch: true,
+		},
+		"repr-digest header": {
+			Header:  http.Header{"Repr-Digest": {"sha-256=:" + base64.StdEncoding.EncodeToString(sha256Sum[:]) + ":"}},
+			Options: []DownloadOption{WithChecksumFromHeader(ChecksumSHA256)},
+		},
+		"digest header": {
+			Header:  http.Header{"Digest": {"MD5=abc, SHA-512=" + base64.StdEncoding.EncodeToString(sha512Sum[:])}},
+			Options: []DownloadOption{WithChecksumFromHeader(ChecksumSHA512)},
+		},
+		"etag": {
+			Header:  http.Header{"Etag": {`"` + hex.EncodeToString(sha256Sum[:]) + `"`}},
+			Options: []DownloadOption{WithChecksumFromHeader(ChecksumSHA256)},
+		},
+		"header mismatch": {
+			Header:           http.Header{"Etag": {`"` + hex.EncodeToString(sha512Sum[:32]) + `"`}},
+			Options:          []DownloadOption{WithChecksumFromHeader(ChecksumSHA256)},
+			ExpectedMismatch: true,
+		},
+		"no header": {
+			Header:      http.Header{"Etag": {`"v1"`}},
+			Options:     []DownloadOption{WithChecksumFromHeader(ChecksumSHA256)},
+			ExpectedErr: ErrNoChecksum,
+		},
+	} {
+		tc := tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				for key, vals := range tc.Header {
+					w.Header()[key] = vals
+				}
+
+				w.Write([]byte(content))
+			}))
+			defer srv.Close()
+
+			var buf bytes.Buffer
+
+			err := NewClient().Download(context.Background(), srv.URL, &buf, tc.Options...)
+
+			switch {
+			case tc.ExpectedMismatch:
+				var mismatch *ChecksumMismatchError
+
+				require.ErrorAs(t, err, &mismatch)
+				assert.Equal(t, hex.EncodeToString(sha256Sum[:]), mismatch.Actual)
+			case tc.ExpectedErr != nil:
+				assert.ErrorIs(t, err, tc.ExpectedErr)
+			default:
+				require.NoError(t, err)
+				assert.Equal(t, content, buf.String())
+			}
+		})
+	}
+}
+
+func TestClientDownloadFileChecksumRetries(t *testing.T) {
+	t.Parallel()
+
+	content := strings.Repeat("0123456789", 100)
+	sum := sha256.Sum256([]byte(content))
+
+	for name, tc := range map[string]struct {
+		Retries          int
+		ExpectedRequests int32
+		ExpectedMismatch bool
+	}{
+		"restarted": {
+			Retries:          1,
+			ExpectedRequests: 2,
+		},
+		"no retries": {
+			ExpectedRequests: 1,
+			ExpectedMismatch: true,
+		},
+	} {
+		tc := tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var requests atomic.Int32
+
+			// the first response is corrupted
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				if requests.Add(1) == 1 {
+					w.Write([]byte(strings.Repeat("x", len(content))))
+
+					return
+				}
+
+				w.Write([]byte(content))
+			}))
+			defer srv.Close()
+
+			path := filepath.Join(t.TempDir(), "artifact")
+
+			err := NewClient().DownloadFile(context.Background(), srv.URL, path,
+				WithChecksum{Algorithm: ChecksumSHA256, Expected: hex.EncodeToString(sum[:])},
+				WithChecksumRetries(tc.Retries),
+			)
+
+			assert.Equal(t, tc.ExpectedRequests, requests.Load())
+
+			if tc.ExpectedMismatch {
+				var mismatch *ChecksumMismatchError
+
+				require.ErrorAs(t, err, &mismatch)
+				assert.NoFileExists(t, path)
+
+				return
+			}
+
+			require.NoError(t, err)
+
+			data, err := os.ReadFile(path)
+			require.NoError(t, err)
+			assert.Equal(t, content, string(data))
+		})
+	}
+}
+
+func TestClientDownloadFileChecksumResumed(t *testing.T) {
+	t.Parallel()
+
+	content := strings.Repeat("0123456789", 100)
+	sum := sha256.Sum256([]byte(content))
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeContent(w, r, "", time.Time{}, strings.NewReader(content))
+	}))
+	defer srv.Close()
+
+	path := filepath.Join(t.TempDir(), "artifact")
+
+	// the digest must cover the partial file being resumed
+	require.NoError(t, os.WriteFile(path+".part", []byte(content[:100]), 0o600))
+
+	err := NewClient().DownloadFile(context.Background(), srv.URL, path,
+		WithResume(0),
+		WithChecksum{Algorithm: ChecksumSHA256, Expected: hex.EncodeToString(sum[:])},
+	)
+	require.NoError(t, err)
+}
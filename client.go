@@ -2,9 +2,17 @@ package client
 
 import (
 	"context"
+	"crypto/tls"
+	"errors"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
+	"net/url"
+	"slices"
+	"time"
+
+	"github.com/go-logr/logr"
 )
 
 // NewClient returns an opionanted HTTP client which can be
@@ -16,82 +24,492 @@ func NewClient(opts ...ClientOption) *Client {
 	cfg.Option(opts...)
 	cfg.Default()
 
-	var client http.Client
+	client := http.Client{
+		Timeout:       cfg.Timeout,
+		CheckRedirect: cfg.checkRedirect(),
+	}
 
 	cfg.Wrap(&client)
 
-	return &Client{
+	c := &Client{
 		cfg:    cfg,
 		client: &client,
 	}
+
+	if cfg.IdleHostTTL > 0 {
+		c.reaper = newIdleReaper(&cfg, client.Transport)
+		client.Transport = c.reaper
+
+		go c.reaper.run()
+	}
+
+	if cfg.probing != nil {
+		c.prober = newConnectionProber(*cfg.probing, cfg.baseTransport())
+
+		go c.prober.run()
+	}
+
+	return c
 }
 
 type Client struct {
 	cfg    ClientConfig
 	client *http.Client
+	reaper *idleReaper
+	prober *connectionProber
+	// derived is set for clients returned by With
+	// which share the transport of their parent.
+	derived bool
+}
+
+// Close stops background tasks started by the Client and closes
+// idle connections unless the Client shares http.DefaultTransport.
+// Closing a Client returned by With has no effect.
+func (c *Client) Close() {
+	if c.derived {
+		return
+	}
+
+	if c.reaper != nil {
+		c.reaper.Close()
+	}
+
+	if c.prober != nil {
+		c.prober.Close()
+	}
+
+	if c.cfg.Transport != http.DefaultTransport {
+		closeIdleConnections(c.cfg.Transport)
+	}
 }
 
 // Get performs a HTTP GET request against the provided URL.
-func (c *Client) Get(ctx context.Context, url string) (*http.Response, error) {
-	return c.requestWithoutBody(ctx, http.MethodGet, url)
+func (c *Client) Get(ctx context.Context, url string, opts ...RequestOption) (*http.Response, error) {
+	return c.requestWithoutBody(ctx, http.MethodGet, url, opts...)
 }
 
 // Head performs a HTTP HEAD request against the provided URL.
-func (c *Client) Head(ctx context.Context, url string) (*http.Response, error) {
-	return c.requestWithoutBody(ctx, http.MethodHead, url)
+func (c *Client) Head(ctx context.Context, url string, opts ...RequestOption) (*http.Response, error) {
+	return c.requestWithoutBody(ctx, http.MethodHead, url, opts...)
 }
 
 // Post performs a HTTP POST request against the provided URL with the given body.
-func (c *Client) Post(ctx context.Context, url string, body io.Reader) (*http.Response, error) {
-	return c.requestWithBody(ctx, http.MethodPost, url, nil)
+func (c *Client) Post(ctx context.Context, url string, body io.Reader, opts ...RequestOption) (*http.Response, error) {
+	return c.requestWithBody(ctx, http.MethodPost, url, body, opts...)
 }
 
 // Put performs a HTTP PUT request against the provided URL with the given body.
-func (c *Client) Put(ctx context.Context, url string, body io.Reader) (*http.Response, error) {
-	return c.requestWithBody(ctx, http.MethodPut, url, nil)
+func (c *Client) Put(ctx context.Context, url string, body io.Reader, opts ...RequestOption) (*http.Response, error) {
+	return c.requestWithBody(ctx, http.MethodPut, url, body, opts...)
 }
 
 // Patch performs a HTTP PATCH request against the provided URL with the given body.
-func (c *Client) Patch(ctx context.Context, url string, body io.Reader) (*http.Response, error) {
-	return c.requestWithBody(ctx, http.MethodPatch, url, nil)
+func (c *Client) Patch(ctx context.Context, url string, body io.Reader, opts ...RequestOption) (*http.Response, error) {
+	return c.requestWithBody(ctx, http.MethodPatch, url, body, opts...)
 }
 
 // Delete performs a HTTP DELETE request against the provided URL.
-func (c *Client) Delete(ctx context.Context, url string) (*http.Response, error) {
-	return c.requestWithoutBody(ctx, http.MethodDelete, url)
+func (c *Client) Delete(ctx context.Context, url string, opts ...RequestOption) (*http.Response, error) {
+	return c.requestWithoutBody(ctx, http.MethodDelete, url, opts...)
 }
 
 // Connect performs a HTTP CONNECT request against the provided URL with the given body.
-func (c *Client) Connect(ctx context.Context, url string, body io.Reader) (*http.Response, error) {
-	return c.requestWithBody(ctx, http.MethodConnect, url, nil)
+func (c *Client) Connect(ctx context.Context, url string, body io.Reader, opts ...RequestOption) (*http.Response, error) {
+	return c.requestWithBody(ctx, http.MethodConnect, url, body, opts...)
 }
 
 // Options performs a HTTP OPTIONS request against the provided URL.
-func (c *Client) Options(ctx context.Context, url string) (*http.Response, error) {
-	return c.requestWithoutBody(ctx, http.MethodOptions, url)
+func (c *Client) Options(ctx context.Context, url string, opts ...RequestOption) (*http.Response, error) {
+	return c.requestWithoutBody(ctx, http.MethodOptions, url, opts...)
 }
 
 // Trace performs a HTTP TRACE request against the provided URL.
-func (c *Client) Trace(ctx context.Context, url string) (*http.Response, error) {
-	return c.requestWithoutBody(ctx, http.MethodTrace, url)
+func (c *Client) Trace(ctx context.Context, url string, opts ...RequestOption) (*http.Response, error) {
+	return c.requestWithoutBody(ctx, http.MethodTrace, url, opts...)
 }
 
-func (c *Client) requestWithoutBody(ctx context.Context, method, url string) (*http.Response, error) {
-	return c.requestWithBody(ctx, method, url, nil)
+func (c *Client) requestWithoutBody(ctx context.Context, method, url string, opts ...RequestOption) (*http.Response, error) {
+	return c.requestWithBody(ctx, method, url, nil, opts...)
 }
 
-func (c *Client) requestWithBody(ctx context.Context, method, url string, body io.Reader) (*http.Response, error) {
+func (c *Client) requestWithBody(ctx context.Context, method, url string, body io.Reader, opts ...RequestOption) (*http.Response, error) {
 	req, err := http.NewRequestWithContext(ctx, method, url, body)
 	if err != nil {
 		return nil, fmt.Errorf("constructing request: %w", err)
 	}
 
-	return c.client.Do(req)
+	return c.do(req, opts...)
+}
+
+// Do sends the given request through the wrappers of the Client
+// applying client level settings such as default headers and the
+// base URL. This allows requests built elsewhere, for instance by
+// generated API clients, to benefit from the configured wrappers.
+// The request is not modified.
+func (c *Client) Do(req *http.Request) (*http.Response, error) {
+	return c.do(req)
+}
+
+// StdClient returns the underlying *http.Client whose transport
+// includes all wrappers of the Client. Requests sent through it
+// bypass client level settings such as default headers, the base
+// URL and ErrorOnNon2xx.
+func (c *Client) StdClient() *http.Client {
+	return c.client
+}
+
+func (c *Client) do(req *http.Request, opts ...RequestOption) (*http.Response, error) {
+	var cfg RequestConfig
+
+	cfg.Option(opts...)
+
+	ctx := req.Context()
+	cancel := context.CancelFunc(func() {})
+
+	if cfg.Timeout > 0 {
+		ctx, cancel = context.WithTimeoutCause(ctx, cfg.Timeout, ErrRequestTimeout)
+	}
+
+	if cfg.Progress != nil {
+		ctx = ContextWithProgress(ctx, cfg.Progress)
+	}
+
+	if cfg.Informational != nil {
+		ctx = ContextWithInformationalResponses(ctx, cfg.Informational)
+	}
+
+	if cfg.Impersonate != nil {
+		ctx = ContextWithImpersonation(ctx, *cfg.Impersonate)
+	}
+
+	if cfg.Transport != nil {
+		ctx = context.WithValue(ctx, transportOverrideKey{}, cfg.Transport)
+	}
+
+	if cfg.RequestID != "" {
+		ctx = ContextWithRequestID(ctx, cfg.RequestID)
+	}
+
+	if cfg.Priority != nil {
+		ctx = ContextWithPriority(ctx, *cfg.Priority)
+	}
+
+	if c.cfg.Redactor != nil {
+		ctx = ContextWithRedactor(ctx, c.cfg.Redactor)
+	}
+
+	for _, sink := range c.cfg.EventSinks {
+		ctx = ContextWithEventSink(ctx, sink)
+	}
+
+	req = req.Clone(ctx)
+
+	if c.cfg.BaseURL != nil && !req.URL.IsAbs() {
+		req.URL = c.cfg.BaseURL.ResolveReference(req.URL)
+	}
+
+	for key, vals := range cfg.Header {
+		for _, val := range vals {
+			req.Header.Add(key, val)
+		}
+	}
+
+	for key, vals := range c.cfg.Header {
+		if _, ok := req.Header[key]; !ok {
+			req.Header[key] = slices.Clone(vals)
+		}
+	}
+
+	if id, ok := RequestIDFromContext(ctx); ok && req.Header.Get(RequestIDHeader) == "" {
+		req.Header.Set(RequestIDHeader, id)
+	}
+
+	var (
+		cached      validators
+		conditional bool
+	)
+
+	if c.cfg.validators != nil {
+		cached, conditional = c.cfg.validators.apply(req)
+	}
+
+	var rec *artifactRecorder
+
+	if c.cfg.FailureArtifactDir != "" {
+		var traceCtx context.Context
+
+		traceCtx, rec = newArtifactRecorder(ctx)
+		req = req.WithContext(traceCtx)
+	}
+
+	emitEvent(ctx, &RequestStarted{
+		Method: req.Method,
+		URL:    redactURL(ctx, req.URL),
+	})
+
+	start := time.Now()
+
+	res, err := c.client.Do(req)
+
+	finished := &RequestFinished{
+		Method:   req.Method,
+		URL:      redactURL(ctx, req.URL),
+		Err:      err,
+		Duration: time.Since(start),
+	}
+
+	if res != nil {
+		finished.StatusCode = res.StatusCode
+	}
+
+	emitEvent(ctx, finished)
+	c.cfg.stats.recordRequest(res, err)
+
+	if err != nil {
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			if u, parseErr := url.Parse(urlErr.URL); parseErr == nil {
+				urlErr.URL = redactURL(ctx, u)
+			}
+		}
+
+		err = withCancelCause(ctx, err)
+
+		cancel()
+
+		if rec != nil {
+			c.writeFailureArtifact(req, rec, err)
+		}
+
+		return nil, err
+	}
+
+	if c.cfg.validators != nil {
+		if conditional && res.StatusCode == http.StatusNotModified {
+			defer cancel()
+
+			closeResponseBody(res)
+
+			return nil, &NotModifiedError{
+				URL:          redactURL(ctx, req.URL),
+				ETag:         cached.etag,
+				LastModified: cached.lastModified,
+			}
+		}
+
+		c.cfg.validators.observe(req, res)
+	}
+
+	upgraded := res.StatusCode == http.StatusSwitchingProtocols
+
+	if c.cfg.ErrorOnNon2xx && !upgraded && (res.StatusCode < 200 || res.StatusCode > 299) {
+		var httpErr *HTTPError
+
+		if c.cfg.CaptureErrorBodies {
+			httpErr = captureHTTPError(res, c.cfg.MaxErrorBodySize)
+		} else {
+			httpErr = newHTTPError(res, c.cfg.MaxErrorBodySize)
+		}
+
+		if httpErr.Truncated && httpErr.Response != nil {
+			// the remainder of the body is read through the request context
+			res.Body = newCancelOnCloseBody(ctx, cancel, res.Body)
+		} else {
+			defer cancel()
+		}
+
+		if rec != nil {
+			c.writeFailureArtifact(req, rec, httpErr)
+		}
+
+		return nil, httpErr
+	}
+
+	if cfg.Progress != nil && !upgraded {
+		res.Body = &progressBody{
+			ReadCloser: res.Body,
+			fn:         cfg.Progress,
+			total:      res.ContentLength,
+		}
+	}
+
+	// the request context must remain valid until the body is consumed
+	if c.cfg.leakDetector != nil {
+		// the transport keeps its response reachable until the body
+		// is closed so the tracked body is only set on a copy
+		out := *res
+		out.Body = c.cfg.leakDetector.track(ctx, cancel, res.Body)
+
+		return &out, nil
+	}
+
+	res.Body = newCancelOnCloseBody(ctx, cancel, res.Body)
+
+	return res, nil
+}
+
+// newCancelOnCloseBody returns a body which calls cancel once closed.
+// Bodies of switching protocols responses remain writable and spooled
+// bodies remain seekable.
+func newCancelOnCloseBody(ctx context.Context, cancel context.CancelFunc, body io.ReadCloser) io.ReadCloser {
+	wrapped := &cancelOnCloseBody{
+		ReadCloser: body,
+		ctx:        ctx,
+		cancel:     cancel,
+	}
+
+	switch b := body.(type) {
+	case io.Writer:
+		return &cancelOnCloseConn{
+			cancelOnCloseBody: wrapped,
+			Writer:            b,
+		}
+	case io.Seeker:
+		return &cancelOnCloseSeeker{
+			cancelOnCloseBody: wrapped,
+			Seeker:            b,
+		}
+	}
+
+	return wrapped
+}
+
+type cancelOnCloseBody struct {
+	io.ReadCloser
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+func (b *cancelOnCloseBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if err != nil && err != io.EOF {
+		err = withCancelCause(b.ctx, err)
+	}
+
+	return n, err
+}
+
+func (b *cancelOnCloseBody) Close() error {
+	defer b.cancel()
+
+	return b.ReadCloser.Close()
+}
+
+// cancelOnCloseConn is a cancelOnCloseBody exposing
+// the connection of a switching protocols response.
+type cancelOnCloseConn struct {
+	*cancelOnCloseBody
+	io.Writer
+}
+
+// cancelOnCloseSeeker is a cancelOnCloseBody
+// exposing the Seek method of a spooled body.
+type cancelOnCloseSeeker struct {
+	*cancelOnCloseBody
+	io.Seeker
 }
 
 type ClientConfig struct {
 	Transport http.RoundTripper
 	Wrappers  []TransportWrapper
+	// Middlewares are applied outside all Wrappers with
+	// the first middleware seeing the request first.
+	Middlewares []Middleware
+	// BaseURL is used to resolve relative request URLs.
+	BaseURL *url.URL
+	// Header is added to every request unless the
+	// request sets a header with the same key.
+	Header http.Header
+	// TLSConfig is applied to the http.Transport constructed
+	// when no Transport has been provided.
+	TLSConfig *tls.Config
+	// Timeout limits the total time taken by a request
+	// including reading the response body.
+	Timeout time.Duration
+	// DialTimeout limits the time taken to establish a connection.
+	DialTimeout time.Duration
+	// TCPKeepAlive is the interval between keep-alive probes
+	// on idle connections. Negative values disable them.
+	TCPKeepAlive time.Duration
+	// TLSHandshakeTimeout limits the time taken by the TLS handshake.
+	TLSHandshakeTimeout time.Duration
+	// ResponseHeaderTimeout limits the time spent waiting for
+	// response headers after the request has been written.
+	ResponseHeaderTimeout time.Duration
+	// Proxy selects the proxy used for a given request. If
+	// unset proxies are selected from the environment.
+	Proxy func(*http.Request) (*url.URL, error)
+	// NoProxy lists hosts for which the proxy is bypassed.
+	NoProxy []string
+	// ProxyConnectHeader is sent to proxies in CONNECT requests.
+	ProxyConnectHeader http.Header
+	// BypassWrappers lists hosts for which requests are sent
+	// directly through Transport skipping all Wrappers
+	// and Middlewares.
+	BypassWrappers []string
+	// ErrorOnNon2xx causes an *HTTPError to be returned for
+	// responses with a status code outside of the 2xx range.
+	ErrorOnNon2xx bool
+	// MaxErrorBodySize limits the portion of the response
+	// body captured by an *HTTPError.
+	MaxErrorBodySize int64
+	// CaptureErrorBodies causes the body captured by an *HTTPError
+	// to be included in its message and the response to be retained.
+	CaptureErrorBodies bool
+	// RedirectPolicy decides whether redirects are followed.
+	RedirectPolicy RedirectPolicy
+	// HTTP2 configures the use of HTTP/2.
+	HTTP2 HTTP2Config
+	// FailureArtifactDir is the directory diagnostic bundles
+	// for failed requests are written to.
+	FailureArtifactDir string
+	// MaxArtifactBodySize limits the portion of the response
+	// body included in a diagnostic bundle.
+	MaxArtifactBodySize int64
+
+	// Resolver is used by the dialer to resolve host names.
+	Resolver *net.Resolver
+	// DNSCacheTTL is the time host name lookups are cached.
+	DNSCacheTTL time.Duration
+	// DNSCacheClock measures DNSCacheTTL. Defaults to RealClock.
+	DNSCacheClock Clock
+	// LookupHost resolves host names in place of Resolver.
+	LookupHost func(ctx context.Context, host string) ([]string, error)
+	// DialContext establishes connections in place of
+	// a dialer configured from the settings above.
+	DialContext DialContextFunc
+	// RequireHTTPS causes requests using any scheme other
+	// than https to fail unless their host is listed
+	// in InsecureHosts.
+	RequireHTTPS bool
+	// InsecureHosts lists hosts exempt from RequireHTTPS.
+	InsecureHosts []string
+	// Redactor redacts URLs and headers before they are logged
+	// or reported. Defaults to the Redactor returned by NewRedactor.
+	Redactor *Redactor
+	// IdleHostTTL is the time after which state held
+	// for unused hosts is released.
+	IdleHostTTL time.Duration
+	// IdleReapInterval is the period at which
+	// idle hosts are reaped.
+	IdleReapInterval time.Duration
+	// EventSinks receive the LifecycleEvents of requests.
+	EventSinks []EventSink
+	// ProtoCodec encodes and decodes protobuf bodies.
+	ProtoCodec ProtoCodec
+
+	registered     []NamedWrapper
+	wrapperHooks   []wrapperHook
+	artifactLogger logr.Logger
+	leakDetector   *leakDetector
+	validators     *validatorStore
+	dnsDialer      *dnsDialer
+	dohResolver    *dohResolver
+	probing        *WithConnectionProbing
+	// caDirectory verifies server certificates
+	// if configured through WithCADirectory.
+	caDirectory *caDirectoryLoader
+	stats       *clientStats
 }
 
 func (c *ClientConfig) Option(opts ...ClientOption) {
@@ -101,19 +519,224 @@ func (c *ClientConfig) Option(opts ...ClientOption) {
 }
 
 func (c *ClientConfig) Default() {
+	c.resolveWrappers()
+
+	if c.stats == nil {
+		c.stats = &clientStats{}
+	}
+
 	if c.Transport == nil {
-		c.Transport = http.DefaultTransport
+		c.Transport = c.defaultTransport()
+	}
+
+	if c.MaxErrorBodySize <= 0 {
+		c.MaxErrorBodySize = defaultMaxErrorBodySize
+	}
+
+	if c.MaxArtifactBodySize <= 0 {
+		c.MaxArtifactBodySize = defaultMaxArtifactBodySize
+	}
+}
+
+// defaultTransport returns http.DefaultTransport unless transport
+// level settings have been configured in which case a clone of
+// http.DefaultTransport with those settings applied is returned.
+func (c *ClientConfig) defaultTransport() http.RoundTripper {
+	if !c.hasTransportSettings() {
+		return http.DefaultTransport
+	}
+
+	tp := http.DefaultTransport.(*http.Transport).Clone()
+
+	if c.TLSConfig != nil {
+		tp.TLSClientConfig = c.TLSConfig
+	}
+
+	guarded := c.egressGuarded()
+
+	if c.DialTimeout > 0 || c.TCPKeepAlive != 0 || c.Resolver != nil || c.DNSCacheTTL > 0 || c.LookupHost != nil || guarded {
+		dialer := &net.Dialer{
+			Timeout:   c.DialTimeout,
+			KeepAlive: 30 * time.Second,
+			Resolver:  c.Resolver,
+		}
+
+		if c.TCPKeepAlive != 0 {
+			dialer.KeepAlive = c.TCPKeepAlive
+		}
+
+		if dialer.Timeout <= 0 {
+			dialer.Timeout = 30 * time.Second
+		}
+
+		if guarded {
+			dialer.ControlContext = egressControl
+		}
+
+		tp.DialContext = dialer.DialContext
+
+		if c.DNSCacheTTL > 0 || c.LookupHost != nil {
+			c.dnsDialer = newDNSDialer(dialer, c.DNSCacheTTL, c.DNSCacheClock)
+			tp.DialContext = c.dnsDialer.DialContext
+
+			if c.LookupHost != nil {
+				c.dnsDialer.lookup = c.LookupHost
+			}
+		}
+	}
+
+	if c.DialContext != nil {
+		tp.DialContext = c.DialContext
+	}
+
+	tp.DialContext = c.stats.countConnections(tp.DialContext)
+
+	if guarded {
+		tp.DialContext = guardEgress(tp.DialContext)
+	}
+
+	if c.caDirectory != nil {
+		tp.DialTLSContext = c.caDirectory.dialTLS(tp, c.TLSHandshakeTimeout)
+	}
+
+	if c.TLSHandshakeTimeout > 0 {
+		tp.TLSHandshakeTimeout = c.TLSHandshakeTimeout
+	}
+
+	if c.ResponseHeaderTimeout > 0 {
+		tp.ResponseHeaderTimeout = c.ResponseHeaderTimeout
+	}
+
+	tp.Proxy = c.proxyFunc()
+
+	if c.ProxyConnectHeader != nil {
+		tp.ProxyConnectHeader = c.ProxyConnectHeader
+	}
+
+	if c.IdleHostTTL > 0 {
+		tp.IdleConnTimeout = c.IdleHostTTL
 	}
+
+	return c.configureHTTP2(tp)
+}
+
+func (c *ClientConfig) hasTransportSettings() bool {
+	return c.TLSConfig != nil ||
+		c.DialTimeout > 0 ||
+		c.TCPKeepAlive != 0 ||
+		c.TLSHandshakeTimeout > 0 ||
+		c.ResponseHeaderTimeout > 0 ||
+		c.Proxy != nil ||
+		len(c.NoProxy) > 0 ||
+		c.ProxyConnectHeader != nil ||
+		c.IdleHostTTL > 0 ||
+		c.Resolver != nil ||
+		c.DNSCacheTTL > 0 ||
+		c.LookupHost != nil ||
+		c.DialContext != nil ||
+		c.HTTP2 != (HTTP2Config{}) ||
+		c.egressGuarded()
+}
+
+// tlsConfig returns the TLSConfig of the ClientConfig
+// initializing it first if necessary.
+func (c *ClientConfig) tlsConfig() *tls.Config {
+	if c.TLSConfig == nil {
+		c.TLSConfig = &tls.Config{
+			MinVersion: tls.VersionTLS12,
+		}
+	}
+
+	return c.TLSConfig
 }
 
 func (c *ClientConfig) Wrap(client *http.Client) {
-	tp := c.Transport
+	base := c.baseTransport()
+
+	client.Transport = c.wrap(base, base)
+}
+
+// baseTransport returns the transport beneath all Wrappers.
+func (c *ClientConfig) baseTransport() *overridableTransport {
+	return &overridableTransport{
+		RoundTripper:  c.Transport,
+		requireHTTPS:  c.RequireHTTPS,
+		insecureHosts: c.InsecureHosts,
+		stats:         c.stats,
+	}
+}
+
+// wrap applies the Wrappers and Middlewares to tp. Requests for
+// BypassWrappers hosts are sent through direct instead.
+func (c *ClientConfig) wrap(tp, direct http.RoundTripper) http.RoundTripper {
+	for _, w := range c.Wrappers {
+		tp = w.Wrap(tp)
+	}
+
+	if len(c.Middlewares) > 0 {
+		tp = Chain(tp.RoundTrip, c.Middlewares...)
+	}
 
 	for _, w := range c.Wrappers {
-		w.Wrap(tp)
+		if w, ok := w.(chainedWrapper); ok {
+			w.useChain(tp)
+		}
+	}
+
+	if len(c.BypassWrappers) > 0 && (len(c.Wrappers) > 0 || len(c.Middlewares) > 0) {
+		tp = &bypassTransport{
+			hosts:   c.BypassWrappers,
+			direct:  direct,
+			wrapped: tp,
+		}
+	}
+
+	return tp
+}
+
+// chainedWrapper is implemented by wrappers which send requests
+// of their own through all Wrappers and Middlewares of the Client.
+type chainedWrapper interface {
+	useChain(http.RoundTripper)
+}
+
+type transportOverrideKey struct{}
+
+// overridableTransport sits beneath all TransportWrappers and
+// substitutes the transport provided through WithRequestTransport
+// for the configured transport. It also enforces WithRequireHTTPS
+// so that no wrapper can send a request over plain HTTP.
+type overridableTransport struct {
+	http.RoundTripper
+	requireHTTPS  bool
+	insecureHosts []string
+	stats         *clientStats
+}
+
+func (t *overridableTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.requireHTTPS && req.URL.Scheme != "https" && !matchHost(t.insecureHosts, req.URL.Hostname()) {
+		if req.Body != nil {
+			req.Body.Close()
+		}
+
+		return nil, fmt.Errorf("%w: %s", ErrHTTPSRequired, redactURL(req.Context(), req.URL))
+	}
+
+	rt := t.RoundTripper
+
+	if override, ok := req.Context().Value(transportOverrideKey{}).(http.RoundTripper); ok {
+		rt = override
+	}
+
+	if t.stats != nil {
+		return t.stats.roundTrip(rt, req)
 	}
 
-	client.Transport = tp
+	return rt.RoundTrip(req)
+}
+
+func (t *overridableTransport) CloseIdleConnections() {
+	closeIdleConnections(t.RoundTripper)
 }
 
 type ClientOption interface {
@@ -131,11 +754,43 @@ func (t WithTransport) ConfigureClient(c *ClientConfig) {
 // WithWrapper configures a Client instance with the given
 // TransportWrapper. This option can be provided multiple
 // times to apply several TransportWrappers. The order in
-// which the TransportWrappers is applied is important!
+// which the TransportWrappers is applied is important! Use
+// WithNamedWrapper to order wrappers explicitly.
 type WithWrapper struct{ TransportWrapper }
 
 func (ww WithWrapper) ConfigureClient(c *ClientConfig) {
 	c.Wrappers = append(c.Wrappers, ww.TransportWrapper)
+	c.register(NamedWrapper{Wrapper: ww.TransportWrapper})
+}
+
+// WithBypassWrappers configures a Client instance to send requests
+// for the given hosts directly through the underlying transport
+// skipping all TransportWrappers. This is intended for loopback or
+// cluster-internal destinations where wrappers such as retries add
+// latency without value. Hosts are matched using the same rules as
+// WithNoProxy. This option can be provided multiple times.
+type WithBypassWrappers []string
+
+func (bw WithBypassWrappers) ConfigureClient(c *ClientConfig) {
+	c.BypassWrappers = append(c.BypassWrappers, bw...)
+}
+
+// LoopbackHosts matches all loopback destinations and
+// may be used with WithBypassWrappers.
+var LoopbackHosts = WithBypassWrappers{"localhost", "127.0.0.0/8", "::1"}
+
+type bypassTransport struct {
+	hosts   []string
+	direct  http.RoundTripper
+	wrapped http.RoundTripper
+}
+
+func (t *bypassTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if matchHost(t.hosts, req.URL.Hostname()) {
+		return t.direct.RoundTrip(req)
+	}
+
+	return t.wrapped.RoundTrip(req)
 }
 
 // TransportWrapper adds functionality to a http.RoundTripper
@@ -1,14 +1,17 @@
 package client
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
 	"testing"
 
 	"github.com/mt-sre/client/internal/testutils"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 )
 
@@ -209,3 +212,247 @@ func TestClientPatch(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, "test\n", string(body))
 }
+
+// TestClientRequestBody ensures that methods accepting a body
+// send it rather than an empty request body.
+func TestClientRequestBody(t *testing.T) {
+	t.Parallel()
+
+	type method func(c *Client, ctx context.Context, url string, body io.Reader, opts ...RequestOption) (*http.Response, error)
+
+	for name, tc := range map[string]struct {
+		Method method
+	}{
+		http.MethodPost:    {Method: (*Client).Post},
+		http.MethodPut:     {Method: (*Client).Put},
+		http.MethodPatch:   {Method: (*Client).Patch},
+		http.MethodConnect: {Method: (*Client).Connect},
+	} {
+		tc := tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			c := NewClient(WithTransport{RoundTripper: Handler(func(req *http.Request) (*http.Response, error) {
+				assert.Equal(t, name, req.Method)
+
+				body, err := io.ReadAll(req.Body)
+				require.NoError(t, err)
+				assert.Equal(t, "payload", string(body))
+
+				return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody, Request: req}, nil
+			})})
+			defer c.Close()
+
+			res, err := tc.Method(c, context.Background(), "https://api.example.com/items", strings.NewReader("payload"))
+			require.NoError(t, err)
+			require.NoError(t, res.Body.Close())
+		})
+	}
+}
+
+// TestClientWrappers ensures that configured TransportWrappers
+// are applied to requests made by the client.
+func TestClientWrappers(t *testing.T) {
+	t.Parallel()
+
+	mrt := &testutils.MockRoundTripper{}
+
+	mrt.
+		On("RoundTrip", mock.Anything).
+		Return(&http.Response{
+			StatusCode: http.StatusServiceUnavailable,
+			Body:       io.NopCloser(bytes.NewBuffer(nil)),
+		}, nil).
+		Times(2)
+
+	client := NewClient(
+		WithTransport{RoundTripper: mrt},
+		WithWrapper{TransportWrapper: NewRetryWrapper(
+			WithBackoffGenerator(NoBackoffGenerator()),
+			WithMaxRetries(1),
+		)},
+	)
+
+	res, err := client.Get(context.Background(), "http://example.com")
+	require.NoError(t, err)
+	defer res.Body.Close()
+
+	mrt.AssertExpectations(t)
+}
+
+// TestClientWrapperOrder ensures that the RoundTripper returned by
+// each TransportWrapper is used with the first wrapper applied
+// closest to the transport.
+func TestClientWrapperOrder(t *testing.T) {
+	t.Parallel()
+
+	var order []string
+
+	record := func(name string) TransportWrapper {
+		return MiddlewareWrapper(func(next Handler) Handler {
+			return func(req *http.Request) (*http.Response, error) {
+				order = append(order, name)
+
+				return next(req)
+			}
+		})
+	}
+
+	c := NewClient(
+		WithTransport{RoundTripper: Handler(func(req *http.Request) (*http.Response, error) {
+			order = append(order, "transport")
+
+			return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody, Request: req}, nil
+		})},
+		WithWrapper{TransportWrapper: record("inner")},
+		WithWrapper{TransportWrapper: record("outer")},
+	)
+	defer c.Close()
+
+	res, err := c.Get(context.Background(), "https://api.example.com/items")
+	require.NoError(t, err)
+	require.NoError(t, res.Body.Close())
+
+	assert.Equal(t, []string{"outer", "inner", "transport"}, order)
+}
+
+// TestWithBypassWrappers ensures that requests to configured hosts
+// skip TransportWrappers while other requests are still wrapped.
+func TestWithBypassWrappers(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		URL           string
+		ExpectedCalls int
+	}{
+		"bypassed loopback": {
+			URL:           "http://127.0.0.1:8080/status",
+			ExpectedCalls: 1,
+		},
+		"bypassed localhost": {
+			URL:           "http://localhost/status",
+			ExpectedCalls: 1,
+		},
+		"bypassed internal": {
+			URL:           "http://api.svc.cluster.local/status",
+			ExpectedCalls: 1,
+		},
+		"wrapped": {
+			URL:           "http://example.com/status",
+			ExpectedCalls: 2,
+		},
+	} {
+		tc := tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			mrt := &testutils.MockRoundTripper{}
+
+			mrt.
+				On("RoundTrip", mock.Anything).
+				Return(&http.Response{
+					StatusCode: http.StatusServiceUnavailable,
+					Body:       io.NopCloser(bytes.NewBuffer(nil)),
+				}, nil).
+				Times(tc.ExpectedCalls)
+
+			client := NewClient(
+				WithTransport{RoundTripper: mrt},
+				WithWrapper{TransportWrapper: NewRetryWrapper(
+					WithBackoffGenerator(NoBackoffGenerator()),
+					WithMaxRetries(1),
+				)},
+				LoopbackHosts,
+				WithBypassWrappers{".cluster.local"},
+			)
+
+			res, err := client.Get(context.Background(), tc.URL)
+			require.NoError(t, err)
+			defer res.Body.Close()
+
+			mrt.AssertExpectations(t)
+		})
+	}
+}
+
+// TestWithRequestTransport ensures that a single request can be sent
+// through a different transport while still applying client wrappers.
+func TestWithRequestTransport(t *testing.T) {
+	t.Parallel()
+
+	var clientRT testutils.MockRoundTripper
+
+	var requestRT testutils.MockRoundTripper
+	requestRT.
+		On("RoundTrip", mock.Anything).
+		Return(&http.Response{
+			StatusCode: http.StatusServiceUnavailable,
+			Body:       io.NopCloser(bytes.NewBuffer(nil)),
+		}, nil).
+		Times(2)
+
+	client := NewClient(
+		WithTransport{RoundTripper: &clientRT},
+		WithWrapper{TransportWrapper: NewRetryWrapper(
+			WithBackoffGenerator(NoBackoffGenerator()),
+			WithMaxRetries(1),
+		)},
+	)
+
+	res, err := client.Get(context.Background(), "http://example.com", WithRequestTransport{RoundTripper: &requestRT})
+	require.NoError(t, err)
+	defer res.Body.Close()
+
+	requestRT.AssertExpectations(t)
+	clientRT.AssertNotCalled(t, "RoundTrip", mock.Anything)
+}
+
+// TestClientDo ensures requests built outside the Client are sent
+// through its wrappers with client level settings applied.
+func TestClientDo(t *testing.T) {
+	t.Parallel()
+
+	var sent []*http.Request
+
+	mrt := &testutils.MockRoundTripper{}
+	mrt.
+		On("RoundTrip", mock.Anything).
+		Run(func(args mock.Arguments) {
+			sent = append(sent, args.Get(0).(*http.Request))
+		}).
+		Return(&http.Response{StatusCode: http.StatusServiceUnavailable, Body: http.NoBody}, nil)
+
+	c := NewClient(
+		WithTransport{RoundTripper: mrt},
+		WithWrapper{TransportWrapper: NewRetryWrapper(
+			WithBackoffGenerator(NoBackoffGenerator()),
+			WithMaxRetries(1),
+		)},
+		WithDefaultHeaders{"X-Tenant": {"a"}},
+		WithErrorOnNon2xx{},
+	)
+	defer c.Close()
+
+	req, err := http.NewRequest(http.MethodGet, "https://api.example.com/items", nil)
+	require.NoError(t, err)
+
+	_, err = c.Do(req)
+
+	var httpErr *HTTPError
+
+	require.ErrorAs(t, err, &httpErr)
+	assert.Equal(t, http.StatusServiceUnavailable, httpErr.StatusCode)
+
+	require.Len(t, sent, 2)
+	assert.Equal(t, "a", sent[0].Header.Get("X-Tenant"))
+	assert.Empty(t, req.Header.Get("X-Tenant"))
+
+	res, err := c.StdClient().Do(req)
+	require.NoError(t, err)
+	require.NoError(t, res.Body.Close())
+
+	assert.Equal(t, http.StatusServiceUnavailable, res.StatusCode)
+	assert.Len(t, sent, 4)
+}
@@ -0,0 +1,186 @@
+package clientmock
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mt-sre/client"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMock(t *testing.T) {
+	t.Parallel()
+
+	m := &Mock{}
+	m.
+		On("Get", mock.Anything, "https://example.com", mock.Anything).
+		Return(&http.Response{StatusCode: http.StatusOK}, nil).
+		Once()
+	m.
+		On("Post", mock.Anything, "https://example.com", mock.Anything, mock.Anything).
+		Return(nil, errors.New("unavailable")).
+		Once()
+
+	var c client.ClientInterface = m
+
+	res, err := c.Get(context.Background(), "https://example.com", client.WithRequestTimeout(time.Second))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+
+	res, err = c.Post(context.Background(), "https://example.com", strings.NewReader("body"))
+	assert.Error(t, err)
+	assert.Nil(t, res)
+
+	m.AssertExpectations(t)
+}
+
+func TestFake(t *testing.T) {
+	t.Parallel()
+
+	errUnavailable := errors.New("unavailable")
+
+	f := (&Fake{}).
+		Respond(http.StatusCreated, "created").
+		Fail(errUnavailable).
+		RespondWithHeader(http.StatusOK, http.Header{"Etag": []string{`"v1"`}}, "ok")
+
+	var c client.ClientInterface = f
+
+	ctx := context.Background()
+
+	res, err := c.Post(ctx, "https://example.com/items", strings.NewReader("item"),
+		client.WithRequestHeaders{"X-Tenant": []string{"a"}},
+	)
+	require.NoError(t, err)
+
+	body, err := io.ReadAll(res.Body)
+	require.NoError(t, err)
+	require.NoError(t, res.Body.Close())
+
+	assert.Equal(t, http.StatusCreated, res.StatusCode)
+	assert.Equal(t, "created", string(body))
+	assert.Equal(t, http.MethodPost, res.Request.Method)
+
+	_, err = c.Get(ctx, "https://example.com/items/1")
+	assert.ErrorIs(t, err, errUnavailable)
+
+	res, err = c.PostForm(ctx, "https://example.com/form", url.Values{"a": []string{"1"}})
+	require.NoError(t, err)
+	require.NoError(t, res.Body.Close())
+
+	assert.Equal(t, `"v1"`, res.Header.Get("ETag"))
+
+	_, err = c.Delete(ctx, "https://example.com/items/1")
+	assert.ErrorIs(t, err, ErrNoResponse)
+
+	assert.Zero(t, f.Pending())
+	assert.Equal(t, []Request{
+		{
+			Method: http.MethodPost,
+			URL:    "https://example.com/items",
+			Header: http.Header{"X-Tenant": []string{"a"}},
+			Body:   []byte("item"),
+		},
+		{
+			Method: http.MethodGet,
+			URL:    "https://example.com/items/1",
+		},
+		{
+			Method: http.MethodPost,
+			URL:    "https://example.com/form",
+			Header: http.Header{"Content-Type": []string{"application/x-www-form-urlencoded"}},
+			Body:   []byte("a=1"),
+			Form:   url.Values{"a": []string{"1"}},
+		},
+		{
+			Method: http.MethodDelete,
+			URL:    "https://example.com/items/1",
+		},
+	}, f.Requests())
+}
+
+func TestFakeCanceledContext(t *testing.T) {
+	t.Parallel()
+
+	f := (&Fake{}).Respond(http.StatusOK, "")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := f.Get(ctx, "https://example.com")
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, 1, f.Pending(), "canceled requests must not consume responses")
+}
+
+func TestFakeMultipart(t *testing.T) {
+	t.Parallel()
+
+	f := (&Fake{}).Respond(http.StatusOK, "")
+
+	res, err := f.PostMultipart(context.Background(), "https://example.com/upload", url.Values{"name": []string{"report"}}, []client.MultipartFile{
+		{FieldName: "file", FileName: "report.csv", ContentType: "text/csv", Content: strings.NewReader("a,b")},
+	})
+	require.NoError(t, err)
+	require.NoError(t, res.Body.Close())
+
+	requests := f.Requests()
+	require.Len(t, requests, 1)
+
+	assert.Equal(t, url.Values{"name": []string{"report"}}, requests[0].Form)
+	assert.Equal(t, []File{
+		{FieldName: "file", FileName: "report.csv", ContentType: "text/csv", Content: []byte("a,b")},
+	}, requests[0].Files)
+}
+
+func TestFakeDownload(t *testing.T) {
+	t.Parallel()
+
+	f := (&Fake{}).
+		Respond(http.StatusOK, "content").
+		Respond(http.StatusNotFound, "")
+
+	path := filepath.Join(t.TempDir(), "download")
+
+	require.NoError(t, f.DownloadFile(context.Background(), "https://example.com/file", path))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "content", string(data))
+
+	var buf strings.Builder
+
+	assert.Error(t, f.Download(context.Background(), "https://example.com/missing", &buf))
+}
+
+func TestFakeDo(t *testing.T) {
+	t.Parallel()
+
+	f := (&Fake{}).Respond(http.StatusAccepted, "")
+
+	req, err := http.NewRequest(http.MethodPut, "https://example.com/items/1", strings.NewReader("item"))
+	require.NoError(t, err)
+
+	req.Header.Set("X-Tenant", "a")
+
+	res, err := f.Do(req)
+	require.NoError(t, err)
+	require.NoError(t, res.Body.Close())
+
+	assert.Equal(t, http.StatusAccepted, res.StatusCode)
+	assert.Equal(t, []Request{{
+		Method: http.MethodPut,
+		URL:    "https://example.com/items/1",
+		Header: http.Header{"X-Tenant": []string{"a"}},
+		Body:   []byte("item"),
+	}}, f.Requests())
+}
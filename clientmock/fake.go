@@ -0,0 +1,281 @@
+package clientmock
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/mt-sre/client"
+)
+
+// ErrNoResponse is returned by a Fake which has
+// no canned responses left.
+var ErrNoResponse = errors.New("no canned response")
+
+// Fake is a programmable client.ClientInterface which answers requests
+// with canned responses in the order they were queued and records every
+// request it receives. The zero value is ready for use and a Fake is
+// safe for concurrent use.
+type Fake struct {
+	mu        sync.Mutex
+	responses []cannedResponse
+	requests  []Request
+}
+
+var _ client.ClientInterface = (*Fake)(nil)
+
+type cannedResponse struct {
+	status int
+	header http.Header
+	body   []byte
+	err    error
+}
+
+// Request is a request recorded by a Fake.
+type Request struct {
+	Method string
+	URL    string
+	// Header holds headers added by request options.
+	Header http.Header
+	Body   []byte
+	// Form holds the values passed to PostForm or PostMultipart.
+	Form  url.Values
+	Files []File
+}
+
+// File is a file part passed to PostMultipart.
+type File struct {
+	FieldName   string
+	FileName    string
+	ContentType string
+	Content     []byte
+}
+
+// Respond queues a response with the given status code and body.
+func (f *Fake) Respond(status int, body string) *Fake {
+	return f.RespondWithHeader(status, nil, body)
+}
+
+// RespondWithHeader queues a response with the given
+// status code, headers and body.
+func (f *Fake) RespondWithHeader(status int, header http.Header, body string) *Fake {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.responses = append(f.responses, cannedResponse{
+		status: status,
+		header: header,
+		body:   []byte(body),
+	})
+
+	return f
+}
+
+// Fail queues an error to be returned instead of a response.
+func (f *Fake) Fail(err error) *Fake {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.responses = append(f.responses, cannedResponse{err: err})
+
+	return f
+}
+
+// Requests returns the requests received in the order they were made.
+func (f *Fake) Requests() []Request {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return append([]Request(nil), f.requests...)
+}
+
+// Pending returns the number of canned responses not yet consumed.
+func (f *Fake) Pending() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return len(f.responses)
+}
+
+func (f *Fake) Get(ctx context.Context, url string, opts ...client.RequestOption) (*http.Response, error) {
+	return f.do(ctx, Request{Method: http.MethodGet, URL: url}, nil, opts)
+}
+
+func (f *Fake) Head(ctx context.Context, url string, opts ...client.RequestOption) (*http.Response, error) {
+	return f.do(ctx, Request{Method: http.MethodHead, URL: url}, nil, opts)
+}
+
+func (f *Fake) Post(ctx context.Context, url string, body io.Reader, opts ...client.RequestOption) (*http.Response, error) {
+	return f.do(ctx, Request{Method: http.MethodPost, URL: url}, body, opts)
+}
+
+func (f *Fake) Put(ctx context.Context, url string, body io.Reader, opts ...client.RequestOption) (*http.Response, error) {
+	return f.do(ctx, Request{Method: http.MethodPut, URL: url}, body, opts)
+}
+
+func (f *Fake) Patch(ctx context.Context, url string, body io.Reader, opts ...client.RequestOption) (*http.Response, error) {
+	return f.do(ctx, Request{Method: http.MethodPatch, URL: url}, body, opts)
+}
+
+func (f *Fake) Delete(ctx context.Context, url string, opts ...client.RequestOption) (*http.Response, error) {
+	return f.do(ctx, Request{Method: http.MethodDelete, URL: url}, nil, opts)
+}
+
+func (f *Fake) Connect(ctx context.Context, url string, body io.Reader, opts ...client.RequestOption) (*http.Response, error) {
+	return f.do(ctx, Request{Method: http.MethodConnect, URL: url}, body, opts)
+}
+
+func (f *Fake) Options(ctx context.Context, url string, opts ...client.RequestOption) (*http.Response, error) {
+	return f.do(ctx, Request{Method: http.MethodOptions, URL: url}, nil, opts)
+}
+
+func (f *Fake) Trace(ctx context.Context, url string, opts ...client.RequestOption) (*http.Response, error) {
+	return f.do(ctx, Request{Method: http.MethodTrace, URL: url}, nil, opts)
+}
+
+func (f *Fake) PostForm(ctx context.Context, url string, data url.Values, opts ...client.RequestOption) (*http.Response, error) {
+	req := Request{Method: http.MethodPost, URL: url, Form: data}
+
+	opts = append([]client.RequestOption{
+		client.WithRequestHeaders{"Content-Type": []string{"application/x-www-form-urlencoded"}},
+	}, opts...)
+
+	return f.do(ctx, req, strings.NewReader(data.Encode()), opts)
+}
+
+func (f *Fake) PostMultipart(ctx context.Context, url string, fields url.Values, files []client.MultipartFile, opts ...client.RequestOption) (*http.Response, error) {
+	req := Request{Method: http.MethodPost, URL: url, Form: fields}
+
+	for _, file := range files {
+		content, err := io.ReadAll(file.Content)
+		if err != nil {
+			return nil, fmt.Errorf("reading file %q: %w", file.FileName, err)
+		}
+
+		req.Files = append(req.Files, File{
+			FieldName:   file.FieldName,
+			FileName:    file.FileName,
+			ContentType: file.ContentType,
+			Content:     content,
+		})
+	}
+
+	return f.do(ctx, req, nil, opts)
+}
+
+// Download writes the body of the next canned response to w.
+// Responses with status codes other than 200 OK fail.
+func (f *Fake) Download(ctx context.Context, url string, w io.Writer, _ ...client.DownloadOption) error {
+	res, err := f.do(ctx, Request{Method: http.MethodGet, URL: url}, nil, nil)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d", res.StatusCode)
+	}
+
+	_, err = io.Copy(w, res.Body)
+
+	return err
+}
+
+// DownloadFile writes the body of the next canned response to
+// the file at path. Responses with status codes other than
+// 200 OK fail.
+func (f *Fake) DownloadFile(ctx context.Context, url, path string, opts ...client.DownloadOption) error {
+	var buf bytes.Buffer
+
+	if err := f.Download(ctx, url, &buf, opts...); err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, buf.Bytes(), 0o644)
+}
+
+// Do records req reading its body.
+func (f *Fake) Do(req *http.Request) (*http.Response, error) {
+	var body io.Reader
+
+	if req.Body != nil {
+		defer req.Body.Close()
+
+		body = req.Body
+	}
+
+	opts := []client.RequestOption{client.WithRequestHeaders(req.Header)}
+
+	return f.do(req.Context(), Request{Method: req.Method, URL: req.URL.String()}, body, opts)
+}
+
+func (f *Fake) do(ctx context.Context, req Request, body io.Reader, opts []client.RequestOption) (*http.Response, error) {
+	var cfg client.RequestConfig
+
+	cfg.Option(opts...)
+
+	req.Header = cfg.Header
+
+	if body != nil {
+		data, err := io.ReadAll(body)
+		if err != nil {
+			return nil, fmt.Errorf("reading request body: %w", err)
+		}
+
+		req.Body = data
+	}
+
+	f.mu.Lock()
+
+	f.requests = append(f.requests, req)
+
+	if err := ctx.Err(); err != nil {
+		f.mu.Unlock()
+
+		return nil, err
+	}
+
+	if len(f.responses) == 0 {
+		f.mu.Unlock()
+
+		return nil, fmt.Errorf("%w for %s %s", ErrNoResponse, req.Method, req.URL)
+	}
+
+	canned := f.responses[0]
+	f.responses = f.responses[1:]
+
+	f.mu.Unlock()
+
+	if canned.err != nil {
+		return nil, canned.err
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, req.Method, req.URL, bytes.NewReader(req.Body))
+	if err != nil {
+		return nil, err
+	}
+
+	header := canned.header.Clone()
+	if header == nil {
+		header = make(http.Header)
+	}
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", canned.status, http.StatusText(canned.status)),
+		StatusCode:    canned.status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(canned.body)),
+		ContentLength: int64(len(canned.body)),
+		Request:       httpReq,
+	}, nil
+}
@@ -0,0 +1,87 @@
+// Package clientmock provides test doubles for code which depends
+// on client.ClientInterface.
+package clientmock
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+
+	"github.com/mt-sre/client"
+	"github.com/stretchr/testify/mock"
+)
+
+// Mock is a testify based mock of client.ClientInterface. Request
+// options are passed to Called as a single slice argument so that
+// expectations can be written as:
+//
+//	m.On("Get", mock.Anything, "https://example.com", mock.Anything).
+//		Return(&http.Response{StatusCode: http.StatusOK}, nil)
+type Mock struct {
+	mock.Mock
+}
+
+var _ client.ClientInterface = (*Mock)(nil)
+
+func (m *Mock) Get(ctx context.Context, url string, opts ...client.RequestOption) (*http.Response, error) {
+	return m.response(m.Called(ctx, url, opts))
+}
+
+func (m *Mock) Head(ctx context.Context, url string, opts ...client.RequestOption) (*http.Response, error) {
+	return m.response(m.Called(ctx, url, opts))
+}
+
+func (m *Mock) Post(ctx context.Context, url string, body io.Reader, opts ...client.RequestOption) (*http.Response, error) {
+	return m.response(m.Called(ctx, url, body, opts))
+}
+
+func (m *Mock) Put(ctx context.Context, url string, body io.Reader, opts ...client.RequestOption) (*http.Response, error) {
+	return m.response(m.Called(ctx, url, body, opts))
+}
+
+func (m *Mock) Patch(ctx context.Context, url string, body io.Reader, opts ...client.RequestOption) (*http.Response, error) {
+	return m.response(m.Called(ctx, url, body, opts))
+}
+
+func (m *Mock) Delete(ctx context.Context, url string, opts ...client.RequestOption) (*http.Response, error) {
+	return m.response(m.Called(ctx, url, opts))
+}
+
+func (m *Mock) Connect(ctx context.Context, url string, body io.Reader, opts ...client.RequestOption) (*http.Response, error) {
+	return m.response(m.Called(ctx, url, body, opts))
+}
+
+func (m *Mock) Options(ctx context.Context, url string, opts ...client.RequestOption) (*http.Response, error) {
+	return m.response(m.Called(ctx, url, opts))
+}
+
+func (m *Mock) Trace(ctx context.Context, url string, opts ...client.RequestOption) (*http.Response, error) {
+	return m.response(m.Called(ctx, url, opts))
+}
+
+func (m *Mock) PostForm(ctx context.Context, url string, data url.Values, opts ...client.RequestOption) (*http.Response, error) {
+	return m.response(m.Called(ctx, url, data, opts))
+}
+
+func (m *Mock) PostMultipart(ctx context.Context, url string, fields url.Values, files []client.MultipartFile, opts ...client.RequestOption) (*http.Response, error) {
+	return m.response(m.Called(ctx, url, fields, files, opts))
+}
+
+func (m *Mock) Download(ctx context.Context, url string, w io.Writer, opts ...client.DownloadOption) error {
+	return m.Called(ctx, url, w, opts).Error(0)
+}
+
+func (m *Mock) DownloadFile(ctx context.Context, url, path string, opts ...client.DownloadOption) error {
+	return m.Called(ctx, url, path, opts).Error(0)
+}
+
+func (m *Mock) Do(req *http.Request) (*http.Response, error) {
+	return m.response(m.Called(req))
+}
+
+func (m *Mock) response(args mock.Arguments) (*http.Response, error) {
+	res, _ := args.Get(0).(*http.Response)
+
+	return res, args.Error(1)
+}
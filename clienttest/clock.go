@@ -0,0 +1,121 @@
+package clienttest
+
+import (
+	"sync"
+	"time"
+
+	"github.com/mt-sre/client"
+)
+
+var _ client.Clock = (*FakeClock)(nil)
+
+// FakeClock is a clock whose time only moves when advanced so that
+// timing behavior can be tested without real sleeps, e.g. by
+// configuring a RetryWrapper with client.WithClock.
+type FakeClock struct {
+	mu      sync.Mutex
+	cond    *sync.Cond
+	now     time.Time
+	pending []*fakeTimer
+}
+
+type fakeTimer struct {
+	at time.Time
+	c  chan time.Time
+}
+
+// NewFakeClock returns a FakeClock starting at now.
+func NewFakeClock(now time.Time) *FakeClock {
+	c := &FakeClock{now: now}
+	c.cond = sync.NewCond(&c.mu)
+
+	return c
+}
+
+// Now returns the current fake time.
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.now
+}
+
+// Sleep blocks until the clock has been advanced by at least d.
+func (c *FakeClock) Sleep(d time.Duration) {
+	ch, _ := c.Timer(d)
+
+	<-ch
+}
+
+// Timer returns a channel which receives the fake time once the
+// clock has been advanced by at least d and a function stopping
+// the timer.
+func (c *FakeClock) Timer(d time.Duration) (<-chan time.Time, func() bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	t := &fakeTimer{
+		at: c.now.Add(d),
+		c:  make(chan time.Time, 1),
+	}
+
+	if d <= 0 {
+		t.c <- c.now
+
+		return t.c, func() bool { return false }
+	}
+
+	c.pending = append(c.pending, t)
+	c.cond.Broadcast()
+
+	return t.c, func() bool { return c.remove(t) }
+}
+
+// Advance moves the clock forward by d firing all timers which
+// expire on the way.
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = c.now.Add(d)
+
+	pending := c.pending[:0]
+
+	for _, t := range c.pending {
+		if t.at.After(c.now) {
+			pending = append(pending, t)
+
+			continue
+		}
+
+		t.c <- c.now
+	}
+
+	c.pending = pending
+}
+
+// BlockUntil blocks until at least n timers are waiting
+// for the clock to be advanced.
+func (c *FakeClock) BlockUntil(n int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for len(c.pending) < n {
+		c.cond.Wait()
+	}
+}
+
+func (c *FakeClock) remove(t *fakeTimer) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for i, p := range c.pending {
+		if p == t {
+			c.pending = append(c.pending[:i], c.pending[i+1:]...)
+
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,44 @@
+package clienttest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFakeClock(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2026, time.October, 16, 8, 0, 0, 0, time.UTC)
+	clock := NewFakeClock(start)
+
+	first, _ := clock.Timer(time.Second)
+	second, _ := clock.Timer(2 * time.Second)
+	stopped, stop := clock.Timer(time.Second)
+
+	assert.True(t, stop())
+	assert.False(t, stop())
+
+	clock.Advance(time.Second)
+
+	assert.Equal(t, start.Add(time.Second), <-first)
+	assert.Empty(t, second)
+	assert.Empty(t, stopped)
+
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		clock.Sleep(time.Second)
+	}()
+
+	clock.BlockUntil(2)
+	clock.Advance(time.Second)
+
+	<-done
+
+	assert.Equal(t, start.Add(2*time.Second), <-second)
+	assert.Equal(t, start.Add(2*time.Second), clock.Now())
+}
@@ -0,0 +1,254 @@
+// Package clienttest provides utilities for testing code
+// built on top of the client package.
+package clienttest
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mt-sre/client"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// conformanceConcurrency is the number of concurrent
+// requests issued when verifying concurrency safety.
+const conformanceConcurrency = 20
+
+// RunTransportWrapperTests verifies that wrapper honors the contract
+// of http.RoundTripper and TransportWrapper. The wrapper is wrapped
+// around a well-behaved transport which answers every request with
+// 200 OK and the following properties are checked:
+//
+//   - the wrapped transport is called and its response is returned
+//   - the original request is not modified
+//   - the request body is closed
+//   - every response body not returned to the caller is closed
+//   - cancellation of the request context is respected
+//   - concurrent requests are handled safely
+//
+// The wrapper must be configured such that a GET or POST request to
+// http://conformance.test succeeds when all attempts receive 200 OK.
+// Subtests run sequentially as Wrap is called for each of them; run
+// the tests with the race detector to surface data races.
+func RunTransportWrapperTests(t *testing.T, wrapper client.TransportWrapper) {
+	t.Helper()
+
+	t.Run("calls wrapped transport", func(t *testing.T) {
+		inner := newConformanceTransport()
+		rt := wrapper.Wrap(inner)
+
+		res, err := rt.RoundTrip(newConformanceRequest(t, context.Background(), http.MethodGet, nil))
+		require.NoError(t, err)
+
+		body, err := io.ReadAll(res.Body)
+		require.NoError(t, err)
+		require.NoError(t, res.Body.Close())
+
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Equal(t, conformanceBody, string(body))
+		assert.Positive(t, inner.calls.Load(), "wrapped transport must be called")
+	})
+
+	t.Run("does not modify request", func(t *testing.T) {
+		rt := wrapper.Wrap(newConformanceTransport())
+
+		req := newConformanceRequest(t, context.Background(), http.MethodGet, nil)
+		req.Header.Set("X-Conformance", "value")
+
+		method, u, header := req.Method, *req.URL, req.Header.Clone()
+
+		res, err := rt.RoundTrip(req)
+		require.NoError(t, err)
+		require.NoError(t, res.Body.Close())
+
+		assert.Equal(t, method, req.Method)
+		assert.Equal(t, u, *req.URL)
+		assert.Equal(t, header, req.Header)
+	})
+
+	t.Run("closes request body", func(t *testing.T) {
+		rt := wrapper.Wrap(newConformanceTransport())
+
+		body := &trackingBody{Reader: strings.NewReader("payload")}
+
+		req := newConformanceRequest(t, context.Background(), http.MethodPost, body)
+		// ensure the wrapper cannot rely on GetBody
+		req.GetBody = nil
+
+		res, err := rt.RoundTrip(req)
+		require.NoError(t, err)
+		require.NoError(t, res.Body.Close())
+
+		assert.Eventually(t, body.closed.Load, time.Second, time.Millisecond, "request body must be closed")
+	})
+
+	t.Run("closes unused response bodies", func(t *testing.T) {
+		inner := newConformanceTransport()
+		rt := wrapper.Wrap(inner)
+
+		res, err := rt.RoundTrip(newConformanceRequest(t, context.Background(), http.MethodGet, nil))
+		require.NoError(t, err)
+
+		_, err = io.Copy(io.Discard, res.Body)
+		require.NoError(t, err)
+		require.NoError(t, res.Body.Close())
+
+		assert.Eventually(t, inner.allClosed, time.Second, time.Millisecond, "response bodies must be closed")
+	})
+
+	t.Run("respects context cancellation", func(t *testing.T) {
+		inner := newConformanceTransport()
+		inner.block = true
+
+		rt := wrapper.Wrap(inner)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		done := make(chan error, 1)
+
+		go func() {
+			res, err := rt.RoundTrip(newConformanceRequest(t, ctx, http.MethodGet, nil))
+			if res != nil {
+				res.Body.Close()
+			}
+
+			done <- err
+		}()
+
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+
+		select {
+		case err := <-done:
+			assert.ErrorIs(t, err, context.Canceled)
+		case <-time.After(5 * time.Second):
+			t.Fatal("RoundTrip did not return after the request context was canceled")
+		}
+	})
+
+	t.Run("is safe for concurrent use", func(t *testing.T) {
+		inner := newConformanceTransport()
+		rt := wrapper.Wrap(inner)
+
+		var wg sync.WaitGroup
+
+		for i := 0; i < conformanceConcurrency; i++ {
+			wg.Add(1)
+
+			go func() {
+				defer wg.Done()
+
+				res, err := rt.RoundTrip(newConformanceRequest(t, context.Background(), http.MethodGet, nil))
+				if !assert.NoError(t, err) {
+					return
+				}
+				defer res.Body.Close()
+
+				body, err := io.ReadAll(res.Body)
+				assert.NoError(t, err)
+				assert.Equal(t, conformanceBody, string(body))
+			}()
+		}
+
+		wg.Wait()
+
+		assert.Eventually(t, inner.allClosed, time.Second, time.Millisecond, "response bodies must be closed")
+	})
+}
+
+const conformanceBody = "conformance"
+
+func newConformanceRequest(t *testing.T, ctx context.Context, method string, body io.Reader) *http.Request {
+	t.Helper()
+
+	req, err := http.NewRequestWithContext(ctx, method, "http://conformance.test/path?q=1", body)
+	require.NoError(t, err)
+
+	return req
+}
+
+// conformanceTransport behaves like a well-behaved http.RoundTripper
+// recording the response bodies it hands out.
+type conformanceTransport struct {
+	// block causes requests to wait until their context is done.
+	block bool
+	calls atomic.Int64
+
+	mu     sync.Mutex
+	bodies []*trackingBody
+}
+
+func newConformanceTransport() *conformanceTransport {
+	return &conformanceTransport{}
+}
+
+func (t *conformanceTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.calls.Add(1)
+
+	if req.Body != nil {
+		_, _ = io.Copy(io.Discard, req.Body)
+		req.Body.Close()
+	}
+
+	if t.block {
+		<-req.Context().Done()
+
+		return nil, &url.Error{Op: req.Method, URL: req.URL.String(), Err: req.Context().Err()}
+	}
+
+	if err := req.Context().Err(); err != nil {
+		return nil, err
+	}
+
+	body := &trackingBody{Reader: bytes.NewBufferString(conformanceBody)}
+
+	t.mu.Lock()
+	t.bodies = append(t.bodies, body)
+	t.mu.Unlock()
+
+	return &http.Response{
+		Status:        "200 OK",
+		StatusCode:    http.StatusOK,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{"Content-Type": []string{"text/plain; charset=utf-8"}},
+		Body:          body,
+		ContentLength: int64(len(conformanceBody)),
+		Request:       req,
+	}, nil
+}
+
+func (t *conformanceTransport) allClosed() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for _, body := range t.bodies {
+		if !body.closed.Load() {
+			return false
+		}
+	}
+
+	return true
+}
+
+type trackingBody struct {
+	io.Reader
+	closed atomic.Bool
+}
+
+func (b *trackingBody) Close() error {
+	b.closed.Store(true)
+
+	return nil
+}
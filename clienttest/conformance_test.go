@@ -0,0 +1,133 @@
+package clienttest
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mt-sre/client"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunTransportWrapperTests(t *testing.T) {
+	t.Parallel()
+
+	tokenPath := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(tokenPath, []byte("token"), 0o600))
+
+	cassetteDir := t.TempDir()
+
+	endpoints := client.WithEndpoints{
+		{URL: &url.URL{Scheme: "http", Host: "conformance.test"}},
+	}
+
+	for name, newWrapper := range map[string]func() client.TransportWrapper{
+		"adaptive concurrency": func() client.TransportWrapper {
+			return client.NewAdaptiveConcurrencyWrapper()
+		},
+		"charset": func() client.TransportWrapper {
+			return client.NewCharsetWrapper()
+		},
+		"compression": func() client.TransportWrapper {
+			return client.NewCompressionWrapper(client.WithRequestCompression{})
+		},
+		"debug dump": func() client.TransportWrapper {
+			return client.NewDebugDumpWrapper(io.Discard)
+		},
+		"connection tracing": func() client.TransportWrapper {
+			return client.NewConnectionTracingWrapper()
+		},
+		"correlation id": func() client.TransportWrapper {
+			return client.NewCorrelationIDWrapper()
+		},
+		"egress guard": func() client.TransportWrapper {
+			return client.NewEgressGuardWrapper(client.WithEgressLookup(func(context.Context, string) ([]netip.Addr, error) {
+				return []netip.Addr{netip.MustParseAddr("192.0.2.1")}, nil
+			}))
+		},
+		"failover": func() client.TransportWrapper {
+			return client.NewFailoverWrapper(endpoints)
+		},
+		"fault injection": func() client.TransportWrapper {
+			return client.NewFaultInjectionWrapper(client.WithFaultRules{{Probability: 1, Latency: time.Millisecond}})
+		},
+		"hedge": func() client.TransportWrapper {
+			return client.NewHedgeWrapper(client.WithHedgeDelay(time.Millisecond))
+		},
+		"impersonation": func() client.TransportWrapper {
+			return client.NewImpersonationWrapper()
+		},
+		"load balancer": func() client.TransportWrapper {
+			return client.NewLoadBalancerWrapper(endpoints)
+		},
+		"method override": func() client.TransportWrapper {
+			return client.NewMethodOverrideWrapper()
+		},
+		"middleware": func() client.TransportWrapper {
+			return client.MiddlewareWrapper(client.ModifyRequest(func(req *http.Request) error {
+				req.Header.Set("X-Middleware", "true")
+
+				return nil
+			}))
+		},
+		"recorder": func() client.TransportWrapper {
+			return client.NewRecorderWrapper(client.WithCassette{
+				Path: filepath.Join(cassetteDir, "cassette.json"),
+				Mode: client.RecorderModeRecord,
+			})
+		},
+		"request normalizer": func() client.TransportWrapper {
+			return client.NewRequestNormalizerWrapper()
+		},
+		"response validation": func() client.TransportWrapper {
+			return client.NewResponseValidationWrapper(client.WithValidator(func(*http.Response) error { return nil }))
+		},
+		"retry": func() client.TransportWrapper {
+			return client.NewRetryWrapper()
+		},
+		"sanitize": func() client.TransportWrapper {
+			return client.NewSanitizeWrapper()
+		},
+		"service account token": func() client.TransportWrapper {
+			return client.NewServiceAccountTokenWrapper(client.WithTokenPath(tokenPath))
+		},
+		"signing": func() client.TransportWrapper {
+			return client.NewSigningWrapper(client.WithSigner{Signer: &client.HMACSigner{Key: []byte("key")}})
+		},
+		"single flight": func() client.TransportWrapper {
+			return client.NewSingleFlightWrapper()
+		},
+		"rate limit": func() client.TransportWrapper {
+			return client.NewRateLimitWrapper()
+		},
+		"scheduler": func() client.TransportWrapper {
+			return client.NewSchedulerWrapper()
+		},
+		"spool": func() client.TransportWrapper {
+			return client.NewSpoolWrapper(client.WithSpoolDir(cassetteDir))
+		},
+		"store and forward": func() client.TransportWrapper {
+			return client.NewStoreAndForwardWrapper(client.NewMemoryRequestQueue())
+		},
+		"status handler": func() client.TransportWrapper {
+			return client.NewStatusHandlerWrapper()
+		},
+		"user agent": func() client.TransportWrapper {
+			return client.NewUserAgentWrapper()
+		},
+	} {
+		newWrapper := newWrapper
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			RunTransportWrapperTests(t, newWrapper())
+		})
+	}
+}
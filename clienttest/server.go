@@ -0,0 +1,288 @@
+package clienttest
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Server is a test server exposing endpoints which simulate the
+// failure modes a client must cope with. Every request received is
+// captured and available through Requests. The following endpoints
+// are served:
+//
+//   - /status?code=404 responds with the given status code.
+//   - /retry-after?seconds=2[&code=503][&format=date] responds with
+//     the given status code, 429 by default, and a Retry-After header
+//     in seconds or, if format is "date", as an HTTP date.
+//   - /delay?ms=500 responds with 200 OK after the given delay or
+//     once the request is canceled.
+//   - /flaky?fail=2[&code=503][&key=name] fails the first fail
+//     requests sharing the same key with the given status code, 503
+//     by default, and responds with 200 OK afterwards. The key
+//     defaults to the fail and code parameters.
+//   - /rate-limit?limit=5[&window=1s][&key=name] permits limit
+//     requests sharing the same key per window and responds with 429
+//     and a Retry-After header once the limit is exhausted.
+//
+// All other paths respond with 200 OK.
+type Server struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	requests []CapturedRequest
+	flaky    map[string]int
+	windows  map[string]*rateLimitWindow
+}
+
+// CapturedRequest is a request received by a Server.
+type CapturedRequest struct {
+	Method string
+	// URL is the request URI including the query.
+	URL    string
+	Header http.Header
+	Body   []byte
+	Time   time.Time
+}
+
+type rateLimitWindow struct {
+	start time.Time
+	count int
+}
+
+// NewServer starts a Server. The caller must call Close
+// once the server is no longer needed.
+func NewServer() *Server {
+	s := &Server{
+		flaky:   make(map[string]int),
+		windows: make(map[string]*rateLimitWindow),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/status", s.handleStatus)
+	mux.HandleFunc("/retry-after", s.handleRetryAfter)
+	mux.HandleFunc("/delay", s.handleDelay)
+	mux.HandleFunc("/flaky", s.handleFlaky)
+	mux.HandleFunc("/rate-limit", s.handleRateLimit)
+	mux.HandleFunc("/", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	s.Server = httptest.NewServer(s.capture(mux))
+
+	return s
+}
+
+// Requests returns the requests received in the order they arrived.
+func (s *Server) Requests() []CapturedRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]CapturedRequest(nil), s.requests...)
+}
+
+// RequestsTo returns the requests received for the given path.
+func (s *Server) RequestsTo(path string) []CapturedRequest {
+	var matched []CapturedRequest
+
+	for _, req := range s.Requests() {
+		if p, _, _ := strings.Cut(req.URL, "?"); p == path {
+			matched = append(matched, req)
+		}
+	}
+
+	return matched
+}
+
+// Reset forgets all captured requests and the state
+// of the flaky and rate limited endpoints.
+func (s *Server) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.requests = nil
+	s.flaky = make(map[string]int)
+	s.windows = make(map[string]*rateLimitWindow)
+}
+
+func (s *Server) capture(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("failed to read body: %v", err), http.StatusBadRequest)
+
+			return
+		}
+
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		s.mu.Lock()
+		s.requests = append(s.requests, CapturedRequest{
+			Method: r.Method,
+			URL:    r.URL.RequestURI(),
+			Header: r.Header.Clone(),
+			Body:   body,
+			Time:   time.Now(),
+		})
+		s.mu.Unlock()
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *Server) handleStatus(w http.ResponseWriter, r *http.Request) {
+	code, ok := intParam(w, r, "code", 0)
+	if !ok {
+		return
+	}
+
+	w.WriteHeader(code)
+}
+
+func (s *Server) handleRetryAfter(w http.ResponseWriter, r *http.Request) {
+	code, ok := intParam(w, r, "code", http.StatusTooManyRequests)
+	if !ok {
+		return
+	}
+
+	seconds, ok := intParam(w, r, "seconds", 0)
+	if !ok {
+		return
+	}
+
+	if r.URL.Query().Get("format") == "date" {
+		w.Header().Set("Retry-After", time.Now().Add(time.Duration(seconds)*time.Second).UTC().Format(http.TimeFormat))
+	} else {
+		w.Header().Set("Retry-After", strconv.Itoa(seconds))
+	}
+
+	w.WriteHeader(code)
+}
+
+func (s *Server) handleDelay(w http.ResponseWriter, r *http.Request) {
+	ms, ok := intParam(w, r, "ms", 0)
+	if !ok {
+		return
+	}
+
+	timer := time.NewTimer(time.Duration(ms) * time.Millisecond)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		w.WriteHeader(http.StatusOK)
+	case <-r.Context().Done():
+	}
+}
+
+func (s *Server) handleFlaky(w http.ResponseWriter, r *http.Request) {
+	fail, ok := intParam(w, r, "fail", 0)
+	if !ok {
+		return
+	}
+
+	code, ok := intParam(w, r, "code", http.StatusServiceUnavailable)
+	if !ok {
+		return
+	}
+
+	key := r.URL.Query().Get("key")
+	if key == "" {
+		key = fmt.Sprintf("%d/%d", fail, code)
+	}
+
+	s.mu.Lock()
+	s.flaky[key]++
+	attempt := s.flaky[key]
+	s.mu.Unlock()
+
+	if attempt <= fail {
+		w.WriteHeader(code)
+
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *Server) handleRateLimit(w http.ResponseWriter, r *http.Request) {
+	limit, ok := intParam(w, r, "limit", 0)
+	if !ok {
+		return
+	}
+
+	window := time.Second
+
+	if raw := r.URL.Query().Get("window"); raw != "" {
+		var err error
+
+		if window, err = time.ParseDuration(raw); err != nil {
+			http.Error(w, fmt.Sprintf("failed to parse window: %v", err), http.StatusBadRequest)
+
+			return
+		}
+	}
+
+	key := r.URL.Query().Get("key")
+	if key == "" {
+		key = fmt.Sprintf("%d/%s", limit, window)
+	}
+
+	now := time.Now()
+
+	s.mu.Lock()
+
+	win, ok := s.windows[key]
+	if !ok || now.Sub(win.start) >= window {
+		win = &rateLimitWindow{start: now}
+		s.windows[key] = win
+	}
+
+	win.count++
+
+	count := win.count
+	reset := win.start.Add(window).Sub(now)
+
+	s.mu.Unlock()
+
+	resetSeconds := strconv.Itoa(int(math.Ceil(reset.Seconds())))
+
+	w.Header().Set("RateLimit-Limit", strconv.Itoa(limit))
+	w.Header().Set("RateLimit-Remaining", strconv.Itoa(max(limit-count, 0)))
+	w.Header().Set("RateLimit-Reset", resetSeconds)
+
+	if count > limit {
+		w.Header().Set("Retry-After", resetSeconds)
+		w.WriteHeader(http.StatusTooManyRequests)
+
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// intParam parses the integer query parameter name responding with
+// 400 Bad Request if it is malformed. def is returned if the parameter
+// is absent; a def of zero makes the parameter required.
+func intParam(w http.ResponseWriter, r *http.Request, name string, def int) (int, bool) {
+	raw := r.URL.Query().Get(name)
+	if raw == "" && def != 0 {
+		return def, true
+	}
+
+	val, err := strconv.Atoi(raw)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to parse %s: %v", name, err), http.StatusBadRequest)
+
+		return 0, false
+	}
+
+	return val, true
+}
@@ -0,0 +1,167 @@
+package clienttest
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mt-sre/client"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		Paths           []string
+		ExpectedCodes   []int
+		ExpectedHeaders map[string]string
+	}{
+		"status": {
+			Paths:         []string{"/status?code=418"},
+			ExpectedCodes: []int{http.StatusTeapot},
+		},
+		"invalid status": {
+			Paths:         []string{"/status?code=abc"},
+			ExpectedCodes: []int{http.StatusBadRequest},
+		},
+		"retry after": {
+			Paths:           []string{"/retry-after?seconds=2&code=503"},
+			ExpectedCodes:   []int{http.StatusServiceUnavailable},
+			ExpectedHeaders: map[string]string{"Retry-After": "2"},
+		},
+		"delay": {
+			Paths:         []string{"/delay?ms=10"},
+			ExpectedCodes: []int{http.StatusOK},
+		},
+		"flaky": {
+			Paths:         []string{"/flaky?fail=2", "/flaky?fail=2", "/flaky?fail=2"},
+			ExpectedCodes: []int{http.StatusServiceUnavailable, http.StatusServiceUnavailable, http.StatusOK},
+		},
+		"flaky keys": {
+			Paths:         []string{"/flaky?fail=1&code=500&key=a", "/flaky?fail=1&code=500&key=b", "/flaky?fail=1&code=500&key=a"},
+			ExpectedCodes: []int{http.StatusInternalServerError, http.StatusInternalServerError, http.StatusOK},
+		},
+		"rate limit": {
+			Paths:         []string{"/rate-limit?limit=2&window=1h", "/rate-limit?limit=2&window=1h", "/rate-limit?limit=2&window=1h"},
+			ExpectedCodes: []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests},
+			ExpectedHeaders: map[string]string{
+				"Retry-After":         "3600",
+				"RateLimit-Remaining": "0",
+			},
+		},
+		"default": {
+			Paths:         []string{"/anything"},
+			ExpectedCodes: []int{http.StatusOK},
+		},
+	} {
+		tc := tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			srv := NewServer()
+			defer srv.Close()
+
+			codes := make([]int, 0, len(tc.Paths))
+
+			var last *http.Response
+
+			for _, path := range tc.Paths {
+				res, err := srv.Client().Get(srv.URL + path)
+				require.NoError(t, err)
+				res.Body.Close()
+
+				codes = append(codes, res.StatusCode)
+				last = res
+			}
+
+			assert.Equal(t, tc.ExpectedCodes, codes)
+
+			for key, val := range tc.ExpectedHeaders {
+				assert.Equal(t, val, last.Header.Get(key))
+			}
+		})
+	}
+}
+
+func TestServerDelayCanceled(t *testing.T) {
+	t.Parallel()
+
+	srv := NewServer()
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/delay?ms=10000", nil)
+	require.NoError(t, err)
+
+	start := time.Now()
+
+	_, err = srv.Client().Do(req)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
+
+func TestServerCapture(t *testing.T) {
+	t.Parallel()
+
+	srv := NewServer()
+	defer srv.Close()
+
+	req, err := http.NewRequest(http.MethodPost, srv.URL+"/items?dry-run=true", strings.NewReader("item"))
+	require.NoError(t, err)
+
+	req.Header.Set("X-Tenant", "a")
+
+	res, err := srv.Client().Do(req)
+	require.NoError(t, err)
+	res.Body.Close()
+
+	res, err = srv.Client().Get(srv.URL + "/status?code=204")
+	require.NoError(t, err)
+	res.Body.Close()
+
+	requests := srv.Requests()
+	require.Len(t, requests, 2)
+
+	assert.Equal(t, http.MethodPost, requests[0].Method)
+	assert.Equal(t, "/items?dry-run=true", requests[0].URL)
+	assert.Equal(t, "a", requests[0].Header.Get("X-Tenant"))
+	assert.Equal(t, "item", string(requests[0].Body))
+
+	assert.Len(t, srv.RequestsTo("/status"), 1)
+
+	srv.Reset()
+
+	assert.Empty(t, srv.Requests())
+}
+
+// TestServerRetries demonstrates verifying a retry
+// configuration end-to-end against the Server.
+func TestServerRetries(t *testing.T) {
+	t.Parallel()
+
+	srv := NewServer()
+	defer srv.Close()
+
+	c := client.NewClient(
+		client.WithTransport{RoundTripper: srv.Client().Transport},
+		client.WithWrapper{TransportWrapper: client.NewRetryWrapper(
+			client.WithBackoffGenerator(client.NoBackoffGenerator()),
+			client.WithMaxRetries(3),
+		)},
+	)
+	defer c.Close()
+
+	res, err := c.Get(context.Background(), srv.URL+"/flaky?fail=2")
+	require.NoError(t, err)
+	res.Body.Close()
+
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Len(t, srv.RequestsTo("/flaky"), 3)
+}
@@ -0,0 +1,120 @@
+package client
+
+import (
+	"context"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+)
+
+// Clock provides the current time and timers to the retry and backoff
+// machinery and to token expiry so that tests can control the passage
+// of time, e.g. with clienttest.FakeClock.
+type Clock interface {
+	// Now returns the current time.
+	Now() time.Time
+	// Sleep blocks until d has elapsed.
+	Sleep(d time.Duration)
+	// Timer returns a channel which receives the current time once d
+	// has elapsed and a function which stops the timer reporting
+	// whether the call stopped it before it fired.
+	Timer(d time.Duration) (c <-chan time.Time, stop func() bool)
+}
+
+// RealClock is the Clock backed by the time package.
+type RealClock struct{}
+
+func (RealClock) Now() time.Time { return time.Now() }
+
+func (RealClock) Sleep(d time.Duration) { time.Sleep(d) }
+
+func (RealClock) Timer(d time.Duration) (<-chan time.Time, func() bool) {
+	t := time.NewTimer(d)
+
+	return t.C, t.Stop
+}
+
+// WithClock configures a RetryWrapper instance to measure attempts and
+// wait between retries, an OAUTHWrapper or ServiceAccountTokenWrapper
+// instance to expire and refresh tokens, and a RateLimitWrapper instance
+// to wait for rate limits to reset, using the provided Clock instead of
+// the time package.
+type WithClock struct{ Clock }
+
+func (c WithClock) ConfigureRetryWrapper(cfg *RetryWrapperConfig) {
+	cfg.Clock = c.Clock
+}
+
+func (c WithClock) ConfigureOAUTH(cfg *OAUTHConfig) {
+	cfg.Clock = c.Clock
+}
+
+func (c WithClock) ConfigureServiceAccountToken(cfg *ServiceAccountTokenConfig) {
+	cfg.Clock = c.Clock
+}
+
+func (c WithClock) ConfigureRateLimit(cfg *RateLimitConfig) {
+	cfg.Clock = c.Clock
+}
+
+// sleepClock blocks until d has elapsed on clock
+// or ctx is done returning the error of ctx.
+func sleepClock(ctx context.Context, clock Clock, d time.Duration) error {
+	timer, stop := clock.Timer(d)
+	defer stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer:
+		return nil
+	}
+}
+
+// withClockTimeout returns a copy of ctx which is cancelled
+// with context.DeadlineExceeded as its cause once d has
+// elapsed on clock.
+func withClockTimeout(ctx context.Context, clock Clock, d time.Duration) (context.Context, context.CancelFunc) {
+	if _, ok := clock.(RealClock); ok {
+		return context.WithTimeout(ctx, d)
+	}
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	timer, stop := clock.Timer(d)
+
+	go func() {
+		select {
+		case <-timer:
+			cancel(context.DeadlineExceeded)
+		case <-ctx.Done():
+		}
+	}()
+
+	return ctx, func() {
+		stop()
+		cancel(context.Canceled)
+	}
+}
+
+// clockTimer adapts a Clock to the backoff.Timer interface.
+type clockTimer struct {
+	clock Clock
+	c     <-chan time.Time
+	stop  func() bool
+}
+
+func (t *clockTimer) Start(d time.Duration) {
+	t.Stop()
+
+	t.c, t.stop = t.clock.Timer(d)
+}
+
+func (t *clockTimer) Stop() {
+	if t.stop != nil {
+		t.stop()
+	}
+}
+
+func (t *clockTimer) C() <-chan time.Time { return t.c }
+
+var _ backoff.Timer = (*clockTimer)(nil)
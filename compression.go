@@ -0,0 +1,369 @@
+package client
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+const (
+	// defaultMaxRequestCompressionSize is the default size up to
+	// which request bodies are buffered to be compressed.
+	defaultMaxRequestCompressionSize = 8 << 20
+	// zstdMaxWindow is the window size decoders are
+	// required to support by RFC 8878 for HTTP.
+	zstdMaxWindow = 8 << 20
+)
+
+// NewCompressionWrapper returns a TransportWrapper which negotiates
+// compressed responses and transparently decodes them. Unlike the
+// gzip support of net/http, any encoding with a registered Decoder is
+// supported and decoding takes place regardless of DisableCompression.
+// gzip, deflate and zstd are supported by default; further encodings
+// can be registered using WithDecoder. Responses are only decoded
+// for requests which do not set their own Accept-Encoding header.
+//
+// Request bodies are compressed if configured with WithRequestCompression.
+// When combined with a SigningWrapper, the SigningWrapper should be
+// applied before the CompressionWrapper so that the signature covers
+// the compressed body.
+func NewCompressionWrapper(opts ...CompressionOption) *CompressionWrapper {
+	var cfg CompressionConfig
+
+	cfg.Option(opts...)
+	cfg.Default()
+
+	encodings := make([]string, 0, len(cfg.Decoders))
+	for _, d := range cfg.Decoders {
+		encodings = append(encodings, d.Encoding)
+	}
+
+	return &CompressionWrapper{
+		cfg:            cfg,
+		acceptEncoding: strings.Join(encodings, ", "),
+	}
+}
+
+type CompressionWrapper struct {
+	cfg            CompressionConfig
+	rt             http.RoundTripper
+	acceptEncoding string
+}
+
+func (w *CompressionWrapper) Wrap(rt http.RoundTripper) http.RoundTripper {
+	w.rt = rt
+
+	return w
+}
+
+func (w *CompressionWrapper) RoundTrip(req *http.Request) (*http.Response, error) {
+	out := req
+
+	if w.cfg.RequestCompression && hasBody(req) && req.Header.Get("Content-Encoding") == "" {
+		compressed, err := w.compressRequest(req)
+		if err != nil {
+			return nil, err
+		}
+
+		out = compressed
+	}
+
+	negotiate := out.Header.Get("Accept-Encoding") == "" && w.acceptEncoding != ""
+
+	if negotiate {
+		if out == req {
+			out = req.Clone(req.Context())
+		}
+
+		out.Header.Set("Accept-Encoding", w.acceptEncoding)
+	}
+
+	res, err := w.rt.RoundTrip(out)
+	if err != nil || !negotiate {
+		return res, err
+	}
+
+	return w.decodeResponse(req, res)
+}
+
+// compressRequest returns a copy of req with a gzip compressed body
+// if the body is at least the configured minimum size. Bodies larger
+// than the configured maximum size are sent uncompressed rather than
+// being buffered.
+func (w *CompressionWrapper) compressRequest(req *http.Request) (*http.Request, error) {
+	limit := w.cfg.MaxRequestCompressionSize
+	if req.ContentLength > limit {
+		return req, nil
+	}
+
+	body, err := io.ReadAll(io.LimitReader(req.Body, limit+1))
+	if err != nil {
+		req.Body.Close()
+
+		return nil, fmt.Errorf("reading request body: %w", err)
+	}
+
+	out := req.Clone(req.Context())
+
+	if int64(len(body)) > limit {
+		out.Body = &transcodedBody{
+			Reader: io.MultiReader(bytes.NewReader(body), req.Body),
+			Closer: req.Body,
+		}
+
+		return out, nil
+	}
+
+	req.Body.Close()
+
+	if int64(len(body)) >= w.cfg.MinRequestCompressionSize {
+		var buf bytes.Buffer
+
+		zw := gzip.NewWriter(&buf)
+
+		if _, err := zw.Write(body); err != nil {
+			return nil, fmt.Errorf("compressing request body: %w", err)
+		}
+
+		if err := zw.Close(); err != nil {
+			return nil, fmt.Errorf("compressing request body: %w", err)
+		}
+
+		body = buf.Bytes()
+
+		out.Header.Set("Content-Encoding", "gzip")
+	}
+
+	out.Body = io.NopCloser(bytes.NewReader(body))
+	out.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(body)), nil
+	}
+	out.ContentLength = int64(len(body))
+
+	return out, nil
+}
+
+func (w *CompressionWrapper) decodeResponse(req *http.Request, res *http.Response) (*http.Response, error) {
+	header := res.Header.Get("Content-Encoding")
+	if header == "" || req.Method == http.MethodHead ||
+		res.StatusCode == http.StatusNoContent || res.StatusCode == http.StatusNotModified {
+		return res, nil
+	}
+
+	encodings := splitHeaderList(header, ',')
+
+	decoders := make([]DecoderFunc, 0, len(encodings))
+
+	// encodings are listed in the order they were applied
+	for i := len(encodings) - 1; i >= 0; i-- {
+		fn := w.cfg.decoder(encodings[i])
+		if fn == nil {
+			if encodings[i] == "identity" {
+				continue
+			}
+
+			// leave responses with unsupported encodings untouched
+			return res, nil
+		}
+
+		decoders = append(decoders, fn)
+	}
+
+	res.Body = &decodedBody{
+		body:     res.Body,
+		decoders: decoders,
+	}
+	res.Header.Del("Content-Encoding")
+	res.Header.Del("Content-Length")
+	res.ContentLength = -1
+	res.Uncompressed = true
+
+	return res, nil
+}
+
+// decodedBody lazily applies decoders to a response body
+// so that decoding errors are reported by Read.
+type decodedBody struct {
+	body     io.ReadCloser
+	decoders []DecoderFunc
+
+	r       io.Reader
+	closers []io.Closer
+	err     error
+}
+
+func (b *decodedBody) Read(p []byte) (int, error) {
+	if b.r == nil && b.err == nil {
+		b.init()
+	}
+
+	if b.err != nil {
+		return 0, b.err
+	}
+
+	return b.r.Read(p)
+}
+
+func (b *decodedBody) init() {
+	var r io.Reader = b.body
+
+	for _, fn := range b.decoders {
+		rc, err := fn(r)
+		if err != nil {
+			b.err = err
+
+			return
+		}
+
+		b.closers = append(b.closers, rc)
+
+		r = rc
+	}
+
+	b.r = r
+}
+
+func (b *decodedBody) Close() error {
+	for _, c := range b.closers {
+		c.Close()
+	}
+
+	return b.body.Close()
+}
+
+// DecoderFunc returns a reader decoding the content of r.
+type DecoderFunc func(r io.Reader) (io.ReadCloser, error)
+
+// Decoder decodes responses with the given content coding.
+type Decoder struct {
+	Encoding  string
+	NewReader DecoderFunc
+}
+
+func gzipDecoder(r io.Reader) (io.ReadCloser, error) {
+	return gzip.NewReader(r)
+}
+
+// deflateDecoder decodes zlib wrapped deflate streams as specified
+// for the deflate content coding as well as the raw deflate streams
+// sent by some servers.
+func deflateDecoder(r io.Reader) (io.ReadCloser, error) {
+	br := bufio.NewReader(r)
+
+	header, err := br.Peek(2)
+	if err != nil {
+		return nil, err
+	}
+
+	if header[0]&0x0f == 8 && (uint16(header[0])<<8|uint16(header[1]))%31 == 0 {
+		return zlib.NewReader(br)
+	}
+
+	return flate.NewReader(br), nil
+}
+
+func zstdDecoder(r io.Reader) (io.ReadCloser, error) {
+	d, err := zstd.NewReader(r,
+		zstd.WithDecoderConcurrency(1),
+		zstd.WithDecoderMaxWindow(zstdMaxWindow),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return d.IOReadCloser(), nil
+}
+
+type CompressionConfig struct {
+	// Decoders are advertised in Accept-Encoding
+	// in the order given.
+	Decoders []Decoder
+	// RequestCompression enables gzip compression
+	// of request bodies.
+	RequestCompression bool
+	// MinRequestCompressionSize is the minimum size of
+	// a request body to be compressed.
+	MinRequestCompressionSize int64
+	// MaxRequestCompressionSize is the maximum size of a request
+	// body to be compressed. Larger bodies are sent uncompressed.
+	// Defaults to 8 MiB.
+	MaxRequestCompressionSize int64
+}
+
+func (c *CompressionConfig) Option(opts ...CompressionOption) {
+	for _, opt := range opts {
+		opt.ConfigureCompression(c)
+	}
+}
+
+func (c *CompressionConfig) Default() {
+	for _, d := range []Decoder{
+		{Encoding: "gzip", NewReader: gzipDecoder},
+		{Encoding: "deflate", NewReader: deflateDecoder},
+		{Encoding: "zstd", NewReader: zstdDecoder},
+	} {
+		if c.decoder(d.Encoding) == nil {
+			c.Decoders = append(c.Decoders, d)
+		}
+	}
+
+	if c.MaxRequestCompressionSize <= 0 {
+		c.MaxRequestCompressionSize = defaultMaxRequestCompressionSize
+	}
+}
+
+func (c *CompressionConfig) decoder(encoding string) DecoderFunc {
+	for _, d := range c.Decoders {
+		if strings.EqualFold(d.Encoding, encoding) {
+			return d.NewReader
+		}
+	}
+
+	return nil
+}
+
+type CompressionOption interface {
+	ConfigureCompression(*CompressionConfig)
+}
+
+// WithDecoder registers a Decoder with a CompressionWrapper replacing
+// any existing Decoder for the same encoding. Decoders registered
+// this way are preferred over the built-in gzip, deflate and zstd decoders.
+// This option can be provided multiple times.
+type WithDecoder Decoder
+
+func (d WithDecoder) ConfigureCompression(c *CompressionConfig) {
+	for i := range c.Decoders {
+		if strings.EqualFold(c.Decoders[i].Encoding, d.Encoding) {
+			c.Decoders[i] = Decoder(d)
+
+			return
+		}
+	}
+
+	c.Decoders = append(c.Decoders, Decoder(d))
+}
+
+// WithRequestCompression configures a CompressionWrapper to gzip
+// request bodies of at least MinSize bytes which do not already
+// carry a Content-Encoding. Bodies are buffered to be compressed
+// so that they can be retried; bodies larger than MaxSize, which
+// defaults to 8 MiB, are sent uncompressed.
+type WithRequestCompression struct {
+	MinSize int64
+	MaxSize int64
+}
+
+func (rc WithRequestCompression) ConfigureCompression(c *CompressionConfig) {
+	c.RequestCompression = true
+	c.MinRequestCompressionSize = rc.MinSize
+	c.MaxRequestCompressionSize = rc.MaxSize
+}
@@ -0,0 +1,278 @@
+package client
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompressionWrapperInterfaces(t *testing.T) {
+	t.Parallel()
+
+	require.Implements(t, new(http.RoundTripper), new(CompressionWrapper))
+
+	require.Implements(t, new(TransportWrapper), new(CompressionWrapper))
+}
+
+const compressionPayload = "the quick brown fox jumps over the lazy dog"
+
+func TestCompressionWrapperDecoding(t *testing.T) {
+	t.Parallel()
+
+	upper := Decoder{
+		Encoding: "x-upper",
+		NewReader: func(r io.Reader) (io.ReadCloser, error) {
+			data, err := io.ReadAll(r)
+
+			return io.NopCloser(bytes.NewReader(bytes.ToLower(data))), err
+		},
+	}
+
+	for name, tc := range map[string]struct {
+		Options                []CompressionOption
+		RequestAcceptEncoding  string
+		ContentEncoding        string
+		Body                   []byte
+		ExpectedAcceptEncoding string
+		ExpectedBody           string
+		ExpectedEncoding       string
+	}{
+		"identity": {
+			Body:                   []byte(compressionPayload),
+			ExpectedAcceptEncoding: "gzip, deflate, zstd",
+			ExpectedBody:           compressionPayload,
+		},
+		"gzip": {
+			ContentEncoding:        "gzip",
+			Body:                   compress(t, "gzip", compressionPayload),
+			ExpectedAcceptEncoding: "gzip, deflate, zstd",
+			ExpectedBody:           compressionPayload,
+		},
+		"deflate": {
+			ContentEncoding:        "deflate",
+			Body:                   compress(t, "deflate", compressionPayload),
+			ExpectedAcceptEncoding: "gzip, deflate, zstd",
+			ExpectedBody:           compressionPayload,
+		},
+		"raw deflate": {
+			ContentEncoding:        "deflate",
+			Body:                   compress(t, "raw deflate", compressionPayload),
+			ExpectedAcceptEncoding: "gzip, deflate, zstd",
+			ExpectedBody:           compressionPayload,
+		},
+		"zstd": {
+			ContentEncoding:        "zstd",
+			Body:                   compress(t, "zstd", compressionPayload),
+			ExpectedAcceptEncoding: "gzip, deflate, zstd",
+			ExpectedBody:           compressionPayload,
+		},
+		"custom decoder": {
+			Options:                []CompressionOption{WithDecoder(upper)},
+			ContentEncoding:        "x-upper",
+			Body:                   []byte(strings.ToUpper(compressionPayload)),
+			ExpectedAcceptEncoding: "x-upper, gzip, deflate, zstd",
+			ExpectedBody:           compressionPayload,
+		},
+		"multiple encodings": {
+			Options:                []CompressionOption{WithDecoder(upper)},
+			ContentEncoding:        "x-upper, gzip",
+			Body:                   compress(t, "gzip", strings.ToUpper(compressionPayload)),
+			ExpectedAcceptEncoding: "x-upper, gzip, deflate, zstd",
+			ExpectedBody:           compressionPayload,
+		},
+		"unsupported encoding": {
+			ContentEncoding:        "br",
+			Body:                   []byte("brotli"),
+			ExpectedAcceptEncoding: "gzip, deflate, zstd",
+			ExpectedBody:           "brotli",
+			ExpectedEncoding:       "br",
+		},
+		"caller negotiated": {
+			RequestAcceptEncoding:  "gzip",
+			ContentEncoding:        "gzip",
+			Body:                   compress(t, "gzip", compressionPayload),
+			ExpectedAcceptEncoding: "gzip",
+			ExpectedBody:           string(compress(t, "gzip", compressionPayload)),
+			ExpectedEncoding:       "gzip",
+		},
+	} {
+		tc := tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, tc.ExpectedAcceptEncoding, r.Header.Get("Accept-Encoding"))
+
+				if tc.ContentEncoding != "" {
+					w.Header().Set("Content-Encoding", tc.ContentEncoding)
+				}
+
+				_, _ = w.Write(tc.Body)
+			}))
+			defer srv.Close()
+
+			// decoding must not depend on the transport
+			transport := &http.Transport{DisableCompression: true}
+			defer transport.CloseIdleConnections()
+
+			rt := NewCompressionWrapper(tc.Options...).Wrap(transport)
+
+			req, err := http.NewRequest(http.MethodGet, srv.URL, nil)
+			require.NoError(t, err)
+
+			if tc.RequestAcceptEncoding != "" {
+				req.Header.Set("Accept-Encoding", tc.RequestAcceptEncoding)
+			}
+
+			res, err := rt.RoundTrip(req)
+			require.NoError(t, err)
+			defer res.Body.Close()
+
+			body, err := io.ReadAll(res.Body)
+			require.NoError(t, err)
+
+			assert.Equal(t, tc.ExpectedBody, string(body))
+			assert.Equal(t, tc.ExpectedEncoding, res.Header.Get("Content-Encoding"))
+		})
+	}
+}
+
+func TestCompressionWrapperInvalidBody(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Encoding", "gzip")
+		_, _ = w.Write([]byte("this is not gzip encoded"))
+	}))
+	defer srv.Close()
+
+	rt := NewCompressionWrapper().Wrap(srv.Client().Transport)
+
+	req, err := http.NewRequest(http.MethodGet, srv.URL, nil)
+	require.NoError(t, err)
+
+	res, err := rt.RoundTrip(req)
+	require.NoError(t, err)
+	defer res.Body.Close()
+
+	_, err = io.ReadAll(res.Body)
+	assert.ErrorIs(t, err, gzip.ErrHeader)
+}
+
+func TestCompressionWrapperRequestCompression(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		Options          []CompressionOption
+		Body             string
+		ContentEncoding  string
+		ExpectedEncoding string
+	}{
+		"disabled": {
+			Body: compressionPayload,
+		},
+		"above threshold": {
+			Options:          []CompressionOption{WithRequestCompression{MinSize: 10}},
+			Body:             compressionPayload,
+			ExpectedEncoding: "gzip",
+		},
+		"below threshold": {
+			Options: []CompressionOption{WithRequestCompression{MinSize: 1024}},
+			Body:    compressionPayload,
+		},
+		"above maximum": {
+			Options: []CompressionOption{WithRequestCompression{MinSize: 10, MaxSize: 20}},
+			Body:    compressionPayload,
+		},
+		"already encoded": {
+			Options:          []CompressionOption{WithRequestCompression{}},
+			Body:             compressionPayload,
+			ContentEncoding:  "identity",
+			ExpectedEncoding: "identity",
+		},
+	} {
+		tc := tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, tc.ExpectedEncoding, r.Header.Get("Content-Encoding"))
+
+				var body io.Reader = r.Body
+
+				if r.Header.Get("Content-Encoding") == "gzip" {
+					zr, err := gzip.NewReader(r.Body)
+					if !assert.NoError(t, err) {
+						return
+					}
+
+					body = zr
+				}
+
+				data, err := io.ReadAll(body)
+				assert.NoError(t, err)
+				assert.Equal(t, tc.Body, string(data))
+			}))
+			defer srv.Close()
+
+			rt := NewCompressionWrapper(tc.Options...).Wrap(srv.Client().Transport)
+
+			// a reader without a known length
+			req, err := http.NewRequest(http.MethodPost, srv.URL, io.MultiReader(strings.NewReader(tc.Body)))
+			require.NoError(t, err)
+
+			if tc.ContentEncoding != "" {
+				req.Header.Set("Content-Encoding", tc.ContentEncoding)
+			}
+
+			res, err := rt.RoundTrip(req)
+			require.NoError(t, err)
+			require.NoError(t, res.Body.Close())
+
+			assert.Equal(t, tc.ContentEncoding, req.Header.Get("Content-Encoding"), "original request must not be modified")
+		})
+	}
+}
+
+func compress(t *testing.T, encoding, data string) []byte {
+	t.Helper()
+
+	var (
+		buf bytes.Buffer
+		w   io.WriteCloser
+		err error
+	)
+
+	switch encoding {
+	case "gzip":
+		w = gzip.NewWriter(&buf)
+	case "deflate":
+		w = zlib.NewWriter(&buf)
+	case "raw deflate":
+		w, err = flate.NewWriter(&buf, flate.DefaultCompression)
+		require.NoError(t, err)
+	case "zstd":
+		w, err = zstd.NewWriter(&buf)
+		require.NoError(t, err)
+	default:
+		t.Fatalf("unknown encoding %q", encoding)
+	}
+
+	_, err = io.WriteString(w, data)
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	return buf.Bytes()
+}
@@ -0,0 +1,150 @@
+package client
+
+import (
+	"container/list"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+const defaultConditionalMaxEntries = 1024
+
+// NotModifiedError is returned by a Client configured with
+// WithConditionalGETs when the server answers a GET request with
+// 304 Not Modified as the resource did not change since the
+// response the validators were taken from.
+type NotModifiedError struct {
+	URL          string
+	ETag         string
+	LastModified string
+}
+
+func (e *NotModifiedError) Error() string {
+	return fmt.Sprintf("GET %s: not modified", e.URL)
+}
+
+// IsNotModified reports whether err is or wraps a *NotModifiedError.
+func IsNotModified(err error) bool {
+	var notModified *NotModifiedError
+
+	return errors.As(err, &notModified)
+}
+
+// WithConditionalGETs configures a Client instance to remember the
+// ETag and Last-Modified headers of successful GET responses and send
+// them as If-None-Match and If-Modified-Since with subsequent GET
+// requests for the same URL. A 304 Not Modified response is reported
+// as a *NotModifiedError so that polling loops skip reprocessing
+// unchanged resources. Requests which set conditional headers
+// themselves are left untouched. Validators of at most MaxEntries
+// URLs, 1024 by default, are kept with the least recently used
+// being evicted first.
+type WithConditionalGETs struct {
+	MaxEntries int
+}
+
+func (cg WithConditionalGETs) ConfigureClient(c *ClientConfig) {
+	maxEntries := cg.MaxEntries
+	if maxEntries <= 0 {
+		maxEntries = defaultConditionalMaxEntries
+	}
+
+	c.validators = newValidatorStore(maxEntries)
+}
+
+type validators struct {
+	url          string
+	etag         string
+	lastModified string
+}
+
+// validatorStore is a size-bounded LRU mapping
+// URLs to the validators of their last response.
+type validatorStore struct {
+	maxEntries int
+
+	mu      sync.Mutex
+	order   *list.List
+	entries map[string]*list.Element
+}
+
+func newValidatorStore(maxEntries int) *validatorStore {
+	return &validatorStore{
+		maxEntries: maxEntries,
+		order:      list.New(),
+		entries:    make(map[string]*list.Element),
+	}
+}
+
+// apply adds the stored validators for req to its headers
+// and reports whether the request was made conditional.
+func (s *validatorStore) apply(req *http.Request) (validators, bool) {
+	if req.Method != http.MethodGet || req.Header.Get("If-None-Match") != "" || req.Header.Get("If-Modified-Since") != "" {
+		return validators{}, false
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	elem, ok := s.entries[req.URL.String()]
+	if !ok {
+		return validators{}, false
+	}
+
+	s.order.MoveToFront(elem)
+
+	v := elem.Value.(validators)
+
+	if v.etag != "" {
+		req.Header.Set("If-None-Match", v.etag)
+	}
+
+	if v.lastModified != "" {
+		req.Header.Set("If-Modified-Since", v.lastModified)
+	}
+
+	return v, true
+}
+
+// observe records the validators of successful GET responses.
+func (s *validatorStore) observe(req *http.Request, res *http.Response) {
+	if req.Method != http.MethodGet || res.StatusCode < 200 || res.StatusCode > 299 {
+		return
+	}
+
+	v := validators{
+		url:          req.URL.String(),
+		etag:         res.Header.Get("ETag"),
+		lastModified: res.Header.Get("Last-Modified"),
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if elem, ok := s.entries[v.url]; ok {
+		if v.etag == "" && v.lastModified == "" {
+			s.order.Remove(elem)
+			delete(s.entries, v.url)
+
+			return
+		}
+
+		elem.Value = v
+		s.order.MoveToFront(elem)
+
+		return
+	}
+
+	if v.etag == "" && v.lastModified == "" {
+		return
+	}
+
+	s.entries[v.url] = s.order.PushFront(v)
+
+	for s.order.Len() > s.maxEntries {
+		oldest := s.order.Back()
+		s.order.Remove(oldest)
+		delete(s.entries, oldest.Value.(validators).url)
+	}
+}
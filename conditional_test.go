@@ -0,0 +1,110 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithConditionalGETs(t *testing.T) {
+	t.Parallel()
+
+	var version atomic.Int32
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		etag := fmt.Sprintf(`"v%d"`, version.Load())
+
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+
+			return
+		}
+
+		w.Header().Set("ETag", etag)
+		w.Header().Set("Last-Modified", "Wed, 14 Oct 2026 08:00:00 GMT")
+		io.WriteString(w, etag)
+	}))
+	defer srv.Close()
+
+	c := NewClient(WithConditionalGETs{})
+	defer c.Close()
+
+	get := func() (string, error) {
+		t.Helper()
+
+		res, err := c.Get(context.Background(), srv.URL)
+		if err != nil {
+			return "", err
+		}
+		defer res.Body.Close()
+
+		body, err := io.ReadAll(res.Body)
+		require.NoError(t, err)
+
+		return string(body), nil
+	}
+
+	body, err := get()
+	require.NoError(t, err)
+	assert.Equal(t, `"v0"`, body)
+
+	_, err = get()
+	require.Error(t, err)
+	assert.True(t, IsNotModified(err))
+
+	var notModified *NotModifiedError
+	require.ErrorAs(t, err, &notModified)
+	assert.Equal(t, `"v0"`, notModified.ETag)
+	assert.Equal(t, "Wed, 14 Oct 2026 08:00:00 GMT", notModified.LastModified)
+
+	version.Store(1)
+
+	body, err = get()
+	require.NoError(t, err)
+	assert.Equal(t, `"v1"`, body)
+
+	// requests setting conditional headers handle 304 themselves
+	res, err := c.Get(context.Background(), srv.URL, WithRequestHeaders{"If-None-Match": {`"v1"`}})
+	require.NoError(t, err)
+	require.NoError(t, res.Body.Close())
+	assert.Equal(t, http.StatusNotModified, res.StatusCode)
+}
+
+func TestValidatorStoreEviction(t *testing.T) {
+	t.Parallel()
+
+	store := newValidatorStore(2)
+
+	observe := func(url string) {
+		req, err := http.NewRequest(http.MethodGet, url, nil)
+		require.NoError(t, err)
+
+		store.observe(req, &http.Response{StatusCode: http.StatusOK, Header: http.Header{"Etag": {`"x"`}}})
+	}
+
+	applied := func(url string) bool {
+		req, err := http.NewRequest(http.MethodGet, url, nil)
+		require.NoError(t, err)
+
+		_, ok := store.apply(req)
+
+		return ok
+	}
+
+	observe("https://api.example.com/a")
+	observe("https://api.example.com/b")
+	assert.True(t, applied("https://api.example.com/a"))
+
+	observe("https://api.example.com/c")
+
+	assert.True(t, applied("https://api.example.com/a"))
+	assert.False(t, applied("https://api.example.com/b"), "least recently used entry must be evicted")
+	assert.True(t, applied("https://api.example.com/c"))
+}
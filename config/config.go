@@ -0,0 +1,462 @@
+// Package config builds client.ClientOptions from a declarative
+// specification stored as YAML or JSON so that client behavior can
+// be driven from templated configuration files:
+//
+//	timeout: 30s
+//	retry:
+//	  maxRetries: 5
+//	  backoff:
+//	    initialInterval: 500ms
+//	    maxInterval: 10s
+//	auth:
+//	  type: bearer
+//	  tokenFile: /var/run/secrets/token
+//	proxy:
+//	  url: http://proxy.example.com:3128
+//	  noProxy: [localhost, 10.0.0.0/8]
+//	tls:
+//	  caFile: /etc/pki/ca.pem
+//
+// Fields which are left unset keep the defaults of the client package.
+package config
+
+import (
+	"bytes"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/mt-sre/client"
+	"gopkg.in/yaml.v3"
+)
+
+// Auth types supported by AuthSpec.
+const (
+	AuthTypeBearer            = "bearer"
+	AuthTypeBasic             = "basic"
+	AuthTypeClientCredentials = "clientCredentials"
+)
+
+// Spec declares the configuration of a client.
+type Spec struct {
+	// Timeout limits the total time taken by a request.
+	Timeout Duration `json:"timeout,omitempty" yaml:"timeout,omitempty"`
+	// DialTimeout limits the time taken to establish a connection.
+	DialTimeout Duration `json:"dialTimeout,omitempty" yaml:"dialTimeout,omitempty"`
+	// TLSHandshakeTimeout limits the time taken by the TLS handshake.
+	TLSHandshakeTimeout Duration `json:"tlsHandshakeTimeout,omitempty" yaml:"tlsHandshakeTimeout,omitempty"`
+	// ResponseHeaderTimeout limits the time spent waiting for response headers.
+	ResponseHeaderTimeout Duration `json:"responseHeaderTimeout,omitempty" yaml:"responseHeaderTimeout,omitempty"`
+	// BaseURL is used to resolve relative request URLs.
+	BaseURL string `json:"baseURL,omitempty" yaml:"baseURL,omitempty"`
+	// Headers are added to every request.
+	Headers map[string]string `json:"headers,omitempty" yaml:"headers,omitempty"`
+
+	Retry *RetrySpec `json:"retry,omitempty" yaml:"retry,omitempty"`
+	Auth  *AuthSpec  `json:"auth,omitempty" yaml:"auth,omitempty"`
+	Proxy *ProxySpec `json:"proxy,omitempty" yaml:"proxy,omitempty"`
+	TLS   *TLSSpec   `json:"tls,omitempty" yaml:"tls,omitempty"`
+}
+
+// RetrySpec configures retries of failed requests.
+type RetrySpec struct {
+	// MaxRetries is the maximum number of retries. The
+	// default of the RetryWrapper is used if unset.
+	MaxRetries *uint64 `json:"maxRetries,omitempty" yaml:"maxRetries,omitempty"`
+	// PerAttemptTimeout limits the time taken by each attempt.
+	PerAttemptTimeout Duration     `json:"perAttemptTimeout,omitempty" yaml:"perAttemptTimeout,omitempty"`
+	Backoff           *BackoffSpec `json:"backoff,omitempty" yaml:"backoff,omitempty"`
+}
+
+// BackoffSpec configures the exponential backoff between retries.
+type BackoffSpec struct {
+	InitialInterval Duration `json:"initialInterval,omitempty" yaml:"initialInterval,omitempty"`
+	MaxInterval     Duration `json:"maxInterval,omitempty" yaml:"maxInterval,omitempty"`
+	MaxElapsedTime  Duration `json:"maxElapsedTime,omitempty" yaml:"maxElapsedTime,omitempty"`
+	Multiplier      float64  `json:"multiplier,omitempty" yaml:"multiplier,omitempty"`
+}
+
+// AuthSpec configures authentication of requests.
+type AuthSpec struct {
+	// Type is one of "bearer", "basic" or "clientCredentials".
+	Type string `json:"type" yaml:"type"`
+	// Token is the bearer token. Either Token or
+	// TokenFile must be set for bearer auth.
+	Token string `json:"token,omitempty" yaml:"token,omitempty"`
+	// TokenFile is read for the bearer token and
+	// re-read periodically to pick up rotations.
+	TokenFile string `json:"tokenFile,omitempty" yaml:"tokenFile,omitempty"`
+	// Username and Password are used for basic auth.
+	Username string `json:"username,omitempty" yaml:"username,omitempty"`
+	Password string `json:"password,omitempty" yaml:"password,omitempty"`
+	// ClientID, ClientSecret, TokenURL and Scopes
+	// are used for the client credentials flow.
+	ClientID     string   `json:"clientID,omitempty" yaml:"clientID,omitempty"`
+	ClientSecret string   `json:"clientSecret,omitempty" yaml:"clientSecret,omitempty"`
+	TokenURL     string   `json:"tokenURL,omitempty" yaml:"tokenURL,omitempty"`
+	Scopes       []string `json:"scopes,omitempty" yaml:"scopes,omitempty"`
+}
+
+// ProxySpec configures the proxy requests are sent through.
+type ProxySpec struct {
+	URL     string   `json:"url,omitempty" yaml:"url,omitempty"`
+	NoProxy []string `json:"noProxy,omitempty" yaml:"noProxy,omitempty"`
+}
+
+// TLSSpec configures TLS.
+type TLSSpec struct {
+	// CAFile is a PEM bundle used instead of the
+	// system roots to verify server certificates.
+	CAFile string `json:"caFile,omitempty" yaml:"caFile,omitempty"`
+	// CertFile and KeyFile are the client certificate
+	// presented for mTLS. They are reloaded on rotation.
+	CertFile           string `json:"certFile,omitempty" yaml:"certFile,omitempty"`
+	KeyFile            string `json:"keyFile,omitempty" yaml:"keyFile,omitempty"`
+	InsecureSkipVerify bool   `json:"insecureSkipVerify,omitempty" yaml:"insecureSkipVerify,omitempty"`
+}
+
+// Load reads and validates the Spec stored at path.
+func Load(path string) (*Spec, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading config: %w", err)
+	}
+
+	return Parse(data)
+}
+
+// Parse decodes and validates a Spec from YAML or JSON data.
+// Unknown fields are rejected to surface typos.
+func Parse(data []byte) (*Spec, error) {
+	var spec Spec
+
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+
+	if err := dec.Decode(&spec); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("decoding config: %w", err)
+	}
+
+	if err := spec.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &spec, nil
+}
+
+// Validate reports all invalid settings of the Spec.
+func (s *Spec) Validate() error {
+	var errs []error
+
+	for name, d := range map[string]Duration{
+		"timeout":               s.Timeout,
+		"dialTimeout":           s.DialTimeout,
+		"tlsHandshakeTimeout":   s.TLSHandshakeTimeout,
+		"responseHeaderTimeout": s.ResponseHeaderTimeout,
+	} {
+		if d < 0 {
+			errs = append(errs, fmt.Errorf("%s must not be negative", name))
+		}
+	}
+
+	if s.BaseURL != "" {
+		if err := validateAbsoluteURL(s.BaseURL); err != nil {
+			errs = append(errs, fmt.Errorf("baseURL: %w", err))
+		}
+	}
+
+	if s.Retry != nil {
+		errs = append(errs, s.Retry.validate())
+	}
+
+	if s.Auth != nil {
+		errs = append(errs, s.Auth.validate())
+	}
+
+	if s.Proxy != nil && s.Proxy.URL != "" {
+		if err := validateAbsoluteURL(s.Proxy.URL); err != nil {
+			errs = append(errs, fmt.Errorf("proxy.url: %w", err))
+		}
+	}
+
+	if s.TLS != nil && (s.TLS.CertFile == "") != (s.TLS.KeyFile == "") {
+		errs = append(errs, errors.New("tls.certFile and tls.keyFile must be set together"))
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("invalid config: %w", err)
+	}
+
+	return nil
+}
+
+func (r *RetrySpec) validate() error {
+	var errs []error
+
+	if r.PerAttemptTimeout < 0 {
+		errs = append(errs, errors.New("retry.perAttemptTimeout must not be negative"))
+	}
+
+	if b := r.Backoff; b != nil {
+		if b.InitialInterval < 0 || b.MaxInterval < 0 || b.MaxElapsedTime < 0 {
+			errs = append(errs, errors.New("retry.backoff intervals must not be negative"))
+		}
+
+		if b.Multiplier != 0 && b.Multiplier < 1 {
+			errs = append(errs, errors.New("retry.backoff.multiplier must be at least 1"))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (a *AuthSpec) validate() error {
+	switch a.Type {
+	case AuthTypeBearer:
+		if (a.Token == "") == (a.TokenFile == "") {
+			return errors.New("auth: exactly one of token and tokenFile must be set for bearer auth")
+		}
+	case AuthTypeBasic:
+		if a.Username == "" {
+			return errors.New("auth: username must be set for basic auth")
+		}
+	case AuthTypeClientCredentials:
+		if a.ClientID == "" || a.TokenURL == "" {
+			return errors.New("auth: clientID and tokenURL must be set for the client credentials flow")
+		}
+
+		if err := validateAbsoluteURL(a.TokenURL); err != nil {
+			return fmt.Errorf("auth.tokenURL: %w", err)
+		}
+	default:
+		return fmt.Errorf("auth: unsupported type %q", a.Type)
+	}
+
+	return nil
+}
+
+func validateAbsoluteURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return err
+	}
+
+	if u.Scheme == "" || u.Host == "" {
+		return fmt.Errorf("%q is not an absolute url", raw)
+	}
+
+	return nil
+}
+
+// Options returns the ClientOptions described by the Spec. Files
+// referenced by the Spec which are needed to construct the options,
+// such as the CA bundle, are read immediately. Authentication is
+// registered as a wrapper named "auth" ahead of the retry wrapper,
+// which is named client.RetryWrapperName, so that every attempt is
+// authenticated.
+func (s *Spec) Options() ([]client.ClientOption, error) {
+	if err := s.Validate(); err != nil {
+		return nil, err
+	}
+
+	var opts []client.ClientOption
+
+	if s.Timeout > 0 {
+		opts = append(opts, client.WithTimeout(s.Timeout))
+	}
+
+	if s.DialTimeout > 0 {
+		opts = append(opts, client.WithDialTimeout(s.DialTimeout))
+	}
+
+	if s.TLSHandshakeTimeout > 0 {
+		opts = append(opts, client.WithTLSHandshakeTimeout(s.TLSHandshakeTimeout))
+	}
+
+	if s.ResponseHeaderTimeout > 0 {
+		opts = append(opts, client.WithResponseHeaderTimeout(s.ResponseHeaderTimeout))
+	}
+
+	if s.BaseURL != "" {
+		base, _ := url.Parse(s.BaseURL)
+
+		opts = append(opts, client.WithBaseURL{URL: base})
+	}
+
+	if len(s.Headers) > 0 {
+		header := make(client.WithDefaultHeaders)
+
+		for key, val := range s.Headers {
+			http.Header(header).Set(key, val)
+		}
+
+		opts = append(opts, header)
+	}
+
+	if s.TLS != nil {
+		tlsOpts, err := s.TLS.options()
+		if err != nil {
+			return nil, err
+		}
+
+		opts = append(opts, tlsOpts...)
+	}
+
+	if s.Proxy != nil {
+		if s.Proxy.URL != "" {
+			proxy, _ := url.Parse(s.Proxy.URL)
+
+			opts = append(opts, client.WithProxyURL{URL: proxy})
+		}
+
+		if len(s.Proxy.NoProxy) > 0 {
+			opts = append(opts, client.WithNoProxy(s.Proxy.NoProxy))
+		}
+	}
+
+	if s.Auth != nil {
+		opts = append(opts, client.WithNamedWrapper{
+			Name:    "auth",
+			Wrapper: s.Auth.wrapper(),
+		})
+	}
+
+	if s.Retry != nil {
+		opts = append(opts, client.WithNamedWrapper{
+			Name:    client.RetryWrapperName,
+			Wrapper: client.NewRetryWrapper(s.Retry.options()...),
+		})
+	}
+
+	return opts, nil
+}
+
+// NewClient returns a Client configured by the Spec with any
+// additional options applied afterwards.
+func (s *Spec) NewClient(opts ...client.ClientOption) (*client.Client, error) {
+	specOpts, err := s.Options()
+	if err != nil {
+		return nil, err
+	}
+
+	return client.NewClient(append(specOpts, opts...)...), nil
+}
+
+func (t *TLSSpec) options() ([]client.ClientOption, error) {
+	var opts []client.ClientOption
+
+	if t.CAFile != "" {
+		data, err := os.ReadFile(t.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("reading tls.caFile: %w", err)
+		}
+
+		pool := x509.NewCertPool()
+
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("tls.caFile %q contains no certificates", t.CAFile)
+		}
+
+		opts = append(opts, client.WithCACertPool{CertPool: pool})
+	}
+
+	if t.CertFile != "" {
+		opts = append(opts, client.WithClientCertificate{
+			CertFile: t.CertFile,
+			KeyFile:  t.KeyFile,
+			Reload:   true,
+		})
+	}
+
+	if t.InsecureSkipVerify {
+		opts = append(opts, client.WithInsecureSkipVerify{})
+	}
+
+	return opts, nil
+}
+
+func (a *AuthSpec) wrapper() client.TransportWrapper {
+	switch a.Type {
+	case AuthTypeBasic:
+		username, password := a.Username, a.Password
+
+		return client.MiddlewareWrapper(client.ModifyRequest(func(req *http.Request) error {
+			req.SetBasicAuth(username, password)
+
+			return nil
+		}))
+	case AuthTypeClientCredentials:
+		return client.NewOAUTHWrapper(client.WithClientCredentials{
+			ClientID:     a.ClientID,
+			ClientSecret: a.ClientSecret,
+			TokenURL:     a.TokenURL,
+			Scopes:       a.Scopes,
+		})
+	default:
+		if a.TokenFile != "" {
+			return client.NewServiceAccountTokenWrapper(client.WithTokenPath(a.TokenFile))
+		}
+
+		return client.NewOAUTHWrapper(client.WithAccessToken(a.Token))
+	}
+}
+
+func (r *RetrySpec) options() []client.RetryWrapperOption {
+	var opts []client.RetryWrapperOption
+
+	if r.MaxRetries != nil {
+		opts = append(opts, client.WithMaxRetries(*r.MaxRetries))
+	}
+
+	if r.PerAttemptTimeout > 0 {
+		opts = append(opts, client.WithPerAttemptTimeout(r.PerAttemptTimeout))
+	}
+
+	if b := r.Backoff; b != nil {
+		var boOpts []client.ExponentialBackoffOption
+
+		if b.InitialInterval > 0 {
+			boOpts = append(boOpts, client.WithInitialInterval(b.InitialInterval))
+		}
+
+		if b.MaxInterval > 0 {
+			boOpts = append(boOpts, client.WithMaxInterval(b.MaxInterval))
+		}
+
+		if b.MaxElapsedTime > 0 {
+			boOpts = append(boOpts, client.WithMaxElapsedTime(b.MaxElapsedTime))
+		}
+
+		if b.Multiplier > 0 {
+			boOpts = append(boOpts, client.WithMultiplier(b.Multiplier))
+		}
+
+		opts = append(opts, client.WithBackoffGenerator(client.ExponentialBackoffGenerator(boOpts...)))
+	}
+
+	return opts
+}
+
+// Duration is a time.Duration encoded as
+// a string such as "1m30s" in YAML and JSON.
+type Duration time.Duration
+
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+func (d *Duration) UnmarshalText(text []byte) error {
+	parsed, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+
+	*d = Duration(parsed)
+
+	return nil
+}
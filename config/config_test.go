@@ -0,0 +1,235 @@
+package config
+
+import (
+	"context"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+
+	maxRetries := uint64(5)
+
+	expected := &Spec{
+		Timeout: Duration(30 * time.Second),
+		BaseURL: "https://api.example.com/v1/",
+		Headers: map[string]string{"X-Tenant": "a"},
+		Retry: &RetrySpec{
+			MaxRetries: &maxRetries,
+			Backoff: &BackoffSpec{
+				InitialInterval: Duration(500 * time.Millisecond),
+				Multiplier:      2,
+			},
+		},
+		Auth: &AuthSpec{
+			Type:  AuthTypeBearer,
+			Token: "token",
+		},
+		Proxy: &ProxySpec{
+			URL:     "http://proxy.example.com:3128",
+			NoProxy: []string{"localhost"},
+		},
+	}
+
+	for name, data := range map[string]string{
+		"yaml": `
+timeout: 30s
+baseURL: https://api.example.com/v1/
+headers:
+  X-Tenant: a
+retry:
+  maxRetries: 5
+  backoff:
+    initialInterval: 500ms
+    multiplier: 2
+auth:
+  type: bearer
+  token: token
+proxy:
+  url: http://proxy.example.com:3128
+  noProxy: [localhost]
+`,
+		"json": `{
+  "timeout": "30s",
+  "baseURL": "https://api.example.com/v1/",
+  "headers": {"X-Tenant": "a"},
+  "retry": {"maxRetries": 5, "backoff": {"initialInterval": "500ms", "multiplier": 2}},
+  "auth": {"type": "bearer", "token": "token"},
+  "proxy": {"url": "http://proxy.example.com:3128", "noProxy": ["localhost"]}
+}`,
+	} {
+		data := data
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			spec, err := Parse([]byte(data))
+			require.NoError(t, err)
+
+			assert.Equal(t, expected, spec)
+
+			_, err = spec.Options()
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		Data        string
+		ExpectedErr []string
+	}{
+		"unknown field": {
+			Data:        "timeot: 1s",
+			ExpectedErr: []string{"timeot"},
+		},
+		"malformed duration": {
+			Data:        "timeout: soon",
+			ExpectedErr: []string{"soon"},
+		},
+		"negative timeout": {
+			Data:        "timeout: -1s",
+			ExpectedErr: []string{"timeout must not be negative"},
+		},
+		"relative base url": {
+			Data:        "baseURL: /v1",
+			ExpectedErr: []string{"baseURL"},
+		},
+		"invalid backoff": {
+			Data:        "retry: {backoff: {multiplier: 0.5}}",
+			ExpectedErr: []string{"multiplier"},
+		},
+		"unsupported auth": {
+			Data:        "auth: {type: digest}",
+			ExpectedErr: []string{`unsupported type "digest"`},
+		},
+		"bearer without token": {
+			Data:        "auth: {type: bearer}",
+			ExpectedErr: []string{"token"},
+		},
+		"client credentials without token url": {
+			Data:        "auth: {type: clientCredentials, clientID: id}",
+			ExpectedErr: []string{"tokenURL"},
+		},
+		"cert without key": {
+			Data:        "tls: {certFile: cert.pem}",
+			ExpectedErr: []string{"tls.certFile and tls.keyFile"},
+		},
+		"multiple errors": {
+			Data:        "{timeout: -1s, proxy: {url: proxy}}",
+			ExpectedErr: []string{"timeout", "proxy.url"},
+		},
+	} {
+		tc := tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := Parse([]byte(tc.Data))
+			require.Error(t, err)
+
+			for _, expected := range tc.ExpectedErr {
+				assert.ErrorContains(t, err, expected)
+			}
+		})
+	}
+}
+
+func TestParseEmpty(t *testing.T) {
+	t.Parallel()
+
+	spec, err := Parse(nil)
+	require.NoError(t, err)
+
+	opts, err := spec.Options()
+	require.NoError(t, err)
+	assert.Empty(t, opts)
+}
+
+func TestSpecNewClient(t *testing.T) {
+	t.Parallel()
+
+	var attempts atomic.Int32
+
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, ok := r.BasicAuth(); !ok || user != "user" || pass != "pass" {
+			w.WriteHeader(http.StatusUnauthorized)
+
+			return
+		}
+
+		if attempts.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+
+			return
+		}
+
+		w.Header().Set("X-Path", r.URL.Path)
+		w.Header().Set("X-Tenant", r.Header.Get("X-Tenant"))
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	caFile := filepath.Join(dir, "ca.pem")
+
+	require.NoError(t, os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{
+		Type:  "CERTIFICATE",
+		Bytes: srv.Certificate().Raw,
+	}), 0o600))
+
+	configFile := filepath.Join(dir, "client.yaml")
+
+	require.NoError(t, os.WriteFile(configFile, []byte(`
+baseURL: `+srv.URL+`/v1/
+headers:
+  x-tenant: a
+retry:
+  maxRetries: 2
+  backoff:
+    initialInterval: 1ms
+auth:
+  type: basic
+  username: user
+  password: pass
+tls:
+  caFile: `+caFile+`
+`), 0o600))
+
+	spec, err := Load(configFile)
+	require.NoError(t, err)
+
+	c, err := spec.NewClient()
+	require.NoError(t, err)
+
+	defer c.Close()
+
+	res, err := c.Get(context.Background(), "clusters")
+	require.NoError(t, err)
+	require.NoError(t, res.Body.Close())
+
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, "/v1/clusters", res.Header.Get("X-Path"))
+	assert.Equal(t, "a", res.Header.Get("X-Tenant"))
+	assert.Equal(t, int32(2), attempts.Load())
+}
+
+func TestSpecOptionsMissingCAFile(t *testing.T) {
+	t.Parallel()
+
+	spec := &Spec{TLS: &TLSSpec{CAFile: filepath.Join(t.TempDir(), "missing.pem")}}
+
+	_, err := spec.Options()
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
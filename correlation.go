@@ -0,0 +1,110 @@
+package client
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// NewCorrelationIDWrapper returns a TransportWrapper which assigns every
+// logical request a correlation ID sent in the RequestIDHeader, or the
+// header configured with WithCorrelationIDHeader, so that the request
+// can be tracked across proxies and servers. An ID already present in
+// the header or carried by the request context through
+// ContextWithRequestID is reused; otherwise a random UUID is generated.
+// The ID is added to the request context so that it appears in the log
+// messages of inner wrappers and can be retrieved from the response
+// with ResponseRequestID. The CorrelationIDWrapper should be applied
+// after any RetryWrapper so that the ID is stable across retries.
+func NewCorrelationIDWrapper(opts ...CorrelationIDOption) *CorrelationIDWrapper {
+	var cfg CorrelationIDConfig
+
+	cfg.Option(opts...)
+	cfg.Default()
+
+	return &CorrelationIDWrapper{
+		cfg: cfg,
+	}
+}
+
+type CorrelationIDWrapper struct {
+	cfg CorrelationIDConfig
+	rt  http.RoundTripper
+}
+
+func (w *CorrelationIDWrapper) Wrap(rt http.RoundTripper) http.RoundTripper {
+	w.rt = rt
+
+	return w
+}
+
+func (w *CorrelationIDWrapper) RoundTrip(req *http.Request) (*http.Response, error) {
+	id := req.Header.Get(w.cfg.Header)
+
+	if id == "" {
+		id, _ = RequestIDFromContext(req.Context())
+	}
+
+	if id == "" {
+		var err error
+
+		if id, err = w.cfg.generate(); err != nil {
+			if req.Body != nil {
+				req.Body.Close()
+			}
+
+			return nil, fmt.Errorf("generating correlation id: %w", err)
+		}
+	}
+
+	out := req.Clone(ContextWithRequestID(req.Context(), id))
+	out.Header.Set(w.cfg.Header, id)
+
+	return w.rt.RoundTrip(out)
+}
+
+// ResponseRequestID returns the request ID of the request
+// which produced res as assigned by a CorrelationIDWrapper
+// or provided through ContextWithRequestID.
+func ResponseRequestID(res *http.Response) (string, bool) {
+	if res == nil || res.Request == nil {
+		return "", false
+	}
+
+	return RequestIDFromContext(res.Request.Context())
+}
+
+type CorrelationIDConfig struct {
+	// Header is the header the correlation ID is
+	// sent in. Defaults to RequestIDHeader.
+	Header string
+
+	generate func() (string, error)
+}
+
+func (c *CorrelationIDConfig) Option(opts ...CorrelationIDOption) {
+	for _, opt := range opts {
+		opt.ConfigureCorrelationID(c)
+	}
+}
+
+func (c *CorrelationIDConfig) Default() {
+	if c.Header == "" {
+		c.Header = RequestIDHeader
+	}
+
+	if c.generate == nil {
+		c.generate = newUUID
+	}
+}
+
+type CorrelationIDOption interface {
+	ConfigureCorrelationID(*CorrelationIDConfig)
+}
+
+// WithCorrelationIDHeader configures a CorrelationIDWrapper
+// to send the correlation ID in the given header.
+type WithCorrelationIDHeader string
+
+func (h WithCorrelationIDHeader) ConfigureCorrelationID(c *CorrelationIDConfig) {
+	c.Header = string(h)
+}
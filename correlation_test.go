@@ -0,0 +1,155 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/mt-sre/client/internal/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCorrelationIDWrapperInterfaces(t *testing.T) {
+	t.Parallel()
+
+	require.Implements(t, new(http.RoundTripper), new(CorrelationIDWrapper))
+
+	require.Implements(t, new(TransportWrapper), new(CorrelationIDWrapper))
+}
+
+func TestCorrelationIDWrapper(t *testing.T) {
+	t.Parallel()
+
+	errFailed := errors.New("failed")
+
+	for name, tc := range map[string]struct {
+		Options     []CorrelationIDOption
+		Ctx         context.Context
+		Header      http.Header
+		Generate    func() (string, error)
+		ExpectedID  string
+		ExpectedKey string
+		ExpectedErr error
+	}{
+		"generated": {
+			Ctx:         context.Background(),
+			ExpectedID:  "generated",
+			ExpectedKey: RequestIDHeader,
+		},
+		"existing header": {
+			Ctx:         ContextWithRequestID(context.Background(), "ctx-id"),
+			Header:      http.Header{RequestIDHeader: {"header-id"}},
+			ExpectedID:  "header-id",
+			ExpectedKey: RequestIDHeader,
+		},
+		"context": {
+			Ctx:         ContextWithRequestID(context.Background(), "ctx-id"),
+			ExpectedID:  "ctx-id",
+			ExpectedKey: RequestIDHeader,
+		},
+		"custom header": {
+			Options:     []CorrelationIDOption{WithCorrelationIDHeader("X-Correlation-ID")},
+			Ctx:         context.Background(),
+			ExpectedID:  "generated",
+			ExpectedKey: "X-Correlation-ID",
+		},
+		"generation failure": {
+			Ctx: context.Background(),
+			Generate: func() (string, error) {
+				return "", errFailed
+			},
+			ExpectedErr: errFailed,
+		},
+	} {
+		tc := tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var sent []*http.Request
+
+			mrt := &testutils.MockRoundTripper{}
+			mrt.
+				On("RoundTrip", mock.Anything).
+				Run(func(args mock.Arguments) {
+					sent = append(sent, args.Get(0).(*http.Request))
+				}).
+				Return(&http.Response{StatusCode: http.StatusOK, Body: http.NoBody}, nil)
+
+			w := NewCorrelationIDWrapper(tc.Options...)
+			w.cfg.generate = func() (string, error) { return "generated", nil }
+
+			if tc.Generate != nil {
+				w.cfg.generate = tc.Generate
+			}
+
+			req := testutils.MockRequest(t, http.MethodGet, nil).WithContext(tc.Ctx)
+
+			for key, vals := range tc.Header {
+				for _, val := range vals {
+					req.Header.Add(key, val)
+				}
+			}
+
+			_, err := w.Wrap(mrt).RoundTrip(req)
+
+			if tc.ExpectedErr != nil {
+				assert.ErrorIs(t, err, tc.ExpectedErr)
+				mrt.AssertNotCalled(t, "RoundTrip", mock.Anything)
+
+				return
+			}
+
+			require.NoError(t, err)
+			require.Len(t, sent, 1)
+
+			assert.Equal(t, tc.ExpectedID, sent[0].Header.Get(tc.ExpectedKey))
+
+			id, ok := RequestIDFromContext(sent[0].Context())
+			require.True(t, ok)
+			assert.Equal(t, tc.ExpectedID, id)
+		})
+	}
+}
+
+func TestCorrelationIDWrapperStableAcrossRetries(t *testing.T) {
+	t.Parallel()
+
+	var ids []string
+
+	next := Handler(func(req *http.Request) (*http.Response, error) {
+		ids = append(ids, req.Header.Get(RequestIDHeader))
+
+		status := http.StatusServiceUnavailable
+		if len(ids) > 1 {
+			status = http.StatusOK
+		}
+
+		return &http.Response{StatusCode: status, Body: http.NoBody, Request: req}, nil
+	})
+
+	c := NewClient(
+		WithTransport{RoundTripper: next},
+		WithWrapper{TransportWrapper: NewRetryWrapper(
+			WithBackoffGenerator(NoBackoffGenerator()),
+			WithMaxRetries(1),
+		)},
+		WithWrapper{TransportWrapper: NewCorrelationIDWrapper()},
+	)
+	defer c.Close()
+
+	res, err := c.Get(context.Background(), "https://api.example.com")
+	require.NoError(t, err)
+	require.NoError(t, res.Body.Close())
+
+	require.Len(t, ids, 2)
+	assert.NotEmpty(t, ids[0])
+	assert.Equal(t, ids[0], ids[1])
+
+	id, ok := ResponseRequestID(res)
+	require.True(t, ok)
+	assert.Equal(t, ids[0], id)
+}
@@ -0,0 +1,285 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"mime"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"sync"
+	"sync/atomic"
+)
+
+// DebugDumpWrapperName is the name under which WithDebugDump
+// registers its DebugDumpWrapper.
+const DebugDumpWrapperName = "debug dump"
+
+const defaultMaxDumpBodySize = 64 << 10
+
+// NewDebugDumpWrapper returns a TransportWrapper which writes every
+// request and response it sees to w in HTTP/1.1 wire format as
+// produced by httputil.DumpRequestOut and httputil.DumpResponse.
+// Credentials in headers are redacted and bodies are truncated to
+// the configured size. Bodies of streamed responses such as event
+// streams, NDJSON and responses of unknown length as well as
+// of switching protocols responses are never dumped as reading them
+// would block.
+// Dumping can be toggled at runtime using SetEnabled.
+func NewDebugDumpWrapper(w io.Writer, opts ...DebugDumpOption) *DebugDumpWrapper {
+	var cfg DebugDumpConfig
+
+	cfg.Option(opts...)
+	cfg.Default()
+
+	wrapper := &DebugDumpWrapper{
+		cfg: cfg,
+		out: w,
+	}
+
+	wrapper.enabled.Store(!cfg.Disabled)
+
+	return wrapper
+}
+
+type DebugDumpWrapper struct {
+	cfg     DebugDumpConfig
+	rt      http.RoundTripper
+	enabled atomic.Bool
+
+	// mu serializes dumps so that
+	// concurrent requests do not interleave.
+	mu  sync.Mutex
+	out io.Writer
+}
+
+func (w *DebugDumpWrapper) Wrap(rt http.RoundTripper) http.RoundTripper {
+	w.rt = rt
+
+	return w
+}
+
+// SetEnabled starts or stops dumping of subsequent requests.
+func (w *DebugDumpWrapper) SetEnabled(enabled bool) {
+	w.enabled.Store(enabled)
+}
+
+// Enabled reports whether requests are currently dumped.
+func (w *DebugDumpWrapper) Enabled() bool {
+	return w.enabled.Load()
+}
+
+func (w *DebugDumpWrapper) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !w.Enabled() {
+		return w.rt.RoundTrip(req)
+	}
+
+	var reqBody []byte
+
+	if hasBody(req) {
+		prefix, body, err := w.peekBody(req.Body)
+		if err != nil {
+			return nil, fmt.Errorf("reading request body: %w", err)
+		}
+
+		out := req.Clone(req.Context())
+		out.Body = body
+
+		req, reqBody = out, prefix
+	}
+
+	w.dumpRequest(req, reqBody)
+
+	res, err := w.rt.RoundTrip(req)
+	if err != nil {
+		w.write(fmt.Appendf(nil, "< %s %s: %v\n\n", req.Method, redactURL(req.Context(), req.URL), err))
+
+		return nil, err
+	}
+
+	var resBody []byte
+
+	if dumpableResponseBody(res) {
+		prefix, body, err := w.peekBody(res.Body)
+		if err != nil {
+			res.Body.Close()
+
+			return nil, fmt.Errorf("reading response body: %w", err)
+		}
+
+		res.Body, resBody = body, prefix
+	}
+
+	w.dumpResponse(req.Context(), res, resBody)
+
+	return res, nil
+}
+
+// peekBody reads up to the configured number of bytes from body
+// returning them along with a body which yields the full content.
+func (w *DebugDumpWrapper) peekBody(body io.ReadCloser) ([]byte, io.ReadCloser, error) {
+	if w.cfg.MaxBodySize < 0 {
+		return nil, body, nil
+	}
+
+	var buf bytes.Buffer
+
+	_, err := io.Copy(&buf, io.LimitReader(body, w.cfg.MaxBodySize+1))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	prefix := buf.Bytes()
+
+	return prefix, &peekedBody{
+		Reader: io.MultiReader(bytes.NewReader(prefix), body),
+		Closer: body,
+	}, nil
+}
+
+func (w *DebugDumpWrapper) dumpRequest(req *http.Request, body []byte) {
+	out := req.Clone(req.Context())
+
+	if header := redactHeader(req.Context(), req.Header); header != nil {
+		out.Header = header
+	}
+
+	if u, err := url.Parse(redactURL(req.Context(), req.URL)); err == nil {
+		out.URL = u
+	}
+
+	head, err := httputil.DumpRequestOut(out, false)
+	if err != nil {
+		head = fmt.Appendf(nil, "dumping request: %v\r\n\r\n", err)
+	}
+
+	w.write(w.appendBody(append([]byte("> "), head...), body))
+}
+
+func (w *DebugDumpWrapper) dumpResponse(ctx context.Context, res *http.Response, body []byte) {
+	out := *res
+	out.Header = redactHeader(ctx, res.Header)
+	out.Body = nil
+
+	head, err := httputil.DumpResponse(&out, false)
+	if err != nil {
+		head = fmt.Appendf(nil, "dumping response: %v\r\n\r\n", err)
+	}
+
+	w.write(w.appendBody(append([]byte("< "), head...), body))
+}
+
+func (w *DebugDumpWrapper) appendBody(dump, body []byte) []byte {
+	if w.cfg.MaxBodySize >= 0 && int64(len(body)) > w.cfg.MaxBodySize {
+		dump = append(dump, body[:w.cfg.MaxBodySize]...)
+		dump = append(dump, "\n[truncated]"...)
+	} else {
+		dump = append(dump, body...)
+	}
+
+	return append(dump, "\n\n"...)
+}
+
+func (w *DebugDumpWrapper) write(p []byte) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.out.Write(p)
+}
+
+// dumpableResponseBody reports whether the body of res
+// can be read without waiting on a long lived stream.
+func dumpableResponseBody(res *http.Response) bool {
+	if res.Body == nil || res.Body == http.NoBody || res.StatusCode == http.StatusSwitchingProtocols {
+		return false
+	}
+
+	if isStreamedMediaType(res.Header.Get("Content-Type")) {
+		return false
+	}
+
+	// bodies of unknown length, whether chunked, sent over
+	// HTTP/2 or delimited by closing the connection, may be
+	// streamed indefinitely, e.g. by watch endpoints
+	return res.ContentLength >= 0
+}
+
+// isStreamedMediaType reports whether contentType denotes
+// a body which is streamed for as long as it is read.
+func isStreamedMediaType(contentType string) bool {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+
+	switch mediaType {
+	case "text/event-stream", "application/x-ndjson", "application/json-seq":
+		return true
+	default:
+		return false
+	}
+}
+
+type peekedBody struct {
+	io.Reader
+	io.Closer
+}
+
+type DebugDumpConfig struct {
+	// MaxBodySize limits the portion of bodies which is dumped.
+	// A negative value omits bodies. Defaults to 64 KiB.
+	MaxBodySize int64
+	// Disabled causes dumping to start disabled.
+	Disabled bool
+}
+
+func (c *DebugDumpConfig) Option(opts ...DebugDumpOption) {
+	for _, opt := range opts {
+		opt.ConfigureDebugDump(c)
+	}
+}
+
+func (c *DebugDumpConfig) Default() {
+	if c.MaxBodySize == 0 {
+		c.MaxBodySize = defaultMaxDumpBodySize
+	}
+}
+
+type DebugDumpOption interface {
+	ConfigureDebugDump(*DebugDumpConfig)
+}
+
+// WithMaxDumpBodySize limits the portion of bodies which
+// is dumped. A negative value omits bodies entirely.
+type WithMaxDumpBodySize int64
+
+func (s WithMaxDumpBodySize) ConfigureDebugDump(c *DebugDumpConfig) {
+	c.MaxBodySize = int64(s)
+}
+
+// WithDumpDisabled causes dumping to start disabled
+// until it is enabled using SetEnabled.
+type WithDumpDisabled struct{}
+
+func (WithDumpDisabled) ConfigureDebugDump(c *DebugDumpConfig) {
+	c.Disabled = true
+}
+
+// WithDebugDump configures a Client instance to dump every attempt
+// of its requests to Writer using a DebugDumpWrapper. The wrapper is
+// registered as DebugDumpWrapperName directly above connection
+// tracing so that the dump reflects the headers set by all other
+// wrappers. Dumping can be toggled at runtime by retrieving the
+// wrapper through Client.Wrappers.
+type WithDebugDump struct {
+	Writer  io.Writer
+	Options []DebugDumpOption
+}
+
+func (d WithDebugDump) ConfigureClient(c *ClientConfig) {
+	c.register(NamedWrapper{
+		Name:     DebugDumpWrapperName,
+		Priority: math.MinInt + 1,
+		Wrapper:  NewDebugDumpWrapper(d.Writer, d.Options...),
+	})
+}
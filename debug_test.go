@@ -0,0 +1,179 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDebugDumpWrapperInterfaces(t *testing.T) {
+	t.Parallel()
+
+	require.Implements(t, new(http.RoundTripper), new(DebugDumpWrapper))
+
+	require.Implements(t, new(TransportWrapper), new(DebugDumpWrapper))
+}
+
+func TestDebugDumpWrapper(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		Options       []DebugDumpOption
+		ContentType   string
+		UnknownLength bool
+		HTTP2         bool
+		Expected      []string
+		NotExpected   []string
+	}{
+		"full": {
+			Expected: []string{
+				"> POST /items?token=REDACTED HTTP/1.1",
+				"Authorization: REDACTED",
+				"X-Trace: abc",
+				"request-body",
+				"< HTTP/1.1 200 OK",
+				"Set-Cookie: REDACTED",
+				"response-body",
+			},
+			NotExpected: []string{"secret", "session"},
+		},
+		"truncated": {
+			Options:     []DebugDumpOption{WithMaxDumpBodySize(4)},
+			Expected:    []string{"requ\n[truncated]", "resp\n[truncated]"},
+			NotExpected: []string{"request-body", "response-body"},
+		},
+		"bodies omitted": {
+			Options:     []DebugDumpOption{WithMaxDumpBodySize(-1)},
+			Expected:    []string{"> POST", "< HTTP/1.1 200 OK"},
+			NotExpected: []string{"request-body", "response-body"},
+		},
+		"event stream": {
+			ContentType: "text/event-stream",
+			Expected:    []string{"request-body", "Content-Type: text/event-stream"},
+			NotExpected: []string{"response-body"},
+		},
+		"ndjson": {
+			ContentType: "application/x-ndjson",
+			Expected:    []string{"request-body", "Content-Type: application/x-ndjson"},
+			NotExpected: []string{"response-body"},
+		},
+		"chunked": {
+			UnknownLength: true,
+			Expected:      []string{"request-body", "Transfer-Encoding: chunked"},
+			NotExpected:   []string{"response-body"},
+		},
+		"http2 unknown length": {
+			UnknownLength: true,
+			HTTP2:         true,
+			Expected:      []string{"request-body", "< HTTP/2.0 200 OK"},
+			NotExpected:   []string{"response-body"},
+		},
+	} {
+		tc := tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var out bytes.Buffer
+
+			srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+				assert.Equal(t, "request-body", string(body))
+
+				if tc.ContentType != "" {
+					w.Header().Set("Content-Type", tc.ContentType)
+				}
+
+				http.SetCookie(w, &http.Cookie{Name: "session", Value: "session"})
+
+				if tc.UnknownLength {
+					// flushing before writing the body
+					// leaves its length unknown
+					w.(http.Flusher).Flush()
+				}
+
+				io.WriteString(w, "response-body")
+			}))
+
+			opts := []ClientOption{WithDebugDump{Writer: &out, Options: tc.Options}}
+
+			if tc.HTTP2 {
+				srv.EnableHTTP2 = true
+				srv.StartTLS()
+
+				opts = append(opts, WithTransport{RoundTripper: srv.Client().Transport})
+			} else {
+				srv.Start()
+			}
+			defer srv.Close()
+
+			c := NewClient(opts...)
+			defer c.Close()
+
+			res, err := c.Post(context.Background(), srv.URL+"/items?token=secret", strings.NewReader("request-body"),
+				WithRequestHeaders{"Authorization": {"Bearer secret"}, "X-Trace": {"abc"}},
+			)
+			require.NoError(t, err)
+
+			body, err := io.ReadAll(res.Body)
+			require.NoError(t, err)
+			require.NoError(t, res.Body.Close())
+
+			assert.Equal(t, "response-body", string(body))
+
+			for _, s := range tc.Expected {
+				assert.Contains(t, out.String(), s)
+			}
+
+			for _, s := range tc.NotExpected {
+				assert.NotContains(t, out.String(), s)
+			}
+		})
+	}
+}
+
+func TestDebugDumpWrapperToggle(t *testing.T) {
+	t.Parallel()
+
+	next := Handler(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusNoContent, Body: http.NoBody, Request: req}, nil
+	})
+
+	var out bytes.Buffer
+
+	c := NewClient(
+		WithTransport{RoundTripper: next},
+		WithDebugDump{Writer: &out, Options: []DebugDumpOption{WithDumpDisabled{}}},
+	)
+	defer c.Close()
+
+	wrappers := c.Wrappers()
+	require.Len(t, wrappers, 1)
+	require.Equal(t, DebugDumpWrapperName, wrappers[0].Name)
+
+	dump, ok := wrappers[0].Wrapper.(*DebugDumpWrapper)
+	require.True(t, ok)
+
+	do := func() {
+		t.Helper()
+
+		res, err := c.Get(context.Background(), "https://api.example.com")
+		require.NoError(t, err)
+		require.NoError(t, res.Body.Close())
+	}
+
+	do()
+	assert.Empty(t, out.String())
+
+	dump.SetEnabled(true)
+	do()
+	assert.Contains(t, out.String(), "> GET / HTTP/1.1")
+	assert.Contains(t, out.String(), "204 No Content")
+}
@@ -0,0 +1,155 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// DefaultDebugPath is the path RegisterDebugHandler
+// registers the debug handler at by default.
+const DefaultDebugPath = "/debug/httpclient"
+
+// DebugStateProvider is implemented by TransportWrappers which expose
+// their live state, e.g. retry budgets or throttled hosts, through the
+// handler returned by Client.DebugHandler. DebugState must be safe for
+// concurrent use and return a value which can be encoded as JSON.
+type DebugStateProvider interface {
+	DebugState() any
+}
+
+// DebugInfo is served by the handler returned by Client.DebugHandler.
+type DebugInfo struct {
+	Stats    Stats          `json:"stats"`
+	Config   DebugConfig    `json:"config"`
+	Wrappers []DebugWrapper `json:"wrappers"`
+	Time     time.Time      `json:"time"`
+}
+
+// DebugConfig is the configuration of a Client
+// with URLs and headers redacted.
+type DebugConfig struct {
+	BaseURL               string              `json:"baseURL,omitempty"`
+	Header                map[string][]string `json:"header,omitempty"`
+	Timeout               time.Duration       `json:"timeout,omitempty"`
+	DialTimeout           time.Duration       `json:"dialTimeout,omitempty"`
+	TLSHandshakeTimeout   time.Duration       `json:"tlsHandshakeTimeout,omitempty"`
+	ResponseHeaderTimeout time.Duration       `json:"responseHeaderTimeout,omitempty"`
+	NoProxy               []string            `json:"noProxy,omitempty"`
+	DNSCacheTTL           time.Duration       `json:"dnsCacheTTL,omitempty"`
+	RequireHTTPS          bool                `json:"requireHTTPS,omitempty"`
+	InsecureHosts         []string            `json:"insecureHosts,omitempty"`
+	ErrorOnNon2xx         bool                `json:"errorOnNon2xx,omitempty"`
+	InsecureSkipVerify    bool                `json:"insecureSkipVerify,omitempty"`
+	Transport             string              `json:"transport"`
+}
+
+// DebugWrapper describes a registered wrapper
+// and its live state if it provides one.
+type DebugWrapper struct {
+	Name     string `json:"name"`
+	Priority int    `json:"priority"`
+	State    any    `json:"state,omitempty"`
+}
+
+// DebugInfo returns the statistics, the redacted configuration and
+// the wrappers of the Client along with the state of wrappers
+// implementing DebugStateProvider.
+func (c *Client) DebugInfo() DebugInfo {
+	ctx := context.Background()
+	if c.cfg.Redactor != nil {
+		ctx = ContextWithRedactor(ctx, c.cfg.Redactor)
+	}
+
+	// WithCADirectory skips the default verification
+	// to verify certificates itself
+	insecure := c.cfg.TLSConfig != nil && c.cfg.TLSConfig.InsecureSkipVerify && c.cfg.caDirectory == nil
+
+	cfg := DebugConfig{
+		Header:                redactHeader(ctx, c.cfg.Header),
+		Timeout:               c.cfg.Timeout,
+		DialTimeout:           c.cfg.DialTimeout,
+		TLSHandshakeTimeout:   c.cfg.TLSHandshakeTimeout,
+		ResponseHeaderTimeout: c.cfg.ResponseHeaderTimeout,
+		NoProxy:               c.cfg.NoProxy,
+		DNSCacheTTL:           c.cfg.DNSCacheTTL,
+		RequireHTTPS:          c.cfg.RequireHTTPS,
+		InsecureHosts:         c.cfg.InsecureHosts,
+		ErrorOnNon2xx:         c.cfg.ErrorOnNon2xx,
+		InsecureSkipVerify:    insecure,
+		Transport:             fmt.Sprintf("%T", c.cfg.Transport),
+	}
+
+	if c.cfg.BaseURL != nil {
+		cfg.BaseURL = redactURL(ctx, c.cfg.BaseURL)
+	}
+
+	wrappers := c.Wrappers()
+	info := DebugInfo{
+		Stats:    c.Stats(),
+		Config:   cfg,
+		Wrappers: make([]DebugWrapper, 0, len(wrappers)),
+		Time:     time.Now(),
+	}
+
+	for _, nw := range wrappers {
+		dw := DebugWrapper{
+			Name:     nw.Name,
+			Priority: nw.Priority,
+		}
+
+		if provider, ok := nw.Wrapper.(DebugStateProvider); ok {
+			dw.State = provider.DebugState()
+		}
+
+		info.Wrappers = append(info.Wrappers, dw)
+	}
+
+	return info
+}
+
+// DebugHandler returns a http.Handler serving the DebugInfo
+// of the Client as JSON for operators to inspect the state
+// of the Client in a running service.
+func (c *Client) DebugHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+
+		if err := enc.Encode(c.DebugInfo()); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+}
+
+// RegisterDebugHandler registers the handler returned by DebugHandler
+// with mux at path or DefaultDebugPath if path is empty. The handler
+// should only be exposed on listeners reachable by operators.
+func (c *Client) RegisterDebugHandler(mux *http.ServeMux, path string) {
+	if path == "" {
+		path = DefaultDebugPath
+	}
+
+	mux.Handle(path, c.DebugHandler())
+}
+
+// PublishExpvar publishes the DebugInfo of the Client as the expvar
+// variable name so that it is served by the expvar handler at
+// /debug/vars. Like expvar.Publish it panics if name is already in use.
+func (c *Client) PublishExpvar(name string) {
+	expvar.Publish(name, expvar.Func(func() any {
+		return c.DebugInfo()
+	}))
+}
@@ -0,0 +1,85 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientDebugHandler(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Remaining", "5")
+		w.Header().Set("X-RateLimit-Reset", "3600")
+	}))
+	defer srv.Close()
+
+	base, err := url.Parse(srv.URL + "/api?token=secret")
+	require.NoError(t, err)
+
+	c := NewClient(
+		WithBaseURL{URL: base},
+		WithDefaultHeaders{"Authorization": {"Bearer secret"}},
+		WithNamedWrapper{Name: "retry", Priority: 10, Wrapper: NewRetryWrapper(
+			WithMaxRetries(3),
+			WithRetryBudget{Ratio: 0.5},
+		)},
+		WithNamedWrapper{Name: "ratelimit", Priority: 20, Wrapper: NewRateLimitWrapper()},
+	)
+	defer c.Close()
+
+	res, err := c.Get(context.Background(), srv.URL)
+	require.NoError(t, err)
+	require.NoError(t, res.Body.Close())
+
+	mux := http.NewServeMux()
+	c.RegisterDebugHandler(mux, "")
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, DefaultDebugPath, nil))
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	assert.NotContains(t, rec.Body.String(), "secret")
+
+	var info struct {
+		Stats    Stats
+		Config   DebugConfig
+		Wrappers []struct {
+			Name  string
+			State json.RawMessage
+		}
+	}
+
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &info))
+
+	assert.EqualValues(t, 1, info.Stats.Requests)
+	assert.Equal(t, redactURL(context.Background(), base), info.Config.BaseURL)
+	require.Len(t, info.Wrappers, 2)
+
+	var retry RetryDebugState
+
+	require.NoError(t, json.Unmarshal(info.Wrappers[0].State, &retry))
+	assert.Equal(t, "retry", info.Wrappers[0].Name)
+	assert.EqualValues(t, 3, retry.MaxRetries)
+	require.NotNil(t, retry.Budget)
+	assert.Equal(t, 1, retry.Budget.Requests)
+
+	var limits map[string]RateLimitDebugState
+
+	require.NoError(t, json.Unmarshal(info.Wrappers[1].State, &limits))
+	assert.Equal(t, "ratelimit", info.Wrappers[1].Name)
+	assert.Equal(t, 5, limits[srv.Listener.Addr().String()].Remaining)
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, DefaultDebugPath, nil))
+
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+}
@@ -0,0 +1,115 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// maxDrainSize limits the portion of a response body which is
+// discarded after decoding so that the connection can be reused.
+const maxDrainSize = 4 << 10
+
+// DecodeJSON decodes the JSON body of res into a value of type T and
+// closes the body. Responses with a status code outside of the 2xx
+// range are reported as an *HTTPError and responses without content
+// yield the zero value of T.
+func DecodeJSON[T any](res *http.Response) (T, error) {
+	var v T
+
+	err := decodeResponse(res, func(r io.Reader) error {
+		return json.NewDecoder(r).Decode(&v)
+	})
+
+	return v, err
+}
+
+// decodeResponse applies decode to the body of res unless its status
+// indicates an error or the absence of content and closes the body.
+func decodeResponse(res *http.Response, decode func(io.Reader) error) error {
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return NewHTTPError(res)
+	}
+
+	defer closeResponseBody(res)
+
+	if res.StatusCode == http.StatusNoContent {
+		return nil
+	}
+
+	if err := decode(res.Body); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("decoding response body: %w", err)
+	}
+
+	return nil
+}
+
+// Do sends req using c as Client.Do would and decodes the JSON
+// body of the response into a value of type T using DecodeJSON.
+// The request asks for JSON unless it sets an Accept header.
+func Do[T any](ctx context.Context, c *Client, req *http.Request, opts ...RequestOption) (T, error) {
+	req = req.Clone(ctx)
+
+	if req.Header.Get("Accept") == "" {
+		req.Header.Set("Accept", "application/json")
+	}
+
+	res, err := c.do(req, opts...)
+	if err != nil {
+		var v T
+
+		return v, err
+	}
+
+	return DecodeJSON[T](res)
+}
+
+// bodyCodec describes the negotiation and decoding
+// of response bodies of a family of media types.
+type bodyCodec struct {
+	// accept is sent as the Accept header.
+	accept string
+	// accepts reports whether a Content-Type can be decoded.
+	accepts func(contentType string) bool
+	decode  func(r io.Reader, v any) error
+}
+
+// doNegotiated performs a request asking for a response which codec
+// can decode and decodes the response body into v unless it is nil.
+func (c *Client) doNegotiated(ctx context.Context, method, url string, body io.Reader, codec bodyCodec, v any, opts ...RequestOption) error {
+	opts = append(opts, withDefaultRequestHeaders{
+		"Accept": []string{codec.accept},
+	})
+
+	res, err := c.requestWithBody(ctx, method, url, body, opts...)
+	if err != nil {
+		return err
+	}
+
+	if v == nil {
+		return decodeResponse(res, func(io.Reader) error { return nil })
+	}
+
+	if res.StatusCode >= 200 && res.StatusCode <= 299 && res.StatusCode != http.StatusNoContent {
+		if contentType := res.Header.Get("Content-Type"); !codec.accepts(contentType) {
+			closeResponseBody(res)
+
+			return fmt.Errorf("unexpected content type %q", contentType)
+		}
+	}
+
+	return decodeResponse(res, func(r io.Reader) error {
+		return codec.decode(r, v)
+	})
+}
+
+// closeResponseBody discards what remains of a small
+// body before closing it to allow connection reuse.
+func closeResponseBody(res *http.Response) {
+	_, _ = io.CopyN(io.Discard, res.Body, maxDrainSize)
+
+	res.Body.Close()
+}
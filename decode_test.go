@@ -0,0 +1,110 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type decodeTestCluster struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type trackingBody struct {
+	io.Reader
+	closed bool
+}
+
+func (b *trackingBody) Close() error {
+	b.closed = true
+
+	return nil
+}
+
+func TestDecodeJSON(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		StatusCode  int
+		Body        string
+		Expected    decodeTestCluster
+		ExpectError bool
+		ExpectHTTP  bool
+	}{
+		"ok": {
+			StatusCode: http.StatusOK,
+			Body:       `{"id": "1a2b", "name": "prod"}`,
+			Expected:   decodeTestCluster{ID: "1a2b", Name: "prod"},
+		},
+		"no content": {
+			StatusCode: http.StatusNoContent,
+		},
+		"empty body": {
+			StatusCode: http.StatusOK,
+		},
+		"malformed body": {
+			StatusCode:  http.StatusOK,
+			Body:        `{"id":`,
+			ExpectError: true,
+		},
+		"error status": {
+			StatusCode:  http.StatusNotFound,
+			Body:        `{"reason": "not found"}`,
+			ExpectError: true,
+			ExpectHTTP:  true,
+		},
+	} {
+		tc := tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			body := &trackingBody{Reader: strings.NewReader(tc.Body)}
+
+			v, err := DecodeJSON[decodeTestCluster](&http.Response{StatusCode: tc.StatusCode, Body: body})
+			assert.True(t, body.closed, "body must be closed")
+
+			if tc.ExpectError {
+				require.Error(t, err)
+				assert.Equal(t, tc.ExpectHTTP, IsStatus(err, tc.StatusCode))
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.Expected, v)
+		})
+	}
+}
+
+func TestDo(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "application/json", r.Header.Get("Accept"))
+		assert.Equal(t, "value", r.Header.Get("X-Test"))
+
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"id": "1a2b", "name": "prod"}`)
+	}))
+	defer srv.Close()
+
+	c := NewClient()
+	defer c.Close()
+
+	req, err := http.NewRequest(http.MethodGet, srv.URL, nil)
+	require.NoError(t, err)
+
+	cluster, err := Do[decodeTestCluster](context.Background(), c, req, WithRequestHeaders{"X-Test": {"value"}})
+	require.NoError(t, err)
+
+	assert.Equal(t, decodeTestCluster{ID: "1a2b", Name: "prod"}, cluster)
+	assert.Empty(t, req.Header.Get("Accept"), "request must not be modified")
+}
@@ -0,0 +1,77 @@
+package client
+
+import (
+	"net/http"
+	"net/url"
+	"slices"
+)
+
+// With returns a Client derived from c with the given options applied
+// on top of its configuration. The derived Client shares the transport,
+// and therefore the connection pool, of c and sends requests through
+// the wrappers of c. Wrappers and middlewares provided to With are
+// applied outside those of c and only affect the derived Client.
+// Options configuring the transport itself such as TLS, proxy, DNS and
+// dial settings have no effect as the transport is shared. Deriving a
+// Client is cheap, making it suitable for a per-API client built from
+// a single client per process.
+func (c *Client) With(opts ...ClientOption) *Client {
+	cfg := c.cfg
+
+	cfg.Wrappers = nil
+	cfg.Middlewares = nil
+	cfg.BypassWrappers = nil
+	cfg.registered = nil
+	cfg.wrapperHooks = nil
+	cfg.Header = cfg.Header.Clone()
+
+	cfg.Option(opts...)
+	cfg.Default()
+
+	client := http.Client{
+		Timeout:       cfg.Timeout,
+		CheckRedirect: cfg.checkRedirect(),
+		Transport: cfg.wrap(
+			c.client.Transport,
+			cfg.baseTransport(),
+		),
+	}
+
+	cfg.Wrappers = append(slices.Clone(c.cfg.Wrappers), cfg.Wrappers...)
+	cfg.Middlewares = append(slices.Clone(c.cfg.Middlewares), cfg.Middlewares...)
+	cfg.registered = append(slices.Clone(c.cfg.registered), cfg.registered...)
+
+	return &Client{
+		cfg:     cfg,
+		client:  &client,
+		derived: true,
+	}
+}
+
+// WithBaseURL configures a Client instance to resolve request
+// URLs against the given URL. Absolute request URLs are used
+// as is. Relative paths are resolved as in a HTML document so
+// the base URL should end with a slash to have paths appended
+// to it, e.g. "clusters" resolves to "https://api.example.com/v1/clusters"
+// against "https://api.example.com/v1/".
+type WithBaseURL struct{ *url.URL }
+
+func (u WithBaseURL) ConfigureClient(c *ClientConfig) {
+	c.BaseURL = u.URL
+}
+
+// WithDefaultHeaders configures a Client instance to add the given
+// headers to every request. Headers set for a request take precedence
+// over default headers with the same key. This option can be provided
+// multiple times with later values replacing earlier ones.
+type WithDefaultHeaders http.Header
+
+func (h WithDefaultHeaders) ConfigureClient(c *ClientConfig) {
+	if c.Header == nil {
+		c.Header = make(http.Header)
+	}
+
+	for key, vals := range h {
+		c.Header[http.CanonicalHeaderKey(key)] = slices.Clone(vals)
+	}
+}
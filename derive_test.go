@@ -0,0 +1,168 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/mt-sre/client/internal/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithBaseURL(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		BaseURL     string
+		URL         string
+		ExpectedURL string
+	}{
+		"relative path": {
+			BaseURL:     "https://api.example.com/v1/",
+			URL:         "clusters?size=10",
+			ExpectedURL: "https://api.example.com/v1/clusters?size=10",
+		},
+		"absolute path": {
+			BaseURL:     "https://api.example.com/v1/",
+			URL:         "/v2/clusters",
+			ExpectedURL: "https://api.example.com/v2/clusters",
+		},
+		"absolute url": {
+			BaseURL:     "https://api.example.com/v1/",
+			URL:         "https://other.example.com/items",
+			ExpectedURL: "https://other.example.com/items",
+		},
+	} {
+		tc := tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var sent []*http.Request
+
+			mrt := &testutils.MockRoundTripper{}
+			mrt.
+				On("RoundTrip", mock.Anything).
+				Run(func(args mock.Arguments) {
+					sent = append(sent, args.Get(0).(*http.Request))
+				}).
+				Return(&http.Response{StatusCode: http.StatusOK, Body: http.NoBody}, nil)
+
+			base, err := url.Parse(tc.BaseURL)
+			require.NoError(t, err)
+
+			c := NewClient(WithTransport{RoundTripper: mrt}, WithBaseURL{URL: base})
+			defer c.Close()
+
+			res, err := c.Get(context.Background(), tc.URL)
+			require.NoError(t, err)
+			require.NoError(t, res.Body.Close())
+
+			require.Len(t, sent, 1)
+			assert.Equal(t, tc.ExpectedURL, sent[0].URL.String())
+		})
+	}
+}
+
+func TestWithDefaultHeaders(t *testing.T) {
+	t.Parallel()
+
+	var sent []*http.Request
+
+	mrt := &testutils.MockRoundTripper{}
+	mrt.
+		On("RoundTrip", mock.Anything).
+		Run(func(args mock.Arguments) {
+			sent = append(sent, args.Get(0).(*http.Request))
+		}).
+		Return(&http.Response{StatusCode: http.StatusOK, Body: http.NoBody}, nil)
+
+	c := NewClient(
+		WithTransport{RoundTripper: mrt},
+		WithDefaultHeaders{"x-tenant": {"a"}, "Accept": {"application/json"}},
+	)
+	defer c.Close()
+
+	res, err := c.Get(context.Background(), "https://api.example.com",
+		WithRequestHeaders{"Accept": {"text/plain"}},
+	)
+	require.NoError(t, err)
+	require.NoError(t, res.Body.Close())
+
+	require.Len(t, sent, 1)
+	assert.Equal(t, "a", sent[0].Header.Get("X-Tenant"))
+	assert.Equal(t, []string{"text/plain"}, sent[0].Header.Values("Accept"))
+}
+
+func TestClientWith(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Path", r.URL.Path)
+		w.Header().Set("X-Tenant", r.Header.Get("X-Tenant"))
+	}))
+	defer srv.Close()
+
+	var parentCalls, derivedCalls int
+
+	counter := func(calls *int) TransportWrapper {
+		return MiddlewareWrapper(func(next Handler) Handler {
+			return func(req *http.Request) (*http.Response, error) {
+				*calls++
+
+				return next(req)
+			}
+		})
+	}
+
+	base, err := url.Parse(srv.URL + "/v1/")
+	require.NoError(t, err)
+
+	parent := NewClient(
+		WithWrapper{TransportWrapper: counter(&parentCalls)},
+		WithDefaultHeaders{"X-Tenant": {"parent"}},
+	)
+	defer parent.Close()
+
+	derived := parent.With(
+		WithBaseURL{URL: base},
+		WithDefaultHeaders{"X-Tenant": {"derived"}},
+		WithNamedWrapper{Name: "derived", Wrapper: counter(&derivedCalls)},
+	)
+	derived.Close()
+
+	res, err := derived.Get(context.Background(), "clusters")
+	require.NoError(t, err)
+	require.NoError(t, res.Body.Close())
+
+	assert.Equal(t, "/v1/clusters", res.Header.Get("X-Path"))
+	assert.Equal(t, "derived", res.Header.Get("X-Tenant"))
+	assert.Equal(t, 1, parentCalls)
+	assert.Equal(t, 1, derivedCalls)
+
+	res, err = parent.Get(context.Background(), srv.URL+"/other")
+	require.NoError(t, err)
+
+	_, err = io.Copy(io.Discard, res.Body)
+	require.NoError(t, err)
+	require.NoError(t, res.Body.Close())
+
+	assert.Equal(t, "/other", res.Header.Get("X-Path"))
+	assert.Equal(t, "parent", res.Header.Get("X-Tenant"))
+	assert.Equal(t, 2, parentCalls)
+	assert.Equal(t, 1, derivedCalls)
+
+	names := make([]string, 0, len(derived.Wrappers()))
+
+	for _, nw := range derived.Wrappers() {
+		names = append(names, nw.Name)
+	}
+
+	assert.Equal(t, []string{"client.middlewareWrapper", "derived"}, names)
+	assert.Len(t, parent.Wrappers(), 1)
+}
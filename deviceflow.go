@@ -0,0 +1,80 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+
+	"golang.org/x/oauth2"
+)
+
+// DeviceFlow performs the OAUTH2 device authorization grant of
+// RFC 8628 for headless CLI logins, e.g. against Red Hat SSO.
+type DeviceFlow struct {
+	ClientID      string
+	DeviceAuthURL string
+	TokenURL      string
+	Scopes        []string
+	// Prompt is called with the verification URI and user code which
+	// the user must visit and enter. Defaults to printing them to
+	// os.Stderr.
+	Prompt func(*oauth2.DeviceAuthResponse)
+	// Store persists tokens so that the flow is only performed if no
+	// token which is valid or can be refreshed is stored. Optional.
+	Store TokenStore
+	// HTTPClient is used for requests to the authorization server.
+	// Defaults to http.DefaultClient.
+	HTTPClient *http.Client
+}
+
+// TokenSource performs the device authorization grant and returns a
+// TokenSource for use with WithTokenSource which refreshes tokens
+// using the obtained refresh token. The token endpoint is polled at
+// the interval requested by the server which is increased whenever
+// the server asks to slow down. ctx bounds the login and the flow
+// fails once the device code expires.
+func (f DeviceFlow) TokenSource(ctx context.Context) (oauth2.TokenSource, error) {
+	conf := &oauth2.Config{
+		ClientID: f.ClientID,
+		Endpoint: oauth2.Endpoint{
+			DeviceAuthURL: f.DeviceAuthURL,
+			TokenURL:      f.TokenURL,
+		},
+		Scopes: f.Scopes,
+	}
+
+	prompt := f.Prompt
+	if prompt == nil {
+		prompt = func(da *oauth2.DeviceAuthResponse) {
+			printDevicePrompt(os.Stderr, da)
+		}
+	}
+
+	return loginTokenSource(ctx, conf, f.HTTPClient, f.Store, func(ctx context.Context) (*oauth2.Token, error) {
+		da, err := conf.DeviceAuth(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("requesting device authorization: %w", err)
+		}
+
+		prompt(da)
+
+		token, err := conf.DeviceAccessToken(ctx, da)
+		if err != nil {
+			return nil, fmt.Errorf("awaiting device authorization: %w", err)
+		}
+
+		return token, nil
+	})
+}
+
+func printDevicePrompt(w io.Writer, da *oauth2.DeviceAuthResponse) {
+	if da.VerificationURIComplete != "" {
+		fmt.Fprintf(w, "To log in, open %s and confirm the code %s\n", da.VerificationURIComplete, da.UserCode)
+
+		return
+	}
+
+	fmt.Fprintf(w, "To log in, open %s and enter the code %s\n", da.VerificationURI, da.UserCode)
+}
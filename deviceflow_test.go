@@ -0,0 +1,89 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+)
+
+func TestDeviceFlow(t *testing.T) {
+	t.Parallel()
+
+	var authorizations, polls atomic.Int32
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		switch r.URL.Path {
+		case "/device":
+			authorizations.Add(1)
+
+			_, _ = io.WriteString(w, `{"device_code":"device","user_code":"ABCD-EFGH",`+
+				`"verification_uri":"https://sso.example.com/device","interval":1,"expires_in":60}`)
+		case "/token":
+			switch r.FormValue("grant_type") {
+			case "urn:ietf:params:oauth:grant-type:device_code":
+				if polls.Add(1) == 1 {
+					w.WriteHeader(http.StatusBadRequest)
+					_, _ = io.WriteString(w, `{"error":"authorization_pending"}`)
+
+					return
+				}
+
+				_, _ = io.WriteString(w, `{"access_token":"initial","refresh_token":"refresh","expires_in":300}`)
+			case "refresh_token":
+				if r.FormValue("refresh_token") != "refresh" {
+					w.WriteHeader(http.StatusBadRequest)
+					_, _ = io.WriteString(w, `{"error":"invalid_grant"}`)
+
+					return
+				}
+
+				_, _ = io.WriteString(w, `{"access_token":"refreshed","expires_in":300}`)
+			}
+		}
+	}))
+	defer srv.Close()
+
+	var prompted *oauth2.DeviceAuthResponse
+
+	flow := DeviceFlow{
+		ClientID:      "cli",
+		DeviceAuthURL: srv.URL + "/device",
+		TokenURL:      srv.URL + "/token",
+		Prompt:        func(da *oauth2.DeviceAuthResponse) { prompted = da },
+		Store:         NewFileTokenStore(t.TempDir()),
+	}
+
+	src, err := flow.TokenSource(context.Background())
+	require.NoError(t, err)
+
+	require.NotNil(t, prompted)
+	assert.Equal(t, "ABCD-EFGH", prompted.UserCode)
+	assert.EqualValues(t, 2, polls.Load())
+
+	token, err := src.Token()
+	require.NoError(t, err)
+	assert.Equal(t, "initial", token.AccessToken)
+
+	token, err = src.Token()
+	require.NoError(t, err)
+	assert.Equal(t, "refreshed", token.AccessToken)
+	assert.Equal(t, "refresh", token.RefreshToken, "the refresh token must be retained")
+
+	// a subsequent login uses the stored token
+	src, err = flow.TokenSource(context.Background())
+	require.NoError(t, err)
+
+	token, err = src.Token()
+	require.NoError(t, err)
+	assert.Equal(t, "refreshed", token.AccessToken)
+	assert.EqualValues(t, 1, authorizations.Load())
+}
@@ -0,0 +1,44 @@
+package client
+
+import (
+	"context"
+	"net"
+	"time"
+)
+
+// DialContextFunc establishes connections on behalf of a transport.
+type DialContextFunc func(ctx context.Context, network, addr string) (net.Conn, error)
+
+// WithDialContext configures a Client instance to establish
+// connections using the given function e.g. to tunnel connections
+// through an SSH session. The function takes precedence over
+// WithDialTimeout, WithResolver and WithDNSCache.
+type WithDialContext DialContextFunc
+
+func (d WithDialContext) ConfigureClient(c *ClientConfig) {
+	c.DialContext = DialContextFunc(d)
+}
+
+// WithUnixSocket configures a Client instance to connect to the
+// Unix domain socket at the given path regardless of the host of
+// request URLs e.g. to talk to the Docker or Podman daemon using
+// URLs such as "http://localhost/v1.43/containers/json". Proxies
+// are bypassed as the destination is always local.
+type WithUnixSocket string
+
+func (s WithUnixSocket) ConfigureClient(c *ClientConfig) {
+	path := string(s)
+
+	c.DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+		timeout := c.DialTimeout
+		if timeout <= 0 {
+			timeout = 30 * time.Second
+		}
+
+		dialer := net.Dialer{Timeout: timeout}
+
+		return dialer.DialContext(ctx, "unix", path)
+	}
+
+	c.NoProxy = append(c.NoProxy, "*")
+}
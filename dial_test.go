@@ -0,0 +1,88 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithUnixSocket(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "daemon.sock")
+
+	l, err := net.Listen("unix", path)
+	require.NoError(t, err)
+
+	var attempts atomic.Int32
+
+	srv := &httptest.Server{
+		Listener: l,
+		Config: &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if attempts.Add(1) == 1 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+
+				return
+			}
+
+			io.WriteString(w, r.URL.Path)
+		})},
+	}
+	srv.Start()
+	defer srv.Close()
+
+	c := NewClient(
+		WithUnixSocket(path),
+		WithProxyURL{URL: &url.URL{Scheme: "http", Host: "proxy.invalid"}},
+		WithWrapper{TransportWrapper: NewRetryWrapper(WithBackoffGenerator(NoBackoffGenerator()))},
+	)
+	defer c.Close()
+
+	res, err := c.Get(context.Background(), "http://localhost/v1.43/containers/json")
+	require.NoError(t, err)
+
+	body, err := io.ReadAll(res.Body)
+	require.NoError(t, err)
+	require.NoError(t, res.Body.Close())
+
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, "/v1.43/containers/json", string(body))
+	assert.Equal(t, int32(2), attempts.Load())
+}
+
+func TestWithDialContext(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	var (
+		dialer net.Dialer
+		dials  atomic.Int32
+	)
+
+	c := NewClient(WithDialContext(func(ctx context.Context, network, _ string) (net.Conn, error) {
+		dials.Add(1)
+
+		return dialer.DialContext(ctx, network, srv.Listener.Addr().String())
+	}))
+	defer c.Close()
+
+	res, err := c.Get(context.Background(), "http://service.invalid")
+	require.NoError(t, err)
+	require.NoError(t, res.Body.Close())
+
+	assert.Equal(t, http.StatusNoContent, res.StatusCode)
+	assert.Equal(t, int32(1), dials.Load())
+}
@@ -0,0 +1,147 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// WithResolver configures a Client instance to resolve host names
+// using the given net.Resolver instead of the system resolver.
+type WithResolver struct{ *net.Resolver }
+
+func (r WithResolver) ConfigureClient(c *ClientConfig) {
+	c.Resolver = r.Resolver
+}
+
+// WithDNSCache configures a Client instance to cache the results of
+// host name lookups for TTL. Connections are distributed round-robin
+// across the addresses a host name resolves to and, should dialing an
+// address fail, the remaining addresses are attempted in turn. This
+// reduces the impact of flaky DNS on connection establishment.
+type WithDNSCache struct {
+	TTL time.Duration
+	// Clock measures TTL. Defaults to RealClock.
+	Clock Clock
+}
+
+func (dc WithDNSCache) ConfigureClient(c *ClientConfig) {
+	c.DNSCacheTTL = dc.TTL
+	c.DNSCacheClock = dc.Clock
+}
+
+type dnsDialer struct {
+	ttl    time.Duration
+	clock  Clock
+	lookup func(ctx context.Context, host string) ([]string, error)
+	dial   func(ctx context.Context, network, addr string) (net.Conn, error)
+	next   atomic.Uint64
+
+	mu    sync.Mutex
+	cache map[string]dnsCacheEntry
+}
+
+type dnsCacheEntry struct {
+	addrs   []string
+	expires time.Time
+}
+
+func newDNSDialer(dialer *net.Dialer, ttl time.Duration, clock Clock) *dnsDialer {
+	resolver := dialer.Resolver
+	if resolver == nil {
+		resolver = net.DefaultResolver
+	}
+
+	if clock == nil {
+		clock = RealClock{}
+	}
+
+	return &dnsDialer{
+		ttl:    ttl,
+		clock:  clock,
+		lookup: resolver.LookupHost,
+		dial:   dialer.DialContext,
+		cache:  make(map[string]dnsCacheEntry),
+	}
+}
+
+func (d *dnsDialer) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+
+	if net.ParseIP(host) != nil {
+		return d.dial(ctx, network, addr)
+	}
+
+	addrs, err := d.resolve(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+
+	start := int(d.next.Add(1) % uint64(len(addrs)))
+
+	var errs []error
+
+	for i := range addrs {
+		ip := addrs[(start+i)%len(addrs)]
+
+		conn, err := d.dial(ctx, network, net.JoinHostPort(ip, port))
+		if err == nil {
+			return conn, nil
+		}
+
+		errs = append(errs, err)
+
+		if ctx.Err() != nil {
+			break
+		}
+	}
+
+	return nil, errors.Join(errs...)
+}
+
+func (d *dnsDialer) resolve(ctx context.Context, host string) ([]string, error) {
+	now := d.clock.Now()
+
+	d.mu.Lock()
+	entry, ok := d.cache[host]
+	d.mu.Unlock()
+
+	if ok && now.Before(entry.expires) {
+		return entry.addrs, nil
+	}
+
+	addrs, err := d.lookup(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(addrs) == 0 {
+		return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+	}
+
+	if d.ttl > 0 {
+		d.mu.Lock()
+		d.cache[host] = dnsCacheEntry{addrs: addrs, expires: now.Add(d.ttl)}
+		d.mu.Unlock()
+	}
+
+	return addrs, nil
+}
+
+// ForgetHost removes the cached addresses of host.
+func (d *dnsDialer) ForgetHost(host string) {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	delete(d.cache, host)
+}
@@ -0,0 +1,123 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDNSDialer(t *testing.T) {
+	t.Parallel()
+
+	var (
+		mu      sync.Mutex
+		lookups int
+		dialed  []string
+	)
+
+	errUnreachable := errors.New("unreachable")
+
+	now := time.Date(2020, time.January, 1, 8, 0, 0, 0, time.UTC)
+	clock := funcClock{now: func() time.Time {
+		mu.Lock()
+		defer mu.Unlock()
+
+		return now
+	}}
+
+	d := newDNSDialer(&net.Dialer{}, time.Minute, clock)
+	d.lookup = func(_ context.Context, host string) ([]string, error) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		lookups++
+
+		return []string{"10.0.0.1", "10.0.0.2", "10.0.0.3"}, nil
+	}
+	d.dial = func(_ context.Context, _, addr string) (net.Conn, error) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		dialed = append(dialed, addr)
+
+		if addr == "10.0.0.2:443" {
+			return nil, errUnreachable
+		}
+
+		client, server := net.Pipe()
+		server.Close()
+
+		return client, nil
+	}
+
+	for i := 0; i < 3; i++ {
+		conn, err := d.DialContext(context.Background(), "tcp", "api.example.com:443")
+		require.NoError(t, err)
+		require.NoError(t, conn.Close())
+	}
+
+	assert.Equal(t, 1, lookups, "lookups must be cached")
+	assert.Equal(t, []string{
+		"10.0.0.2:443",
+		"10.0.0.3:443",
+		"10.0.0.3:443",
+		"10.0.0.1:443",
+	}, dialed, "addresses must be attempted round-robin skipping failures")
+
+	d.ForgetHost("api.example.com:443")
+
+	conn, err := d.DialContext(context.Background(), "tcp", "api.example.com:443")
+	require.NoError(t, err)
+	require.NoError(t, conn.Close())
+
+	assert.Equal(t, 2, lookups)
+
+	mu.Lock()
+	now = now.Add(time.Minute - time.Second)
+	mu.Unlock()
+
+	conn, err = d.DialContext(context.Background(), "tcp", "api.example.com:443")
+	require.NoError(t, err)
+	require.NoError(t, conn.Close())
+
+	assert.Equal(t, 2, lookups, "lookups must be cached until the TTL elapses")
+
+	mu.Lock()
+	now = now.Add(time.Second)
+	mu.Unlock()
+
+	conn, err = d.DialContext(context.Background(), "tcp", "api.example.com:443")
+	require.NoError(t, err)
+	require.NoError(t, conn.Close())
+
+	assert.Equal(t, 3, lookups, "lookups must expire after the TTL")
+}
+
+func TestWithDNSCache(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	t.Cleanup(srv.Close)
+
+	client := NewClient(WithDNSCache{TTL: time.Minute}, WithResolver{Resolver: &net.Resolver{}})
+	t.Cleanup(client.Close)
+
+	require.NotNil(t, client.cfg.dnsDialer)
+
+	_, port, err := net.SplitHostPort(srv.Listener.Addr().String())
+	require.NoError(t, err)
+
+	res, err := client.Get(context.Background(), "http://localhost:"+port)
+	require.NoError(t, err)
+	require.NoError(t, res.Body.Close())
+
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+}
@@ -0,0 +1,243 @@
+package client
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// dohContentType is the media type of DNS
+// messages exchanged with DoH servers.
+const dohContentType = "application/dns-message"
+
+// maxDoHResponseSize is the largest DNS message accepted from a DoH server.
+const maxDoHResponseSize = 64 << 10
+
+// WithDNSOverHTTPS configures a Client instance to resolve host names
+// by querying the RFC 8484 DNS-over-HTTPS endpoint at URL, for instance
+// "https://cloudflare-dns.com/dns-query", which is useful where HTTPS
+// egress is allowed but the system resolver is unreliable. Answers are
+// cached for the TTL they carry. Lookups which fail are retried using
+// the system resolver, or the one configured with WithResolver, unless
+// DisableFallback is set. Queries are sent with Client, which defaults
+// to a client with a 5 second timeout using the system resolver to
+// reach the DoH endpoint.
+type WithDNSOverHTTPS struct {
+	URL             string
+	Client          *http.Client
+	DisableFallback bool
+}
+
+func (d WithDNSOverHTTPS) ConfigureClient(c *ClientConfig) {
+	client := d.Client
+	if client == nil {
+		client = &http.Client{Timeout: 5 * time.Second}
+	}
+
+	doh := &dohResolver{
+		url:    d.URL,
+		client: client,
+		cache:  make(map[string]dnsCacheEntry),
+	}
+
+	c.dohResolver = doh
+
+	if d.DisableFallback {
+		c.LookupHost = doh.LookupHost
+
+		return
+	}
+
+	c.LookupHost = func(ctx context.Context, host string) ([]string, error) {
+		addrs, err := doh.LookupHost(ctx, host)
+		if err == nil || ctx.Err() != nil {
+			return addrs, err
+		}
+
+		resolver := c.Resolver
+		if resolver == nil {
+			resolver = net.DefaultResolver
+		}
+
+		addrs, fallbackErr := resolver.LookupHost(ctx, host)
+		if fallbackErr != nil {
+			return nil, errors.Join(err, fallbackErr)
+		}
+
+		return addrs, nil
+	}
+}
+
+// dohResolver resolves host names using a DoH endpoint.
+type dohResolver struct {
+	url    string
+	client *http.Client
+
+	mu    sync.Mutex
+	cache map[string]dnsCacheEntry
+}
+
+// ForgetHost removes the cached answers for host.
+func (r *dohResolver) ForgetHost(host string) {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.cache, host)
+}
+
+// LookupHost returns the IPv4 and IPv6 addresses of host.
+func (r *dohResolver) LookupHost(ctx context.Context, host string) ([]string, error) {
+	now := time.Now()
+
+	r.mu.Lock()
+	entry, ok := r.cache[host]
+	r.mu.Unlock()
+
+	if ok && now.Before(entry.expires) {
+		return entry.addrs, nil
+	}
+
+	var (
+		addrs []string
+		ttl   uint32
+		errs  []error
+	)
+
+	for _, qtype := range []dnsmessage.Type{dnsmessage.TypeA, dnsmessage.TypeAAAA} {
+		answers, answerTTL, err := r.query(ctx, host, qtype)
+		if err != nil {
+			errs = append(errs, err)
+
+			continue
+		}
+
+		if len(answers) > 0 && (len(addrs) == 0 || answerTTL < ttl) {
+			ttl = answerTTL
+		}
+
+		addrs = append(addrs, answers...)
+	}
+
+	if len(addrs) == 0 {
+		if len(errs) > 0 {
+			return nil, errors.Join(errs...)
+		}
+
+		return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+	}
+
+	if ttl > 0 {
+		r.mu.Lock()
+		r.cache[host] = dnsCacheEntry{addrs: addrs, expires: now.Add(time.Duration(ttl) * time.Second)}
+		r.mu.Unlock()
+	}
+
+	return addrs, nil
+}
+
+// query sends a single question for host and returns the addresses
+// in the answer along with the lowest TTL among them.
+func (r *dohResolver) query(ctx context.Context, host string, qtype dnsmessage.Type) ([]string, uint32, error) {
+	name, err := dnsmessage.NewName(strings.TrimSuffix(host, ".") + ".")
+	if err != nil {
+		return nil, 0, &net.DNSError{Err: err.Error(), Name: host}
+	}
+
+	msg := dnsmessage.Message{
+		Header: dnsmessage.Header{RecursionDesired: true},
+		Questions: []dnsmessage.Question{
+			{Name: name, Type: qtype, Class: dnsmessage.ClassINET},
+		},
+	}
+
+	packed, err := msg.Pack()
+	if err != nil {
+		return nil, 0, fmt.Errorf("packing DNS query: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.url, nil)
+	if err != nil {
+		return nil, 0, fmt.Errorf("constructing DoH request: %w", err)
+	}
+
+	query := req.URL.Query()
+	query.Set("dns", base64.RawURLEncoding.EncodeToString(packed))
+	req.URL.RawQuery = query.Encode()
+	req.Header.Set("Accept", dohContentType)
+
+	res, err := r.client.Do(req)
+	if err != nil {
+		return nil, 0, fmt.Errorf("querying DoH server: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, 0, &net.DNSError{
+			Err:         fmt.Sprintf("DoH server responded with status %d", res.StatusCode),
+			Name:        host,
+			IsTemporary: res.StatusCode >= 500,
+		}
+	}
+
+	body, err := io.ReadAll(io.LimitReader(res.Body, maxDoHResponseSize))
+	if err != nil {
+		return nil, 0, fmt.Errorf("reading DoH response: %w", err)
+	}
+
+	var answer dnsmessage.Message
+
+	if err := answer.Unpack(body); err != nil {
+		return nil, 0, fmt.Errorf("unpacking DoH response: %w", err)
+	}
+
+	switch answer.RCode {
+	case dnsmessage.RCodeSuccess:
+	case dnsmessage.RCodeNameError:
+		return nil, 0, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+	default:
+		return nil, 0, &net.DNSError{
+			Err:         "DoH server responded with " + answer.RCode.String(),
+			Name:        host,
+			IsTemporary: answer.RCode == dnsmessage.RCodeServerFailure,
+		}
+	}
+
+	var (
+		addrs []string
+		ttl   uint32
+	)
+
+	for _, rr := range answer.Answers {
+		var ip net.IP
+
+		switch body := rr.Body.(type) {
+		case *dnsmessage.AResource:
+			ip = net.IP(body.A[:])
+		case *dnsmessage.AAAAResource:
+			ip = net.IP(body.AAAA[:])
+		default:
+			continue
+		}
+
+		if len(addrs) == 0 || rr.Header.TTL < ttl {
+			ttl = rr.Header.TTL
+		}
+
+		addrs = append(addrs, ip.String())
+	}
+
+	return addrs, ttl, nil
+}
@@ -0,0 +1,170 @@
+package client
+
+import (
+	"context"
+	"encoding/base64"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// newDoHServer returns a DoH server resolving service.test
+// to 127.0.0.1 and counting the queries it receives.
+func newDoHServer(t *testing.T, queries *atomic.Int32) *httptest.Server {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queries.Add(1)
+
+		assert.Equal(t, dohContentType, r.Header.Get("Accept"))
+
+		packed, err := base64.RawURLEncoding.DecodeString(r.URL.Query().Get("dns"))
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		var msg dnsmessage.Message
+		if !assert.NoError(t, msg.Unpack(packed)) || !assert.Len(t, msg.Questions, 1) {
+			return
+		}
+
+		q := msg.Questions[0]
+
+		msg.Header.Response = true
+
+		switch {
+		case q.Name.String() != "service.test.":
+			msg.Header.RCode = dnsmessage.RCodeNameError
+		case q.Type == dnsmessage.TypeA:
+			msg.Answers = []dnsmessage.Resource{{
+				Header: dnsmessage.ResourceHeader{Name: q.Name, Type: q.Type, Class: q.Class, TTL: 60},
+				Body:   &dnsmessage.AResource{A: [4]byte{127, 0, 0, 1}},
+			}}
+		}
+
+		out, err := msg.Pack()
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		w.Header().Set("Content-Type", dohContentType)
+		w.Write(out)
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv
+}
+
+func TestWithDNSOverHTTPS(t *testing.T) {
+	t.Parallel()
+
+	var queries atomic.Int32
+
+	doh := newDoHServer(t, &queries)
+
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "resolved")
+	}))
+	defer target.Close()
+
+	u, err := url.Parse(target.URL)
+	require.NoError(t, err)
+
+	c := NewClient(WithDNSOverHTTPS{URL: doh.URL, DisableFallback: true})
+	defer c.Close()
+
+	res, err := c.Get(context.Background(), "http://"+net.JoinHostPort("service.test", u.Port()))
+	require.NoError(t, err)
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(res.Body)
+	require.NoError(t, err)
+	assert.Equal(t, "resolved", string(body))
+	assert.EqualValues(t, 2, queries.Load(), "A and AAAA records must be queried")
+}
+
+func TestDoHResolverLookupHost(t *testing.T) {
+	t.Parallel()
+
+	var queries atomic.Int32
+
+	doh := newDoHServer(t, &queries)
+
+	resolver := &dohResolver{
+		url:    doh.URL,
+		client: doh.Client(),
+		cache:  make(map[string]dnsCacheEntry),
+	}
+
+	for range 2 {
+		addrs, err := resolver.LookupHost(context.Background(), "service.test")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"127.0.0.1"}, addrs)
+	}
+
+	assert.EqualValues(t, 2, queries.Load(), "answers must be cached for their TTL")
+
+	_, err := resolver.LookupHost(context.Background(), "unknown.test")
+
+	var dnsErr *net.DNSError
+	require.ErrorAs(t, err, &dnsErr)
+	assert.True(t, dnsErr.IsNotFound)
+}
+
+func TestWithDNSOverHTTPSFallback(t *testing.T) {
+	t.Parallel()
+
+	unavailable := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	t.Cleanup(unavailable.Close)
+
+	resolver := &net.Resolver{
+		PreferGo: true,
+		Dial: func(context.Context, string, string) (net.Conn, error) {
+			return nil, &net.OpError{Op: "dial", Err: io.ErrUnexpectedEOF}
+		},
+	}
+
+	for name, tc := range map[string]struct {
+		Option      WithDNSOverHTTPS
+		ExpectError bool
+	}{
+		"fallback": {
+			Option: WithDNSOverHTTPS{URL: unavailable.URL},
+		},
+		"fallback disabled": {
+			Option:      WithDNSOverHTTPS{URL: unavailable.URL, DisableFallback: true},
+			ExpectError: true,
+		},
+	} {
+		tc := tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var cfg ClientConfig
+
+			// localhost is answered from the hosts file without querying a server
+			cfg.Option(tc.Option, WithResolver{resolver})
+
+			addrs, err := cfg.LookupHost(context.Background(), "localhost")
+			if tc.ExpectError {
+				require.Error(t, err)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.NotEmpty(t, addrs)
+		})
+	}
+}
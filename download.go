@@ -0,0 +1,501 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+)
+
+var (
+	errIncompleteDownload = errors.New("incomplete download")
+	errRangeNotSupported  = errors.New("server does not support range requests")
+)
+
+// Download performs a HTTP GET request against the provided URL and
+// streams the response body to w. The number of bytes received is
+// verified against the response's Content-Length and, if resuming
+// is enabled with WithResume, interrupted transfers are continued
+// using Range requests.
+func (c *Client) Download(ctx context.Context, url string, w io.Writer, opts ...DownloadOption) error {
+	var cfg DownloadConfig
+
+	cfg.Option(opts...)
+
+	digest, err := newDownloadDigest(cfg)
+	if err != nil {
+		return err
+	}
+
+	return c.download(ctx, url, w, 0, nil, &downloadValidator{}, digest, cfg)
+}
+
+// DownloadFile performs a HTTP GET request against the provided URL
+// and streams the response body to the file at path. Data is written
+// to a temporary file next to path which is renamed once the download
+// has completed successfully. If resuming is enabled with WithResume
+// a partial temporary file left behind by a previous call is resumed
+// provided the validator of the resource, which is stored next to it,
+// is still current.
+func (c *Client) DownloadFile(ctx context.Context, url, path string, opts ...DownloadOption) error {
+	var cfg DownloadConfig
+
+	cfg.Option(opts...)
+
+	digest, err := newDownloadDigest(cfg)
+	if err != nil {
+		return err
+	}
+
+	partial := path + ".part"
+
+	flags := os.O_CREATE | os.O_RDWR
+	if cfg.Resume {
+		flags |= os.O_APPEND
+	} else {
+		flags |= os.O_TRUNC
+	}
+
+	f, err := os.OpenFile(partial, flags, 0o644)
+	if err != nil {
+		return fmt.Errorf("opening file: %w", err)
+	}
+	defer f.Close()
+
+	validator := &downloadValidator{path: partial + ".validator"}
+
+	if cfg.Resume {
+		if err := validator.load(); err != nil {
+			return err
+		}
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		return fmt.Errorf("inspecting file: %w", err)
+	}
+
+	// partial files whose resource cannot be validated
+	// may belong to a different version of the resource
+	if info.Size() > 0 && validator.value == "" {
+		if err := f.Truncate(0); err != nil {
+			return fmt.Errorf("truncating file: %w", err)
+		}
+
+		info, err = f.Stat()
+		if err != nil {
+			return fmt.Errorf("inspecting file: %w", err)
+		}
+	}
+
+	if digest != nil {
+		// the digest covers the content of a partial file being resumed
+		if _, err := io.Copy(digest.hash, io.NewSectionReader(f, 0, info.Size())); err != nil {
+			return fmt.Errorf("reading partial file: %w", err)
+		}
+	}
+
+	reset := func() error {
+		if err := f.Truncate(0); err != nil {
+			return fmt.Errorf("truncating file: %w", err)
+		}
+
+		_, err := f.Seek(0, io.SeekStart)
+
+		return err
+	}
+
+	if !cfg.Resume {
+		validator.path = ""
+	}
+
+	if err := c.download(ctx, url, f, info.Size(), reset, validator, digest, cfg); err != nil {
+		return err
+	}
+
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("closing file: %w", err)
+	}
+
+	if err := os.Rename(partial, path); err != nil {
+		return fmt.Errorf("renaming file: %w", err)
+	}
+
+	return validator.remove()
+}
+
+// downloadValidator holds the strong ETag or, lacking one, the
+// Last-Modified date of the resource being downloaded. It is sent
+// in If-Range when resuming so that a resource which has changed
+// is sent in full rather than appended to the previous version.
+type downloadValidator struct {
+	value string
+	// path persists the validator across processes if set.
+	path string
+}
+
+func (v *downloadValidator) load() error {
+	data, err := os.ReadFile(v.path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("reading download validator: %w", err)
+	}
+
+	v.value = strings.TrimSpace(string(data))
+
+	return nil
+}
+
+// record stores the validator of a response carrying the resource
+// from its start.
+func (v *downloadValidator) record(header http.Header) error {
+	v.value = header.Get("Last-Modified")
+
+	// weak ETags must not be used in If-Range
+	if etag := header.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+		v.value = etag
+	}
+
+	if v.path == "" {
+		return nil
+	}
+
+	if v.value == "" {
+		return v.remove()
+	}
+
+	if err := os.WriteFile(v.path, []byte(v.value), 0o644); err != nil {
+		return &downloadWriteError{err: fmt.Errorf("storing download validator: %w", err)}
+	}
+
+	return nil
+}
+
+func (v *downloadValidator) remove() error {
+	if v.path == "" {
+		return nil
+	}
+
+	if err := os.Remove(v.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("removing download validator: %w", err)
+	}
+
+	return nil
+}
+
+// download writes the resource at url to w starting at offset. If
+// the server does not honor a Range request reset is called to
+// discard the data previously written to w; a nil reset causes
+// the download to fail instead. If digest is set the content is
+// verified once complete and downloads failing verification are
+// restarted using reset.
+func (c *Client) download(ctx context.Context, url string, w io.Writer, offset int64, reset func() error, validator *downloadValidator, digest *downloadDigest, cfg DownloadConfig) error {
+	if digest != nil {
+		w = io.MultiWriter(w, digest.hash)
+
+		if reset != nil {
+			resetOutput := reset
+
+			reset = func() error {
+				digest.hash.Reset()
+
+				return resetOutput()
+			}
+		}
+	}
+
+	for restarts := 0; ; restarts++ {
+		err := c.downloadResuming(ctx, url, w, offset, reset, validator, digest, cfg)
+		if err == nil && digest != nil {
+			err = digest.verify()
+		}
+
+		var mismatch *ChecksumMismatchError
+
+		if !errors.As(err, &mismatch) || reset == nil || restarts >= cfg.ChecksumRetries || ctx.Err() != nil {
+			return err
+		}
+
+		if err := reset(); err != nil {
+			return &downloadWriteError{err: err}
+		}
+
+		offset = 0
+	}
+}
+
+// downloadResuming writes the resource at url to w starting at
+// offset resuming interrupted transfers if enabled.
+func (c *Client) downloadResuming(ctx context.Context, url string, w io.Writer, offset int64, reset func() error, validator *downloadValidator, digest *downloadDigest, cfg DownloadConfig) error {
+	for resumes := 0; ; resumes++ {
+		n, err := c.downloadOnce(ctx, url, w, offset, reset, validator, digest, cfg)
+		if err == nil {
+			return nil
+		}
+
+		var werr *downloadWriteError
+
+		if !cfg.Resume || resumes >= cfg.MaxResumes || ctx.Err() != nil || errors.As(err, &werr) || errors.Is(err, ErrNoChecksum) {
+			return err
+		}
+
+		offset = n
+	}
+}
+
+// downloadOnce performs a single request for the resource at url
+// and returns the total number of bytes written to w. Resumed
+// requests carry the validator in If-Range so that the server
+// sends the full resource if it has changed.
+func (c *Client) downloadOnce(ctx context.Context, url string, w io.Writer, offset int64, reset func() error, validator *downloadValidator, digest *downloadDigest, cfg DownloadConfig) (int64, error) {
+	var opts []RequestOption
+
+	if offset > 0 {
+		header := http.Header{"Range": {fmt.Sprintf("bytes=%d-", offset)}}
+		if validator.value != "" {
+			header.Set("If-Range", validator.value)
+		}
+
+		opts = append(opts, WithRequestHeaders(header))
+	}
+
+	res, err := c.Get(ctx, url, opts...)
+	if err != nil {
+		var httpErr *HTTPError
+
+		// a previous download may have completed without being finalized
+		if errors.As(err, &httpErr) && httpErr.StatusCode == http.StatusRequestedRangeNotSatisfiable && rangeSatisfied(httpErr.Header, offset) {
+			return offset, digest.observe(httpErr.Header)
+		}
+
+		return offset, fmt.Errorf("requesting download: %w", err)
+	}
+	defer res.Body.Close()
+
+	total := res.ContentLength
+
+	switch res.StatusCode {
+	case http.StatusOK:
+		if offset > 0 {
+			if reset == nil {
+				return offset, errRangeNotSupported
+			}
+
+			if err := reset(); err != nil {
+				return offset, &downloadWriteError{err: err}
+			}
+
+			offset = 0
+		}
+
+		if err := validator.record(res.Header); err != nil {
+			return offset, err
+		}
+	case http.StatusPartialContent:
+		start, size, err := parseContentRange(res.Header.Get("Content-Range"))
+		if err != nil {
+			return offset, err
+		}
+
+		if start != offset {
+			return offset, fmt.Errorf("unexpected Content-Range start %d; expected %d", start, offset)
+		}
+
+		total = size
+	case http.StatusRequestedRangeNotSatisfiable:
+		// a previous download may have completed without being finalized
+		if rangeSatisfied(res.Header, offset) {
+			return offset, digest.observe(res.Header)
+		}
+
+		return offset, fmt.Errorf("unexpected status %d", res.StatusCode)
+	default:
+		return offset, fmt.Errorf("unexpected status %d", res.StatusCode)
+	}
+
+	if err := digest.observe(res.Header); err != nil {
+		return offset, err
+	}
+
+	body := io.Reader(res.Body)
+
+	if cfg.Progress != nil {
+		body = &downloadProgressReader{
+			Reader: res.Body,
+			fn:     cfg.Progress,
+			read:   offset,
+			total:  total,
+		}
+	}
+
+	n, err := io.Copy(&downloadWriter{Writer: w}, body)
+	offset += n
+
+	if err != nil {
+		return offset, fmt.Errorf("copying response body: %w", err)
+	}
+
+	if total >= 0 && offset != total {
+		return offset, fmt.Errorf("%w: received %d of %d bytes", errIncompleteDownload, offset, total)
+	}
+
+	if cfg.Progress != nil {
+		cfg.Progress(ProgressEvent{
+			Phase:      PhaseDone,
+			BytesRead:  offset,
+			BytesTotal: total,
+		})
+	}
+
+	return offset, nil
+}
+
+// rangeSatisfied reports whether the Content-Range header of a 416
+// response indicates that offset is already the size of the resource.
+func rangeSatisfied(header http.Header, offset int64) bool {
+	_, size, err := parseContentRange(header.Get("Content-Range"))
+
+	return err == nil && size == offset
+}
+
+// parseContentRange parses a Content-Range header of the forms
+// "bytes start-end/size" and "bytes */size". A size of -1 is
+// returned when the size is given as "*".
+func parseContentRange(val string) (start, size int64, err error) {
+	rng, ok := strings.CutPrefix(val, "bytes ")
+	if !ok {
+		return 0, 0, fmt.Errorf("invalid Content-Range %q", val)
+	}
+
+	span, sizeStr, ok := strings.Cut(rng, "/")
+	if !ok {
+		return 0, 0, fmt.Errorf("invalid Content-Range %q", val)
+	}
+
+	size = -1
+
+	if sizeStr != "*" {
+		if size, err = strconv.ParseInt(sizeStr, 10, 64); err != nil {
+			return 0, 0, fmt.Errorf("invalid Content-Range %q: %w", val, err)
+		}
+	}
+
+	if span == "*" {
+		return 0, size, nil
+	}
+
+	startStr, _, ok := strings.Cut(span, "-")
+	if !ok {
+		return 0, 0, fmt.Errorf("invalid Content-Range %q", val)
+	}
+
+	if start, err = strconv.ParseInt(startStr, 10, 64); err != nil {
+		return 0, 0, fmt.Errorf("invalid Content-Range %q: %w", val, err)
+	}
+
+	return start, size, nil
+}
+
+// downloadWriter marks errors returned by the destination so that
+// they can be distinguished from errors reading the response.
+type downloadWriter struct {
+	io.Writer
+}
+
+func (w *downloadWriter) Write(p []byte) (int, error) {
+	n, err := w.Writer.Write(p)
+	if err != nil {
+		return n, &downloadWriteError{err: err}
+	}
+
+	return n, nil
+}
+
+type downloadWriteError struct {
+	err error
+}
+
+func (e *downloadWriteError) Error() string {
+	return fmt.Sprintf("writing download: %v", e.err)
+}
+
+func (e *downloadWriteError) Unwrap() error {
+	return e.err
+}
+
+type downloadProgressReader struct {
+	io.Reader
+	fn    ProgressFunc
+	read  int64
+	total int64
+}
+
+func (r *downloadProgressReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	if n > 0 {
+		r.read += int64(n)
+
+		r.fn(ProgressEvent{
+			Phase:      PhaseDownloading,
+			BytesRead:  r.read,
+			BytesTotal: r.total,
+		})
+	}
+
+	return n, err
+}
+
+type DownloadConfig struct {
+	// Resume enables continuing interrupted downloads
+	// using Range requests.
+	Resume bool
+	// MaxResumes limits the number of times a single
+	// download is resumed.
+	MaxResumes int
+	// Progress receives the cumulative progress of
+	// the download across resumed requests.
+	Progress ProgressFunc
+	// ChecksumAlgorithm enables verifying the downloaded
+	// content with the given algorithm.
+	ChecksumAlgorithm ChecksumAlgorithm
+	// Checksum is the expected hex encoded digest.
+	Checksum string
+	// ChecksumFromHeader verifies against the digest advertised
+	// in the response headers rather than Checksum.
+	ChecksumFromHeader bool
+	// ChecksumRetries limits the number of times downloads
+	// failing verification are restarted.
+	ChecksumRetries int
+}
+
+func (c *DownloadConfig) Option(opts ...DownloadOption) {
+	for _, opt := range opts {
+		opt.ConfigureDownload(c)
+	}
+}
+
+type DownloadOption interface {
+	ConfigureDownload(*DownloadConfig)
+}
+
+// WithResume enables resuming interrupted downloads using Range
+// requests at most the given number of times. For DownloadFile it
+// also enables resuming partial files left behind by previous calls.
+type WithResume int
+
+func (r WithResume) ConfigureDownload(c *DownloadConfig) {
+	c.Resume = true
+	c.MaxResumes = int(r)
+}
+
+// WithDownloadProgress registers a ProgressFunc which receives
+// PhaseDownloading and PhaseDone events reporting the cumulative
+// number of bytes downloaded.
+type WithDownloadProgress ProgressFunc
+
+func (p WithDownloadProgress) ConfigureDownload(c *DownloadConfig) {
+	c.Progress = ProgressFunc(p)
+}
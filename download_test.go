@@ -0,0 +1,269 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientDownload(t *testing.T) {
+	t.Parallel()
+
+	content := strings.Repeat("0123456789", 100)
+
+	for name, tc := range map[string]struct {
+		Options     []DownloadOption
+		Interrupts  int32
+		ExpectError bool
+	}{
+		"complete": {},
+		"interrupted without resume": {
+			Interrupts:  1,
+			ExpectError: true,
+		},
+		"interrupted with resume": {
+			Options:    []DownloadOption{WithResume(1)},
+			Interrupts: 1,
+		},
+		"resumes exhausted": {
+			Options:     []DownloadOption{WithResume(1)},
+			Interrupts:  2,
+			ExpectError: true,
+		},
+	} {
+		tc := tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			srv := interruptingServer(t, content, tc.Interrupts)
+
+			var (
+				buf  bytes.Buffer
+				last ProgressEvent
+			)
+
+			opts := append([]DownloadOption{
+				WithDownloadProgress(func(ev ProgressEvent) { last = ev }),
+			}, tc.Options...)
+
+			err := NewClient().Download(context.Background(), srv.URL, &buf, opts...)
+			if tc.ExpectError {
+				require.Error(t, err)
+
+				return
+			}
+
+			require.NoError(t, err)
+
+			assert.Equal(t, content, buf.String())
+			assert.Equal(t, PhaseDone, last.Phase)
+			assert.Equal(t, int64(len(content)), last.BytesRead)
+			assert.Equal(t, int64(len(content)), last.BytesTotal)
+		})
+	}
+}
+
+func TestClientDownloadFile(t *testing.T) {
+	t.Parallel()
+
+	content := strings.Repeat("0123456789", 100)
+
+	for name, tc := range map[string]struct {
+		Validator       string
+		ExpectedRange   string
+		ExpectedIfRange string
+	}{
+		"current validator": {
+			Validator:       `"v1"`,
+			ExpectedRange:   "bytes=100-",
+			ExpectedIfRange: `"v1"`,
+		},
+		"changed resource": {
+			Validator:       `"v0"`,
+			ExpectedRange:   "bytes=100-",
+			ExpectedIfRange: `"v0"`,
+		},
+		"no validator": {},
+	} {
+		tc := tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			path := filepath.Join(t.TempDir(), "artifact")
+
+			// simulate a partial file left behind by a previous call
+			// of an earlier version of the resource
+			require.NoError(t, os.WriteFile(path+".part", []byte(strings.Repeat("x", 100)), 0o600))
+
+			if tc.Validator != "" {
+				require.NoError(t, os.WriteFile(path+".part.validator", []byte(tc.Validator), 0o600))
+			}
+
+			var requestedRange, requestedIfRange atomic.Value
+
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requestedRange.Store(r.Header.Get("Range"))
+				requestedIfRange.Store(r.Header.Get("If-Range"))
+
+				w.Header().Set("ETag", `"v1"`)
+				http.ServeContent(w, r, "", time.Time{}, strings.NewReader(content))
+			}))
+			t.Cleanup(srv.Close)
+
+			err := NewClient().DownloadFile(context.Background(), srv.URL, path, WithResume(0))
+			require.NoError(t, err)
+
+			data, err := os.ReadFile(path)
+			require.NoError(t, err)
+
+			if tc.Validator == `"v1"` {
+				// the prefix written by the previous call is kept
+				assert.Equal(t, strings.Repeat("x", 100)+content[100:], string(data))
+			} else {
+				assert.Equal(t, content, string(data))
+			}
+
+			assert.Equal(t, tc.ExpectedRange, requestedRange.Load())
+			assert.Equal(t, tc.ExpectedIfRange, requestedIfRange.Load())
+			assert.NoFileExists(t, path+".part")
+			assert.NoFileExists(t, path+".part.validator")
+		})
+	}
+}
+
+// TestClientDownloadFileValidatorStored ensures that the validator
+// of an interrupted download is stored for later calls to resume it.
+func TestClientDownloadFileValidatorStored(t *testing.T) {
+	t.Parallel()
+
+	content := strings.Repeat("0123456789", 100)
+
+	srv := interruptingServer(t, content, 1)
+
+	path := filepath.Join(t.TempDir(), "artifact")
+
+	err := NewClient().DownloadFile(context.Background(), srv.URL, path, WithResume(0))
+	require.Error(t, err)
+
+	validator, err := os.ReadFile(path + ".part.validator")
+	require.NoError(t, err)
+	assert.Equal(t, interruptingServerETag, string(validator))
+
+	require.NoError(t, NewClient().DownloadFile(context.Background(), srv.URL, path, WithResume(0)))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, content, string(data))
+	assert.NoFileExists(t, path+".part.validator")
+}
+
+func TestClientDownloadUnexpectedStatus(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.NotFoundHandler())
+	t.Cleanup(srv.Close)
+
+	var buf bytes.Buffer
+
+	err := NewClient().Download(context.Background(), srv.URL, &buf)
+	require.Error(t, err)
+}
+
+func TestParseContentRange(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		Value         string
+		ExpectedStart int64
+		ExpectedSize  int64
+		ExpectError   bool
+	}{
+		"range":          {Value: "bytes 10-19/100", ExpectedStart: 10, ExpectedSize: 100},
+		"unknown size":   {Value: "bytes 10-19/*", ExpectedStart: 10, ExpectedSize: -1},
+		"unsatisfiable":  {Value: "bytes */100", ExpectedStart: 0, ExpectedSize: 100},
+		"missing unit":   {Value: "10-19/100", ExpectError: true},
+		"missing size":   {Value: "bytes 10-19", ExpectError: true},
+		"invalid start":  {Value: "bytes a-19/100", ExpectError: true},
+		"invalid length": {Value: "bytes 10-19/a", ExpectError: true},
+	} {
+		tc := tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			start, size, err := parseContentRange(tc.Value)
+			if tc.ExpectError {
+				require.Error(t, err)
+
+				return
+			}
+
+			require.NoError(t, err)
+
+			assert.Equal(t, tc.ExpectedStart, start)
+			assert.Equal(t, tc.ExpectedSize, size)
+		})
+	}
+}
+
+const interruptingServerETag = `"content"`
+
+// interruptingServer returns a server which serves content with
+// support for Range requests but aborts the first interrupts
+// responses after writing half of the requested data.
+func interruptingServer(t *testing.T, content string, interrupts int32) *httptest.Server {
+	t.Helper()
+
+	var remaining atomic.Int32
+
+	remaining.Store(interrupts)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", interruptingServerETag)
+
+		if r.Header.Get("Range") != "" {
+			assert.Equal(t, interruptingServerETag, r.Header.Get("If-Range"))
+		}
+
+		if remaining.Add(-1) < 0 {
+			http.ServeContent(w, r, "", time.Time{}, strings.NewReader(content))
+
+			return
+		}
+
+		offset := 0
+
+		if rng := r.Header.Get("Range"); rng != "" {
+			start, _, _ := strings.Cut(strings.TrimPrefix(rng, "bytes="), "-")
+			offset, _ = strconv.Atoi(start)
+
+			w.Header().Set("Content-Range", "bytes "+start+"-"+strconv.Itoa(len(content)-1)+"/"+strconv.Itoa(len(content)))
+			w.Header().Set("Content-Length", strconv.Itoa(len(content)-offset))
+			w.WriteHeader(http.StatusPartialContent)
+		} else {
+			w.Header().Set("Content-Length", strconv.Itoa(len(content)))
+		}
+
+		_, _ = w.Write([]byte(content[offset : offset+(len(content)-offset)/2]))
+
+		w.(http.Flusher).Flush()
+
+		panic(http.ErrAbortHandler)
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv
+}
@@ -0,0 +1,38 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptrace"
+	"net/textproto"
+)
+
+// InformationalFunc receives the status code and headers of 1xx
+// informational responses such as 103 Early Hints which precede the
+// final response. It is called synchronously from the goroutine
+// performing the request and should therefore return quickly.
+type InformationalFunc func(code int, header http.Header)
+
+// WithInformationalResponses registers an InformationalFunc which
+// receives the 1xx informational responses of a single request. This
+// allows latency sensitive callers to act upon resources hinted by a
+// 103 Early Hints response before the final response arrives.
+type WithInformationalResponses InformationalFunc
+
+func (i WithInformationalResponses) ConfigureRequest(c *RequestConfig) {
+	c.Informational = InformationalFunc(i)
+}
+
+// ContextWithInformationalResponses returns a copy of ctx which
+// reports the 1xx informational responses of requests made with it
+// to fn. This allows receiving Early Hints when using a plain
+// http.Client.
+func ContextWithInformationalResponses(ctx context.Context, fn InformationalFunc) context.Context {
+	return httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+		Got1xxResponse: func(code int, header textproto.MIMEHeader) error {
+			fn(code, http.Header(header).Clone())
+
+			return nil
+		},
+	})
+}
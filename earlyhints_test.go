@@ -0,0 +1,46 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestWithInformationalResponses ensures that 103 Early Hints
+// are reported before the final response is returned.
+func TestWithInformationalResponses(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Link", "</style.css>; rel=preload; as=style")
+		w.WriteHeader(http.StatusEarlyHints)
+
+		w.Header().Del("Link")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	client := NewClient()
+
+	type hint struct {
+		code   int
+		header http.Header
+	}
+
+	var hints []hint
+
+	res, err := client.Get(context.Background(), srv.URL, WithInformationalResponses(func(code int, header http.Header) {
+		hints = append(hints, hint{code: code, header: header})
+	}))
+	require.NoError(t, err)
+	require.NoError(t, res.Body.Close())
+
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	require.Len(t, hints, 1)
+	assert.Equal(t, http.StatusEarlyHints, hints[0].code)
+	assert.Equal(t, "</style.css>; rel=preload; as=style", hints[0].header.Get("Link"))
+}
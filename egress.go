@@ -0,0 +1,268 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+	"syscall"
+)
+
+// ErrEgressDenied is returned by an EgressGuardWrapper for requests
+// whose target resolves to an address which is not permitted.
+var ErrEgressDenied = errors.New("egress denied")
+
+// defaultEgressDeny are the address ranges denied by default as
+// they refer to the local host, private networks or cloud metadata
+// services, none of which a user-supplied URL should reach.
+var defaultEgressDeny = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("10.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("127.0.0.0/8"),
+	netip.MustParsePrefix("169.254.0.0/16"),
+	netip.MustParsePrefix("172.16.0.0/12"),
+	netip.MustParsePrefix("192.168.0.0/16"),
+	netip.MustParsePrefix("224.0.0.0/4"),
+	netip.MustParsePrefix("::/128"),
+	netip.MustParsePrefix("::1/128"),
+	// NAT64 prefixes embed IPv4 addresses including private ones
+	netip.MustParsePrefix("64:ff9b::/96"),
+	netip.MustParsePrefix("64:ff9b:1::/48"),
+	netip.MustParsePrefix("fc00::/7"),
+	netip.MustParsePrefix("fe80::/10"),
+	netip.MustParsePrefix("ff00::/8"),
+}
+
+// NewEgressGuardWrapper returns a TransportWrapper which protects
+// services fetching user-supplied URLs against server-side request
+// forgery. The host of every request, including those issued while
+// following redirects, is resolved and the request is rejected with
+// ErrEgressDenied if any of its addresses lies within a loopback,
+// link-local, private, multicast, NAT64 or metadata service range or a
+// configured deny range, unless the address is explicitly allowed. As
+// the transport resolves the host again when dialing, the address a
+// connection is established to is checked as well to guard against
+// DNS rebinding. This requires the transport to be constructed by the
+// Client, i.e. not provided through WithTransport, and does not apply
+// to connections through proxies.
+func NewEgressGuardWrapper(opts ...EgressGuardOption) *EgressGuardWrapper {
+	var cfg EgressGuardConfig
+
+	cfg.Option(opts...)
+	cfg.Default()
+
+	return &EgressGuardWrapper{
+		cfg: cfg,
+	}
+}
+
+type EgressGuardWrapper struct {
+	cfg EgressGuardConfig
+	rt  http.RoundTripper
+}
+
+func (w *EgressGuardWrapper) Wrap(rt http.RoundTripper) http.RoundTripper {
+	w.rt = rt
+
+	return w
+}
+
+func (w *EgressGuardWrapper) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := w.check(req.Context(), req.URL.Hostname()); err != nil {
+		if req.Body != nil {
+			req.Body.Close()
+		}
+
+		return nil, err
+	}
+
+	ctx := context.WithValue(req.Context(), egressTargetKey{}, egressTarget{
+		guard: w,
+		host:  req.URL.Hostname(),
+	})
+
+	return w.rt.RoundTrip(req.WithContext(ctx))
+}
+
+func (w *EgressGuardWrapper) check(ctx context.Context, host string) error {
+	var addrs []netip.Addr
+
+	if addr, err := netip.ParseAddr(host); err == nil {
+		addrs = []netip.Addr{addr}
+	} else {
+		addrs, err = w.cfg.Lookup(ctx, host)
+		if err != nil {
+			return fmt.Errorf("resolving %s: %w", host, err)
+		}
+	}
+
+	for _, addr := range addrs {
+		if !w.permitted(addr) {
+			return fmt.Errorf("%w: %s resolves to %s", ErrEgressDenied, host, addr)
+		}
+	}
+
+	return nil
+}
+
+func (w *EgressGuardWrapper) permitted(addr netip.Addr) bool {
+	addr = addr.Unmap().WithZone("")
+
+	for _, prefix := range w.cfg.Allow {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+
+	for _, prefix := range w.cfg.Deny {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+
+	return true
+}
+
+type (
+	egressTargetKey struct{}
+	egressDialKey   struct{}
+)
+
+// egressTarget is the host of a request checked by guard.
+type egressTarget struct {
+	guard *EgressGuardWrapper
+	host  string
+}
+
+// guardEgress returns a DialContextFunc which rejects connections for
+// requests checked by an EgressGuardWrapper if the address connected
+// to is not permitted. Dials to other hosts than that of the request
+// i.e. to proxies are not checked.
+func guardEgress(dial DialContextFunc) DialContextFunc {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		target, ok := ctx.Value(egressTargetKey{}).(egressTarget)
+		if !ok {
+			return dial(ctx, network, addr)
+		}
+
+		host, _, err := net.SplitHostPort(addr)
+		if err != nil || !strings.EqualFold(host, target.host) {
+			return dial(ctx, network, addr)
+		}
+
+		// allows egressControl to reject addresses before connecting
+		conn, err := dial(context.WithValue(ctx, egressDialKey{}, target.guard), network, addr)
+		if err != nil {
+			return nil, err
+		}
+
+		// covers dial functions not using egressControl
+		remote, err := netip.ParseAddrPort(conn.RemoteAddr().String())
+		if err != nil || !target.guard.permitted(remote.Addr()) {
+			conn.Close()
+
+			return nil, fmt.Errorf("%w: %s connected to %s", ErrEgressDenied, host, conn.RemoteAddr())
+		}
+
+		return conn, nil
+	}
+}
+
+// egressControl is used as net.Dialer.ControlContext to reject
+// addresses not permitted by the EgressGuardWrapper of the dial.
+func egressControl(ctx context.Context, _, address string, _ syscall.RawConn) error {
+	guard, ok := ctx.Value(egressDialKey{}).(*EgressGuardWrapper)
+	if !ok {
+		return nil
+	}
+
+	addr, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrEgressDenied, address)
+	}
+
+	if !guard.permitted(addr.Addr()) {
+		return fmt.Errorf("%w: connecting to %s", ErrEgressDenied, addr.Addr())
+	}
+
+	return nil
+}
+
+// egressGuarded reports whether an EgressGuardWrapper is configured.
+func (c *ClientConfig) egressGuarded() bool {
+	for _, nw := range c.registered {
+		if _, ok := nw.Wrapper.(*EgressGuardWrapper); ok {
+			return true
+		}
+	}
+
+	for _, w := range c.Wrappers {
+		if _, ok := w.(*EgressGuardWrapper); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+type EgressGuardConfig struct {
+	// Allow lists address ranges which are permitted
+	// even if they are contained in a denied range.
+	Allow []netip.Prefix
+	// Deny lists address ranges which are rejected in addition to
+	// loopback, link-local, private and metadata service ranges.
+	Deny []netip.Prefix
+	// Lookup resolves host names. Defaults to the system resolver.
+	Lookup EgressLookupFunc
+}
+
+func (c *EgressGuardConfig) Option(opts ...EgressGuardOption) {
+	for _, opt := range opts {
+		opt.ConfigureEgressGuard(c)
+	}
+}
+
+func (c *EgressGuardConfig) Default() {
+	c.Deny = append(c.Deny, defaultEgressDeny...)
+
+	if c.Lookup == nil {
+		c.Lookup = func(ctx context.Context, host string) ([]netip.Addr, error) {
+			return net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+		}
+	}
+}
+
+type EgressGuardOption interface {
+	ConfigureEgressGuard(*EgressGuardConfig)
+}
+
+// WithEgressAllow permits requests to the given address
+// ranges even if they are contained in a denied range.
+type WithEgressAllow []netip.Prefix
+
+func (a WithEgressAllow) ConfigureEgressGuard(c *EgressGuardConfig) {
+	c.Allow = append(c.Allow, a...)
+}
+
+// WithEgressDeny rejects requests to the given address ranges
+// in addition to those denied by default.
+type WithEgressDeny []netip.Prefix
+
+func (d WithEgressDeny) ConfigureEgressGuard(c *EgressGuardConfig) {
+	c.Deny = append(c.Deny, d...)
+}
+
+// EgressLookupFunc resolves a host name to its addresses.
+type EgressLookupFunc func(ctx context.Context, host string) ([]netip.Addr, error)
+
+// WithEgressLookup resolves host names using the given
+// function instead of the system resolver.
+type WithEgressLookup EgressLookupFunc
+
+func (l WithEgressLookup) ConfigureEgressGuard(c *EgressGuardConfig) {
+	c.Lookup = EgressLookupFunc(l)
+}
@@ -0,0 +1,227 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"net/url"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEgressGuardWrapperInterfaces(t *testing.T) {
+	t.Parallel()
+
+	require.Implements(t, new(http.RoundTripper), new(EgressGuardWrapper))
+
+	require.Implements(t, new(TransportWrapper), new(EgressGuardWrapper))
+}
+
+func TestEgressGuardWrapper(t *testing.T) {
+	t.Parallel()
+
+	lookup := WithEgressLookup(func(_ context.Context, host string) ([]netip.Addr, error) {
+		switch host {
+		case "public.example.com":
+			return []netip.Addr{netip.MustParseAddr("93.184.215.14")}, nil
+		case "mixed.example.com":
+			return []netip.Addr{netip.MustParseAddr("93.184.215.14"), netip.MustParseAddr("10.0.0.1")}, nil
+		case "internal.example.com":
+			return []netip.Addr{netip.MustParseAddr("192.168.1.10")}, nil
+		default:
+			return nil, errors.New("no such host")
+		}
+	})
+
+	for name, tc := range map[string]struct {
+		URL         string
+		Options     []EgressGuardOption
+		ExpectedErr error
+		ExpectError bool
+	}{
+		"public host": {
+			URL: "https://public.example.com",
+		},
+		"public address": {
+			URL: "https://93.184.215.14",
+		},
+		"loopback": {
+			URL:         "http://127.0.0.1:8080",
+			ExpectedErr: ErrEgressDenied,
+		},
+		"ipv6 loopback": {
+			URL:         "http://[::1]",
+			ExpectedErr: ErrEgressDenied,
+		},
+		"ipv4 mapped loopback": {
+			URL:         "http://[::ffff:127.0.0.1]",
+			ExpectedErr: ErrEgressDenied,
+		},
+		"nat64": {
+			URL:         "http://[64:ff9b::7f00:1]",
+			ExpectedErr: ErrEgressDenied,
+		},
+		"multicast": {
+			URL:         "http://224.0.0.251",
+			ExpectedErr: ErrEgressDenied,
+		},
+		"metadata service": {
+			URL:         "http://169.254.169.254/latest/meta-data",
+			ExpectedErr: ErrEgressDenied,
+		},
+		"private host": {
+			URL:         "https://internal.example.com",
+			ExpectedErr: ErrEgressDenied,
+		},
+		"any address private": {
+			URL:         "https://mixed.example.com",
+			ExpectedErr: ErrEgressDenied,
+		},
+		"allowed private host": {
+			URL:     "https://internal.example.com",
+			Options: []EgressGuardOption{WithEgressAllow{netip.MustParsePrefix("192.168.1.0/24")}},
+		},
+		"denied public host": {
+			URL:         "https://public.example.com",
+			Options:     []EgressGuardOption{WithEgressDeny{netip.MustParsePrefix("93.184.215.0/24")}},
+			ExpectedErr: ErrEgressDenied,
+		},
+		"unresolvable host": {
+			URL:         "https://unknown.example.com",
+			ExpectError: true,
+		},
+	} {
+		tc := tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var called bool
+
+			next := Handler(func(req *http.Request) (*http.Response, error) {
+				called = true
+
+				return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody, Request: req}, nil
+			})
+
+			rt := NewEgressGuardWrapper(append(tc.Options, lookup)...).Wrap(next)
+
+			req, err := http.NewRequest(http.MethodGet, tc.URL, nil)
+			require.NoError(t, err)
+
+			res, err := rt.RoundTrip(req)
+			if tc.ExpectedErr != nil || tc.ExpectError {
+				require.Error(t, err)
+				if tc.ExpectedErr != nil {
+					assert.ErrorIs(t, err, tc.ExpectedErr)
+				}
+				assert.False(t, called)
+
+				return
+			}
+
+			require.NoError(t, err)
+			require.NoError(t, res.Body.Close())
+			assert.True(t, called)
+		})
+	}
+}
+
+// withLookupHost resolves host names of dialed connections using fn.
+type withLookupHost func(ctx context.Context, host string) ([]string, error)
+
+func (l withLookupHost) ConfigureClient(c *ClientConfig) {
+	c.LookupHost = l
+}
+
+// TestEgressGuardWrapperRebinding ensures that connections are checked
+// against the address dialed rather than the one the wrapper resolved.
+func TestEgressGuardWrapperRebinding(t *testing.T) {
+	t.Parallel()
+
+	var requests atomic.Int32
+
+	srv := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/allowed" {
+			requests.Add(1)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	// runs once all subtests have completed
+	t.Cleanup(func() {
+		assert.Zero(t, requests.Load(), "no request must reach the denied address")
+	})
+
+	u, err := url.Parse(srv.URL)
+	require.NoError(t, err)
+
+	_, port, err := net.SplitHostPort(u.Host)
+	require.NoError(t, err)
+
+	// the wrapper sees a public address while dialing
+	// resolves the host to the loopback interface
+	lookup := WithEgressLookup(func(context.Context, string) ([]netip.Addr, error) {
+		return []netip.Addr{netip.MustParseAddr("93.184.215.14")}, nil
+	})
+
+	rebind := withLookupHost(func(context.Context, string) ([]string, error) {
+		return []string{"127.0.0.1"}, nil
+	})
+
+	for name, tc := range map[string]struct {
+		Options     []ClientOption
+		Guard       []EgressGuardOption
+		Path        string
+		ExpectedErr error
+	}{
+		"allowed": {
+			Options: []ClientOption{rebind},
+			Guard:   []EgressGuardOption{WithEgressAllow{netip.MustParsePrefix("127.0.0.0/8")}},
+			Path:    "/allowed",
+		},
+		"dialer": {
+			Options:     []ClientOption{rebind},
+			ExpectedErr: ErrEgressDenied,
+		},
+		"custom dial function": {
+			Options: []ClientOption{WithDialContext(func(ctx context.Context, network, _ string) (net.Conn, error) {
+				var d net.Dialer
+
+				return d.DialContext(ctx, network, net.JoinHostPort("127.0.0.1", port))
+			})},
+			ExpectedErr: ErrEgressDenied,
+		},
+		"forced http2": {
+			Options:     []ClientOption{rebind, WithForceHTTP2{}},
+			ExpectedErr: ErrEgressDenied,
+		},
+	} {
+		tc := tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			c := NewClient(append(tc.Options,
+				WithWrapper{TransportWrapper: NewEgressGuardWrapper(append(tc.Guard, lookup)...)},
+			)...)
+			defer c.Close()
+
+			res, err := c.Get(context.Background(), "http://rebind.example.com:"+port+tc.Path)
+			if tc.ExpectedErr != nil {
+				require.ErrorIs(t, err, tc.ExpectedErr)
+
+				return
+			}
+
+			require.NoError(t, err)
+			require.NoError(t, res.Body.Close())
+		})
+	}
+}
@@ -0,0 +1,174 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Environment variables read by OptionsFromEnv.
+const (
+	// EnvMaxRetries sets the maximum number of retries,
+	// e.g. MT_CLIENT_MAX_RETRIES=5.
+	EnvMaxRetries = "MT_CLIENT_MAX_RETRIES"
+	// EnvTimeout sets the total timeout of a request,
+	// e.g. MT_CLIENT_TIMEOUT=30s.
+	EnvTimeout = "MT_CLIENT_TIMEOUT"
+	// EnvDialTimeout sets the timeout for establishing
+	// a connection, e.g. MT_CLIENT_DIAL_TIMEOUT=5s.
+	EnvDialTimeout = "MT_CLIENT_DIAL_TIMEOUT"
+	// EnvProxy sets the proxy all requests are sent through,
+	// e.g. MT_CLIENT_PROXY=http://proxy.example.com:3128.
+	EnvProxy = "MT_CLIENT_PROXY"
+	// EnvNoProxy lists hosts for which the proxy is bypassed
+	// separated by commas, e.g. MT_CLIENT_NO_PROXY=localhost,10.0.0.0/8.
+	EnvNoProxy = "MT_CLIENT_NO_PROXY"
+	// EnvInsecureSkipVerify disables verification of server
+	// certificates, e.g. MT_CLIENT_INSECURE_SKIP_VERIFY=true.
+	EnvInsecureSkipVerify = "MT_CLIENT_INSECURE_SKIP_VERIFY"
+)
+
+// RetryWrapperName is the name of the RetryWrapper
+// configured from the environment.
+const RetryWrapperName = "retry"
+
+// NewClientFromEnv returns a Client configured with the given options
+// followed by those returned by OptionsFromEnv so that settings from
+// the environment take precedence over settings made in code.
+func NewClientFromEnv(opts ...ClientOption) (*Client, error) {
+	envOpts, err := OptionsFromEnv()
+	if err != nil {
+		return nil, err
+	}
+
+	return NewClient(append(opts, envOpts...)...), nil
+}
+
+// OptionsFromEnv returns ClientOptions for the MT_CLIENT_* variables
+// set in the environment allowing operators to tune deployed tools
+// without code changes. Unset or empty variables are ignored. If
+// EnvMaxRetries is set it overrides the maximum retries of the
+// RetryWrapper registered under the name RetryWrapperName, or else of
+// the first RetryWrapper of the Client, keeping the rest of its
+// configuration. A RetryWrapper is registered under RetryWrapperName
+// if the Client has none. An error is returned describing all
+// malformed values.
+func OptionsFromEnv() ([]ClientOption, error) {
+	return optionsFromEnv(os.Getenv)
+}
+
+func optionsFromEnv(getenv func(string) string) ([]ClientOption, error) {
+	var (
+		opts []ClientOption
+		errs []error
+	)
+
+	if raw := getenv(EnvMaxRetries); raw != "" {
+		retries, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("parsing %s: %w", EnvMaxRetries, err))
+		} else {
+			opts = append(opts, withEnvMaxRetries(retries))
+		}
+	}
+
+	if raw := getenv(EnvTimeout); raw != "" {
+		timeout, err := time.ParseDuration(raw)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("parsing %s: %w", EnvTimeout, err))
+		} else {
+			opts = append(opts, WithTimeout(timeout))
+		}
+	}
+
+	if raw := getenv(EnvDialTimeout); raw != "" {
+		timeout, err := time.ParseDuration(raw)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("parsing %s: %w", EnvDialTimeout, err))
+		} else {
+			opts = append(opts, WithDialTimeout(timeout))
+		}
+	}
+
+	if raw := getenv(EnvProxy); raw != "" {
+		proxy, err := url.Parse(raw)
+		if err == nil && (proxy.Scheme == "" || proxy.Host == "") {
+			err = errors.New("proxy url must be absolute")
+		}
+
+		if err != nil {
+			errs = append(errs, fmt.Errorf("parsing %s: %w", EnvProxy, err))
+		} else {
+			opts = append(opts, WithProxyURL{URL: proxy})
+		}
+	}
+
+	if raw := getenv(EnvNoProxy); raw != "" {
+		var hosts WithNoProxy
+
+		for _, host := range strings.Split(raw, ",") {
+			if host = strings.TrimSpace(host); host != "" {
+				hosts = append(hosts, host)
+			}
+		}
+
+		opts = append(opts, hosts)
+	}
+
+	if raw := getenv(EnvInsecureSkipVerify); raw != "" {
+		insecure, err := strconv.ParseBool(raw)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("parsing %s: %w", EnvInsecureSkipVerify, err))
+		} else if insecure {
+			opts = append(opts, WithInsecureSkipVerify{})
+		}
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+
+	return opts, nil
+}
+
+// withEnvMaxRetries applies EnvMaxRetries to the RetryWrapper of
+// a Client once all of its wrappers have been registered.
+type withEnvMaxRetries uint64
+
+func (n withEnvMaxRetries) ConfigureClient(c *ClientConfig) {
+	c.wrapperHooks = append(c.wrapperHooks, n.apply)
+}
+
+func (n withEnvMaxRetries) apply(registered []NamedWrapper) []NamedWrapper {
+	idx := slices.IndexFunc(registered, func(nw NamedWrapper) bool {
+		return nw.Name == RetryWrapperName
+	})
+
+	if idx < 0 {
+		idx = slices.IndexFunc(registered, func(nw NamedWrapper) bool {
+			_, ok := nw.Wrapper.(*RetryWrapper)
+
+			return ok
+		})
+	}
+
+	if idx < 0 {
+		return append(registered, NamedWrapper{
+			Name:    RetryWrapperName,
+			Wrapper: NewRetryWrapper(WithMaxRetries(n)),
+		})
+	}
+
+	if retry, ok := registered[idx].Wrapper.(*RetryWrapper); ok {
+		retry.cfg.Option(WithMaxRetries(n))
+	} else {
+		registered[idx].Wrapper = NewRetryWrapper(WithMaxRetries(n))
+	}
+
+	return registered
+}
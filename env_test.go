@@ -0,0 +1,155 @@
+package client
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOptionsFromEnv(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		Env         map[string]string
+		Validate    func(t *testing.T, cfg ClientConfig)
+		ExpectedErr []string
+	}{
+		"empty": {
+			Validate: func(t *testing.T, cfg ClientConfig) {
+				t.Helper()
+
+				assert.Equal(t, ClientConfig{}, cfg)
+			},
+		},
+		"all settings": {
+			Env: map[string]string{
+				EnvMaxRetries:         "5",
+				EnvTimeout:            "30s",
+				EnvDialTimeout:        "5s",
+				EnvProxy:              "http://proxy.example.com:3128",
+				EnvNoProxy:            "localhost, 10.0.0.0/8,",
+				EnvInsecureSkipVerify: "true",
+			},
+			Validate: func(t *testing.T, cfg ClientConfig) {
+				t.Helper()
+
+				cfg.resolveWrappers()
+
+				require.Len(t, cfg.registered, 1)
+				assert.Equal(t, RetryWrapperName, cfg.registered[0].Name)
+
+				retry, ok := cfg.registered[0].Wrapper.(*RetryWrapper)
+				require.True(t, ok)
+				assert.Equal(t, uint64(5), retry.cfg.maxRetries)
+
+				assert.Equal(t, 30*time.Second, cfg.Timeout)
+				assert.Equal(t, 5*time.Second, cfg.DialTimeout)
+				assert.Equal(t, []string{"localhost", "10.0.0.0/8"}, cfg.NoProxy)
+				assert.True(t, cfg.TLSConfig.InsecureSkipVerify)
+
+				proxy, err := cfg.Proxy(&http.Request{URL: &url.URL{Scheme: "https", Host: "api.example.com"}})
+				require.NoError(t, err)
+				assert.Equal(t, "http://proxy.example.com:3128", proxy.String())
+			},
+		},
+		"insecure disabled": {
+			Env: map[string]string{
+				EnvInsecureSkipVerify: "false",
+			},
+			Validate: func(t *testing.T, cfg ClientConfig) {
+				t.Helper()
+
+				assert.Nil(t, cfg.TLSConfig)
+			},
+		},
+		"invalid values": {
+			Env: map[string]string{
+				EnvMaxRetries:         "-1",
+				EnvTimeout:            "soon",
+				EnvProxy:              "proxy.example.com",
+				EnvInsecureSkipVerify: "maybe",
+			},
+			ExpectedErr: []string{EnvMaxRetries, EnvTimeout, EnvProxy, EnvInsecureSkipVerify},
+		},
+	} {
+		tc := tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			opts, err := optionsFromEnv(func(key string) string {
+				return tc.Env[key]
+			})
+
+			if len(tc.ExpectedErr) > 0 {
+				require.Error(t, err)
+
+				for _, expected := range tc.ExpectedErr {
+					assert.ErrorContains(t, err, expected)
+				}
+
+				return
+			}
+
+			require.NoError(t, err)
+
+			var cfg ClientConfig
+
+			cfg.Option(opts...)
+
+			tc.Validate(t, cfg)
+		})
+	}
+}
+
+func TestOptionsFromEnvConfiguresRetryWrapper(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		Option ClientOption
+	}{
+		"named wrapper": {
+			Option: WithNamedWrapper{
+				Name:     RetryWrapperName,
+				Priority: 10,
+				Wrapper:  NewRetryWrapper(WithIdempotencyKey{}, WithMaxRetryDuration(time.Minute)),
+			},
+		},
+		"unnamed wrapper": {
+			Option: WithWrapper{
+				TransportWrapper: NewRetryWrapper(WithIdempotencyKey{}, WithMaxRetryDuration(time.Minute)),
+			},
+		},
+	} {
+		tc := tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			opts, err := optionsFromEnv(func(key string) string {
+				if key == EnvMaxRetries {
+					return "2"
+				}
+
+				return ""
+			})
+			require.NoError(t, err)
+
+			c := NewClient(append([]ClientOption{tc.Option}, opts...)...)
+			defer c.Close()
+
+			wrappers := c.Wrappers()
+			require.Len(t, wrappers, 1)
+
+			retry, ok := wrappers[0].Wrapper.(*RetryWrapper)
+			require.True(t, ok)
+			assert.Equal(t, uint64(2), retry.cfg.maxRetries)
+			assert.True(t, retry.cfg.idempotencyKeys, "retry configuration must be kept")
+			assert.Equal(t, time.Minute, retry.cfg.maxRetryDuration)
+		})
+	}
+}
@@ -0,0 +1,136 @@
+package client
+
+import (
+	"context"
+	"time"
+)
+
+// LifecycleEvent is implemented by the events a Client and
+// its wrappers emit to EventSinks: *RequestStarted,
+// *RequestFinished, *AttemptFinished, *RetryScheduled,
+// *CircuitOpened and *TokenRefreshed.
+type LifecycleEvent interface {
+	lifecycleEvent()
+}
+
+// RequestStarted is emitted by a Client before a request is sent.
+type RequestStarted struct {
+	Method string
+	// URL is redacted of credentials and sensitive
+	// query parameters.
+	URL string
+}
+
+// RequestFinished is emitted by a Client once the response
+// headers of a request have been received or it has failed.
+type RequestFinished struct {
+	Method     string
+	URL        string
+	StatusCode int
+	Err        error
+	Duration   time.Duration
+}
+
+// AttemptFinished is emitted by a RetryWrapper once a
+// single attempt of a request has completed.
+type AttemptFinished struct {
+	// Attempt is the attempt number starting at 1.
+	Attempt    int
+	Method     string
+	URL        string
+	StatusCode int
+	Err        error
+	Duration   time.Duration
+}
+
+// RetryScheduled is emitted by a RetryWrapper when
+// a failed attempt is going to be retried.
+type RetryScheduled struct {
+	// Attempt is the number of the upcoming attempt.
+	Attempt int
+	Method  string
+	URL     string
+	Delay   time.Duration
+}
+
+// CircuitOpened is emitted when a wrapper stops sending
+// requests to a host until the given time e.g. because
+// a RateLimitWrapper found its quota exhausted.
+type CircuitOpened struct {
+	Host   string
+	Until  time.Time
+	Reason string
+}
+
+// TokenRefreshed is emitted when a wrapper has
+// obtained a new credential.
+type TokenRefreshed struct {
+	// Source names the kind of credential
+	// e.g. "service account".
+	Source string
+}
+
+func (*RequestStarted) lifecycleEvent()  {}
+func (*RequestFinished) lifecycleEvent() {}
+func (*AttemptFinished) lifecycleEvent() {}
+func (*RetryScheduled) lifecycleEvent()  {}
+func (*CircuitOpened) lifecycleEvent()   {}
+func (*TokenRefreshed) lifecycleEvent()  {}
+
+// EventSink receives the LifecycleEvents of requests. Sinks are
+// invoked synchronously from the goroutine performing the request
+// and must therefore return quickly.
+type EventSink interface {
+	HandleEvent(ctx context.Context, ev LifecycleEvent)
+}
+
+// EventSinkFunc adapts a function to the EventSink interface.
+type EventSinkFunc func(ctx context.Context, ev LifecycleEvent)
+
+func (f EventSinkFunc) HandleEvent(ctx context.Context, ev LifecycleEvent) {
+	f(ctx, ev)
+}
+
+type eventSinks []EventSink
+
+func (s eventSinks) HandleEvent(ctx context.Context, ev LifecycleEvent) {
+	for _, sink := range s {
+		sink.HandleEvent(ctx, ev)
+	}
+}
+
+type eventSinkKey struct{}
+
+// ContextWithEventSink returns a copy of ctx which carries the given
+// EventSink in addition to any sink ctx already carries. Requests
+// made with the returned context emit their LifecycleEvents to the
+// sink. This allows receiving events when using a plain http.Client.
+func ContextWithEventSink(ctx context.Context, sink EventSink) context.Context {
+	if existing := eventSinkFromContext(ctx); existing != nil {
+		sink = eventSinks{existing, sink}
+	}
+
+	return context.WithValue(ctx, eventSinkKey{}, sink)
+}
+
+func eventSinkFromContext(ctx context.Context) EventSink {
+	sink, _ := ctx.Value(eventSinkKey{}).(EventSink)
+
+	return sink
+}
+
+// emitEvent passes ev to the EventSink carried by ctx if any.
+func emitEvent(ctx context.Context, ev LifecycleEvent) {
+	if sink := eventSinkFromContext(ctx); sink != nil {
+		sink.HandleEvent(ctx, ev)
+	}
+}
+
+// WithEventSink configures a Client instance to emit the
+// LifecycleEvents of its requests to the given EventSink.
+// This option can be provided multiple times.
+type WithEventSink struct{ EventSink }
+
+func (s WithEventSink) ConfigureClient(c *ClientConfig) {
+	c.EventSinks = append(c.EventSinks, s.EventSink)
+}
@@ -0,0 +1,172 @@
+package client
+
+import (
+	"math/rand/v2"
+	"net/http"
+	"slices"
+
+	"github.com/go-logr/logr"
+)
+
+// NewFailoverWrapper returns a TransportWrapper which sends requests
+// to the first of an ordered list of mirror endpoints and fails over
+// to the next endpoint when a connection error or one of the
+// configured status codes is encountered. This suits APIs served by
+// active/passive replicas. Requests whose body cannot be replayed
+// are only sent to the first endpoint.
+func NewFailoverWrapper(opts ...FailoverOption) *FailoverWrapper {
+	var cfg FailoverConfig
+
+	cfg.Option(opts...)
+	cfg.Default()
+
+	return &FailoverWrapper{
+		cfg: cfg,
+	}
+}
+
+type FailoverWrapper struct {
+	cfg FailoverConfig
+	rt  http.RoundTripper
+}
+
+func (w *FailoverWrapper) Wrap(rt http.RoundTripper) http.RoundTripper {
+	w.rt = rt
+
+	return w
+}
+
+func (w *FailoverWrapper) RoundTrip(req *http.Request) (*http.Response, error) {
+	order := w.order()
+	if len(order) == 0 {
+		return nil, errNoEndpoints
+	}
+
+	replayable := !hasBody(req) || req.GetBody != nil
+
+	for i, ep := range order {
+		out := rewriteRequest(req, ep.URL)
+
+		if i > 0 && hasBody(req) {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+
+			out.Body = body
+		}
+
+		res, err := w.rt.RoundTrip(out)
+
+		last := i == len(order)-1 || !replayable
+
+		switch {
+		case last:
+			return res, err
+		case err != nil:
+			if req.Context().Err() != nil {
+				return nil, err
+			}
+		case slices.Contains(w.cfg.StatusCodes, res.StatusCode):
+			drainResponseBody(logr.Discard(), res)
+		default:
+			return res, nil
+		}
+	}
+
+	// unreachable as the last endpoint always returns
+	return nil, errNoEndpoints
+}
+
+// order returns the endpoints in the order they are attempted.
+func (w *FailoverWrapper) order() []Endpoint {
+	if !w.cfg.Weighted || len(w.cfg.Endpoints) < 2 {
+		return w.cfg.Endpoints
+	}
+
+	var total int
+
+	for _, ep := range w.cfg.Endpoints {
+		total += endpointWeight(ep)
+	}
+
+	n := rand.IntN(total)
+
+	start := 0
+
+	for i, ep := range w.cfg.Endpoints {
+		if n -= endpointWeight(ep); n < 0 {
+			start = i
+
+			break
+		}
+	}
+
+	order := make([]Endpoint, 0, len(w.cfg.Endpoints))
+	order = append(order, w.cfg.Endpoints[start:]...)
+	order = append(order, w.cfg.Endpoints[:start]...)
+
+	return order
+}
+
+func endpointWeight(ep Endpoint) int {
+	if ep.Weight <= 0 {
+		return 1
+	}
+
+	return ep.Weight
+}
+
+type FailoverConfig struct {
+	// Endpoints are attempted in order.
+	Endpoints []Endpoint
+	// Weighted selects the first endpoint attempted at random
+	// in proportion to the endpoint weights.
+	Weighted bool
+	// StatusCodes are the response status codes
+	// which cause a failover.
+	StatusCodes []int
+}
+
+func (c *FailoverConfig) Option(opts ...FailoverOption) {
+	for _, opt := range opts {
+		opt.ConfigureFailover(c)
+	}
+}
+
+func (c *FailoverConfig) Default() {
+	if c.StatusCodes == nil {
+		c.StatusCodes = []int{
+			http.StatusBadGateway,
+			http.StatusServiceUnavailable,
+			http.StatusGatewayTimeout,
+		}
+	}
+}
+
+type FailoverOption interface {
+	ConfigureFailover(*FailoverConfig)
+}
+
+func (e WithEndpoints) ConfigureFailover(c *FailoverConfig) {
+	c.Endpoints = append(c.Endpoints, e...)
+}
+
+// WithWeightedFailover configures a FailoverWrapper to select the
+// first endpoint attempted at random in proportion to the endpoint
+// weights. Remaining endpoints are attempted in their configured
+// order.
+type WithWeightedFailover struct{}
+
+func (WithWeightedFailover) ConfigureFailover(c *FailoverConfig) {
+	c.Weighted = true
+}
+
+// WithFailoverStatusCodes configures the response status codes
+// which cause a FailoverWrapper to attempt the next endpoint.
+// Defaults to 502, 503 and 504.
+type WithFailoverStatusCodes []int
+
+func (sc WithFailoverStatusCodes) ConfigureFailover(c *FailoverConfig) {
+	c.StatusCodes = sc
+}
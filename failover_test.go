@@ -0,0 +1,177 @@
+package client
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/mt-sre/client/internal/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFailoverWrapperInterfaces(t *testing.T) {
+	t.Parallel()
+
+	require.Implements(t, new(http.RoundTripper), new(FailoverWrapper))
+
+	require.Implements(t, new(TransportWrapper), new(FailoverWrapper))
+}
+
+func TestFailoverWrapper(t *testing.T) {
+	t.Parallel()
+
+	errConnRefused := errors.New("connection refused")
+
+	type outcome struct {
+		StatusCode int
+		Err        error
+	}
+
+	for name, tc := range map[string]struct {
+		Options            []FailoverOption
+		Outcomes           map[string]outcome
+		Body               io.Reader
+		ExpectedHosts      []string
+		ExpectedStatusCode int
+		ExpectedErr        error
+	}{
+		"primary healthy": {
+			Outcomes: map[string]outcome{
+				"a.example.com": {StatusCode: http.StatusOK},
+			},
+			ExpectedHosts:      []string{"a.example.com"},
+			ExpectedStatusCode: http.StatusOK,
+		},
+		"connection error": {
+			Outcomes: map[string]outcome{
+				"a.example.com": {Err: errConnRefused},
+				"b.example.com": {StatusCode: http.StatusOK},
+			},
+			ExpectedHosts:      []string{"a.example.com", "b.example.com"},
+			ExpectedStatusCode: http.StatusOK,
+		},
+		"failover status": {
+			Outcomes: map[string]outcome{
+				"a.example.com": {StatusCode: http.StatusServiceUnavailable},
+				"b.example.com": {StatusCode: http.StatusOK},
+			},
+			Body:               strings.NewReader("payload"),
+			ExpectedHosts:      []string{"a.example.com", "b.example.com"},
+			ExpectedStatusCode: http.StatusOK,
+		},
+		"custom status codes": {
+			Options: []FailoverOption{WithFailoverStatusCodes{http.StatusTooManyRequests}},
+			Outcomes: map[string]outcome{
+				"a.example.com": {StatusCode: http.StatusServiceUnavailable},
+			},
+			ExpectedHosts:      []string{"a.example.com"},
+			ExpectedStatusCode: http.StatusServiceUnavailable,
+		},
+		"all endpoints fail": {
+			Outcomes: map[string]outcome{
+				"a.example.com": {Err: errConnRefused},
+				"b.example.com": {Err: errConnRefused},
+			},
+			ExpectedHosts: []string{"a.example.com", "b.example.com"},
+			ExpectedErr:   errConnRefused,
+		},
+	} {
+		tc := tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var (
+				mrt    testutils.MockRoundTripper
+				seen   []string
+				bodies []string
+			)
+
+			for host, out := range tc.Outcomes {
+				var res *http.Response
+				if out.Err == nil {
+					res = &http.Response{
+						StatusCode: out.StatusCode,
+						Body:       io.NopCloser(bytes.NewBuffer(nil)),
+					}
+				}
+
+				mrt.
+					On("RoundTrip", mock.MatchedBy(func(req *http.Request) bool {
+						return req.URL.Host == host
+					})).
+					Run(func(args mock.Arguments) {
+						req := args.Get(0).(*http.Request)
+						seen = append(seen, req.URL.Host)
+
+						if req.Body != nil {
+							body, err := io.ReadAll(req.Body)
+							require.NoError(t, err)
+
+							bodies = append(bodies, string(body))
+						}
+					}).
+					Return(res, out.Err).
+					Once()
+			}
+
+			opts := append([]FailoverOption{
+				WithEndpoints{
+					{URL: mustParseURL(t, "https://a.example.com")},
+					{URL: mustParseURL(t, "https://b.example.com")},
+				},
+			}, tc.Options...)
+
+			rt := NewFailoverWrapper(opts...).Wrap(&mrt)
+
+			req, err := http.NewRequest(http.MethodPut, "http://original/path", tc.Body)
+			require.NoError(t, err)
+
+			res, err := rt.RoundTrip(req)
+			if tc.ExpectedErr != nil {
+				require.ErrorIs(t, err, tc.ExpectedErr)
+			} else {
+				require.NoError(t, err)
+				require.NoError(t, res.Body.Close())
+
+				assert.Equal(t, tc.ExpectedStatusCode, res.StatusCode)
+			}
+
+			assert.Equal(t, tc.ExpectedHosts, seen)
+
+			for _, body := range bodies {
+				assert.Equal(t, "payload", body)
+			}
+
+			mrt.AssertExpectations(t)
+		})
+	}
+}
+
+func TestFailoverWrapperWeighted(t *testing.T) {
+	t.Parallel()
+
+	w := NewFailoverWrapper(
+		WithEndpoints{
+			{URL: mustParseURL(t, "https://a.example.com"), Weight: 1},
+			{URL: mustParseURL(t, "https://b.example.com"), Weight: 3},
+		},
+		WithWeightedFailover{},
+	)
+
+	first := make(map[string]int)
+
+	for i := 0; i < 1000; i++ {
+		order := w.order()
+		require.Len(t, order, 2)
+
+		first[order[0].URL.Host]++
+	}
+
+	assert.Greater(t, first["b.example.com"], first["a.example.com"])
+}
@@ -0,0 +1,228 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"path"
+	"slices"
+	"strings"
+	"time"
+)
+
+// ErrInjectedFault is returned by a FaultInjectionWrapper for
+// rules which inject an error without specifying one.
+var ErrInjectedFault = errors.New("injected fault")
+
+// NewFaultInjectionWrapper returns a TransportWrapper which injects
+// faults into requests matching the rules configured with
+// WithFaultRules so that the resilience of a client can be tested
+// without a fault injecting proxy. Every matching rule is evaluated
+// independently and triggers with its configured probability. The
+// FaultInjectionWrapper should be applied before any RetryWrapper so
+// that faults are injected into individual attempts.
+func NewFaultInjectionWrapper(opts ...FaultInjectionOption) *FaultInjectionWrapper {
+	var cfg FaultInjectionConfig
+
+	cfg.Option(opts...)
+	cfg.Default()
+
+	return &FaultInjectionWrapper{
+		cfg: cfg,
+	}
+}
+
+type FaultInjectionWrapper struct {
+	cfg FaultInjectionConfig
+	rt  http.RoundTripper
+}
+
+func (w *FaultInjectionWrapper) Wrap(rt http.RoundTripper) http.RoundTripper {
+	w.rt = rt
+
+	return w
+}
+
+func (w *FaultInjectionWrapper) RoundTrip(req *http.Request) (*http.Response, error) {
+	var triggered []FaultRule
+
+	for _, rule := range w.cfg.Rules {
+		if rule.matches(req) && w.cfg.random() < rule.Probability {
+			triggered = append(triggered, rule)
+		}
+	}
+
+	if len(triggered) == 0 {
+		return w.rt.RoundTrip(req)
+	}
+
+	var latency time.Duration
+
+	for _, rule := range triggered {
+		latency += rule.Latency
+	}
+
+	if latency > 0 {
+		timer := time.NewTimer(latency)
+
+		select {
+		case <-timer.C:
+		case <-req.Context().Done():
+			timer.Stop()
+
+			if req.Body != nil {
+				req.Body.Close()
+			}
+
+			return nil, req.Context().Err()
+		}
+	}
+
+	for _, rule := range triggered {
+		if rule.Err == nil && !rule.Fail {
+			continue
+		}
+
+		if req.Body != nil {
+			req.Body.Close()
+		}
+
+		if rule.Err != nil {
+			return nil, rule.Err
+		}
+
+		return nil, fmt.Errorf("%w: %s %s", ErrInjectedFault, req.Method, redactURL(req.Context(), req.URL))
+	}
+
+	res, err := w.rt.RoundTrip(req)
+	if err != nil {
+		return res, err
+	}
+
+	for _, rule := range triggered {
+		if rule.StatusCode != 0 {
+			res.StatusCode = rule.StatusCode
+			res.Status = fmt.Sprintf("%d %s", rule.StatusCode, http.StatusText(rule.StatusCode))
+		}
+
+		if rule.TruncateBody {
+			res.Body = &truncatedBody{
+				ReadCloser: res.Body,
+				remaining:  rule.TruncateAfter,
+			}
+		}
+	}
+
+	return res, nil
+}
+
+// truncatedBody fails with io.ErrUnexpectedEOF once
+// remaining bytes have been read.
+type truncatedBody struct {
+	io.ReadCloser
+	remaining int64
+}
+
+func (b *truncatedBody) Read(p []byte) (int, error) {
+	if b.remaining <= 0 {
+		return 0, io.ErrUnexpectedEOF
+	}
+
+	if int64(len(p)) > b.remaining {
+		p = p[:b.remaining]
+	}
+
+	n, err := b.ReadCloser.Read(p)
+	b.remaining -= int64(n)
+
+	return n, err
+}
+
+// FaultRule describes faults injected into matching requests.
+type FaultRule struct {
+	// Methods restricts the rule to requests with one of
+	// the given methods. An empty list matches all methods.
+	Methods []string
+	// Hosts restricts the rule to requests for one of the
+	// given hosts. An empty list matches all hosts.
+	Hosts []string
+	// Paths restricts the rule to requests whose path matches
+	// one of the given path.Match patterns. An empty list
+	// matches all paths.
+	Paths []string
+	// Probability with which the rule triggers
+	// for a matching request between 0 and 1.
+	Probability float64
+
+	// Latency delays the request.
+	Latency time.Duration
+	// Err is returned instead of sending the request.
+	Err error
+	// Fail returns an error wrapping ErrInjectedFault instead
+	// of sending the request if Err is not set.
+	Fail bool
+	// StatusCode replaces the status code of the response.
+	StatusCode int
+	// TruncateBody cuts the response body short after
+	// TruncateAfter bytes causing reads to fail with
+	// io.ErrUnexpectedEOF.
+	TruncateBody  bool
+	TruncateAfter int64
+}
+
+func (r FaultRule) matches(req *http.Request) bool {
+	if len(r.Methods) > 0 && !slices.ContainsFunc(r.Methods, func(m string) bool {
+		return strings.EqualFold(m, req.Method)
+	}) {
+		return false
+	}
+
+	if len(r.Hosts) > 0 && !slices.ContainsFunc(r.Hosts, func(h string) bool {
+		return strings.EqualFold(h, req.URL.Host) || strings.EqualFold(h, req.URL.Hostname())
+	}) {
+		return false
+	}
+
+	if len(r.Paths) > 0 && !slices.ContainsFunc(r.Paths, func(pattern string) bool {
+		ok, _ := path.Match(pattern, req.URL.Path)
+
+		return ok
+	}) {
+		return false
+	}
+
+	return true
+}
+
+type FaultInjectionConfig struct {
+	Rules []FaultRule
+
+	// random returns a number in [0, 1).
+	random func() float64
+}
+
+func (c *FaultInjectionConfig) Option(opts ...FaultInjectionOption) {
+	for _, opt := range opts {
+		opt.ConfigureFaultInjection(c)
+	}
+}
+
+func (c *FaultInjectionConfig) Default() {
+	if c.random == nil {
+		c.random = rand.Float64
+	}
+}
+
+type FaultInjectionOption interface {
+	ConfigureFaultInjection(*FaultInjectionConfig)
+}
+
+// WithFaultRules adds the given rules to a FaultInjectionWrapper.
+// This option can be provided multiple times.
+type WithFaultRules []FaultRule
+
+func (r WithFaultRules) ConfigureFaultInjection(c *FaultInjectionConfig) {
+	c.Rules = append(c.Rules, r...)
+}
@@ -0,0 +1,199 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/mt-sre/client/internal/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFaultInjectionWrapperInterfaces(t *testing.T) {
+	t.Parallel()
+
+	require.Implements(t, new(http.RoundTripper), new(FaultInjectionWrapper))
+
+	require.Implements(t, new(TransportWrapper), new(FaultInjectionWrapper))
+}
+
+func TestFaultInjectionWrapper(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		Rules           []FaultRule
+		Method          string
+		URL             string
+		Random          float64
+		ExpectSent      bool
+		ExpectedErr     error
+		ExpectedStatus  int
+		ExpectedBody    string
+		ExpectedReadErr error
+	}{
+		"no rules": {
+			Method:         http.MethodGet,
+			URL:            "https://api.example.com/items",
+			ExpectSent:     true,
+			ExpectedStatus: http.StatusOK,
+			ExpectedBody:   "payload",
+		},
+		"injected error": {
+			Rules:       []FaultRule{{Probability: 1, Err: syscall.ECONNRESET}},
+			Method:      http.MethodGet,
+			URL:         "https://api.example.com/items",
+			ExpectedErr: syscall.ECONNRESET,
+		},
+		"injected failure": {
+			Rules:       []FaultRule{{Probability: 1, Fail: true}},
+			Method:      http.MethodGet,
+			URL:         "https://api.example.com/items",
+			ExpectedErr: ErrInjectedFault,
+		},
+		"probability not reached": {
+			Rules:          []FaultRule{{Probability: 0.5, Fail: true}},
+			Method:         http.MethodGet,
+			URL:            "https://api.example.com/items",
+			Random:         0.5,
+			ExpectSent:     true,
+			ExpectedStatus: http.StatusOK,
+			ExpectedBody:   "payload",
+		},
+		"status code": {
+			Rules:          []FaultRule{{Probability: 1, StatusCode: http.StatusServiceUnavailable}},
+			Method:         http.MethodGet,
+			URL:            "https://api.example.com/items",
+			ExpectSent:     true,
+			ExpectedStatus: http.StatusServiceUnavailable,
+			ExpectedBody:   "payload",
+		},
+		"truncated body": {
+			Rules:           []FaultRule{{Probability: 1, TruncateBody: true, TruncateAfter: 3}},
+			Method:          http.MethodGet,
+			URL:             "https://api.example.com/items",
+			ExpectSent:      true,
+			ExpectedStatus:  http.StatusOK,
+			ExpectedBody:    "pay",
+			ExpectedReadErr: io.ErrUnexpectedEOF,
+		},
+		"method matched": {
+			Rules:       []FaultRule{{Methods: []string{"post"}, Probability: 1, Fail: true}},
+			Method:      http.MethodPost,
+			URL:         "https://api.example.com/items",
+			ExpectedErr: ErrInjectedFault,
+		},
+		"method not matched": {
+			Rules:          []FaultRule{{Methods: []string{http.MethodPost}, Probability: 1, Fail: true}},
+			Method:         http.MethodGet,
+			URL:            "https://api.example.com/items",
+			ExpectSent:     true,
+			ExpectedStatus: http.StatusOK,
+			ExpectedBody:   "payload",
+		},
+		"host matched": {
+			Rules:       []FaultRule{{Hosts: []string{"api.example.com"}, Probability: 1, Fail: true}},
+			Method:      http.MethodGet,
+			URL:         "https://api.example.com:8443/items",
+			ExpectedErr: ErrInjectedFault,
+		},
+		"host not matched": {
+			Rules:          []FaultRule{{Hosts: []string{"other.example.com"}, Probability: 1, Fail: true}},
+			Method:         http.MethodGet,
+			URL:            "https://api.example.com/items",
+			ExpectSent:     true,
+			ExpectedStatus: http.StatusOK,
+			ExpectedBody:   "payload",
+		},
+		"path matched": {
+			Rules:       []FaultRule{{Paths: []string{"/items/*"}, Probability: 1, Fail: true}},
+			Method:      http.MethodGet,
+			URL:         "https://api.example.com/items/1",
+			ExpectedErr: ErrInjectedFault,
+		},
+		"path not matched": {
+			Rules:          []FaultRule{{Paths: []string{"/items/*"}, Probability: 1, Fail: true}},
+			Method:         http.MethodGet,
+			URL:            "https://api.example.com/users/1",
+			ExpectSent:     true,
+			ExpectedStatus: http.StatusOK,
+			ExpectedBody:   "payload",
+		},
+	} {
+		tc := tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			mrt := &testutils.MockRoundTripper{}
+			mrt.
+				On("RoundTrip", mock.Anything).
+				Return(&http.Response{
+					StatusCode: http.StatusOK,
+					Body:       io.NopCloser(strings.NewReader("payload")),
+				}, nil)
+
+			w := NewFaultInjectionWrapper(WithFaultRules(tc.Rules))
+			w.cfg.random = func() float64 { return tc.Random }
+
+			req, err := http.NewRequest(tc.Method, tc.URL, nil)
+			require.NoError(t, err)
+
+			res, err := w.Wrap(mrt).RoundTrip(req)
+
+			if tc.ExpectSent {
+				mrt.AssertNumberOfCalls(t, "RoundTrip", 1)
+			} else {
+				mrt.AssertNotCalled(t, "RoundTrip", mock.Anything)
+			}
+
+			if tc.ExpectedErr != nil {
+				assert.ErrorIs(t, err, tc.ExpectedErr)
+				assert.Nil(t, res)
+
+				return
+			}
+
+			require.NoError(t, err)
+
+			body, err := io.ReadAll(res.Body)
+			assert.Equal(t, tc.ExpectedReadErr, err)
+			assert.Equal(t, tc.ExpectedBody, string(body))
+			assert.Equal(t, tc.ExpectedStatus, res.StatusCode)
+		})
+	}
+}
+
+func TestFaultInjectionWrapperLatency(t *testing.T) {
+	t.Parallel()
+
+	mrt := &testutils.MockRoundTripper{}
+	mrt.
+		On("RoundTrip", mock.Anything).
+		Return(&http.Response{StatusCode: http.StatusOK, Body: http.NoBody}, nil)
+
+	rt := NewFaultInjectionWrapper(WithFaultRules{{Probability: 1, Latency: 20 * time.Millisecond}}).Wrap(mrt)
+
+	start := time.Now()
+
+	res, err := rt.RoundTrip(testutils.MockRequest(t, http.MethodGet, nil))
+	require.NoError(t, err)
+	require.NoError(t, res.Body.Close())
+
+	assert.GreaterOrEqual(t, time.Since(start), 20*time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	rt = NewFaultInjectionWrapper(WithFaultRules{{Probability: 1, Latency: time.Hour}}).Wrap(mrt)
+
+	_, err = rt.RoundTrip(testutils.MockRequest(t, http.MethodGet, nil).WithContext(ctx))
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	mrt.AssertNumberOfCalls(t, "RoundTrip", 1)
+}
@@ -0,0 +1,126 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// PostForm performs a HTTP POST request against the provided URL
+// with the given values URL-encoded as the request body.
+func (c *Client) PostForm(ctx context.Context, rawURL string, data url.Values, opts ...RequestOption) (*http.Response, error) {
+	opts = append(opts, withDefaultRequestHeaders{
+		"Content-Type": []string{"application/x-www-form-urlencoded"},
+	})
+
+	return c.requestWithBody(ctx, http.MethodPost, rawURL, strings.NewReader(data.Encode()), opts...)
+}
+
+// MultipartFile describes a file part of a multipart/form-data body.
+type MultipartFile struct {
+	// FieldName is the name of the form field.
+	FieldName string
+	// FileName is the file name reported to the server.
+	FileName string
+	// ContentType of the file. Defaults to "application/octet-stream".
+	ContentType string
+	// Content is streamed into the request body. Closing it, if
+	// required, is the responsibility of the caller.
+	Content io.Reader
+}
+
+// PostMultipart performs a HTTP POST request against the provided URL
+// with a multipart/form-data body built from the given fields and
+// files. File contents are streamed into the request rather than
+// being buffered in memory.
+func (c *Client) PostMultipart(ctx context.Context, rawURL string, fields url.Values, files []MultipartFile, opts ...RequestOption) (*http.Response, error) {
+	pr, pw := io.Pipe()
+	mw := multipart.NewWriter(pw)
+
+	go func() {
+		pw.CloseWithError(writeMultipart(mw, fields, files))
+	}()
+
+	// unblock the writer if the body was not consumed completely
+	defer pr.Close()
+
+	opts = append(opts, withDefaultRequestHeaders{
+		"Content-Type": []string{mw.FormDataContentType()},
+	})
+
+	return c.requestWithBody(ctx, http.MethodPost, rawURL, pr, opts...)
+}
+
+func writeMultipart(mw *multipart.Writer, fields url.Values, files []MultipartFile) error {
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		for _, val := range fields[key] {
+			if err := mw.WriteField(key, val); err != nil {
+				return fmt.Errorf("writing field %q: %w", key, err)
+			}
+		}
+	}
+
+	for _, file := range files {
+		if err := writeMultipartFile(mw, file); err != nil {
+			return fmt.Errorf("writing file %q: %w", file.FileName, err)
+		}
+	}
+
+	return mw.Close()
+}
+
+var quoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
+
+func writeMultipartFile(mw *multipart.Writer, file MultipartFile) error {
+	contentType := file.ContentType
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	header := make(textproto.MIMEHeader)
+	header.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%s"; filename="%s"`,
+		quoteEscaper.Replace(file.FieldName), quoteEscaper.Replace(file.FileName)))
+	header.Set("Content-Type", contentType)
+
+	part, err := mw.CreatePart(header)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(part, file.Content)
+
+	return err
+}
+
+// DecodeForm decodes the application/x-www-form-urlencoded body of
+// res, as returned by some legacy services and OAuth token endpoints,
+// and closes the body. Errors are reported as with DecodeJSON.
+func DecodeForm(res *http.Response) (url.Values, error) {
+	values := url.Values{}
+
+	err := decodeResponse(res, func(r io.Reader) error {
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return err
+		}
+
+		values, err = url.ParseQuery(string(data))
+
+		return err
+	})
+
+	return values, err
+}
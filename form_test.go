@@ -0,0 +1,135 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientPostForm(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		Options             []RequestOption
+		ExpectedContentType string
+	}{
+		"default content type": {
+			ExpectedContentType: "application/x-www-form-urlencoded",
+		},
+		"explicit content type": {
+			Options: []RequestOption{
+				WithRequestHeaders{"Content-Type": {"application/x-www-form-urlencoded; charset=utf-8"}},
+			},
+			ExpectedContentType: "application/x-www-form-urlencoded; charset=utf-8",
+		},
+	} {
+		tc := tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, http.MethodPost, r.Method)
+				assert.Equal(t, []string{tc.ExpectedContentType}, r.Header.Values("Content-Type"))
+
+				require.NoError(t, r.ParseForm())
+
+				assert.Equal(t, []string{"a", "b"}, r.PostForm["key"])
+				assert.Equal(t, "with space", r.PostForm.Get("other"))
+			}))
+			defer srv.Close()
+
+			res, err := NewClient().PostForm(context.Background(), srv.URL, url.Values{
+				"key":   {"a", "b"},
+				"other": {"with space"},
+			}, tc.Options...)
+			require.NoError(t, err)
+			defer res.Body.Close()
+
+			assert.Equal(t, http.StatusOK, res.StatusCode)
+		})
+	}
+}
+
+func TestDecodeForm(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/x-www-form-urlencoded")
+		io.WriteString(w, "access_token=abc&scope=repo%2Cgist&token_type=bearer")
+	}))
+	defer srv.Close()
+
+	res, err := NewClient().PostForm(context.Background(), srv.URL, url.Values{"code": {"123"}})
+	require.NoError(t, err)
+
+	values, err := DecodeForm(res)
+	require.NoError(t, err)
+
+	assert.Equal(t, "abc", values.Get("access_token"))
+	assert.Equal(t, "repo,gist", values.Get("scope"))
+}
+
+func TestClientPostMultipart(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Len(t, r.Header.Values("Content-Type"), 1)
+
+		require.NoError(t, r.ParseMultipartForm(1<<20))
+
+		assert.Equal(t, "value", r.FormValue("field"))
+
+		f, header, err := r.FormFile("upload")
+		require.NoError(t, err)
+		defer f.Close()
+
+		assert.Equal(t, "bundle.tar", header.Filename)
+		assert.Equal(t, "application/x-tar", header.Header.Get("Content-Type"))
+
+		data, err := io.ReadAll(f)
+		require.NoError(t, err)
+
+		assert.Equal(t, "file contents", string(data))
+	}))
+	defer srv.Close()
+
+	res, err := NewClient().PostMultipart(context.Background(), srv.URL,
+		url.Values{"field": {"value"}},
+		[]MultipartFile{
+			{
+				FieldName:   "upload",
+				FileName:    "bundle.tar",
+				ContentType: "application/x-tar",
+				Content:     strings.NewReader("file contents"),
+			},
+		},
+	)
+	require.NoError(t, err)
+	defer res.Body.Close()
+
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+}
+
+// TestClientPostMultipartRequestError ensures that the body writer
+// does not block when the request fails before the body is read.
+func TestClientPostMultipartRequestError(t *testing.T) {
+	t.Parallel()
+
+	_, err := NewClient().PostMultipart(context.Background(), "http://127.0.0.1:0", nil, []MultipartFile{
+		{
+			FieldName: "upload",
+			FileName:  "large",
+			Content:   strings.NewReader(strings.Repeat("x", 1<<20)),
+		},
+	})
+	require.Error(t, err)
+}
@@ -0,0 +1,239 @@
+package client
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Link is a single link of an RFC 8288 Link header.
+type Link struct {
+	// URL is the target of the link as it appears in the header.
+	URL string
+	// Rel is the link's relation type e.g. "next".
+	Rel string
+	// Params holds all parameters of the link keyed
+	// by their lower-cased name.
+	Params map[string]string
+}
+
+// Resolve returns the link's target resolved against base.
+func (l Link) Resolve(base *url.URL) (*url.URL, error) {
+	ref, err := url.Parse(l.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	if base == nil {
+		return ref, nil
+	}
+
+	return base.ResolveReference(ref), nil
+}
+
+// Links is a list of links parsed from Link headers.
+type Links []Link
+
+// Rel returns the first link with the given relation type.
+// Links declaring multiple space separated relation types
+// are matched against each of them.
+func (ls Links) Rel(rel string) (Link, bool) {
+	for _, l := range ls {
+		for _, r := range strings.Fields(l.Rel) {
+			if strings.EqualFold(r, rel) {
+				return l, true
+			}
+		}
+	}
+
+	return Link{}, false
+}
+
+// ParseLinkHeader parses the given Link header values. Malformed
+// links are skipped so that a single bad entry does not hide the
+// remaining links.
+func ParseLinkHeader(vals ...string) Links {
+	var links Links
+
+	for _, val := range vals {
+		for _, part := range splitHeaderList(val, ',') {
+			link, ok := parseLink(part)
+			if ok {
+				links = append(links, link)
+			}
+		}
+	}
+
+	return links
+}
+
+func parseLink(val string) (Link, bool) {
+	val = strings.TrimSpace(val)
+
+	target, rest, ok := strings.Cut(val, ">")
+	if !ok || !strings.HasPrefix(target, "<") {
+		return Link{}, false
+	}
+
+	link := Link{
+		URL:    strings.TrimSpace(target[1:]),
+		Params: make(map[string]string),
+	}
+
+	for _, param := range splitHeaderList(rest, ';') {
+		key, val := parseHeaderParam(param)
+		if key == "" {
+			continue
+		}
+
+		// only the first occurrence of a parameter is used
+		if _, ok := link.Params[key]; !ok {
+			link.Params[key] = val
+		}
+	}
+
+	link.Rel = link.Params["rel"]
+
+	return link, true
+}
+
+// CacheControl holds the directives of a Cache-Control header keyed
+// by their lower-cased name. Directives without a value map to "".
+type CacheControl map[string]string
+
+// ParseCacheControl parses the given Cache-Control header values.
+func ParseCacheControl(vals ...string) CacheControl {
+	cc := make(CacheControl)
+
+	for _, val := range vals {
+		for _, directive := range splitHeaderList(val, ',') {
+			key, val := parseHeaderParam(directive)
+			if key == "" {
+				continue
+			}
+
+			if _, ok := cc[key]; !ok {
+				cc[key] = val
+			}
+		}
+	}
+
+	return cc
+}
+
+// Has reports whether the given directive is present.
+func (cc CacheControl) Has(directive string) bool {
+	_, ok := cc[strings.ToLower(directive)]
+
+	return ok
+}
+
+// NoStore reports whether the "no-store" directive is present.
+func (cc CacheControl) NoStore() bool {
+	return cc.Has("no-store")
+}
+
+// NoCache reports whether the "no-cache" directive is present.
+func (cc CacheControl) NoCache() bool {
+	return cc.Has("no-cache")
+}
+
+// MaxAge returns the value of the "max-age" directive.
+func (cc CacheControl) MaxAge() (time.Duration, bool) {
+	return cc.Seconds("max-age")
+}
+
+// Seconds returns the value of a delta-seconds directive
+// such as "max-age" or "stale-while-revalidate".
+func (cc CacheControl) Seconds(directive string) (time.Duration, bool) {
+	val, ok := cc[strings.ToLower(directive)]
+	if !ok {
+		return 0, false
+	}
+
+	secs, err := strconv.ParseInt(val, 10, 64)
+	if err != nil || secs < 0 {
+		return 0, false
+	}
+
+	return time.Duration(secs) * time.Second, true
+}
+
+// splitHeaderList splits val on sep ignoring separators
+// which appear within quoted strings or angle brackets.
+func splitHeaderList(val string, sep byte) []string {
+	var (
+		parts   []string
+		start   int
+		quoted  bool
+		escaped bool
+		angled  bool
+	)
+
+	for i := 0; i < len(val); i++ {
+		c := val[i]
+
+		switch {
+		case escaped:
+			escaped = false
+		case quoted && c == '\\':
+			escaped = true
+		case c == '"':
+			quoted = !quoted
+		case quoted:
+		case c == '<':
+			angled = true
+		case c == '>':
+			angled = false
+		case c == sep && !angled:
+			parts = append(parts, val[start:i])
+			start = i + 1
+		}
+	}
+
+	parts = append(parts, val[start:])
+
+	res := parts[:0]
+
+	for _, part := range parts {
+		if part = strings.TrimSpace(part); part != "" {
+			res = append(res, part)
+		}
+	}
+
+	return res
+}
+
+// parseHeaderParam parses a "key=value" pair where value is
+// either a token or a quoted string. The key is lower-cased.
+func parseHeaderParam(param string) (string, string) {
+	key, val, _ := strings.Cut(param, "=")
+
+	key = strings.ToLower(strings.TrimSpace(key))
+	val = strings.TrimSpace(val)
+
+	if len(val) >= 2 && val[0] == '"' && val[len(val)-1] == '"' {
+		val = unquoteHeaderString(val[1 : len(val)-1])
+	}
+
+	return key, val
+}
+
+func unquoteHeaderString(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+
+	var b strings.Builder
+
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) {
+			i++
+		}
+
+		b.WriteByte(s[i])
+	}
+
+	return b.String()
+}
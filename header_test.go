@@ -0,0 +1,57 @@
+package client
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLinkHeader(t *testing.T) {
+	t.Parallel()
+
+	links := ParseLinkHeader(
+		`<https://api.example.com/items?page=2>; rel="next", <https://api.example.com/items?page=9>; rel="last"`,
+		`<../style.css>; rel="preload prefetch"; as=style; title="a, b; c"`,
+		`missing-brackets; rel=broken`,
+	)
+	require.Len(t, links, 3)
+
+	next, ok := links.Rel("next")
+	require.True(t, ok)
+	assert.Equal(t, "https://api.example.com/items?page=2", next.URL)
+
+	prefetch, ok := links.Rel("prefetch")
+	require.True(t, ok)
+	assert.Equal(t, "style", prefetch.Params["as"])
+	assert.Equal(t, "a, b; c", prefetch.Params["title"])
+
+	base, err := url.Parse("https://example.com/docs/index.html")
+	require.NoError(t, err)
+
+	resolved, err := prefetch.Resolve(base)
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com/style.css", resolved.String())
+
+	_, ok = links.Rel("prev")
+	assert.False(t, ok)
+}
+
+func TestParseCacheControl(t *testing.T) {
+	t.Parallel()
+
+	cc := ParseCacheControl(`Max-Age=60, private="Set-Cookie, X-Token"`, "no-cache, max-age=10")
+
+	assert.True(t, cc.NoCache())
+	assert.False(t, cc.NoStore())
+	assert.Equal(t, "Set-Cookie, X-Token", cc["private"])
+
+	maxAge, ok := cc.MaxAge()
+	require.True(t, ok)
+	assert.Equal(t, time.Minute, maxAge)
+
+	_, ok = ParseCacheControl("max-age=abc").MaxAge()
+	assert.False(t, ok)
+}
@@ -0,0 +1,223 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	defaultHedgeDelay          = 100 * time.Millisecond
+	defaultMaxHedgesPerHost    = 10
+	maxHedgeAttemptsPerRequest = 2
+)
+
+// NewHedgeWrapper returns a TransportWrapper which reduces tail
+// latency by issuing a duplicate of an idempotent request if no
+// response has been received after a delay. Whichever attempt
+// completes first successfully is returned and the other attempt is
+// canceled. The number of hedged attempts in flight per host is
+// capped so that a slow backend does not receive double the load.
+func NewHedgeWrapper(opts ...HedgeOption) *HedgeWrapper {
+	var cfg HedgeConfig
+
+	cfg.Option(opts...)
+	cfg.Default()
+
+	return &HedgeWrapper{
+		cfg:      cfg,
+		inFlight: make(map[string]int),
+	}
+}
+
+type HedgeWrapper struct {
+	cfg HedgeConfig
+	rt  http.RoundTripper
+
+	mu       sync.Mutex
+	inFlight map[string]int
+}
+
+type hedgeResult struct {
+	attempt int
+	res     *http.Response
+	err     error
+}
+
+func (w *HedgeWrapper) Wrap(rt http.RoundTripper) http.RoundTripper {
+	w.rt = rt
+
+	return w
+}
+
+func (w *HedgeWrapper) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !isRequestIdempotent(req) || (hasBody(req) && req.GetBody == nil) {
+		return w.rt.RoundTrip(req)
+	}
+
+	results := make(chan hedgeResult, maxHedgeAttemptsPerRequest)
+	contexts := make([]context.Context, 0, maxHedgeAttemptsPerRequest)
+	cancels := make([]context.CancelFunc, 0, maxHedgeAttemptsPerRequest)
+
+	launch := func(r *http.Request, release func()) {
+		ctx, cancel := context.WithCancel(req.Context())
+
+		attempt := len(cancels)
+		contexts = append(contexts, ctx)
+		cancels = append(cancels, cancel)
+
+		go func() {
+			defer release()
+
+			res, err := w.rt.RoundTrip(r.WithContext(ctx))
+			results <- hedgeResult{attempt: attempt, res: res, err: err}
+		}()
+	}
+
+	launch(req, func() {})
+
+	timer := time.NewTimer(w.cfg.Delay)
+	defer timer.Stop()
+
+	pending := 1
+
+	for {
+		select {
+		case <-timer.C:
+			if hedge, release, ok := w.hedge(req); ok {
+				launch(hedge, release)
+				pending++
+			}
+		case r := <-results:
+			pending--
+
+			if r.err != nil && pending > 0 {
+				// another attempt may still succeed
+				cancels[r.attempt]()
+
+				continue
+			}
+
+			for i, cancel := range cancels {
+				if i != r.attempt {
+					cancel()
+				}
+			}
+
+			go discardHedgeResults(results, pending)
+
+			if r.err != nil {
+				cancels[r.attempt]()
+
+				return nil, r.err
+			}
+
+			// the attempt's context must remain valid until the body is consumed
+			r.res.Body = newCancelOnCloseBody(contexts[r.attempt], cancels[r.attempt], r.res.Body)
+
+			return r.res, nil
+		}
+	}
+}
+
+// hedge returns a duplicate of req and a function releasing its
+// slot in the per-host limit if hedging is currently permitted.
+func (w *HedgeWrapper) hedge(req *http.Request) (*http.Request, func(), bool) {
+	hedge := req.Clone(req.Context())
+
+	if hasBody(req) {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, nil, false
+		}
+
+		hedge.Body = body
+	}
+
+	host := req.URL.Host
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.inFlight[host] >= w.cfg.MaxPerHost {
+		if hedge.Body != nil {
+			hedge.Body.Close()
+		}
+
+		return nil, nil, false
+	}
+
+	w.inFlight[host]++
+
+	release := func() {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+
+		if w.inFlight[host]--; w.inFlight[host] <= 0 {
+			delete(w.inFlight, host)
+		}
+	}
+
+	return hedge, release, true
+}
+
+// discardHedgeResults closes the responses of the
+// remaining attempts once they have completed.
+func discardHedgeResults(results <-chan hedgeResult, pending int) {
+	for ; pending > 0; pending-- {
+		if r := <-results; r.res != nil {
+			r.res.Body.Close()
+		}
+	}
+}
+
+func hasBody(req *http.Request) bool {
+	return req.Body != nil && req.Body != http.NoBody
+}
+
+type HedgeConfig struct {
+	// Delay is the time to wait for a response
+	// before issuing a hedged request.
+	Delay time.Duration
+	// MaxPerHost limits the number of hedged
+	// requests in flight for a single host.
+	MaxPerHost int
+}
+
+func (c *HedgeConfig) Option(opts ...HedgeOption) {
+	for _, opt := range opts {
+		opt.ConfigureHedge(c)
+	}
+}
+
+func (c *HedgeConfig) Default() {
+	if c.Delay <= 0 {
+		c.Delay = defaultHedgeDelay
+	}
+
+	if c.MaxPerHost <= 0 {
+		c.MaxPerHost = defaultMaxHedgesPerHost
+	}
+}
+
+type HedgeOption interface {
+	ConfigureHedge(*HedgeConfig)
+}
+
+// WithHedgeDelay configures the time a HedgeWrapper waits for a
+// response before issuing a hedged request. A good value is the
+// observed p95 latency of the backend. Defaults to 100ms.
+type WithHedgeDelay time.Duration
+
+func (d WithHedgeDelay) ConfigureHedge(c *HedgeConfig) {
+	c.Delay = time.Duration(d)
+}
+
+// WithMaxHedgesPerHost limits the number of hedged requests a
+// HedgeWrapper has in flight for a single host. Defaults to 10.
+type WithMaxHedgesPerHost int
+
+func (m WithMaxHedgesPerHost) ConfigureHedge(c *HedgeConfig) {
+	c.MaxPerHost = int(m)
+}
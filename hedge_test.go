@@ -0,0 +1,124 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/mt-sre/client/internal/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHedgeWrapperInterfaces(t *testing.T) {
+	t.Parallel()
+
+	require.Implements(t, new(http.RoundTripper), new(HedgeWrapper))
+
+	require.Implements(t, new(TransportWrapper), new(HedgeWrapper))
+}
+
+func TestHedgeWrapper(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		Method           string
+		Options          []HedgeOption
+		SlowPrimary      bool
+		ExpectedCalls    int
+		ExpectedCanceled bool
+	}{
+		"fast primary": {
+			Method:        http.MethodGet,
+			ExpectedCalls: 1,
+		},
+		"slow primary is hedged": {
+			Method:           http.MethodGet,
+			SlowPrimary:      true,
+			ExpectedCalls:    2,
+			ExpectedCanceled: true,
+		},
+		"non-idempotent method": {
+			Method:        http.MethodPost,
+			Options:       []HedgeOption{WithHedgeDelay(time.Millisecond)},
+			ExpectedCalls: 1,
+		},
+	} {
+		tc := tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			canceled := make(chan struct{})
+
+			var mrt testutils.MockRoundTripper
+
+			primary := mrt.On("RoundTrip", mock.Anything).Once()
+			if tc.SlowPrimary {
+				primary.
+					Run(func(args mock.Arguments) {
+						<-args.Get(0).(*http.Request).Context().Done()
+						close(canceled)
+					}).
+					Return((*http.Response)(nil), context.Canceled)
+			} else {
+				primary.Return(&http.Response{
+					StatusCode: http.StatusOK,
+					Body:       io.NopCloser(bytes.NewBufferString("primary")),
+				}, nil)
+			}
+
+			mrt.
+				On("RoundTrip", mock.Anything).
+				Return(&http.Response{
+					StatusCode: http.StatusOK,
+					Body:       io.NopCloser(bytes.NewBufferString("hedge")),
+				}, nil).
+				Maybe()
+
+			opts := append([]HedgeOption{WithHedgeDelay(10 * time.Millisecond)}, tc.Options...)
+			rt := NewHedgeWrapper(opts...).Wrap(&mrt)
+
+			res, err := rt.RoundTrip(testutils.MockRequest(t, tc.Method, nil))
+			require.NoError(t, err)
+
+			body, err := io.ReadAll(res.Body)
+			require.NoError(t, err)
+			require.NoError(t, res.Body.Close())
+
+			if tc.ExpectedCanceled {
+				assert.Equal(t, "hedge", string(body))
+
+				select {
+				case <-canceled:
+				case <-time.After(time.Second):
+					t.Fatal("primary attempt was not canceled")
+				}
+			}
+
+			mrt.AssertNumberOfCalls(t, "RoundTrip", tc.ExpectedCalls)
+		})
+	}
+}
+
+func TestHedgeWrapperMaxPerHost(t *testing.T) {
+	t.Parallel()
+
+	w := NewHedgeWrapper(WithMaxHedgesPerHost(1))
+	req := testutils.MockRequest(t, http.MethodGet, nil)
+
+	_, release, ok := w.hedge(req)
+	require.True(t, ok)
+
+	_, _, ok = w.hedge(req)
+	assert.False(t, ok, "second hedge must exceed the per host limit")
+
+	release()
+
+	_, _, ok = w.hedge(req)
+	assert.True(t, ok)
+}
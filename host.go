@@ -0,0 +1,43 @@
+package client
+
+import (
+	"net"
+	"strings"
+)
+
+// matchHost reports whether host matches any of the given patterns.
+// Patterns follow the conventions of the NO_PROXY environment
+// variable: a host name matches itself and its subdomains, a leading
+// "." matches subdomains only, an IP address or CIDR range matches
+// IP hosts and "*" matches every host.
+func matchHost(patterns []string, host string) bool {
+	host = strings.ToLower(host)
+	ip := net.ParseIP(host)
+
+	for _, entry := range patterns {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+
+		switch {
+		case entry == "":
+			continue
+		case entry == "*":
+			return true
+		case ip != nil:
+			if _, cidr, err := net.ParseCIDR(entry); err == nil && cidr.Contains(ip) {
+				return true
+			}
+
+			if entryIP := net.ParseIP(entry); entryIP != nil && entryIP.Equal(ip) {
+				return true
+			}
+		case strings.HasPrefix(entry, "."):
+			if strings.HasSuffix(host, entry) {
+				return true
+			}
+		case host == entry || strings.HasSuffix(host, "."+entry):
+			return true
+		}
+	}
+
+	return false
+}
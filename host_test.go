@@ -0,0 +1,38 @@
+package client
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatchHost(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		Patterns []string
+		Host     string
+		Expected bool
+	}{
+		"empty":                 {Patterns: []string{""}, Host: "example.com", Expected: false},
+		"wildcard":              {Patterns: []string{"*"}, Host: "example.com", Expected: true},
+		"exact host":            {Patterns: []string{"example.com"}, Host: "example.com", Expected: true},
+		"subdomain":             {Patterns: []string{"example.com"}, Host: "api.example.com", Expected: true},
+		"suffix only":           {Patterns: []string{".example.com"}, Host: "example.com", Expected: false},
+		"suffix subdomain":      {Patterns: []string{".example.com"}, Host: "api.example.com", Expected: true},
+		"different host":        {Patterns: []string{"example.com"}, Host: "badexample.com", Expected: false},
+		"case insensitive":      {Patterns: []string{"Example.COM"}, Host: "example.com", Expected: true},
+		"ip address":            {Patterns: []string{"10.0.0.1"}, Host: "10.0.0.1", Expected: true},
+		"cidr":                  {Patterns: []string{"10.0.0.0/8"}, Host: "10.1.2.3", Expected: true},
+		"cidr no match":         {Patterns: []string{"10.0.0.0/8"}, Host: "192.168.0.1", Expected: false},
+		"cidr ignored for host": {Patterns: []string{"10.0.0.0/8"}, Host: "example.com", Expected: false},
+	} {
+		tc := tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tc.Expected, matchHost(tc.Patterns, tc.Host))
+		})
+	}
+}
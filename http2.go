@@ -0,0 +1,156 @@
+package client
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"time"
+
+	"golang.org/x/net/http2"
+)
+
+// HTTP2Mode controls whether HTTP/2 is used by a Client.
+type HTTP2Mode int
+
+const (
+	// HTTP2Auto negotiates HTTP/2 over TLS when supported
+	// by the server and uses HTTP/1.1 otherwise.
+	HTTP2Auto HTTP2Mode = iota
+	// HTTP2Disabled always uses HTTP/1.1.
+	HTTP2Disabled
+	// HTTP2Forced always uses HTTP/2. Plain HTTP requests use
+	// HTTP/2 with prior knowledge (h2c).
+	HTTP2Forced
+)
+
+// HTTP2Config configures the use of HTTP/2.
+type HTTP2Config struct {
+	Mode HTTP2Mode
+	// ReadIdleTimeout is the time after which a health check
+	// using a ping frame is performed if no frame has been
+	// received on a connection.
+	ReadIdleTimeout time.Duration
+	// PingTimeout is the time after which a connection is
+	// closed if no response to a ping frame is received.
+	PingTimeout time.Duration
+}
+
+// WithDisableHTTP2 configures a Client instance to always use HTTP/1.1.
+// This can be used to work around misbehaving HTTP/2 intermediaries
+// which cause PROTOCOL_ERROR or REFUSED_STREAM failures.
+type WithDisableHTTP2 struct{}
+
+func (WithDisableHTTP2) ConfigureClient(c *ClientConfig) {
+	c.HTTP2.Mode = HTTP2Disabled
+}
+
+// WithForceHTTP2 configures a Client instance to always use HTTP/2.
+// Requests to servers which do not support HTTP/2 fail. Plain HTTP
+// requests use HTTP/2 with prior knowledge (h2c). Proxy and dialer
+// related options do not apply to forced HTTP/2 connections.
+type WithForceHTTP2 struct{}
+
+func (WithForceHTTP2) ConfigureClient(c *ClientConfig) {
+	c.HTTP2.Mode = HTTP2Forced
+}
+
+// WithHTTP2HealthCheck configures a Client instance to probe idle
+// HTTP/2 connections with ping frames after ReadIdleTimeout and to
+// close them if no response is received within PingTimeout.
+type WithHTTP2HealthCheck struct {
+	ReadIdleTimeout time.Duration
+	PingTimeout     time.Duration
+}
+
+func (hc WithHTTP2HealthCheck) ConfigureClient(c *ClientConfig) {
+	c.HTTP2.ReadIdleTimeout = hc.ReadIdleTimeout
+	c.HTTP2.PingTimeout = hc.PingTimeout
+}
+
+// configureHTTP2 applies the HTTP/2 configuration to tp returning
+// the http.RoundTripper which should be used in its place.
+func (c *ClientConfig) configureHTTP2(tp *http.Transport) http.RoundTripper {
+	switch c.HTTP2.Mode {
+	case HTTP2Disabled:
+		tp.ForceAttemptHTTP2 = false
+		// a non-nil empty map disables HTTP/2 negotiation
+		tp.TLSNextProto = make(map[string]func(string, *tls.Conn) http.RoundTripper)
+
+		return tp
+	case HTTP2Forced:
+		return c.forcedHTTP2Transport(tp)
+	}
+
+	if c.HTTP2.ReadIdleTimeout == 0 && c.HTTP2.PingTimeout == 0 {
+		return tp
+	}
+
+	// discard protocols inherited from http.DefaultTransport so
+	// that a configurable HTTP/2 transport can be registered
+	tp.TLSNextProto = nil
+
+	// ConfigureTransports only fails if HTTP/2 is already registered
+	h2, err := http2.ConfigureTransports(tp)
+	if err != nil {
+		return tp
+	}
+
+	h2.ReadIdleTimeout = c.HTTP2.ReadIdleTimeout
+	h2.PingTimeout = c.HTTP2.PingTimeout
+
+	return tp
+}
+
+func (c *ClientConfig) forcedHTTP2Transport(tp *http.Transport) http.RoundTripper {
+	// dial through tp so that connections are resolved,
+	// counted and guarded like those of tp itself
+	dial := tp.DialContext
+	tlsConfig := tp.TLSClientConfig
+
+	return &schemeTransport{
+		https: &http2.Transport{
+			TLSClientConfig: tlsConfig,
+			ReadIdleTimeout: c.HTTP2.ReadIdleTimeout,
+			PingTimeout:     c.HTTP2.PingTimeout,
+			DialTLSContext: func(ctx context.Context, network, addr string, cfg *tls.Config) (net.Conn, error) {
+				conn, err := dial(ctx, network, addr)
+				if err != nil {
+					return nil, err
+				}
+
+				tlsConn := tls.Client(conn, cfg)
+
+				if err := tlsConn.HandshakeContext(ctx); err != nil {
+					conn.Close()
+
+					return nil, err
+				}
+
+				return tlsConn, nil
+			},
+		},
+		http: &http2.Transport{
+			AllowHTTP:       true,
+			ReadIdleTimeout: c.HTTP2.ReadIdleTimeout,
+			PingTimeout:     c.HTTP2.PingTimeout,
+			DialTLSContext: func(ctx context.Context, network, addr string, _ *tls.Config) (net.Conn, error) {
+				return dial(ctx, network, addr)
+			},
+		},
+	}
+}
+
+// schemeTransport routes requests to a transport based on the URL scheme.
+type schemeTransport struct {
+	https http.RoundTripper
+	http  http.RoundTripper
+}
+
+func (t *schemeTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.URL.Scheme == "http" {
+		return t.http.RoundTrip(req)
+	}
+
+	return t.https.RoundTrip(req)
+}
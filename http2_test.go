@@ -0,0 +1,86 @@
+package client
+
+import (
+	"context"
+	"crypto/x509"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+)
+
+func TestHTTP2Options(t *testing.T) {
+	t.Parallel()
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	tlsSrv := httptest.NewUnstartedServer(handler)
+	tlsSrv.EnableHTTP2 = true
+	tlsSrv.StartTLS()
+	t.Cleanup(tlsSrv.Close)
+
+	h2cSrv := httptest.NewServer(h2c.NewHandler(handler, &http2.Server{}))
+	t.Cleanup(h2cSrv.Close)
+
+	pool := x509.NewCertPool()
+	pool.AddCert(tlsSrv.Certificate())
+
+	for name, tc := range map[string]struct {
+		Options       []ClientOption
+		URL           string
+		ExpectedProto int
+	}{
+		"auto tls": {
+			URL:           tlsSrv.URL,
+			ExpectedProto: 2,
+		},
+		"auto plain": {
+			URL:           h2cSrv.URL,
+			ExpectedProto: 1,
+		},
+		"disabled": {
+			Options:       []ClientOption{WithDisableHTTP2{}},
+			URL:           tlsSrv.URL,
+			ExpectedProto: 1,
+		},
+		"forced tls": {
+			Options:       []ClientOption{WithForceHTTP2{}},
+			URL:           tlsSrv.URL,
+			ExpectedProto: 2,
+		},
+		"forced prior knowledge": {
+			Options:       []ClientOption{WithForceHTTP2{}},
+			URL:           h2cSrv.URL,
+			ExpectedProto: 2,
+		},
+		"health check": {
+			Options: []ClientOption{WithHTTP2HealthCheck{
+				ReadIdleTimeout: time.Minute,
+				PingTimeout:     time.Second,
+			}},
+			URL:           tlsSrv.URL,
+			ExpectedProto: 2,
+		},
+	} {
+		tc := tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			opts := append([]ClientOption{WithCACertPool{CertPool: pool}}, tc.Options...)
+
+			res, err := NewClient(opts...).Get(context.Background(), tc.URL)
+			require.NoError(t, err)
+			defer res.Body.Close()
+
+			assert.Equal(t, tc.ExpectedProto, res.ProtoMajor)
+		})
+	}
+}
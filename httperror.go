@@ -0,0 +1,221 @@
+package client
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+)
+
+// defaultMaxErrorBodySize limits the portion of a response
+// body which is captured by an HTTPError.
+const defaultMaxErrorBodySize = 64 << 10
+
+// maxErrorMessageBodySize limits the portion of a
+// captured body included in the error message.
+const maxErrorMessageBodySize = 512
+
+// HTTPError is returned by a Client configured with WithErrorOnNon2xx
+// when a response with a status code outside of the 2xx range is
+// received.
+type HTTPError struct {
+	Method     string
+	URL        string
+	StatusCode int
+	Status     string
+	Header     http.Header
+	// Body holds the beginning of the response body
+	// up to a size limit.
+	Body []byte
+	// Truncated is set if Body does not hold
+	// the complete response body.
+	Truncated bool
+	// Response is set by a Client configured with
+	// WithCaptureErrorBodies. Its body yields the complete
+	// response body and must be closed if Truncated is set.
+	Response *http.Response
+
+	// includeBody causes Error to include Body.
+	includeBody bool
+}
+
+// NewHTTPError returns an *HTTPError describing res. The body of res
+// is consumed and closed. This allows code handling responses itself
+// to report unexpected statuses consistently with WithErrorOnNon2xx.
+func NewHTTPError(res *http.Response) *HTTPError {
+	return newHTTPError(res, defaultMaxErrorBodySize)
+}
+
+// newHTTPError consumes and closes the body of res
+// capturing at most limit bytes.
+func newHTTPError(res *http.Response, limit int64) *HTTPError {
+	defer res.Body.Close()
+
+	httpErr := &HTTPError{
+		StatusCode: res.StatusCode,
+		Status:     res.Status,
+		Header:     res.Header,
+	}
+
+	if res.Request != nil {
+		httpErr.Method = res.Request.Method
+		httpErr.URL = redactURL(res.Request.Context(), res.Request.URL)
+	}
+
+	body, _ := io.ReadAll(io.LimitReader(res.Body, limit+1))
+	if int64(len(body)) > limit {
+		body = body[:limit]
+		httpErr.Truncated = true
+	}
+
+	httpErr.Body = body
+
+	return httpErr
+}
+
+// captureHTTPError behaves like newHTTPError but leaves the body of
+// res readable in full. The body is closed unless it exceeds limit.
+func captureHTTPError(res *http.Response, limit int64) *HTTPError {
+	httpErr := &HTTPError{
+		StatusCode:  res.StatusCode,
+		Status:      res.Status,
+		Header:      res.Header,
+		Response:    res,
+		includeBody: true,
+	}
+
+	if res.Request != nil {
+		httpErr.Method = res.Request.Method
+		httpErr.URL = redactURL(res.Request.Context(), res.Request.URL)
+	}
+
+	body, _ := io.ReadAll(io.LimitReader(res.Body, limit+1))
+	if int64(len(body)) > limit {
+		httpErr.Body = body[:limit]
+		httpErr.Truncated = true
+
+		res.Body = &peekedBody{
+			Reader: io.MultiReader(bytes.NewReader(body), res.Body),
+			Closer: res.Body,
+		}
+
+		return httpErr
+	}
+
+	res.Body.Close()
+
+	httpErr.Body = body
+	res.Body = io.NopCloser(bytes.NewReader(body))
+
+	return httpErr
+}
+
+func (e *HTTPError) Error() string {
+	status := e.Status
+	if status == "" {
+		status = fmt.Sprintf("%d %s", e.StatusCode, http.StatusText(e.StatusCode))
+	}
+
+	msg := fmt.Sprintf("unexpected status %s", status)
+	if e.Method != "" {
+		msg = fmt.Sprintf("%s %s: %s", e.Method, e.URL, msg)
+	}
+
+	if detail := e.bodySnippet(); detail != "" {
+		msg = fmt.Sprintf("%s: %s", msg, detail)
+	}
+
+	return msg
+}
+
+// bodySnippet returns the beginning of a captured textual
+// body collapsed onto a single line.
+func (e *HTTPError) bodySnippet() string {
+	if !e.includeBody || !utf8.Valid(e.Body) {
+		return ""
+	}
+
+	snippet := strings.Join(strings.Fields(string(e.Body)), " ")
+
+	if len(snippet) > maxErrorMessageBodySize {
+		cut := maxErrorMessageBodySize
+		for !utf8.RuneStart(snippet[cut]) {
+			cut--
+		}
+
+		snippet = snippet[:cut] + "..."
+	}
+
+	return snippet
+}
+
+// IsStatus reports whether err is or wraps an *HTTPError
+// with the given status code.
+func IsStatus(err error, code int) bool {
+	var httpErr *HTTPError
+
+	return errors.As(err, &httpErr) && httpErr.StatusCode == code
+}
+
+// IsBadRequest reports whether err is or wraps an *HTTPError
+// with status 400 Bad Request.
+func IsBadRequest(err error) bool { return IsStatus(err, http.StatusBadRequest) }
+
+// IsUnauthorized reports whether err is or wraps an *HTTPError
+// with status 401 Unauthorized.
+func IsUnauthorized(err error) bool { return IsStatus(err, http.StatusUnauthorized) }
+
+// IsForbidden reports whether err is or wraps an *HTTPError
+// with status 403 Forbidden.
+func IsForbidden(err error) bool { return IsStatus(err, http.StatusForbidden) }
+
+// IsNotFound reports whether err is or wraps an *HTTPError
+// with status 404 Not Found.
+func IsNotFound(err error) bool { return IsStatus(err, http.StatusNotFound) }
+
+// IsConflict reports whether err is or wraps an *HTTPError
+// with status 409 Conflict.
+func IsConflict(err error) bool { return IsStatus(err, http.StatusConflict) }
+
+// IsTooManyRequests reports whether err is or wraps an *HTTPError
+// with status 429 Too Many Requests.
+func IsTooManyRequests(err error) bool { return IsStatus(err, http.StatusTooManyRequests) }
+
+// IsServerError reports whether err is or wraps an *HTTPError
+// with a 5xx status code.
+func IsServerError(err error) bool {
+	var httpErr *HTTPError
+
+	return errors.As(err, &httpErr) && httpErr.StatusCode >= 500 && httpErr.StatusCode < 600
+}
+
+// WithErrorOnNon2xx configures a Client instance to return an
+// *HTTPError from its request methods whenever a response with a
+// status code outside of the 2xx range is received. The response
+// body is consumed and at most MaxBodySize bytes, 64 KiB by
+// default, are captured in the error.
+type WithErrorOnNon2xx struct {
+	MaxBodySize int64
+}
+
+func (e WithErrorOnNon2xx) ConfigureClient(c *ClientConfig) {
+	c.ErrorOnNon2xx = true
+	c.MaxErrorBodySize = e.MaxBodySize
+}
+
+// WithCaptureErrorBodies behaves like WithErrorOnNon2xx but includes
+// the beginning of the captured body in the message of the returned
+// *HTTPError so that the explanation given by the server is reported.
+// The response is retained as HTTPError.Response with its body
+// restored for callers which need to decode it.
+type WithCaptureErrorBodies struct {
+	MaxBodySize int64
+}
+
+func (e WithCaptureErrorBodies) ConfigureClient(c *ClientConfig) {
+	WithErrorOnNon2xx(e).ConfigureClient(c)
+	c.CaptureErrorBodies = true
+}
@@ -0,0 +1,186 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithErrorOnNon2xx(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		code, err := strconv.Atoi(r.URL.Query().Get("code"))
+		require.NoError(t, err)
+
+		w.Header().Set("X-Test", "value")
+		w.WriteHeader(code)
+		fmt.Fprint(w, strings.Repeat("x", 10))
+	}))
+	t.Cleanup(srv.Close)
+
+	for name, tc := range map[string]struct {
+		Options       []ClientOption
+		StatusCode    int
+		ExpectError   bool
+		ExpectedBody  string
+		ExpectedTrunc bool
+	}{
+		"success": {
+			Options:    []ClientOption{WithErrorOnNon2xx{}},
+			StatusCode: http.StatusNoContent,
+		},
+		"not found": {
+			Options:      []ClientOption{WithErrorOnNon2xx{}},
+			StatusCode:   http.StatusNotFound,
+			ExpectError:  true,
+			ExpectedBody: strings.Repeat("x", 10),
+		},
+		"truncated body": {
+			Options:       []ClientOption{WithErrorOnNon2xx{MaxBodySize: 4}},
+			StatusCode:    http.StatusInternalServerError,
+			ExpectError:   true,
+			ExpectedBody:  "xxxx",
+			ExpectedTrunc: true,
+		},
+		"disabled": {
+			StatusCode: http.StatusNotFound,
+		},
+	} {
+		tc := tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			client := NewClient(tc.Options...)
+
+			url := fmt.Sprintf("%s/?code=%d", srv.URL, tc.StatusCode)
+
+			res, err := client.Get(context.Background(), url)
+			if !tc.ExpectError {
+				require.NoError(t, err)
+				defer res.Body.Close()
+
+				assert.Equal(t, tc.StatusCode, res.StatusCode)
+
+				return
+			}
+
+			require.Error(t, err)
+
+			var httpErr *HTTPError
+			require.ErrorAs(t, err, &httpErr)
+
+			assert.Equal(t, tc.StatusCode, httpErr.StatusCode)
+			assert.Equal(t, http.MethodGet, httpErr.Method)
+			assert.Equal(t, url, httpErr.URL)
+			assert.Equal(t, "value", httpErr.Header.Get("X-Test"))
+			assert.Equal(t, tc.ExpectedBody, string(httpErr.Body))
+			assert.Equal(t, tc.ExpectedTrunc, httpErr.Truncated)
+			assert.True(t, IsStatus(err, tc.StatusCode))
+		})
+	}
+}
+
+func TestHTTPErrorHelpers(t *testing.T) {
+	t.Parallel()
+
+	wrap := func(code int) error {
+		return fmt.Errorf("wrapped: %w", &HTTPError{StatusCode: code})
+	}
+
+	assert.True(t, IsBadRequest(wrap(http.StatusBadRequest)))
+	assert.True(t, IsUnauthorized(wrap(http.StatusUnauthorized)))
+	assert.True(t, IsForbidden(wrap(http.StatusForbidden)))
+	assert.True(t, IsNotFound(wrap(http.StatusNotFound)))
+	assert.True(t, IsConflict(wrap(http.StatusConflict)))
+	assert.True(t, IsTooManyRequests(wrap(http.StatusTooManyRequests)))
+	assert.True(t, IsServerError(wrap(http.StatusBadGateway)))
+
+	assert.False(t, IsNotFound(wrap(http.StatusConflict)))
+	assert.False(t, IsServerError(wrap(http.StatusNotFound)))
+	assert.False(t, IsNotFound(fmt.Errorf("not an HTTPError")))
+	assert.False(t, IsNotFound(nil))
+
+	assert.Equal(t, "unexpected status 404 Not Found", (&HTTPError{StatusCode: http.StatusNotFound}).Error())
+}
+
+func TestWithCaptureErrorBodies(t *testing.T) {
+	t.Parallel()
+
+	const message = `{"kind": "Error",
+  "reason": "Cluster name is already taken"}`
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusConflict)
+		fmt.Fprint(w, message)
+	}))
+	t.Cleanup(srv.Close)
+
+	for name, tc := range map[string]struct {
+		MaxBodySize       int64
+		ExpectedMessage   string
+		ExpectedTruncated bool
+	}{
+		"complete body": {
+			ExpectedMessage: `unexpected status 409 Conflict: {"kind": "Error", "reason": "Cluster name is already taken"}`,
+		},
+		"truncated body": {
+			MaxBodySize:       16,
+			ExpectedMessage:   `unexpected status 409 Conflict: {"kind": "Error"`,
+			ExpectedTruncated: true,
+		},
+	} {
+		tc := tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			client := NewClient(WithCaptureErrorBodies{MaxBodySize: tc.MaxBodySize})
+			defer client.Close()
+
+			_, err := client.Get(context.Background(), srv.URL)
+			require.Error(t, err)
+
+			var httpErr *HTTPError
+			require.ErrorAs(t, err, &httpErr)
+
+			assert.True(t, strings.HasSuffix(err.Error(), tc.ExpectedMessage), err.Error())
+			assert.Equal(t, tc.ExpectedTruncated, httpErr.Truncated)
+
+			require.NotNil(t, httpErr.Response)
+
+			body, err := io.ReadAll(httpErr.Response.Body)
+			require.NoError(t, err)
+			require.NoError(t, httpErr.Response.Body.Close())
+
+			assert.Equal(t, message, string(body))
+		})
+	}
+}
+
+func TestHTTPErrorBodySnippet(t *testing.T) {
+	t.Parallel()
+
+	httpErr := &HTTPError{
+		StatusCode:  http.StatusBadRequest,
+		Body:        []byte(strings.Repeat("é", maxErrorMessageBodySize)),
+		includeBody: true,
+	}
+
+	snippet := httpErr.bodySnippet()
+	assert.True(t, strings.HasSuffix(snippet, "..."))
+	assert.True(t, utf8.ValidString(snippet))
+
+	httpErr.Body = []byte{0xff, 0xfe}
+	assert.Equal(t, "unexpected status 400 Bad Request", httpErr.Error())
+}
@@ -0,0 +1,37 @@
+package client
+
+import (
+	"crypto/rand"
+	"fmt"
+)
+
+// IdempotencyKeyHeader is the header which identifies repeated
+// attempts of a non-idempotent request to the server.
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// WithIdempotencyKey configures a RetryWrapper instance to attach
+// a randomly generated Idempotency-Key header to POST and PATCH
+// requests which do not already carry one. The same key is sent
+// with every attempt so that servers supporting idempotency keys
+// can deduplicate them, and such requests are considered
+// idempotent by the DefaultRetryPolicy allowing them to be
+// retried on 500, 502 and 504 responses.
+type WithIdempotencyKey struct{}
+
+func (WithIdempotencyKey) ConfigureRetryWrapper(c *RetryWrapperConfig) {
+	c.idempotencyKeys = true
+}
+
+// newUUID returns a random (version 4) UUID.
+func newUUID() (string, error) {
+	var uuid [16]byte
+
+	if _, err := rand.Read(uuid[:]); err != nil {
+		return "", err
+	}
+
+	uuid[6] = (uuid[6] & 0x0f) | 0x40
+	uuid[8] = (uuid[8] & 0x3f) | 0x80
+
+	return fmt.Sprintf("%x-%x-%x-%x-%x", uuid[0:4], uuid[4:6], uuid[6:8], uuid[8:10], uuid[10:16]), nil
+}
@@ -0,0 +1,105 @@
+package client
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"regexp"
+	"testing"
+
+	"github.com/mt-sre/client/internal/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithIdempotencyKey(t *testing.T) {
+	t.Parallel()
+
+	uuidPattern := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+
+	for name, tc := range map[string]struct {
+		Method        string
+		Options       []RetryWrapperOption
+		ExistingKey   string
+		ExpectedCalls int
+		ExpectKey     bool
+	}{
+		"POST without idempotency keys": {
+			Method:        http.MethodPost,
+			ExpectedCalls: 1,
+		},
+		"POST with idempotency keys": {
+			Method:        http.MethodPost,
+			Options:       []RetryWrapperOption{WithIdempotencyKey{}},
+			ExpectedCalls: 3,
+			ExpectKey:     true,
+		},
+		"PATCH with existing key": {
+			Method:        http.MethodPatch,
+			ExistingKey:   "existing",
+			ExpectedCalls: 3,
+			ExpectKey:     true,
+		},
+		"GET is not assigned a key": {
+			Method:        http.MethodGet,
+			Options:       []RetryWrapperOption{WithIdempotencyKey{}},
+			ExpectedCalls: 3,
+		},
+	} {
+		tc := tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var keys []string
+
+			var mrt testutils.MockRoundTripper
+			mrt.
+				On("RoundTrip", mock.Anything).
+				Run(func(args mock.Arguments) {
+					keys = append(keys, args.Get(0).(*http.Request).Header.Get(IdempotencyKeyHeader))
+				}).
+				Return(&http.Response{
+					StatusCode: http.StatusBadGateway,
+					Body:       io.NopCloser(bytes.NewBuffer(nil)),
+				}, nil).
+				Times(tc.ExpectedCalls)
+
+			opts := append([]RetryWrapperOption{
+				WithBackoffGenerator(NoBackoffGenerator()),
+				WithMaxRetries(2),
+			}, tc.Options...)
+
+			req := testutils.MockRequest(t, tc.Method, bytes.NewBufferString("payload"))
+			if tc.ExistingKey != "" {
+				req.Header.Set(IdempotencyKeyHeader, tc.ExistingKey)
+			}
+
+			res, err := NewRetryWrapper(opts...).Wrap(&mrt).RoundTrip(req)
+			require.NoError(t, err)
+			require.NoError(t, res.Body.Close())
+
+			mrt.AssertExpectations(t)
+
+			if !tc.ExpectKey {
+				for _, key := range keys {
+					assert.Empty(t, key)
+				}
+
+				return
+			}
+
+			for _, key := range keys {
+				assert.Equal(t, keys[0], key, "all attempts must share the key")
+			}
+
+			if tc.ExistingKey != "" {
+				assert.Equal(t, tc.ExistingKey, keys[0])
+			} else {
+				assert.Regexp(t, uuidPattern, keys[0])
+				assert.Empty(t, req.Header.Get(IdempotencyKeyHeader), "original request must not be modified")
+			}
+		})
+	}
+}
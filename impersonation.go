@@ -0,0 +1,193 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/go-logr/logr"
+)
+
+// Identity is the user a request is performed on behalf of.
+type Identity struct {
+	User   string
+	UID    string
+	Groups []string
+	// Extra holds additional attributes of the user
+	// e.g. scopes.
+	Extra map[string][]string
+}
+
+// WithImpersonation performs a single request on behalf of the given
+// Identity. The Identity is only sent if the Client is configured
+// with an ImpersonationWrapper.
+type WithImpersonation Identity
+
+func (i WithImpersonation) ConfigureRequest(c *RequestConfig) {
+	id := Identity(i)
+
+	c.Impersonate = &id
+}
+
+type impersonationKey struct{}
+
+// ContextWithImpersonation returns a copy of ctx which carries the
+// given Identity. Requests made with the returned context through an
+// ImpersonationWrapper are performed on behalf of the Identity. This
+// allows impersonation when using a plain http.Client.
+func ContextWithImpersonation(ctx context.Context, id Identity) context.Context {
+	return context.WithValue(ctx, impersonationKey{}, id)
+}
+
+// NewImpersonationWrapper returns a TransportWrapper which sets
+// impersonation headers for requests carrying an Identity provided
+// through WithImpersonation or ContextWithImpersonation. By default
+// the Kubernetes/OpenShift headers are used. Every impersonated
+// request is recorded by the configured logger for auditing.
+func NewImpersonationWrapper(opts ...ImpersonationOption) *ImpersonationWrapper {
+	var cfg ImpersonationConfig
+
+	cfg.Option(opts...)
+	cfg.Default()
+
+	return &ImpersonationWrapper{
+		cfg: cfg,
+	}
+}
+
+type ImpersonationWrapper struct {
+	cfg ImpersonationConfig
+	rt  http.RoundTripper
+}
+
+func (w *ImpersonationWrapper) Wrap(rt http.RoundTripper) http.RoundTripper {
+	w.rt = rt
+
+	return w
+}
+
+func (w *ImpersonationWrapper) RoundTrip(req *http.Request) (*http.Response, error) {
+	id, ok := req.Context().Value(impersonationKey{}).(Identity)
+	if !ok || isCrossHostRedirect(req) {
+		return w.rt.RoundTrip(req)
+	}
+
+	out := req.Clone(req.Context())
+	w.cfg.Headers.apply(out.Header, id)
+
+	res, err := w.rt.RoundTrip(out)
+
+	kv := []any{
+		"method", req.Method,
+		"url", redactURL(req.Context(), req.URL),
+		"user", id.User,
+		"groups", id.Groups,
+	}
+
+	log := contextLogger(w.cfg.Logger, req.Context())
+
+	if err != nil {
+		log.Info("impersonated request failed", append(kv, "error", err.Error())...)
+	} else {
+		log.Info("impersonated request", append(kv, "status", res.StatusCode)...)
+	}
+
+	return res, err
+}
+
+// ImpersonationHeaders names the headers used to convey an Identity.
+type ImpersonationHeaders struct {
+	User  string
+	UID   string
+	Group string
+	// ExtraPrefix is followed by the
+	// name of each extra attribute.
+	ExtraPrefix string
+}
+
+// KubernetesImpersonationHeaders are the headers understood
+// by Kubernetes and OpenShift API servers.
+var KubernetesImpersonationHeaders = ImpersonationHeaders{
+	User:        "Impersonate-User",
+	UID:         "Impersonate-Uid",
+	Group:       "Impersonate-Group",
+	ExtraPrefix: "Impersonate-Extra-",
+}
+
+func (h ImpersonationHeaders) apply(header http.Header, id Identity) {
+	if h.ExtraPrefix != "" {
+		prefix := http.CanonicalHeaderKey(h.ExtraPrefix)
+
+		for key := range header {
+			if strings.HasPrefix(key, prefix) {
+				delete(header, key)
+			}
+		}
+	}
+
+	set := func(key string, vals ...string) {
+		if key == "" {
+			return
+		}
+
+		header.Del(key)
+
+		for _, val := range vals {
+			if val != "" {
+				header.Add(key, val)
+			}
+		}
+	}
+
+	set(h.User, id.User)
+	set(h.UID, id.UID)
+	set(h.Group, id.Groups...)
+
+	if h.ExtraPrefix == "" {
+		return
+	}
+
+	for key, vals := range id.Extra {
+		set(h.ExtraPrefix+url.PathEscape(key), vals...)
+	}
+}
+
+type ImpersonationConfig struct {
+	Logger  logr.Logger
+	Headers ImpersonationHeaders
+}
+
+func (c *ImpersonationConfig) Option(opts ...ImpersonationOption) {
+	for _, opt := range opts {
+		opt.ConfigureImpersonation(c)
+	}
+}
+
+func (c *ImpersonationConfig) Default() {
+	if c.Logger.GetSink() == nil {
+		c.Logger = logr.Discard()
+	}
+
+	if c.Headers == (ImpersonationHeaders{}) {
+		c.Headers = KubernetesImpersonationHeaders
+	}
+}
+
+type ImpersonationOption interface {
+	ConfigureImpersonation(*ImpersonationConfig)
+}
+
+func (l WithLogger) ConfigureImpersonation(c *ImpersonationConfig) {
+	c.Logger = l.Logger
+}
+
+// WithImpersonationHeaders configures the headers an
+// ImpersonationWrapper uses to convey an Identity e.g. for
+// APIs which do not follow the Kubernetes conventions.
+// Empty header names are not sent.
+type WithImpersonationHeaders ImpersonationHeaders
+
+func (h WithImpersonationHeaders) ConfigureImpersonation(c *ImpersonationConfig) {
+	c.Headers = ImpersonationHeaders(h)
+}
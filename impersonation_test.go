@@ -0,0 +1,111 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/go-logr/logr/funcr"
+	"github.com/mt-sre/client/internal/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImpersonationWrapperInterfaces(t *testing.T) {
+	t.Parallel()
+
+	require.Implements(t, new(http.RoundTripper), new(ImpersonationWrapper))
+
+	require.Implements(t, new(TransportWrapper), new(ImpersonationWrapper))
+}
+
+func TestImpersonationWrapper(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		Options        []ImpersonationOption
+		Identity       *Identity
+		ExpectedHeader http.Header
+		ExpectedLogs   int
+	}{
+		"no identity": {
+			ExpectedHeader: http.Header{"Impersonate-Extra-Stale": []string{"x"}},
+		},
+		"kubernetes headers": {
+			Identity: &Identity{
+				User:   "jane",
+				Groups: []string{"system:authenticated", "sre"},
+				Extra:  map[string][]string{"scopes.example.com/a b": {"view"}},
+			},
+			ExpectedHeader: http.Header{
+				"Impersonate-User":                             []string{"jane"},
+				"Impersonate-Group":                            []string{"system:authenticated", "sre"},
+				"Impersonate-Extra-Scopes.example.com%2fa%20b": []string{"view"},
+			},
+			ExpectedLogs: 1,
+		},
+		"custom headers": {
+			Options: []ImpersonationOption{
+				WithImpersonationHeaders{User: "X-Impersonate-User"},
+			},
+			Identity: &Identity{User: "jane", Groups: []string{"sre"}},
+			ExpectedHeader: http.Header{
+				"Impersonate-Extra-Stale": []string{"x"},
+				"X-Impersonate-User":      []string{"jane"},
+			},
+			ExpectedLogs: 1,
+		},
+	} {
+		tc := tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var logs []string
+
+			logger := funcr.New(func(prefix, args string) {
+				logs = append(logs, args)
+			}, funcr.Options{})
+
+			var mrt testutils.MockRoundTripper
+			mrt.
+				On("RoundTrip", mock.Anything).
+				Return(&http.Response{
+					StatusCode: http.StatusOK,
+					Body:       io.NopCloser(bytes.NewBuffer(nil)),
+				}, nil).
+				Once()
+
+			client := NewClient(
+				WithTransport{RoundTripper: &mrt},
+				WithWrapper{TransportWrapper: NewImpersonationWrapper(
+					append(tc.Options, WithLogger{Logger: logger})...,
+				)},
+			)
+
+			opts := []RequestOption{
+				WithRequestHeaders{"Impersonate-Extra-Stale": []string{"x"}},
+			}
+
+			if tc.Identity != nil {
+				opts = append(opts, WithImpersonation(*tc.Identity))
+			}
+
+			res, err := client.Get(context.Background(), "http://example.com", opts...)
+			require.NoError(t, err)
+			require.NoError(t, res.Body.Close())
+
+			sent := mrt.Calls[0].Arguments.Get(0).(*http.Request)
+			assert.Equal(t, tc.ExpectedHeader, sent.Header)
+
+			require.Len(t, logs, tc.ExpectedLogs)
+
+			if tc.ExpectedLogs > 0 {
+				assert.Contains(t, logs[0], `"user"="jane"`)
+			}
+		})
+	}
+}
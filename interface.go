@@ -0,0 +1,30 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+// ClientInterface is the set of request methods implemented by Client.
+// Code which accepts a ClientInterface rather than a *Client can be
+// tested using the mock and fake provided by the clientmock package.
+type ClientInterface interface {
+	Get(ctx context.Context, url string, opts ...RequestOption) (*http.Response, error)
+	Head(ctx context.Context, url string, opts ...RequestOption) (*http.Response, error)
+	Post(ctx context.Context, url string, body io.Reader, opts ...RequestOption) (*http.Response, error)
+	Put(ctx context.Context, url string, body io.Reader, opts ...RequestOption) (*http.Response, error)
+	Patch(ctx context.Context, url string, body io.Reader, opts ...RequestOption) (*http.Response, error)
+	Delete(ctx context.Context, url string, opts ...RequestOption) (*http.Response, error)
+	Connect(ctx context.Context, url string, body io.Reader, opts ...RequestOption) (*http.Response, error)
+	Options(ctx context.Context, url string, opts ...RequestOption) (*http.Response, error)
+	Trace(ctx context.Context, url string, opts ...RequestOption) (*http.Response, error)
+	PostForm(ctx context.Context, url string, data url.Values, opts ...RequestOption) (*http.Response, error)
+	PostMultipart(ctx context.Context, url string, fields url.Values, files []MultipartFile, opts ...RequestOption) (*http.Response, error)
+	Download(ctx context.Context, url string, w io.Writer, opts ...DownloadOption) error
+	DownloadFile(ctx context.Context, url, path string, opts ...DownloadOption) error
+	Do(req *http.Request) (*http.Response, error)
+}
+
+var _ ClientInterface = (*Client)(nil)
@@ -0,0 +1,135 @@
+package testutils
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// retryAfterHandler responds with the status code given by the "code"
+// query parameter, 429 by default, and a Retry-After header set to
+// the number of seconds given by the "seconds" query parameter. If
+// the "format" query parameter is "date" the header is expressed as
+// an HTTP date instead.
+func retryAfterHandler(w http.ResponseWriter, req *http.Request) {
+	if err := req.ParseForm(); err != nil {
+		http.Error(w, fmt.Sprintf("failed to parse form: %v", err), http.StatusBadRequest)
+
+		return
+	}
+
+	code := http.StatusTooManyRequests
+
+	if raw := req.FormValue("code"); raw != "" {
+		var err error
+
+		if code, err = strconv.Atoi(raw); err != nil {
+			http.Error(w, fmt.Sprintf("failed to parse code: %v", err), http.StatusBadRequest)
+
+			return
+		}
+	}
+
+	seconds, err := strconv.Atoi(req.FormValue("seconds"))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to parse seconds: %v", err), http.StatusBadRequest)
+
+		return
+	}
+
+	if req.FormValue("format") == "date" {
+		w.Header().Set("Retry-After", time.Now().Add(time.Duration(seconds)*time.Second).UTC().Format(http.TimeFormat))
+	} else {
+		w.Header().Set("Retry-After", strconv.Itoa(seconds))
+	}
+
+	w.WriteHeader(code)
+}
+
+// RateLimitServer is a test server which permits Limit requests per
+// Window. Every response carries RateLimit-Limit, RateLimit-Remaining
+// and RateLimit-Reset headers. Once the limit is reached requests are
+// rejected with 429 and a Retry-After header. Requests received while
+// rejected are counted as violations so that tests can assert that a
+// client honors the advertised headers.
+type RateLimitServer struct {
+	*httptest.Server
+
+	limit  int
+	window time.Duration
+
+	mu         sync.Mutex
+	start      time.Time
+	count      int
+	requests   int
+	violations int
+}
+
+// NewRateLimitServer starts a RateLimitServer permitting
+// limit requests per window.
+func NewRateLimitServer(limit int, window time.Duration) *RateLimitServer {
+	srv := &RateLimitServer{
+		limit:  limit,
+		window: window,
+	}
+
+	srv.Server = httptest.NewServer(http.HandlerFunc(srv.handle))
+
+	return srv
+}
+
+func (s *RateLimitServer) handle(w http.ResponseWriter, _ *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+
+	if now.Sub(s.start) >= s.window {
+		s.start = now
+		s.count = 0
+	}
+
+	s.requests++
+	s.count++
+
+	reset := s.start.Add(s.window).Sub(now)
+	resetSeconds := strconv.Itoa(int(math.Ceil(reset.Seconds())))
+
+	w.Header().Set("RateLimit-Limit", strconv.Itoa(s.limit))
+	w.Header().Set("RateLimit-Remaining", strconv.Itoa(max(s.limit-s.count, 0)))
+	w.Header().Set("RateLimit-Reset", resetSeconds)
+
+	if s.count > s.limit {
+		if s.count > s.limit+1 {
+			s.violations++
+		}
+
+		w.Header().Set("Retry-After", resetSeconds)
+		w.WriteHeader(http.StatusTooManyRequests)
+
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// Requests returns the total number of requests received.
+func (s *RateLimitServer) Requests() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.requests
+}
+
+// Violations returns the number of requests received after a 429
+// response had been sent and before the advertised reset.
+func (s *RateLimitServer) Violations() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.violations
+}
@@ -0,0 +1,64 @@
+package testutils
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRetryAfterHandler(t *testing.T) {
+	t.Parallel()
+
+	srv := ServerFixture()
+	defer srv.Close()
+
+	res, err := srv.Client().Get(srv.URL + "/retry-after?code=503&seconds=2")
+	require.NoError(t, err)
+	res.Body.Close()
+
+	assert.Equal(t, http.StatusServiceUnavailable, res.StatusCode)
+	assert.Equal(t, "2", res.Header.Get("Retry-After"))
+
+	res, err = srv.Client().Get(srv.URL + "/retry-after?seconds=2&format=date")
+	require.NoError(t, err)
+	res.Body.Close()
+
+	assert.Equal(t, http.StatusTooManyRequests, res.StatusCode)
+
+	_, err = http.ParseTime(res.Header.Get("Retry-After"))
+	assert.NoError(t, err)
+}
+
+func TestRateLimitServer(t *testing.T) {
+	t.Parallel()
+
+	srv := NewRateLimitServer(2, time.Hour)
+	defer srv.Close()
+
+	codes := make([]int, 0, 4)
+
+	for i := 0; i < 4; i++ {
+		res, err := srv.Client().Get(srv.URL)
+		require.NoError(t, err)
+		res.Body.Close()
+
+		codes = append(codes, res.StatusCode)
+
+		if res.StatusCode == http.StatusTooManyRequests {
+			assert.Equal(t, "3600", res.Header.Get("Retry-After"))
+			assert.Equal(t, "0", res.Header.Get("RateLimit-Remaining"))
+		}
+	}
+
+	assert.Equal(t, []int{
+		http.StatusOK,
+		http.StatusOK,
+		http.StatusTooManyRequests,
+		http.StatusTooManyRequests,
+	}, codes)
+	assert.Equal(t, 4, srv.Requests())
+	assert.Equal(t, 1, srv.Violations())
+}
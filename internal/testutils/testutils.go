@@ -1,20 +1,32 @@
 package testutils
 
 import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
 	"fmt"
 	"io"
+	"math/big"
 	"net/http"
 	"net/http/httptest"
 	"strconv"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 )
 
+// ServerFixture returns a minimal test server for tests of the client
+// package which cannot import clienttest without an import cycle.
+// clienttest.NewServer provides a superset of its endpoints.
 func ServerFixture() *httptest.Server {
 	handler := http.NewServeMux()
 	handler.HandleFunc("/status", statusHandler)
+	handler.HandleFunc("/retry-after", retryAfterHandler)
 
 	return httptest.NewServer(handler)
 }
@@ -54,3 +66,32 @@ func (m *MockRoundTripper) RoundTrip(req *http.Request) (*http.Response, error)
 
 	return args.Get(0).(*http.Response), args.Error(1)
 }
+
+// GenerateCertificate returns a PEM encoded self-signed certificate
+// and private key suitable for use as a TLS client certificate.
+func GenerateCertificate(t *testing.T, serial int64) (certPEM, keyPEM []byte) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: "client"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+
+	certPEM = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM = pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER})
+
+	return certPEM, keyPEM
+}
@@ -0,0 +1,192 @@
+package client
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+// defaultJWTTTL is the lifetime of minted tokens and the
+// maximum accepted by GitHub for App authentication.
+const defaultJWTTTL = 10 * time.Minute
+
+// jwtClockSkew backdates the issued at time of minted tokens
+// to tolerate clocks of the receiving server running behind.
+const jwtClockSkew = time.Minute
+
+var errUnsupportedJWTKey = errors.New("unsupported JWT signing key")
+
+// WithJWTBearer configures an OAUTHWrapper to authenticate using
+// short-lived JWTs signed with Key as bearer tokens, as required
+// for GitHub App authentication. Tokens are signed with RS256 for
+// RSA keys and ES256 for P-256 ECDSA keys and are re-minted once
+// 80% of their lifetime has elapsed.
+type WithJWTBearer struct {
+	// Key is a *rsa.PrivateKey or P-256 *ecdsa.PrivateKey.
+	Key crypto.Signer
+	// KeyID is sent as the "kid" header if set.
+	KeyID    string
+	Issuer   string
+	Subject  string
+	Audience string
+	// TTL is the lifetime of each token.
+	// Defaults to 10 minutes.
+	TTL time.Duration
+}
+
+func (jb WithJWTBearer) ConfigureOAUTH(c *OAUTHConfig) {
+	ttl := jb.TTL
+	if ttl <= 0 {
+		ttl = defaultJWTTTL
+	}
+
+	src := &jwtSource{
+		cfg:   jb,
+		ttl:   ttl,
+		clock: RealClock{},
+	}
+
+	c.source = src
+	c.defaultSkew = ttl / 5
+}
+
+// ParsePrivateKeyPEM parses a PEM encoded PKCS #1, PKCS #8 or
+// SEC 1 private key such as those issued for GitHub Apps.
+func ParsePrivateKeyPEM(data []byte) (crypto.Signer, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+
+	if key, err := x509.ParseECPrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("parsing private key: %w", err)
+	}
+
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("%w: %T", errUnsupportedJWTKey, key)
+	}
+
+	return signer, nil
+}
+
+// jwtSource mints a new signed JWT for every call to Token.
+type jwtSource struct {
+	cfg   WithJWTBearer
+	ttl   time.Duration
+	clock Clock
+}
+
+func (s *jwtSource) useClock(clock Clock) {
+	s.clock = clock
+}
+
+type jwtClaims struct {
+	Issuer    string `json:"iss,omitempty"`
+	Subject   string `json:"sub,omitempty"`
+	Audience  string `json:"aud,omitempty"`
+	IssuedAt  int64  `json:"iat"`
+	ExpiresAt int64  `json:"exp"`
+}
+
+func (s *jwtSource) Token() (*oauth2.Token, error) {
+	alg, err := jwtAlgorithm(s.cfg.Key)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.clock.Now()
+	expiry := now.Add(s.ttl)
+
+	header, err := json.Marshal(struct {
+		Algorithm string `json:"alg"`
+		Type      string `json:"typ"`
+		KeyID     string `json:"kid,omitempty"`
+	}{Algorithm: alg, Type: "JWT", KeyID: s.cfg.KeyID})
+	if err != nil {
+		return nil, fmt.Errorf("encoding JWT header: %w", err)
+	}
+
+	claims, err := json.Marshal(jwtClaims{
+		Issuer:    s.cfg.Issuer,
+		Subject:   s.cfg.Subject,
+		Audience:  s.cfg.Audience,
+		IssuedAt:  now.Add(-jwtClockSkew).Unix(),
+		ExpiresAt: expiry.Unix(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("encoding JWT claims: %w", err)
+	}
+
+	signingInput := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+
+	sig, err := signJWT(s.cfg.Key, signingInput)
+	if err != nil {
+		return nil, fmt.Errorf("signing JWT: %w", err)
+	}
+
+	return &oauth2.Token{
+		AccessToken: signingInput + "." + base64.RawURLEncoding.EncodeToString(sig),
+		TokenType:   "Bearer",
+		Expiry:      expiry,
+	}, nil
+}
+
+func jwtAlgorithm(key crypto.Signer) (string, error) {
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		return "RS256", nil
+	case *ecdsa.PrivateKey:
+		if k.Curve != elliptic.P256() {
+			return "", fmt.Errorf("%w: ECDSA curve %s", errUnsupportedJWTKey, k.Curve.Params().Name)
+		}
+
+		return "ES256", nil
+	default:
+		return "", fmt.Errorf("%w: %T", errUnsupportedJWTKey, key)
+	}
+}
+
+func signJWT(key crypto.Signer, signingInput string) ([]byte, error) {
+	digest := sha256.Sum256([]byte(signingInput))
+
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		return rsa.SignPKCS1v15(rand.Reader, k, crypto.SHA256, digest[:])
+	case *ecdsa.PrivateKey:
+		r, s, err := ecdsa.Sign(rand.Reader, k, digest[:])
+		if err != nil {
+			return nil, err
+		}
+
+		// JWS uses the fixed size concatenation of r and s
+		sig := make([]byte, 64)
+		r.FillBytes(sig[:32])
+		s.FillBytes(sig[32:])
+
+		return sig, nil
+	default:
+		return nil, fmt.Errorf("%w: %T", errUnsupportedJWTKey, key)
+	}
+}
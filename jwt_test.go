@@ -0,0 +1,228 @@
+package client
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mt-sre/client/internal/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJWTSource(t *testing.T) {
+	t.Parallel()
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	now := time.Unix(1700000000, 0)
+
+	for name, tc := range map[string]struct {
+		Key       crypto.Signer
+		Algorithm string
+		Verify    func(t *testing.T, digest, sig []byte)
+	}{
+		"RS256": {
+			Key:       rsaKey,
+			Algorithm: "RS256",
+			Verify: func(t *testing.T, digest, sig []byte) {
+				t.Helper()
+
+				assert.NoError(t, rsa.VerifyPKCS1v15(&rsaKey.PublicKey, crypto.SHA256, digest, sig))
+			},
+		},
+		"ES256": {
+			Key:       ecKey,
+			Algorithm: "ES256",
+			Verify: func(t *testing.T, digest, sig []byte) {
+				t.Helper()
+
+				require.Len(t, sig, 64)
+
+				r := new(big.Int).SetBytes(sig[:32])
+				s := new(big.Int).SetBytes(sig[32:])
+
+				assert.True(t, ecdsa.Verify(&ecKey.PublicKey, digest, r, s))
+			},
+		},
+	} {
+		tc := tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			src := &jwtSource{
+				cfg: WithJWTBearer{
+					Key:      tc.Key,
+					KeyID:    "key-1",
+					Issuer:   "123456",
+					Audience: "https://api.example.com",
+				},
+				ttl:   defaultJWTTTL,
+				clock: funcClock{now: func() time.Time { return now }},
+			}
+
+			tok, err := src.Token()
+			require.NoError(t, err)
+
+			assert.Equal(t, "Bearer", tok.TokenType)
+			assert.Equal(t, now.Add(defaultJWTTTL), tok.Expiry)
+
+			parts := strings.Split(tok.AccessToken, ".")
+			require.Len(t, parts, 3)
+
+			var header map[string]string
+
+			decodeJWTPart(t, parts[0], &header)
+
+			assert.Equal(t, map[string]string{"alg": tc.Algorithm, "typ": "JWT", "kid": "key-1"}, header)
+
+			var claims jwtClaims
+
+			decodeJWTPart(t, parts[1], &claims)
+
+			assert.Equal(t, jwtClaims{
+				Issuer:    "123456",
+				Audience:  "https://api.example.com",
+				IssuedAt:  now.Add(-jwtClockSkew).Unix(),
+				ExpiresAt: now.Add(defaultJWTTTL).Unix(),
+			}, claims)
+
+			sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+			require.NoError(t, err)
+
+			digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+
+			tc.Verify(t, digest[:], sig)
+		})
+	}
+}
+
+func TestJWTSourceUnsupportedKey(t *testing.T) {
+	t.Parallel()
+
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	p384Key, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	require.NoError(t, err)
+
+	for name, key := range map[string]crypto.Signer{
+		"ed25519":    edKey,
+		"ecdsa p384": p384Key,
+	} {
+		key := key
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			src := &jwtSource{cfg: WithJWTBearer{Key: key}, ttl: defaultJWTTTL, clock: RealClock{}}
+
+			_, err := src.Token()
+			assert.ErrorIs(t, err, errUnsupportedJWTKey)
+		})
+	}
+}
+
+func TestWithJWTBearer(t *testing.T) {
+	t.Parallel()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	var tokens []string
+
+	mrt := &testutils.MockRoundTripper{}
+	mrt.
+		On("RoundTrip", mock.Anything).
+		Run(func(args mock.Arguments) {
+			tokens = append(tokens, args.Get(0).(*http.Request).Header.Get("Authorization"))
+		}).
+		Return(&http.Response{StatusCode: http.StatusOK}, nil)
+
+	rt := NewOAUTHWrapper(WithJWTBearer{Key: key, Issuer: "123456"}).Wrap(mrt)
+
+	for i := 0; i < 2; i++ {
+		_, err := rt.RoundTrip(testutils.MockRequest(t, http.MethodGet, nil))
+		require.NoError(t, err)
+	}
+
+	require.Len(t, tokens, 2)
+	assert.True(t, strings.HasPrefix(tokens[0], "Bearer "))
+	assert.Equal(t, tokens[0], tokens[1], "token must be reused until it nears expiry")
+}
+
+func TestParsePrivateKeyPEM(t *testing.T) {
+	t.Parallel()
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	ecDER, err := x509.MarshalECPrivateKey(ecKey)
+	require.NoError(t, err)
+
+	pkcs8DER, err := x509.MarshalPKCS8PrivateKey(rsaKey)
+	require.NoError(t, err)
+
+	for name, tc := range map[string]struct {
+		Block    *pem.Block
+		Expected crypto.Signer
+	}{
+		"pkcs1": {
+			Block:    &pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(rsaKey)},
+			Expected: rsaKey,
+		},
+		"sec1": {
+			Block:    &pem.Block{Type: "EC PRIVATE KEY", Bytes: ecDER},
+			Expected: ecKey,
+		},
+		"pkcs8": {
+			Block:    &pem.Block{Type: "PRIVATE KEY", Bytes: pkcs8DER},
+			Expected: rsaKey,
+		},
+	} {
+		tc := tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			key, err := ParsePrivateKeyPEM(pem.EncodeToMemory(tc.Block))
+			require.NoError(t, err)
+
+			assert.True(t, tc.Expected.Public().(interface{ Equal(crypto.PublicKey) bool }).Equal(key.Public()))
+		})
+	}
+
+	_, err = ParsePrivateKeyPEM([]byte("not a key"))
+	assert.Error(t, err)
+}
+
+func decodeJWTPart(t *testing.T, part string, v any) {
+	t.Helper()
+
+	data, err := base64.RawURLEncoding.DecodeString(part)
+	require.NoError(t, err)
+
+	require.NoError(t, json.Unmarshal(data, v))
+}
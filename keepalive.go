@@ -0,0 +1,171 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+)
+
+const (
+	defaultProbeInterval = 30 * time.Second
+	defaultProbeTimeout  = 5 * time.Second
+)
+
+// WithTCPKeepAlive configures a Client instance to send TCP keep-alive
+// probes on idle connections at the given interval so that connections
+// silently dropped by middleboxes are detected. A negative value
+// disables keep-alive probes. The default interval is 30 seconds.
+type WithTCPKeepAlive time.Duration
+
+func (k WithTCPKeepAlive) ConfigureClient(c *ClientConfig) {
+	c.TCPKeepAlive = time.Duration(k)
+}
+
+// WithConnectionProbing configures a Client instance to send HEAD
+// requests to each of URLs when the Client is created and every
+// Interval, 30 seconds by default, thereafter. This pre-warms the
+// connection pool and replaces pooled connections which have gone bad
+// while idle so that the first request after an idle period does not
+// pay for establishing a connection. Probes bypass all wrappers, time
+// out after Timeout, 5 seconds by default, and failures are logged to
+// Logger with successful probes being logged at V(1). Probing runs in
+// the background until the Client is closed.
+type WithConnectionProbing struct {
+	URLs     []string
+	Interval time.Duration
+	Timeout  time.Duration
+	Logger   logr.Logger
+}
+
+func (cp WithConnectionProbing) ConfigureClient(c *ClientConfig) {
+	c.probing = &cp
+}
+
+type connectionProber struct {
+	urls     []*url.URL
+	interval time.Duration
+	timeout  time.Duration
+	log      logr.Logger
+	rt       http.RoundTripper
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	done   chan struct{}
+	once   sync.Once
+}
+
+func newConnectionProber(cp WithConnectionProbing, rt http.RoundTripper) *connectionProber {
+	p := &connectionProber{
+		interval: cp.Interval,
+		timeout:  cp.Timeout,
+		log:      cp.Logger,
+		rt:       rt,
+		done:     make(chan struct{}),
+	}
+
+	if p.interval <= 0 {
+		p.interval = defaultProbeInterval
+	}
+
+	if p.timeout <= 0 {
+		p.timeout = defaultProbeTimeout
+	}
+
+	if p.log.GetSink() == nil {
+		p.log = logr.Discard()
+	}
+
+	for _, raw := range cp.URLs {
+		u, err := url.Parse(raw)
+		if err != nil {
+			p.log.Error(err, "ignoring invalid probe URL")
+
+			continue
+		}
+
+		p.urls = append(p.urls, u)
+	}
+
+	p.ctx, p.cancel = context.WithCancel(context.Background())
+
+	return p
+}
+
+func (p *connectionProber) run() {
+	defer close(p.done)
+
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		p.probeAll()
+
+		select {
+		case <-ticker.C:
+		case <-p.ctx.Done():
+			return
+		}
+	}
+}
+
+func (p *connectionProber) probeAll() {
+	var wg sync.WaitGroup
+
+	for _, u := range p.urls {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			err := p.probe(u)
+
+			switch {
+			case err == nil:
+				p.log.V(1).Info("connection probe succeeded",
+					"url", redactURL(p.ctx, u),
+				)
+			case p.ctx.Err() == nil:
+				p.log.Info("connection probe failed",
+					"url", redactURL(p.ctx, u),
+					"error", err,
+				)
+			}
+		}()
+	}
+
+	wg.Wait()
+}
+
+func (p *connectionProber) probe(u *url.URL) error {
+	ctx, cancel := context.WithTimeout(p.ctx, p.timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, u.String(), nil)
+	if err != nil {
+		return err
+	}
+
+	res, err := p.rt.RoundTrip(req)
+	if err != nil {
+		return err
+	}
+
+	// drain the body so that the connection is returned to the pool
+	io.Copy(io.Discard, res.Body)
+
+	return res.Body.Close()
+}
+
+// Close stops probing and waits for in-flight probes to finish.
+func (p *connectionProber) Close() {
+	p.once.Do(func() {
+		p.cancel()
+
+		<-p.done
+	})
+}
@@ -0,0 +1,106 @@
+package client
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr/funcr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithTCPKeepAlive(t *testing.T) {
+	t.Parallel()
+
+	var cfg ClientConfig
+
+	cfg.Option(WithTCPKeepAlive(-1))
+
+	assert.True(t, cfg.hasTransportSettings())
+	assert.Equal(t, time.Duration(-1), cfg.TCPKeepAlive)
+}
+
+func TestWithConnectionProbing(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		Interval time.Duration
+		Test     func(t *testing.T, c *Client, url string, conns, probes *atomic.Int32, succeeded <-chan struct{})
+	}{
+		"pre-warm": {
+			Interval: time.Hour,
+			Test: func(t *testing.T, c *Client, url string, conns, probes *atomic.Int32, succeeded <-chan struct{}) {
+				<-succeeded
+
+				res, err := c.Get(context.Background(), url)
+				require.NoError(t, err)
+				require.NoError(t, res.Body.Close())
+
+				assert.EqualValues(t, 1, conns.Load(), "requests must reuse the probed connection")
+			},
+		},
+		"periodic": {
+			Interval: 10 * time.Millisecond,
+			Test: func(t *testing.T, c *Client, _ string, _, probes *atomic.Int32, _ <-chan struct{}) {
+				require.Eventually(t, func() bool { return probes.Load() >= 3 }, 5*time.Second, time.Millisecond)
+
+				c.Close()
+
+				stopped := probes.Load()
+
+				time.Sleep(50 * time.Millisecond)
+				assert.Equal(t, stopped, probes.Load(), "probing must stop once the client is closed")
+			},
+		},
+	} {
+		tc := tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var conns, probes atomic.Int32
+
+			srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method == http.MethodHead {
+					probes.Add(1)
+				}
+			}))
+			srv.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+				if state == http.StateNew {
+					conns.Add(1)
+				}
+			}
+			srv.Start()
+			defer srv.Close()
+
+			succeeded := make(chan struct{}, 1)
+
+			logger := funcr.New(func(_, args string) {
+				if strings.Contains(args, "connection probe succeeded") {
+					select {
+					case succeeded <- struct{}{}:
+					default:
+					}
+				}
+			}, funcr.Options{Verbosity: 1})
+
+			c := NewClient(
+				WithTCPKeepAlive(time.Minute),
+				WithConnectionProbing{
+					URLs:     []string{srv.URL},
+					Interval: tc.Interval,
+					Logger:   logger,
+				},
+			)
+			defer c.Close()
+
+			tc.Test(t, c, srv.URL, &conns, &probes, succeeded)
+		})
+	}
+}
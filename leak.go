@@ -0,0 +1,74 @@
+package client
+
+import (
+	"context"
+	"io"
+	"runtime"
+	"runtime/debug"
+	"sync/atomic"
+
+	"github.com/go-logr/logr"
+)
+
+// WithLeakDetection configures a Client instance to track the bodies
+// of the responses it returns. Bodies which are garbage collected
+// without having been closed are closed, counted and reported to
+// Logger along with the stack which issued the request. This helps
+// to find the cause of exhausted connection pools but captures a
+// stack trace per request and is therefore meant for development.
+// Leaks are only counted if no Logger is provided.
+type WithLeakDetection struct {
+	Logger logr.Logger
+}
+
+func (ld WithLeakDetection) ConfigureClient(c *ClientConfig) {
+	logger := ld.Logger
+	if logger.GetSink() == nil {
+		logger = logr.Discard()
+	}
+
+	c.leakDetector = &leakDetector{logger: logger}
+}
+
+// LeakedBodies returns the number of response bodies which were
+// garbage collected without having been closed. It is always zero
+// unless the Client was configured with WithLeakDetection.
+func (c *Client) LeakedBodies() int64 {
+	if c.cfg.leakDetector == nil {
+		return 0
+	}
+
+	return c.cfg.leakDetector.leaked.Load()
+}
+
+type leakDetector struct {
+	logger logr.Logger
+	leaked atomic.Int64
+}
+
+// track behaves like newCancelOnCloseBody but reports the
+// returned body if it becomes unreachable while still open.
+func (d *leakDetector) track(ctx context.Context, cancel context.CancelFunc, body io.ReadCloser) io.ReadCloser {
+	var (
+		stack  = debug.Stack()
+		closed atomic.Bool
+	)
+
+	tracked := newCancelOnCloseBody(ctx, func() {
+		closed.Store(true)
+		cancel()
+	}, body)
+
+	runtime.SetFinalizer(tracked, func(b io.ReadCloser) {
+		if closed.Load() {
+			return
+		}
+
+		d.leaked.Add(1)
+		d.logger.Info("response body garbage collected without being closed", "stack", string(stack))
+
+		b.Close()
+	})
+
+	return tracked
+}
@@ -0,0 +1,94 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr/funcr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithLeakDetection(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = io.WriteString(w, "body")
+	}))
+	t.Cleanup(srv.Close)
+
+	for name, tc := range map[string]struct {
+		Logged bool
+	}{
+		"logger": {
+			Logged: true,
+		},
+		"no logger": {},
+	} {
+		tc := tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var (
+				mu   sync.Mutex
+				logs []string
+			)
+
+			var opt WithLeakDetection
+
+			if tc.Logged {
+				opt.Logger = funcr.New(func(prefix, args string) {
+					mu.Lock()
+					defer mu.Unlock()
+
+					logs = append(logs, args)
+				}, funcr.Options{})
+			}
+
+			c := NewClient(opt)
+			defer c.Close()
+
+			closed, err := c.Get(context.Background(), srv.URL+"/closed")
+			require.NoError(t, err)
+			require.NoError(t, closed.Body.Close())
+
+			func() {
+				_, err := c.Get(context.Background(), srv.URL+"/leaked")
+				require.NoError(t, err)
+			}()
+
+			require.Eventually(t, func() bool {
+				runtime.GC()
+
+				return c.LeakedBodies() == 1
+			}, 5*time.Second, 10*time.Millisecond)
+
+			runtime.KeepAlive(closed)
+
+			if !tc.Logged {
+				return
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			require.Len(t, logs, 1)
+			assert.Contains(t, logs[0], "garbage collected without being closed")
+			assert.True(t, strings.Contains(logs[0], "TestWithLeakDetection"), "log must contain the stack of the request")
+		})
+	}
+}
+
+func TestLeakedBodiesDisabled(t *testing.T) {
+	t.Parallel()
+
+	assert.Zero(t, NewClient().LeakedBodies())
+}
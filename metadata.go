@@ -0,0 +1,71 @@
+package client
+
+import (
+	"context"
+	"slices"
+
+	"github.com/go-logr/logr"
+)
+
+// RequestIDHeader is the header a request ID
+// carried by the request context is sent in.
+const RequestIDHeader = "X-Request-ID"
+
+type requestIDKey struct{}
+
+type logFieldsKey struct{}
+
+// ContextWithRequestID returns a copy of ctx carrying the given request
+// ID. A Client sends the ID in the RequestIDHeader of requests made with
+// the returned context unless the header is set explicitly, and wrappers
+// include it in their log messages so that a request can be followed
+// through the whole chain.
+func ContextWithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestIDFromContext returns the request ID carried by ctx.
+func RequestIDFromContext(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(requestIDKey{}).(string)
+
+	return id, ok && id != ""
+}
+
+// ContextWithLogFields returns a copy of ctx carrying the given
+// key/value pairs in addition to any already carried by ctx. Wrappers
+// add the fields to their log messages for requests made with the
+// returned context.
+func ContextWithLogFields(ctx context.Context, kv ...any) context.Context {
+	fields := append(slices.Clone(LogFieldsFromContext(ctx)), kv...)
+
+	return context.WithValue(ctx, logFieldsKey{}, fields)
+}
+
+// LogFieldsFromContext returns the key/value pairs carried by ctx.
+func LogFieldsFromContext(ctx context.Context) []any {
+	fields, _ := ctx.Value(logFieldsKey{}).([]any)
+
+	return fields
+}
+
+// contextLogger returns logger with the request
+// ID and log fields carried by ctx added.
+func contextLogger(logger logr.Logger, ctx context.Context) logr.Logger {
+	if id, ok := RequestIDFromContext(ctx); ok {
+		logger = logger.WithValues("requestID", id)
+	}
+
+	if fields := LogFieldsFromContext(ctx); len(fields) > 0 {
+		logger = logger.WithValues(fields...)
+	}
+
+	return logger
+}
+
+// WithRequestID sets the request ID of a single request.
+// See ContextWithRequestID.
+type WithRequestID string
+
+func (id WithRequestID) ConfigureRequest(c *RequestConfig) {
+	c.RequestID = string(id)
+}
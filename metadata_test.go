@@ -0,0 +1,129 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/go-logr/logr/funcr"
+	"github.com/mt-sre/client/internal/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContextWithLogFields(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	assert.Empty(t, LogFieldsFromContext(ctx))
+
+	parent := ContextWithLogFields(ctx, "cluster", "a")
+	child := ContextWithLogFields(parent, "org", "b")
+	sibling := ContextWithLogFields(parent, "org", "c")
+
+	assert.Equal(t, []any{"cluster", "a"}, LogFieldsFromContext(parent))
+	assert.Equal(t, []any{"cluster", "a", "org", "b"}, LogFieldsFromContext(child))
+	assert.Equal(t, []any{"cluster", "a", "org", "c"}, LogFieldsFromContext(sibling))
+}
+
+func TestRequestIDHeader(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		Ctx            context.Context
+		Options        []RequestOption
+		ExpectedHeader string
+	}{
+		"no request id": {
+			Ctx: context.Background(),
+		},
+		"context": {
+			Ctx:            ContextWithRequestID(context.Background(), "ctx-id"),
+			ExpectedHeader: "ctx-id",
+		},
+		"request option": {
+			Ctx:            ContextWithRequestID(context.Background(), "ctx-id"),
+			Options:        []RequestOption{WithRequestID("option-id")},
+			ExpectedHeader: "option-id",
+		},
+		"explicit header": {
+			Ctx:            ContextWithRequestID(context.Background(), "ctx-id"),
+			Options:        []RequestOption{WithRequestHeaders{RequestIDHeader: {"header-id"}}},
+			ExpectedHeader: "header-id",
+		},
+	} {
+		tc := tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var sent []*http.Request
+
+			mrt := &testutils.MockRoundTripper{}
+			mrt.
+				On("RoundTrip", mock.Anything).
+				Run(func(args mock.Arguments) {
+					sent = append(sent, args.Get(0).(*http.Request))
+				}).
+				Return(&http.Response{StatusCode: http.StatusOK, Body: http.NoBody}, nil)
+
+			c := NewClient(WithTransport{RoundTripper: mrt})
+			defer c.Close()
+
+			res, err := c.Get(tc.Ctx, "https://api.example.com", tc.Options...)
+			require.NoError(t, err)
+			require.NoError(t, res.Body.Close())
+
+			require.Len(t, sent, 1)
+			assert.Equal(t, tc.ExpectedHeader, sent[0].Header.Get(RequestIDHeader))
+		})
+	}
+}
+
+func TestRetryWrapperContextLogger(t *testing.T) {
+	t.Parallel()
+
+	var (
+		mu   sync.Mutex
+		logs []string
+	)
+
+	logger := funcr.New(func(_, args string) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		logs = append(logs, args)
+	}, funcr.Options{Verbosity: 1})
+
+	mrt := &testutils.MockRoundTripper{}
+	mrt.
+		On("RoundTrip", mock.Anything).
+		Return(&http.Response{StatusCode: http.StatusServiceUnavailable, Body: http.NoBody}, nil)
+
+	rt := NewRetryWrapper(
+		WithLogger{Logger: logger},
+		WithBackoffGenerator(NoBackoffGenerator()),
+		WithMaxRetries(1),
+	).Wrap(mrt)
+
+	ctx := ContextWithRequestID(context.Background(), "abc")
+	ctx = ContextWithLogFields(ctx, "cluster", "a")
+
+	res, err := rt.RoundTrip(testutils.MockRequest(t, http.MethodGet, nil).WithContext(ctx))
+	require.NoError(t, err)
+	require.NoError(t, res.Body.Close())
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	require.NotEmpty(t, logs)
+
+	for _, entry := range logs {
+		assert.True(t, strings.Contains(entry, `"requestID"="abc"`), entry)
+		assert.True(t, strings.Contains(entry, `"cluster"="a"`), entry)
+	}
+}
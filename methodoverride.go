@@ -0,0 +1,106 @@
+package client
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// MethodOverrideHeader carries the original method of
+// requests tunneled through POST by a MethodOverrideWrapper.
+const MethodOverrideHeader = "X-HTTP-Method-Override"
+
+// NewMethodOverrideWrapper returns a TransportWrapper which sends
+// requests using one of the overridden methods, PATCH and DELETE by
+// default, as POST requests carrying the original method in the
+// MethodOverrideHeader. This allows reaching servers behind proxies or
+// gateways which block those methods. Overriding is limited to the
+// hosts configured with WithMethodOverrideHosts or applies to all
+// hosts if none are configured. The MethodOverrideWrapper should be
+// applied before any RetryWrapper so that retries are decided based
+// on the original method.
+func NewMethodOverrideWrapper(opts ...MethodOverrideOption) *MethodOverrideWrapper {
+	var cfg MethodOverrideConfig
+
+	cfg.Option(opts...)
+	cfg.Default()
+
+	return &MethodOverrideWrapper{
+		cfg: cfg,
+	}
+}
+
+type MethodOverrideWrapper struct {
+	cfg MethodOverrideConfig
+	rt  http.RoundTripper
+}
+
+func (w *MethodOverrideWrapper) Wrap(rt http.RoundTripper) http.RoundTripper {
+	w.rt = rt
+
+	return w
+}
+
+func (w *MethodOverrideWrapper) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !w.overrides(req) {
+		return w.rt.RoundTrip(req)
+	}
+
+	out := req.Clone(req.Context())
+	out.Method = http.MethodPost
+	out.Header.Set(MethodOverrideHeader, req.Method)
+
+	return w.rt.RoundTrip(out)
+}
+
+func (w *MethodOverrideWrapper) overrides(req *http.Request) bool {
+	if !slices.ContainsFunc(w.cfg.Methods, func(m string) bool {
+		return strings.EqualFold(m, req.Method)
+	}) {
+		return false
+	}
+
+	return len(w.cfg.Hosts) == 0 || matchHost(w.cfg.Hosts, req.URL.Hostname())
+}
+
+type MethodOverrideConfig struct {
+	// Methods lists the methods tunneled through POST.
+	// Defaults to PATCH and DELETE.
+	Methods []string
+	// Hosts restricts overriding to requests for hosts matching
+	// one of the given patterns following the conventions of the
+	// NO_PROXY environment variable. An empty list matches all hosts.
+	Hosts []string
+}
+
+func (c *MethodOverrideConfig) Option(opts ...MethodOverrideOption) {
+	for _, opt := range opts {
+		opt.ConfigureMethodOverride(c)
+	}
+}
+
+func (c *MethodOverrideConfig) Default() {
+	if len(c.Methods) == 0 {
+		c.Methods = []string{http.MethodPatch, http.MethodDelete}
+	}
+}
+
+type MethodOverrideOption interface {
+	ConfigureMethodOverride(*MethodOverrideConfig)
+}
+
+// WithOverriddenMethods sets the methods a MethodOverrideWrapper
+// tunnels through POST.
+type WithOverriddenMethods []string
+
+func (m WithOverriddenMethods) ConfigureMethodOverride(c *MethodOverrideConfig) {
+	c.Methods = []string(m)
+}
+
+// WithMethodOverrideHosts restricts a MethodOverrideWrapper to the
+// given hosts. This option can be provided multiple times.
+type WithMethodOverrideHosts []string
+
+func (h WithMethodOverrideHosts) ConfigureMethodOverride(c *MethodOverrideConfig) {
+	c.Hosts = append(c.Hosts, h...)
+}
@@ -0,0 +1,100 @@
+package client
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMethodOverrideWrapperInterfaces(t *testing.T) {
+	t.Parallel()
+
+	require.Implements(t, new(http.RoundTripper), new(MethodOverrideWrapper))
+
+	require.Implements(t, new(TransportWrapper), new(MethodOverrideWrapper))
+}
+
+func TestMethodOverrideWrapper(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		Method           string
+		URL              string
+		Options          []MethodOverrideOption
+		ExpectedMethod   string
+		ExpectedOverride string
+	}{
+		"patch": {
+			Method:           http.MethodPatch,
+			URL:              "https://api.example.com/clusters/1",
+			ExpectedMethod:   http.MethodPost,
+			ExpectedOverride: http.MethodPatch,
+		},
+		"delete": {
+			Method:           http.MethodDelete,
+			URL:              "https://api.example.com/clusters/1",
+			ExpectedMethod:   http.MethodPost,
+			ExpectedOverride: http.MethodDelete,
+		},
+		"get": {
+			Method:         http.MethodGet,
+			URL:            "https://api.example.com/clusters/1",
+			ExpectedMethod: http.MethodGet,
+		},
+		"matching host": {
+			Method:           http.MethodPatch,
+			URL:              "https://legacy.gateway.example.com/clusters/1",
+			Options:          []MethodOverrideOption{WithMethodOverrideHosts{"gateway.example.com"}},
+			ExpectedMethod:   http.MethodPost,
+			ExpectedOverride: http.MethodPatch,
+		},
+		"other host": {
+			Method:         http.MethodPatch,
+			URL:            "https://api.example.com/clusters/1",
+			Options:        []MethodOverrideOption{WithMethodOverrideHosts{"gateway.example.com"}},
+			ExpectedMethod: http.MethodPatch,
+		},
+		"custom methods": {
+			Method:           http.MethodPut,
+			URL:              "https://api.example.com/clusters/1",
+			Options:          []MethodOverrideOption{WithOverriddenMethods{"put"}},
+			ExpectedMethod:   http.MethodPost,
+			ExpectedOverride: http.MethodPut,
+		},
+	} {
+		tc := tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			req, err := http.NewRequest(tc.Method, tc.URL, strings.NewReader("payload"))
+			require.NoError(t, err)
+
+			rt := NewMethodOverrideWrapper(tc.Options...).Wrap(Handler(func(out *http.Request) (*http.Response, error) {
+				assert.Equal(t, tc.ExpectedMethod, out.Method)
+				assert.Equal(t, tc.ExpectedOverride, out.Header.Get(MethodOverrideHeader))
+
+				body, err := io.ReadAll(out.Body)
+				assert.NoError(t, err)
+				assert.Equal(t, "payload", string(body))
+
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Body:       io.NopCloser(bytes.NewBuffer(nil)),
+				}, nil
+			}))
+
+			res, err := rt.RoundTrip(req)
+			require.NoError(t, err)
+			require.NoError(t, res.Body.Close())
+
+			assert.Equal(t, tc.Method, req.Method, "original request must not be modified")
+			assert.Empty(t, req.Header.Get(MethodOverrideHeader))
+		})
+	}
+}
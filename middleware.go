@@ -0,0 +1,107 @@
+package client
+
+import (
+	"net/http"
+)
+
+// Handler sends a request and returns its response. Handler
+// implements http.RoundTripper and must follow its contract;
+// in particular the request must not be modified.
+type Handler func(*http.Request) (*http.Response, error)
+
+func (h Handler) RoundTrip(req *http.Request) (*http.Response, error) {
+	return h(req)
+}
+
+// Middleware adds functionality to a Handler. Middlewares are a
+// lightweight alternative to TransportWrappers for simple request
+// and response mutations.
+type Middleware func(next Handler) Handler
+
+// Chain returns a Handler which passes requests through the given
+// middlewares in order before calling next. The first middleware
+// sees the request first and the response last.
+func Chain(next Handler, mws ...Middleware) Handler {
+	for i := len(mws) - 1; i >= 0; i-- {
+		next = mws[i](next)
+	}
+
+	return next
+}
+
+// MiddlewareWrapper adapts a Middleware to a TransportWrapper.
+func MiddlewareWrapper(mw Middleware) TransportWrapper {
+	return middlewareWrapper{mw: mw}
+}
+
+type middlewareWrapper struct {
+	mw Middleware
+}
+
+func (w middlewareWrapper) Wrap(rt http.RoundTripper) http.RoundTripper {
+	return w.mw(rt.RoundTrip)
+}
+
+// WrapperMiddleware adapts a TransportWrapper to a Middleware.
+// As TransportWrappers retain the transport they wrap the
+// resulting Middleware must only be used in a single chain.
+func WrapperMiddleware(w TransportWrapper) Middleware {
+	return func(next Handler) Handler {
+		return w.Wrap(next).RoundTrip
+	}
+}
+
+// ModifyRequest returns a Middleware which calls fn with a
+// copy of each request before it is sent. The request is
+// not sent if fn returns an error.
+func ModifyRequest(fn func(*http.Request) error) Middleware {
+	return func(next Handler) Handler {
+		return func(req *http.Request) (*http.Response, error) {
+			out := req.Clone(req.Context())
+
+			if err := fn(out); err != nil {
+				if req.Body != nil {
+					req.Body.Close()
+				}
+
+				return nil, err
+			}
+
+			return next(out)
+		}
+	}
+}
+
+// ModifyResponse returns a Middleware which calls fn with each
+// response received. If fn returns an error the response body
+// is closed and the error is returned.
+func ModifyResponse(fn func(*http.Response) error) Middleware {
+	return func(next Handler) Handler {
+		return func(req *http.Request) (*http.Response, error) {
+			res, err := next(req)
+			if err != nil {
+				return res, err
+			}
+
+			if err := fn(res); err != nil {
+				res.Body.Close()
+
+				return nil, err
+			}
+
+			return res, nil
+		}
+	}
+}
+
+// WithMiddleware configures a Client instance with the given
+// middlewares. Middlewares are applied outside all Wrappers so that
+// they observe each request once regardless of retries. They run in
+// the order given with the first middleware seeing the request first
+// and the response last. This option can be provided multiple times
+// in which case the middlewares are appended to the chain.
+type WithMiddleware []Middleware
+
+func (mw WithMiddleware) ConfigureClient(c *ClientConfig) {
+	c.Middlewares = append(c.Middlewares, mw...)
+}
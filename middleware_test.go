@@ -0,0 +1,241 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mt-sre/client/internal/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandlerInterfaces(t *testing.T) {
+	t.Parallel()
+
+	require.Implements(t, new(http.RoundTripper), new(Handler))
+}
+
+func recordingMiddleware(name string, calls *[]string) Middleware {
+	return func(next Handler) Handler {
+		return func(req *http.Request) (*http.Response, error) {
+			*calls = append(*calls, name+" request")
+
+			res, err := next(req)
+
+			*calls = append(*calls, name+" response")
+
+			return res, err
+		}
+	}
+}
+
+func TestChain(t *testing.T) {
+	t.Parallel()
+
+	var calls []string
+
+	h := Chain(func(*http.Request) (*http.Response, error) {
+		calls = append(calls, "handler")
+
+		return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody}, nil
+	}, recordingMiddleware("first", &calls), recordingMiddleware("second", &calls))
+
+	_, err := h(testutils.MockRequest(t, http.MethodGet, nil))
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{
+		"first request",
+		"second request",
+		"handler",
+		"second response",
+		"first response",
+	}, calls)
+}
+
+func TestModifyRequest(t *testing.T) {
+	t.Parallel()
+
+	errFailed := errors.New("failed")
+
+	for name, tc := range map[string]struct {
+		Modify         func(*http.Request) error
+		ExpectedHeader string
+		ExpectedErr    error
+	}{
+		"header set": {
+			Modify: func(req *http.Request) error {
+				req.Header.Set("X-Tenant", "a")
+
+				return nil
+			},
+			ExpectedHeader: "a",
+		},
+		"error": {
+			Modify: func(*http.Request) error {
+				return errFailed
+			},
+			ExpectedErr: errFailed,
+		},
+	} {
+		tc := tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var sent []*http.Request
+
+			mrt := &testutils.MockRoundTripper{}
+			mrt.
+				On("RoundTrip", mock.Anything).
+				Run(func(args mock.Arguments) {
+					sent = append(sent, args.Get(0).(*http.Request))
+				}).
+				Return(&http.Response{StatusCode: http.StatusOK, Body: http.NoBody}, nil)
+
+			req := testutils.MockRequest(t, http.MethodGet, nil)
+
+			_, err := MiddlewareWrapper(ModifyRequest(tc.Modify)).Wrap(mrt).RoundTrip(req)
+
+			assert.Empty(t, req.Header.Get("X-Tenant"))
+
+			if tc.ExpectedErr != nil {
+				assert.ErrorIs(t, err, tc.ExpectedErr)
+				mrt.AssertNotCalled(t, "RoundTrip", mock.Anything)
+
+				return
+			}
+
+			require.NoError(t, err)
+			require.Len(t, sent, 1)
+			assert.Equal(t, tc.ExpectedHeader, sent[0].Header.Get("X-Tenant"))
+		})
+	}
+}
+
+func TestModifyResponse(t *testing.T) {
+	t.Parallel()
+
+	errFailed := errors.New("failed")
+
+	for name, tc := range map[string]struct {
+		Modify         func(*http.Response) error
+		ExpectedHeader string
+		ExpectedErr    error
+	}{
+		"header set": {
+			Modify: func(res *http.Response) error {
+				res.Header.Set("X-Modified", "true")
+
+				return nil
+			},
+			ExpectedHeader: "true",
+		},
+		"error": {
+			Modify: func(*http.Response) error {
+				return errFailed
+			},
+			ExpectedErr: errFailed,
+		},
+	} {
+		tc := tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			mrt := &testutils.MockRoundTripper{}
+			mrt.
+				On("RoundTrip", mock.Anything).
+				Return(&http.Response{
+					StatusCode: http.StatusOK,
+					Header:     make(http.Header),
+					Body:       http.NoBody,
+				}, nil)
+
+			res, err := MiddlewareWrapper(ModifyResponse(tc.Modify)).Wrap(mrt).RoundTrip(testutils.MockRequest(t, http.MethodGet, nil))
+
+			if tc.ExpectedErr != nil {
+				assert.ErrorIs(t, err, tc.ExpectedErr)
+				assert.Nil(t, res)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.ExpectedHeader, res.Header.Get("X-Modified"))
+		})
+	}
+}
+
+func TestWrapperMiddleware(t *testing.T) {
+	t.Parallel()
+
+	mrt := &testutils.MockRoundTripper{}
+	mrt.
+		On("RoundTrip", mock.Anything).
+		Return(&http.Response{StatusCode: http.StatusServiceUnavailable, Body: http.NoBody}, nil).
+		Once()
+	mrt.
+		On("RoundTrip", mock.Anything).
+		Return(&http.Response{StatusCode: http.StatusOK, Body: http.NoBody}, nil).
+		Once()
+
+	retry := WrapperMiddleware(NewRetryWrapper(
+		WithBackoffGenerator(NoBackoffGenerator()),
+		WithMaxRetries(1),
+	))
+
+	res, err := Chain(mrt.RoundTrip, retry)(testutils.MockRequest(t, http.MethodGet, nil))
+	require.NoError(t, err)
+
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	mrt.AssertNumberOfCalls(t, "RoundTrip", 2)
+}
+
+func TestClientMiddleware(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, r.Header.Get("X-Order"))
+	}))
+	defer srv.Close()
+
+	appendOrder := func(val string) Middleware {
+		return ModifyRequest(func(req *http.Request) error {
+			req.Header.Set("X-Order", strings.TrimPrefix(req.Header.Get("X-Order")+","+val, ","))
+
+			return nil
+		})
+	}
+
+	var attempts int
+
+	c := NewClient(
+		WithMiddleware{appendOrder("first")},
+		WithMiddleware{appendOrder("second")},
+		WithWrapper{TransportWrapper: MiddlewareWrapper(func(next Handler) Handler {
+			return func(req *http.Request) (*http.Response, error) {
+				attempts++
+
+				return next(req)
+			}
+		})},
+	)
+	defer c.Close()
+
+	res, err := c.Get(context.Background(), srv.URL)
+	require.NoError(t, err)
+
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(res.Body)
+	require.NoError(t, err)
+
+	assert.Equal(t, "first,second", string(body))
+	assert.Equal(t, 1, attempts)
+}
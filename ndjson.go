@@ -0,0 +1,118 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// ndjsonAccept is sent by GetNDJSON to ask for a JSON stream.
+const ndjsonAccept = "application/x-ndjson, application/jsonl;q=0.9, application/json;q=0.8"
+
+// GetNDJSON performs a HTTP GET request against the provided URL and
+// invokes fn for every element of the newline-delimited JSON stream or
+// top-level JSON array in the response body as it is received. The
+// body is never buffered in full. Iteration stops at the first error
+// returned by fn, which is returned as is, at the first malformed
+// element or when ctx is canceled.
+func (c *Client) GetNDJSON(ctx context.Context, url string, fn func(json.RawMessage) error, opts ...RequestOption) error {
+	opts = append(opts, withDefaultRequestHeaders{
+		"Accept": []string{ndjsonAccept},
+	})
+
+	res, err := c.requestWithBody(ctx, http.MethodGet, url, nil, opts...)
+	if err != nil {
+		return err
+	}
+
+	var fnErr error
+
+	err = decodeResponse(res, func(r io.Reader) error {
+		return decodeJSONStream(ctx, r, func(raw json.RawMessage) error {
+			if err := fn(raw); err != nil {
+				fnErr = err
+
+				return err
+			}
+
+			return nil
+		})
+	})
+	if fnErr != nil {
+		return fnErr
+	}
+
+	return err
+}
+
+// decodeJSONStream invokes fn for every value of a newline-delimited
+// JSON stream or for every element if the stream is a JSON array.
+func decodeJSONStream(ctx context.Context, r io.Reader, fn func(json.RawMessage) error) error {
+	br := bufio.NewReader(r)
+
+	array, err := startsJSONArray(br)
+	if err != nil {
+		return err
+	}
+
+	dec := json.NewDecoder(br)
+
+	if array {
+		// consume the opening bracket
+		if _, err := dec.Token(); err != nil {
+			return err
+		}
+	}
+
+	for index := 0; ; index++ {
+		if err := ctx.Err(); err != nil {
+			return context.Cause(ctx)
+		}
+
+		if array && !dec.More() {
+			_, err := dec.Token()
+
+			return err
+		}
+
+		var raw json.RawMessage
+
+		if err := dec.Decode(&raw); err != nil {
+			if !array && errors.Is(err, io.EOF) {
+				return nil
+			}
+
+			return fmt.Errorf("element %d: %w", index, withCancelCause(ctx, err))
+		}
+
+		if err := fn(raw); err != nil {
+			return err
+		}
+	}
+}
+
+// startsJSONArray reports whether the first
+// non-whitespace byte of br opens an array.
+func startsJSONArray(br *bufio.Reader) (bool, error) {
+	for {
+		b, err := br.ReadByte()
+		if errors.Is(err, io.EOF) {
+			return false, nil
+		}
+
+		if err != nil {
+			return false, err
+		}
+
+		switch b {
+		case ' ', '\t', '\r', '\n':
+			continue
+		}
+
+		return b == '[', br.UnreadByte()
+	}
+}
@@ -0,0 +1,125 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientGetNDJSON(t *testing.T) {
+	t.Parallel()
+
+	errStop := errors.New("stop")
+
+	for name, tc := range map[string]struct {
+		StatusCode  int
+		Body        string
+		StopAfter   int
+		Expected    []string
+		ExpectedErr error
+		ExpectError bool
+	}{
+		"ndjson": {
+			Body:     "{\"id\":1}\n{\"id\":2}\n\n{\"id\":3}\n",
+			Expected: []string{`{"id":1}`, `{"id":2}`, `{"id":3}`},
+		},
+		"array": {
+			Body:     " [{\"id\":1}, {\"id\":2}]",
+			Expected: []string{`{"id":1}`, `{"id":2}`},
+		},
+		"empty body": {},
+		"empty array": {
+			Body: "[]",
+		},
+		"callback error": {
+			Body:        "{\"id\":1}\n{\"id\":2}\n",
+			StopAfter:   1,
+			Expected:    []string{`{"id":1}`},
+			ExpectedErr: errStop,
+		},
+		"malformed element": {
+			Body:        "{\"id\":1}\n{\"id\":\n",
+			Expected:    []string{`{"id":1}`},
+			ExpectError: true,
+		},
+		"truncated array": {
+			Body:        "[{\"id\":1},",
+			Expected:    []string{`{"id":1}`},
+			ExpectError: true,
+		},
+		"error status": {
+			StatusCode:  http.StatusServiceUnavailable,
+			Body:        "unavailable",
+			ExpectError: true,
+		},
+	} {
+		tc := tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, ndjsonAccept, r.Header.Get("Accept"))
+
+				if tc.StatusCode != 0 {
+					w.WriteHeader(tc.StatusCode)
+				}
+
+				io.WriteString(w, tc.Body)
+			}))
+			defer srv.Close()
+
+			var elements []string
+
+			err := NewClient().GetNDJSON(context.Background(), srv.URL, func(raw json.RawMessage) error {
+				elements = append(elements, string(raw))
+
+				if tc.StopAfter > 0 && len(elements) == tc.StopAfter {
+					return errStop
+				}
+
+				return nil
+			})
+
+			assert.Equal(t, tc.Expected, elements)
+
+			switch {
+			case tc.ExpectedErr != nil:
+				require.ErrorIs(t, err, tc.ExpectedErr)
+			case tc.ExpectError:
+				require.Error(t, err)
+			default:
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestClientGetNDJSONCancel(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "{\"id\":1}\n")
+		w.(http.Flusher).Flush()
+
+		<-r.Context().Done()
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	err := NewClient().GetNDJSON(ctx, srv.URL, func(json.RawMessage) error {
+		cancel()
+
+		return nil
+	})
+	require.ErrorIs(t, err, context.Canceled)
+}
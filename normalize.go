@@ -0,0 +1,165 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+var (
+	// ErrURLCredentials is reported for URLs embedding user information.
+	ErrURLCredentials = errors.New("URL contains credentials")
+	// ErrURLControlCharacter is reported for URLs containing
+	// ASCII control characters.
+	ErrURLControlCharacter = errors.New("URL contains control characters")
+	// ErrURLScheme is reported for URLs whose scheme is not allowed.
+	ErrURLScheme = errors.New("URL scheme not allowed")
+	// ErrURLHost is reported for URLs without a host.
+	ErrURLHost = errors.New("URL has no host")
+)
+
+// URLValidationError is returned by a RequestNormalizerWrapper when
+// the URL of a request fails validation. URL is redacted.
+type URLValidationError struct {
+	URL string
+	Err error
+}
+
+func (e *URLValidationError) Error() string {
+	return fmt.Sprintf("invalid request URL %q: %v", e.URL, e.Err)
+}
+
+func (e *URLValidationError) Unwrap() error {
+	return e.Err
+}
+
+// NewRequestNormalizerWrapper returns a TransportWrapper which
+// validates and normalizes request URLs before they are sent.
+// Requests are rejected with a *URLValidationError if their URL
+// embeds credentials, contains control characters, has no host or
+// uses a scheme other than those configured with WithAllowedSchemes,
+// http and https by default. Accepted URLs have their scheme and host
+// lower-cased, default ports removed, dot segments and duplicate
+// slashes removed from the path and the fragment dropped.
+func NewRequestNormalizerWrapper(opts ...RequestNormalizerOption) *RequestNormalizerWrapper {
+	var cfg RequestNormalizerConfig
+
+	cfg.Option(opts...)
+	cfg.Default()
+
+	return &RequestNormalizerWrapper{
+		cfg: cfg,
+	}
+}
+
+type RequestNormalizerWrapper struct {
+	cfg RequestNormalizerConfig
+	rt  http.RoundTripper
+}
+
+func (w *RequestNormalizerWrapper) Wrap(rt http.RoundTripper) http.RoundTripper {
+	w.rt = rt
+
+	return w
+}
+
+func (w *RequestNormalizerWrapper) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := w.validate(req.URL); err != nil {
+		if req.Body != nil {
+			req.Body.Close()
+		}
+
+		return nil, &URLValidationError{
+			URL: redactURL(req.Context(), req.URL),
+			Err: err,
+		}
+	}
+
+	normalized := normalizeURL(req.URL)
+	if normalized.String() == req.URL.String() {
+		return w.rt.RoundTrip(req)
+	}
+
+	out := req.Clone(req.Context())
+	out.URL = normalized
+
+	return w.rt.RoundTrip(out)
+}
+
+func (w *RequestNormalizerWrapper) validate(u *url.URL) error {
+	if u.User != nil {
+		return ErrURLCredentials
+	}
+
+	for _, part := range []string{u.Host, u.Path, u.RawPath, u.RawQuery, u.Fragment} {
+		if strings.ContainsFunc(part, isControlCharacter) {
+			return ErrURLControlCharacter
+		}
+	}
+
+	if !slices.ContainsFunc(w.cfg.Schemes, func(s string) bool {
+		return strings.EqualFold(s, u.Scheme)
+	}) {
+		return fmt.Errorf("%w: %q", ErrURLScheme, u.Scheme)
+	}
+
+	if u.Host == "" {
+		return ErrURLHost
+	}
+
+	return nil
+}
+
+func isControlCharacter(r rune) bool {
+	return r < 0x20 || r == 0x7f
+}
+
+// normalizeURL returns a copy of u with a lower-cased scheme and
+// host, without default port or fragment and with a cleaned path.
+func normalizeURL(u *url.URL) *url.URL {
+	out := u.JoinPath()
+	out.Scheme = strings.ToLower(out.Scheme)
+	out.Host = strings.ToLower(out.Host)
+	out.Fragment = ""
+	out.RawFragment = ""
+
+	switch port := out.Port(); {
+	case out.Scheme == "http" && port == "80", out.Scheme == "https" && port == "443":
+		out.Host = strings.TrimSuffix(out.Host, ":"+port)
+	}
+
+	return out
+}
+
+type RequestNormalizerConfig struct {
+	// Schemes lists the allowed URL schemes.
+	// Defaults to http and https.
+	Schemes []string
+}
+
+func (c *RequestNormalizerConfig) Option(opts ...RequestNormalizerOption) {
+	for _, opt := range opts {
+		opt.ConfigureRequestNormalizer(c)
+	}
+}
+
+func (c *RequestNormalizerConfig) Default() {
+	if len(c.Schemes) == 0 {
+		c.Schemes = []string{"http", "https"}
+	}
+}
+
+type RequestNormalizerOption interface {
+	ConfigureRequestNormalizer(*RequestNormalizerConfig)
+}
+
+// WithAllowedSchemes replaces the URL schemes
+// a RequestNormalizerWrapper accepts.
+type WithAllowedSchemes []string
+
+func (s WithAllowedSchemes) ConfigureRequestNormalizer(c *RequestNormalizerConfig) {
+	c.Schemes = append([]string{}, s...)
+}
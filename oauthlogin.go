@@ -0,0 +1,108 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+
+	"golang.org/x/oauth2"
+)
+
+var errNoRefreshToken = errors.New("token cannot be refreshed without a refresh token")
+
+// loginTokenSource returns a TokenSource yielding the token stored in
+// store, if it is still valid or can be refreshed, and otherwise the
+// token obtained by login. Subsequent tokens are obtained using the
+// refresh token and stored in store if it is not nil.
+func loginTokenSource(
+	ctx context.Context,
+	conf *oauth2.Config,
+	client *http.Client,
+	store TokenStore,
+	login func(ctx context.Context) (*oauth2.Token, error),
+) (oauth2.TokenSource, error) {
+	if client != nil {
+		ctx = context.WithValue(ctx, oauth2.HTTPClient, client)
+	}
+
+	src := &refreshTokenSource{
+		// refreshes outlive the login
+		ctx:   context.WithoutCancel(ctx),
+		conf:  conf,
+		store: store,
+		key:   TokenStoreKey(conf.Endpoint.TokenURL, conf.ClientID),
+	}
+
+	if store != nil {
+		token, err := store.LoadToken(src.key)
+		if err == nil && token != nil && (token.Valid() || token.RefreshToken != "") {
+			src.token = token
+
+			return src, nil
+		}
+	}
+
+	token, err := login(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	src.set(token)
+
+	return src, nil
+}
+
+// refreshTokenSource returns its current token if not yet expired
+// and otherwise obtains a new one using the refresh token.
+type refreshTokenSource struct {
+	ctx   context.Context
+	conf  *oauth2.Config
+	store TokenStore
+	key   string
+
+	mu    sync.Mutex
+	token *oauth2.Token
+	used  bool
+}
+
+func (s *refreshTokenSource) Token() (*oauth2.Token, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// the initial token is returned once as OAUTHWrapper
+	// only calls Token to replace its current token
+	if !s.used && s.token.Valid() {
+		s.used = true
+
+		return s.token, nil
+	}
+
+	s.used = true
+
+	if s.token.RefreshToken == "" {
+		return nil, errNoRefreshToken
+	}
+
+	// a token without access token forces the refresh
+	token, err := s.conf.TokenSource(s.ctx, &oauth2.Token{
+		RefreshToken: s.token.RefreshToken,
+	}).Token()
+	if err != nil {
+		return nil, err
+	}
+
+	s.set(token)
+
+	return token, nil
+}
+
+// set replaces the current token persisting it if a store is
+// configured. Failing to store the token does not fail the login.
+func (s *refreshTokenSource) set(token *oauth2.Token) {
+	s.token = token
+
+	if s.store != nil {
+		_ = s.store.StoreToken(s.key, token)
+	}
+}
@@ -0,0 +1,72 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// ocmOperationIDHeader carries the operation ID of OCM responses.
+const ocmOperationIDHeader = "X-Operation-Id"
+
+// OCMError is the error payload returned by OCM APIs such as
+// clusters_mgmt and accounts_mgmt. The OperationID identifies the
+// failed request in support tickets.
+type OCMError struct {
+	Kind        string          `json:"kind"`
+	ID          string          `json:"id"`
+	Href        string          `json:"href"`
+	Code        string          `json:"code"`
+	Reason      string          `json:"reason"`
+	OperationID string          `json:"operation_id"`
+	Details     json.RawMessage `json:"details,omitempty"`
+
+	// HTTPError is the error the payload was decoded from.
+	HTTPError *HTTPError `json:"-"`
+}
+
+// AsOCMError decodes the OCM error payload captured by an *HTTPError
+// within err's chain as returned by a Client configured with
+// WithErrorOnNon2xx or WithCaptureErrorBodies. It reports false if err
+// does not wrap an *HTTPError or its body is not an OCM error, e.g.
+// because it was truncated.
+func AsOCMError(err error) (*OCMError, bool) {
+	var httpErr *HTTPError
+	if !errors.As(err, &httpErr) {
+		return nil, false
+	}
+
+	var ocmErr OCMError
+	if jsonErr := json.Unmarshal(httpErr.Body, &ocmErr); jsonErr != nil || ocmErr.Kind != "Error" {
+		return nil, false
+	}
+
+	if ocmErr.OperationID == "" {
+		ocmErr.OperationID = httpErr.Header.Get(ocmOperationIDHeader)
+	}
+
+	ocmErr.HTTPError = httpErr
+
+	return &ocmErr, true
+}
+
+func (e *OCMError) Error() string {
+	msg := e.Reason
+	if e.Code != "" {
+		msg = fmt.Sprintf("%s: %s", e.Code, msg)
+	}
+
+	if e.OperationID != "" {
+		msg = fmt.Sprintf("%s (operation ID: %s)", msg, e.OperationID)
+	}
+
+	return msg
+}
+
+func (e *OCMError) Unwrap() error {
+	if e.HTTPError == nil {
+		return nil
+	}
+
+	return e.HTTPError
+}
@@ -0,0 +1,81 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAsOCMError(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		Err             error
+		ExpectedOK      bool
+		ExpectedCode    string
+		ExpectedOpID    string
+		ExpectedMessage string
+	}{
+		"ocm error": {
+			Err: fmt.Errorf("creating cluster: %w", &HTTPError{
+				StatusCode: http.StatusBadRequest,
+				Body: []byte(`{
+					"kind": "Error",
+					"id": "400",
+					"href": "/api/clusters_mgmt/v1/errors/400",
+					"code": "CLUSTERS-MGMT-400",
+					"reason": "Cluster name is invalid",
+					"operation_id": "8f3b6a2e"
+				}`),
+			}),
+			ExpectedOK:      true,
+			ExpectedCode:    "CLUSTERS-MGMT-400",
+			ExpectedOpID:    "8f3b6a2e",
+			ExpectedMessage: "CLUSTERS-MGMT-400: Cluster name is invalid (operation ID: 8f3b6a2e)",
+		},
+		"operation id header": {
+			Err: &HTTPError{
+				StatusCode: http.StatusNotFound,
+				Header:     http.Header{"X-Operation-Id": {"c41d9e07"}},
+				Body:       []byte(`{"kind": "Error", "code": "CLUSTERS-MGMT-404", "reason": "Cluster not found"}`),
+			},
+			ExpectedOK:      true,
+			ExpectedCode:    "CLUSTERS-MGMT-404",
+			ExpectedOpID:    "c41d9e07",
+			ExpectedMessage: "CLUSTERS-MGMT-404: Cluster not found (operation ID: c41d9e07)",
+		},
+		"other payload": {
+			Err: &HTTPError{StatusCode: http.StatusBadRequest, Body: []byte(`{"message": "bad request"}`)},
+		},
+		"not json": {
+			Err: &HTTPError{StatusCode: http.StatusBadGateway, Body: []byte("<html>Bad Gateway</html>")},
+		},
+		"not an HTTPError": {
+			Err: errors.New("connection refused"),
+		},
+	} {
+		tc := tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			ocmErr, ok := AsOCMError(tc.Err)
+			require.Equal(t, tc.ExpectedOK, ok)
+
+			if !ok {
+				return
+			}
+
+			assert.Equal(t, tc.ExpectedCode, ocmErr.Code)
+			assert.Equal(t, tc.ExpectedOpID, ocmErr.OperationID)
+			assert.Equal(t, tc.ExpectedMessage, ocmErr.Error())
+
+			var httpErr *HTTPError
+			assert.ErrorAs(t, ocmErr, &httpErr)
+		})
+	}
+}
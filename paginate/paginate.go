@@ -0,0 +1,191 @@
+// Package paginate walks paginated collections exposed over HTTP. A
+// Strategy determines how the request for the following page is
+// derived, for instance from RFC 8288 Link headers or from page and
+// offset query parameters, and a Decoder extracts the items of each
+// page. Retries of individual pages are left to the wrappers of the
+// client used to fetch them.
+//
+//	for cluster, err := range paginate.Items(ctx, c, "https://api.example.com/clusters",
+//		paginate.JSONField[Cluster]("items"), paginate.PageNumber{Size: 100}) {
+//		if err != nil {
+//			return err
+//		}
+//		...
+//	}
+package paginate
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"iter"
+	"net/http"
+	"net/url"
+
+	"github.com/mt-sre/client"
+)
+
+// Getter performs GET requests. It is implemented
+// by *client.Client and client.ClientInterface.
+type Getter interface {
+	Get(ctx context.Context, url string, opts ...client.RequestOption) (*http.Response, error)
+}
+
+// Decoder extracts the items of a page from the response. The
+// response body is closed by the caller.
+type Decoder[T any] func(res *http.Response) ([]T, error)
+
+// Page is a single page of a collection.
+type Page[T any] struct {
+	// Number is the position of the page starting at 1.
+	Number int
+	// URL is the URL the page was fetched from.
+	URL   *url.URL
+	Items []T
+	// Header holds the headers of the response.
+	Header http.Header
+}
+
+// Pages returns an iterator over the pages of the collection at
+// rawURL. Iteration stops once the Strategy reports no further page,
+// the context is canceled or an error occurs, in which case the error
+// is yielded as the final element. Responses with a status code
+// outside of the 2xx range are reported as *client.HTTPError.
+func Pages[T any](ctx context.Context, c Getter, rawURL string, decode Decoder[T], strategy Strategy, opts ...client.RequestOption) iter.Seq2[Page[T], error] {
+	return func(yield func(Page[T], error) bool) {
+		u, err := url.Parse(rawURL)
+		if err != nil {
+			yield(Page[T]{}, fmt.Errorf("parsing url: %w", err))
+
+			return
+		}
+
+		u = strategy.First(u)
+
+		for number := 1; ; number++ {
+			if err := ctx.Err(); err != nil {
+				yield(Page[T]{}, err)
+
+				return
+			}
+
+			page, res, err := fetch(ctx, c, u, decode, opts)
+			if err != nil {
+				yield(Page[T]{}, fmt.Errorf("fetching page %d: %w", number, err))
+
+				return
+			}
+
+			page.Number = number
+
+			next, ok := strategy.Next(u, res, len(page.Items))
+
+			if !yield(page, nil) || !ok {
+				return
+			}
+
+			u = next
+		}
+	}
+}
+
+func fetch[T any](ctx context.Context, c Getter, u *url.URL, decode Decoder[T], opts []client.RequestOption) (Page[T], *http.Response, error) {
+	res, err := c.Get(ctx, u.String(), opts...)
+	if err != nil {
+		return Page[T]{}, nil, err
+	}
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return Page[T]{}, nil, client.NewHTTPError(res)
+	}
+
+	defer res.Body.Close()
+
+	items, err := decode(res)
+	if err != nil {
+		return Page[T]{}, nil, fmt.Errorf("decoding page: %w", err)
+	}
+
+	return Page[T]{
+		URL:    u,
+		Items:  items,
+		Header: res.Header,
+	}, res, nil
+}
+
+// Items returns an iterator over the items of all pages
+// of the collection at rawURL. See Pages.
+func Items[T any](ctx context.Context, c Getter, rawURL string, decode Decoder[T], strategy Strategy, opts ...client.RequestOption) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		for page, err := range Pages(ctx, c, rawURL, decode, strategy, opts...) {
+			if err != nil {
+				var zero T
+
+				yield(zero, err)
+
+				return
+			}
+
+			for _, item := range page.Items {
+				if !yield(item, nil) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// Collect returns the items of all pages of the
+// collection at rawURL. See Pages.
+func Collect[T any](ctx context.Context, c Getter, rawURL string, decode Decoder[T], strategy Strategy, opts ...client.RequestOption) ([]T, error) {
+	var all []T
+
+	for page, err := range Pages(ctx, c, rawURL, decode, strategy, opts...) {
+		if err != nil {
+			return all, err
+		}
+
+		all = append(all, page.Items...)
+	}
+
+	return all, nil
+}
+
+// JSON returns a Decoder for pages whose body is a JSON array of items.
+func JSON[T any]() Decoder[T] {
+	return func(res *http.Response) ([]T, error) {
+		var items []T
+
+		if err := json.NewDecoder(res.Body).Decode(&items); err != nil {
+			return nil, err
+		}
+
+		return items, nil
+	}
+}
+
+// JSONField returns a Decoder for pages whose body is a JSON object
+// holding the array of items in the given field as in OCM list
+// responses.
+func JSONField[T any](field string) Decoder[T] {
+	return func(res *http.Response) ([]T, error) {
+		var obj map[string]json.RawMessage
+
+		if err := json.NewDecoder(res.Body).Decode(&obj); err != nil {
+			return nil, err
+		}
+
+		raw, ok := obj[field]
+		if !ok {
+			return nil, nil
+		}
+
+		var items []T
+
+		if err := json.Unmarshal(raw, &items); err != nil {
+			return nil, fmt.Errorf("decoding field %q: %w", field, err)
+		}
+
+		return items, nil
+	}
+}
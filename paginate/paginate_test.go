@@ -0,0 +1,206 @@
+package paginate
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+
+	"github.com/mt-sre/client"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newCollectionServer serves the items 0 to total-1
+// paginated according to the query parameters used.
+func newCollectionServer(t *testing.T, total int, requests *atomic.Int32) *httptest.Server {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+
+		query := r.URL.Query()
+
+		param := func(name string, def int) int {
+			if val, err := strconv.Atoi(query.Get(name)); err == nil {
+				return val
+			}
+
+			return def
+		}
+
+		var start, size int
+
+		switch r.URL.Path {
+		case "/pages":
+			size = param("size", 0)
+			start = (param("page", 0) - 1) * size
+		case "/offset":
+			size = param("limit", 0)
+			start = param("offset", 0)
+		case "/links":
+			size = 2
+			start = param("cursor", 0)
+
+			if start+size < total {
+				w.Header().Set("Link", fmt.Sprintf(`</links?cursor=%d>; rel="next"`, start+size))
+			}
+		case "/fail":
+			w.WriteHeader(http.StatusInternalServerError)
+
+			return
+		}
+
+		items := []int{}
+
+		for i := start; i < start+size && i < total; i++ {
+			items = append(items, i)
+		}
+
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"kind":  "List",
+			"items": items,
+		})
+	}))
+
+	t.Cleanup(srv.Close)
+
+	return srv
+}
+
+func TestCollect(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		Path             string
+		Strategy         Strategy
+		Total            int
+		ExpectedRequests int32
+	}{
+		"page number": {
+			Path:             "/pages",
+			Strategy:         PageNumber{Size: 2},
+			Total:            5,
+			ExpectedRequests: 3,
+		},
+		"page number exact multiple": {
+			Path:             "/pages",
+			Strategy:         PageNumber{Size: 2},
+			Total:            4,
+			ExpectedRequests: 3,
+		},
+		"offset": {
+			Path:             "/offset",
+			Strategy:         Offset{Limit: 3},
+			Total:            7,
+			ExpectedRequests: 3,
+		},
+		"link header": {
+			Path:             "/links",
+			Strategy:         LinkHeader{},
+			Total:            5,
+			ExpectedRequests: 3,
+		},
+		"empty": {
+			Path:             "/pages",
+			Strategy:         PageNumber{},
+			ExpectedRequests: 1,
+		},
+	} {
+		tc := tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var requests atomic.Int32
+
+			srv := newCollectionServer(t, tc.Total, &requests)
+
+			c := client.NewClient()
+			defer c.Close()
+
+			items, err := Collect(context.Background(), c, srv.URL+tc.Path, JSONField[int]("items"), tc.Strategy)
+			require.NoError(t, err)
+
+			expected := make([]int, 0, tc.Total)
+
+			for i := range tc.Total {
+				expected = append(expected, i)
+			}
+
+			assert.ElementsMatch(t, expected, items)
+			assert.Equal(t, tc.ExpectedRequests, requests.Load())
+		})
+	}
+}
+
+func TestPages(t *testing.T) {
+	t.Parallel()
+
+	var requests atomic.Int32
+
+	srv := newCollectionServer(t, 5, &requests)
+
+	c := client.NewClient()
+	defer c.Close()
+
+	var numbers []int
+
+	for page, err := range Pages(context.Background(), c, srv.URL+"/pages", JSONField[int]("items"), PageNumber{Size: 2}) {
+		require.NoError(t, err)
+
+		numbers = append(numbers, page.Number)
+
+		assert.Equal(t, strconv.Itoa(page.Number), page.URL.Query().Get("page"))
+	}
+
+	assert.Equal(t, []int{1, 2, 3}, numbers)
+}
+
+func TestItemsStopsEarly(t *testing.T) {
+	t.Parallel()
+
+	var requests atomic.Int32
+
+	srv := newCollectionServer(t, 10, &requests)
+
+	c := client.NewClient()
+	defer c.Close()
+
+	for item, err := range Items(context.Background(), c, srv.URL+"/pages", JSONField[int]("items"), PageNumber{Size: 2}) {
+		require.NoError(t, err)
+
+		if item == 2 {
+			break
+		}
+	}
+
+	assert.Equal(t, int32(2), requests.Load())
+}
+
+func TestCollectErrors(t *testing.T) {
+	t.Parallel()
+
+	var requests atomic.Int32
+
+	srv := newCollectionServer(t, 5, &requests)
+
+	c := client.NewClient()
+	defer c.Close()
+
+	_, err := Collect(context.Background(), c, srv.URL+"/fail", JSONField[int]("items"), LinkHeader{})
+	assert.True(t, client.IsStatus(err, http.StatusInternalServerError))
+
+	_, err = Collect(context.Background(), c, srv.URL+"/pages", JSON[int](), PageNumber{})
+	assert.ErrorContains(t, err, "decoding page")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err = Collect(ctx, c, srv.URL+"/pages", JSONField[int]("items"), PageNumber{})
+	assert.ErrorIs(t, err, context.Canceled)
+}
@@ -0,0 +1,166 @@
+package paginate
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/mt-sre/client"
+)
+
+// Strategy derives the requests for the pages of a collection.
+type Strategy interface {
+	// First returns the URL of the first page given the
+	// URL of the collection.
+	First(u *url.URL) *url.URL
+	// Next returns the URL of the page following the page fetched
+	// from u which held the given number of items, or false if the
+	// page was the last one.
+	Next(u *url.URL, res *http.Response, items int) (*url.URL, bool)
+}
+
+// LinkHeader follows the "next" link of RFC 8288 Link headers.
+type LinkHeader struct{}
+
+func (LinkHeader) First(u *url.URL) *url.URL {
+	return u
+}
+
+func (LinkHeader) Next(u *url.URL, res *http.Response, _ int) (*url.URL, bool) {
+	link, ok := client.ParseLinkHeader(res.Header.Values("Link")...).Rel("next")
+	if !ok {
+		return nil, false
+	}
+
+	next, err := link.Resolve(u)
+	if err != nil {
+		return nil, false
+	}
+
+	return next, true
+}
+
+// PageNumber requests pages by number and size as done by OCM. A
+// page holding fewer than Size items is considered to be the last.
+type PageNumber struct {
+	// PageParam names the page number parameter.
+	// Defaults to "page".
+	PageParam string
+	// SizeParam names the page size parameter.
+	// Defaults to "size".
+	SizeParam string
+	// Size is the number of items requested per page.
+	// Defaults to 100.
+	Size int
+	// Start is the number of the first page. Defaults to 1.
+	Start int
+}
+
+func (p PageNumber) First(u *url.URL) *url.URL {
+	p.defaults()
+
+	return withQuery(u, map[string]int{
+		p.PageParam: p.Start,
+		p.SizeParam: p.Size,
+	})
+}
+
+func (p PageNumber) Next(u *url.URL, _ *http.Response, items int) (*url.URL, bool) {
+	p.defaults()
+
+	if items < p.Size {
+		return nil, false
+	}
+
+	page, err := strconv.Atoi(u.Query().Get(p.PageParam))
+	if err != nil {
+		return nil, false
+	}
+
+	return withQuery(u, map[string]int{p.PageParam: page + 1}), true
+}
+
+func (p *PageNumber) defaults() {
+	if p.PageParam == "" {
+		p.PageParam = "page"
+	}
+
+	if p.SizeParam == "" {
+		p.SizeParam = "size"
+	}
+
+	if p.Size <= 0 {
+		p.Size = 100
+	}
+
+	if p.Start <= 0 {
+		p.Start = 1
+	}
+}
+
+// Offset requests pages by item offset and limit. A page
+// holding fewer than Limit items is considered to be the last.
+type Offset struct {
+	// OffsetParam names the offset parameter.
+	// Defaults to "offset".
+	OffsetParam string
+	// LimitParam names the page size parameter.
+	// Defaults to "limit".
+	LimitParam string
+	// Limit is the number of items requested per page.
+	// Defaults to 100.
+	Limit int
+}
+
+func (o Offset) First(u *url.URL) *url.URL {
+	o.defaults()
+
+	return withQuery(u, map[string]int{
+		o.OffsetParam: 0,
+		o.LimitParam:  o.Limit,
+	})
+}
+
+func (o Offset) Next(u *url.URL, _ *http.Response, items int) (*url.URL, bool) {
+	o.defaults()
+
+	if items < o.Limit {
+		return nil, false
+	}
+
+	offset, err := strconv.Atoi(u.Query().Get(o.OffsetParam))
+	if err != nil {
+		return nil, false
+	}
+
+	return withQuery(u, map[string]int{o.OffsetParam: offset + items}), true
+}
+
+func (o *Offset) defaults() {
+	if o.OffsetParam == "" {
+		o.OffsetParam = "offset"
+	}
+
+	if o.LimitParam == "" {
+		o.LimitParam = "limit"
+	}
+
+	if o.Limit <= 0 {
+		o.Limit = 100
+	}
+}
+
+// withQuery returns a copy of u with the given query parameters set.
+func withQuery(u *url.URL, params map[string]int) *url.URL {
+	out := *u
+
+	query := out.Query()
+
+	for key, val := range params {
+		query.Set(key, strconv.Itoa(val))
+	}
+
+	out.RawQuery = query.Encode()
+
+	return &out
+}
@@ -0,0 +1,202 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"runtime"
+
+	"golang.org/x/oauth2"
+)
+
+const defaultCallbackPath = "/callback"
+
+var errStateMismatch = errors.New("authorization response state does not match request")
+
+// AuthCodeFlow performs the OAUTH2 authorization code grant with PKCE
+// (RFC 7636) for interactive CLI logins. The user authenticates in
+// their browser which is redirected to a listener on the loopback
+// interface as described by RFC 8252.
+type AuthCodeFlow struct {
+	ClientID string
+	// ClientSecret is optional as CLIs are usually public clients.
+	ClientSecret string
+	AuthURL      string
+	TokenURL     string
+	Scopes       []string
+	// ListenAddr is the loopback address the redirect listener binds
+	// to. Defaults to 127.0.0.1 with a random port. Set a fixed port
+	// if the authorization server requires exact redirect URIs.
+	ListenAddr string
+	// CallbackPath is the path of the redirect URI.
+	// Defaults to "/callback".
+	CallbackPath string
+	// OpenBrowser opens the given URL in the user's browser.
+	// Defaults to using xdg-open, open or rundll32 depending on the
+	// platform. The URL is printed to os.Stderr in any case so that
+	// it can be opened manually.
+	OpenBrowser func(url string) error
+	// Store persists tokens so that the flow is only performed if no
+	// token which is valid or can be refreshed is stored. Optional.
+	Store TokenStore
+	// HTTPClient is used for requests to the authorization server.
+	// Defaults to http.DefaultClient.
+	HTTPClient *http.Client
+}
+
+// TokenSource performs the authorization code grant and returns a
+// TokenSource for use with WithTokenSource which refreshes tokens
+// using the obtained refresh token. ctx bounds the login including
+// the time the user takes to authenticate.
+func (f AuthCodeFlow) TokenSource(ctx context.Context) (oauth2.TokenSource, error) {
+	conf := &oauth2.Config{
+		ClientID:     f.ClientID,
+		ClientSecret: f.ClientSecret,
+		Endpoint: oauth2.Endpoint{
+			AuthURL:  f.AuthURL,
+			TokenURL: f.TokenURL,
+		},
+		Scopes: f.Scopes,
+	}
+
+	return loginTokenSource(ctx, conf, f.HTTPClient, f.Store, func(ctx context.Context) (*oauth2.Token, error) {
+		return f.login(ctx, conf)
+	})
+}
+
+type authCodeResult struct {
+	code string
+	err  error
+}
+
+func (f AuthCodeFlow) login(ctx context.Context, conf *oauth2.Config) (*oauth2.Token, error) {
+	addr := f.ListenAddr
+	if addr == "" {
+		addr = "127.0.0.1:0"
+	}
+
+	path := f.CallbackPath
+	if path == "" {
+		path = defaultCallbackPath
+	}
+
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("listening for authorization redirect: %w", err)
+	}
+
+	conf.RedirectURL = "http://" + l.Addr().String() + path
+
+	var (
+		state    = oauth2.GenerateVerifier()
+		verifier = oauth2.GenerateVerifier()
+		results  = make(chan authCodeResult, 1)
+	)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+		res := authCodeCallback(r, state)
+
+		if res.err != nil {
+			http.Error(w, "Login failed: "+res.err.Error(), http.StatusBadRequest)
+
+			// requests without the state, e.g. sent by other local
+			// processes or pages, must not end the login
+			if errors.Is(res.err, errStateMismatch) {
+				return
+			}
+		} else {
+			fmt.Fprintln(w, "Login complete, you may close this window.")
+		}
+
+		select {
+		case results <- res:
+		default:
+		}
+	})
+
+	srv := &http.Server{Handler: mux}
+
+	go srv.Serve(l)
+	defer srv.Close()
+
+	authURL := conf.AuthCodeURL(state, oauth2.S256ChallengeOption(verifier))
+
+	fmt.Fprintf(os.Stderr, "To log in, open %s\n", authURL)
+
+	open := f.OpenBrowser
+	if open == nil {
+		open = openBrowser
+	}
+
+	// the printed URL remains if the browser cannot be opened
+	_ = open(authURL)
+
+	var res authCodeResult
+
+	select {
+	case res = <-results:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	if res.err != nil {
+		return nil, res.err
+	}
+
+	token, err := conf.Exchange(ctx, res.code, oauth2.VerifierOption(verifier))
+	if err != nil {
+		return nil, fmt.Errorf("exchanging authorization code: %w", err)
+	}
+
+	return token, nil
+}
+
+// authCodeCallback extracts the authorization code
+// from the redirect of the authorization server.
+func authCodeCallback(r *http.Request, state string) authCodeResult {
+	query := r.URL.Query()
+
+	if query.Get("state") != state {
+		return authCodeResult{err: errStateMismatch}
+	}
+
+	if code := query.Get("error"); code != "" {
+		return authCodeResult{err: &oauth2.RetrieveError{
+			ErrorCode:        code,
+			ErrorDescription: query.Get("error_description"),
+			ErrorURI:         query.Get("error_uri"),
+		}}
+	}
+
+	if query.Get("code") == "" {
+		return authCodeResult{err: errors.New("authorization response lacks code")}
+	}
+
+	return authCodeResult{code: query.Get("code")}
+}
+
+func openBrowser(url string) error {
+	var cmd *exec.Cmd
+
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", url)
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", url)
+	default:
+		cmd = exec.Command("xdg-open", url)
+	}
+
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	go cmd.Wait()
+
+	return nil
+}
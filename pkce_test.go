@@ -0,0 +1,128 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+)
+
+func TestAuthCodeFlow(t *testing.T) {
+	t.Parallel()
+
+	var challenges sync.Map
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.FormValue("code") != "code" || !loaded(&challenges, oauth2.S256ChallengeFromVerifier(r.FormValue("code_verifier"))) {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = io.WriteString(w, `{"error":"invalid_grant"}`)
+
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"access_token":"access","refresh_token":"refresh","expires_in":300}`)
+	}))
+	t.Cleanup(srv.Close)
+
+	for name, tc := range map[string]struct {
+		Redirects   func(query url.Values) []url.Values
+		ExpectedErr error
+	}{
+		"success": {
+			Redirects: func(query url.Values) []url.Values {
+				return []url.Values{{"code": {"code"}, "state": {query.Get("state")}}}
+			},
+		},
+		"state mismatch": {
+			Redirects: func(query url.Values) []url.Values {
+				return []url.Values{
+					{"code": {"forged"}, "state": {"forged"}},
+					{"code": {"code"}, "state": {query.Get("state")}},
+				}
+			},
+		},
+		"state mismatch only": {
+			Redirects: func(url.Values) []url.Values {
+				return []url.Values{{"code": {"code"}, "state": {"forged"}}}
+			},
+			ExpectedErr: context.DeadlineExceeded,
+		},
+		"access denied": {
+			Redirects: func(query url.Values) []url.Values {
+				return []url.Values{{"error": {"access_denied"}, "state": {query.Get("state")}}}
+			},
+			ExpectedErr: &oauth2.RetrieveError{},
+		},
+	} {
+		tc := tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			flow := AuthCodeFlow{
+				ClientID: "cli",
+				AuthURL:  srv.URL + "/auth",
+				TokenURL: srv.URL + "/token",
+				// the browser follows the redirect of the authorization server
+				OpenBrowser: func(raw string) error {
+					u, err := url.Parse(raw)
+					require.NoError(t, err)
+
+					query := u.Query()
+					assert.Equal(t, "S256", query.Get("code_challenge_method"))
+					challenges.Store(query.Get("code_challenge"), struct{}{})
+
+					redirects := tc.Redirects(query)
+
+					go func() {
+						for _, redirect := range redirects {
+							res, err := http.Get(query.Get("redirect_uri") + "?" + redirect.Encode())
+							if err == nil {
+								res.Body.Close()
+							}
+						}
+					}()
+
+					return nil
+				},
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+
+			src, err := flow.TokenSource(ctx)
+
+			var retrieveErr *oauth2.RetrieveError
+
+			switch {
+			case errors.As(tc.ExpectedErr, &retrieveErr):
+				require.True(t, errors.As(err, &retrieveErr))
+				assert.Equal(t, "access_denied", retrieveErr.ErrorCode)
+			case tc.ExpectedErr != nil:
+				require.ErrorIs(t, err, tc.ExpectedErr)
+			default:
+				require.NoError(t, err)
+
+				token, err := src.Token()
+				require.NoError(t, err)
+				assert.Equal(t, "access", token.AccessToken)
+			}
+		})
+	}
+}
+
+func loaded(m *sync.Map, key string) bool {
+	_, ok := m.Load(key)
+
+	return ok
+}
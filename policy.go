@@ -18,6 +18,14 @@ type RetryPolicy interface {
 	IsStatusRetryableForMethod(string, int) bool
 }
 
+// RequestRetryPolicy may be implemented by a RetryPolicy to decide
+// whether a status code is retryable based on the complete request
+// rather than only its method. A RetryWrapper prefers this method
+// when it is available.
+type RequestRetryPolicy interface {
+	IsStatusRetryableForRequest(*http.Request, int) bool
+}
+
 // NewDefaultRetryPolicy returns the default retry policy
 // implementation.
 func NewDefaultRetryPolicy() DefaultRetryPolicy {
@@ -40,6 +48,17 @@ func (p DefaultRetryPolicy) IsErrorRetryable(err error) bool {
 }
 
 func (p DefaultRetryPolicy) IsStatusRetryableForMethod(method string, code int) bool {
+	return isStatusRetryable(code, isMethodIdempotent(method))
+}
+
+// IsStatusRetryableForRequest behaves like IsStatusRetryableForMethod
+// but additionally treats requests carrying an Idempotency-Key header
+// as idempotent.
+func (p DefaultRetryPolicy) IsStatusRetryableForRequest(req *http.Request, code int) bool {
+	return isStatusRetryable(code, isRequestIdempotent(req))
+}
+
+func isStatusRetryable(code int, idempotent bool) bool {
 	switch code {
 	case http.StatusRequestTimeout, // 408
 		http.StatusTooManyRequests,    // 429
@@ -48,7 +67,7 @@ func (p DefaultRetryPolicy) IsStatusRetryableForMethod(method string, code int)
 	case http.StatusInternalServerError, // 500
 		http.StatusBadGateway,     // 502
 		http.StatusGatewayTimeout: // 504
-		return isMethodIdempotent(method)
+		return idempotent
 	default:
 		return false
 	}
@@ -82,3 +101,10 @@ func isMethodIdempotent(method string) bool {
 		return true
 	}
 }
+
+// isRequestIdempotent reports whether req may be repeated safely
+// either because of its method or because it carries an
+// Idempotency-Key header.
+func isRequestIdempotent(req *http.Request) bool {
+	return isMethodIdempotent(req.Method) || req.Header.Get(IdempotencyKeyHeader) != ""
+}
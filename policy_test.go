@@ -197,3 +197,18 @@ func nonIdempotentHTTPMethods() []string {
 		http.MethodPost,
 	}
 }
+
+func TestDefaultRetryPolicyIsStatusRetryableForRequest(t *testing.T) {
+	t.Parallel()
+
+	require.Implements(t, new(RequestRetryPolicy), new(DefaultRetryPolicy))
+
+	policy := NewDefaultRetryPolicy()
+
+	req := testutils.MockRequest(t, http.MethodPost, nil)
+	require.False(t, policy.IsStatusRetryableForRequest(req, http.StatusBadGateway))
+
+	req.Header.Set(IdempotencyKeyHeader, "key")
+	require.True(t, policy.IsStatusRetryableForRequest(req, http.StatusBadGateway))
+	require.False(t, policy.IsStatusRetryableForRequest(req, http.StatusBadRequest))
+}
@@ -0,0 +1,224 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+)
+
+// PollFunc is invoked by Poll with each response whose representation
+// differs from the previous one. The response body is closed once the
+// function returns. Returning true stops polling.
+type PollFunc func(res *http.Response) (done bool, err error)
+
+// Poll repeatedly performs HTTP GET requests against the provided URL
+// and invokes fn whenever the resource changes. Conditional requests
+// using If-None-Match and If-Modified-Since avoid transferring
+// unchanged representations. Polls are spaced by the configured
+// interval or the delay requested through a Retry-After header,
+// whichever is longer. Transport errors and retryable statuses are
+// retried using the configured backoff while other responses outside
+// of the 2xx range are returned as *HTTPError. Poll returns once fn
+// reports completion or fails, the context is canceled or the backoff
+// gives up.
+func (c *Client) Poll(ctx context.Context, url string, fn PollFunc, opts ...PollOption) error {
+	var cfg PollConfig
+
+	cfg.Option(opts...)
+	cfg.Default()
+
+	var (
+		etag, lastModified string
+		bo                 = cfg.Backoff()
+	)
+
+	for {
+		header := make(http.Header)
+
+		if etag != "" {
+			header.Set("If-None-Match", etag)
+		} else if lastModified != "" {
+			header.Set("If-Modified-Since", lastModified)
+		}
+
+		reqOpts := append([]RequestOption{WithRequestHeaders(header)}, cfg.RequestOptions...)
+
+		res, err := c.Get(ctx, url, reqOpts...)
+		if err != nil && ctx.Err() != nil {
+			return err
+		}
+
+		status, resHeader := pollStatus(res, err)
+
+		var delay time.Duration
+
+		switch {
+		case status == http.StatusNotModified:
+			closeBody(res)
+
+			bo.Reset()
+			delay = cfg.Interval
+		case status >= 200 && status <= 299:
+			done, err := handlePoll(res, fn, &etag, &lastModified)
+			if done || err != nil {
+				return err
+			}
+
+			bo.Reset()
+			delay = cfg.Interval
+		case isStatusRetryable(status, true) || status == 0 && NewDefaultRetryPolicy().IsErrorRetryable(err):
+			if res != nil {
+				err = NewHTTPError(res)
+			}
+
+			if delay = bo.NextBackOff(); delay == backoff.Stop {
+				return fmt.Errorf("polling: %w", err)
+			}
+		case res != nil:
+			return NewHTTPError(res)
+		default:
+			return err
+		}
+
+		if after, ok := retryAfter(resHeader, time.Now()); ok {
+			delay = max(delay, after)
+		}
+
+		if err := sleepCtx(ctx, delay); err != nil {
+			return err
+		}
+	}
+}
+
+// handlePoll invokes fn if res holds a changed representation
+// and records the validators of res.
+func handlePoll(res *http.Response, fn PollFunc, etag, lastModified *string) (bool, error) {
+	defer res.Body.Close()
+
+	newETag := res.Header.Get("ETag")
+
+	// servers ignoring If-None-Match may repeat an unchanged representation
+	if newETag != "" && newETag == *etag {
+		return false, nil
+	}
+
+	*etag = newETag
+	*lastModified = res.Header.Get("Last-Modified")
+
+	return fn(res)
+}
+
+// pollStatus returns the status code and headers of the outcome
+// of a request taking into account errors reported by a Client
+// configured with WithErrorOnNon2xx.
+func pollStatus(res *http.Response, err error) (int, http.Header) {
+	if res != nil {
+		return res.StatusCode, res.Header
+	}
+
+	var httpErr *HTTPError
+
+	if errors.As(err, &httpErr) {
+		return httpErr.StatusCode, httpErr.Header
+	}
+
+	return 0, nil
+}
+
+func closeBody(res *http.Response) {
+	if res != nil {
+		res.Body.Close()
+	}
+}
+
+// retryAfter parses the Retry-After header given either as a
+// number of seconds or as an HTTP date relative to now.
+func retryAfter(header http.Header, now time.Time) (time.Duration, bool) {
+	val := header.Get("Retry-After")
+	if val == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.ParseUint(val, 10, 32); err == nil {
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	date, err := http.ParseTime(val)
+	if err != nil {
+		return 0, false
+	}
+
+	return max(date.Sub(now), 0), true
+}
+
+// sleepCtx waits for d to elapse or for ctx to be canceled.
+func sleepCtx(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+type PollConfig struct {
+	// Interval is the delay between successful polls.
+	// Defaults to 30 seconds.
+	Interval time.Duration
+	// Backoff generates the delays after failed polls.
+	// Defaults to ExponentialBackoffGenerator().
+	Backoff BackoffGenerator
+	// RequestOptions are applied to every request.
+	RequestOptions []RequestOption
+}
+
+func (c *PollConfig) Option(opts ...PollOption) {
+	for _, opt := range opts {
+		opt.ConfigurePoll(c)
+	}
+}
+
+func (c *PollConfig) Default() {
+	if c.Interval <= 0 {
+		c.Interval = 30 * time.Second
+	}
+
+	if c.Backoff == nil {
+		c.Backoff = ExponentialBackoffGenerator()
+	}
+}
+
+type PollOption interface {
+	ConfigurePoll(*PollConfig)
+}
+
+// WithPollInterval sets the delay between successful polls.
+type WithPollInterval time.Duration
+
+func (i WithPollInterval) ConfigurePoll(c *PollConfig) {
+	c.Interval = time.Duration(i)
+}
+
+// WithPollBackoff sets the BackoffGenerator used to
+// delay polls following failed requests.
+type WithPollBackoff BackoffGenerator
+
+func (b WithPollBackoff) ConfigurePoll(c *PollConfig) {
+	c.Backoff = BackoffGenerator(b)
+}
+
+// WithPollRequestOptions applies the given
+// RequestOptions to every request.
+type WithPollRequestOptions []RequestOption
+
+func (o WithPollRequestOptions) ConfigurePoll(c *PollConfig) {
+	c.RequestOptions = append(c.RequestOptions, o...)
+}
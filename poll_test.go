@@ -0,0 +1,234 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientPoll(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		ClientOptions []ClientOption
+	}{
+		"default": {},
+		"error on non-2xx": {
+			ClientOptions: []ClientOption{WithErrorOnNon2xx{}},
+		},
+	} {
+		tc := tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var (
+				mu          sync.Mutex
+				ifNoneMatch []string
+			)
+
+			// serves v1 twice, fails once, serves v2
+			// ignoring If-None-Match and then v3
+			responses := []func(w http.ResponseWriter, r *http.Request){
+				serveVersion("v1"),
+				serveVersion("v1"),
+				func(w http.ResponseWriter, _ *http.Request) {
+					w.Header().Set("Retry-After", "0")
+					w.WriteHeader(http.StatusServiceUnavailable)
+				},
+				serveVersion("v2"),
+				func(w http.ResponseWriter, _ *http.Request) {
+					w.Header().Set("ETag", `"v2"`)
+					io.WriteString(w, "v2")
+				},
+				serveVersion("v3"),
+			}
+
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				ifNoneMatch = append(ifNoneMatch, r.Header.Get("If-None-Match"))
+				attempt := len(ifNoneMatch) - 1
+				mu.Unlock()
+
+				responses[attempt](w, r)
+			}))
+			defer srv.Close()
+
+			c := NewClient(tc.ClientOptions...)
+			defer c.Close()
+
+			var versions []string
+
+			err := c.Poll(context.Background(), srv.URL, func(res *http.Response) (bool, error) {
+				body, err := io.ReadAll(res.Body)
+				if err != nil {
+					return false, err
+				}
+
+				versions = append(versions, string(body))
+
+				return string(body) == "v3", nil
+			},
+				WithPollInterval(time.Millisecond),
+				WithPollBackoff(NoBackoffGenerator()),
+			)
+			require.NoError(t, err)
+
+			assert.Equal(t, []string{"v1", "v2", "v3"}, versions)
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			assert.Equal(t, []string{"", `"v1"`, `"v1"`, `"v1"`, `"v2"`, `"v2"`}, ifNoneMatch)
+		})
+	}
+}
+
+// serveVersion serves the given version of a resource
+// responding with 304 to matching conditional requests.
+func serveVersion(version string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		etag := `"` + version + `"`
+
+		w.Header().Set("ETag", etag)
+
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+
+			return
+		}
+
+		io.WriteString(w, version)
+	}
+}
+
+func TestClientPollErrors(t *testing.T) {
+	t.Parallel()
+
+	errFailed := errors.New("failed")
+
+	for name, tc := range map[string]struct {
+		Handler        http.HandlerFunc
+		Func           PollFunc
+		Options        []PollOption
+		ExpectedErr    error
+		ExpectedStatus int
+	}{
+		"non-retryable status": {
+			Handler: func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(http.StatusNotFound)
+			},
+			ExpectedStatus: http.StatusNotFound,
+		},
+		"backoff exhausted": {
+			Handler: func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(http.StatusServiceUnavailable)
+			},
+			Options: []PollOption{
+				WithPollBackoff(func() backoff.BackOff {
+					return backoff.WithMaxRetries(&backoff.ZeroBackOff{}, 1)
+				}),
+			},
+			ExpectedStatus: http.StatusServiceUnavailable,
+		},
+		"callback error": {
+			Handler: serveVersion("v1"),
+			Func: func(*http.Response) (bool, error) {
+				return false, errFailed
+			},
+			ExpectedErr: errFailed,
+		},
+		"canceled": {
+			Handler: serveVersion("v1"),
+			Options: []PollOption{
+				WithPollInterval(time.Hour),
+			},
+			ExpectedErr: context.DeadlineExceeded,
+		},
+	} {
+		tc := tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			srv := httptest.NewServer(tc.Handler)
+			defer srv.Close()
+
+			c := NewClient()
+			defer c.Close()
+
+			fn := tc.Func
+			if fn == nil {
+				fn = func(*http.Response) (bool, error) { return false, nil }
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+			defer cancel()
+
+			err := c.Poll(ctx, srv.URL, fn, tc.Options...)
+
+			if tc.ExpectedStatus != 0 {
+				assert.True(t, IsStatus(err, tc.ExpectedStatus), err)
+			} else {
+				assert.ErrorIs(t, err, tc.ExpectedErr)
+			}
+		})
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	for name, tc := range map[string]struct {
+		Value         string
+		ExpectedDelay time.Duration
+		ExpectedOK    bool
+	}{
+		"missing": {},
+		"seconds": {
+			Value:         "120",
+			ExpectedDelay: 2 * time.Minute,
+			ExpectedOK:    true,
+		},
+		"date": {
+			Value:         now.Add(time.Minute).Format(http.TimeFormat),
+			ExpectedDelay: time.Minute,
+			ExpectedOK:    true,
+		},
+		"past date": {
+			Value:      now.Add(-time.Minute).Format(http.TimeFormat),
+			ExpectedOK: true,
+		},
+		"invalid": {
+			Value: "soon",
+		},
+	} {
+		tc := tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			header := make(http.Header)
+
+			if tc.Value != "" {
+				header.Set("Retry-After", tc.Value)
+			}
+
+			delay, ok := retryAfter(header, now)
+
+			assert.Equal(t, tc.ExpectedOK, ok)
+			assert.Equal(t, tc.ExpectedDelay, delay)
+		})
+	}
+}
@@ -0,0 +1,140 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http/httptrace"
+	"sync/atomic"
+	"time"
+)
+
+// ProgressPhase identifies the stage a request has reached.
+type ProgressPhase int
+
+const (
+	// PhaseDialing is reported when a new connection is being established.
+	PhaseDialing ProgressPhase = iota
+	// PhaseTLSHandshake is reported when a TLS handshake begins.
+	PhaseTLSHandshake
+	// PhaseWaitingForHeaders is reported once the request has been
+	// written and the client is waiting for the response.
+	PhaseWaitingForHeaders
+	// PhaseDownloading is reported as the response body is read.
+	PhaseDownloading
+	// PhaseRetrying is reported before a retry attempt is delayed.
+	PhaseRetrying
+	// PhaseDone is reported once the response body has been read
+	// completely.
+	PhaseDone
+)
+
+func (p ProgressPhase) String() string {
+	switch p {
+	case PhaseDialing:
+		return "dialing"
+	case PhaseTLSHandshake:
+		return "tls handshake"
+	case PhaseWaitingForHeaders:
+		return "waiting for headers"
+	case PhaseDownloading:
+		return "downloading"
+	case PhaseRetrying:
+		return "retrying"
+	case PhaseDone:
+		return "done"
+	default:
+		return "unknown"
+	}
+}
+
+// ProgressEvent describes the progress of a single request.
+type ProgressEvent struct {
+	Phase ProgressPhase
+	// Retry is the number of the upcoming retry for PhaseRetrying.
+	Retry int
+	// Delay is the time until the upcoming retry for PhaseRetrying.
+	Delay time.Duration
+	// BytesRead is the number of response body bytes read so far
+	// for PhaseDownloading and PhaseDone.
+	BytesRead int64
+	// BytesTotal is the expected size of the response body or -1
+	// if unknown.
+	BytesTotal int64
+}
+
+// ProgressFunc receives ProgressEvents for a request. It is called
+// synchronously from the goroutine performing the request and should
+// therefore return quickly.
+type ProgressFunc func(ProgressEvent)
+
+// WithProgress registers a ProgressFunc which receives events
+// describing the progress of a single request so that long running
+// requests can be reported to users.
+type WithProgress ProgressFunc
+
+func (p WithProgress) ConfigureRequest(c *RequestConfig) {
+	c.Progress = ProgressFunc(p)
+}
+
+type progressKey struct{}
+
+// ContextWithProgress returns a copy of ctx which carries the given
+// ProgressFunc. Requests made with the returned context report their
+// progress to fn including retries performed by a RetryWrapper. This
+// allows progress reporting when using a plain http.Client.
+func ContextWithProgress(ctx context.Context, fn ProgressFunc) context.Context {
+	ctx = context.WithValue(ctx, progressKey{}, fn)
+
+	return httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+		ConnectStart: func(string, string) {
+			fn(ProgressEvent{Phase: PhaseDialing})
+		},
+		TLSHandshakeStart: func() {
+			fn(ProgressEvent{Phase: PhaseTLSHandshake})
+		},
+		WroteRequest: func(httptrace.WroteRequestInfo) {
+			fn(ProgressEvent{Phase: PhaseWaitingForHeaders})
+		},
+	})
+}
+
+// progressFromContext returns the ProgressFunc carried by ctx
+// or nil if none is present.
+func progressFromContext(ctx context.Context) ProgressFunc {
+	fn, _ := ctx.Value(progressKey{}).(ProgressFunc)
+
+	return fn
+}
+
+type progressBody struct {
+	io.ReadCloser
+	fn    ProgressFunc
+	total int64
+	read  atomic.Int64
+	done  atomic.Bool
+}
+
+func (b *progressBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+
+	read := b.read.Add(int64(n))
+
+	if n > 0 {
+		b.fn(ProgressEvent{
+			Phase:      PhaseDownloading,
+			BytesRead:  read,
+			BytesTotal: b.total,
+		})
+	}
+
+	if errors.Is(err, io.EOF) && b.done.CompareAndSwap(false, true) {
+		b.fn(ProgressEvent{
+			Phase:      PhaseDone,
+			BytesRead:  read,
+			BytesTotal: b.total,
+		})
+	}
+
+	return n, err
+}
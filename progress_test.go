@@ -0,0 +1,81 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestWithProgress ensures that progress events are reported
+// for every phase of a request including retries.
+func TestWithProgress(t *testing.T) {
+	t.Parallel()
+
+	var calls atomic.Int32
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+
+			return
+		}
+
+		_, err := w.Write([]byte("test"))
+		assert.NoError(t, err)
+	}))
+	defer srv.Close()
+
+	client := NewClient(
+		WithWrapper{TransportWrapper: NewRetryWrapper(
+			WithBackoffGenerator(ConstantBackoffGenerator(time.Millisecond)),
+			WithMaxRetries(1),
+		)},
+	)
+
+	var events []ProgressEvent
+
+	res, err := client.Get(context.Background(), srv.URL, WithProgress(func(ev ProgressEvent) {
+		events = append(events, ev)
+	}))
+	require.NoError(t, err)
+
+	_, err = io.ReadAll(res.Body)
+	require.NoError(t, err)
+	require.NoError(t, res.Body.Close())
+
+	phases := make([]ProgressPhase, 0, len(events))
+	for _, ev := range events {
+		phases = append(phases, ev.Phase)
+	}
+
+	assert.Equal(t, []ProgressPhase{
+		PhaseDialing,
+		PhaseWaitingForHeaders,
+		PhaseRetrying,
+		PhaseWaitingForHeaders,
+		PhaseDownloading,
+		PhaseDone,
+	}, phases)
+
+	retry := events[2]
+	assert.Equal(t, 1, retry.Retry)
+	assert.Equal(t, time.Millisecond, retry.Delay)
+
+	done := events[len(events)-1]
+	assert.Equal(t, int64(4), done.BytesRead)
+	assert.Equal(t, int64(4), done.BytesTotal)
+}
+
+func TestProgressPhaseString(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "waiting for headers", PhaseWaitingForHeaders.String())
+	assert.Equal(t, "unknown", ProgressPhase(-1).String())
+}
@@ -0,0 +1,132 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+)
+
+// ProtobufContentType is the media type of protobuf encoded bodies.
+const ProtobufContentType = "application/x-protobuf"
+
+// ProtoCodec encodes and decodes protobuf messages. It decouples the
+// client from a particular protobuf runtime; protocodec.Codec is the
+// implementation for google.golang.org/protobuf.
+type ProtoCodec interface {
+	Marshal(v any) ([]byte, error)
+	Unmarshal(data []byte, v any) error
+}
+
+// ProtoMarshaler is implemented by messages generated with
+// gogo/protobuf and similar generators which encode themselves.
+type ProtoMarshaler interface {
+	Marshal() ([]byte, error)
+}
+
+// ProtoUnmarshaler is implemented by messages generated with
+// gogo/protobuf and similar generators which decode themselves.
+type ProtoUnmarshaler interface {
+	Unmarshal(data []byte) error
+}
+
+var errNoProtoCodec = errors.New("message does not encode itself; configure a ProtoCodec using WithProtoCodec")
+
+// selfCodec is the ProtoCodec used unless one is configured. It
+// relies on messages implementing ProtoMarshaler and ProtoUnmarshaler.
+type selfCodec struct{}
+
+func (selfCodec) Marshal(v any) ([]byte, error) {
+	m, ok := v.(ProtoMarshaler)
+	if !ok {
+		return nil, errNoProtoCodec
+	}
+
+	return m.Marshal()
+}
+
+func (selfCodec) Unmarshal(data []byte, v any) error {
+	m, ok := v.(ProtoUnmarshaler)
+	if !ok {
+		return errNoProtoCodec
+	}
+
+	return m.Unmarshal(data)
+}
+
+// WithProtoCodec configures the ProtoCodec used by a Client instance
+// to encode and decode the bodies of GetProto and PostProto, e.g.
+// protocodec.Codec for messages generated by protoc-gen-go. Without
+// it messages must implement ProtoMarshaler and ProtoUnmarshaler.
+type WithProtoCodec struct{ ProtoCodec }
+
+func (pc WithProtoCodec) ConfigureClient(c *ClientConfig) {
+	c.ProtoCodec = pc.ProtoCodec
+}
+
+// GetProto performs a HTTP GET request against the provided URL
+// asking for a protobuf encoded response and decodes the response
+// body into out.
+func (c *Client) GetProto(ctx context.Context, url string, out any, opts ...RequestOption) error {
+	return c.doNegotiated(ctx, http.MethodGet, url, nil, c.protoBodyCodec(), out, opts...)
+}
+
+// PostProto performs a HTTP POST request against the provided URL
+// with in encoded as protobuf as the request body and decodes the
+// response body into out unless it is nil.
+func (c *Client) PostProto(ctx context.Context, url string, in, out any, opts ...RequestOption) error {
+	body, err := c.protoCodec().Marshal(in)
+	if err != nil {
+		return fmt.Errorf("encoding request body: %w", err)
+	}
+
+	opts = append(opts, withDefaultRequestHeaders{
+		"Content-Type": []string{ProtobufContentType},
+	})
+
+	return c.doNegotiated(ctx, http.MethodPost, url, bytes.NewReader(body), c.protoBodyCodec(), out, opts...)
+}
+
+func (c *Client) protoCodec() ProtoCodec {
+	if c.cfg.ProtoCodec == nil {
+		return selfCodec{}
+	}
+
+	return c.cfg.ProtoCodec
+}
+
+func (c *Client) protoBodyCodec() bodyCodec {
+	codec := c.protoCodec()
+
+	return bodyCodec{
+		accept:  ProtobufContentType,
+		accepts: isProtobufMediaType,
+		decode: func(r io.Reader, v any) error {
+			data, err := io.ReadAll(r)
+			if err != nil {
+				return err
+			}
+
+			return codec.Unmarshal(data, v)
+		},
+	}
+}
+
+// isProtobufMediaType reports whether contentType denotes
+// protobuf using any of the commonly used media types.
+func isProtobufMediaType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+
+	switch mediaType {
+	case ProtobufContentType, "application/protobuf", "application/vnd.google.protobuf":
+		return true
+	default:
+		return false
+	}
+}
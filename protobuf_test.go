@@ -0,0 +1,152 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// protoTestMessage stands in for a generated message
+// encoding a single string field as its raw bytes.
+type protoTestMessage struct {
+	Name string
+}
+
+func (m *protoTestMessage) Marshal() ([]byte, error) { return []byte(m.Name), nil }
+
+func (m *protoTestMessage) Unmarshal(data []byte) error {
+	m.Name = string(data)
+
+	return nil
+}
+
+type protoTestCodec struct{}
+
+func (protoTestCodec) Marshal(v any) ([]byte, error) {
+	m, ok := v.(*protoTestMessage)
+	if !ok {
+		return nil, errors.New("unexpected message")
+	}
+
+	return []byte("codec:" + m.Name), nil
+}
+
+func (protoTestCodec) Unmarshal(data []byte, v any) error {
+	m, ok := v.(*protoTestMessage)
+	if !ok {
+		return errors.New("unexpected message")
+	}
+
+	m.Name = "codec:" + string(data)
+
+	return nil
+}
+
+func TestClientPostProto(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		Options                    []ClientOption
+		RequestOptions             []RequestOption
+		In                         any
+		ContentType                string
+		ExpectedRequestContentType string
+		ExpectedBody               string
+		Expected                   string
+		ExpectError                bool
+	}{
+		"self encoding message": {
+			In:           &protoTestMessage{Name: "request"},
+			ContentType:  ProtobufContentType,
+			ExpectedBody: "request",
+			Expected:     "response",
+		},
+		"codec": {
+			Options:      []ClientOption{WithProtoCodec{ProtoCodec: protoTestCodec{}}},
+			In:           &protoTestMessage{Name: "request"},
+			ContentType:  "application/protobuf",
+			ExpectedBody: "codec:request",
+			Expected:     "codec:response",
+		},
+		"explicit content type": {
+			RequestOptions: []RequestOption{
+				WithRequestHeaders{"Content-Type": {"application/vnd.google.protobuf"}},
+			},
+			In:                         &protoTestMessage{Name: "request"},
+			ContentType:                ProtobufContentType,
+			ExpectedRequestContentType: "application/vnd.google.protobuf",
+			ExpectedBody:               "request",
+			Expected:                   "response",
+		},
+		"no codec": {
+			In:          struct{}{},
+			ExpectError: true,
+		},
+		"unexpected content type": {
+			In:           &protoTestMessage{Name: "request"},
+			ContentType:  "application/json",
+			ExpectedBody: "request",
+			ExpectError:  true,
+		},
+	} {
+		tc := tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			expectedContentType := tc.ExpectedRequestContentType
+			if expectedContentType == "" {
+				expectedContentType = ProtobufContentType
+			}
+
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, []string{expectedContentType}, r.Header.Values("Content-Type"))
+				assert.Equal(t, ProtobufContentType, r.Header.Get("Accept"))
+
+				body, err := io.ReadAll(r.Body)
+				assert.NoError(t, err)
+				assert.Equal(t, tc.ExpectedBody, string(body))
+
+				w.Header().Set("Content-Type", tc.ContentType)
+				io.WriteString(w, "response")
+			}))
+			defer srv.Close()
+
+			c := NewClient(tc.Options...)
+			defer c.Close()
+
+			var out protoTestMessage
+
+			err := c.PostProto(context.Background(), srv.URL, tc.In, &out, tc.RequestOptions...)
+			if tc.ExpectError {
+				require.Error(t, err)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.Expected, out.Name)
+		})
+	}
+}
+
+func TestClientGetProto(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/x-protobuf; messageType=example.Cluster")
+		io.WriteString(w, "cluster")
+	}))
+	defer srv.Close()
+
+	var out protoTestMessage
+
+	require.NoError(t, NewClient().GetProto(context.Background(), srv.URL, &out))
+	assert.Equal(t, "cluster", out.Name)
+}
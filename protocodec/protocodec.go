@@ -0,0 +1,49 @@
+// Package protocodec provides a client.ProtoCodec backed by
+// google.golang.org/protobuf. It is kept apart from the client
+// package so that only programs exchanging protobuf bodies depend
+// on the protobuf runtime.
+//
+//	c := client.NewClient(
+//		client.WithProtoCodec{ProtoCodec: protocodec.Codec{}},
+//	)
+//
+//	var cluster v1.Cluster
+//
+//	err := c.GetProto(ctx, "https://api.example.com/clusters/abc", &cluster)
+package protocodec
+
+import (
+	"fmt"
+
+	"github.com/mt-sre/client"
+	"google.golang.org/protobuf/proto"
+)
+
+var _ client.ProtoCodec = Codec{}
+
+// Codec encodes and decodes messages implementing proto.Message
+// using MarshalOptions and UnmarshalOptions respectively.
+type Codec struct {
+	MarshalOptions   proto.MarshalOptions
+	UnmarshalOptions proto.UnmarshalOptions
+}
+
+// Marshal returns the wire encoding of v which must be a proto.Message.
+func (c Codec) Marshal(v any) ([]byte, error) {
+	m, ok := v.(proto.Message)
+	if !ok {
+		return nil, fmt.Errorf("%T is not a proto.Message", v)
+	}
+
+	return c.MarshalOptions.Marshal(m)
+}
+
+// Unmarshal decodes data into v which must be a proto.Message.
+func (c Codec) Unmarshal(data []byte, v any) error {
+	m, ok := v.(proto.Message)
+	if !ok {
+		return fmt.Errorf("%T is not a proto.Message", v)
+	}
+
+	return c.UnmarshalOptions.Unmarshal(data, m)
+}
@@ -0,0 +1,70 @@
+package protocodec
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mt-sre/client"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func TestCodec(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		In          any
+		ExpectError bool
+	}{
+		"message": {
+			In: wrapperspb.String("request"),
+		},
+		"not a message": {
+			In:          struct{}{},
+			ExpectError: true,
+		},
+	} {
+		tc := tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, err := io.ReadAll(r.Body)
+				assert.NoError(t, err)
+
+				var in wrapperspb.StringValue
+
+				assert.NoError(t, proto.Unmarshal(body, &in))
+				assert.Equal(t, "request", in.GetValue())
+
+				out, err := proto.Marshal(wrapperspb.String("response"))
+				assert.NoError(t, err)
+
+				w.Header().Set("Content-Type", client.ProtobufContentType)
+				_, _ = w.Write(out)
+			}))
+			defer srv.Close()
+
+			c := client.NewClient(client.WithProtoCodec{ProtoCodec: Codec{}})
+			defer c.Close()
+
+			var out wrapperspb.StringValue
+
+			err := c.PostProto(context.Background(), srv.URL, tc.In, &out)
+			if tc.ExpectError {
+				require.Error(t, err)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, "response", out.GetValue())
+		})
+	}
+}
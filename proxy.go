@@ -0,0 +1,79 @@
+package client
+
+import (
+	"net/http"
+	"net/url"
+)
+
+// WithProxyURL configures a Client instance to send all requests
+// through the proxy at the given URL. User information included
+// in the URL is sent to the proxy as basic authentication
+// credentials both for plain HTTP requests and CONNECT tunnels.
+type WithProxyURL struct{ *url.URL }
+
+func (p WithProxyURL) ConfigureClient(c *ClientConfig) {
+	c.Proxy = http.ProxyURL(p.URL)
+}
+
+// WithProxyFromEnvironment configures a Client instance to select
+// a proxy based on the HTTP_PROXY, HTTPS_PROXY and NO_PROXY
+// environment variables. This matches the behavior of
+// http.DefaultTransport and can be used to restore it after
+// another proxy option has been applied.
+type WithProxyFromEnvironment struct{}
+
+func (WithProxyFromEnvironment) ConfigureClient(c *ClientConfig) {
+	c.Proxy = http.ProxyFromEnvironment
+}
+
+// WithNoProxy configures a Client instance to bypass the configured
+// proxy for the given hosts. Entries follow the conventions of the
+// NO_PROXY environment variable: a host name matches itself and its
+// subdomains, a leading "." matches subdomains only, an IP address
+// or CIDR range matches destination IPs and "*" disables proxying
+// entirely. This option can be provided multiple times.
+type WithNoProxy []string
+
+func (np WithNoProxy) ConfigureClient(c *ClientConfig) {
+	c.NoProxy = append(c.NoProxy, np...)
+}
+
+// WithProxyConnectHeader configures a Client instance with headers
+// which are sent to the proxy in CONNECT requests, e.g. for
+// proxy authentication schemes other than basic authentication.
+type WithProxyConnectHeader http.Header
+
+func (h WithProxyConnectHeader) ConfigureClient(c *ClientConfig) {
+	if c.ProxyConnectHeader == nil {
+		c.ProxyConnectHeader = make(http.Header)
+	}
+
+	for key, vals := range h {
+		for _, val := range vals {
+			c.ProxyConnectHeader.Add(key, val)
+		}
+	}
+}
+
+// proxyFunc returns the function the transport uses to select a
+// proxy for each request taking NoProxy exclusions into account.
+func (c *ClientConfig) proxyFunc() func(*http.Request) (*url.URL, error) {
+	proxy := c.Proxy
+	if proxy == nil {
+		proxy = http.ProxyFromEnvironment
+	}
+
+	if len(c.NoProxy) == 0 {
+		return proxy
+	}
+
+	noProxy := c.NoProxy
+
+	return func(req *http.Request) (*url.URL, error) {
+		if matchHost(noProxy, req.URL.Hostname()) {
+			return nil, nil
+		}
+
+		return proxy(req)
+	}
+}
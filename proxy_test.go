@@ -0,0 +1,107 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestWithProxyURL ensures that requests are routed through the
+// configured proxy unless the destination is excluded.
+func TestWithProxyURL(t *testing.T) {
+	t.Parallel()
+
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Served-By", "target")
+	}))
+	t.Cleanup(target.Close)
+
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, pass, _ := parseProxyAuthorization(r)
+
+		w.Header().Set("X-Served-By", "proxy")
+		w.Header().Set("X-Proxy-User", user+":"+pass)
+		w.Header().Set("X-Proxy-Target", r.URL.String())
+	}))
+	t.Cleanup(proxy.Close)
+
+	proxyURL, err := url.Parse(proxy.URL)
+	require.NoError(t, err)
+
+	proxyURL.User = url.UserPassword("user", "pass")
+
+	targetURL, err := url.Parse(target.URL)
+	require.NoError(t, err)
+
+	for name, tc := range map[string]struct {
+		Options          []ClientOption
+		ExpectedServedBy string
+	}{
+		"proxied": {
+			Options:          []ClientOption{WithProxyURL{URL: proxyURL}},
+			ExpectedServedBy: "proxy",
+		},
+		"excluded host": {
+			Options: []ClientOption{
+				WithProxyURL{URL: proxyURL},
+				WithNoProxy{targetURL.Hostname()},
+			},
+			ExpectedServedBy: "target",
+		},
+		"excluded wildcard": {
+			Options: []ClientOption{
+				WithProxyURL{URL: proxyURL},
+				WithNoProxy{"*"},
+			},
+			ExpectedServedBy: "target",
+		},
+	} {
+		tc := tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			client := NewClient(tc.Options...)
+
+			res, err := client.Get(context.Background(), target.URL+"/path")
+			require.NoError(t, err)
+			defer res.Body.Close()
+
+			assert.Equal(t, tc.ExpectedServedBy, res.Header.Get("X-Served-By"))
+
+			if tc.ExpectedServedBy == "proxy" {
+				assert.Equal(t, "user:pass", res.Header.Get("X-Proxy-User"))
+				assert.Equal(t, target.URL+"/path", res.Header.Get("X-Proxy-Target"))
+			}
+		})
+	}
+}
+
+// TestWithProxyConnectHeader ensures that CONNECT headers are
+// applied to the constructed transport.
+func TestWithProxyConnectHeader(t *testing.T) {
+	t.Parallel()
+
+	client := NewClient(
+		WithProxyConnectHeader{"Proxy-Authorization": []string{"Bearer token"}},
+		WithProxyFromEnvironment{},
+	)
+
+	tp, ok := client.cfg.Transport.(*http.Transport)
+	require.True(t, ok, "expected transport to be *http.Transport")
+
+	assert.Equal(t, "Bearer token", tp.ProxyConnectHeader.Get("Proxy-Authorization"))
+}
+
+func parseProxyAuthorization(r *http.Request) (string, string, bool) {
+	req := http.Request{Header: http.Header{
+		"Authorization": r.Header.Values("Proxy-Authorization"),
+	}}
+
+	return req.BasicAuth()
+}
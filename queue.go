@@ -0,0 +1,461 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+)
+
+// ErrRequestQueued is returned by a StoreAndForwardWrapper for
+// requests which failed and were persisted for later delivery.
+var ErrRequestQueued = errors.New("request queued for later delivery")
+
+// QueuedRequest is a request persisted by a StoreAndForwardWrapper.
+// Credential headers are never persisted.
+type QueuedRequest struct {
+	// ID is the Idempotency-Key of the request.
+	ID         string      `json:"id"`
+	Method     string      `json:"method"`
+	URL        string      `json:"url"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       []byte      `json:"body,omitempty"`
+	EnqueuedAt time.Time   `json:"enqueuedAt"`
+	// Attempts is the number of times delivery was attempted.
+	Attempts int `json:"attempts"`
+}
+
+func (q QueuedRequest) request(ctx context.Context) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, q.Method, q.URL, bytes.NewReader(q.Body))
+	if err != nil {
+		return nil, fmt.Errorf("constructing request: %w", err)
+	}
+
+	req.Header = q.Header.Clone()
+	if req.Header == nil {
+		req.Header = make(http.Header)
+	}
+
+	return req, nil
+}
+
+func sortQueuedRequests(requests []QueuedRequest) {
+	slices.SortStableFunc(requests, func(a, b QueuedRequest) int {
+		return a.EnqueuedAt.Compare(b.EnqueuedAt)
+	})
+}
+
+// RequestQueue persists requests awaiting delivery. Implementations
+// must be safe for concurrent use.
+type RequestQueue interface {
+	// Put stores the request replacing any
+	// stored request with the same ID.
+	Put(ctx context.Context, req QueuedRequest) error
+	// List returns the stored requests
+	// ordered by EnqueuedAt.
+	List(ctx context.Context) ([]QueuedRequest, error)
+	// Remove deletes the request with the given ID.
+	Remove(ctx context.Context, id string) error
+}
+
+// NewMemoryRequestQueue returns a RequestQueue which holds
+// requests in memory for the lifetime of the process.
+func NewMemoryRequestQueue() *MemoryRequestQueue {
+	return &MemoryRequestQueue{}
+}
+
+type MemoryRequestQueue struct {
+	mu       sync.Mutex
+	requests []QueuedRequest
+}
+
+func (q *MemoryRequestQueue) Put(_ context.Context, req QueuedRequest) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if i := q.index(req.ID); i >= 0 {
+		q.requests[i] = req
+	} else {
+		q.requests = append(q.requests, req)
+	}
+
+	return nil
+}
+
+func (q *MemoryRequestQueue) List(context.Context) ([]QueuedRequest, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	requests := slices.Clone(q.requests)
+	sortQueuedRequests(requests)
+
+	return requests, nil
+}
+
+func (q *MemoryRequestQueue) Remove(_ context.Context, id string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if i := q.index(id); i >= 0 {
+		q.requests = slices.Delete(q.requests, i, i+1)
+	}
+
+	return nil
+}
+
+func (q *MemoryRequestQueue) index(id string) int {
+	return slices.IndexFunc(q.requests, func(req QueuedRequest) bool {
+		return req.ID == id
+	})
+}
+
+// NewFileRequestQueue returns a RequestQueue which stores each
+// request as a JSON file within dir so that queued requests
+// survive restarts of the process.
+func NewFileRequestQueue(dir string) *FileRequestQueue {
+	return &FileRequestQueue{dir: dir}
+}
+
+type FileRequestQueue struct {
+	dir string
+	mu  sync.Mutex
+}
+
+func (q *FileRequestQueue) Put(_ context.Context, req QueuedRequest) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if err := os.MkdirAll(q.dir, 0o700); err != nil {
+		return fmt.Errorf("creating queue directory: %w", err)
+	}
+
+	data, err := json.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("encoding queued request: %w", err)
+	}
+
+	f, err := os.CreateTemp(q.dir, ".queued-*")
+	if err != nil {
+		return fmt.Errorf("creating queued request: %w", err)
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+
+		return fmt.Errorf("writing queued request: %w", err)
+	}
+
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("writing queued request: %w", err)
+	}
+
+	// replace atomically so that a crash never leaves a partial request
+	if err := os.Rename(f.Name(), q.path(req.ID)); err != nil {
+		return fmt.Errorf("writing queued request: %w", err)
+	}
+
+	return nil
+}
+
+func (q *FileRequestQueue) List(context.Context) ([]QueuedRequest, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	entries, err := os.ReadDir(q.dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("reading queue directory: %w", err)
+	}
+
+	var requests []QueuedRequest
+
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+
+		data, err := os.ReadFile(filepath.Join(q.dir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("reading queued request: %w", err)
+		}
+
+		var req QueuedRequest
+
+		if err := json.Unmarshal(data, &req); err != nil {
+			return nil, fmt.Errorf("decoding queued request %q: %w", entry.Name(), err)
+		}
+
+		requests = append(requests, req)
+	}
+
+	sortQueuedRequests(requests)
+
+	return requests, nil
+}
+
+func (q *FileRequestQueue) Remove(_ context.Context, id string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if err := os.Remove(q.path(id)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("removing queued request: %w", err)
+	}
+
+	return nil
+}
+
+// path returns the file holding the request with the given ID
+// which is hashed as IDs are not restricted to safe file names.
+func (q *FileRequestQueue) path(id string) string {
+	sum := sha256.Sum256([]byte(id))
+
+	return filepath.Join(q.dir, hex.EncodeToString(sum[:])+".json")
+}
+
+// NewStoreAndForwardWrapper returns a TransportWrapper which persists
+// POST and PATCH requests carrying an Idempotency-Key header to the
+// given RequestQueue if they fail with a retryable error or status.
+// Such requests return an error wrapping ErrRequestQueued and are
+// delivered later by Replay or Run providing at-least-once delivery
+// for tooling operating on unreliable networks. The wrapper should be
+// applied after a RetryWrapper configured with WithIdempotencyKey so
+// that requests are only queued once retries have been exhausted.
+// Credential headers such as Authorization and Cookie are removed
+// before requests are persisted. When used by a Client, requests are
+// replayed through all of its wrappers so that credentials are applied
+// again by the wrappers which added them.
+func NewStoreAndForwardWrapper(queue RequestQueue, opts ...StoreAndForwardOption) *StoreAndForwardWrapper {
+	var cfg StoreAndForwardConfig
+
+	cfg.Option(opts...)
+	cfg.Default()
+
+	return &StoreAndForwardWrapper{
+		cfg:   cfg,
+		queue: queue,
+	}
+}
+
+type StoreAndForwardWrapper struct {
+	cfg   StoreAndForwardConfig
+	queue RequestQueue
+	rt    http.RoundTripper
+	// chain is the transport of the Client using
+	// the wrapper through which requests are replayed.
+	chain http.RoundTripper
+
+	// replaying serializes replays so that
+	// requests are delivered in order.
+	replaying sync.Mutex
+}
+
+func (w *StoreAndForwardWrapper) Wrap(rt http.RoundTripper) http.RoundTripper {
+	w.rt = rt
+
+	return w
+}
+
+func (w *StoreAndForwardWrapper) useChain(rt http.RoundTripper) {
+	w.chain = rt
+}
+
+// replayingKey marks requests replayed by the StoreAndForwardWrapper
+// it holds which must not be queued again when passing through it.
+type replayingKey struct{}
+
+func (w *StoreAndForwardWrapper) RoundTrip(req *http.Request) (*http.Response, error) {
+	id := req.Header.Get(IdempotencyKeyHeader)
+	if id == "" || isMethodIdempotent(req.Method) || req.Context().Value(replayingKey{}) == w {
+		return w.rt.RoundTrip(req)
+	}
+
+	body, err := readRequestBody(req)
+	if err != nil {
+		return nil, err
+	}
+
+	out := req.Clone(req.Context())
+	out.Body = io.NopCloser(bytes.NewReader(body))
+
+	res, err := w.rt.RoundTrip(out)
+	if !w.isQueueable(res, err) || req.Context().Err() != nil {
+		return res, err
+	}
+
+	if res != nil {
+		err = NewHTTPError(res)
+	}
+
+	queued := QueuedRequest{
+		ID:         id,
+		Method:     req.Method,
+		URL:        req.URL.String(),
+		Header:     withoutCredentials(req.Header),
+		Body:       body,
+		EnqueuedAt: time.Now(),
+		Attempts:   1,
+	}
+
+	if qErr := w.queue.Put(req.Context(), queued); qErr != nil {
+		return nil, errors.Join(err, fmt.Errorf("queueing request: %w", qErr))
+	}
+
+	contextLogger(w.cfg.Logger, req.Context()).Info("queued failed request",
+		"method", req.Method,
+		"url", redactURL(req.Context(), req.URL),
+		"error", err.Error(),
+	)
+
+	return nil, fmt.Errorf("%w: %w", ErrRequestQueued, err)
+}
+
+func (w *StoreAndForwardWrapper) isQueueable(res *http.Response, err error) bool {
+	if err != nil {
+		return NewDefaultRetryPolicy().IsErrorRetryable(err)
+	}
+
+	return isStatusRetryable(res.StatusCode, true)
+}
+
+// isUnauthorized reports whether a replayed request was refused
+// for lack of credentials which may be resolved by a later replay.
+func isUnauthorized(res *http.Response) bool {
+	return res != nil && (res.StatusCode == http.StatusUnauthorized || res.StatusCode == http.StatusForbidden)
+}
+
+// withoutCredentials returns a copy of h without sensitiveHeaders.
+func withoutCredentials(h http.Header) http.Header {
+	h = h.Clone()
+
+	for _, key := range sensitiveHeaders {
+		h.Del(key)
+	}
+
+	return h
+}
+
+// Replay attempts to deliver every queued request in order. Delivered
+// requests and requests rejected with non-retryable statuses other
+// than 401 and 403 are removed from the queue. Replay stops at the first request which
+// fails again, recording the attempt, and returns its error.
+func (w *StoreAndForwardWrapper) Replay(ctx context.Context) error {
+	w.replaying.Lock()
+	defer w.replaying.Unlock()
+
+	queued, err := w.queue.List(ctx)
+	if err != nil {
+		return fmt.Errorf("listing queued requests: %w", err)
+	}
+
+	for _, q := range queued {
+		if err := w.replay(ctx, q); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (w *StoreAndForwardWrapper) replay(ctx context.Context, q QueuedRequest) error {
+	req, err := q.request(ctx)
+	if err != nil {
+		return err
+	}
+
+	log := w.cfg.Logger.WithValues("method", q.Method, "url", redactURL(ctx, req.URL))
+
+	rt := w.rt
+	if w.chain != nil {
+		rt = w.chain
+		req = req.WithContext(context.WithValue(ctx, replayingKey{}, w))
+	}
+
+	res, err := rt.RoundTrip(req)
+	if w.isQueueable(res, err) || isUnauthorized(res) {
+		if res != nil {
+			err = NewHTTPError(res)
+		}
+
+		q.Attempts++
+
+		if qErr := w.queue.Put(ctx, q); qErr != nil {
+			return errors.Join(err, fmt.Errorf("queueing request: %w", qErr))
+		}
+
+		return fmt.Errorf("replaying request: %w", err)
+	}
+
+	if err != nil {
+		return fmt.Errorf("replaying request: %w", err)
+	}
+
+	res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		log.Info("dropped rejected queued request", "status", res.StatusCode, "attempts", q.Attempts+1)
+	}
+
+	if err := w.queue.Remove(ctx, q.ID); err != nil {
+		return fmt.Errorf("removing queued request: %w", err)
+	}
+
+	return nil
+}
+
+// Run replays queued requests every interval until ctx is canceled.
+// Failed replays are logged and retried with the next interval.
+func (w *StoreAndForwardWrapper) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := w.Replay(ctx); err != nil && ctx.Err() == nil {
+			w.cfg.Logger.Info("replaying queued requests failed", "error", err.Error())
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+type StoreAndForwardConfig struct {
+	Logger logr.Logger
+}
+
+func (c *StoreAndForwardConfig) Option(opts ...StoreAndForwardOption) {
+	for _, opt := range opts {
+		opt.ConfigureStoreAndForward(c)
+	}
+}
+
+func (c *StoreAndForwardConfig) Default() {
+	if c.Logger.GetSink() == nil {
+		c.Logger = logr.Discard()
+	}
+}
+
+type StoreAndForwardOption interface {
+	ConfigureStoreAndForward(*StoreAndForwardConfig)
+}
+
+func (l WithLogger) ConfigureStoreAndForward(c *StoreAndForwardConfig) {
+	c.Logger = l.Logger
+}
@@ -0,0 +1,312 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mt-sre/client/internal/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStoreAndForwardWrapperInterfaces(t *testing.T) {
+	t.Parallel()
+
+	require.Implements(t, new(http.RoundTripper), new(StoreAndForwardWrapper))
+
+	require.Implements(t, new(TransportWrapper), new(StoreAndForwardWrapper))
+
+	require.Implements(t, new(RequestQueue), new(MemoryRequestQueue))
+
+	require.Implements(t, new(RequestQueue), new(FileRequestQueue))
+}
+
+func TestRequestQueues(t *testing.T) {
+	t.Parallel()
+
+	for name, newQueue := range map[string]func(t *testing.T) RequestQueue{
+		"memory": func(*testing.T) RequestQueue { return NewMemoryRequestQueue() },
+		"file":   func(t *testing.T) RequestQueue { return NewFileRequestQueue(t.TempDir() + "/queue") },
+	} {
+		newQueue := newQueue
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := context.Background()
+			queue := newQueue(t)
+
+			requests, err := queue.List(ctx)
+			require.NoError(t, err)
+			assert.Empty(t, requests)
+
+			now := time.Now().UTC()
+
+			first := QueuedRequest{ID: "a/../b", Method: http.MethodPost, URL: "https://api.example.com", Body: []byte("1"), EnqueuedAt: now}
+			second := QueuedRequest{ID: "c", Method: http.MethodPatch, URL: "https://api.example.com", EnqueuedAt: now.Add(time.Second)}
+
+			require.NoError(t, queue.Put(ctx, second))
+			require.NoError(t, queue.Put(ctx, first))
+
+			first.Attempts = 2
+			require.NoError(t, queue.Put(ctx, first))
+
+			requests, err = queue.List(ctx)
+			require.NoError(t, err)
+			require.Len(t, requests, 2)
+			assert.Equal(t, "a/../b", requests[0].ID)
+			assert.Equal(t, 2, requests[0].Attempts)
+			assert.Equal(t, []byte("1"), requests[0].Body)
+			assert.Equal(t, "c", requests[1].ID)
+
+			require.NoError(t, queue.Remove(ctx, first.ID))
+			require.NoError(t, queue.Remove(ctx, "unknown"))
+
+			requests, err = queue.List(ctx)
+			require.NoError(t, err)
+			require.Len(t, requests, 1)
+			assert.Equal(t, "c", requests[0].ID)
+		})
+	}
+}
+
+func TestStoreAndForwardWrapper(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		Method         string
+		Key            string
+		StatusCode     int
+		Err            error
+		ExpectedQueued bool
+	}{
+		"transport error": {
+			Method:         http.MethodPost,
+			Key:            "key",
+			Err:            errors.New("connection refused"),
+			ExpectedQueued: true,
+		},
+		"retryable status": {
+			Method:         http.MethodPatch,
+			Key:            "key",
+			StatusCode:     http.StatusServiceUnavailable,
+			ExpectedQueued: true,
+		},
+		"non-retryable status": {
+			Method:     http.MethodPost,
+			Key:        "key",
+			StatusCode: http.StatusBadRequest,
+		},
+		"success": {
+			Method:     http.MethodPost,
+			Key:        "key",
+			StatusCode: http.StatusCreated,
+		},
+		"no idempotency key": {
+			Method: http.MethodPost,
+			Err:    errors.New("connection refused"),
+		},
+		"idempotent method": {
+			Method: http.MethodPut,
+			Key:    "key",
+			Err:    errors.New("connection refused"),
+		},
+	} {
+		tc := tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			next := Handler(func(req *http.Request) (*http.Response, error) {
+				body, err := io.ReadAll(req.Body)
+				require.NoError(t, err)
+				assert.Equal(t, "payload", string(body))
+
+				if tc.Err != nil {
+					return nil, tc.Err
+				}
+
+				return &http.Response{StatusCode: tc.StatusCode, Body: http.NoBody, Request: req}, nil
+			})
+
+			queue := NewMemoryRequestQueue()
+			rt := NewStoreAndForwardWrapper(queue).Wrap(next)
+
+			req := testutils.MockRequest(t, tc.Method, strings.NewReader("payload"))
+			req.Header.Set("Authorization", "Bearer token")
+			req.Header.Set("Cookie", "session=secret")
+
+			if tc.Key != "" {
+				req.Header.Set(IdempotencyKeyHeader, tc.Key)
+			}
+
+			res, err := rt.RoundTrip(req)
+
+			requests, qErr := queue.List(context.Background())
+			require.NoError(t, qErr)
+
+			if !tc.ExpectedQueued {
+				assert.Empty(t, requests)
+				assert.False(t, errors.Is(err, ErrRequestQueued))
+
+				if err == nil {
+					assert.Equal(t, tc.StatusCode, res.StatusCode)
+				}
+
+				return
+			}
+
+			assert.ErrorIs(t, err, ErrRequestQueued)
+
+			if tc.Err != nil {
+				assert.ErrorIs(t, err, tc.Err)
+			} else {
+				assert.True(t, IsStatus(err, tc.StatusCode))
+			}
+
+			require.Len(t, requests, 1)
+			assert.Equal(t, tc.Key, requests[0].ID)
+			assert.Equal(t, tc.Method, requests[0].Method)
+			assert.Equal(t, []byte("payload"), requests[0].Body)
+			assert.Equal(t, 1, requests[0].Attempts)
+			assert.Equal(t, http.Header{IdempotencyKeyHeader: {tc.Key}}, requests[0].Header)
+		})
+	}
+}
+
+func TestStoreAndForwardWrapperReplay(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	statuses := map[string]int{
+		"/delivered": http.StatusOK,
+		"/rejected":  http.StatusBadRequest,
+		"/failing":   http.StatusServiceUnavailable,
+		"/pending":   http.StatusOK,
+	}
+
+	var delivered []string
+
+	next := Handler(func(req *http.Request) (*http.Response, error) {
+		body, err := io.ReadAll(req.Body)
+		require.NoError(t, err)
+		assert.Equal(t, req.URL.Path, string(body))
+		assert.Equal(t, req.URL.Path, req.Header.Get(IdempotencyKeyHeader))
+
+		delivered = append(delivered, req.URL.Path)
+
+		return &http.Response{StatusCode: statuses[req.URL.Path], Body: http.NoBody, Request: req}, nil
+	})
+
+	queue := NewMemoryRequestQueue()
+
+	for _, path := range []string{"/delivered", "/rejected", "/failing", "/pending"} {
+		require.NoError(t, queue.Put(ctx, QueuedRequest{
+			ID:       path,
+			Method:   http.MethodPost,
+			URL:      "https://api.example.com" + path,
+			Header:   http.Header{IdempotencyKeyHeader: {path}},
+			Body:     []byte(path),
+			Attempts: 1,
+		}))
+	}
+
+	w := NewStoreAndForwardWrapper(queue)
+	w.Wrap(next)
+
+	err := w.Replay(ctx)
+	assert.True(t, IsStatus(err, http.StatusServiceUnavailable))
+	assert.Equal(t, []string{"/delivered", "/rejected", "/failing"}, delivered)
+
+	requests, err := queue.List(ctx)
+	require.NoError(t, err)
+	require.Len(t, requests, 2)
+	assert.Equal(t, "/failing", requests[0].ID)
+	assert.Equal(t, 2, requests[0].Attempts)
+
+	statuses["/failing"] = http.StatusOK
+
+	require.NoError(t, w.Replay(ctx))
+
+	requests, err = queue.List(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, requests)
+}
+
+func TestClientStoreAndForwardReplay(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	var (
+		token      = "expired"
+		statusCode = http.StatusServiceUnavailable
+		delivered  []string
+	)
+
+	next := Handler(func(req *http.Request) (*http.Response, error) {
+		delivered = append(delivered, req.Header.Get("Authorization"))
+
+		switch {
+		case statusCode != http.StatusOK:
+			return &http.Response{StatusCode: statusCode, Body: http.NoBody, Request: req}, nil
+		case req.Header.Get("Authorization") != "Bearer current":
+			return &http.Response{StatusCode: http.StatusUnauthorized, Body: http.NoBody, Request: req}, nil
+		default:
+			return &http.Response{StatusCode: http.StatusCreated, Body: http.NoBody, Request: req}, nil
+		}
+	})
+
+	auth := func(next Handler) Handler {
+		return func(req *http.Request) (*http.Response, error) {
+			req = req.Clone(req.Context())
+			req.Header.Set("Authorization", "Bearer "+token)
+
+			return next(req)
+		}
+	}
+
+	queue := NewMemoryRequestQueue()
+	w := NewStoreAndForwardWrapper(queue)
+
+	c := NewClient(
+		WithTransport{RoundTripper: next},
+		WithWrapper{TransportWrapper: w},
+		WithMiddleware{auth},
+	)
+
+	_, err := c.Post(ctx, "https://api.example.com/items", strings.NewReader("payload"),
+		WithRequestHeaders{IdempotencyKeyHeader: {"key"}},
+	)
+	require.ErrorIs(t, err, ErrRequestQueued)
+
+	requests, err := queue.List(ctx)
+	require.NoError(t, err)
+	require.Len(t, requests, 1)
+	assert.Empty(t, requests[0].Header.Get("Authorization"))
+
+	statusCode = http.StatusOK
+
+	err = w.Replay(ctx)
+	assert.True(t, IsStatus(err, http.StatusUnauthorized))
+
+	requests, err = queue.List(ctx)
+	require.NoError(t, err)
+	require.Len(t, requests, 1, "unauthorized requests must stay queued")
+	assert.Equal(t, 2, requests[0].Attempts)
+
+	token = "current"
+
+	require.NoError(t, w.Replay(ctx))
+
+	requests, err = queue.List(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, requests)
+	assert.Equal(t, []string{"Bearer expired", "Bearer expired", "Bearer current"}, delivered)
+}
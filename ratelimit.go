@@ -0,0 +1,347 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ErrRateLimitExceeded is returned by a RateLimitWrapper for requests
+// which would exceed the rate limit advertised by the server.
+var ErrRateLimitExceeded = errors.New("rate limit exceeded")
+
+const (
+	defaultRateLimitMaxDelay = time.Minute
+	// unixResetThreshold distinguishes X-RateLimit-Reset values
+	// given as Unix timestamps from those given in seconds.
+	unixResetThreshold = 1e9
+)
+
+// NewRateLimitWrapper returns a TransportWrapper which throttles
+// requests before the server responds with 429 Too Many Requests.
+// The remaining quota of each host is tracked using the RateLimit
+// header of draft-ietf-httpapi-ratelimit-headers, the RateLimit-*
+// headers of its earlier drafts and the X-RateLimit-* headers used by
+// e.g. GitHub and OCM as well as Retry-After headers of throttled
+// responses. Once the quota is exhausted requests wait for the limit
+// to reset or fail with ErrRateLimitExceeded if the reset is further
+// away than the configured maximum delay.
+func NewRateLimitWrapper(opts ...RateLimitOption) *RateLimitWrapper {
+	var cfg RateLimitConfig
+
+	cfg.Option(opts...)
+	cfg.Default()
+
+	return &RateLimitWrapper{
+		cfg:   cfg,
+		hosts: make(map[string]*rateLimitState),
+	}
+}
+
+type RateLimitWrapper struct {
+	cfg RateLimitConfig
+	rt  http.RoundTripper
+
+	mu    sync.Mutex
+	hosts map[string]*rateLimitState
+}
+
+type rateLimitState struct {
+	remaining int
+	reset     time.Time
+}
+
+func (w *RateLimitWrapper) Wrap(rt http.RoundTripper) http.RoundTripper {
+	w.rt = rt
+
+	return w
+}
+
+func (w *RateLimitWrapper) RoundTrip(req *http.Request) (*http.Response, error) {
+	host := req.URL.Host
+
+	for {
+		delay := w.reserve(host)
+		if delay <= 0 {
+			break
+		}
+
+		if delay > w.cfg.MaxDelay {
+			if req.Body != nil {
+				req.Body.Close()
+			}
+
+			return nil, fmt.Errorf("%w for %s: resets in %s", ErrRateLimitExceeded, host, delay.Round(time.Second))
+		}
+
+		if err := sleepClock(req.Context(), w.cfg.Clock, delay); err != nil {
+			if req.Body != nil {
+				req.Body.Close()
+			}
+
+			return nil, err
+		}
+	}
+
+	res, err := w.rt.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	w.observe(req.Context(), host, res)
+
+	return res, nil
+}
+
+// reserve consumes a unit of the quota of host returning
+// the time to wait for the limit to reset if exhausted.
+func (w *RateLimitWrapper) reserve(host string) time.Duration {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	state, ok := w.hosts[host]
+	if !ok {
+		return 0
+	}
+
+	now := w.cfg.Clock.Now()
+
+	if !now.Before(state.reset) {
+		delete(w.hosts, host)
+
+		return 0
+	}
+
+	if state.remaining <= w.cfg.Reserve {
+		return state.reset.Sub(now)
+	}
+
+	// account for requests in flight before their responses arrive
+	state.remaining--
+
+	return 0
+}
+
+// observe records the quota advertised by res.
+func (w *RateLimitWrapper) observe(ctx context.Context, host string, res *http.Response) {
+	now := w.cfg.Clock.Now()
+
+	remaining, reset, ok := parseRateLimit(res.Header, now)
+
+	if res.StatusCode == http.StatusTooManyRequests {
+		if after, found := retryAfter(res.Header, now); found {
+			remaining, reset, ok = 0, now.Add(after), true
+		}
+	}
+
+	if !ok || !reset.After(now) {
+		return
+	}
+
+	w.mu.Lock()
+
+	prev, hadPrev := w.hosts[host]
+	opened := remaining <= w.cfg.Reserve && (!hadPrev || prev.remaining > w.cfg.Reserve || !prev.reset.Equal(reset))
+
+	w.hosts[host] = &rateLimitState{
+		remaining: remaining,
+		reset:     reset,
+	}
+
+	w.mu.Unlock()
+
+	if opened {
+		emitEvent(ctx, &CircuitOpened{
+			Host:   host,
+			Until:  reset,
+			Reason: "rate limit exhausted",
+		})
+	}
+}
+
+// parseRateLimit returns the remaining quota and the time the quota
+// resets as advertised by the given headers.
+func parseRateLimit(header http.Header, now time.Time) (int, time.Time, bool) {
+	if vals := header.Values("RateLimit"); len(vals) > 0 {
+		if remaining, reset, ok := parseRateLimitField(vals); ok {
+			return remaining, now.Add(time.Duration(reset) * time.Second), true
+		}
+	}
+
+	for _, prefix := range []string{"RateLimit-", "X-RateLimit-"} {
+		remaining, err := strconv.Atoi(header.Get(prefix + "Remaining"))
+		if err != nil {
+			continue
+		}
+
+		reset, err := strconv.ParseInt(header.Get(prefix+"Reset"), 10, 64)
+		if err != nil {
+			continue
+		}
+
+		if reset >= unixResetThreshold {
+			return remaining, time.Unix(reset, 0), true
+		}
+
+		return remaining, now.Add(time.Duration(reset) * time.Second), true
+	}
+
+	return 0, time.Time{}, false
+}
+
+// parseRateLimitField parses the RateLimit field either as a list of
+// quota policies with "r" and "t" parameters, returning the most
+// restrictive one, or as a dictionary with "remaining" and "reset"
+// members as specified by earlier drafts.
+func parseRateLimitField(vals []string) (int, int64, bool) {
+	if list, err := ParseStructuredList(vals...); err == nil {
+		var (
+			remaining, reset int64
+			found            bool
+		)
+
+		for _, member := range list {
+			item, ok := member.(StructuredItem)
+			if !ok {
+				continue
+			}
+
+			r, rOK := item.Params.Get("r")
+			t, tOK := item.Params.Get("t")
+
+			rVal, rInt := r.(int64)
+			tVal, tInt := t.(int64)
+
+			if !rOK || !tOK || !rInt || !tInt {
+				continue
+			}
+
+			if !found || rVal < remaining || rVal == remaining && tVal > reset {
+				remaining, reset, found = rVal, tVal, true
+			}
+		}
+
+		if found {
+			return int(remaining), reset, true
+		}
+	}
+
+	dict, err := ParseStructuredDictionary(vals...)
+	if err != nil {
+		return 0, 0, false
+	}
+
+	remaining, rOK := structuredInt(dict, "remaining")
+	reset, tOK := structuredInt(dict, "reset")
+
+	return int(remaining), reset, rOK && tOK
+}
+
+func structuredInt(dict StructuredDictionary, key string) (int64, bool) {
+	member, ok := dict.Get(key)
+	if !ok {
+		return 0, false
+	}
+
+	item, ok := member.(StructuredItem)
+	if !ok {
+		return 0, false
+	}
+
+	val, ok := item.Value.(int64)
+
+	return val, ok
+}
+
+type RateLimitConfig struct {
+	// MaxDelay is the longest a request waits for the rate limit
+	// to reset before failing with ErrRateLimitExceeded. Defaults
+	// to one minute.
+	MaxDelay time.Duration
+	// Reserve is the part of the quota left unused e.g.
+	// for other clients sharing the same credentials.
+	Reserve int
+	// Clock is used to wait for rate limits to reset.
+	// Defaults to RealClock.
+	Clock Clock
+}
+
+func (c *RateLimitConfig) Option(opts ...RateLimitOption) {
+	for _, opt := range opts {
+		opt.ConfigureRateLimit(c)
+	}
+}
+
+func (c *RateLimitConfig) Default() {
+	if c.MaxDelay == 0 {
+		c.MaxDelay = defaultRateLimitMaxDelay
+	}
+
+	if c.Clock == nil {
+		c.Clock = RealClock{}
+	}
+}
+
+type RateLimitOption interface {
+	ConfigureRateLimit(*RateLimitConfig)
+}
+
+// WithRateLimitMaxDelay sets the longest a request waits for the
+// rate limit to reset. A negative value rejects requests instead
+// of delaying them.
+type WithRateLimitMaxDelay time.Duration
+
+func (d WithRateLimitMaxDelay) ConfigureRateLimit(c *RateLimitConfig) {
+	c.MaxDelay = time.Duration(d)
+}
+
+// WithRateLimitReserve leaves the given part of the quota
+// unused e.g. for other clients sharing the same credentials.
+type WithRateLimitReserve int
+
+func (r WithRateLimitReserve) ConfigureRateLimit(c *RateLimitConfig) {
+	c.Reserve = int(r)
+}
+
+// ForgetHost discards the quota tracked for host. The quota is
+// learned again from the next response of the host.
+func (w *RateLimitWrapper) ForgetHost(host string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	delete(w.hosts, host)
+}
+
+// RateLimitDebugState is the remaining quota of a host
+// reported by RateLimitWrapper.DebugState.
+type RateLimitDebugState struct {
+	Remaining int       `json:"remaining"`
+	Reset     time.Time `json:"reset"`
+}
+
+// DebugState returns the remaining quota of each host
+// which is currently being throttled.
+func (w *RateLimitWrapper) DebugState() any {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	now := w.cfg.Clock.Now()
+	hosts := make(map[string]RateLimitDebugState, len(w.hosts))
+
+	for host, state := range w.hosts {
+		if !now.Before(state.reset) {
+			continue
+		}
+
+		hosts[host] = RateLimitDebugState{
+			Remaining: state.remaining,
+			Reset:     state.reset,
+		}
+	}
+
+	return hosts
+}
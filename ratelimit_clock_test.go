@@ -0,0 +1,70 @@
+package client_test
+
+import (
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mt-sre/client"
+	"github.com/mt-sre/client/clienttest"
+	"github.com/mt-sre/client/internal/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestRateLimitWrapperClock ensures that exhausted quotas are
+// tracked and waited for on the configured clock.
+func TestRateLimitWrapperClock(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2020, time.January, 1, 8, 0, 0, 0, time.UTC)
+	clock := clienttest.NewFakeClock(start)
+
+	var requests atomic.Int32
+
+	w := client.NewRateLimitWrapper(client.WithClock{clock})
+
+	rt := w.Wrap(client.Handler(func(req *http.Request) (*http.Response, error) {
+		res := &http.Response{StatusCode: http.StatusOK, Header: make(http.Header), Body: http.NoBody, Request: req}
+
+		if requests.Add(1) == 1 {
+			res.Header.Set("RateLimit-Remaining", "0")
+			res.Header.Set("RateLimit-Reset", "30")
+		}
+
+		return res, nil
+	}))
+
+	res, err := rt.RoundTrip(testutils.MockRequest(t, http.MethodGet, nil))
+	require.NoError(t, err)
+	require.NoError(t, res.Body.Close())
+
+	state, ok := w.DebugState().(map[string]client.RateLimitDebugState)
+	require.True(t, ok)
+	require.Len(t, state, 1)
+
+	for _, host := range state {
+		assert.Equal(t, start.Add(30*time.Second), host.Reset)
+	}
+
+	done := make(chan error)
+
+	go func() {
+		res, err := rt.RoundTrip(testutils.MockRequest(t, http.MethodGet, nil))
+		if err == nil {
+			err = res.Body.Close()
+		}
+
+		done <- err
+	}()
+
+	clock.BlockUntil(1)
+	assert.EqualValues(t, 1, requests.Load(), "request must wait for the limit to reset")
+
+	clock.Advance(30 * time.Second)
+
+	require.NoError(t, <-done)
+	assert.EqualValues(t, 2, requests.Load())
+	assert.Empty(t, w.DebugState())
+}
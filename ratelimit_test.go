@@ -0,0 +1,208 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/mt-sre/client/internal/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateLimitWrapperInterfaces(t *testing.T) {
+	t.Parallel()
+
+	require.Implements(t, new(http.RoundTripper), new(RateLimitWrapper))
+
+	require.Implements(t, new(TransportWrapper), new(RateLimitWrapper))
+}
+
+func TestParseRateLimit(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	for name, tc := range map[string]struct {
+		Header            http.Header
+		ExpectedRemaining int
+		ExpectedReset     time.Time
+		ExpectedOK        bool
+	}{
+		"ratelimit list": {
+			Header:            http.Header{"Ratelimit": {`"default";r=50;t=30, "burst";r=5;t=1`}},
+			ExpectedRemaining: 5,
+			ExpectedReset:     now.Add(time.Second),
+			ExpectedOK:        true,
+		},
+		"ratelimit dictionary": {
+			Header:            http.Header{"Ratelimit": {"limit=100, remaining=50, reset=5"}},
+			ExpectedRemaining: 50,
+			ExpectedReset:     now.Add(5 * time.Second),
+			ExpectedOK:        true,
+		},
+		"ratelimit fields": {
+			Header: http.Header{
+				"Ratelimit-Remaining": {"7"},
+				"Ratelimit-Reset":     {"10"},
+			},
+			ExpectedRemaining: 7,
+			ExpectedReset:     now.Add(10 * time.Second),
+			ExpectedOK:        true,
+		},
+		"x-ratelimit unix reset": {
+			Header: http.Header{
+				"X-Ratelimit-Remaining": {"0"},
+				"X-Ratelimit-Reset":     {"1704067260"},
+			},
+			ExpectedRemaining: 0,
+			ExpectedReset:     time.Unix(1704067260, 0),
+			ExpectedOK:        true,
+		},
+		"x-ratelimit seconds reset": {
+			Header: http.Header{
+				"X-Ratelimit-Remaining": {"3"},
+				"X-Ratelimit-Reset":     {"60"},
+			},
+			ExpectedRemaining: 3,
+			ExpectedReset:     now.Add(time.Minute),
+			ExpectedOK:        true,
+		},
+		"missing reset": {
+			Header: http.Header{"X-Ratelimit-Remaining": {"3"}},
+		},
+		"none": {
+			Header: http.Header{},
+		},
+	} {
+		tc := tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			remaining, reset, ok := parseRateLimit(tc.Header, now)
+
+			require.Equal(t, tc.ExpectedOK, ok)
+
+			if ok {
+				assert.Equal(t, tc.ExpectedRemaining, remaining)
+				assert.True(t, tc.ExpectedReset.Equal(reset))
+			}
+		})
+	}
+}
+
+func TestRateLimitWrapper(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		Header      http.Header
+		StatusCode  int
+		Options     []RateLimitOption
+		ExpectedErr error
+		ExpectedRTs int
+	}{
+		"quota remaining": {
+			Header: http.Header{
+				"X-Ratelimit-Remaining": {"1"},
+				"X-Ratelimit-Reset":     {"3600"},
+			},
+			StatusCode:  http.StatusOK,
+			ExpectedRTs: 2,
+		},
+		"quota exhausted": {
+			Header: http.Header{
+				"X-Ratelimit-Remaining": {"0"},
+				"X-Ratelimit-Reset":     {"3600"},
+			},
+			StatusCode:  http.StatusOK,
+			ExpectedErr: ErrRateLimitExceeded,
+			ExpectedRTs: 1,
+		},
+		"reserve": {
+			Header: http.Header{
+				"Ratelimit-Remaining": {"2"},
+				"Ratelimit-Reset":     {"3600"},
+			},
+			StatusCode:  http.StatusOK,
+			Options:     []RateLimitOption{WithRateLimitReserve(2)},
+			ExpectedErr: ErrRateLimitExceeded,
+			ExpectedRTs: 1,
+		},
+		"retry after": {
+			Header:      http.Header{"Retry-After": {"3600"}},
+			StatusCode:  http.StatusTooManyRequests,
+			ExpectedErr: ErrRateLimitExceeded,
+			ExpectedRTs: 1,
+		},
+	} {
+		tc := tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var rts int
+
+			next := Handler(func(req *http.Request) (*http.Response, error) {
+				rts++
+
+				return &http.Response{
+					StatusCode: tc.StatusCode,
+					Header:     tc.Header,
+					Body:       http.NoBody,
+					Request:    req,
+				}, nil
+			})
+
+			rt := NewRateLimitWrapper(tc.Options...).Wrap(next)
+
+			_, err := rt.RoundTrip(testutils.MockRequest(t, http.MethodGet, nil))
+			require.NoError(t, err)
+
+			_, err = rt.RoundTrip(testutils.MockRequest(t, http.MethodGet, nil))
+
+			if tc.ExpectedErr != nil {
+				assert.ErrorIs(t, err, tc.ExpectedErr)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.Equal(t, tc.ExpectedRTs, rts)
+		})
+	}
+}
+
+func TestRateLimitWrapperDelays(t *testing.T) {
+	t.Parallel()
+
+	next := Handler(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody, Request: req}, nil
+	})
+
+	w := NewRateLimitWrapper()
+	rt := w.Wrap(next)
+
+	req := testutils.MockRequest(t, http.MethodGet, nil)
+
+	w.hosts[req.URL.Host] = &rateLimitState{reset: time.Now().Add(20 * time.Millisecond)}
+
+	start := time.Now()
+
+	_, err := rt.RoundTrip(req)
+	require.NoError(t, err)
+
+	assert.GreaterOrEqual(t, time.Since(start), 20*time.Millisecond)
+	assert.Empty(t, w.hosts)
+
+	w = NewRateLimitWrapper(WithRateLimitMaxDelay(2 * time.Hour))
+	rt = w.Wrap(next)
+
+	w.hosts[req.URL.Host] = &rateLimitState{reset: time.Now().Add(time.Hour)}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	_, err = rt.RoundTrip(req.WithContext(ctx))
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
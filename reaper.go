@@ -0,0 +1,145 @@
+package client
+
+import (
+	"net/http"
+	"sync"
+	"time"
+)
+
+// HostForgetter is implemented by TransportWrappers which keep per
+// host state such as caches or limiters. A Client configured with
+// WithIdleReaper calls ForgetHost for hosts which have not been used
+// within the configured TTL so that the state can be released.
+type HostForgetter interface {
+	ForgetHost(host string)
+}
+
+// WithIdleReaper configures a Client instance to release state held
+// for hosts which have not been used within TTL. Idle connections to
+// such hosts are closed and TransportWrappers implementing
+// HostForgetter are notified. This keeps long-running multi-tenant
+// services from accumulating unbounded per-host state. The reaper
+// runs in the background until the Client is closed.
+type WithIdleReaper struct {
+	TTL time.Duration
+	// Interval is the period at which idle hosts are
+	// reaped. Defaults to half of TTL.
+	Interval time.Duration
+}
+
+func (ir WithIdleReaper) ConfigureClient(c *ClientConfig) {
+	c.IdleHostTTL = ir.TTL
+	c.IdleReapInterval = ir.Interval
+}
+
+type idleReaper struct {
+	ttl        time.Duration
+	interval   time.Duration
+	rt         http.RoundTripper
+	transport  http.RoundTripper
+	forgetters []HostForgetter
+
+	mu       sync.Mutex
+	lastUsed map[string]time.Time
+
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+func newIdleReaper(cfg *ClientConfig, rt http.RoundTripper) *idleReaper {
+	r := &idleReaper{
+		ttl:       cfg.IdleHostTTL,
+		interval:  cfg.IdleReapInterval,
+		rt:        rt,
+		transport: cfg.Transport,
+		lastUsed:  make(map[string]time.Time),
+		stop:      make(chan struct{}),
+	}
+
+	if r.interval <= 0 {
+		r.interval = r.ttl / 2
+	}
+
+	for _, w := range cfg.Wrappers {
+		if f, ok := w.(HostForgetter); ok {
+			r.forgetters = append(r.forgetters, f)
+		}
+	}
+
+	if cfg.dnsDialer != nil {
+		r.forgetters = append(r.forgetters, cfg.dnsDialer)
+	}
+
+	if cfg.dohResolver != nil {
+		r.forgetters = append(r.forgetters, cfg.dohResolver)
+	}
+
+	return r
+}
+
+func (r *idleReaper) RoundTrip(req *http.Request) (*http.Response, error) {
+	r.mu.Lock()
+	r.lastUsed[req.URL.Host] = time.Now()
+	r.mu.Unlock()
+
+	return r.rt.RoundTrip(req)
+}
+
+func (r *idleReaper) run() {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case now := <-ticker.C:
+			r.reap(now)
+		case <-r.stop:
+			return
+		}
+	}
+}
+
+// reap releases the state of hosts last used before now-ttl.
+func (r *idleReaper) reap(now time.Time) {
+	var stale []string
+
+	r.mu.Lock()
+
+	for host, lastUsed := range r.lastUsed {
+		if now.Sub(lastUsed) >= r.ttl {
+			stale = append(stale, host)
+
+			delete(r.lastUsed, host)
+		}
+	}
+
+	idle := len(r.lastUsed) == 0
+
+	r.mu.Unlock()
+
+	if len(stale) == 0 {
+		return
+	}
+
+	for _, host := range stale {
+		for _, f := range r.forgetters {
+			f.ForgetHost(host)
+		}
+	}
+
+	// transports constructed by the Client expire idle connections
+	// after the TTL themselves; others can only be closed in bulk
+	if idle {
+		closeIdleConnections(r.transport)
+	}
+}
+
+func (r *idleReaper) Close() {
+	r.stopOnce.Do(func() { close(r.stop) })
+}
+
+func closeIdleConnections(rt http.RoundTripper) {
+	if closer, ok := rt.(interface{ CloseIdleConnections() }); ok {
+		closer.CloseIdleConnections()
+	}
+}
@@ -0,0 +1,162 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mt-sre/client/internal/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type closingRoundTripper struct {
+	testutils.MockRoundTripper
+}
+
+func (rt *closingRoundTripper) CloseIdleConnections() {
+	rt.Called()
+}
+
+type hostRecordingWrapper struct {
+	rt http.RoundTripper
+
+	mu        sync.Mutex
+	forgotten []string
+}
+
+func (w *hostRecordingWrapper) Wrap(rt http.RoundTripper) http.RoundTripper {
+	w.rt = rt
+
+	return w
+}
+
+func (w *hostRecordingWrapper) RoundTrip(req *http.Request) (*http.Response, error) {
+	return w.rt.RoundTrip(req)
+}
+
+func (w *hostRecordingWrapper) ForgetHost(host string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.forgotten = append(w.forgotten, host)
+}
+
+func TestIdleReaper(t *testing.T) {
+	t.Parallel()
+
+	var rt closingRoundTripper
+	rt.
+		On("RoundTrip", mock.Anything).
+		Return(&http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(bytes.NewBuffer(nil)),
+		}, nil)
+	rt.On("CloseIdleConnections")
+
+	var wrapper hostRecordingWrapper
+
+	client := NewClient(
+		WithTransport{RoundTripper: &rt},
+		WithWrapper{TransportWrapper: &wrapper},
+		WithIdleReaper{TTL: time.Minute, Interval: time.Hour},
+	)
+	t.Cleanup(client.Close)
+
+	for _, url := range []string{"http://a.example.com", "http://b.example.com"} {
+		res, err := client.Get(context.Background(), url)
+		require.NoError(t, err)
+		require.NoError(t, res.Body.Close())
+	}
+
+	now := time.Now()
+
+	client.reaper.reap(now)
+	assert.Empty(t, wrapper.forgotten)
+
+	client.reaper.mu.Lock()
+	client.reaper.lastUsed["a.example.com"] = now.Add(-2 * time.Minute)
+	client.reaper.mu.Unlock()
+
+	client.reaper.reap(now)
+	assert.Equal(t, []string{"a.example.com"}, wrapper.forgotten)
+	rt.AssertNotCalled(t, "CloseIdleConnections")
+
+	client.reaper.reap(now.Add(2 * time.Minute))
+	assert.ElementsMatch(t, []string{"a.example.com", "b.example.com"}, wrapper.forgotten)
+	rt.AssertNumberOfCalls(t, "CloseIdleConnections", 1)
+}
+
+func TestWithIdleReaperTransport(t *testing.T) {
+	t.Parallel()
+
+	client := NewClient(WithIdleReaper{TTL: 30 * time.Second})
+	t.Cleanup(client.Close)
+
+	tp, ok := client.cfg.Transport.(*http.Transport)
+	require.True(t, ok)
+
+	assert.Equal(t, 30*time.Second, tp.IdleConnTimeout)
+	assert.Equal(t, 15*time.Second, client.reaper.interval)
+}
+
+func TestIdleReaperForgetsHostState(t *testing.T) {
+	t.Parallel()
+
+	var queries atomic.Int32
+
+	doh := newDoHServer(t, &queries)
+
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Ratelimit-Remaining", "10")
+		w.Header().Set("X-Ratelimit-Reset", "3600")
+	}))
+	defer target.Close()
+
+	u, err := url.Parse(target.URL)
+	require.NoError(t, err)
+
+	limiter := NewRateLimitWrapper()
+
+	client := NewClient(
+		WithDNSOverHTTPS{URL: doh.URL, DisableFallback: true},
+		WithWrapper{TransportWrapper: limiter},
+		WithIdleReaper{TTL: time.Minute, Interval: time.Hour},
+	)
+	t.Cleanup(client.Close)
+
+	host := net.JoinHostPort("service.test", u.Port())
+
+	res, err := client.Get(context.Background(), "http://"+host)
+	require.NoError(t, err)
+	require.NoError(t, res.Body.Close())
+
+	resolver := client.cfg.dohResolver
+
+	resolver.mu.Lock()
+	assert.Contains(t, resolver.cache, "service.test")
+	resolver.mu.Unlock()
+
+	limiter.mu.Lock()
+	assert.Contains(t, limiter.hosts, host)
+	limiter.mu.Unlock()
+
+	client.reaper.reap(time.Now().Add(2 * time.Minute))
+
+	resolver.mu.Lock()
+	assert.Empty(t, resolver.cache)
+	resolver.mu.Unlock()
+
+	limiter.mu.Lock()
+	assert.Empty(t, limiter.hosts)
+	limiter.mu.Unlock()
+}
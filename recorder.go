@@ -0,0 +1,366 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"unicode/utf8"
+)
+
+// ErrNoInteraction is returned by a RecorderWrapper in replay mode
+// when the cassette holds no unused interaction matching a request.
+var ErrNoInteraction = errors.New("no recorded interaction matches request")
+
+// RecorderMode selects whether a RecorderWrapper records
+// interactions or replays previously recorded ones.
+type RecorderMode int
+
+const (
+	// RecorderModeReplay answers requests from the cassette
+	// without sending them.
+	RecorderModeReplay RecorderMode = iota
+	// RecorderModeRecord sends requests and records the
+	// interactions to the cassette replacing its contents.
+	RecorderModeRecord
+)
+
+// NewRecorderWrapper returns a TransportWrapper which records
+// request/response pairs to a cassette file and replays them in
+// tests so that integration tests can run without access to the
+// real API. Credentials in headers and query parameters are
+// redacted before interactions are written; additional headers
+// can be redacted with WithRedactedHeaders. In replay mode each
+// recorded interaction is used once, in the order recorded, for
+// a request with the same method, redacted URL and body.
+func NewRecorderWrapper(opts ...RecorderOption) *RecorderWrapper {
+	var cfg RecorderConfig
+
+	cfg.Option(opts...)
+
+	return &RecorderWrapper{
+		cfg: cfg,
+	}
+}
+
+type RecorderWrapper struct {
+	cfg RecorderConfig
+	rt  http.RoundTripper
+
+	mu      sync.Mutex
+	loaded  bool
+	loadErr error
+	tape    Cassette
+	used    []bool
+}
+
+func (w *RecorderWrapper) Wrap(rt http.RoundTripper) http.RoundTripper {
+	w.rt = rt
+
+	return w
+}
+
+func (w *RecorderWrapper) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, err := readRequestBody(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if w.cfg.Mode == RecorderModeRecord {
+		return w.record(req, body)
+	}
+
+	return w.replay(req, body)
+}
+
+func (w *RecorderWrapper) record(req *http.Request, body []byte) (*http.Response, error) {
+	out := req.Clone(req.Context())
+
+	if body != nil {
+		out.Body = io.NopCloser(bytes.NewReader(body))
+		out.GetBody = func() (io.ReadCloser, error) {
+			return io.NopCloser(bytes.NewReader(body)), nil
+		}
+	}
+
+	res, err := w.rt.RoundTrip(out)
+	if err != nil {
+		return res, err
+	}
+
+	resBody, err := io.ReadAll(res.Body)
+	res.Body.Close()
+
+	if err != nil {
+		return nil, fmt.Errorf("reading response body: %w", err)
+	}
+
+	res.Body = io.NopCloser(bytes.NewReader(resBody))
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.tape.Interactions = append(w.tape.Interactions, Interaction{
+		Request: RecordedRequest{
+			Method: req.Method,
+			URL:    redactURL(req.Context(), req.URL),
+			Header: w.cfg.redactHeader(req.Context(), req.Header),
+			Body:   newRecordedBody(body),
+		},
+		Response: RecordedResponse{
+			StatusCode: res.StatusCode,
+			Header:     w.cfg.redactHeader(req.Context(), res.Header),
+			Body:       newRecordedBody(resBody),
+		},
+	})
+
+	if err := w.save(); err != nil {
+		res.Body.Close()
+
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func (w *RecorderWrapper) replay(req *http.Request, body []byte) (*http.Response, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if err := w.load(); err != nil {
+		return nil, err
+	}
+
+	url := redactURL(req.Context(), req.URL)
+
+	for i, interaction := range w.tape.Interactions {
+		if w.used[i] {
+			continue
+		}
+
+		recorded := interaction.Request
+
+		if recorded.Method != req.Method || recorded.URL != url || !bytes.Equal(recorded.Body.bytes(), body) {
+			continue
+		}
+
+		w.used[i] = true
+
+		data := interaction.Response.Body.bytes()
+
+		header := interaction.Response.Header.Clone()
+		if header == nil {
+			header = make(http.Header)
+		}
+
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", interaction.Response.StatusCode, http.StatusText(interaction.Response.StatusCode)),
+			StatusCode:    interaction.Response.StatusCode,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        header,
+			Body:          io.NopCloser(bytes.NewReader(data)),
+			ContentLength: int64(len(data)),
+			Request:       req,
+		}, nil
+	}
+
+	return nil, fmt.Errorf("%w: %s %s", ErrNoInteraction, req.Method, url)
+}
+
+func (w *RecorderWrapper) load() error {
+	if w.loaded {
+		return w.loadErr
+	}
+
+	w.loaded = true
+
+	data, err := os.ReadFile(w.cfg.Cassette)
+	if err != nil {
+		w.loadErr = fmt.Errorf("reading cassette: %w", err)
+
+		return w.loadErr
+	}
+
+	if err := json.Unmarshal(data, &w.tape); err != nil {
+		w.loadErr = fmt.Errorf("decoding cassette: %w", err)
+
+		return w.loadErr
+	}
+
+	w.used = make([]bool, len(w.tape.Interactions))
+
+	return nil
+}
+
+// save atomically replaces the cassette with the recorded interactions.
+func (w *RecorderWrapper) save() error {
+	dir := filepath.Dir(w.cfg.Cassette)
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("creating cassette directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(w.tape, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding cassette: %w", err)
+	}
+
+	f, err := os.CreateTemp(dir, filepath.Base(w.cfg.Cassette)+".*")
+	if err != nil {
+		return fmt.Errorf("creating cassette: %w", err)
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		f.Close()
+
+		return fmt.Errorf("writing cassette: %w", err)
+	}
+
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("writing cassette: %w", err)
+	}
+
+	if err := os.Rename(f.Name(), w.cfg.Cassette); err != nil {
+		return fmt.Errorf("writing cassette: %w", err)
+	}
+
+	return nil
+}
+
+// readRequestBody reads and closes the body of req
+// returning nil if the request has no body.
+func readRequestBody(req *http.Request) ([]byte, error) {
+	if !hasBody(req) {
+		return nil, nil
+	}
+
+	body, err := io.ReadAll(req.Body)
+	req.Body.Close()
+
+	if err != nil {
+		return nil, fmt.Errorf("reading request body: %w", err)
+	}
+
+	return body, nil
+}
+
+// Cassette holds the interactions recorded by a RecorderWrapper.
+type Cassette struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Interaction is a recorded request/response pair.
+type Interaction struct {
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+}
+
+// RecordedRequest is a request as stored in a Cassette.
+type RecordedRequest struct {
+	Method string       `json:"method"`
+	URL    string       `json:"url"`
+	Header http.Header  `json:"header,omitempty"`
+	Body   RecordedBody `json:"body,omitempty"`
+}
+
+// RecordedResponse is a response as stored in a Cassette.
+type RecordedResponse struct {
+	StatusCode int          `json:"statusCode"`
+	Header     http.Header  `json:"header,omitempty"`
+	Body       RecordedBody `json:"body,omitempty"`
+}
+
+// RecordedBody is a message body as stored in a Cassette. Bodies
+// which are not valid UTF-8 are stored base64 encoded.
+type RecordedBody struct {
+	Data     string `json:"data,omitempty"`
+	Encoding string `json:"encoding,omitempty"`
+}
+
+func newRecordedBody(data []byte) RecordedBody {
+	if utf8.Valid(data) {
+		return RecordedBody{Data: string(data)}
+	}
+
+	return RecordedBody{
+		Data:     base64.StdEncoding.EncodeToString(data),
+		Encoding: "base64",
+	}
+}
+
+func (b RecordedBody) bytes() []byte {
+	if b.Encoding == "base64" {
+		data, _ := base64.StdEncoding.DecodeString(b.Data)
+
+		return data
+	}
+
+	if b.Data == "" {
+		return nil
+	}
+
+	return []byte(b.Data)
+}
+
+type RecorderConfig struct {
+	Mode RecorderMode
+	// Cassette is the path of the file
+	// interactions are stored in.
+	Cassette string
+	// RedactedHeaders are replaced in recorded interactions
+	// in addition to the default sensitive headers.
+	RedactedHeaders []string
+}
+
+func (c *RecorderConfig) Option(opts ...RecorderOption) {
+	for _, opt := range opts {
+		opt.ConfigureRecorder(c)
+	}
+}
+
+func (c *RecorderConfig) redactHeader(ctx context.Context, h http.Header) http.Header {
+	out := redactHeader(ctx, h)
+
+	for _, key := range c.RedactedHeaders {
+		if _, ok := out[http.CanonicalHeaderKey(key)]; ok {
+			out[http.CanonicalHeaderKey(key)] = []string{redacted}
+		}
+	}
+
+	return out
+}
+
+type RecorderOption interface {
+	ConfigureRecorder(*RecorderConfig)
+}
+
+// WithCassette configures a RecorderWrapper to store interactions
+// in the file at Path using the given Mode.
+type WithCassette struct {
+	Path string
+	Mode RecorderMode
+}
+
+func (wc WithCassette) ConfigureRecorder(c *RecorderConfig) {
+	c.Cassette = wc.Path
+	c.Mode = wc.Mode
+}
+
+// WithRedactedHeaders configures a RecorderWrapper or Redactor to
+// redact the given headers from recorded interactions, logs and dumps.
+// This option can be provided multiple times.
+type WithRedactedHeaders []string
+
+func (rh WithRedactedHeaders) ConfigureRecorder(c *RecorderConfig) {
+	c.RedactedHeaders = append(c.RedactedHeaders, rh...)
+}
@@ -0,0 +1,180 @@
+package client
+
+import (
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mt-sre/client/internal/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecorderWrapperInterfaces(t *testing.T) {
+	t.Parallel()
+
+	require.Implements(t, new(http.RoundTripper), new(RecorderWrapper))
+
+	require.Implements(t, new(TransportWrapper), new(RecorderWrapper))
+}
+
+func TestRecorderWrapperRecordAndReplay(t *testing.T) {
+	t.Parallel()
+
+	cassette := filepath.Join(t.TempDir(), "fixtures", "cassette.json")
+
+	mrt := &testutils.MockRoundTripper{}
+	mrt.
+		On("RoundTrip", mock.Anything).
+		Return(&http.Response{
+			StatusCode: http.StatusCreated,
+			Header:     http.Header{"Content-Type": {"application/json"}, "X-Upstream-Key": {"upstream"}},
+			Body:       io.NopCloser(strings.NewReader(`{"id":"1"}`)),
+		}, nil).
+		Once()
+	mrt.
+		On("RoundTrip", mock.Anything).
+		Return(&http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(strings.NewReader("\xff\xfe")),
+		}, nil).
+		Once()
+
+	recorder := NewRecorderWrapper(
+		WithCassette{Path: cassette, Mode: RecorderModeRecord},
+		WithRedactedHeaders{"x-upstream-key"},
+	).Wrap(mrt)
+
+	req, err := http.NewRequest(http.MethodPost, "https://api.example.com/items?token=secret", strings.NewReader("item"))
+	require.NoError(t, err)
+
+	req.Header.Set("Authorization", "Bearer secret")
+
+	res, err := recorder.RoundTrip(req)
+	require.NoError(t, err)
+
+	body, err := io.ReadAll(res.Body)
+	require.NoError(t, err)
+	assert.Equal(t, `{"id":"1"}`, string(body))
+
+	res, err = recorder.RoundTrip(testutils.MockRequest(t, http.MethodGet, nil))
+	require.NoError(t, err)
+	require.NoError(t, res.Body.Close())
+
+	mrt.AssertNumberOfCalls(t, "RoundTrip", 2)
+
+	data, err := os.ReadFile(cassette)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "secret")
+	assert.NotContains(t, string(data), "upstream")
+
+	replay := NewRecorderWrapper(WithCassette{Path: cassette}).Wrap(&testutils.MockRoundTripper{})
+
+	req, err = http.NewRequest(http.MethodPost, "https://api.example.com/items?token=other", strings.NewReader("item"))
+	require.NoError(t, err)
+
+	res, err = replay.RoundTrip(req)
+	require.NoError(t, err)
+
+	body, err = io.ReadAll(res.Body)
+	require.NoError(t, err)
+
+	assert.Equal(t, http.StatusCreated, res.StatusCode)
+	assert.Equal(t, `{"id":"1"}`, string(body))
+	assert.Equal(t, "application/json", res.Header.Get("Content-Type"))
+
+	res, err = replay.RoundTrip(testutils.MockRequest(t, http.MethodGet, nil))
+	require.NoError(t, err)
+
+	body, err = io.ReadAll(res.Body)
+	require.NoError(t, err)
+	assert.Equal(t, "\xff\xfe", string(body))
+
+	_, err = replay.RoundTrip(testutils.MockRequest(t, http.MethodGet, nil))
+	assert.ErrorIs(t, err, ErrNoInteraction)
+}
+
+func TestRecorderWrapperReplay(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		Method         string
+		URL            string
+		Body           string
+		ExpectedStatus int
+		ExpectedErr    error
+	}{
+		"matched": {
+			Method:         http.MethodPut,
+			URL:            "https://api.example.com/items/1",
+			Body:           "update",
+			ExpectedStatus: http.StatusNoContent,
+		},
+		"method mismatch": {
+			Method:      http.MethodPost,
+			URL:         "https://api.example.com/items/1",
+			Body:        "update",
+			ExpectedErr: ErrNoInteraction,
+		},
+		"url mismatch": {
+			Method:      http.MethodPut,
+			URL:         "https://api.example.com/items/2",
+			Body:        "update",
+			ExpectedErr: ErrNoInteraction,
+		},
+		"body mismatch": {
+			Method:      http.MethodPut,
+			URL:         "https://api.example.com/items/1",
+			Body:        "other",
+			ExpectedErr: ErrNoInteraction,
+		},
+	} {
+		tc := tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			cassette := filepath.Join(t.TempDir(), "cassette.json")
+
+			require.NoError(t, os.WriteFile(cassette, []byte(`{
+  "interactions": [
+    {
+      "request": {"method": "PUT", "url": "https://api.example.com/items/1", "body": {"data": "update"}},
+      "response": {"statusCode": 204}
+    }
+  ]
+}`), 0o600))
+
+			mrt := &testutils.MockRoundTripper{}
+
+			req, err := http.NewRequest(tc.Method, tc.URL, strings.NewReader(tc.Body))
+			require.NoError(t, err)
+
+			res, err := NewRecorderWrapper(WithCassette{Path: cassette}).Wrap(mrt).RoundTrip(req)
+
+			mrt.AssertNotCalled(t, "RoundTrip", mock.Anything)
+
+			if tc.ExpectedErr != nil {
+				assert.ErrorIs(t, err, tc.ExpectedErr)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.ExpectedStatus, res.StatusCode)
+		})
+	}
+}
+
+func TestRecorderWrapperMissingCassette(t *testing.T) {
+	t.Parallel()
+
+	rt := NewRecorderWrapper(WithCassette{Path: filepath.Join(t.TempDir(), "missing.json")}).Wrap(&testutils.MockRoundTripper{})
+
+	_, err := rt.RoundTrip(testutils.MockRequest(t, http.MethodGet, nil))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
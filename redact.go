@@ -0,0 +1,171 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+const redacted = "REDACTED"
+
+// defaultRedactedHeaders are the headers whose values
+// are redacted by the default Redactor.
+var defaultRedactedHeaders = append([]string{"Set-Cookie"}, sensitiveHeaders...)
+
+// defaultRedactedQueryKeys are substrings of query parameter names
+// whose values are redacted by the default Redactor.
+var defaultRedactedQueryKeys = []string{"token", "key", "secret", "password", "signature", "auth"}
+
+// NewRedactor returns a Redactor which redacts the default
+// credential bearing headers and query parameters along with
+// those configured through the given options.
+func NewRedactor(opts ...RedactionOption) *Redactor {
+	var cfg RedactionConfig
+
+	cfg.Option(opts...)
+	cfg.Default()
+
+	return &Redactor{cfg: cfg}
+}
+
+// Redactor removes credentials from URLs and headers before they are
+// written to logs, debug dumps, recordings, events or errors. It is
+// shared by all of these through the request context so that the
+// redaction rules are configured once per Client using WithRedaction.
+type Redactor struct {
+	cfg RedactionConfig
+}
+
+// URL returns u as a string with its password and the values of
+// sensitive query parameters replaced.
+func (r *Redactor) URL(u *url.URL) string {
+	if u == nil {
+		return ""
+	}
+
+	out := *u
+
+	query := out.Query()
+
+	for key := range query {
+		if r.sensitiveQueryKey(key) {
+			query[key] = []string{redacted}
+		}
+	}
+
+	if len(query) > 0 {
+		out.RawQuery = query.Encode()
+	}
+
+	return out.Redacted()
+}
+
+func (r *Redactor) sensitiveQueryKey(key string) bool {
+	lower := strings.ToLower(key)
+
+	for _, sensitive := range r.cfg.QueryKeys {
+		if strings.Contains(lower, strings.ToLower(sensitive)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Header returns a copy of h with the values of sensitive
+// headers replaced or nil if h is empty.
+func (r *Redactor) Header(h http.Header) http.Header {
+	if len(h) == 0 {
+		return nil
+	}
+
+	out := h.Clone()
+
+	for _, key := range r.cfg.Headers {
+		key = http.CanonicalHeaderKey(key)
+
+		if _, ok := out[key]; ok {
+			out[key] = []string{redacted}
+		}
+	}
+
+	return out
+}
+
+var defaultRedactor = NewRedactor()
+
+type redactorKey struct{}
+
+// ContextWithRedactor returns a context causing the Client and its
+// wrappers to redact URLs and headers using r.
+func ContextWithRedactor(ctx context.Context, r *Redactor) context.Context {
+	return context.WithValue(ctx, redactorKey{}, r)
+}
+
+// redactorFromContext returns the Redactor of ctx
+// falling back to the default Redactor.
+func redactorFromContext(ctx context.Context) *Redactor {
+	if r, ok := ctx.Value(redactorKey{}).(*Redactor); ok && r != nil {
+		return r
+	}
+
+	return defaultRedactor
+}
+
+func redactURL(ctx context.Context, u *url.URL) string {
+	return redactorFromContext(ctx).URL(u)
+}
+
+func redactHeader(ctx context.Context, h http.Header) http.Header {
+	return redactorFromContext(ctx).Header(h)
+}
+
+type RedactionConfig struct {
+	// Headers lists the names of headers whose values are
+	// redacted in addition to Authorization, Proxy-Authorization,
+	// Cookie, Set-Cookie and WWW-Authenticate.
+	Headers []string
+	// QueryKeys lists substrings of query parameter names whose
+	// values are redacted in addition to token, key, secret,
+	// password, signature and auth. Matching is case-insensitive.
+	QueryKeys []string
+}
+
+func (c *RedactionConfig) Option(opts ...RedactionOption) {
+	for _, opt := range opts {
+		opt.ConfigureRedaction(c)
+	}
+}
+
+func (c *RedactionConfig) Default() {
+	c.Headers = append(slices.Clone(defaultRedactedHeaders), c.Headers...)
+	c.QueryKeys = append(slices.Clone(defaultRedactedQueryKeys), c.QueryKeys...)
+}
+
+type RedactionOption interface {
+	ConfigureRedaction(*RedactionConfig)
+}
+
+func (h WithRedactedHeaders) ConfigureRedaction(c *RedactionConfig) {
+	c.Headers = append(c.Headers, h...)
+}
+
+// WithRedactedQueryKeys redacts the values of query parameters whose
+// names contain any of the given substrings in addition to the
+// default ones.
+type WithRedactedQueryKeys []string
+
+func (k WithRedactedQueryKeys) ConfigureRedaction(c *RedactionConfig) {
+	c.QueryKeys = append(c.QueryKeys, k...)
+}
+
+// WithRedaction configures the Redactor used by a Client instance
+// and its wrappers when logging, dumping, recording and reporting
+// requests, including the URLs embedded in returned errors.
+type WithRedaction []RedactionOption
+
+func (r WithRedaction) ConfigureClient(c *ClientConfig) {
+	c.Redactor = NewRedactor(r...)
+}
@@ -0,0 +1,105 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// defaultMaxRedirects matches the limit applied by http.Client.
+const defaultMaxRedirects = 10
+
+// RedirectPolicy decides whether a redirect is followed. It receives
+// the upcoming request and the requests made so far, oldest first.
+// Returning http.ErrUseLastResponse stops following redirects and
+// returns the most recent response; any other error aborts the
+// request.
+type RedirectPolicy func(req *http.Request, via []*http.Request) error
+
+// WithRedirectPolicy configures a Client instance with a custom
+// RedirectPolicy replacing the default limit of 10 redirects.
+// Credentials are stripped from redirects to other hosts before
+// the policy is consulted and credential wrappers do not
+// authenticate such redirects.
+type WithRedirectPolicy RedirectPolicy
+
+func (p WithRedirectPolicy) ConfigureClient(c *ClientConfig) {
+	c.RedirectPolicy = RedirectPolicy(p)
+}
+
+// WithMaxRedirects configures a Client instance to follow at most
+// the given number of redirects before returning an error.
+type WithMaxRedirects int
+
+func (m WithMaxRedirects) ConfigureClient(c *ClientConfig) {
+	c.RedirectPolicy = maxRedirectsPolicy(int(m))
+}
+
+// WithNoFollowRedirects configures a Client instance to return
+// redirect responses to the caller instead of following them.
+type WithNoFollowRedirects struct{}
+
+func (WithNoFollowRedirects) ConfigureClient(c *ClientConfig) {
+	c.RedirectPolicy = func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+}
+
+func maxRedirectsPolicy(max int) RedirectPolicy {
+	return func(_ *http.Request, via []*http.Request) error {
+		if len(via) > max {
+			return fmt.Errorf("stopped after %d redirects", max)
+		}
+
+		return nil
+	}
+}
+
+// sensitiveHeaders are removed from redirected requests which leave
+// the original host or downgrade from HTTPS to HTTP.
+var sensitiveHeaders = []string{
+	"Authorization",
+	"Proxy-Authorization",
+	"Cookie",
+	"Www-Authenticate",
+}
+
+// checkRedirect returns the function used as http.Client.CheckRedirect.
+func (c *ClientConfig) checkRedirect() func(*http.Request, []*http.Request) error {
+	policy := c.RedirectPolicy
+	if policy == nil {
+		policy = maxRedirectsPolicy(defaultMaxRedirects)
+	}
+
+	return func(req *http.Request, via []*http.Request) error {
+		if len(via) == 0 {
+			return errors.New("redirect without previous request")
+		}
+
+		orig := via[0].URL
+
+		if req.URL.Host != orig.Host || (orig.Scheme == "https" && req.URL.Scheme != "https") {
+			for _, key := range sensitiveHeaders {
+				req.Header.Del(key)
+			}
+
+			// wrappers run again for every redirect so those adding
+			// credentials must be told to skip this request
+			*req = *req.WithContext(context.WithValue(req.Context(), crossHostRedirectKey{}, true))
+		}
+
+		return policy(req, via)
+	}
+}
+
+type crossHostRedirectKey struct{}
+
+// isCrossHostRedirect reports whether req is a redirect which left the
+// host of the original request or downgraded from HTTPS to HTTP.
+// Wrappers adding credentials must not authenticate such requests.
+func isCrossHostRedirect(req *http.Request) bool {
+	redirect, _ := req.Context().Value(crossHostRedirectKey{}).(bool)
+
+	return redirect
+}
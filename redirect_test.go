@@ -0,0 +1,158 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedirectOptions(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n, err := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/"))
+		require.NoError(t, err)
+
+		if n == 0 {
+			w.WriteHeader(http.StatusOK)
+
+			return
+		}
+
+		http.Redirect(w, r, "/"+strconv.Itoa(n-1), http.StatusFound)
+	}))
+	t.Cleanup(srv.Close)
+
+	for name, tc := range map[string]struct {
+		Options            []ClientOption
+		Redirects          int
+		ExpectError        bool
+		ExpectedStatusCode int
+	}{
+		"default follows": {
+			Redirects:          3,
+			ExpectedStatusCode: http.StatusOK,
+		},
+		"default limit": {
+			Redirects:   11,
+			ExpectError: true,
+		},
+		"max redirects": {
+			Options:     []ClientOption{WithMaxRedirects(2)},
+			Redirects:   3,
+			ExpectError: true,
+		},
+		"within max redirects": {
+			Options:            []ClientOption{WithMaxRedirects(3)},
+			Redirects:          3,
+			ExpectedStatusCode: http.StatusOK,
+		},
+		"no follow": {
+			Options:            []ClientOption{WithNoFollowRedirects{}},
+			Redirects:          1,
+			ExpectedStatusCode: http.StatusFound,
+		},
+		"custom policy": {
+			Options: []ClientOption{WithRedirectPolicy(func(req *http.Request, _ []*http.Request) error {
+				if req.URL.Path == "/1" {
+					return http.ErrUseLastResponse
+				}
+
+				return nil
+			})},
+			Redirects:          3,
+			ExpectedStatusCode: http.StatusFound,
+		},
+	} {
+		tc := tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			res, err := NewClient(tc.Options...).Get(context.Background(), srv.URL+"/"+strconv.Itoa(tc.Redirects))
+			if tc.ExpectError {
+				require.Error(t, err)
+
+				return
+			}
+
+			require.NoError(t, err)
+			defer res.Body.Close()
+
+			assert.Equal(t, tc.ExpectedStatusCode, res.StatusCode)
+		})
+	}
+}
+
+// TestRedirectStripsCredentials ensures that credentials are not
+// forwarded when a redirect leaves the original host.
+func TestRedirectStripsCredentials(t *testing.T) {
+	t.Parallel()
+
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Authorization", r.Header.Get("Authorization"))
+	}))
+	t.Cleanup(target.Close)
+
+	origin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/same-host":
+			http.Redirect(w, r, "/echo", http.StatusFound)
+		case "/cross-host":
+			http.Redirect(w, r, target.URL, http.StatusFound)
+		case "/echo":
+			w.Header().Set("X-Authorization", r.Header.Get("Authorization"))
+		}
+	}))
+	t.Cleanup(origin.Close)
+
+	for name, tc := range map[string]struct {
+		Path     string
+		Options  []ClientOption
+		Header   http.Header
+		Expected string
+	}{
+		"same host": {
+			Path:     "/same-host",
+			Header:   http.Header{"Authorization": {"Bearer token"}},
+			Expected: "Bearer token",
+		},
+		"cross host": {
+			Path:     "/cross-host",
+			Header:   http.Header{"Authorization": {"Bearer token"}},
+			Expected: "",
+		},
+		"oauth wrapper same host": {
+			Path: "/same-host",
+			Options: []ClientOption{
+				WithWrapper{TransportWrapper: NewOAUTHWrapper(WithAccessToken("secret"))},
+			},
+			Expected: "Bearer secret",
+		},
+		"oauth wrapper cross host": {
+			Path: "/cross-host",
+			Options: []ClientOption{
+				WithWrapper{TransportWrapper: NewOAUTHWrapper(WithAccessToken("secret"))},
+			},
+			Expected: "",
+		},
+	} {
+		tc := tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			res, err := NewClient(tc.Options...).Get(context.Background(), origin.URL+tc.Path, WithRequestHeaders(tc.Header))
+			require.NoError(t, err)
+			defer res.Body.Close()
+
+			assert.Equal(t, tc.Expected, res.Header.Get("X-Authorization"))
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package client
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+)
+
+// NamedWrapper is a TransportWrapper registered with a Client.
+type NamedWrapper struct {
+	// Name identifies the wrapper. Wrappers added with
+	// WithWrapper are named after their type.
+	Name string
+	// Priority orders the wrapper relative to other wrappers.
+	// Wrappers with a lower priority are applied first and so
+	// sit closer to the underlying transport. Wrappers added
+	// with WithWrapper have a priority of zero.
+	Priority int
+	Wrapper  TransportWrapper
+}
+
+// Wrappers returns the wrappers of the Client in the order they
+// were applied starting with the wrapper closest to the transport.
+func (c *Client) Wrappers() []NamedWrapper {
+	return slices.Clone(c.cfg.registered)
+}
+
+// register adds nw to the registered wrappers replacing
+// any wrapper previously registered with the same name.
+func (c *ClientConfig) register(nw NamedWrapper) {
+	if nw.Name != "" {
+		idx := slices.IndexFunc(c.registered, func(r NamedWrapper) bool {
+			return r.Name == nw.Name
+		})
+
+		if idx >= 0 {
+			c.registered = slices.Delete(c.registered, idx, idx+1)
+		}
+	}
+
+	c.registered = append(c.registered, nw)
+}
+
+// wrapperHook adjusts the registered wrappers once all
+// options have been applied and before they are resolved.
+type wrapperHook func(registered []NamedWrapper) []NamedWrapper
+
+// resolveWrappers orders the registered wrappers by priority and
+// assigns them to Wrappers. Wrappers of equal priority keep the
+// order in which they were registered. If Wrappers was assigned
+// directly it is used as is.
+func (c *ClientConfig) resolveWrappers() {
+	for _, hook := range c.wrapperHooks {
+		c.registered = hook(c.registered)
+	}
+
+	if len(c.registered) == 0 {
+		for _, w := range c.Wrappers {
+			c.registered = append(c.registered, NamedWrapper{Wrapper: w})
+		}
+	} else {
+		slices.SortStableFunc(c.registered, func(a, b NamedWrapper) int {
+			return cmp.Compare(a.Priority, b.Priority)
+		})
+
+		c.Wrappers = make([]TransportWrapper, 0, len(c.registered))
+
+		for _, nw := range c.registered {
+			c.Wrappers = append(c.Wrappers, nw.Wrapper)
+		}
+	}
+
+	for i, nw := range c.registered {
+		if nw.Name == "" {
+			c.registered[i].Name = fmt.Sprintf("%T", nw.Wrapper)
+		}
+	}
+}
+
+// WithNamedWrapper configures a Client instance with a TransportWrapper
+// identified by Name and ordered by Priority rather than by the order
+// in which options are provided. Wrappers with a lower priority are
+// applied first and so sit closer to the underlying transport; those
+// added with WithWrapper have a priority of zero. Providing a wrapper
+// with the name of an existing wrapper replaces it. The resulting
+// order can be inspected with Client.Wrappers.
+type WithNamedWrapper NamedWrapper
+
+func (nw WithNamedWrapper) ConfigureClient(c *ClientConfig) {
+	c.register(NamedWrapper(nw))
+}
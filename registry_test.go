@@ -0,0 +1,106 @@
+package client
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/mt-sre/client/internal/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func orderWrapper(name string, calls *[]string) TransportWrapper {
+	return MiddlewareWrapper(func(next Handler) Handler {
+		return func(req *http.Request) (*http.Response, error) {
+			*calls = append(*calls, name)
+
+			return next(req)
+		}
+	})
+}
+
+func TestClientWrapperRegistry(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		Options       func(calls *[]string) []ClientOption
+		ExpectedNames []string
+		// ExpectedCalls lists wrappers from outermost to innermost.
+		ExpectedCalls []string
+	}{
+		"option order": {
+			Options: func(calls *[]string) []ClientOption {
+				return []ClientOption{
+					WithWrapper{TransportWrapper: orderWrapper("a", calls)},
+					WithWrapper{TransportWrapper: orderWrapper("b", calls)},
+				}
+			},
+			ExpectedNames: []string{"client.middlewareWrapper", "client.middlewareWrapper"},
+			ExpectedCalls: []string{"b", "a"},
+		},
+		"priority": {
+			Options: func(calls *[]string) []ClientOption {
+				return []ClientOption{
+					WithNamedWrapper{Name: "retry", Priority: 100, Wrapper: orderWrapper("retry", calls)},
+					WithNamedWrapper{Name: "auth", Priority: -10, Wrapper: orderWrapper("auth", calls)},
+					WithWrapper{TransportWrapper: orderWrapper("unnamed", calls)},
+				}
+			},
+			ExpectedNames: []string{"auth", "client.middlewareWrapper", "retry"},
+			ExpectedCalls: []string{"retry", "unnamed", "auth"},
+		},
+		"equal priority keeps registration order": {
+			Options: func(calls *[]string) []ClientOption {
+				return []ClientOption{
+					WithNamedWrapper{Name: "a", Wrapper: orderWrapper("a", calls)},
+					WithWrapper{TransportWrapper: orderWrapper("unnamed", calls)},
+					WithNamedWrapper{Name: "b", Wrapper: orderWrapper("b", calls)},
+				}
+			},
+			ExpectedNames: []string{"a", "client.middlewareWrapper", "b"},
+			ExpectedCalls: []string{"b", "unnamed", "a"},
+		},
+		"replaced by name": {
+			Options: func(calls *[]string) []ClientOption {
+				return []ClientOption{
+					WithNamedWrapper{Name: "a", Priority: 1, Wrapper: orderWrapper("old", calls)},
+					WithNamedWrapper{Name: "b", Priority: 2, Wrapper: orderWrapper("b", calls)},
+					WithNamedWrapper{Name: "a", Priority: 3, Wrapper: orderWrapper("new", calls)},
+				}
+			},
+			ExpectedNames: []string{"b", "a"},
+			ExpectedCalls: []string{"new", "b"},
+		},
+	} {
+		tc := tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			mrt := &testutils.MockRoundTripper{}
+			mrt.
+				On("RoundTrip", mock.Anything).
+				Return(&http.Response{StatusCode: http.StatusOK, Body: http.NoBody}, nil)
+
+			var calls []string
+
+			c := NewClient(append(tc.Options(&calls), WithTransport{RoundTripper: mrt})...)
+			defer c.Close()
+
+			names := make([]string, 0, len(c.Wrappers()))
+
+			for _, nw := range c.Wrappers() {
+				names = append(names, nw.Name)
+			}
+
+			assert.Equal(t, tc.ExpectedNames, names)
+
+			res, err := c.client.Transport.RoundTrip(testutils.MockRequest(t, http.MethodGet, nil))
+			require.NoError(t, err)
+			require.NoError(t, res.Body.Close())
+
+			assert.Equal(t, tc.ExpectedCalls, calls)
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package client
+
+import (
+	"net/http"
+	"time"
+)
+
+// RequestConfig holds settings which apply to a single
+// request made by a Client.
+type RequestConfig struct {
+	// Timeout limits the total time taken by the request
+	// including reading the response body.
+	Timeout time.Duration
+	// Progress receives events describing the
+	// progress of the request.
+	Progress ProgressFunc
+	// Informational receives 1xx informational
+	// responses such as 103 Early Hints.
+	Informational InformationalFunc
+	// Header is added to the request's headers.
+	Header http.Header
+	// Impersonate is the Identity the request
+	// is performed on behalf of.
+	Impersonate *Identity
+	// Transport replaces the client's transport
+	// beneath any TransportWrappers.
+	Transport http.RoundTripper
+	// RequestID identifies the request in
+	// headers and log messages.
+	RequestID string
+	// Priority orders the request relative to
+	// others waiting to be dispatched.
+	Priority *Priority
+}
+
+func (c *RequestConfig) Option(opts ...RequestOption) {
+	for _, opt := range opts {
+		opt.ConfigureRequest(c)
+	}
+}
+
+// RequestOption configures a single request made by a Client
+// overriding any client level settings.
+type RequestOption interface {
+	ConfigureRequest(*RequestConfig)
+}
+
+// WithRequestHeaders adds the given headers to a single request.
+// This option can be provided multiple times.
+type WithRequestHeaders http.Header
+
+func (h WithRequestHeaders) ConfigureRequest(c *RequestConfig) {
+	if c.Header == nil {
+		c.Header = make(http.Header)
+	}
+
+	for key, vals := range h {
+		for _, val := range vals {
+			c.Header.Add(key, val)
+		}
+	}
+}
+
+// withDefaultRequestHeaders sets the given headers on a single request
+// unless they were provided by the caller. It must follow the options
+// of the caller so that their headers replace rather than join these.
+type withDefaultRequestHeaders http.Header
+
+func (h withDefaultRequestHeaders) ConfigureRequest(c *RequestConfig) {
+	if c.Header == nil {
+		c.Header = make(http.Header)
+	}
+
+	for key, vals := range h {
+		if c.Header.Get(key) == "" {
+			c.Header[http.CanonicalHeaderKey(key)] = vals
+		}
+	}
+}
+
+// WithRequestTransport sends a single request through the given
+// http.RoundTripper instead of the client's transport. Client level
+// TransportWrappers are still applied. This is intended as an escape
+// hatch e.g. to probe a destination directly while debugging
+// connectivity.
+type WithRequestTransport struct{ http.RoundTripper }
+
+func (t WithRequestTransport) ConfigureRequest(c *RequestConfig) {
+	c.Transport = t.RoundTripper
+}
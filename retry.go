@@ -7,12 +7,26 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/cenkalti/backoff/v4"
 	"github.com/go-logr/logr"
 )
 
-var errTemporary = errors.New("temporary error occurred")
+var (
+	errTemporary         = errors.New("temporary error occurred")
+	errBodyNotReplayable = errors.New("request body cannot be replayed")
+)
+
+// ErrRetryAborted is reported when a RetryCallback
+// aborts further retries of a request.
+var ErrRetryAborted = errors.New("retry aborted by callback")
+
+// defaultMaxBufferedBodySize is the largest request body which is
+// buffered in memory to allow retries when the request provides no
+// GetBody function.
+const defaultMaxBufferedBodySize = 10 << 20
 
 // NewRetryWrapper returns a TransportWrapper which detects whether
 // a HTTP request should be retried given a particular failure scenario.
@@ -25,14 +39,6 @@ func NewRetryWrapper(opts ...RetryWrapperOption) *RetryWrapper {
 
 	cfg.Default()
 
-	if cfg.maxRetries > 0 {
-		inner := cfg.GenerateBackoff
-
-		cfg.GenerateBackoff = func() backoff.BackOff {
-			return backoff.WithMaxRetries(inner(), cfg.maxRetries)
-		}
-	}
-
 	return &RetryWrapper{
 		cfg: cfg,
 	}
@@ -50,46 +56,152 @@ func (w *RetryWrapper) Wrap(rt http.RoundTripper) http.RoundTripper {
 }
 
 func (w *RetryWrapper) RoundTrip(req *http.Request) (*http.Response, error) {
-	log := w.cfg.Logger.WithValues(
+	log := contextLogger(w.cfg.Logger, req.Context()).WithValues(
 		"method", req.Method,
 		"host", req.URL.Host,
 		"path", req.URL.Path,
 	)
 
-	// preserve request body so that each request can be made with a readable body
-	copy, err := copyRequestBody(req)
+	state := &RetryState{}
+
+	if w.cfg.idempotencyKeys && !isMethodIdempotent(req.Method) && req.Header.Get(IdempotencyKeyHeader) == "" {
+		key, err := newUUID()
+		if err != nil {
+			return nil, fmt.Errorf("generating idempotency key: %w", err)
+		}
+
+		req = req.Clone(req.Context())
+		req.Header.Set(IdempotencyKeyHeader, key)
+	}
+
+	// obtain a means of replaying the request body so that each request can be made with a readable body
+	getBody, err := replayableBody(req, w.cfg.maxBufferedBodySize)
 	if err != nil {
-		return nil, fmt.Errorf("copying request body: %w", err)
+		return nil, fmt.Errorf("preparing request body: %w", err)
 	}
 
 	retries := 0
 
-	var res *http.Response
+	var (
+		res      *http.Response
+		lastErr  error
+		attempts []AttemptError
+		// attemptCtx and cancelAttempt belong to the attempt which
+		// produced res when a per-attempt timeout is configured.
+		attemptCtx    = req.Context()
+		cancelAttempt = context.CancelFunc(func() {})
+	)
+
+	if w.cfg.budget != nil {
+		w.cfg.budget.RecordRequest(w.cfg.Clock.Now())
+	}
 
 	roundtrip := func() error {
 		if retries > 0 {
+			if w.cfg.budget != nil {
+				release, ok := w.cfg.budget.TryRetry(w.cfg.Clock.Now())
+				if !ok {
+					log.Info("retry budget exhausted",
+						"retries", retries,
+					)
+
+					return backoff.Permanent(ErrRetryBudgetExhausted)
+				}
+
+				defer release()
+			}
+
 			log.Info("retrying request",
 				"retries", retries,
 			)
 		}
 
-		if copy != nil {
-			req.Body = io.NopCloser(bytes.NewBuffer(copy))
+		if retries > 0 && getBody != nil {
+			body, err := getBody()
+			if err != nil {
+				return backoff.Permanent(fmt.Errorf("rewinding request body: %w", err))
+			}
+
+			req.Body = body
 		}
 
 		// drain open response body so that existing connections may be reused
 		if res != nil {
-			drainResponseBody(w.cfg.Logger.V(1), res)
+			drainResponseBody(log.V(1), res)
 		}
 
+		cancelAttempt()
+
+		attempt := retries + 1
+		attemptReq := req
+
+		state.Attempts = attempt
+
+		if w.cfg.perAttemptTimeout > 0 {
+			attemptCtx, cancelAttempt = context.WithTimeoutCause(req.Context(), w.cfg.perAttemptTimeout, ErrAttemptTimeout)
+			attemptReq = req.WithContext(attemptCtx)
+		}
+
+		for _, hook := range w.cfg.OnRequest {
+			hook(attempt, attemptReq)
+		}
+
+		start := w.cfg.Clock.Now()
+
 		var err error
-		res, err = w.rt.RoundTrip(req)
+		res, err = w.rt.RoundTrip(attemptReq)
+
+		for _, hook := range w.cfg.OnResponse {
+			hook(attempt, attemptReq, res, err)
+		}
+
+		finished := &AttemptFinished{
+			Attempt:  attempt,
+			Method:   req.Method,
+			URL:      redactURL(req.Context(), req.URL),
+			Err:      err,
+			Duration: w.cfg.Clock.Now().Sub(start),
+		}
+
+		if res != nil {
+			finished.StatusCode = res.StatusCode
+		}
+
+		emitEvent(req.Context(), finished)
+
 		if err != nil {
-			if !w.cfg.Policy.IsErrorRetryable(err) {
+			// attempts which timed out are retried while the request context remains valid
+			attemptTimedOut := req.Context().Err() == nil && errors.Is(context.Cause(attemptCtx), ErrAttemptTimeout)
+			if attemptTimedOut {
+				err = withCancelCause(attemptCtx, err)
+			}
+
+			if !attemptTimedOut && !w.isErrorRetryable(err) {
 				// exit with error if request failed before a response was received
 				return backoff.Permanent(err)
 			}
 
+			lastErr = err
+			attempts = append(attempts, AttemptError{
+				Attempt: attempt,
+				Err:     err,
+			})
+			state.LastFailure = &attempts[len(attempts)-1]
+
+			if getBody == nil {
+				log.Info("request body cannot be replayed; not retrying")
+
+				return backoff.Permanent(errBodyNotReplayable)
+			}
+
+			if !w.shouldRetry(attempt, req, res, err) {
+				log.Info("retry aborted by callback")
+
+				return backoff.Permanent(ErrRetryAborted)
+			}
+
+			retries++
+
 			return errTemporary
 		}
 
@@ -97,43 +209,245 @@ func (w *RetryWrapper) RoundTrip(req *http.Request) (*http.Response, error) {
 			"responseStatus", res.StatusCode,
 		)
 
-		if !w.cfg.Policy.IsStatusRetryableForMethod(req.Method, res.StatusCode) {
+		if !w.isStatusRetryable(req, res.StatusCode) {
 			// exit with no error if HTTP status code does not permit retry
 			return nil
 		}
 
+		attempts = append(attempts, AttemptError{
+			Attempt:    attempt,
+			StatusCode: res.StatusCode,
+		})
+		state.LastFailure = &attempts[len(attempts)-1]
+
+		if getBody == nil {
+			log.Info("request body cannot be replayed; not retrying")
+
+			return backoff.Permanent(errBodyNotReplayable)
+		}
+
+		if !w.shouldRetry(attempt, req, res, nil) {
+			log.Info("retry aborted by callback")
+
+			return backoff.Permanent(ErrRetryAborted)
+		}
+
 		retries++
 
 		// exit with temporary error to retry request
 		return errTemporary
 	}
 
-	bo := backoff.WithContext(w.cfg.GenerateBackoff(), req.Context())
+	bo := generateBackOff(req.Context(), w.cfg.Clock, w.cfg.GenerateBackoff)
+	if w.cfg.maxRetries > 0 {
+		bo = backoff.WithMaxRetries(bo, w.cfg.maxRetries)
+	}
+
+	var limited *maxDurationBackOff
+
+	if w.cfg.maxRetryDuration > 0 {
+		limited = &maxDurationBackOff{
+			BackOff: bo,
+			limit:   w.cfg.maxRetryDuration,
+			clock:   w.cfg.Clock,
+		}
+		bo = limited
+	}
+
+	bo = backoff.WithContext(bo, req.Context())
+
+	progress := progressFromContext(req.Context())
+
+	notify := func(_ error, delay time.Duration) {
+		state.TotalDelay += delay
+
+		emitEvent(req.Context(), &RetryScheduled{
+			Attempt: retries + 1,
+			Method:  req.Method,
+			URL:     redactURL(req.Context(), req.URL),
+			Delay:   delay,
+		})
+
+		if progress != nil {
+			progress(ProgressEvent{
+				Phase: PhaseRetrying,
+				Retry: retries,
+				Delay: delay,
+			})
+		}
+	}
+
+	if err := backoff.RetryNotifyWithTimer(roundtrip, bo, notify, &clockTimer{clock: w.cfg.Clock}); err != nil {
+		err = withCancelCause(req.Context(), err)
+
+		stopped := errors.Is(err, ErrRetryBudgetExhausted) ||
+			errors.Is(err, ErrRetryAborted) ||
+			errors.Is(err, errBodyNotReplayable)
+
+		if !stopped && !errors.Is(err, errTemporary) && !errors.Is(err, context.DeadlineExceeded) {
+			cancelAttempt()
 
-	if err := backoff.Retry(roundtrip, bo); err != nil {
-		if !errors.Is(err, errTemporary) && !errors.Is(err, context.DeadlineExceeded) {
 			return nil, fmt.Errorf("permanent error encountered: %w", err)
 		}
+
+		if limited != nil && limited.exceeded && errors.Is(err, errTemporary) {
+			timeout := &RetryTimeoutError{
+				Limit:    limited.limit,
+				Elapsed:  w.cfg.Clock.Now().Sub(limited.start),
+				Attempts: attempts,
+			}
+
+			if res == nil {
+				cancelAttempt()
+			} else {
+				if w.cfg.perAttemptTimeout > 0 {
+					res.Body = newCancelOnCloseBody(attemptCtx, cancelAttempt, res.Body)
+				}
+
+				timeout.Response = res
+			}
+
+			return nil, timeout
+		}
+
+		if w.cfg.errorOnExhaustion {
+			if res != nil {
+				drainResponseBody(log.V(1), res)
+			}
+
+			cancelAttempt()
+
+			exhausted := &RetriesExhaustedError{
+				Attempts: attempts,
+			}
+
+			if !errors.Is(err, errTemporary) {
+				exhausted.Reason = err
+			}
+
+			return nil, exhausted
+		}
+
+		if res == nil {
+			cancelAttempt()
+
+			switch {
+			case lastErr == nil:
+				return nil, err
+			case errors.Is(err, errTemporary):
+				// retries were exhausted without receiving a response
+				return nil, lastErr
+			default:
+				return nil, fmt.Errorf("%w: %w", err, lastErr)
+			}
+		}
+	}
+
+	if w.cfg.perAttemptTimeout > 0 {
+		// the attempt's context must remain valid until the body is consumed
+		res.Body = newCancelOnCloseBody(attemptCtx, cancelAttempt, res.Body)
+	}
+
+	// the state is attached to the request of the returned response only
+	// so that the request passed to the transport remains unchanged
+	stateReq := res.Request
+	if stateReq == nil {
+		stateReq = req
+	}
+
+	res.Request = stateReq.WithContext(context.WithValue(stateReq.Context(), retryStateKey{}, state))
+
+	if w.cfg.retryStateHeader {
+		res.Header.Set(RetryAttemptsHeader, strconv.Itoa(state.Attempts))
 	}
 
 	return res, nil
 }
 
-func copyRequestBody(req *http.Request) ([]byte, error) {
+// isErrorRetryable consults the RetryPolicy unless err is a response
+// validation failure whose retryability was decided by the validator.
+func (w *RetryWrapper) isErrorRetryable(err error) bool {
+	var verr *ResponseValidationError
+
+	if errors.As(err, &verr) {
+		return verr.Retryable
+	}
+
+	return w.cfg.Policy.IsErrorRetryable(err)
+}
+
+func (w *RetryWrapper) isStatusRetryable(req *http.Request, code int) bool {
+	if policy, ok := w.cfg.Policy.(RequestRetryPolicy); ok {
+		return policy.IsStatusRetryableForRequest(req, code)
+	}
+
+	return w.cfg.Policy.IsStatusRetryableForMethod(req.Method, code)
+}
+
+// shouldRetry invokes all OnRetry callbacks and reports
+// whether every callback permits the retry.
+func (w *RetryWrapper) shouldRetry(attempt int, req *http.Request, res *http.Response, err error) bool {
+	for _, hook := range w.cfg.OnRetry {
+		if !hook(attempt, req, res, err) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// replayableBody returns a function which provides a fresh copy of
+// the request body for each retry attempt. Requests without a body
+// or with a GetBody function are replayed without buffering. Other
+// bodies are buffered in memory if they do not exceed limit; larger
+// bodies are left unbuffered and a nil function is returned to
+// signal that the request must not be retried. A negative limit
+// disables buffering entirely.
+func replayableBody(req *http.Request, limit int64) (func() (io.ReadCloser, error), error) {
 	if req.Body == nil || req.Body == http.NoBody {
+		return func() (io.ReadCloser, error) { return http.NoBody, nil }, nil
+	}
+
+	if req.GetBody != nil {
+		return req.GetBody, nil
+	}
+
+	if limit < 0 {
 		return nil, nil
 	}
 
-	copy, err := io.ReadAll(req.Body)
+	buf, err := io.ReadAll(io.LimitReader(req.Body, limit+1))
 	if err != nil {
 		return nil, fmt.Errorf("reading request body: %w", err)
 	}
 
+	if int64(len(buf)) > limit {
+		// restore the consumed prefix so that a single attempt can still be made
+		req.Body = &multiReadCloser{
+			Reader: io.MultiReader(bytes.NewReader(buf), req.Body),
+			Closer: req.Body,
+		}
+
+		return nil, nil
+	}
+
 	if err := req.Body.Close(); err != nil {
 		return nil, fmt.Errorf("closing request body: %w", err)
 	}
 
-	return copy, nil
+	getBody := func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(buf)), nil
+	}
+
+	req.Body, _ = getBody()
+	req.GetBody = getBody
+
+	return getBody, nil
+}
+
+type multiReadCloser struct {
+	io.Reader
+	io.Closer
 }
 
 func drainResponseBody(logger logr.Logger, res *http.Response) {
@@ -150,7 +464,24 @@ type RetryWrapperConfig struct {
 	Logger          logr.Logger
 	GenerateBackoff func() backoff.BackOff
 	Policy          RetryPolicy
-	maxRetries      uint64
+	// Clock is used to measure attempts and to wait between them.
+	Clock Clock
+	// OnRequest hooks are invoked before each attempt.
+	OnRequest []RequestHook
+	// OnResponse hooks are invoked after each attempt.
+	OnResponse []ResponseHook
+	// OnRetry callbacks are invoked before a retry is scheduled.
+	OnRetry           []RetryCallback
+	maxRetries        uint64
+	budget            *retryBudget
+	errorOnExhaustion bool
+	// maxBufferedBodySize limits the size of request
+	// bodies which are buffered to allow retries.
+	maxBufferedBodySize int64
+	perAttemptTimeout   time.Duration
+	maxRetryDuration    time.Duration
+	idempotencyKeys     bool
+	retryStateHeader    bool
 }
 
 func (c *RetryWrapperConfig) Option(opts ...RetryWrapperOption) {
@@ -171,14 +502,23 @@ func (c *RetryWrapperConfig) Default() {
 	if c.Policy == nil {
 		c.Policy = NewDefaultRetryPolicy()
 	}
+
+	if c.Clock == nil {
+		c.Clock = RealClock{}
+	}
+
+	if c.maxBufferedBodySize == 0 {
+		c.maxBufferedBodySize = defaultMaxBufferedBodySize
+	}
 }
 
 type RetryWrapperOption interface {
 	ConfigureRetryWrapper(*RetryWrapperConfig)
 }
 
-// WithLogger configures a RetryWrapper instance with the provided
-// logr.Logger instance.
+// WithLogger configures a RetryWrapper, ImpersonationWrapper or
+// ConnectionTracingWrapper instance with the provided logr.Logger
+// instance.
 type WithLogger struct{ logr.Logger }
 
 func (l WithLogger) ConfigureRetryWrapper(c *RetryWrapperConfig) {
@@ -199,3 +539,186 @@ type WithMaxRetries uint64
 func (mr WithMaxRetries) ConfigureRetryWrapper(c *RetryWrapperConfig) {
 	c.maxRetries = uint64(mr)
 }
+
+// WithMaxBufferedBodySize limits the size in bytes of request bodies
+// which a RetryWrapper instance buffers in memory so that they can be
+// replayed on retries. Requests which provide a GetBody function are
+// never buffered. Requests with larger bodies are attempted once and
+// not retried. A negative value disables buffering entirely. The
+// default limit is 10 MiB.
+type WithMaxBufferedBodySize int64
+
+func (s WithMaxBufferedBodySize) ConfigureRetryWrapper(c *RetryWrapperConfig) {
+	c.maxBufferedBodySize = int64(s)
+}
+
+// WithPerAttemptTimeout configures a RetryWrapper instance to limit
+// the time taken by each individual attempt including reading the
+// response body. The limit is carved out of the request context so
+// that the overall deadline still applies. Attempts exceeding the
+// limit are retried which prevents a single stalled connection from
+// consuming the time available for all retries.
+type WithPerAttemptTimeout time.Duration
+
+func (t WithPerAttemptTimeout) ConfigureRetryWrapper(c *RetryWrapperConfig) {
+	c.perAttemptTimeout = time.Duration(t)
+}
+
+// WithMaxRetryDuration bounds the total time a RetryWrapper instance
+// spends on a request measured from the first attempt regardless of
+// the BackoffGenerator in use. No wait is scheduled which would end
+// past the limit. A request still failing when the limit is reached
+// results in a *RetryTimeoutError holding the last response.
+type WithMaxRetryDuration time.Duration
+
+func (d WithMaxRetryDuration) ConfigureRetryWrapper(c *RetryWrapperConfig) {
+	c.maxRetryDuration = time.Duration(d)
+}
+
+// maxDurationBackOff stops retries once the next wait
+// would end later than limit after the last reset.
+type maxDurationBackOff struct {
+	backoff.BackOff
+	limit    time.Duration
+	clock    Clock
+	start    time.Time
+	exceeded bool
+}
+
+func (b *maxDurationBackOff) Reset() {
+	b.BackOff.Reset()
+
+	b.start = b.clock.Now()
+	b.exceeded = false
+}
+
+func (b *maxDurationBackOff) NextBackOff() time.Duration {
+	next := b.BackOff.NextBackOff()
+	if next == backoff.Stop {
+		return next
+	}
+
+	if b.clock.Now().Add(next).Sub(b.start) > b.limit {
+		b.exceeded = true
+
+		return backoff.Stop
+	}
+
+	return next
+}
+
+// WithErrorOnExhaustion configures a RetryWrapper instance to return
+// a *RetriesExhaustedError instead of the last response received when
+// a request is still failing after all retries have been used.
+type WithErrorOnExhaustion struct{}
+
+func (WithErrorOnExhaustion) ConfigureRetryWrapper(c *RetryWrapperConfig) {
+	c.errorOnExhaustion = true
+}
+
+// RetryAttemptsHeader is the synthetic response header set by a
+// RetryWrapper configured with WithRetryStateHeader.
+const RetryAttemptsHeader = "X-Client-Retry-Attempts"
+
+// WithRetryStateHeader configures a RetryWrapper instance to set the
+// number of attempts made in the RetryAttemptsHeader of the response
+// returned for libraries which only have access to response headers.
+type WithRetryStateHeader struct{}
+
+func (WithRetryStateHeader) ConfigureRetryWrapper(c *RetryWrapperConfig) {
+	c.retryStateHeader = true
+}
+
+// RetryState describes the attempts a RetryWrapper
+// made to obtain a response.
+type RetryState struct {
+	// Attempts is the number of attempts made starting at 1.
+	Attempts int
+	// TotalDelay is the total time spent waiting between attempts.
+	TotalDelay time.Duration
+	// LastFailure is the outcome of the last failed
+	// attempt or nil if no attempt failed.
+	LastFailure *AttemptError
+}
+
+// ResponseRetryState returns the RetryState of the request which
+// produced res if the request was made through a RetryWrapper.
+func ResponseRetryState(res *http.Response) (RetryState, bool) {
+	if res == nil || res.Request == nil {
+		return RetryState{}, false
+	}
+
+	state, ok := res.Request.Context().Value(retryStateKey{}).(*RetryState)
+	if !ok {
+		return RetryState{}, false
+	}
+
+	return *state, true
+}
+
+type retryStateKey struct{}
+
+// RequestHook is invoked with the attempt number, starting at 1,
+// and the request before each attempt is made. Hooks may modify
+// the request e.g. to update headers.
+type RequestHook func(attempt int, req *http.Request)
+
+// ResponseHook is invoked with the attempt number and the outcome
+// of each attempt. Either res or err may be nil.
+type ResponseHook func(attempt int, req *http.Request, res *http.Response, err error)
+
+// RetryCallback is invoked with the outcome of an attempt which
+// is about to be retried. Returning false aborts further retries
+// in which case the response of the attempt is returned or, if the
+// attempt failed without a response, its error.
+type RetryCallback func(attempt int, req *http.Request, res *http.Response, err error) bool
+
+// WithOnRequest registers a RequestHook with a RetryWrapper instance.
+// This option can be provided multiple times.
+type WithOnRequest RequestHook
+
+func (h WithOnRequest) ConfigureRetryWrapper(c *RetryWrapperConfig) {
+	c.OnRequest = append(c.OnRequest, RequestHook(h))
+}
+
+// WithOnResponse registers a ResponseHook with a RetryWrapper instance.
+// This option can be provided multiple times.
+type WithOnResponse ResponseHook
+
+func (h WithOnResponse) ConfigureRetryWrapper(c *RetryWrapperConfig) {
+	c.OnResponse = append(c.OnResponse, ResponseHook(h))
+}
+
+// WithRetryCallback registers a RetryCallback with a RetryWrapper
+// instance. This option can be provided multiple times.
+type WithRetryCallback RetryCallback
+
+func (cb WithRetryCallback) ConfigureRetryWrapper(c *RetryWrapperConfig) {
+	c.OnRetry = append(c.OnRetry, RetryCallback(cb))
+}
+
+// RetryDebugState is the configuration and retry
+// budget usage reported by RetryWrapper.DebugState.
+type RetryDebugState struct {
+	MaxRetries        uint64                 `json:"maxRetries,omitempty"`
+	MaxRetryDuration  time.Duration          `json:"maxRetryDuration,omitempty"`
+	PerAttemptTimeout time.Duration          `json:"perAttemptTimeout,omitempty"`
+	Budget            *RetryBudgetDebugState `json:"budget,omitempty"`
+}
+
+// DebugState returns the limits of the wrapper
+// and the usage of its retry budget if any.
+func (w *RetryWrapper) DebugState() any {
+	state := RetryDebugState{
+		MaxRetries:        w.cfg.maxRetries,
+		MaxRetryDuration:  w.cfg.maxRetryDuration,
+		PerAttemptTimeout: w.cfg.perAttemptTimeout,
+	}
+
+	if w.cfg.budget != nil {
+		budget := w.cfg.budget.debugState(w.cfg.Clock.Now())
+		state.Budget = &budget
+	}
+
+	return state
+}
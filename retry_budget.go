@@ -0,0 +1,184 @@
+package client
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrRetryBudgetExhausted is reported when a retry is denied
+// by the budget configured with WithRetryBudget.
+var ErrRetryBudgetExhausted = errors.New("retry budget exhausted")
+
+const retryBudgetBuckets = 10
+
+// WithRetryBudget limits the retries performed by a RetryWrapper
+// across all requests so that retry storms cannot amplify load on
+// an already degraded backend. Once the budget is exhausted requests
+// return the last response received instead of being retried.
+type WithRetryBudget struct {
+	// Ratio is the maximum fraction of requests which may be
+	// retries within Window e.g. 0.2 permits one retry for every
+	// five requests. A Ratio of zero disables the ratio limit.
+	Ratio float64
+	// Window is the sliding window over which Ratio is enforced.
+	// Defaults to ten seconds.
+	Window time.Duration
+	// MinRetries is the number of retries permitted within Window
+	// regardless of Ratio so that low traffic clients can still
+	// retry.
+	MinRetries int
+	// MaxConcurrent caps the number of retry attempts in flight at
+	// any time. A value of zero disables the concurrency limit.
+	MaxConcurrent int
+}
+
+func (rb WithRetryBudget) ConfigureRetryWrapper(c *RetryWrapperConfig) {
+	c.budget = newRetryBudget(rb)
+}
+
+type retryBudget struct {
+	ratio      float64
+	minRetries int
+	bucketSize time.Duration
+	sem        chan struct{}
+
+	mu      sync.Mutex
+	buckets [retryBudgetBuckets]retryBudgetBucket
+}
+
+type retryBudgetBucket struct {
+	start    time.Time
+	requests int
+	retries  int
+}
+
+func newRetryBudget(opts WithRetryBudget) *retryBudget {
+	window := opts.Window
+	if window <= 0 {
+		window = 10 * time.Second
+	}
+
+	b := &retryBudget{
+		ratio:      opts.Ratio,
+		minRetries: opts.MinRetries,
+		bucketSize: window / retryBudgetBuckets,
+	}
+
+	if b.bucketSize <= 0 {
+		b.bucketSize = 1
+	}
+
+	if opts.MaxConcurrent > 0 {
+		b.sem = make(chan struct{}, opts.MaxConcurrent)
+	}
+
+	return b
+}
+
+// RecordRequest registers an initial request attempt made at now.
+func (b *retryBudget) RecordRequest(now time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.current(now).requests++
+}
+
+// TryRetry reports whether a retry at now is permitted by the budget. If it
+// is, the retry is recorded and the returned function must be called
+// once the retry attempt has completed.
+func (b *retryBudget) TryRetry(now time.Time) (func(), bool) {
+	if b.sem != nil {
+		select {
+		case b.sem <- struct{}{}:
+		default:
+			return nil, false
+		}
+	}
+
+	release := func() {
+		if b.sem != nil {
+			<-b.sem
+		}
+	}
+
+	if !b.withdraw(now) {
+		release()
+
+		return nil, false
+	}
+
+	return release, true
+}
+
+func (b *retryBudget) withdraw(now time.Time) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.ratio > 0 {
+		var requests, retries int
+
+		for _, bucket := range b.buckets {
+			if now.Sub(bucket.start) >= b.bucketSize*retryBudgetBuckets {
+				continue
+			}
+
+			requests += bucket.requests
+			retries += bucket.retries
+		}
+
+		allowed := int(b.ratio * float64(requests))
+		if allowed < b.minRetries {
+			allowed = b.minRetries
+		}
+
+		if retries >= allowed {
+			return false
+		}
+	}
+
+	b.current(now).retries++
+
+	return true
+}
+
+// current returns the bucket for the given time resetting
+// it first if it belongs to an expired window.
+func (b *retryBudget) current(now time.Time) *retryBudgetBucket {
+	start := now.Truncate(b.bucketSize)
+	bucket := &b.buckets[(start.UnixNano()/int64(b.bucketSize))%retryBudgetBuckets]
+
+	if !bucket.start.Equal(start) {
+		*bucket = retryBudgetBucket{start: start}
+	}
+
+	return bucket
+}
+
+// RetryBudgetDebugState is the usage of a retry budget
+// within its window.
+type RetryBudgetDebugState struct {
+	Requests int `json:"requests"`
+	Retries  int `json:"retries"`
+	Allowed  int `json:"allowed"`
+}
+
+func (b *retryBudget) debugState(now time.Time) RetryBudgetDebugState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var state RetryBudgetDebugState
+
+	for _, bucket := range b.buckets {
+		if now.Sub(bucket.start) >= b.bucketSize*retryBudgetBuckets {
+			continue
+		}
+
+		state.Requests += bucket.requests
+		state.Retries += bucket.retries
+	}
+
+	state.Allowed = max(int(b.ratio*float64(state.Requests)), b.minRetries)
+
+	return state
+}
@@ -0,0 +1,116 @@
+package client
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/mt-sre/client/internal/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// TestRetryBudget ensures that retries across requests are
+// limited once the retry budget has been exhausted.
+func TestRetryBudget(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		Budget        WithRetryBudget
+		Requests      int
+		ExpectedCalls int
+	}{
+		"ratio exhausted": {
+			Budget:        WithRetryBudget{Ratio: 0.5},
+			Requests:      1,
+			ExpectedCalls: 1,
+		},
+		"min retries": {
+			Budget:        WithRetryBudget{Ratio: 0.1, MinRetries: 2},
+			Requests:      2,
+			ExpectedCalls: 4,
+		},
+		"ratio accumulates": {
+			Budget:        WithRetryBudget{Ratio: 0.5},
+			Requests:      4,
+			ExpectedCalls: 6,
+		},
+	} {
+		tc := tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var mrt testutils.MockRoundTripper
+			mrt.
+				On("RoundTrip", mock.Anything).
+				Return(&http.Response{
+					StatusCode: http.StatusServiceUnavailable,
+					Body:       io.NopCloser(bytes.NewBuffer(nil)),
+				}, nil).
+				Times(tc.ExpectedCalls)
+
+			retry := NewRetryWrapper(
+				WithBackoffGenerator(NoBackoffGenerator()),
+				WithMaxRetries(5),
+				tc.Budget,
+			)
+
+			var client http.Client
+			client.Transport = retry.Wrap(&mrt)
+
+			for i := 0; i < tc.Requests; i++ {
+				req := testutils.MockRequest(t, http.MethodGet, nil)
+
+				res, err := client.Do(req)
+				require.NoError(t, err)
+
+				assert.Equal(t, http.StatusServiceUnavailable, res.StatusCode)
+
+				res.Body.Close()
+			}
+
+			mrt.AssertExpectations(t)
+		})
+	}
+}
+
+func TestRetryBudget_MaxConcurrent(t *testing.T) {
+	t.Parallel()
+
+	budget := newRetryBudget(WithRetryBudget{MaxConcurrent: 1})
+
+	release, ok := budget.TryRetry(time.Now())
+	require.True(t, ok)
+
+	_, ok = budget.TryRetry(time.Now())
+	assert.False(t, ok, "concurrent retry should be denied")
+
+	release()
+
+	release, ok = budget.TryRetry(time.Now())
+	require.True(t, ok, "retry should be permitted once released")
+
+	release()
+}
+
+func TestRetryBudget_Window(t *testing.T) {
+	t.Parallel()
+
+	const window = time.Second
+
+	budget := newRetryBudget(WithRetryBudget{
+		Ratio:      0.1,
+		MinRetries: 1,
+		Window:     window,
+	})
+
+	now := time.Now()
+
+	assert.True(t, budget.withdraw(now))
+	assert.False(t, budget.withdraw(now.Add(window/2)), "budget should be exhausted within window")
+	assert.True(t, budget.withdraw(now.Add(2*window)), "budget should be replenished after window")
+}
@@ -0,0 +1,162 @@
+package client_test
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mt-sre/client"
+	"github.com/mt-sre/client/clienttest"
+	"github.com/mt-sre/client/internal/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestRetryWrapperClock ensures that waits between attempts
+// are scheduled on the configured clock.
+func TestRetryWrapperClock(t *testing.T) {
+	t.Parallel()
+
+	clock := clienttest.NewFakeClock(time.Date(2026, time.October, 16, 8, 0, 0, 0, time.UTC))
+
+	var attempts atomic.Int32
+
+	retry := client.NewRetryWrapper(
+		client.WithBackoffGenerator(client.LinearBackoffGenerator(time.Minute, time.Hour)),
+		client.WithClock{clock},
+	)
+
+	rt := retry.Wrap(client.Handler(func(req *http.Request) (*http.Response, error) {
+		code := http.StatusServiceUnavailable
+		if attempts.Add(1) == 3 {
+			code = http.StatusOK
+		}
+
+		return &http.Response{
+			StatusCode: code,
+			Body:       io.NopCloser(bytes.NewBuffer(nil)),
+		}, nil
+	}))
+
+	done := make(chan *http.Response)
+
+	go func() {
+		res, err := rt.RoundTrip(testutils.MockRequest(t, http.MethodGet, nil))
+		assert.NoError(t, err)
+
+		done <- res
+	}()
+
+	clock.BlockUntil(1)
+	assert.EqualValues(t, 1, attempts.Load())
+
+	clock.Advance(time.Minute)
+	clock.BlockUntil(1)
+	assert.EqualValues(t, 2, attempts.Load())
+
+	// the second wait grows linearly
+	clock.Advance(time.Minute)
+	assert.EqualValues(t, 2, attempts.Load())
+
+	clock.Advance(time.Minute)
+
+	res := <-done
+	require.NotNil(t, res)
+	require.NoError(t, res.Body.Close())
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.EqualValues(t, 3, attempts.Load())
+}
+
+// TestRetryWrapperMaxRetryDuration ensures that retries stop once the
+// maximum retry duration would be exceeded and that the last response
+// is returned with a *client.RetryTimeoutError.
+func TestRetryWrapperMaxRetryDuration(t *testing.T) {
+	t.Parallel()
+
+	clock := clienttest.NewFakeClock(time.Date(2026, time.October, 16, 8, 0, 0, 0, time.UTC))
+
+	var attempts atomic.Int32
+
+	retry := client.NewRetryWrapper(
+		client.WithBackoffGenerator(client.ConstantBackoffGenerator(time.Minute)),
+		client.WithMaxRetryDuration(150*time.Second),
+		client.WithClock{clock},
+	)
+
+	rt := retry.Wrap(client.Handler(func(req *http.Request) (*http.Response, error) {
+		attempts.Add(1)
+
+		return &http.Response{
+			StatusCode: http.StatusServiceUnavailable,
+			Body:       io.NopCloser(bytes.NewBufferString("unavailable")),
+		}, nil
+	}))
+
+	done := make(chan error)
+
+	go func() {
+		_, err := rt.RoundTrip(testutils.MockRequest(t, http.MethodGet, nil))
+
+		done <- err
+	}()
+
+	for range 2 {
+		clock.BlockUntil(1)
+		clock.Advance(time.Minute)
+	}
+
+	err := <-done
+	require.Error(t, err)
+
+	var timeout *client.RetryTimeoutError
+	require.ErrorAs(t, err, &timeout)
+
+	assert.EqualValues(t, 3, attempts.Load())
+	assert.Len(t, timeout.Attempts, 3)
+	assert.Equal(t, 2*time.Minute, timeout.Elapsed)
+	assert.True(t, timeout.Timeout())
+
+	require.NotNil(t, timeout.Response)
+	assert.Equal(t, http.StatusServiceUnavailable, timeout.Response.StatusCode)
+
+	body, err := io.ReadAll(timeout.Response.Body)
+	require.NoError(t, err)
+	require.NoError(t, timeout.Response.Body.Close())
+	assert.Equal(t, "unavailable", string(body))
+}
+
+// TestRetryWrapperDebugStateClock ensures that the retry budget
+// reported by DebugState is measured on the configured clock.
+func TestRetryWrapperDebugStateClock(t *testing.T) {
+	t.Parallel()
+
+	clock := clienttest.NewFakeClock(time.Date(2020, time.January, 1, 8, 0, 0, 0, time.UTC))
+
+	retry := client.NewRetryWrapper(
+		client.WithRetryBudget{Ratio: 0.5, Window: time.Minute},
+		client.WithClock{clock},
+	)
+
+	rt := retry.Wrap(client.Handler(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody, Request: req}, nil
+	}))
+
+	res, err := rt.RoundTrip(testutils.MockRequest(t, http.MethodGet, nil))
+	require.NoError(t, err)
+	require.NoError(t, res.Body.Close())
+
+	state, ok := retry.DebugState().(client.RetryDebugState)
+	require.True(t, ok)
+	require.NotNil(t, state.Budget)
+	assert.Equal(t, 1, state.Budget.Requests)
+
+	clock.Advance(2 * time.Minute)
+
+	state, ok = retry.DebugState().(client.RetryDebugState)
+	require.True(t, ok)
+	require.NotNil(t, state.Budget)
+	assert.Equal(t, 0, state.Budget.Requests, "requests must leave the window as the clock advances")
+}
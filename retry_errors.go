@@ -0,0 +1,105 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// RetriesExhaustedError is returned by a RetryWrapper configured
+// with WithErrorOnExhaustion when a request did not succeed before
+// retrying stopped.
+type RetriesExhaustedError struct {
+	// Attempts holds the outcome of every failed attempt in order.
+	Attempts []AttemptError
+	// Reason is the cause for retrying to stop early, e.g. the
+	// request context expiring, or nil if all retries were used.
+	Reason error
+}
+
+func (e *RetriesExhaustedError) Error() string {
+	return fmt.Sprintf("retries exhausted after %d attempts: %v", len(e.Attempts), e.Unwrap())
+}
+
+// Unwrap returns the errors of all attempts joined with
+// the Reason retrying stopped.
+func (e *RetriesExhaustedError) Unwrap() error {
+	errs := make([]error, 0, len(e.Attempts)+1)
+
+	for i := range e.Attempts {
+		errs = append(errs, &e.Attempts[i])
+	}
+
+	if e.Reason != nil {
+		errs = append(errs, e.Reason)
+	}
+
+	return errors.Join(errs...)
+}
+
+// LastStatusCode returns the status code of the last response
+// received or zero if no response was received.
+func (e *RetriesExhaustedError) LastStatusCode() int {
+	for i := len(e.Attempts) - 1; i >= 0; i-- {
+		if code := e.Attempts[i].StatusCode; code != 0 {
+			return code
+		}
+	}
+
+	return 0
+}
+
+// RetryTimeoutError is returned by a RetryWrapper configured with
+// WithMaxRetryDuration when a request did not succeed before the
+// maximum retry duration elapsed. Response holds the last response
+// received, if any, whose body must be closed by the caller.
+type RetryTimeoutError struct {
+	// Limit is the configured maximum retry duration.
+	Limit time.Duration
+	// Elapsed is the time spent since the first attempt.
+	Elapsed time.Duration
+	// Attempts holds the outcome of every failed attempt in order.
+	Attempts []AttemptError
+	Response *http.Response
+}
+
+func (e *RetryTimeoutError) Error() string {
+	return fmt.Sprintf("max retry duration %s exceeded after %d attempts in %s", e.Limit, len(e.Attempts), e.Elapsed)
+}
+
+// Timeout reports true so that the error is treated as a timeout.
+func (e *RetryTimeoutError) Timeout() bool { return true }
+
+// Unwrap returns the errors of all attempts joined.
+func (e *RetryTimeoutError) Unwrap() error {
+	errs := make([]error, 0, len(e.Attempts))
+
+	for i := range e.Attempts {
+		errs = append(errs, &e.Attempts[i])
+	}
+
+	return errors.Join(errs...)
+}
+
+// AttemptError describes the outcome of a single failed attempt.
+// Either StatusCode or Err is set depending on whether a response
+// was received.
+type AttemptError struct {
+	// Attempt is the number of the attempt starting at 1.
+	Attempt    int
+	StatusCode int
+	Err        error
+}
+
+func (e *AttemptError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("attempt %d: %v", e.Attempt, e.Err)
+	}
+
+	return fmt.Sprintf("attempt %d: received status %d (%s)", e.Attempt, e.StatusCode, http.StatusText(e.StatusCode))
+}
+
+func (e *AttemptError) Unwrap() error {
+	return e.Err
+}
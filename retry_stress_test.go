@@ -0,0 +1,250 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var errChaosConnReset = errors.New("read tcp: connection reset by peer")
+
+// chaosOutcome is the result produced by a chaosTransport attempt.
+type chaosOutcome int
+
+const (
+	chaosOK chaosOutcome = iota
+	chaosUnavailable
+	chaosInternalError
+	chaosConnReset
+	chaosOutcomes
+)
+
+// chaosTransport answers requests with outcomes chosen by next while
+// verifying that every attempt carries the complete request body and
+// tracking response bodies to detect leaks.
+type chaosTransport struct {
+	t       testing.TB
+	payload []byte
+	next    func() chaosOutcome
+
+	calls  atomic.Int64
+	mu     sync.Mutex
+	bodies []*chaosBody
+}
+
+func (c *chaosTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	c.calls.Add(1)
+
+	if req.Body != nil {
+		body, err := io.ReadAll(req.Body)
+		req.Body.Close()
+
+		if assert.NoError(c.t, err) {
+			assert.Equal(c.t, c.payload, body, "attempt must carry the complete request body")
+		}
+	}
+
+	var code int
+
+	switch c.next() {
+	case chaosUnavailable:
+		code = http.StatusServiceUnavailable
+	case chaosInternalError:
+		code = http.StatusInternalServerError
+	case chaosConnReset:
+		return nil, errChaosConnReset
+	default:
+		code = http.StatusOK
+	}
+
+	body := &chaosBody{Reader: bytes.NewReader([]byte(http.StatusText(code)))}
+
+	c.mu.Lock()
+	c.bodies = append(c.bodies, body)
+	c.mu.Unlock()
+
+	return &http.Response{
+		StatusCode: code,
+		Body:       body,
+		Request:    req,
+	}, nil
+}
+
+// leaked returns the number of response bodies which were not closed.
+func (c *chaosTransport) leaked() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var n int
+
+	for _, body := range c.bodies {
+		if !body.closed.Load() {
+			n++
+		}
+	}
+
+	return n
+}
+
+type chaosBody struct {
+	io.Reader
+	closed atomic.Bool
+}
+
+func (b *chaosBody) Close() error {
+	b.closed.Store(true)
+
+	return nil
+}
+
+// TestRetryWrapperStress issues concurrent requests with randomized
+// failures through a single RetryWrapper. It is intended to be run
+// with the race detector to surface shared state between requests.
+func TestRetryWrapperStress(t *testing.T) {
+	t.Parallel()
+
+	const (
+		workers    = 16
+		maxRetries = 3
+	)
+
+	requests := 50
+	if testing.Short() {
+		requests = 10
+	}
+
+	payload := []byte("stress payload")
+
+	chaos := &chaosTransport{
+		t:       t,
+		payload: payload,
+		next: func() chaosOutcome {
+			return chaosOutcome(rand.IntN(int(chaosOutcomes)))
+		},
+	}
+
+	rt := NewRetryWrapper(
+		WithBackoffGenerator(ConstantBackoffGenerator(time.Microsecond)),
+		WithMaxRetries(maxRetries),
+		WithRetryBudget{Ratio: 0.5, MinRetries: 100, MaxConcurrent: workers},
+	).Wrap(chaos)
+
+	var (
+		wg      sync.WaitGroup
+		started atomic.Int64
+	)
+
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for i := 0; i < requests; i++ {
+				started.Add(1)
+
+				var body io.Reader = bytes.NewReader(payload)
+				if i%2 == 0 {
+					// hide GetBody so that the body must be buffered
+					body = io.MultiReader(body)
+				}
+
+				req, err := http.NewRequestWithContext(context.Background(), http.MethodPut, "http://stress.test", body)
+				if !assert.NoError(t, err) {
+					return
+				}
+
+				res, err := rt.RoundTrip(req)
+				if err != nil {
+					assert.Nil(t, res)
+					assert.ErrorIs(t, err, errChaosConnReset)
+
+					continue
+				}
+
+				if assert.NotNil(t, res) {
+					_, err = io.Copy(io.Discard, res.Body)
+					assert.NoError(t, err)
+					assert.NoError(t, res.Body.Close())
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	assert.Zero(t, chaos.leaked(), "response bodies must not leak")
+	assert.LessOrEqual(t, chaos.calls.Load(), started.Load()*(maxRetries+1))
+}
+
+// FuzzRetryWrapper drives a single request through a RetryWrapper
+// with a scripted sequence of attempt outcomes and verifies the
+// invariants of the retry path.
+func FuzzRetryWrapper(f *testing.F) {
+	f.Add([]byte{0}, []byte("body"), true, uint8(3))
+	f.Add([]byte{1, 1, 0}, []byte("body"), false, uint8(3))
+	f.Add([]byte{3, 3, 3, 3}, []byte{}, false, uint8(2))
+	f.Add([]byte{2, 3, 1, 0}, []byte("some longer request body"), true, uint8(5))
+
+	f.Fuzz(func(t *testing.T, script, payload []byte, useGetBody bool, maxRetries uint8) {
+		maxRetries %= 8
+
+		var step atomic.Int64
+
+		chaos := &chaosTransport{
+			t:       t,
+			payload: payload,
+			next: func() chaosOutcome {
+				i := step.Add(1) - 1
+				if int(i) >= len(script) {
+					return chaosOK
+				}
+
+				return chaosOutcome(script[i] % byte(chaosOutcomes))
+			},
+		}
+
+		opts := []RetryWrapperOption{
+			WithBackoffGenerator(NoBackoffGenerator()),
+		}
+
+		if maxRetries > 0 {
+			opts = append(opts, WithMaxRetries(maxRetries))
+		}
+
+		var body io.Reader = bytes.NewReader(payload)
+		if !useGetBody {
+			body = io.MultiReader(body)
+		}
+
+		req, err := http.NewRequest(http.MethodPut, "http://fuzz.test", body)
+		require.NoError(t, err)
+
+		res, err := NewRetryWrapper(opts...).Wrap(chaos).RoundTrip(req)
+		if err != nil {
+			require.Nil(t, res, "a response must not be returned with an error")
+		} else {
+			require.NotNil(t, res, "a response must be returned without an error")
+
+			_, err = io.Copy(io.Discard, res.Body)
+			require.NoError(t, err)
+			require.NoError(t, res.Body.Close())
+		}
+
+		if maxRetries > 0 {
+			assert.LessOrEqual(t, chaos.calls.Load(), int64(maxRetries)+1)
+		}
+
+		assert.Zero(t, chaos.leaked(), "response bodies must not leak")
+	})
+}
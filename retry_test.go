@@ -6,13 +6,17 @@ import (
 	"errors"
 	"io"
 	"net/http"
+	"net/http/httptest"
+	"strconv"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/go-logr/logr"
 	"github.com/mt-sre/client/internal/testutils"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 )
 
@@ -292,3 +296,375 @@ func TestWithLogger_ConfigureRetryWrapper(t *testing.T) {
 	// check that the Logger field is set to the logger instance
 	require.Equal(t, logger, config.Logger, "Logger field is not set correctly")
 }
+
+// TestRetryWrapperHooks ensures that request and response hooks are
+// invoked for every attempt and that retry callbacks can abort retries.
+func TestRetryWrapperHooks(t *testing.T) {
+	t.Parallel()
+
+	const abortAfter = 2
+
+	req := testutils.MockRequest(t, http.MethodGet, nil)
+
+	var mrt testutils.MockRoundTripper
+	mrt.
+		On("RoundTrip", req).
+		Return(&http.Response{
+			StatusCode: http.StatusServiceUnavailable,
+			Body:       io.NopCloser(bytes.NewBuffer(nil)),
+		}, nil).
+		Times(abortAfter)
+
+	var (
+		requestAttempts  []int
+		responseAttempts []int
+		retryAttempts    []int
+	)
+
+	retry := NewRetryWrapper(
+		WithBackoffGenerator(NoBackoffGenerator()),
+		WithMaxRetries(5),
+		WithOnRequest(func(attempt int, req *http.Request) {
+			requestAttempts = append(requestAttempts, attempt)
+
+			req.Header.Set("X-Attempt", strconv.Itoa(attempt))
+		}),
+		WithOnResponse(func(attempt int, _ *http.Request, res *http.Response, err error) {
+			assert.NoError(t, err)
+			assert.Equal(t, http.StatusServiceUnavailable, res.StatusCode)
+
+			responseAttempts = append(responseAttempts, attempt)
+		}),
+		WithRetryCallback(func(attempt int, _ *http.Request, _ *http.Response, _ error) bool {
+			retryAttempts = append(retryAttempts, attempt)
+
+			return attempt < abortAfter
+		}),
+	)
+
+	var client http.Client
+	client.Transport = retry.Wrap(&mrt)
+
+	res, err := client.Do(req)
+	require.NoError(t, err)
+	defer res.Body.Close()
+
+	assert.Equal(t, http.StatusServiceUnavailable, res.StatusCode)
+	assert.Equal(t, "2", req.Header.Get("X-Attempt"))
+	assert.Equal(t, []int{1, 2}, requestAttempts)
+	assert.Equal(t, []int{1, 2}, responseAttempts)
+	assert.Equal(t, []int{1, 2}, retryAttempts)
+
+	mrt.AssertExpectations(t)
+}
+
+// TestRetryWrapperRetryCallbackAbortOnError ensures that aborting
+// retries after a failed attempt returns the attempt's error.
+func TestRetryWrapperRetryCallbackAbortOnError(t *testing.T) {
+	t.Parallel()
+
+	req := testutils.MockRequest(t, http.MethodGet, nil)
+
+	var mrt testutils.MockRoundTripper
+	mrt.
+		On("RoundTrip", req).
+		Return((*http.Response)(nil), errors.New("connection refused")).
+		Once()
+
+	retry := NewRetryWrapper(
+		WithBackoffGenerator(NoBackoffGenerator()),
+		WithMaxRetries(5),
+		WithRetryCallback(func(int, *http.Request, *http.Response, error) bool {
+			return false
+		}),
+	)
+
+	_, err := retry.Wrap(&mrt).RoundTrip(req)
+	require.Error(t, err)
+
+	assert.ErrorContains(t, err, "connection refused")
+
+	mrt.AssertExpectations(t)
+}
+
+// TestRetryWrapperErrorOnExhaustion ensures that a RetriesExhaustedError
+// describing every attempt is returned once retries are exhausted.
+func TestRetryWrapperErrorOnExhaustion(t *testing.T) {
+	t.Parallel()
+
+	errRefused := errors.New("connection refused")
+
+	req := testutils.MockRequest(t, http.MethodGet, nil)
+
+	var mrt testutils.MockRoundTripper
+	mrt.
+		On("RoundTrip", req).
+		Return((*http.Response)(nil), errRefused).
+		Once()
+	mrt.
+		On("RoundTrip", req).
+		Return(&http.Response{
+			StatusCode: http.StatusServiceUnavailable,
+			Body:       io.NopCloser(bytes.NewBuffer(nil)),
+		}, nil).
+		Twice()
+
+	retry := NewRetryWrapper(
+		WithBackoffGenerator(NoBackoffGenerator()),
+		WithMaxRetries(2),
+		WithErrorOnExhaustion{},
+	)
+
+	_, err := retry.Wrap(&mrt).RoundTrip(req)
+	require.Error(t, err)
+
+	var exhausted *RetriesExhaustedError
+	require.ErrorAs(t, err, &exhausted)
+
+	assert.Equal(t, []AttemptError{
+		{Attempt: 1, Err: errRefused},
+		{Attempt: 2, StatusCode: http.StatusServiceUnavailable},
+		{Attempt: 3, StatusCode: http.StatusServiceUnavailable},
+	}, exhausted.Attempts)
+	assert.Equal(t, http.StatusServiceUnavailable, exhausted.LastStatusCode())
+	assert.ErrorIs(t, err, errRefused)
+	assert.NoError(t, exhausted.Reason)
+
+	mrt.AssertExpectations(t)
+}
+
+// TestRetryWrapperErrorOnExhaustionSuccess ensures that successful
+// requests are unaffected by WithErrorOnExhaustion.
+func TestRetryWrapperErrorOnExhaustionSuccess(t *testing.T) {
+	t.Parallel()
+
+	req := testutils.MockRequest(t, http.MethodGet, nil)
+
+	var mrt testutils.MockRoundTripper
+	mrt.
+		On("RoundTrip", req).
+		Return(&http.Response{
+			StatusCode: http.StatusServiceUnavailable,
+			Body:       io.NopCloser(bytes.NewBuffer(nil)),
+		}, nil).
+		Once()
+	mrt.
+		On("RoundTrip", req).
+		Return(&http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(bytes.NewBuffer(nil)),
+		}, nil).
+		Once()
+
+	retry := NewRetryWrapper(
+		WithBackoffGenerator(NoBackoffGenerator()),
+		WithMaxRetries(2),
+		WithErrorOnExhaustion{},
+	)
+
+	res, err := retry.Wrap(&mrt).RoundTrip(req)
+	require.NoError(t, err)
+	defer res.Body.Close()
+
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+
+	mrt.AssertExpectations(t)
+}
+
+// TestRetryWrapperRequestBody ensures that request bodies are
+// replayed on every attempt and that bodies which cannot be
+// replayed are sent once without retries.
+func TestRetryWrapperRequestBody(t *testing.T) {
+	t.Parallel()
+
+	const payload = "payload"
+
+	for name, tc := range map[string]struct {
+		Options       []RetryWrapperOption
+		UseGetBody    bool
+		ExpectedCalls int
+	}{
+		"buffered": {
+			ExpectedCalls: 3,
+		},
+		"get body": {
+			Options:       []RetryWrapperOption{WithMaxBufferedBodySize(-1)},
+			UseGetBody:    true,
+			ExpectedCalls: 3,
+		},
+		"exceeds buffer limit": {
+			Options:       []RetryWrapperOption{WithMaxBufferedBodySize(len(payload) - 1)},
+			ExpectedCalls: 1,
+		},
+		"within buffer limit": {
+			Options:       []RetryWrapperOption{WithMaxBufferedBodySize(len(payload))},
+			ExpectedCalls: 3,
+		},
+		"buffering disabled": {
+			Options:       []RetryWrapperOption{WithMaxBufferedBodySize(-1)},
+			ExpectedCalls: 1,
+		},
+	} {
+		tc := tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			req := testutils.MockRequest(t, http.MethodPut, io.NopCloser(bytes.NewBufferString(payload)))
+			req.GetBody = nil
+
+			getBodyCalls := 0
+
+			if tc.UseGetBody {
+				req.GetBody = func() (io.ReadCloser, error) {
+					getBodyCalls++
+
+					return io.NopCloser(bytes.NewBufferString(payload)), nil
+				}
+			}
+
+			var mrt testutils.MockRoundTripper
+			mrt.
+				On("RoundTrip", req).
+				Run(func(args mock.Arguments) {
+					body, err := io.ReadAll(args.Get(0).(*http.Request).Body)
+					require.NoError(t, err)
+
+					assert.Equal(t, payload, string(body))
+				}).
+				Return(&http.Response{
+					StatusCode: http.StatusServiceUnavailable,
+					Body:       io.NopCloser(bytes.NewBuffer(nil)),
+				}, nil).
+				Times(tc.ExpectedCalls)
+
+			opts := append([]RetryWrapperOption{
+				WithBackoffGenerator(NoBackoffGenerator()),
+				WithMaxRetries(2),
+			}, tc.Options...)
+
+			res, err := NewRetryWrapper(opts...).Wrap(&mrt).RoundTrip(req)
+			require.NoError(t, err)
+			defer res.Body.Close()
+
+			assert.Equal(t, http.StatusServiceUnavailable, res.StatusCode)
+
+			if tc.UseGetBody {
+				assert.Equal(t, tc.ExpectedCalls-1, getBodyCalls)
+			}
+
+			mrt.AssertExpectations(t)
+		})
+	}
+}
+
+// TestRetryWrapperPerAttemptTimeout ensures that stalled attempts
+// are abandoned and retried within the overall request deadline.
+func TestRetryWrapperPerAttemptTimeout(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		StalledAttempts    int32
+		ExpectedStatusCode int
+		ExpectedErr        error
+	}{
+		"stalled attempt is retried": {
+			StalledAttempts:    1,
+			ExpectedStatusCode: http.StatusOK,
+		},
+		"all attempts stall": {
+			StalledAttempts: 3,
+			ExpectedErr:     ErrAttemptTimeout,
+		},
+	} {
+		tc := tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var calls atomic.Int32
+
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if calls.Add(1) <= tc.StalledAttempts {
+					select {
+					case <-r.Context().Done():
+					case <-time.After(5 * time.Second):
+					}
+
+					return
+				}
+
+				_, err := w.Write([]byte("ok"))
+				assert.NoError(t, err)
+			}))
+			t.Cleanup(srv.Close)
+
+			client := NewClient(
+				WithWrapper{TransportWrapper: NewRetryWrapper(
+					WithBackoffGenerator(NoBackoffGenerator()),
+					WithMaxRetries(2),
+					WithPerAttemptTimeout(50*time.Millisecond),
+				)},
+			)
+
+			res, err := client.Get(context.Background(), srv.URL, WithRequestTimeout(5*time.Second))
+			if tc.ExpectedErr != nil {
+				require.ErrorIs(t, err, tc.ExpectedErr)
+				assert.Equal(t, int32(3), calls.Load())
+
+				return
+			}
+
+			require.NoError(t, err)
+
+			body, err := io.ReadAll(res.Body)
+			require.NoError(t, err)
+			require.NoError(t, res.Body.Close())
+
+			assert.Equal(t, tc.ExpectedStatusCode, res.StatusCode)
+			assert.Equal(t, "ok", string(body))
+			assert.Equal(t, tc.StalledAttempts+1, calls.Load())
+		})
+	}
+}
+
+// TestResponseRetryState ensures that the attempts made for a
+// response are available from the response and its headers.
+func TestResponseRetryState(t *testing.T) {
+	t.Parallel()
+
+	var attempts atomic.Int32
+
+	retry := NewRetryWrapper(
+		WithBackoffGenerator(ConstantBackoffGenerator(time.Millisecond)),
+		WithRetryStateHeader{},
+	)
+
+	res, err := retry.Wrap(Handler(func(req *http.Request) (*http.Response, error) {
+		code := http.StatusServiceUnavailable
+		if attempts.Add(1) == 3 {
+			code = http.StatusOK
+		}
+
+		return &http.Response{
+			StatusCode: code,
+			Header:     http.Header{},
+			Body:       io.NopCloser(bytes.NewBuffer(nil)),
+		}, nil
+	})).RoundTrip(testutils.MockRequest(t, http.MethodGet, nil))
+	require.NoError(t, err)
+	require.NoError(t, res.Body.Close())
+
+	state, ok := ResponseRetryState(res)
+	require.True(t, ok)
+
+	assert.Equal(t, RetryState{
+		Attempts:    3,
+		TotalDelay:  2 * time.Millisecond,
+		LastFailure: &AttemptError{Attempt: 2, StatusCode: http.StatusServiceUnavailable},
+	}, state)
+	assert.Equal(t, "3", res.Header.Get(RetryAttemptsHeader))
+
+	_, ok = ResponseRetryState(&http.Response{Request: testutils.MockRequest(t, http.MethodGet, nil)})
+	assert.False(t, ok)
+}
@@ -0,0 +1,132 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// ErrMalformedResponse is returned by a SanitizeWrapper when
+// a response fails validation.
+var ErrMalformedResponse = errors.New("malformed response")
+
+// NewSanitizeWrapper returns a TransportWrapper which rejects
+// ambiguous responses which could indicate request smuggling or
+// response splitting, e.g. when traversing shared proxies. A
+// variadic slice of options can be provided to configure the
+// validation from default.
+func NewSanitizeWrapper(opts ...SanitizeOption) *SanitizeWrapper {
+	var cfg SanitizeConfig
+
+	cfg.Option(opts...)
+	cfg.Default()
+
+	return &SanitizeWrapper{
+		cfg: cfg,
+	}
+}
+
+type SanitizeWrapper struct {
+	cfg SanitizeConfig
+	rt  http.RoundTripper
+}
+
+func (w *SanitizeWrapper) Wrap(rt http.RoundTripper) http.RoundTripper {
+	w.rt = rt
+
+	return w
+}
+
+func (w *SanitizeWrapper) RoundTrip(req *http.Request) (*http.Response, error) {
+	res, err := w.rt.RoundTrip(req)
+	if err != nil {
+		return res, err
+	}
+
+	if err := w.validate(res); err != nil {
+		if res.Body != nil {
+			res.Body.Close()
+		}
+
+		return nil, fmt.Errorf("%w: %w", ErrMalformedResponse, err)
+	}
+
+	return res, nil
+}
+
+func (w *SanitizeWrapper) validate(res *http.Response) error {
+	contentLengths := res.Header.Values("Content-Length")
+
+	if len(contentLengths) > 0 && (len(res.TransferEncoding) > 0 || len(res.Header.Values("Transfer-Encoding")) > 0) {
+		return errors.New("both Content-Length and Transfer-Encoding present")
+	}
+
+	for _, val := range contentLengths {
+		if val != contentLengths[0] {
+			return fmt.Errorf("conflicting Content-Length values %q", contentLengths)
+		}
+	}
+
+	for _, name := range w.cfg.CriticalHeaders {
+		if n := len(res.Header.Values(name)); n > 1 {
+			return fmt.Errorf("header %q present %d times", http.CanonicalHeaderKey(name), n)
+		}
+	}
+
+	if w.cfg.MaxHeaderDuplicates > 0 {
+		for name, vals := range res.Header {
+			if len(vals) > w.cfg.MaxHeaderDuplicates {
+				return fmt.Errorf("header %q present %d times exceeding limit of %d", name, len(vals), w.cfg.MaxHeaderDuplicates)
+			}
+		}
+	}
+
+	return nil
+}
+
+type SanitizeConfig struct {
+	// CriticalHeaders lists headers which may appear at most once.
+	CriticalHeaders []string
+	// MaxHeaderDuplicates limits the number of values any single
+	// header may have. A value of zero disables the limit.
+	MaxHeaderDuplicates int
+}
+
+func (c *SanitizeConfig) Option(opts ...SanitizeOption) {
+	for _, opt := range opts {
+		opt.ConfigureSanitize(c)
+	}
+}
+
+func (c *SanitizeConfig) Default() {
+	if c.CriticalHeaders == nil {
+		c.CriticalHeaders = []string{
+			"Content-Type",
+			"Content-Encoding",
+			"Transfer-Encoding",
+			"Location",
+		}
+	}
+}
+
+type SanitizeOption interface {
+	ConfigureSanitize(*SanitizeConfig)
+}
+
+// WithMaxResponseHeaderDuplicates limits the number of values any
+// single response header may have before the response is rejected.
+type WithMaxResponseHeaderDuplicates int
+
+func (m WithMaxResponseHeaderDuplicates) ConfigureSanitize(c *SanitizeConfig) {
+	c.MaxHeaderDuplicates = int(m)
+}
+
+// WithCriticalHeaders replaces the set of response headers which
+// may appear at most once. By default these are Content-Type,
+// Content-Encoding, Transfer-Encoding and Location. Content-Length
+// is always validated regardless of this option.
+type WithCriticalHeaders []string
+
+func (ch WithCriticalHeaders) ConfigureSanitize(c *SanitizeConfig) {
+	c.CriticalHeaders = append([]string{}, ch...)
+}
@@ -0,0 +1,111 @@
+package client
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/mt-sre/client/internal/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSanitizeWrapperInterfaces(t *testing.T) {
+	t.Parallel()
+
+	require.Implements(t, new(http.RoundTripper), new(SanitizeWrapper))
+
+	require.Implements(t, new(TransportWrapper), new(SanitizeWrapper))
+}
+
+func TestSanitizeWrapper(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		Options          []SanitizeOption
+		Header           http.Header
+		TransferEncoding []string
+		ShouldReject     bool
+	}{
+		"valid": {
+			Header: http.Header{
+				"Content-Length": {"4"},
+				"Content-Type":   {"text/plain"},
+				"Set-Cookie":     {"a=1", "b=2"},
+			},
+		},
+		"content length and transfer encoding": {
+			Header:           http.Header{"Content-Length": {"4"}},
+			TransferEncoding: []string{"chunked"},
+			ShouldReject:     true,
+		},
+		"content length and transfer encoding header": {
+			Header: http.Header{
+				"Content-Length":    {"4"},
+				"Transfer-Encoding": {"chunked"},
+			},
+			ShouldReject: true,
+		},
+		"conflicting content length": {
+			Header:       http.Header{"Content-Length": {"4", "5"}},
+			ShouldReject: true,
+		},
+		"repeated identical content length": {
+			Header: http.Header{"Content-Length": {"4", "4"}},
+		},
+		"duplicate critical header": {
+			Header:       http.Header{"Location": {"/a", "/b"}},
+			ShouldReject: true,
+		},
+		"custom critical header": {
+			Options:      []SanitizeOption{WithCriticalHeaders{"X-Request-Id"}},
+			Header:       http.Header{"X-Request-Id": {"a", "b"}},
+			ShouldReject: true,
+		},
+		"custom critical header replaces defaults": {
+			Options: []SanitizeOption{WithCriticalHeaders{"X-Request-Id"}},
+			Header:  http.Header{"Location": {"/a", "/b"}},
+		},
+		"max duplicates exceeded": {
+			Options:      []SanitizeOption{WithMaxResponseHeaderDuplicates(2)},
+			Header:       http.Header{"Set-Cookie": {"a=1", "b=2", "c=3"}},
+			ShouldReject: true,
+		},
+		"max duplicates not exceeded": {
+			Options: []SanitizeOption{WithMaxResponseHeaderDuplicates(2)},
+			Header:  http.Header{"Set-Cookie": {"a=1", "b=2"}},
+		},
+	} {
+		tc := tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			req := testutils.MockRequest(t, http.MethodGet, nil)
+
+			var mrt testutils.MockRoundTripper
+			mrt.
+				On("RoundTrip", req).
+				Return(&http.Response{
+					StatusCode:       http.StatusOK,
+					Header:           tc.Header,
+					TransferEncoding: tc.TransferEncoding,
+					Body:             io.NopCloser(bytes.NewBufferString("test")),
+				}, nil)
+
+			res, err := NewSanitizeWrapper(tc.Options...).Wrap(&mrt).RoundTrip(req)
+			if tc.ShouldReject {
+				require.Error(t, err)
+				assert.ErrorIs(t, err, ErrMalformedResponse)
+
+				return
+			}
+
+			require.NoError(t, err)
+			defer res.Body.Close()
+
+			mrt.AssertExpectations(t)
+		})
+	}
+}
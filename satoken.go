@@ -0,0 +1,183 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultServiceAccountTokenPath is the path at which Kubernetes
+// mounts the projected service account token of a pod.
+const DefaultServiceAccountTokenPath = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+
+// defaultTokenRefreshInterval matches the interval at which
+// client-go re-reads projected service account tokens.
+const defaultTokenRefreshInterval = time.Minute
+
+var errEmptyServiceAccountToken = errors.New("service account token is empty")
+
+// NewServiceAccountTokenWrapper returns a TransportWrapper which
+// authenticates requests with the Kubernetes service account token
+// mounted into the pod. The kubelet rotates projected tokens in place
+// so the token file is re-read once the refresh interval has elapsed
+// and after any 401 Unauthorized response. If re-reading fails the
+// previously read token continues to be used.
+func NewServiceAccountTokenWrapper(opts ...ServiceAccountTokenOption) *ServiceAccountTokenWrapper {
+	var cfg ServiceAccountTokenConfig
+
+	cfg.Option(opts...)
+	cfg.Default()
+
+	return &ServiceAccountTokenWrapper{
+		cfg: cfg,
+	}
+}
+
+type ServiceAccountTokenWrapper struct {
+	cfg ServiceAccountTokenConfig
+	rt  http.RoundTripper
+
+	mu     sync.Mutex
+	token  string
+	readAt time.Time
+}
+
+func (w *ServiceAccountTokenWrapper) Wrap(rt http.RoundTripper) http.RoundTripper {
+	w.rt = rt
+
+	return w
+}
+
+func (w *ServiceAccountTokenWrapper) RoundTrip(req *http.Request) (*http.Response, error) {
+	if isCrossHostRedirect(req) {
+		return w.rt.RoundTrip(req)
+	}
+
+	token, refreshed, err := w.currentToken()
+	if refreshed {
+		emitEvent(req.Context(), &TokenRefreshed{Source: "service account"})
+	}
+
+	if err != nil {
+		if req.Body != nil {
+			req.Body.Close()
+		}
+
+		return nil, err
+	}
+
+	out := req.Clone(req.Context())
+	out.Header.Set("Authorization", "Bearer "+token)
+
+	res, err := w.rt.RoundTrip(out)
+	if err == nil && res.StatusCode == http.StatusUnauthorized {
+		w.invalidate()
+	}
+
+	return res, err
+}
+
+// currentToken returns the token re-reading it once the refresh
+// interval has elapsed and reports whether a new token was read.
+func (w *ServiceAccountTokenWrapper) currentToken() (string, bool, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	now := w.cfg.Clock.Now()
+
+	if w.token != "" && now.Sub(w.readAt) < w.cfg.RefreshInterval {
+		return w.token, false, nil
+	}
+
+	token, err := readServiceAccountToken(w.cfg.Path)
+	if err != nil {
+		if w.token != "" {
+			return w.token, false, nil
+		}
+
+		return "", false, err
+	}
+
+	refreshed := token != w.token
+
+	w.token = token
+	w.readAt = now
+
+	return token, refreshed, nil
+}
+
+// invalidate causes the token to be re-read by the next request.
+func (w *ServiceAccountTokenWrapper) invalidate() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.readAt = time.Time{}
+}
+
+func readServiceAccountToken(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("reading service account token: %w", err)
+	}
+
+	token := strings.TrimSpace(string(data))
+	if token == "" {
+		return "", fmt.Errorf("%w: %s", errEmptyServiceAccountToken, path)
+	}
+
+	return token, nil
+}
+
+type ServiceAccountTokenConfig struct {
+	Path string
+	// RefreshInterval is the maximum age of a token
+	// before the token file is read again.
+	RefreshInterval time.Duration
+	// Clock provides the time at which the token
+	// is read again. Defaults to RealClock.
+	Clock Clock
+}
+
+func (c *ServiceAccountTokenConfig) Option(opts ...ServiceAccountTokenOption) {
+	for _, opt := range opts {
+		opt.ConfigureServiceAccountToken(c)
+	}
+}
+
+func (c *ServiceAccountTokenConfig) Default() {
+	if c.Path == "" {
+		c.Path = DefaultServiceAccountTokenPath
+	}
+
+	if c.RefreshInterval <= 0 {
+		c.RefreshInterval = defaultTokenRefreshInterval
+	}
+
+	if c.Clock == nil {
+		c.Clock = RealClock{}
+	}
+}
+
+type ServiceAccountTokenOption interface {
+	ConfigureServiceAccountToken(*ServiceAccountTokenConfig)
+}
+
+// WithTokenPath configures a ServiceAccountTokenWrapper to read
+// the token from the given path instead of the default location.
+type WithTokenPath string
+
+func (p WithTokenPath) ConfigureServiceAccountToken(c *ServiceAccountTokenConfig) {
+	c.Path = string(p)
+}
+
+// WithTokenRefreshInterval configures the maximum age of a token
+// read by a ServiceAccountTokenWrapper. Defaults to one minute.
+type WithTokenRefreshInterval time.Duration
+
+func (i WithTokenRefreshInterval) ConfigureServiceAccountToken(c *ServiceAccountTokenConfig) {
+	c.RefreshInterval = time.Duration(i)
+}
@@ -0,0 +1,126 @@
+package client
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mt-sre/client/internal/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServiceAccountTokenWrapperInterfaces(t *testing.T) {
+	t.Parallel()
+
+	require.Implements(t, new(http.RoundTripper), new(ServiceAccountTokenWrapper))
+
+	require.Implements(t, new(TransportWrapper), new(ServiceAccountTokenWrapper))
+}
+
+func TestServiceAccountTokenWrapper(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(path, []byte("token-1\n"), 0o600))
+
+	var (
+		tokens   []string
+		statuses = []int{http.StatusOK, http.StatusOK, http.StatusUnauthorized, http.StatusOK, http.StatusOK}
+	)
+
+	mrt := &testutils.MockRoundTripper{}
+	for _, status := range statuses {
+		mrt.
+			On("RoundTrip", mock.Anything).
+			Run(func(args mock.Arguments) {
+				tokens = append(tokens, args.Get(0).(*http.Request).Header.Get("Authorization"))
+			}).
+			Return(&http.Response{StatusCode: status}, nil).
+			Once()
+	}
+
+	now := time.Unix(1700000000, 0)
+
+	w := NewServiceAccountTokenWrapper(
+		WithTokenPath(path),
+		WithTokenRefreshInterval(time.Minute),
+		WithClock{funcClock{now: func() time.Time { return now }}},
+	)
+
+	rt := w.Wrap(mrt)
+
+	do := func() {
+		t.Helper()
+
+		req := testutils.MockRequest(t, http.MethodGet, nil)
+
+		_, err := rt.RoundTrip(req)
+		require.NoError(t, err)
+		assert.Empty(t, req.Header.Get("Authorization"), "original request must not be modified")
+	}
+
+	// token is cached within the refresh interval
+	do()
+	require.NoError(t, os.WriteFile(path, []byte("token-2"), 0o600))
+	do()
+
+	// token is re-read after the refresh interval
+	now = now.Add(time.Minute)
+	do()
+
+	// token is re-read after a 401 response
+	require.NoError(t, os.WriteFile(path, []byte("token-3"), 0o600))
+	do()
+
+	// previous token is used if the file cannot be read
+	require.NoError(t, os.Remove(path))
+	now = now.Add(time.Minute)
+	do()
+
+	assert.Equal(t, []string{
+		"Bearer token-1",
+		"Bearer token-1",
+		"Bearer token-2",
+		"Bearer token-3",
+		"Bearer token-3",
+	}, tokens)
+
+	mrt.AssertExpectations(t)
+}
+
+func TestServiceAccountTokenWrapperMissingToken(t *testing.T) {
+	t.Parallel()
+
+	for name, content := range map[string]*string{
+		"missing file": nil,
+		"empty file":   new(string),
+	} {
+		content := content
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			path := filepath.Join(t.TempDir(), "token")
+			if content != nil {
+				require.NoError(t, os.WriteFile(path, []byte(*content), 0o600))
+			}
+
+			mrt := &testutils.MockRoundTripper{}
+
+			rt := NewServiceAccountTokenWrapper(WithTokenPath(path)).Wrap(mrt)
+
+			req, err := http.NewRequest(http.MethodPost, "https://example.com", strings.NewReader("body"))
+			require.NoError(t, err)
+
+			_, err = rt.RoundTrip(req)
+			assert.Error(t, err)
+
+			mrt.AssertNotCalled(t, "RoundTrip", mock.Anything)
+		})
+	}
+}
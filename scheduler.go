@@ -0,0 +1,260 @@
+package client
+
+import (
+	"container/heap"
+	"context"
+	"net/http"
+	"sync"
+)
+
+const defaultMaxConcurrentPerHost = 10
+
+// Priority orders requests waiting to be dispatched by a
+// SchedulerWrapper. Requests with higher priorities are
+// dispatched first.
+type Priority int
+
+const (
+	PriorityLow    Priority = -1
+	PriorityNormal Priority = 0
+	PriorityHigh   Priority = 1
+)
+
+// WithPriority performs a single request with the given Priority.
+// The Priority only takes effect if the Client is configured with
+// a SchedulerWrapper.
+type WithPriority Priority
+
+func (p WithPriority) ConfigureRequest(c *RequestConfig) {
+	prio := Priority(p)
+
+	c.Priority = &prio
+}
+
+type priorityKey struct{}
+
+// ContextWithPriority returns a copy of ctx which carries the given
+// Priority. Requests made with the returned context through a
+// SchedulerWrapper are dispatched according to the Priority.
+func ContextWithPriority(ctx context.Context, p Priority) context.Context {
+	return context.WithValue(ctx, priorityKey{}, p)
+}
+
+func priorityFromContext(ctx context.Context) Priority {
+	p, _ := ctx.Value(priorityKey{}).(Priority)
+
+	return p
+}
+
+// NewSchedulerWrapper returns a TransportWrapper which bounds the
+// number of concurrent requests per host. Requests exceeding the
+// limit wait until a slot is released, which happens once the
+// response body is closed or the request fails, and are dispatched
+// in order of their Priority and then in arrival order. This lets
+// interactive requests overtake background traffic sharing a Client.
+func NewSchedulerWrapper(opts ...SchedulerOption) *SchedulerWrapper {
+	var cfg SchedulerConfig
+
+	cfg.Option(opts...)
+	cfg.Default()
+
+	return &SchedulerWrapper{
+		cfg:   cfg,
+		hosts: make(map[string]*schedulerHost),
+	}
+}
+
+type SchedulerWrapper struct {
+	cfg SchedulerConfig
+	rt  http.RoundTripper
+
+	mu    sync.Mutex
+	hosts map[string]*schedulerHost
+	seq   uint64
+}
+
+func (w *SchedulerWrapper) Wrap(rt http.RoundTripper) http.RoundTripper {
+	w.rt = rt
+
+	return w
+}
+
+func (w *SchedulerWrapper) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	host := req.URL.Host
+
+	if err := w.acquire(ctx, host, priorityFromContext(ctx)); err != nil {
+		if req.Body != nil {
+			req.Body.Close()
+		}
+
+		return nil, err
+	}
+
+	var once sync.Once
+
+	release := func() { once.Do(func() { w.release(host) }) }
+
+	res, err := w.rt.RoundTrip(req)
+	if err != nil {
+		release()
+
+		return nil, err
+	}
+
+	// the slot remains occupied until the body is consumed
+	res.Body = newCancelOnCloseBody(ctx, release, res.Body)
+
+	return res, nil
+}
+
+// acquire waits for a slot for host to become available.
+func (w *SchedulerWrapper) acquire(ctx context.Context, host string, prio Priority) error {
+	w.mu.Lock()
+
+	h, ok := w.hosts[host]
+	if !ok {
+		h = &schedulerHost{}
+		w.hosts[host] = h
+	}
+
+	if h.active < w.cfg.MaxConcurrentPerHost && len(h.queue) == 0 {
+		h.active++
+		w.mu.Unlock()
+
+		return nil
+	}
+
+	waiter := &schedulerWaiter{
+		priority: prio,
+		seq:      w.seq,
+		ready:    make(chan struct{}),
+	}
+
+	w.seq++
+
+	heap.Push(&h.queue, waiter)
+	w.mu.Unlock()
+
+	select {
+	case <-waiter.ready:
+		return nil
+	case <-ctx.Done():
+	}
+
+	w.mu.Lock()
+
+	select {
+	case <-waiter.ready:
+		// the slot was handed over concurrently
+		w.mu.Unlock()
+		w.release(host)
+	default:
+		heap.Remove(&h.queue, waiter.index)
+		w.mu.Unlock()
+	}
+
+	return ctx.Err()
+}
+
+// release hands the slot of a completed request for host
+// to the waiting request with the highest priority.
+func (w *SchedulerWrapper) release(host string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	h := w.hosts[host]
+
+	if len(h.queue) > 0 {
+		close(heap.Pop(&h.queue).(*schedulerWaiter).ready)
+
+		return
+	}
+
+	if h.active--; h.active <= 0 {
+		delete(w.hosts, host)
+	}
+}
+
+type schedulerHost struct {
+	active int
+	queue  schedulerQueue
+}
+
+type schedulerWaiter struct {
+	priority Priority
+	seq      uint64
+	ready    chan struct{}
+	index    int
+}
+
+// schedulerQueue implements heap.Interface ordering waiters
+// by descending priority and ascending arrival.
+type schedulerQueue []*schedulerWaiter
+
+func (q schedulerQueue) Len() int {
+	return len(q)
+}
+
+func (q schedulerQueue) Less(i, j int) bool {
+	if q[i].priority != q[j].priority {
+		return q[i].priority > q[j].priority
+	}
+
+	return q[i].seq < q[j].seq
+}
+
+func (q schedulerQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].index = i
+	q[j].index = j
+}
+
+func (q *schedulerQueue) Push(x any) {
+	waiter := x.(*schedulerWaiter)
+	waiter.index = len(*q)
+
+	*q = append(*q, waiter)
+}
+
+func (q *schedulerQueue) Pop() any {
+	old := *q
+	n := len(old)
+
+	waiter := old[n-1]
+	old[n-1] = nil
+
+	*q = old[:n-1]
+
+	return waiter
+}
+
+type SchedulerConfig struct {
+	// MaxConcurrentPerHost limits the number of requests
+	// in flight for a single host. Defaults to 10.
+	MaxConcurrentPerHost int
+}
+
+func (c *SchedulerConfig) Option(opts ...SchedulerOption) {
+	for _, opt := range opts {
+		opt.ConfigureScheduler(c)
+	}
+}
+
+func (c *SchedulerConfig) Default() {
+	if c.MaxConcurrentPerHost <= 0 {
+		c.MaxConcurrentPerHost = defaultMaxConcurrentPerHost
+	}
+}
+
+type SchedulerOption interface {
+	ConfigureScheduler(*SchedulerConfig)
+}
+
+// WithMaxConcurrentPerHost limits the number of requests a
+// SchedulerWrapper has in flight for a single host. Defaults to 10.
+type WithMaxConcurrentPerHost int
+
+func (m WithMaxConcurrentPerHost) ConfigureScheduler(c *SchedulerConfig) {
+	c.MaxConcurrentPerHost = int(m)
+}
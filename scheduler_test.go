@@ -0,0 +1,151 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mt-sre/client/internal/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchedulerWrapperInterfaces(t *testing.T) {
+	t.Parallel()
+
+	require.Implements(t, new(http.RoundTripper), new(SchedulerWrapper))
+
+	require.Implements(t, new(TransportWrapper), new(SchedulerWrapper))
+}
+
+func TestSchedulerWrapperPriorities(t *testing.T) {
+	t.Parallel()
+
+	var (
+		mu    sync.Mutex
+		order []string
+	)
+
+	next := Handler(func(req *http.Request) (*http.Response, error) {
+		mu.Lock()
+		order = append(order, req.URL.Path)
+		mu.Unlock()
+
+		return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody, Request: req}, nil
+	})
+
+	w := NewSchedulerWrapper(WithMaxConcurrentPerHost(1))
+	rt := w.Wrap(next)
+
+	first, err := rt.RoundTrip(testutils.MockRequest(t, http.MethodGet, nil))
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+
+	for i, tc := range []struct {
+		Path     string
+		Priority Priority
+	}{
+		{Path: "/low", Priority: PriorityLow},
+		{Path: "/normal-1", Priority: PriorityNormal},
+		{Path: "/high", Priority: PriorityHigh},
+		{Path: "/normal-2", Priority: PriorityNormal},
+	} {
+		req := testutils.MockRequest(t, http.MethodGet, nil)
+		req.URL.Path = tc.Path
+		req = req.WithContext(ContextWithPriority(req.Context(), tc.Priority))
+
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			res, err := rt.RoundTrip(req)
+			if assert.NoError(t, err) {
+				res.Body.Close()
+			}
+		}()
+
+		require.Eventually(t, func() bool { return queued(w, req.URL.Host) == i+1 }, time.Second, time.Millisecond)
+	}
+
+	require.NoError(t, first.Body.Close())
+
+	wg.Wait()
+
+	assert.Equal(t, []string{"", "/high", "/normal-1", "/normal-2", "/low"}, order)
+	assert.Empty(t, w.hosts)
+}
+
+func TestSchedulerWrapperCanceled(t *testing.T) {
+	t.Parallel()
+
+	next := Handler(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody, Request: req}, nil
+	})
+
+	w := NewSchedulerWrapper(WithMaxConcurrentPerHost(1))
+	rt := w.Wrap(next)
+
+	first, err := rt.RoundTrip(testutils.MockRequest(t, http.MethodGet, nil))
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	req := testutils.MockRequest(t, http.MethodGet, nil).WithContext(ctx)
+
+	errs := make(chan error)
+
+	go func() {
+		_, err := rt.RoundTrip(req)
+
+		errs <- err
+	}()
+
+	require.Eventually(t, func() bool { return queued(w, req.URL.Host) == 1 }, time.Second, time.Millisecond)
+
+	cancel()
+
+	assert.ErrorIs(t, <-errs, context.Canceled)
+	assert.Equal(t, 0, queued(w, req.URL.Host))
+
+	require.NoError(t, first.Body.Close())
+	assert.Empty(t, w.hosts)
+}
+
+func TestClientWithPriority(t *testing.T) {
+	t.Parallel()
+
+	var got Priority
+
+	next := Handler(func(req *http.Request) (*http.Response, error) {
+		got = priorityFromContext(req.Context())
+
+		return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody, Request: req}, nil
+	})
+
+	c := NewClient(
+		WithTransport{RoundTripper: next},
+		WithWrapper{TransportWrapper: NewSchedulerWrapper()},
+	)
+	defer c.Close()
+
+	res, err := c.Get(context.Background(), "https://api.example.com", WithPriority(PriorityHigh))
+	require.NoError(t, err)
+	require.NoError(t, res.Body.Close())
+
+	assert.Equal(t, PriorityHigh, got)
+}
+
+func queued(w *SchedulerWrapper, host string) int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if h, ok := w.hosts[host]; ok {
+		return len(h.queue)
+	}
+
+	return 0
+}
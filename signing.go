@@ -0,0 +1,375 @@
+package client
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// NewSigningWrapper returns a TransportWrapper which signs requests
+// using the Signer configured with WithSigner. Request bodies are
+// buffered in memory so that the signature covers the final body.
+// The SigningWrapper should be applied before a RetryWrapper so that
+// every attempt is signed anew and carries a fresh timestamp.
+func NewSigningWrapper(opts ...SigningOption) *SigningWrapper {
+	var cfg SigningConfig
+
+	cfg.Option(opts...)
+
+	return &SigningWrapper{
+		cfg: cfg,
+	}
+}
+
+type SigningWrapper struct {
+	cfg SigningConfig
+	rt  http.RoundTripper
+}
+
+func (w *SigningWrapper) Wrap(rt http.RoundTripper) http.RoundTripper {
+	w.rt = rt
+
+	return w
+}
+
+func (w *SigningWrapper) RoundTrip(req *http.Request) (*http.Response, error) {
+	if w.cfg.Signer == nil {
+		return w.rt.RoundTrip(req)
+	}
+
+	var body []byte
+
+	if hasBody(req) {
+		var err error
+
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+
+		if err != nil {
+			return nil, fmt.Errorf("reading request body: %w", err)
+		}
+	}
+
+	out := req.Clone(req.Context())
+
+	if body != nil {
+		out.Body = io.NopCloser(bytes.NewReader(body))
+		out.GetBody = func() (io.ReadCloser, error) {
+			return io.NopCloser(bytes.NewReader(body)), nil
+		}
+		out.ContentLength = int64(len(body))
+	}
+
+	if err := w.cfg.Signer.Sign(out, body); err != nil {
+		return nil, fmt.Errorf("signing request: %w", err)
+	}
+
+	return w.rt.RoundTrip(out)
+}
+
+// Signer adds a signature to an outgoing request.
+type Signer interface {
+	// Sign adds a signature covering the request and the
+	// given body to the headers of req.
+	Sign(req *http.Request, body []byte) error
+}
+
+// SignerFunc adapts a function to the Signer interface.
+type SignerFunc func(req *http.Request, body []byte) error
+
+func (f SignerFunc) Sign(req *http.Request, body []byte) error {
+	return f(req, body)
+}
+
+type SigningConfig struct {
+	Signer Signer
+}
+
+func (c *SigningConfig) Option(opts ...SigningOption) {
+	for _, opt := range opts {
+		opt.ConfigureSigning(c)
+	}
+}
+
+type SigningOption interface {
+	ConfigureSigning(*SigningConfig)
+}
+
+// WithSigner configures a SigningWrapper with the given Signer.
+type WithSigner struct{ Signer }
+
+func (s WithSigner) ConfigureSigning(c *SigningConfig) {
+	c.Signer = s.Signer
+}
+
+const (
+	defaultHMACSignatureHeader = "X-Signature"
+	defaultHMACTimestampHeader = "X-Signature-Timestamp"
+)
+
+// HMACSigner signs requests with HMAC-SHA256 using a shared key.
+// The signature is computed over the newline separated timestamp
+// in Unix seconds, method, request URI and hex encoded SHA-256
+// digest of the body:
+//
+//	1700000000
+//	POST
+//	/hooks/events?source=ci
+//	e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855
+//
+// and sent as "sha256=<hex signature>" alongside the timestamp.
+type HMACSigner struct {
+	Key []byte
+	// Header carries the signature.
+	// Defaults to "X-Signature".
+	Header string
+	// TimestampHeader carries the signing timestamp.
+	// Defaults to "X-Signature-Timestamp".
+	TimestampHeader string
+
+	now func() time.Time
+}
+
+func (s *HMACSigner) Sign(req *http.Request, body []byte) error {
+	header := s.Header
+	if header == "" {
+		header = defaultHMACSignatureHeader
+	}
+
+	timestampHeader := s.TimestampHeader
+	if timestampHeader == "" {
+		timestampHeader = defaultHMACTimestampHeader
+	}
+
+	timestamp := strconv.FormatInt(signingTime(s.now).Unix(), 10)
+
+	mac := hmac.New(sha256.New, s.Key)
+
+	fmt.Fprintf(mac, "%s\n%s\n%s\n%s", timestamp, req.Method, req.URL.RequestURI(), hashHex(body))
+
+	req.Header.Set(timestampHeader, timestamp)
+	req.Header.Set(header, "sha256="+hex.EncodeToString(mac.Sum(nil)))
+
+	return nil
+}
+
+const (
+	sigV4Algorithm       = "AWS4-HMAC-SHA256"
+	sigV4TimeFormat      = "20060102T150405Z"
+	sigV4DateFormat      = "20060102"
+	sigV4UnsignedPayload = "UNSIGNED-PAYLOAD"
+)
+
+// sigV4IgnoredHeaders are excluded from SigV4 signatures since
+// they may legitimately be altered after signing.
+var sigV4IgnoredHeaders = map[string]struct{}{
+	"Authorization":     {},
+	"User-Agent":        {},
+	"X-Amzn-Trace-Id":   {},
+	"Expect":            {},
+	"Transfer-Encoding": {},
+	"Accept-Encoding":   {},
+	"Connection":        {},
+}
+
+// SigV4Signer signs requests using AWS Signature Version 4 for
+// use with AWS and S3-compatible services. Requests to the "s3"
+// service additionally carry the X-Amz-Content-Sha256 header.
+type SigV4Signer struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	// SessionToken is sent for temporary credentials.
+	SessionToken string
+	Region       string
+	Service      string
+	// UnsignedPayload excludes the body from the signature.
+	UnsignedPayload bool
+
+	now func() time.Time
+}
+
+func (s *SigV4Signer) Sign(req *http.Request, body []byte) error {
+	t := signingTime(s.now).UTC()
+	amzDate := t.Format(sigV4TimeFormat)
+
+	payloadHash := sigV4UnsignedPayload
+	if !s.UnsignedPayload {
+		payloadHash = hashHex(body)
+	}
+
+	req.Header.Del("Authorization")
+	req.Header.Set("X-Amz-Date", amzDate)
+
+	if s.Service == "s3" || s.UnsignedPayload {
+		req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	}
+
+	if s.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", s.SessionToken)
+	}
+
+	signedHeaders, canonicalHeaders := s.canonicalHeaders(req)
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		s.canonicalURI(req.URL),
+		canonicalQuery(req.URL),
+		canonicalHeaders,
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := strings.Join([]string{t.Format(sigV4DateFormat), s.Region, s.Service, "aws4_request"}, "/")
+
+	stringToSign := strings.Join([]string{
+		sigV4Algorithm,
+		amzDate,
+		scope,
+		hashHex([]byte(canonicalRequest)),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+s.SecretAccessKey), t.Format(sigV4DateFormat))
+	for _, part := range []string{s.Region, s.Service, "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		sigV4Algorithm, s.AccessKeyID, scope, signedHeaders, signature))
+
+	return nil
+}
+
+// canonicalURI returns the URI encoded path of u. Paths are
+// encoded twice for all services except S3.
+func (s *SigV4Signer) canonicalURI(u *url.URL) string {
+	path := u.Path
+	if path == "" {
+		path = "/"
+	}
+
+	path = awsURIEncode(path, false)
+
+	if s.Service != "s3" {
+		path = awsURIEncode(path, false)
+	}
+
+	return path
+}
+
+func (s *SigV4Signer) canonicalHeaders(req *http.Request) (signed, canonical string) {
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+
+	headers := map[string]string{"host": host}
+
+	for key, vals := range req.Header {
+		if _, ok := sigV4IgnoredHeaders[http.CanonicalHeaderKey(key)]; ok {
+			continue
+		}
+
+		trimmed := make([]string, 0, len(vals))
+		for _, val := range vals {
+			trimmed = append(trimmed, strings.Join(strings.Fields(val), " "))
+		}
+
+		headers[strings.ToLower(key)] = strings.Join(trimmed, ",")
+	}
+
+	keys := make([]string, 0, len(headers))
+	for key := range headers {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	var b strings.Builder
+
+	for _, key := range keys {
+		b.WriteString(key + ":" + headers[key] + "\n")
+	}
+
+	return strings.Join(keys, ";"), b.String()
+}
+
+func canonicalQuery(u *url.URL) string {
+	type pair struct{ key, val string }
+
+	var pairs []pair
+
+	for key, vals := range u.Query() {
+		for _, val := range vals {
+			pairs = append(pairs, pair{key: awsURIEncode(key, true), val: awsURIEncode(val, true)})
+		}
+	}
+
+	sort.Slice(pairs, func(i, j int) bool {
+		if pairs[i].key != pairs[j].key {
+			return pairs[i].key < pairs[j].key
+		}
+
+		return pairs[i].val < pairs[j].val
+	})
+
+	encoded := make([]string, 0, len(pairs))
+	for _, p := range pairs {
+		encoded = append(encoded, p.key+"="+p.val)
+	}
+
+	return strings.Join(encoded, "&")
+}
+
+// awsURIEncode percent-encodes all characters of s except the
+// unreserved characters of RFC 3986 and, unless encodeSlash is
+// set, '/'.
+func awsURIEncode(s string, encodeSlash bool) string {
+	var b strings.Builder
+
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+
+		switch {
+		case isAlpha(c), isDigit(c), c == '-', c == '_', c == '.', c == '~':
+			b.WriteByte(c)
+		case c == '/' && !encodeSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+
+	return b.String()
+}
+
+func hashHex(data []byte) string {
+	sum := sha256.Sum256(data)
+
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+
+	return mac.Sum(nil)
+}
+
+func signingTime(now func() time.Time) time.Time {
+	if now == nil {
+		return time.Now()
+	}
+
+	return now()
+}
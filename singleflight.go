@@ -0,0 +1,312 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync"
+)
+
+// NewSingleFlightWrapper returns a TransportWrapper which coalesces
+// concurrent identical requests into a single upstream request. The
+// response body of the upstream request is read into memory and every
+// waiting caller receives its own copy of the response. By default
+// only GET requests are coalesced and requests are considered
+// identical if their URLs and headers match, ignoring headers which
+// identify the individual request such as X-Request-ID. The upstream
+// request is only cancelled once all waiting callers have given up.
+// Streamed responses and responses of unknown or large size are not
+// shared; they are returned to one caller while the others send
+// their own requests.
+func NewSingleFlightWrapper(opts ...SingleFlightOption) *SingleFlightWrapper {
+	var cfg SingleFlightConfig
+
+	cfg.Option(opts...)
+	cfg.Default()
+
+	return &SingleFlightWrapper{
+		cfg:   cfg,
+		calls: make(map[string]*flightCall),
+	}
+}
+
+type SingleFlightWrapper struct {
+	cfg SingleFlightConfig
+	rt  http.RoundTripper
+
+	mu    sync.Mutex
+	calls map[string]*flightCall
+}
+
+// maxSharedBodySize is the largest response
+// body buffered to be shared between callers.
+const maxSharedBodySize = 8 << 20
+
+type flightCall struct {
+	key    string
+	done   chan struct{}
+	cancel context.CancelFunc
+
+	// callers is the number of callers waiting for the call
+	// and stream an unshared response not yet handed to a
+	// caller. Both are guarded by the wrapper's mutex.
+	callers int
+	stream  *http.Response
+
+	res  *http.Response
+	body []byte
+	err  error
+}
+
+func (w *SingleFlightWrapper) Wrap(rt http.RoundTripper) http.RoundTripper {
+	w.rt = rt
+
+	return w
+}
+
+func (w *SingleFlightWrapper) RoundTrip(req *http.Request) (*http.Response, error) {
+	key, ok := w.cfg.Key(req)
+	if !ok {
+		return w.rt.RoundTrip(req)
+	}
+
+	w.mu.Lock()
+
+	call, ok := w.calls[key]
+	if !ok {
+		// the upstream request must not fail the other callers
+		// if the caller which started it gives up
+		ctx, cancel := context.WithCancel(context.WithoutCancel(req.Context()))
+
+		call = &flightCall{key: key, done: make(chan struct{}), cancel: cancel}
+		w.calls[key] = call
+
+		go w.do(call, req.WithContext(ctx))
+	}
+
+	call.callers++
+
+	w.mu.Unlock()
+
+	select {
+	case <-call.done:
+		return w.response(call, req)
+	case <-req.Context().Done():
+		w.leave(call)
+
+		return nil, req.Context().Err()
+	}
+}
+
+// do performs the upstream request of call.
+func (w *SingleFlightWrapper) do(call *flightCall, req *http.Request) {
+	var stream *http.Response
+
+	res, err := w.rt.RoundTrip(req)
+
+	switch {
+	case err != nil:
+		call.err = err
+	case !shareableResponse(res):
+		stream = res
+	default:
+		body, err := io.ReadAll(res.Body)
+		res.Body.Close()
+
+		if err != nil {
+			call.err = fmt.Errorf("reading response body: %w", err)
+		} else {
+			call.res, call.body = res, body
+		}
+
+		call.cancel()
+	}
+
+	w.mu.Lock()
+
+	if w.calls[call.key] == call {
+		delete(w.calls, call.key)
+	}
+
+	// hand the unshared response to a caller unless all have given up
+	if call.callers > 0 {
+		call.stream = stream
+	} else if stream != nil {
+		stream.Body.Close()
+	}
+
+	w.mu.Unlock()
+
+	close(call.done)
+}
+
+// leave removes a caller which has given up waiting for call
+// cancelling the upstream request if no caller is left.
+func (w *SingleFlightWrapper) leave(call *flightCall) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	call.callers--
+
+	if call.callers > 0 {
+		return
+	}
+
+	if w.calls[call.key] == call {
+		delete(w.calls, call.key)
+	}
+
+	call.cancel()
+
+	if call.stream != nil {
+		call.stream.Body.Close()
+		call.stream = nil
+	}
+}
+
+// response returns a copy of the shared response of the completed
+// call for req, the unshared response if no other caller has taken
+// it or the response of a request of its own otherwise.
+func (w *SingleFlightWrapper) response(call *flightCall, req *http.Request) (*http.Response, error) {
+	w.mu.Lock()
+
+	call.callers--
+
+	stream := call.stream
+	call.stream = nil
+
+	w.mu.Unlock()
+
+	switch {
+	case call.err != nil:
+		return nil, call.err
+	case stream != nil:
+		stream.Request = req
+
+		return stream, nil
+	case call.res == nil:
+		return w.rt.RoundTrip(req)
+	}
+
+	res := *call.res
+	res.Header = call.res.Header.Clone()
+	res.Trailer = call.res.Trailer.Clone()
+	res.Body = io.NopCloser(bytes.NewReader(call.body))
+	res.ContentLength = int64(len(call.body))
+	res.Request = req
+
+	return &res, nil
+}
+
+// shareableResponse reports whether the body of res can be
+// buffered without waiting on a long lived stream.
+func shareableResponse(res *http.Response) bool {
+	return res.ContentLength >= 0 && res.ContentLength <= maxSharedBodySize &&
+		!isStreamedMediaType(res.Header.Get("Content-Type"))
+}
+
+// SingleFlightDebugState is the number of upstream requests in flight
+// and of callers waiting for them reported by SingleFlightWrapper.DebugState.
+type SingleFlightDebugState struct {
+	InFlight int `json:"inFlight"`
+	Waiters  int `json:"waiters"`
+}
+
+// DebugState returns the number of upstream requests in flight and
+// of the callers waiting for them. Keys are omitted as they may contain
+// credentials.
+func (w *SingleFlightWrapper) DebugState() any {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	state := SingleFlightDebugState{InFlight: len(w.calls)}
+
+	for _, call := range w.calls {
+		state.Waiters += call.callers
+	}
+
+	return state
+}
+
+type SingleFlightConfig struct {
+	// Key returns the key identifying identical requests and
+	// whether the request may be coalesced at all.
+	Key func(*http.Request) (string, bool)
+}
+
+func (c *SingleFlightConfig) Option(opts ...SingleFlightOption) {
+	for _, opt := range opts {
+		opt.ConfigureSingleFlight(c)
+	}
+}
+
+func (c *SingleFlightConfig) Default() {
+	if c.Key == nil {
+		c.Key = defaultSingleFlightKey
+	}
+}
+
+// ignoredSingleFlightHeaders identify individual requests
+// rather than affect the response.
+var ignoredSingleFlightHeaders = map[string]bool{
+	"User-Agent":       true,
+	"X-Request-Id":     true,
+	"X-Correlation-Id": true,
+	"Traceparent":      true,
+	"Tracestate":       true,
+	"Baggage":          true,
+}
+
+func defaultSingleFlightKey(req *http.Request) (string, bool) {
+	if req.Method != http.MethodGet || (req.Body != nil && req.Body != http.NoBody) {
+		return "", false
+	}
+
+	var key bytes.Buffer
+
+	key.WriteString(req.URL.String())
+
+	headers := make([]string, 0, len(req.Header))
+
+	for name, vals := range req.Header {
+		name = http.CanonicalHeaderKey(name)
+		if ignoredSingleFlightHeaders[name] {
+			continue
+		}
+
+		// values are quoted so that they cannot
+		// be mistaken for further headers
+		header := name + ":"
+		for _, val := range vals {
+			header += strconv.Quote(val) + ","
+		}
+
+		headers = append(headers, header)
+	}
+
+	slices.Sort(headers)
+
+	for _, header := range headers {
+		key.WriteByte('\n')
+		key.WriteString(header)
+	}
+
+	return key.String(), true
+}
+
+type SingleFlightOption interface {
+	ConfigureSingleFlight(*SingleFlightConfig)
+}
+
+// WithSingleFlightKey configures the function a SingleFlightWrapper
+// uses to identify identical requests. The function should return
+// false for requests which must not be coalesced.
+type WithSingleFlightKey func(*http.Request) (string, bool)
+
+func (k WithSingleFlightKey) ConfigureSingleFlight(c *SingleFlightConfig) {
+	c.Key = k
+}
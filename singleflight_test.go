@@ -0,0 +1,305 @@
+package client
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mt-sre/client/internal/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSingleFlightWrapperInterfaces(t *testing.T) {
+	t.Parallel()
+
+	require.Implements(t, new(http.RoundTripper), new(SingleFlightWrapper))
+
+	require.Implements(t, new(TransportWrapper), new(SingleFlightWrapper))
+}
+
+// TestSingleFlightWrapper ensures that concurrent identical requests
+// result in a single upstream request whose response is shared.
+func TestSingleFlightWrapper(t *testing.T) {
+	t.Parallel()
+
+	const callers = 5
+
+	release := make(chan struct{})
+
+	var mrt testutils.MockRoundTripper
+	mrt.
+		On("RoundTrip", mock.Anything).
+		Run(func(mock.Arguments) { <-release }).
+		Return(&http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": []string{"text/plain"}},
+			Body:       io.NopCloser(bytes.NewBufferString("shared")),
+		}, nil).
+		Once()
+
+	wrapper := NewSingleFlightWrapper()
+	rt := wrapper.Wrap(&mrt)
+
+	var wg sync.WaitGroup
+
+	bodies := make([]string, callers)
+
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+
+		go func(i int) {
+			defer wg.Done()
+
+			res, err := rt.RoundTrip(testutils.MockRequest(t, http.MethodGet, nil))
+			if !assert.NoError(t, err) {
+				return
+			}
+			defer res.Body.Close()
+
+			body, err := io.ReadAll(res.Body)
+			assert.NoError(t, err)
+
+			bodies[i] = string(body)
+		}(i)
+	}
+
+	require.Eventually(t, func() bool {
+		return wrapper.DebugState() == SingleFlightDebugState{InFlight: 1, Waiters: callers}
+	}, time.Second, time.Millisecond)
+
+	close(release)
+	wg.Wait()
+
+	mrt.AssertExpectations(t)
+
+	for _, body := range bodies {
+		assert.Equal(t, "shared", body)
+	}
+}
+
+func TestSingleFlightWrapperSkipsNonGET(t *testing.T) {
+	t.Parallel()
+
+	var mrt testutils.MockRoundTripper
+	mrt.
+		On("RoundTrip", mock.Anything).
+		Return(&http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(bytes.NewBuffer(nil)),
+		}, nil).
+		Once()
+
+	rt := NewSingleFlightWrapper().Wrap(&mrt)
+
+	res, err := rt.RoundTrip(testutils.MockRequest(t, http.MethodPost, nil))
+	require.NoError(t, err)
+	require.NoError(t, res.Body.Close())
+
+	mrt.AssertExpectations(t)
+}
+
+func TestDefaultSingleFlightKey(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		A, B         http.Header
+		ExpectShared bool
+	}{
+		"authorization": {
+			A: http.Header{"Authorization": {"Bearer a"}},
+			B: http.Header{"Authorization": {"Bearer b"}},
+		},
+		"cookie": {
+			A: http.Header{"Cookie": {"session=a"}},
+			B: http.Header{"Cookie": {"session=b"}},
+		},
+		"impersonation": {
+			A: http.Header{"Impersonate-User": {"a"}},
+			B: http.Header{"Impersonate-User": {"b"}},
+		},
+		"api key": {
+			A: http.Header{"X-Api-Key": {"a"}},
+			B: http.Header{},
+		},
+		"value spanning headers": {
+			A: http.Header{"X-A": {"a\nX-B:b"}},
+			B: http.Header{"X-A": {"a"}, "X-B": {"b"}},
+		},
+		"request id": {
+			A:            http.Header{"X-Request-Id": {"a"}, "Accept": {"application/json"}},
+			B:            http.Header{"X-Request-Id": {"b"}, "Accept": {"application/json"}},
+			ExpectShared: true,
+		},
+	} {
+		tc := tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			a := testutils.MockRequest(t, http.MethodGet, nil)
+			a.Header = tc.A
+
+			b := testutils.MockRequest(t, http.MethodGet, nil)
+			b.Header = tc.B
+
+			keyA, ok := defaultSingleFlightKey(a)
+			require.True(t, ok)
+
+			keyB, ok := defaultSingleFlightKey(b)
+			require.True(t, ok)
+
+			if tc.ExpectShared {
+				assert.Equal(t, keyA, keyB)
+			} else {
+				assert.NotEqual(t, keyA, keyB)
+			}
+		})
+	}
+}
+
+// TestSingleFlightWrapperLeaderCancelled ensures that waiting callers
+// receive the shared response if the caller which started the upstream
+// request gives up and that callers which give up stop being counted.
+func TestSingleFlightWrapperLeaderCancelled(t *testing.T) {
+	t.Parallel()
+
+	release := make(chan struct{})
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		<-release
+		io.WriteString(w, "shared")
+	}))
+	defer srv.Close()
+
+	wrapper := NewSingleFlightWrapper()
+	rt := wrapper.Wrap(srv.Client().Transport)
+
+	leaderCtx, cancelLeader := context.WithCancel(context.Background())
+
+	leader, err := http.NewRequestWithContext(leaderCtx, http.MethodGet, srv.URL, nil)
+	require.NoError(t, err)
+
+	leaderErr := make(chan error)
+
+	go func() {
+		_, err := rt.RoundTrip(leader)
+
+		leaderErr <- err
+	}()
+
+	require.Eventually(t, func() bool {
+		return wrapper.DebugState() == SingleFlightDebugState{InFlight: 1, Waiters: 1}
+	}, time.Second, time.Millisecond)
+
+	waiter, err := http.NewRequest(http.MethodGet, srv.URL, nil)
+	require.NoError(t, err)
+
+	type result struct {
+		body string
+		err  error
+	}
+
+	results := make(chan result)
+
+	go func() {
+		res, err := rt.RoundTrip(waiter)
+		if err != nil {
+			results <- result{err: err}
+
+			return
+		}
+		defer res.Body.Close()
+
+		body, err := io.ReadAll(res.Body)
+
+		results <- result{body: string(body), err: err}
+	}()
+
+	require.Eventually(t, func() bool {
+		return wrapper.DebugState() == SingleFlightDebugState{InFlight: 1, Waiters: 2}
+	}, time.Second, time.Millisecond)
+
+	cancelLeader()
+	require.ErrorIs(t, <-leaderErr, context.Canceled)
+
+	assert.Equal(t, SingleFlightDebugState{InFlight: 1, Waiters: 1}, wrapper.DebugState())
+
+	close(release)
+
+	res := <-results
+	require.NoError(t, res.err)
+	assert.Equal(t, "shared", res.body)
+}
+
+// TestSingleFlightWrapperStreamed ensures that streamed responses
+// are not buffered and are returned to a single caller only.
+func TestSingleFlightWrapperStreamed(t *testing.T) {
+	t.Parallel()
+
+	var (
+		requests atomic.Int32
+		release  = make(chan struct{})
+	)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) == 1 {
+			<-release
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		io.WriteString(w, "data: event\n")
+		w.(http.Flusher).Flush()
+
+		// the stream stays open until the client goes away
+		<-r.Context().Done()
+	}))
+	defer srv.Close()
+
+	wrapper := NewSingleFlightWrapper()
+	rt := wrapper.Wrap(srv.Client().Transport)
+
+	var wg sync.WaitGroup
+
+	for i := 1; i <= 2; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			req, err := http.NewRequest(http.MethodGet, srv.URL, nil)
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			res, err := rt.RoundTrip(req)
+			if !assert.NoError(t, err) {
+				return
+			}
+			defer res.Body.Close()
+
+			assert.Same(t, req, res.Request)
+
+			line, err := bufio.NewReader(res.Body).ReadString('\n')
+			assert.NoError(t, err)
+			assert.Equal(t, "data: event\n", line)
+		}()
+
+		require.Eventually(t, func() bool {
+			return wrapper.DebugState() == SingleFlightDebugState{InFlight: 1, Waiters: i}
+		}, time.Second, time.Millisecond)
+	}
+
+	close(release)
+	wg.Wait()
+
+	assert.EqualValues(t, 2, requests.Load(), "streamed responses must not be shared")
+}
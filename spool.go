@@ -0,0 +1,203 @@
+package client
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"os"
+)
+
+// defaultSpoolThreshold is the size above which
+// response bodies are spooled to disk by default.
+const defaultSpoolThreshold = 32 << 20
+
+// NewSpoolWrapper returns a TransportWrapper which reads response
+// bodies completely before returning the response. Bodies up to the
+// configured threshold are held in memory while larger bodies are
+// spooled to a temporary file which is removed once the body is
+// closed. In both cases the body implements io.ReadSeekCloser.
+// Streamed responses such as event streams and NDJSON as well as
+// responses of unknown length, e.g. watches, are passed through as
+// reading them would block. The SpoolWrapper should be applied after
+// RetryWrapper so that only the final response is spooled.
+func NewSpoolWrapper(opts ...SpoolOption) *SpoolWrapper {
+	var cfg SpoolConfig
+
+	cfg.Option(opts...)
+	cfg.Default()
+
+	return &SpoolWrapper{
+		cfg: cfg,
+	}
+}
+
+type SpoolWrapper struct {
+	cfg SpoolConfig
+	rt  http.RoundTripper
+}
+
+func (w *SpoolWrapper) Wrap(rt http.RoundTripper) http.RoundTripper {
+	w.rt = rt
+
+	return w
+}
+
+func (w *SpoolWrapper) RoundTrip(req *http.Request) (*http.Response, error) {
+	res, err := w.rt.RoundTrip(req)
+	if err != nil || !spoolableResponse(res) {
+		return res, err
+	}
+
+	body, err := w.spool(res.Body, res.ContentLength)
+	res.Body.Close()
+
+	if err != nil {
+		return nil, fmt.Errorf("spooling response body: %w", err)
+	}
+
+	res.Body = body
+
+	return res, nil
+}
+
+// spoolableResponse reports whether the body of res
+// can be read without waiting on a long lived stream.
+func spoolableResponse(res *http.Response) bool {
+	if res.Body == nil || res.Body == http.NoBody || res.StatusCode == http.StatusSwitchingProtocols {
+		return false
+	}
+
+	return res.ContentLength >= 0 && !isStreamedMediaType(res.Header.Get("Content-Type"))
+}
+
+// spool reads r into memory if it holds at most the
+// threshold of bytes and into a temporary file otherwise.
+func (w *SpoolWrapper) spool(r io.Reader, length int64) (io.ReadSeekCloser, error) {
+	var buf bytes.Buffer
+
+	if length <= w.cfg.Threshold {
+		n, err := io.Copy(&buf, io.LimitReader(r, w.cfg.Threshold+1))
+		if err != nil {
+			return nil, err
+		}
+
+		if n <= w.cfg.Threshold {
+			return &memoryBody{Reader: bytes.NewReader(buf.Bytes())}, nil
+		}
+	}
+
+	f, err := os.CreateTemp(w.cfg.Dir, "client-spool-*")
+	if err != nil {
+		return nil, err
+	}
+
+	body := &fileBody{f: f}
+
+	if _, err := io.Copy(f, io.MultiReader(&buf, r)); err != nil {
+		body.Close()
+
+		return nil, err
+	}
+
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		body.Close()
+
+		return nil, err
+	}
+
+	return body, nil
+}
+
+type memoryBody struct {
+	*bytes.Reader
+}
+
+func (*memoryBody) Close() error {
+	return nil
+}
+
+// fileBody removes the spooled file once closed. The file is not
+// embedded so that the body is not mistaken for a writable connection.
+type fileBody struct {
+	f *os.File
+}
+
+func (b *fileBody) Read(p []byte) (int, error) {
+	return b.f.Read(p)
+}
+
+func (b *fileBody) Seek(offset int64, whence int) (int64, error) {
+	return b.f.Seek(offset, whence)
+}
+
+func (b *fileBody) Close() error {
+	closeErr := b.f.Close()
+
+	if err := os.Remove(b.f.Name()); err != nil {
+		return err
+	}
+
+	return closeErr
+}
+
+type SpoolConfig struct {
+	// Threshold is the size in bytes above which bodies
+	// are spooled to disk. Defaults to 32 MiB.
+	Threshold int64
+	// Dir is the directory holding spooled bodies.
+	// Defaults to the directory returned by os.TempDir.
+	Dir string
+}
+
+func (c *SpoolConfig) Option(opts ...SpoolOption) {
+	for _, opt := range opts {
+		opt.ConfigureSpool(c)
+	}
+}
+
+func (c *SpoolConfig) Default() {
+	if c.Threshold <= 0 {
+		c.Threshold = defaultSpoolThreshold
+	}
+}
+
+type SpoolOption interface {
+	ConfigureSpool(*SpoolConfig)
+}
+
+// WithSpoolThreshold sets the size in bytes above
+// which response bodies are spooled to disk.
+type WithSpoolThreshold int64
+
+func (t WithSpoolThreshold) ConfigureSpool(c *SpoolConfig) {
+	c.Threshold = int64(t)
+}
+
+// WithSpoolDir sets the directory holding spooled bodies.
+type WithSpoolDir string
+
+func (d WithSpoolDir) ConfigureSpool(c *SpoolConfig) {
+	c.Dir = string(d)
+}
+
+// WithSpoolLargeBodies configures a Client instance to spool response
+// bodies larger than Threshold bytes to temporary files in Dir using a
+// SpoolWrapper. The wrapper is registered with the highest priority so
+// that it is applied above all other wrappers.
+type WithSpoolLargeBodies struct {
+	Threshold int64
+	Dir       string
+}
+
+func (s WithSpoolLargeBodies) ConfigureClient(c *ClientConfig) {
+	c.register(NamedWrapper{
+		Name:     "spool",
+		Priority: math.MaxInt,
+		Wrapper: NewSpoolWrapper(
+			WithSpoolThreshold(s.Threshold),
+			WithSpoolDir(s.Dir),
+		),
+	})
+}
@@ -0,0 +1,227 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mt-sre/client/internal/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSpoolWrapperInterfaces(t *testing.T) {
+	t.Parallel()
+
+	require.Implements(t, new(http.RoundTripper), new(SpoolWrapper))
+
+	require.Implements(t, new(TransportWrapper), new(SpoolWrapper))
+}
+
+func TestSpoolWrapper(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		Body          string
+		ContentLength int64
+		ExpectedFile  bool
+	}{
+		"small": {
+			Body:          "small",
+			ContentLength: 5,
+		},
+		"at threshold": {
+			Body:          strings.Repeat("x", 16),
+			ContentLength: 16,
+		},
+		"large": {
+			Body:          strings.Repeat("x", 17),
+			ContentLength: 17,
+			ExpectedFile:  true,
+		},
+	} {
+		tc := tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			dir := t.TempDir()
+
+			mrt := &testutils.MockRoundTripper{}
+			mrt.
+				On("RoundTrip", mock.Anything).
+				Return(&http.Response{
+					StatusCode:    http.StatusOK,
+					ContentLength: tc.ContentLength,
+					Body:          io.NopCloser(strings.NewReader(tc.Body)),
+				}, nil)
+
+			rt := NewSpoolWrapper(
+				WithSpoolThreshold(16),
+				WithSpoolDir(dir),
+			).Wrap(mrt)
+
+			res, err := rt.RoundTrip(testutils.MockRequest(t, http.MethodGet, nil))
+			require.NoError(t, err)
+
+			body, ok := res.Body.(io.ReadSeekCloser)
+			require.True(t, ok)
+
+			entries, err := os.ReadDir(dir)
+			require.NoError(t, err)
+
+			if tc.ExpectedFile {
+				assert.Len(t, entries, 1)
+			} else {
+				assert.Empty(t, entries)
+			}
+
+			for range 2 {
+				data, err := io.ReadAll(body)
+				require.NoError(t, err)
+				assert.Equal(t, tc.Body, string(data))
+
+				_, err = body.Seek(0, io.SeekStart)
+				require.NoError(t, err)
+			}
+
+			require.NoError(t, body.Close())
+
+			entries, err = os.ReadDir(dir)
+			require.NoError(t, err)
+			assert.Empty(t, entries)
+		})
+	}
+}
+
+func TestSpoolWrapperPassesStreamsThrough(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		ContentType   string
+		ContentLength int64
+	}{
+		"unknown length": {
+			ContentLength: -1,
+		},
+		"event stream": {
+			ContentType:   "text/event-stream",
+			ContentLength: 100,
+		},
+		"ndjson": {
+			ContentType:   "application/x-ndjson; charset=utf-8",
+			ContentLength: 100,
+		},
+	} {
+		tc := tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			body := io.NopCloser(strings.NewReader("stream"))
+
+			mrt := &testutils.MockRoundTripper{}
+			mrt.
+				On("RoundTrip", mock.Anything).
+				Return(&http.Response{
+					StatusCode:    http.StatusOK,
+					Header:        http.Header{"Content-Type": {tc.ContentType}},
+					ContentLength: tc.ContentLength,
+					Body:          body,
+				}, nil)
+
+			res, err := NewSpoolWrapper(WithSpoolDir(t.TempDir())).Wrap(mrt).
+				RoundTrip(testutils.MockRequest(t, http.MethodGet, nil))
+			require.NoError(t, err)
+
+			assert.Equal(t, body, res.Body, "streamed bodies must not be spooled")
+		})
+	}
+}
+
+// TestClientSpoolEventStream ensures that events of a stream are
+// received while they are sent with spooling configured.
+func TestClientSpoolEventStream(t *testing.T) {
+	t.Parallel()
+
+	done := make(chan struct{})
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		io.WriteString(w, "data: first\n\n")
+		w.(http.Flusher).Flush()
+
+		select {
+		case <-done:
+		case <-r.Context().Done():
+		}
+	}))
+	defer srv.Close()
+	defer close(done)
+
+	c := NewClient(WithSpoolLargeBodies{Threshold: 1, Dir: t.TempDir()})
+	defer c.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var received bool
+
+	// a spooled stream would only end once the context expires
+	for event, err := range c.GetSSE(ctx, srv.URL) {
+		require.NoError(t, err)
+		assert.Equal(t, "first", event.Data)
+
+		received = true
+
+		break
+	}
+
+	assert.True(t, received)
+}
+
+func TestClientSpoolLargeBodies(t *testing.T) {
+	t.Parallel()
+
+	content := strings.Repeat("0123456789", 100)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		io.WriteString(w, content)
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+
+	c := NewClient(
+		WithSpoolLargeBodies{Threshold: 100, Dir: dir},
+		WithWrapper{TransportWrapper: NewRetryWrapper()},
+	)
+	defer c.Close()
+
+	require.Equal(t, "spool", c.Wrappers()[len(c.Wrappers())-1].Name)
+
+	res, err := c.Get(context.Background(), srv.URL)
+	require.NoError(t, err)
+
+	body, ok := res.Body.(io.ReadSeekCloser)
+	require.True(t, ok)
+
+	_, err = body.Seek(500, io.SeekStart)
+	require.NoError(t, err)
+
+	data, err := io.ReadAll(body)
+	require.NoError(t, err)
+	assert.Equal(t, content[500:], string(data))
+
+	require.NoError(t, body.Close())
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
@@ -0,0 +1,339 @@
+package client
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"iter"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+)
+
+// maxSSELineSize limits the length of a single line of an event stream.
+const maxSSELineSize = 1 << 20
+
+var (
+	errUnexpectedContentType = errors.New("unexpected content type")
+	errSSEStopped            = errors.New("iteration stopped")
+	errSSENoContent          = errors.New("no content")
+	errSSEClosed             = errors.New("event stream closed")
+)
+
+// Event is a single Server-Sent Event.
+type Event struct {
+	// ID is the last event ID received on the stream.
+	ID string
+	// Type is the event type which defaults to "message".
+	Type string
+	// Data holds the data lines of the event joined by newlines.
+	Data string
+}
+
+// GetSSE performs a HTTP GET request against the provided URL and
+// returns an iterator over the Server-Sent Events received. When the
+// stream ends or fails the request is repeated after the delay given
+// by the configured backoff, carrying the ID of the last event in the
+// Last-Event-ID header. Iteration stops once the context is canceled,
+// the server responds with 204 No Content or the backoff gives up, in
+// which case the error is yielded as the final element. Responses
+// other than a 200 OK event stream are not reconnected.
+func (c *Client) GetSSE(ctx context.Context, url string, opts ...SSEOption) iter.Seq2[Event, error] {
+	var cfg SSEConfig
+
+	cfg.Option(opts...)
+	cfg.Default()
+
+	return func(yield func(Event, error) bool) {
+		s := sseStream{
+			lastEventID: cfg.LastEventID,
+		}
+
+		bo := cfg.Backoff()
+
+		for {
+			received, err := c.streamOnce(ctx, url, &s, cfg, yield)
+			if errors.Is(err, errSSEStopped) || errors.Is(err, errSSENoContent) {
+				return
+			}
+
+			var reconnectErr *sseReconnectError
+
+			if !errors.As(err, &reconnectErr) {
+				yield(Event{}, err)
+
+				return
+			}
+
+			if received {
+				bo = cfg.Backoff()
+			}
+
+			delay := bo.NextBackOff()
+			if delay == backoff.Stop {
+				yield(Event{}, fmt.Errorf("reconnecting event stream: %w", reconnectErr.err))
+
+				return
+			}
+
+			delay = max(delay, s.retry)
+
+			timer := time.NewTimer(delay)
+
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+
+				yield(Event{}, ctx.Err())
+
+				return
+			case <-timer.C:
+			}
+		}
+	}
+}
+
+// sseReconnectError marks failures after which
+// the event stream should be reconnected.
+type sseReconnectError struct {
+	err error
+}
+
+func (e *sseReconnectError) Error() string {
+	return e.err.Error()
+}
+
+func (e *sseReconnectError) Unwrap() error {
+	return e.err
+}
+
+// streamOnce connects to the event stream once and yields the received
+// events. It reports whether any events were received on the connection.
+func (c *Client) streamOnce(ctx context.Context, url string, s *sseStream, cfg SSEConfig, yield func(Event, error) bool) (bool, error) {
+	header := http.Header{
+		"Accept":        {"text/event-stream"},
+		"Cache-Control": {"no-cache"},
+	}
+
+	if s.lastEventID != "" {
+		header.Set("Last-Event-ID", s.lastEventID)
+	}
+
+	opts := append([]RequestOption{WithRequestHeaders(header)}, cfg.RequestOptions...)
+
+	res, err := c.Get(ctx, url, opts...)
+	if err != nil {
+		if ctx.Err() != nil {
+			return false, ctx.Err()
+		}
+
+		var httpErr *HTTPError
+
+		if errors.As(err, &httpErr) {
+			return false, err
+		}
+
+		return false, &sseReconnectError{err: err}
+	}
+	defer res.Body.Close()
+
+	switch {
+	case res.StatusCode == http.StatusNoContent:
+		return false, errSSENoContent
+	case res.StatusCode != http.StatusOK:
+		return false, NewHTTPError(res)
+	}
+
+	if mediaType, _, _ := mime.ParseMediaType(res.Header.Get("Content-Type")); mediaType != "text/event-stream" {
+		return false, fmt.Errorf("%w %q", errUnexpectedContentType, res.Header.Get("Content-Type"))
+	}
+
+	scanner := bufio.NewScanner(res.Body)
+	scanner.Buffer(make([]byte, 4096), maxSSELineSize)
+	scanner.Split(scanSSELines)
+
+	var received bool
+
+	for scanner.Scan() {
+		event, ok := s.processLine(scanner.Text())
+		if !ok {
+			continue
+		}
+
+		received = true
+
+		if !yield(event, nil) {
+			return received, errSSEStopped
+		}
+	}
+
+	if ctx.Err() != nil {
+		return received, ctx.Err()
+	}
+
+	err = scanner.Err()
+	if err == nil {
+		err = errSSEClosed
+	}
+
+	return received, &sseReconnectError{err: err}
+}
+
+// sseStream holds the parser state of an event stream
+// as described by the WHATWG HTML specification.
+type sseStream struct {
+	lastEventID string
+	retry       time.Duration
+	eventType   string
+	data        strings.Builder
+}
+
+// processLine processes a single line of the stream and returns
+// the event dispatched by it, if any.
+func (s *sseStream) processLine(line string) (Event, bool) {
+	if line == "" {
+		return s.dispatch()
+	}
+
+	if strings.HasPrefix(line, ":") {
+		return Event{}, false
+	}
+
+	field, value, _ := strings.Cut(line, ":")
+	value = strings.TrimPrefix(value, " ")
+
+	switch field {
+	case "event":
+		s.eventType = value
+	case "data":
+		s.data.WriteString(value)
+		s.data.WriteByte('\n')
+	case "id":
+		if !strings.ContainsRune(value, 0) {
+			s.lastEventID = value
+		}
+	case "retry":
+		if ms, err := strconv.ParseUint(value, 10, 63); err == nil {
+			s.retry = time.Duration(ms) * time.Millisecond
+		}
+	}
+
+	return Event{}, false
+}
+
+func (s *sseStream) dispatch() (Event, bool) {
+	defer func() {
+		s.eventType = ""
+		s.data.Reset()
+	}()
+
+	if s.data.Len() == 0 {
+		return Event{}, false
+	}
+
+	event := Event{
+		ID:   s.lastEventID,
+		Type: s.eventType,
+		Data: strings.TrimSuffix(s.data.String(), "\n"),
+	}
+
+	if event.Type == "" {
+		event.Type = "message"
+	}
+
+	return event, true
+}
+
+// scanSSELines is a bufio.SplitFunc splitting lines
+// terminated by CRLF, LF or a single CR.
+func scanSSELines(data []byte, atEOF bool) (int, []byte, error) {
+	if atEOF && len(data) == 0 {
+		return 0, nil, nil
+	}
+
+	if i := bytes.IndexAny(data, "\r\n"); i >= 0 {
+		if data[i] == '\n' {
+			return i + 1, data[:i], nil
+		}
+
+		if i+1 < len(data) {
+			if data[i+1] == '\n' {
+				return i + 2, data[:i], nil
+			}
+
+			return i + 1, data[:i], nil
+		}
+
+		// a trailing CR may be followed by LF
+		if !atEOF {
+			return 0, nil, nil
+		}
+
+		return i + 1, data[:i], nil
+	}
+
+	if atEOF {
+		// an incomplete final line is discarded
+		// along with any pending event
+		return len(data), nil, nil
+	}
+
+	return 0, nil, nil
+}
+
+type SSEConfig struct {
+	// Backoff generates the delays between reconnection attempts.
+	// Defaults to ExponentialBackoffGenerator().
+	Backoff BackoffGenerator
+	// LastEventID is sent with the initial request
+	// to resume a previously consumed stream.
+	LastEventID string
+	// RequestOptions are applied to every request.
+	RequestOptions []RequestOption
+}
+
+func (c *SSEConfig) Option(opts ...SSEOption) {
+	for _, opt := range opts {
+		opt.ConfigureSSE(c)
+	}
+}
+
+func (c *SSEConfig) Default() {
+	if c.Backoff == nil {
+		c.Backoff = ExponentialBackoffGenerator()
+	}
+}
+
+type SSEOption interface {
+	ConfigureSSE(*SSEConfig)
+}
+
+// WithReconnectBackoff sets the BackoffGenerator used to delay
+// reconnection attempts. A delay requested by the server through
+// the retry field is used if it is longer than the backoff.
+type WithReconnectBackoff BackoffGenerator
+
+func (b WithReconnectBackoff) ConfigureSSE(c *SSEConfig) {
+	c.Backoff = BackoffGenerator(b)
+}
+
+// WithLastEventID resumes a stream after the event with the given ID.
+type WithLastEventID string
+
+func (id WithLastEventID) ConfigureSSE(c *SSEConfig) {
+	c.LastEventID = string(id)
+}
+
+// WithSSERequestOptions applies the given RequestOptions to
+// the initial request as well as every reconnection attempt.
+type WithSSERequestOptions []RequestOption
+
+func (o WithSSERequestOptions) ConfigureSSE(c *SSEConfig) {
+	c.RequestOptions = append(c.RequestOptions, o...)
+}
@@ -0,0 +1,285 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSSEStreamParsing(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		Stream          string
+		ExpectedEvents  []Event
+		ExpectedLastID  string
+		ExpectedRetryMS int
+	}{
+		"single data line": {
+			Stream:         "data: hello\n\n",
+			ExpectedEvents: []Event{{Type: "message", Data: "hello"}},
+		},
+		"multiple data lines": {
+			Stream:         "data: a\ndata:b\n\n",
+			ExpectedEvents: []Event{{Type: "message", Data: "a\nb"}},
+		},
+		"event type and id": {
+			Stream: "event: update\nid: 1\ndata: x\n\ndata: y\n\n",
+			ExpectedEvents: []Event{
+				{ID: "1", Type: "update", Data: "x"},
+				{ID: "1", Type: "message", Data: "y"},
+			},
+			ExpectedLastID: "1",
+		},
+		"comments and unknown fields": {
+			Stream:         ": keepalive\nfoo: bar\ndata: x\n\n",
+			ExpectedEvents: []Event{{Type: "message", Data: "x"}},
+		},
+		"CRLF and CR line endings": {
+			Stream:         "data: a\r\ndata: b\r\rdata: c\r\n\r\n",
+			ExpectedEvents: []Event{{Type: "message", Data: "a\nb"}, {Type: "message", Data: "c"}},
+		},
+		"retry": {
+			Stream:          "retry: 1500\n\n",
+			ExpectedRetryMS: 1500,
+		},
+		"invalid retry": {
+			Stream: "retry: soon\n\n",
+		},
+		"incomplete final event": {
+			Stream: "data: a\n",
+		},
+		"empty data dispatches nothing": {
+			Stream:         "id: 7\n\n",
+			ExpectedLastID: "7",
+		},
+	} {
+		tc := tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var s sseStream
+
+			scanner := bufio.NewScanner(strings.NewReader(tc.Stream))
+			scanner.Split(scanSSELines)
+
+			var events []Event
+
+			for scanner.Scan() {
+				if event, ok := s.processLine(scanner.Text()); ok {
+					events = append(events, event)
+				}
+			}
+
+			require.NoError(t, scanner.Err())
+
+			assert.Equal(t, tc.ExpectedEvents, events)
+			assert.Equal(t, tc.ExpectedLastID, s.lastEventID)
+			assert.Equal(t, tc.ExpectedRetryMS, int(s.retry.Milliseconds()))
+		})
+	}
+}
+
+func TestClientGetSSE(t *testing.T) {
+	t.Parallel()
+
+	var (
+		mu          sync.Mutex
+		lastEventID []string
+	)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		lastEventID = append(lastEventID, r.Header.Get("Last-Event-ID"))
+		attempt := len(lastEventID)
+		mu.Unlock()
+
+		assert.Equal(t, "text/event-stream", r.Header.Get("Accept"))
+
+		if attempt > 2 {
+			w.WriteHeader(http.StatusNoContent)
+
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream; charset=utf-8")
+
+		fmt.Fprintf(w, "id: %d\ndata: event %d\n\n", attempt, attempt)
+	}))
+	defer srv.Close()
+
+	c := NewClient()
+	defer c.Close()
+
+	var events []Event
+
+	for event, err := range c.GetSSE(context.Background(), srv.URL, WithReconnectBackoff(NoBackoffGenerator())) {
+		require.NoError(t, err)
+
+		events = append(events, event)
+	}
+
+	assert.Equal(t, []Event{
+		{ID: "1", Type: "message", Data: "event 1"},
+		{ID: "2", Type: "message", Data: "event 2"},
+	}, events)
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	assert.Equal(t, []string{"", "1", "2"}, lastEventID)
+}
+
+func TestClientGetSSEErrors(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		Handler          http.HandlerFunc
+		Options          []SSEOption
+		ExpectedErr      error
+		ExpectedStatus   int
+		ExpectedAttempts int
+	}{
+		"unexpected status": {
+			Handler: func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(http.StatusUnauthorized)
+			},
+			ExpectedStatus:   http.StatusUnauthorized,
+			ExpectedAttempts: 1,
+		},
+		"unexpected content type": {
+			Handler: func(w http.ResponseWriter, _ *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+			},
+			ExpectedErr:      errUnexpectedContentType,
+			ExpectedAttempts: 1,
+		},
+		"backoff exhausted": {
+			Handler: func(w http.ResponseWriter, _ *http.Request) {
+				w.Header().Set("Content-Type", "text/event-stream")
+			},
+			Options: []SSEOption{
+				WithReconnectBackoff(func() backoff.BackOff {
+					return backoff.WithMaxRetries(&backoff.ZeroBackOff{}, 2)
+				}),
+			},
+			ExpectedErr:      errSSEClosed,
+			ExpectedAttempts: 3,
+		},
+	} {
+		tc := tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var (
+				mu       sync.Mutex
+				attempts int
+			)
+
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				attempts++
+				mu.Unlock()
+
+				tc.Handler(w, r)
+			}))
+			defer srv.Close()
+
+			c := NewClient()
+			defer c.Close()
+
+			var errs []error
+
+			for _, err := range c.GetSSE(context.Background(), srv.URL, tc.Options...) {
+				errs = append(errs, err)
+			}
+
+			require.Len(t, errs, 1)
+
+			if tc.ExpectedStatus != 0 {
+				assert.True(t, IsStatus(errs[0], tc.ExpectedStatus))
+			} else {
+				assert.ErrorIs(t, errs[0], tc.ExpectedErr)
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			assert.Equal(t, tc.ExpectedAttempts, attempts)
+		})
+	}
+}
+
+func TestClientGetSSECanceled(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+
+		fmt.Fprint(w, "data: x\n\n")
+	}))
+	defer srv.Close()
+
+	c := NewClient()
+	defer c.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var errs []error
+
+	for _, err := range c.GetSSE(ctx, srv.URL, WithReconnectBackoff(ConstantBackoffGenerator(time.Hour))) {
+		if err != nil {
+			errs = append(errs, err)
+
+			continue
+		}
+
+		cancel()
+	}
+
+	require.Len(t, errs, 1)
+	assert.ErrorIs(t, errs[0], context.Canceled)
+}
+
+func TestClientGetSSEStopsEarly(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+
+		for i := range 3 {
+			fmt.Fprintf(w, "data: %d\n\n", i)
+		}
+	}))
+	defer srv.Close()
+
+	c := NewClient()
+	defer c.Close()
+
+	var events []Event
+
+	for event, err := range c.GetSSE(context.Background(), srv.URL) {
+		require.NoError(t, err)
+
+		events = append(events, event)
+
+		if len(events) == 2 {
+			break
+		}
+	}
+
+	assert.Len(t, events, 2)
+}
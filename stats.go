@@ -0,0 +1,228 @@
+package client
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"sync/atomic"
+)
+
+// ErrorClass groups failed requests by their cause.
+type ErrorClass string
+
+const (
+	// ErrorClassTimeout covers requests which timed out.
+	ErrorClassTimeout ErrorClass = "timeout"
+	// ErrorClassCanceled covers requests whose context was canceled.
+	ErrorClassCanceled ErrorClass = "canceled"
+	// ErrorClassDNS covers failed host name lookups.
+	ErrorClassDNS ErrorClass = "dns"
+	// ErrorClassTLS covers failed TLS handshakes and certificate checks.
+	ErrorClassTLS ErrorClass = "tls"
+	// ErrorClassConnection covers other network errors.
+	ErrorClassConnection ErrorClass = "connection"
+	// ErrorClassClient covers responses with a 4xx status code.
+	ErrorClassClient ErrorClass = "client"
+	// ErrorClassServer covers responses with a 5xx status code.
+	ErrorClassServer ErrorClass = "server"
+	// ErrorClassOther covers all remaining errors.
+	ErrorClassOther ErrorClass = "other"
+)
+
+// Stats is a snapshot of the counters maintained by a Client.
+type Stats struct {
+	// Requests is the number of requests sent through
+	// the Client excluding those made through StdClient.
+	Requests int64
+	// Attempts is the number of requests passed to the
+	// transport including retries and hedged requests.
+	Attempts int64
+	// Retries is the number of retries made by RetryWrappers for
+	// Requests which received a response or failed with one of
+	// *RetriesExhaustedError or *RetryTimeoutError.
+	Retries int64
+	// Errors holds the number of failed Requests by class.
+	Errors map[ErrorClass]int64
+	// BytesSent is the number of request body bytes sent.
+	BytesSent int64
+	// BytesReceived is the number of response body bytes read.
+	BytesReceived int64
+	// OpenConnections is the number of connections currently open.
+	// Only connections of transports constructed by the Client are
+	// counted, not those of http.DefaultTransport or a transport
+	// provided through WithTransport.
+	OpenConnections int64
+}
+
+// Stats returns a snapshot of the counters of the Client. Clients
+// returned by With share the counters of the Client they are derived
+// from.
+func (c *Client) Stats() Stats {
+	return c.cfg.stats.snapshot()
+}
+
+type clientStats struct {
+	requests        atomic.Int64
+	attempts        atomic.Int64
+	retries         atomic.Int64
+	bytesSent       atomic.Int64
+	bytesReceived   atomic.Int64
+	openConnections atomic.Int64
+
+	mu     sync.Mutex
+	errors map[ErrorClass]int64
+}
+
+func (s *clientStats) snapshot() Stats {
+	s.mu.Lock()
+	errs := make(map[ErrorClass]int64, len(s.errors))
+
+	for class, n := range s.errors {
+		errs[class] = n
+	}
+	s.mu.Unlock()
+
+	return Stats{
+		Requests:        s.requests.Load(),
+		Attempts:        s.attempts.Load(),
+		Retries:         s.retries.Load(),
+		Errors:          errs,
+		BytesSent:       s.bytesSent.Load(),
+		BytesReceived:   s.bytesReceived.Load(),
+		OpenConnections: s.openConnections.Load(),
+	}
+}
+
+// recordRequest counts a request sent through the Client along with
+// the retries made for it and its failure if any.
+func (s *clientStats) recordRequest(res *http.Response, err error) {
+	s.requests.Add(1)
+
+	var (
+		exhausted *RetriesExhaustedError
+		timeout   *RetryTimeoutError
+		class     ErrorClass
+	)
+
+	if state, ok := ResponseRetryState(res); ok {
+		s.retries.Add(int64(state.Attempts - 1))
+	} else if errors.As(err, &exhausted) {
+		s.retries.Add(int64(len(exhausted.Attempts) - 1))
+	} else if errors.As(err, &timeout) {
+		s.retries.Add(int64(len(timeout.Attempts) - 1))
+	}
+
+	switch {
+	case err != nil:
+		class = classifyError(err)
+	case res.StatusCode >= 500:
+		class = ErrorClassServer
+	case res.StatusCode >= 400:
+		class = ErrorClassClient
+	default:
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.errors == nil {
+		s.errors = make(map[ErrorClass]int64)
+	}
+
+	s.errors[class]++
+}
+
+// roundTrip counts the attempt and the body bytes of req and its response.
+func (s *clientStats) roundTrip(rt http.RoundTripper, req *http.Request) (*http.Response, error) {
+	s.attempts.Add(1)
+
+	if req.Body != nil && req.Body != http.NoBody {
+		req = req.WithContext(req.Context())
+		req.Body = &countingBody{ReadCloser: req.Body, n: &s.bytesSent}
+	}
+
+	res, err := rt.RoundTrip(req)
+	if err != nil || res.Body == nil || res.Body == http.NoBody || res.StatusCode == http.StatusSwitchingProtocols {
+		return res, err
+	}
+
+	res.Body = &countingBody{ReadCloser: res.Body, n: &s.bytesReceived}
+
+	return res, nil
+}
+
+// countConnections returns a DialContextFunc tracking
+// the number of open connections established by dial.
+func (s *clientStats) countConnections(dial DialContextFunc) DialContextFunc {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		conn, err := dial(ctx, network, addr)
+		if err != nil {
+			return nil, err
+		}
+
+		s.openConnections.Add(1)
+
+		return &countedConn{Conn: conn, open: &s.openConnections}, nil
+	}
+}
+
+type countingBody struct {
+	io.ReadCloser
+	n *atomic.Int64
+}
+
+func (b *countingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.n.Add(int64(n))
+
+	return n, err
+}
+
+type countedConn struct {
+	net.Conn
+	open *atomic.Int64
+	once sync.Once
+}
+
+func (c *countedConn) Close() error {
+	c.once.Do(func() { c.open.Add(-1) })
+
+	return c.Conn.Close()
+}
+
+// classifyError returns the ErrorClass of a failed request.
+func classifyError(err error) ErrorClass {
+	var (
+		timeout interface{ Timeout() bool }
+		dnsErr  *net.DNSError
+		opErr   *net.OpError
+		certErr *tls.CertificateVerificationError
+		alert   tls.AlertError
+		record  tls.RecordHeaderError
+		unknown x509.UnknownAuthorityError
+		invalid x509.CertificateInvalidError
+		host    x509.HostnameError
+	)
+
+	switch {
+	case errors.Is(err, context.Canceled):
+		return ErrorClassCanceled
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &timeout) && timeout.Timeout():
+		return ErrorClassTimeout
+	case errors.As(err, &dnsErr):
+		return ErrorClassDNS
+	case errors.As(err, &certErr), errors.As(err, &alert), errors.As(err, &record),
+		errors.As(err, &unknown), errors.As(err, &invalid), errors.As(err, &host):
+		return ErrorClassTLS
+	case errors.As(err, &opErr):
+		return ErrorClassConnection
+	default:
+		return ErrorClassOther
+	}
+}
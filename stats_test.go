@@ -0,0 +1,119 @@
+package client
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientStats(t *testing.T) {
+	t.Parallel()
+
+	var posts atomic.Int32
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+
+		switch {
+		case r.Method == http.MethodGet:
+			w.WriteHeader(http.StatusNotFound)
+		case posts.Add(1) == 1:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		default:
+			io.WriteString(w, "world")
+		}
+	}))
+	defer srv.Close()
+
+	c := NewClient(
+		WithDialTimeout(time.Second),
+		WithWrapper{TransportWrapper: NewRetryWrapper(
+			WithBackoffGenerator(NoBackoffGenerator()),
+			WithMaxRetries(1),
+		)},
+	)
+
+	res, err := c.Post(context.Background(), srv.URL, strings.NewReader("hello"))
+	require.NoError(t, err)
+
+	body, err := io.ReadAll(res.Body)
+	require.NoError(t, err)
+	require.NoError(t, res.Body.Close())
+	assert.Equal(t, "world", string(body))
+
+	res, err = c.With().Get(context.Background(), srv.URL)
+	require.NoError(t, err)
+	require.NoError(t, res.Body.Close())
+
+	assert.Equal(t, Stats{
+		Requests:        2,
+		Attempts:        3,
+		Retries:         1,
+		Errors:          map[ErrorClass]int64{ErrorClassClient: 1},
+		BytesSent:       10,
+		BytesReceived:   5,
+		OpenConnections: 1,
+	}, c.Stats())
+
+	c.Close()
+
+	assert.Zero(t, c.Stats().OpenConnections)
+}
+
+func TestClassifyError(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		Err      error
+		Expected ErrorClass
+	}{
+		"canceled": {
+			Err:      fmt.Errorf("request: %w", context.Canceled),
+			Expected: ErrorClassCanceled,
+		},
+		"deadline": {
+			Err:      context.DeadlineExceeded,
+			Expected: ErrorClassTimeout,
+		},
+		"retry timeout": {
+			Err:      &RetryTimeoutError{},
+			Expected: ErrorClassTimeout,
+		},
+		"dns": {
+			Err:      &net.DNSError{Err: "no such host", Name: "example.invalid", IsNotFound: true},
+			Expected: ErrorClassDNS,
+		},
+		"tls": {
+			Err:      x509.UnknownAuthorityError{},
+			Expected: ErrorClassTLS,
+		},
+		"connection": {
+			Err:      &net.OpError{Op: "dial", Err: errors.New("connection refused")},
+			Expected: ErrorClassConnection,
+		},
+		"other": {
+			Err:      errors.New("unexpected"),
+			Expected: ErrorClassOther,
+		},
+	} {
+		tc := tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tc.Expected, classifyError(tc.Err))
+		})
+	}
+}
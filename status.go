@@ -0,0 +1,90 @@
+package client
+
+import (
+	"net/http"
+)
+
+// NewStatusHandlerWrapper returns a TransportWrapper which invokes
+// handlers registered for specific response status codes before the
+// response is returned to the caller. This allows cross-cutting
+// handling such as refreshing credentials on 401 or mapping 451 to
+// a custom error to be configured declaratively.
+func NewStatusHandlerWrapper(opts ...StatusHandlerOption) *StatusHandlerWrapper {
+	var cfg StatusHandlerConfig
+
+	cfg.Option(opts...)
+
+	return &StatusHandlerWrapper{
+		cfg: cfg,
+	}
+}
+
+type StatusHandlerWrapper struct {
+	cfg StatusHandlerConfig
+	rt  http.RoundTripper
+}
+
+func (w *StatusHandlerWrapper) Wrap(rt http.RoundTripper) http.RoundTripper {
+	w.rt = rt
+
+	return w
+}
+
+func (w *StatusHandlerWrapper) RoundTrip(req *http.Request) (*http.Response, error) {
+	res, err := w.rt.RoundTrip(req)
+	if err != nil {
+		return res, err
+	}
+
+	handler, ok := w.cfg.Handlers[res.StatusCode]
+	if !ok {
+		return res, nil
+	}
+
+	if res.Request == nil {
+		res.Request = req
+	}
+
+	return handler(w.rt, res)
+}
+
+// StatusHandler is invoked with a response whose status code it was
+// registered for. The originating request is available through
+// res.Request and rt is the transport wrapped by the
+// StatusHandlerWrapper which may be used to re-issue the request.
+// The returned response and error are passed to the caller in place
+// of the original response. Handlers which do not return res are
+// responsible for closing its body.
+type StatusHandler func(rt http.RoundTripper, res *http.Response) (*http.Response, error)
+
+type StatusHandlerConfig struct {
+	Handlers map[int]StatusHandler
+}
+
+func (c *StatusHandlerConfig) Option(opts ...StatusHandlerOption) {
+	for _, opt := range opts {
+		opt.ConfigureStatusHandler(c)
+	}
+}
+
+type StatusHandlerOption interface {
+	ConfigureStatusHandler(*StatusHandlerConfig)
+}
+
+// WithStatusHandler registers Handler for the given status codes.
+// Registering a handler for a code which already has a handler
+// replaces the existing handler.
+type WithStatusHandler struct {
+	Codes   []int
+	Handler StatusHandler
+}
+
+func (sh WithStatusHandler) ConfigureStatusHandler(c *StatusHandlerConfig) {
+	if c.Handlers == nil {
+		c.Handlers = make(map[int]StatusHandler)
+	}
+
+	for _, code := range sh.Codes {
+		c.Handlers[code] = sh.Handler
+	}
+}
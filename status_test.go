@@ -0,0 +1,103 @@
+package client
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/mt-sre/client/internal/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStatusHandlerWrapperInterfaces(t *testing.T) {
+	t.Parallel()
+
+	require.Implements(t, new(http.RoundTripper), new(StatusHandlerWrapper))
+
+	require.Implements(t, new(TransportWrapper), new(StatusHandlerWrapper))
+}
+
+func TestStatusHandlerWrapper(t *testing.T) {
+	t.Parallel()
+
+	errUnavailableForLegalReasons := errors.New("unavailable for legal reasons")
+
+	for name, tc := range map[string]struct {
+		StatusCode         int
+		ExpectedStatusCode int
+		ExpectedErr        error
+	}{
+		"no handler": {
+			StatusCode:         http.StatusOK,
+			ExpectedStatusCode: http.StatusOK,
+		},
+		"handler returns error": {
+			StatusCode:  http.StatusUnavailableForLegalReasons,
+			ExpectedErr: errUnavailableForLegalReasons,
+		},
+		"handler reissues request": {
+			StatusCode:         http.StatusUnauthorized,
+			ExpectedStatusCode: http.StatusOK,
+		},
+	} {
+		tc := tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			req := testutils.MockRequest(t, http.MethodGet, nil)
+
+			var mrt testutils.MockRoundTripper
+			mrt.
+				On("RoundTrip", req).
+				Return(&http.Response{
+					StatusCode: tc.StatusCode,
+					Body:       io.NopCloser(bytes.NewBuffer(nil)),
+				}, nil).
+				Once()
+			mrt.
+				On("RoundTrip", req).
+				Return(&http.Response{
+					StatusCode: http.StatusOK,
+					Body:       io.NopCloser(bytes.NewBuffer(nil)),
+				}, nil).
+				Maybe()
+
+			wrapper := NewStatusHandlerWrapper(
+				WithStatusHandler{
+					Codes: []int{http.StatusUnavailableForLegalReasons},
+					Handler: func(_ http.RoundTripper, res *http.Response) (*http.Response, error) {
+						res.Body.Close()
+
+						return nil, errUnavailableForLegalReasons
+					},
+				},
+				WithStatusHandler{
+					Codes: []int{http.StatusUnauthorized},
+					Handler: func(rt http.RoundTripper, res *http.Response) (*http.Response, error) {
+						res.Body.Close()
+
+						res.Request.Header.Set("Authorization", "Bearer refreshed")
+
+						return rt.RoundTrip(res.Request)
+					},
+				},
+			)
+
+			res, err := wrapper.Wrap(&mrt).RoundTrip(req)
+			if tc.ExpectedErr != nil {
+				require.ErrorIs(t, err, tc.ExpectedErr)
+
+				return
+			}
+
+			require.NoError(t, err)
+			defer res.Body.Close()
+
+			assert.Equal(t, tc.ExpectedStatusCode, res.StatusCode)
+		})
+	}
+}
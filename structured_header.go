@@ -0,0 +1,505 @@
+package client
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidStructuredHeader is returned when a header value
+// is not a valid RFC 8941 structured field.
+var ErrInvalidStructuredHeader = errors.New("invalid structured header")
+
+// StructuredToken is a token bare item e.g. the value of "a=b".
+type StructuredToken string
+
+// StructuredParam is a single parameter of a structured
+// item or inner list.
+type StructuredParam struct {
+	Key string
+	// Value is one of int64, float64, string, StructuredToken,
+	// []byte or bool.
+	Value any
+}
+
+// StructuredParams is an ordered set of parameters.
+type StructuredParams []StructuredParam
+
+// Get returns the value of the parameter with the given key.
+func (p StructuredParams) Get(key string) (any, bool) {
+	for _, param := range p {
+		if param.Key == key {
+			return param.Value, true
+		}
+	}
+
+	return nil, false
+}
+
+// StructuredMember is either a StructuredItem or a StructuredInnerList.
+type StructuredMember interface {
+	structuredMember()
+}
+
+// StructuredItem is a bare item with parameters.
+type StructuredItem struct {
+	// Value is one of int64, float64, string, StructuredToken,
+	// []byte or bool.
+	Value  any
+	Params StructuredParams
+}
+
+func (StructuredItem) structuredMember() {}
+
+// StructuredInnerList is a parenthesized list of items with parameters.
+type StructuredInnerList struct {
+	Items  []StructuredItem
+	Params StructuredParams
+}
+
+func (StructuredInnerList) structuredMember() {}
+
+// StructuredDictionaryEntry is a single member of a structured dictionary.
+type StructuredDictionaryEntry struct {
+	Key    string
+	Member StructuredMember
+}
+
+// StructuredDictionary is an ordered map of keys to members.
+type StructuredDictionary []StructuredDictionaryEntry
+
+// Get returns the member with the given key.
+func (d StructuredDictionary) Get(key string) (StructuredMember, bool) {
+	for _, entry := range d {
+		if entry.Key == key {
+			return entry.Member, true
+		}
+	}
+
+	return nil, false
+}
+
+// ParseStructuredItem parses the given header field values as
+// an RFC 8941 item.
+func ParseStructuredItem(vals ...string) (StructuredItem, error) {
+	p := sfParser{s: strings.Join(vals, ",")}
+
+	p.discardSP()
+
+	item, err := p.parseItem()
+	if err != nil {
+		return StructuredItem{}, err
+	}
+
+	p.discardSP()
+
+	if !p.eof() {
+		return StructuredItem{}, p.errorf("unexpected trailing characters")
+	}
+
+	return item, nil
+}
+
+// ParseStructuredList parses the given header field values as
+// an RFC 8941 list. Multiple values are combined as if they
+// were sent in a single field.
+func ParseStructuredList(vals ...string) ([]StructuredMember, error) {
+	p := sfParser{s: strings.Join(vals, ",")}
+
+	var members []StructuredMember
+
+	p.discardSP()
+
+	for !p.eof() {
+		member, err := p.parseItemOrInnerList()
+		if err != nil {
+			return nil, err
+		}
+
+		members = append(members, member)
+
+		if err := p.parseMemberSeparator(); err != nil {
+			return nil, err
+		}
+	}
+
+	return members, nil
+}
+
+// ParseStructuredDictionary parses the given header field values
+// as an RFC 8941 dictionary. Multiple values are combined as if
+// they were sent in a single field.
+func ParseStructuredDictionary(vals ...string) (StructuredDictionary, error) {
+	p := sfParser{s: strings.Join(vals, ",")}
+
+	var dict StructuredDictionary
+
+	p.discardSP()
+
+	for !p.eof() {
+		key, err := p.parseKey()
+		if err != nil {
+			return nil, err
+		}
+
+		var member StructuredMember
+
+		if p.peek() == '=' {
+			p.i++
+
+			if member, err = p.parseItemOrInnerList(); err != nil {
+				return nil, err
+			}
+		} else {
+			params, err := p.parseParams()
+			if err != nil {
+				return nil, err
+			}
+
+			member = StructuredItem{Value: true, Params: params}
+		}
+
+		dict = setDictionaryEntry(dict, key, member)
+
+		if err := p.parseMemberSeparator(); err != nil {
+			return nil, err
+		}
+	}
+
+	return dict, nil
+}
+
+func setDictionaryEntry(dict StructuredDictionary, key string, member StructuredMember) StructuredDictionary {
+	for i := range dict {
+		if dict[i].Key == key {
+			dict[i].Member = member
+
+			return dict
+		}
+	}
+
+	return append(dict, StructuredDictionaryEntry{Key: key, Member: member})
+}
+
+type sfParser struct {
+	s string
+	i int
+}
+
+func (p *sfParser) eof() bool {
+	return p.i >= len(p.s)
+}
+
+func (p *sfParser) peek() byte {
+	if p.eof() {
+		return 0
+	}
+
+	return p.s[p.i]
+}
+
+func (p *sfParser) errorf(format string, args ...any) error {
+	return fmt.Errorf("%w: %s at offset %d", ErrInvalidStructuredHeader, fmt.Sprintf(format, args...), p.i)
+}
+
+func (p *sfParser) discardSP() {
+	for p.peek() == ' ' {
+		p.i++
+	}
+}
+
+func (p *sfParser) discardOWS() {
+	for c := p.peek(); c == ' ' || c == '\t'; c = p.peek() {
+		p.i++
+	}
+}
+
+// parseMemberSeparator consumes the separator following a list
+// or dictionary member.
+func (p *sfParser) parseMemberSeparator() error {
+	p.discardOWS()
+
+	if p.eof() {
+		return nil
+	}
+
+	if p.peek() != ',' {
+		return p.errorf("expected ','")
+	}
+
+	p.i++
+
+	p.discardOWS()
+
+	if p.eof() {
+		return p.errorf("trailing ','")
+	}
+
+	return nil
+}
+
+func (p *sfParser) parseItemOrInnerList() (StructuredMember, error) {
+	if p.peek() == '(' {
+		return p.parseInnerList()
+	}
+
+	return p.parseItem()
+}
+
+func (p *sfParser) parseInnerList() (StructuredInnerList, error) {
+	p.i++
+
+	var list StructuredInnerList
+
+	for !p.eof() {
+		p.discardSP()
+
+		if p.peek() == ')' {
+			p.i++
+
+			params, err := p.parseParams()
+			if err != nil {
+				return StructuredInnerList{}, err
+			}
+
+			list.Params = params
+
+			return list, nil
+		}
+
+		item, err := p.parseItem()
+		if err != nil {
+			return StructuredInnerList{}, err
+		}
+
+		list.Items = append(list.Items, item)
+
+		if c := p.peek(); c != ' ' && c != ')' {
+			return StructuredInnerList{}, p.errorf("expected ' ' or ')'")
+		}
+	}
+
+	return StructuredInnerList{}, p.errorf("unterminated inner list")
+}
+
+func (p *sfParser) parseItem() (StructuredItem, error) {
+	val, err := p.parseBareItem()
+	if err != nil {
+		return StructuredItem{}, err
+	}
+
+	params, err := p.parseParams()
+	if err != nil {
+		return StructuredItem{}, err
+	}
+
+	return StructuredItem{Value: val, Params: params}, nil
+}
+
+func (p *sfParser) parseParams() (StructuredParams, error) {
+	var params StructuredParams
+
+	for p.peek() == ';' {
+		p.i++
+
+		p.discardSP()
+
+		key, err := p.parseKey()
+		if err != nil {
+			return nil, err
+		}
+
+		var val any = true
+
+		if p.peek() == '=' {
+			p.i++
+
+			if val, err = p.parseBareItem(); err != nil {
+				return nil, err
+			}
+		}
+
+		params = setParam(params, key, val)
+	}
+
+	return params, nil
+}
+
+func setParam(params StructuredParams, key string, val any) StructuredParams {
+	for i := range params {
+		if params[i].Key == key {
+			params[i].Value = val
+
+			return params
+		}
+	}
+
+	return append(params, StructuredParam{Key: key, Value: val})
+}
+
+func (p *sfParser) parseKey() (string, error) {
+	start := p.i
+
+	if c := p.peek(); !isLCAlpha(c) && c != '*' {
+		return "", p.errorf("invalid key")
+	}
+
+	for c := p.peek(); isLCAlpha(c) || isDigit(c) || strings.IndexByte("_-.*", c) >= 0; c = p.peek() {
+		p.i++
+	}
+
+	return p.s[start:p.i], nil
+}
+
+func (p *sfParser) parseBareItem() (any, error) {
+	switch c := p.peek(); {
+	case c == '-' || isDigit(c):
+		return p.parseNumber()
+	case c == '"':
+		return p.parseString()
+	case c == '*' || isAlpha(c):
+		return p.parseToken(), nil
+	case c == ':':
+		return p.parseByteSequence()
+	case c == '?':
+		return p.parseBoolean()
+	default:
+		return nil, p.errorf("invalid item")
+	}
+}
+
+func (p *sfParser) parseNumber() (any, error) {
+	start := p.i
+
+	if p.peek() == '-' {
+		p.i++
+	}
+
+	if !isDigit(p.peek()) {
+		return nil, p.errorf("invalid number")
+	}
+
+	decimal := false
+	dot := 0
+
+	for c := p.peek(); isDigit(c) || c == '.' && !decimal; c = p.peek() {
+		if c == '.' {
+			decimal = true
+			dot = p.i
+		}
+
+		p.i++
+	}
+
+	num := p.s[start:p.i]
+	digits := strings.TrimPrefix(num, "-")
+
+	if !decimal {
+		if len(digits) > 15 {
+			return nil, p.errorf("integer too long")
+		}
+
+		return strconv.ParseInt(num, 10, 64)
+	}
+
+	intPart, fracPart := p.s[start:dot], p.s[dot+1:p.i]
+	if len(strings.TrimPrefix(intPart, "-")) > 12 || len(fracPart) == 0 || len(fracPart) > 3 {
+		return nil, p.errorf("invalid decimal")
+	}
+
+	return strconv.ParseFloat(num, 64)
+}
+
+func (p *sfParser) parseString() (string, error) {
+	p.i++
+
+	var b strings.Builder
+
+	for !p.eof() {
+		c := p.s[p.i]
+		p.i++
+
+		switch {
+		case c == '\\':
+			if next := p.peek(); next != '"' && next != '\\' {
+				return "", p.errorf("invalid escape")
+			}
+
+			b.WriteByte(p.s[p.i])
+			p.i++
+		case c == '"':
+			return b.String(), nil
+		case c < 0x20 || c > 0x7e:
+			return "", p.errorf("invalid string character")
+		default:
+			b.WriteByte(c)
+		}
+	}
+
+	return "", p.errorf("unterminated string")
+}
+
+func (p *sfParser) parseToken() StructuredToken {
+	start := p.i
+
+	p.i++
+
+	for c := p.peek(); isTChar(c) || c == ':' || c == '/'; c = p.peek() {
+		p.i++
+	}
+
+	return StructuredToken(p.s[start:p.i])
+}
+
+func (p *sfParser) parseByteSequence() ([]byte, error) {
+	p.i++
+
+	end := strings.IndexByte(p.s[p.i:], ':')
+	if end < 0 {
+		return nil, p.errorf("unterminated byte sequence")
+	}
+
+	encoded := p.s[p.i : p.i+end]
+	p.i += end + 1
+
+	if strings.Trim(encoded, "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789+/=") != "" {
+		return nil, p.errorf("invalid byte sequence")
+	}
+
+	return base64.RawStdEncoding.DecodeString(strings.TrimRight(encoded, "="))
+}
+
+func (p *sfParser) parseBoolean() (bool, error) {
+	p.i++
+
+	switch p.peek() {
+	case '1':
+		p.i++
+
+		return true, nil
+	case '0':
+		p.i++
+
+		return false, nil
+	default:
+		return false, p.errorf("invalid boolean")
+	}
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isLCAlpha(c byte) bool {
+	return c >= 'a' && c <= 'z'
+}
+
+func isAlpha(c byte) bool {
+	return isLCAlpha(c) || c >= 'A' && c <= 'Z'
+}
+
+func isTChar(c byte) bool {
+	return isAlpha(c) || isDigit(c) || strings.IndexByte("!#$%&'*+-.^_`|~", c) >= 0
+}
@@ -0,0 +1,139 @@
+package client
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseStructuredItem(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		Value       string
+		Expected    StructuredItem
+		ExpectedErr error
+	}{
+		"integer": {
+			Value:    "42",
+			Expected: StructuredItem{Value: int64(42)},
+		},
+		"negative decimal": {
+			Value:    "-4.5",
+			Expected: StructuredItem{Value: -4.5},
+		},
+		"string with escapes": {
+			Value:    `"a \"quoted\" \\ value"`,
+			Expected: StructuredItem{Value: `a "quoted" \ value`},
+		},
+		"token with params": {
+			Value: "text/html; q=0.5; charset=utf-8; a",
+			Expected: StructuredItem{
+				Value: StructuredToken("text/html"),
+				Params: StructuredParams{
+					{Key: "q", Value: 0.5},
+					{Key: "charset", Value: StructuredToken("utf-8")},
+					{Key: "a", Value: true},
+				},
+			},
+		},
+		"byte sequence": {
+			Value:    ":aGVsbG8=:",
+			Expected: StructuredItem{Value: []byte("hello")},
+		},
+		"boolean": {
+			Value:    "?0",
+			Expected: StructuredItem{Value: false},
+		},
+		"integer too long": {
+			Value:       "1234567890123456",
+			ExpectedErr: ErrInvalidStructuredHeader,
+		},
+		"decimal with too many fraction digits": {
+			Value:       "1.2345",
+			ExpectedErr: ErrInvalidStructuredHeader,
+		},
+		"unterminated string": {
+			Value:       `"abc`,
+			ExpectedErr: ErrInvalidStructuredHeader,
+		},
+		"trailing characters": {
+			Value:       "1 2",
+			ExpectedErr: ErrInvalidStructuredHeader,
+		},
+		"uppercase parameter key": {
+			Value:       "a;Q=1",
+			ExpectedErr: ErrInvalidStructuredHeader,
+		},
+	} {
+		tc := tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			item, err := ParseStructuredItem(tc.Value)
+			if tc.ExpectedErr != nil {
+				require.ErrorIs(t, err, tc.ExpectedErr)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.Expected, item)
+		})
+	}
+}
+
+func TestParseStructuredList(t *testing.T) {
+	t.Parallel()
+
+	list, err := ParseStructuredList(`sugar, tea;hot, ("a" "b");lvl=5`, "rum")
+	require.NoError(t, err)
+
+	assert.Equal(t, []StructuredMember{
+		StructuredItem{Value: StructuredToken("sugar")},
+		StructuredItem{
+			Value:  StructuredToken("tea"),
+			Params: StructuredParams{{Key: "hot", Value: true}},
+		},
+		StructuredInnerList{
+			Items:  []StructuredItem{{Value: "a"}, {Value: "b"}},
+			Params: StructuredParams{{Key: "lvl", Value: int64(5)}},
+		},
+		StructuredItem{Value: StructuredToken("rum")},
+	}, list)
+
+	_, err = ParseStructuredList("a,")
+	require.ErrorIs(t, err, ErrInvalidStructuredHeader)
+
+	empty, err := ParseStructuredList("")
+	require.NoError(t, err)
+	assert.Empty(t, empty)
+}
+
+func TestParseStructuredDictionary(t *testing.T) {
+	t.Parallel()
+
+	dict, err := ParseStructuredDictionary(`remaining=10, reset=30;unit=s, busy, remaining=5`)
+	require.NoError(t, err)
+
+	assert.Equal(t, StructuredDictionary{
+		{Key: "remaining", Member: StructuredItem{Value: int64(5)}},
+		{Key: "reset", Member: StructuredItem{
+			Value:  int64(30),
+			Params: StructuredParams{{Key: "unit", Value: StructuredToken("s")}},
+		}},
+		{Key: "busy", Member: StructuredItem{Value: true}},
+	}, dict)
+
+	reset, ok := dict.Get("reset")
+	require.True(t, ok)
+
+	unit, ok := reset.(StructuredItem).Params.Get("unit")
+	require.True(t, ok)
+	assert.Equal(t, StructuredToken("s"), unit)
+
+	_, err = ParseStructuredDictionary("a=1 b=2")
+	require.ErrorIs(t, err, ErrInvalidStructuredHeader)
+}
@@ -0,0 +1,47 @@
+package client
+
+import "time"
+
+// WithTimeout configures a Client instance with a limit on the
+// total time taken by a request including connecting, redirects,
+// retries performed by wrappers and reading the response body.
+type WithTimeout time.Duration
+
+func (t WithTimeout) ConfigureClient(c *ClientConfig) {
+	c.Timeout = time.Duration(t)
+}
+
+// WithDialTimeout configures a Client instance with a limit on
+// the time taken to establish a connection.
+type WithDialTimeout time.Duration
+
+func (t WithDialTimeout) ConfigureClient(c *ClientConfig) {
+	c.DialTimeout = time.Duration(t)
+}
+
+// WithTLSHandshakeTimeout configures a Client instance with a limit
+// on the time taken to perform a TLS handshake.
+type WithTLSHandshakeTimeout time.Duration
+
+func (t WithTLSHandshakeTimeout) ConfigureClient(c *ClientConfig) {
+	c.TLSHandshakeTimeout = time.Duration(t)
+}
+
+// WithResponseHeaderTimeout configures a Client instance with a
+// limit on the time spent waiting for response headers after
+// the request has been fully written.
+type WithResponseHeaderTimeout time.Duration
+
+func (t WithResponseHeaderTimeout) ConfigureClient(c *ClientConfig) {
+	c.ResponseHeaderTimeout = time.Duration(t)
+}
+
+// WithRequestTimeout limits the total time taken by a single
+// request including reading the response body. The timeout is
+// applied in addition to any deadline of the request's context
+// and any Client level timeout; whichever expires first wins.
+type WithRequestTimeout time.Duration
+
+func (t WithRequestTimeout) ConfigureRequest(c *RequestConfig) {
+	c.Timeout = time.Duration(t)
+}
@@ -0,0 +1,99 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestTimeoutOptions ensures that transport level timeouts
+// are applied to the constructed http.Transport.
+func TestTimeoutOptions(t *testing.T) {
+	t.Parallel()
+
+	client := NewClient(
+		WithTimeout(time.Minute),
+		WithDialTimeout(time.Second),
+		WithTLSHandshakeTimeout(2*time.Second),
+		WithResponseHeaderTimeout(3*time.Second),
+	)
+
+	assert.Equal(t, time.Minute, client.client.Timeout)
+
+	tp, ok := client.cfg.Transport.(*http.Transport)
+	require.True(t, ok, "expected transport to be *http.Transport")
+
+	assert.NotSame(t, http.DefaultTransport, tp)
+	assert.NotNil(t, tp.DialContext)
+	assert.Equal(t, 2*time.Second, tp.TLSHandshakeTimeout)
+	assert.Equal(t, 3*time.Second, tp.ResponseHeaderTimeout)
+}
+
+func TestTimeouts(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(100 * time.Millisecond):
+		case <-r.Context().Done():
+		}
+
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(srv.Close)
+
+	for name, tc := range map[string]struct {
+		ClientOptions  []ClientOption
+		RequestOptions []RequestOption
+		ExpectTimeout  bool
+	}{
+		"no timeout": {},
+		"client timeout": {
+			ClientOptions: []ClientOption{WithTimeout(10 * time.Millisecond)},
+			ExpectTimeout: true,
+		},
+		"response header timeout": {
+			ClientOptions: []ClientOption{WithResponseHeaderTimeout(10 * time.Millisecond)},
+			ExpectTimeout: true,
+		},
+		"request timeout": {
+			RequestOptions: []RequestOption{WithRequestTimeout(10 * time.Millisecond)},
+			ExpectTimeout:  true,
+		},
+		"request timeout shorter than client timeout": {
+			ClientOptions:  []ClientOption{WithTimeout(time.Minute)},
+			RequestOptions: []RequestOption{WithRequestTimeout(10 * time.Millisecond)},
+			ExpectTimeout:  true,
+		},
+	} {
+		tc := tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			client := NewClient(tc.ClientOptions...)
+
+			res, err := client.Get(context.Background(), srv.URL, tc.RequestOptions...)
+			if !tc.ExpectTimeout {
+				require.NoError(t, err)
+				res.Body.Close()
+
+				return
+			}
+
+			require.Error(t, err)
+
+			var netErr interface{ Timeout() bool }
+
+			if !errors.As(err, &netErr) || !netErr.Timeout() {
+				assert.ErrorIs(t, err, context.DeadlineExceeded)
+			}
+		})
+	}
+}
@@ -0,0 +1,415 @@
+package client
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// WithTLSConfig configures a Client instance with the given
+// tls.Config which is used by the underlying http.Transport.
+// Options which modify the TLS configuration such as
+// WithClientCertificate and WithCACertPool are applied on top
+// of this configuration and should therefore be provided after it.
+type WithTLSConfig struct{ *tls.Config }
+
+func (t WithTLSConfig) ConfigureClient(c *ClientConfig) {
+	if t.Config == nil {
+		return
+	}
+
+	c.TLSConfig = t.Config.Clone()
+}
+
+// WithClientCertificate configures a Client instance to present
+// the PEM encoded certificate and key stored at CertFile and KeyFile
+// when a server requests client authentication (mTLS). The files are
+// read lazily during the TLS handshake so that errors are surfaced
+// from the request rather than from client construction. If Reload
+// is set the files are re-read whenever their modification time
+// changes which allows rotated secrets to be picked up without
+// recreating the Client.
+type WithClientCertificate struct {
+	CertFile string
+	KeyFile  string
+	Reload   bool
+}
+
+func (cc WithClientCertificate) ConfigureClient(c *ClientConfig) {
+	loader := &certificateLoader{
+		certFile: cc.CertFile,
+		keyFile:  cc.KeyFile,
+		reload:   cc.Reload,
+	}
+
+	c.tlsConfig().GetClientCertificate = loader.GetClientCertificate
+}
+
+// WithCACertPool configures a Client instance to verify server
+// certificates against the given x509.CertPool instead of the
+// system roots. If WithCADirectory is also provided certificates
+// from either source are trusted.
+type WithCACertPool struct{ *x509.CertPool }
+
+func (p WithCACertPool) ConfigureClient(c *ClientConfig) {
+	if c.caDirectory != nil {
+		c.caDirectory.setBase(p.CertPool)
+
+		return
+	}
+
+	c.tlsConfig().RootCAs = p.CertPool
+}
+
+// WithCADirectory configures a Client instance to verify server
+// certificates against the PEM encoded certificates of all files in
+// the given directory instead of the system roots. The directory is
+// re-read during TLS handshakes whenever its contents change so that
+// rotated trust bundles, e.g. mounted from a ConfigMap, are picked up
+// without recreating the Client. Hidden files are ignored and should
+// a reload fail the previously loaded certificates remain in use.
+// Certificates of a pool provided through WithCACertPool and of
+// further directories are trusted as well.
+type WithCADirectory string
+
+func (d WithCADirectory) ConfigureClient(c *ClientConfig) {
+	if c.caDirectory != nil {
+		c.caDirectory.addDir(string(d))
+
+		return
+	}
+
+	cfg := c.tlsConfig()
+	loader := &caDirectoryLoader{
+		dirs: []string{string(d)},
+		base: cfg.RootCAs,
+	}
+
+	c.caDirectory = loader
+
+	// Verification is performed against the current pool by the
+	// TLS dialer of the transport and by VerifyConnection for
+	// connections through proxies as RootCAs cannot be swapped
+	// safely.
+	cfg.RootCAs = nil
+	cfg.InsecureSkipVerify = true
+	cfg.VerifyConnection = loader.VerifyConnection
+}
+
+// WithInsecureSkipVerify configures a Client instance to accept
+// any certificate presented by the server. This disables protection
+// against man-in-the-middle attacks and should only be used for
+// testing or debugging.
+type WithInsecureSkipVerify struct{}
+
+func (WithInsecureSkipVerify) ConfigureClient(c *ClientConfig) {
+	cfg := c.tlsConfig()
+	cfg.InsecureSkipVerify = true
+
+	if c.caDirectory != nil {
+		c.caDirectory = nil
+		cfg.VerifyConnection = nil
+	}
+}
+
+// WithMinTLSVersion configures a Client instance to refuse
+// connections negotiating a TLS version lower than the given
+// one e.g. tls.VersionTLS13. Defaults to TLS 1.2.
+type WithMinTLSVersion uint16
+
+func (v WithMinTLSVersion) ConfigureClient(c *ClientConfig) {
+	c.tlsConfig().MinVersion = uint16(v)
+}
+
+// WithCipherSuites restricts the cipher suites offered by a Client
+// instance for TLS 1.2 and earlier to the given IDs. TLS 1.3 cipher
+// suites are not configurable.
+type WithCipherSuites []uint16
+
+func (cs WithCipherSuites) ConfigureClient(c *ClientConfig) {
+	c.tlsConfig().CipherSuites = cs
+}
+
+// ErrHTTPSRequired is returned for requests using plain HTTP
+// by a Client instance configured with WithRequireHTTPS.
+var ErrHTTPSRequired = errors.New("https required")
+
+// WithRequireHTTPS configures a Client instance to reject requests
+// which do not use https, including redirects and requests issued by
+// TransportWrappers, with ErrHTTPSRequired. Requests for AllowHosts,
+// matched using the same rules as WithNoProxy, are exempt e.g. to
+// reach sidecars on the loopback interface.
+type WithRequireHTTPS struct {
+	AllowHosts []string
+}
+
+func (r WithRequireHTTPS) ConfigureClient(c *ClientConfig) {
+	c.RequireHTTPS = true
+	c.InsecureHosts = append(c.InsecureHosts, r.AllowHosts...)
+}
+
+var errUnverifiableServerName = errors.New("cannot verify server certificate without server name")
+
+type caDirectoryLoader struct {
+	dirs []string
+
+	mu          sync.Mutex
+	base        *x509.CertPool
+	pool        *x509.CertPool
+	fingerprint string
+}
+
+func (l *caDirectoryLoader) addDir(dir string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.dirs = append(l.dirs, dir)
+	l.pool = nil
+}
+
+func (l *caDirectoryLoader) setBase(pool *x509.CertPool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.base = pool
+	l.pool = nil
+}
+
+// VerifyConnection verifies connections established by the transport
+// itself i.e. through proxies. The connection state only holds the
+// server name sent using SNI which is empty for IP addresses so such
+// connections are rejected rather than accepted without checking the
+// certificate against the host.
+func (l *caDirectoryLoader) VerifyConnection(cs tls.ConnectionState) error {
+	if len(cs.PeerCertificates) == 0 {
+		return errors.New("server presented no certificates")
+	}
+
+	if cs.ServerName == "" {
+		return errUnverifiableServerName
+	}
+
+	roots, err := l.roots()
+	if err != nil {
+		return err
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, cert := range cs.PeerCertificates[1:] {
+		intermediates.AddCert(cert)
+	}
+
+	_, err = cs.PeerCertificates[0].Verify(x509.VerifyOptions{
+		DNSName:       cs.ServerName,
+		Roots:         roots,
+		Intermediates: intermediates,
+	})
+
+	return err
+}
+
+// dialTLS returns a DialTLSContext function for tp which performs the
+// handshake verifying the server certificate against the current pool
+// and the host being dialled including IP addresses.
+func (l *caDirectoryLoader) dialTLS(tp *http.Transport, timeout time.Duration) DialContextFunc {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		roots, err := l.roots()
+		if err != nil {
+			return nil, err
+		}
+
+		conn, err := tp.DialContext(ctx, network, addr)
+		if err != nil {
+			return nil, err
+		}
+
+		// read at dial time as HTTP/2 support adds to NextProtos
+		cfg := tp.TLSClientConfig.Clone()
+		cfg.RootCAs = roots
+		cfg.InsecureSkipVerify = false
+		cfg.VerifyConnection = nil
+
+		if cfg.ServerName == "" {
+			host, _, err := net.SplitHostPort(addr)
+			if err != nil {
+				conn.Close()
+
+				return nil, err
+			}
+
+			cfg.ServerName = host
+		}
+
+		if timeout > 0 {
+			var cancel context.CancelFunc
+
+			ctx, cancel = context.WithTimeout(ctx, timeout)
+			defer cancel()
+		}
+
+		tlsConn := tls.Client(conn, cfg)
+
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			conn.Close()
+
+			return nil, err
+		}
+
+		return tlsConn, nil
+	}
+}
+
+// roots returns the current pool reloading
+// the directory if its contents changed.
+func (l *caDirectoryLoader) roots() (*x509.CertPool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	files, fingerprint, err := l.scan()
+	if err != nil {
+		if l.pool != nil {
+			return l.pool, nil
+		}
+
+		return nil, err
+	}
+
+	if l.pool != nil && fingerprint == l.fingerprint {
+		return l.pool, nil
+	}
+
+	pool := x509.NewCertPool()
+	loaded := false
+
+	if l.base != nil {
+		pool = l.base.Clone()
+		loaded = true
+	}
+
+	for _, name := range files {
+		data, err := os.ReadFile(name)
+		if err != nil {
+			return l.fallback(fmt.Errorf("reading CA certificate file: %w", err))
+		}
+
+		if pool.AppendCertsFromPEM(data) {
+			loaded = true
+		}
+	}
+
+	if !loaded {
+		return l.fallback(fmt.Errorf("no CA certificates found in %q", l.dirs))
+	}
+
+	l.pool = pool
+	l.fingerprint = fingerprint
+
+	return l.pool, nil
+}
+
+func (l *caDirectoryLoader) fallback(err error) (*x509.CertPool, error) {
+	if l.pool != nil {
+		return l.pool, nil
+	}
+
+	return nil, err
+}
+
+// scan lists the regular files in the directories along with a
+// fingerprint of their names, sizes and modification times.
+func (l *caDirectoryLoader) scan() ([]string, string, error) {
+	var (
+		files       []string
+		fingerprint strings.Builder
+	)
+
+	for _, dir := range l.dirs {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return nil, "", fmt.Errorf("reading CA directory: %w", err)
+		}
+
+		for _, entry := range entries {
+			if strings.HasPrefix(entry.Name(), ".") {
+				continue
+			}
+
+			name := filepath.Join(dir, entry.Name())
+
+			// Stat follows the symlinks used by ConfigMap mounts.
+			info, err := os.Stat(name)
+			if err != nil || !info.Mode().IsRegular() {
+				continue
+			}
+
+			files = append(files, name)
+			fmt.Fprintf(&fingerprint, "%s:%d:%d;", name, info.Size(), info.ModTime().UnixNano())
+		}
+	}
+
+	return files, fingerprint.String(), nil
+}
+
+type certificateLoader struct {
+	certFile string
+	keyFile  string
+	reload   bool
+
+	mu      sync.Mutex
+	cert    *tls.Certificate
+	modTime time.Time
+}
+
+func (l *certificateLoader) GetClientCertificate(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.cert != nil && !l.reload {
+		return l.cert, nil
+	}
+
+	modTime, err := l.latestModTime()
+	if err != nil {
+		return nil, err
+	}
+
+	if l.cert != nil && !modTime.After(l.modTime) {
+		return l.cert, nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(l.certFile, l.keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("loading client certificate: %w", err)
+	}
+
+	l.cert = &cert
+	l.modTime = modTime
+
+	return l.cert, nil
+}
+
+func (l *certificateLoader) latestModTime() (time.Time, error) {
+	var latest time.Time
+
+	for _, name := range []string{l.certFile, l.keyFile} {
+		info, err := os.Stat(name)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("inspecting client certificate file: %w", err)
+		}
+
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+
+	return latest, nil
+}
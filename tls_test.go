@@ -0,0 +1,387 @@
+package client
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mt-sre/client/internal/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestWithCACertPool ensures that server certificates are
+// verified against the configured pool.
+func TestWithCACertPool(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	_, err := NewClient().Get(context.Background(), srv.URL)
+	require.Error(t, err, "request to server with unknown CA should fail")
+
+	pool := x509.NewCertPool()
+	pool.AddCert(srv.Certificate())
+
+	client := NewClient(
+		WithCACertPool{CertPool: pool},
+	)
+
+	res, err := client.Get(context.Background(), srv.URL)
+	require.NoError(t, err)
+	defer res.Body.Close()
+
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+}
+
+// TestWithClientCertificate ensures that the configured client
+// certificate is presented to the server and that rotated
+// certificates are picked up when Reload is enabled.
+func TestWithClientCertificate(t *testing.T) {
+	t.Parallel()
+
+	serials := make(chan int64, 2)
+
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		serials <- r.TLS.PeerCertificates[0].SerialNumber.Int64()
+
+		w.WriteHeader(http.StatusOK)
+	}))
+	srv.TLS = &tls.Config{
+		ClientAuth: tls.RequireAnyClientCert,
+		MinVersion: tls.VersionTLS12,
+	}
+	srv.StartTLS()
+	t.Cleanup(srv.Close)
+
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "tls.crt")
+	keyFile := filepath.Join(dir, "tls.key")
+
+	writeCertificate(t, certFile, keyFile, 1, time.Now().Add(-time.Minute))
+
+	pool := x509.NewCertPool()
+	pool.AddCert(srv.Certificate())
+
+	client := NewClient(
+		WithCACertPool{CertPool: pool},
+		WithClientCertificate{
+			CertFile: certFile,
+			KeyFile:  keyFile,
+			Reload:   true,
+		},
+	)
+
+	res, err := client.Get(context.Background(), srv.URL)
+	require.NoError(t, err)
+	res.Body.Close()
+
+	assert.Equal(t, int64(1), <-serials)
+
+	writeCertificate(t, certFile, keyFile, 2, time.Now())
+	srv.CloseClientConnections()
+
+	res, err = client.Get(context.Background(), srv.URL)
+	require.NoError(t, err)
+	res.Body.Close()
+
+	assert.Equal(t, int64(2), <-serials)
+}
+
+// TestWithClientCertificate_MissingFiles ensures that errors loading
+// the client certificate are surfaced from the request.
+func TestWithClientCertificate_MissingFiles(t *testing.T) {
+	t.Parallel()
+
+	loader := certificateLoader{
+		certFile: filepath.Join(t.TempDir(), "missing.crt"),
+		keyFile:  filepath.Join(t.TempDir(), "missing.key"),
+	}
+
+	_, err := loader.GetClientCertificate(nil)
+	require.Error(t, err)
+}
+
+// TestWithCADirectory ensures that server certificates are verified
+// against the certificates in the directory and that changes to the
+// directory are picked up.
+func TestWithCADirectory(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+
+	unrelated, _ := testutils.GenerateCertificate(t, 1)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "unrelated.pem"), unrelated, 0o600))
+
+	c := NewClient(WithCADirectory(dir))
+	defer c.Close()
+
+	_, err := c.Get(context.Background(), srv.URL)
+	require.Error(t, err, "request to server with unknown CA should fail")
+
+	trusted := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "server.pem"), trusted, 0o600))
+
+	res, err := c.Get(context.Background(), srv.URL)
+	require.NoError(t, err)
+	require.NoError(t, res.Body.Close())
+
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+}
+
+// TestWithCADirectory_HostVerification ensures that certificates
+// are verified against the dialled host for IP addresses which are
+// not sent as server names.
+func TestWithCADirectory_HostVerification(t *testing.T) {
+	t.Parallel()
+
+	cert, certPEM := selfSignedServerCertificate(t, "unrelated.example")
+
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	srv.TLS = &tls.Config{Certificates: []tls.Certificate{cert}}
+	srv.StartTLS()
+	t.Cleanup(srv.Close)
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "ca.pem"), certPEM, 0o600))
+
+	c := NewClient(WithCADirectory(dir))
+	defer c.Close()
+
+	_, err := c.Get(context.Background(), srv.URL)
+
+	var hostErr x509.HostnameError
+
+	require.ErrorAs(t, err, &hostErr, "certificate for another host must be rejected")
+}
+
+// TestWithCADirectory_CACertPool ensures that certificates of both
+// WithCACertPool and WithCADirectory are trusted regardless of order.
+func TestWithCADirectory_CACertPool(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(srv.Close)
+
+	pool := x509.NewCertPool()
+	pool.AddCert(srv.Certificate())
+
+	dir := t.TempDir()
+	_, unrelated := selfSignedServerCertificate(t, "unrelated.example")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "unrelated.pem"), unrelated, 0o600))
+
+	for name, opts := range map[string][]ClientOption{
+		"pool first":      {WithCACertPool{CertPool: pool}, WithCADirectory(dir)},
+		"directory first": {WithCADirectory(dir), WithCACertPool{CertPool: pool}},
+	} {
+		opts := opts
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			c := NewClient(opts...)
+			defer c.Close()
+
+			res, err := c.Get(context.Background(), srv.URL)
+			require.NoError(t, err)
+			require.NoError(t, res.Body.Close())
+
+			assert.False(t, c.DebugInfo().Config.InsecureSkipVerify)
+		})
+	}
+}
+
+func selfSignedServerCertificate(t *testing.T, dnsName string) (tls.Certificate, []byte) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: dnsName},
+		DNSNames:              []string{dnsName},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key},
+		pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+// TestWithCADirectory_Empty ensures that handshakes fail
+// if the directory contains no certificates.
+func TestWithCADirectory_Empty(t *testing.T) {
+	t.Parallel()
+
+	loader := caDirectoryLoader{dirs: []string{t.TempDir()}}
+
+	_, err := loader.roots()
+	require.Error(t, err)
+}
+
+// TestWithMinTLSVersion ensures that connections negotiating
+// an older TLS version than the configured minimum fail.
+func TestWithMinTLSVersion(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	srv.TLS = &tls.Config{MaxVersion: tls.VersionTLS12}
+	srv.StartTLS()
+	t.Cleanup(srv.Close)
+
+	pool := x509.NewCertPool()
+	pool.AddCert(srv.Certificate())
+
+	for name, tc := range map[string]struct {
+		Options     []ClientOption
+		ExpectError bool
+	}{
+		"default": {},
+		"tls 1.3": {
+			Options:     []ClientOption{WithMinTLSVersion(tls.VersionTLS13)},
+			ExpectError: true,
+		},
+		"matching cipher suite": {
+			Options: []ClientOption{WithCipherSuites{tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256}},
+		},
+		"unsupported cipher suite": {
+			Options:     []ClientOption{WithCipherSuites{tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256}},
+			ExpectError: true,
+		},
+	} {
+		tc := tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			c := NewClient(append([]ClientOption{WithCACertPool{CertPool: pool}}, tc.Options...)...)
+			defer c.Close()
+
+			res, err := c.Get(context.Background(), srv.URL)
+			if tc.ExpectError {
+				require.Error(t, err)
+
+				return
+			}
+
+			require.NoError(t, err)
+			require.NoError(t, res.Body.Close())
+		})
+	}
+}
+
+// TestWithRequireHTTPS ensures that plain HTTP requests are
+// rejected, including redirects, unless the host is allowed.
+func TestWithRequireHTTPS(t *testing.T) {
+	t.Parallel()
+
+	plain := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(plain.Close)
+
+	secure := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/redirect" {
+			http.Redirect(w, r, plain.URL, http.StatusFound)
+
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(secure.Close)
+
+	pool := x509.NewCertPool()
+	pool.AddCert(secure.Certificate())
+
+	for name, tc := range map[string]struct {
+		URL         string
+		AllowHosts  []string
+		ExpectError bool
+	}{
+		"https": {
+			URL: secure.URL,
+		},
+		"http": {
+			URL:         plain.URL,
+			ExpectError: true,
+		},
+		"redirect to http": {
+			URL:         secure.URL + "/redirect",
+			ExpectError: true,
+		},
+		"allowed host": {
+			URL:        plain.URL,
+			AllowHosts: []string{"127.0.0.1"},
+		},
+	} {
+		tc := tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			c := NewClient(
+				WithCACertPool{CertPool: pool},
+				WithRequireHTTPS{AllowHosts: tc.AllowHosts},
+				WithWrapper{TransportWrapper: NewRetryWrapper()},
+			)
+			defer c.Close()
+
+			res, err := c.Get(context.Background(), tc.URL)
+			if tc.ExpectError {
+				require.ErrorIs(t, err, ErrHTTPSRequired)
+
+				return
+			}
+
+			require.NoError(t, err)
+			require.NoError(t, res.Body.Close())
+		})
+	}
+}
+
+func writeCertificate(t *testing.T, certFile, keyFile string, serial int64, modTime time.Time) {
+	t.Helper()
+
+	certPEM, keyPEM := testutils.GenerateCertificate(t, serial)
+
+	require.NoError(t, os.WriteFile(certFile, certPEM, 0o600))
+	require.NoError(t, os.WriteFile(keyFile, keyPEM, 0o600))
+
+	require.NoError(t, os.Chtimes(certFile, modTime, modTime))
+	require.NoError(t, os.Chtimes(keyFile, modTime, modTime))
+}
@@ -0,0 +1,193 @@
+package client
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+// WithTokenSource configures an OAUTHWrapper to obtain tokens from the
+// given TokenSource. The wrapper caches and proactively refreshes tokens
+// itself so the source should return a new token on every call rather
+// than caching them e.g. using oauth2.ReuseTokenSource.
+type WithTokenSource struct{ oauth2.TokenSource }
+
+func (ts WithTokenSource) ConfigureOAUTH(c *OAUTHConfig) {
+	c.source = ts.TokenSource
+}
+
+// WithTokenCache configures an OAUTHWrapper to persist the tokens it
+// obtains in Store under a key derived from Issuer and ClientID. A
+// stored token which is not about to expire is used instead of
+// obtaining a new one when the wrapper first needs a token so that
+// e.g. successive invocations of a CLI do not re-authenticate. Failing
+// to load or store tokens does not fail requests.
+type WithTokenCache struct {
+	Store TokenStore
+	// Issuer identifies the authorization server
+	// e.g. by its issuer or token URL.
+	Issuer   string
+	ClientID string
+}
+
+func (tc WithTokenCache) ConfigureOAUTH(c *OAUTHConfig) {
+	c.cache = &tc
+}
+
+// TokenStore persists tokens across processes. Implementations
+// must be safe for concurrent use.
+type TokenStore interface {
+	// LoadToken returns the token stored under key
+	// or nil if there is none.
+	LoadToken(key string) (*oauth2.Token, error)
+	// StoreToken stores token under key replacing
+	// any token previously stored.
+	StoreToken(key string, token *oauth2.Token) error
+}
+
+// TokenStoreKey returns the key under which tokens issued by
+// issuer to the client with the given ID are stored.
+func TokenStoreKey(issuer, clientID string) string {
+	sum := sha256.Sum256([]byte(issuer + "\x00" + clientID))
+
+	return hex.EncodeToString(sum[:])
+}
+
+// DefaultTokenStoreDir returns the directory in which tokens are
+// stored by default within the user's cache directory.
+func DefaultTokenStoreDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("determining cache directory: %w", err)
+	}
+
+	return filepath.Join(dir, "mt-sre-client", "tokens"), nil
+}
+
+// NewFileTokenStore returns a TokenStore which stores each token as a
+// JSON file within dir. The directory is created with 0700 and token
+// files with 0600 permissions as tokens are credentials.
+func NewFileTokenStore(dir string) *FileTokenStore {
+	return &FileTokenStore{dir: dir}
+}
+
+type FileTokenStore struct {
+	dir string
+	mu  sync.Mutex
+}
+
+func (s *FileTokenStore) LoadToken(key string) (*oauth2.Token, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, err := os.ReadFile(s.path(key))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("reading token: %w", err)
+	}
+
+	var token oauth2.Token
+
+	if err := json.Unmarshal(data, &token); err != nil {
+		return nil, fmt.Errorf("decoding token: %w", err)
+	}
+
+	return &token, nil
+}
+
+func (s *FileTokenStore) StoreToken(key string, token *oauth2.Token) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := os.MkdirAll(s.dir, 0o700); err != nil {
+		return fmt.Errorf("creating token directory: %w", err)
+	}
+
+	data, err := json.Marshal(token)
+	if err != nil {
+		return fmt.Errorf("encoding token: %w", err)
+	}
+
+	// CreateTemp creates files with 0600 permissions
+	f, err := os.CreateTemp(s.dir, ".token-*")
+	if err != nil {
+		return fmt.Errorf("creating token: %w", err)
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+
+		return fmt.Errorf("writing token: %w", err)
+	}
+
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("writing token: %w", err)
+	}
+
+	if err := os.Rename(f.Name(), s.path(key)); err != nil {
+		return fmt.Errorf("writing token: %w", err)
+	}
+
+	return nil
+}
+
+func (s *FileTokenStore) path(key string) string {
+	return filepath.Join(s.dir, key+".json")
+}
+
+// storedTokenSource persists the tokens of src in store using the
+// stored token in place of the first token of src if still valid.
+type storedTokenSource struct {
+	src   oauth2.TokenSource
+	store TokenStore
+	key   string
+	skew  time.Duration
+	clock Clock
+
+	mu     sync.Mutex
+	loaded bool
+}
+
+func newStoredTokenSource(src oauth2.TokenSource, tc WithTokenCache, skew time.Duration, clock Clock) *storedTokenSource {
+	return &storedTokenSource{
+		src:   src,
+		store: tc.Store,
+		key:   TokenStoreKey(tc.Issuer, tc.ClientID),
+		skew:  skew,
+		clock: clock,
+	}
+}
+
+func (s *storedTokenSource) Token() (*oauth2.Token, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.loaded {
+		s.loaded = true
+
+		token, err := s.store.LoadToken(s.key)
+		if err == nil && token != nil && token.AccessToken != "" &&
+			(token.Expiry.IsZero() || token.Expiry.Sub(s.clock.Now()) > s.skew) {
+			return token, nil
+		}
+	}
+
+	token, err := s.src.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	_ = s.store.StoreToken(s.key, token)
+
+	return token, nil
+}
@@ -0,0 +1,98 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+)
+
+func TestFileTokenStore(t *testing.T) {
+	t.Parallel()
+
+	dir := filepath.Join(t.TempDir(), "tokens")
+	store := NewFileTokenStore(dir)
+	key := TokenStoreKey("https://sso.example.com", "cli")
+
+	token, err := store.LoadToken(key)
+	require.NoError(t, err)
+	assert.Nil(t, token)
+
+	expiry := time.Now().Add(time.Hour).Round(0)
+
+	require.NoError(t, store.StoreToken(key, &oauth2.Token{
+		AccessToken:  "access",
+		RefreshToken: "refresh",
+		Expiry:       expiry,
+	}))
+
+	token, err = store.LoadToken(key)
+	require.NoError(t, err)
+	assert.Equal(t, "access", token.AccessToken)
+	assert.Equal(t, "refresh", token.RefreshToken)
+	assert.True(t, expiry.Equal(token.Expiry))
+
+	info, err := os.Stat(filepath.Join(dir, key+".json"))
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+	info, err = os.Stat(dir)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o700), info.Mode().Perm())
+
+	assert.NotEqual(t, key, TokenStoreKey("https://sso.example.com", "other"))
+}
+
+func TestWithTokenCache(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, r.Header.Get("Authorization"))
+	}))
+	defer srv.Close()
+
+	store := NewFileTokenStore(t.TempDir())
+
+	for name, tc := range map[string]struct {
+		TTL      time.Duration
+		Expected []string
+	}{
+		"valid token reused": {
+			TTL:      time.Hour,
+			Expected: []string{"Bearer token-1", "Bearer token-1"},
+		},
+		"expiring token replaced": {
+			TTL:      5 * time.Second,
+			Expected: []string{"Bearer token-1", "Bearer token-2"},
+		},
+	} {
+		src := &countingTokenSource{ttl: tc.TTL}
+
+		// each client stands in for a separate invocation of a CLI
+		for _, expected := range tc.Expected {
+			c := NewClient(WithWrapper{TransportWrapper: NewOAUTHWrapper(
+				WithTokenSource{TokenSource: src},
+				WithTokenCache{Store: store, Issuer: srv.URL, ClientID: name},
+			)})
+
+			res, err := c.Get(context.Background(), srv.URL)
+			require.NoError(t, err)
+
+			body, err := io.ReadAll(res.Body)
+			require.NoError(t, err)
+			require.NoError(t, res.Body.Close())
+
+			c.Close()
+
+			assert.Equal(t, expected, string(body), name)
+		}
+	}
+}
@@ -0,0 +1,299 @@
+package client
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptrace"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+)
+
+// ConnectionTrace holds the latency breakdown of a single attempt.
+// Phases which were not performed, for instance because a pooled
+// connection was reused, have a zero duration.
+type ConnectionTrace struct {
+	// Start is the time the attempt started.
+	Start time.Time
+	// GetConn is the time taken to obtain a connection
+	// including DNS, connect and the TLS handshake.
+	GetConn time.Duration
+	// DNS is the time taken to resolve the host name.
+	DNS time.Duration
+	// Connect is the time taken to establish the connection.
+	Connect time.Duration
+	// TLSHandshake is the time taken by the TLS handshake.
+	TLSHandshake time.Duration
+	// TimeToFirstByte is the time from Start until the
+	// first byte of the response was received.
+	TimeToFirstByte time.Duration
+	// Duration is the time from Start until
+	// the response headers were received.
+	Duration time.Duration
+	// Total is the time from Start until the response body was
+	// read to the end or closed. It is zero while the body is
+	// still being read and equals Duration for responses without
+	// a body.
+	Total time.Duration
+	// Reused is set if a pooled connection was used.
+	Reused     bool
+	RemoteAddr string
+}
+
+// ResponseConnectionTrace returns the ConnectionTrace of the attempt
+// which produced res if the request was traced by a
+// ConnectionTracingWrapper.
+func ResponseConnectionTrace(res *http.Response) (ConnectionTrace, bool) {
+	if res == nil || res.Request == nil {
+		return ConnectionTrace{}, false
+	}
+
+	tracer, ok := res.Request.Context().Value(connectionTraceKey{}).(*connectionTracer)
+	if !ok {
+		return ConnectionTrace{}, false
+	}
+
+	return tracer.snapshot(), true
+}
+
+type connectionTraceKey struct{}
+
+// TraceHook is called with the ConnectionTrace of every attempt.
+type TraceHook func(req *http.Request, trace ConnectionTrace)
+
+// NewConnectionTracingWrapper returns a TransportWrapper which records
+// a ConnectionTrace for every attempt. Traces are logged at V(1) by the
+// configured logger, passed to the configured TraceHook, for instance
+// to record metrics, and are available from the response through
+// ResponseConnectionTrace. The ConnectionTracingWrapper should be applied
+// before any RetryWrapper so that each attempt is traced individually.
+func NewConnectionTracingWrapper(opts ...ConnectionTracingOption) *ConnectionTracingWrapper {
+	var cfg ConnectionTracingConfig
+
+	cfg.Option(opts...)
+	cfg.Default()
+
+	return &ConnectionTracingWrapper{
+		cfg: cfg,
+	}
+}
+
+type ConnectionTracingWrapper struct {
+	cfg ConnectionTracingConfig
+	rt  http.RoundTripper
+}
+
+func (w *ConnectionTracingWrapper) Wrap(rt http.RoundTripper) http.RoundTripper {
+	w.rt = rt
+
+	return w
+}
+
+func (w *ConnectionTracingWrapper) RoundTrip(req *http.Request) (*http.Response, error) {
+	tracer := &connectionTracer{
+		trace: ConnectionTrace{Start: time.Now()},
+	}
+
+	ctx := context.WithValue(req.Context(), connectionTraceKey{}, tracer)
+	ctx = httptrace.WithClientTrace(ctx, tracer.clientTrace())
+
+	res, err := w.rt.RoundTrip(req.WithContext(ctx))
+
+	tracer.done()
+
+	if err == nil {
+		if res.Body == nil || res.Body == http.NoBody || res.StatusCode == http.StatusSwitchingProtocols {
+			tracer.update(func() { tracer.trace.Total = tracer.trace.Duration })
+		} else {
+			res.Body = &tracedBody{ReadCloser: res.Body, tracer: tracer}
+		}
+	}
+
+	trace := tracer.snapshot()
+
+	contextLogger(w.cfg.Logger, req.Context()).V(1).Info("connection trace",
+		"method", req.Method,
+		"url", redactURL(req.Context(), req.URL),
+		"getConn", trace.GetConn,
+		"dns", trace.DNS,
+		"connect", trace.Connect,
+		"tlsHandshake", trace.TLSHandshake,
+		"timeToFirstByte", trace.TimeToFirstByte,
+		"duration", trace.Duration,
+		"reused", trace.Reused,
+		"remoteAddr", trace.RemoteAddr,
+	)
+
+	if w.cfg.Hook != nil {
+		w.cfg.Hook(req, trace)
+	}
+
+	return res, err
+}
+
+// connectionTracer records a ConnectionTrace from httptrace
+// callbacks which may be invoked from other goroutines.
+type connectionTracer struct {
+	mu    sync.Mutex
+	trace ConnectionTrace
+
+	dnsStart, connectStart, tlsStart time.Time
+}
+
+func (t *connectionTracer) clientTrace() *httptrace.ClientTrace {
+	return &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) {
+			t.update(func() { t.dnsStart = time.Now() })
+		},
+		DNSDone: func(httptrace.DNSDoneInfo) {
+			t.update(func() { t.trace.DNS = since(t.dnsStart) })
+		},
+		ConnectStart: func(string, string) {
+			t.update(func() {
+				// only the first of several parallel dials is timed
+				if t.connectStart.IsZero() {
+					t.connectStart = time.Now()
+				}
+			})
+		},
+		ConnectDone: func(string, string, error) {
+			t.update(func() { t.trace.Connect = since(t.connectStart) })
+		},
+		TLSHandshakeStart: func() {
+			t.update(func() { t.tlsStart = time.Now() })
+		},
+		TLSHandshakeDone: func(tls.ConnectionState, error) {
+			t.update(func() { t.trace.TLSHandshake = since(t.tlsStart) })
+		},
+		GotConn: func(info httptrace.GotConnInfo) {
+			t.update(func() {
+				t.trace.GetConn = time.Since(t.trace.Start)
+				t.trace.Reused = info.Reused
+
+				if info.Conn != nil {
+					t.trace.RemoteAddr = info.Conn.RemoteAddr().String()
+				}
+			})
+		},
+		GotFirstResponseByte: func() {
+			t.update(func() { t.trace.TimeToFirstByte = time.Since(t.trace.Start) })
+		},
+	}
+}
+
+func (t *connectionTracer) update(fn func()) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	fn()
+}
+
+func (t *connectionTracer) done() {
+	t.update(func() { t.trace.Duration = time.Since(t.trace.Start) })
+}
+
+func (t *connectionTracer) finish() {
+	t.update(func() {
+		if t.trace.Total == 0 {
+			t.trace.Total = time.Since(t.trace.Start)
+		}
+	})
+}
+
+func (t *connectionTracer) snapshot() ConnectionTrace {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.trace
+}
+
+// tracedBody records the Total duration of a
+// ConnectionTrace once the body is consumed.
+type tracedBody struct {
+	io.ReadCloser
+	tracer *connectionTracer
+}
+
+func (b *tracedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if errors.Is(err, io.EOF) {
+		b.tracer.finish()
+	}
+
+	return n, err
+}
+
+func (b *tracedBody) Close() error {
+	b.tracer.finish()
+
+	return b.ReadCloser.Close()
+}
+
+// since returns the time elapsed since start
+// or zero if start has not been recorded.
+func since(start time.Time) time.Duration {
+	if start.IsZero() {
+		return 0
+	}
+
+	return time.Since(start)
+}
+
+type ConnectionTracingConfig struct {
+	Logger logr.Logger
+	Hook   TraceHook
+}
+
+func (c *ConnectionTracingConfig) Option(opts ...ConnectionTracingOption) {
+	for _, opt := range opts {
+		opt.ConfigureConnectionTracing(c)
+	}
+}
+
+func (c *ConnectionTracingConfig) Default() {
+	if c.Logger.GetSink() == nil {
+		c.Logger = logr.Discard()
+	}
+}
+
+type ConnectionTracingOption interface {
+	ConfigureConnectionTracing(*ConnectionTracingConfig)
+}
+
+func (l WithLogger) ConfigureConnectionTracing(c *ConnectionTracingConfig) {
+	c.Logger = l.Logger
+}
+
+// WithTraceHook configures a ConnectionTracingWrapper
+// to pass the trace of every attempt to the given hook.
+type WithTraceHook TraceHook
+
+func (h WithTraceHook) ConfigureConnectionTracing(c *ConnectionTracingConfig) {
+	c.Hook = TraceHook(h)
+}
+
+// WithConnectionTracing configures a Client instance to trace the
+// connection of every attempt using a ConnectionTracingWrapper. The
+// wrapper is registered with the lowest priority so that it is applied
+// beneath all other wrappers. The trace of the attempt which produced a
+// response is available through ResponseConnectionTrace.
+type WithConnectionTracing struct {
+	Logger logr.Logger
+	Hook   TraceHook
+}
+
+func (ct WithConnectionTracing) ConfigureClient(c *ClientConfig) {
+	c.register(NamedWrapper{
+		Name:     "connection tracing",
+		Priority: math.MinInt,
+		Wrapper: NewConnectionTracingWrapper(
+			WithLogger{Logger: ct.Logger},
+			WithTraceHook(ct.Hook),
+		),
+	})
+}
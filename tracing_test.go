@@ -0,0 +1,126 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/mt-sre/client/internal/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConnectionTracingWrapperInterfaces(t *testing.T) {
+	t.Parallel()
+
+	require.Implements(t, new(http.RoundTripper), new(ConnectionTracingWrapper))
+
+	require.Implements(t, new(TransportWrapper), new(ConnectionTracingWrapper))
+}
+
+func TestConnectionTracingWrapper(t *testing.T) {
+	t.Parallel()
+
+	next := Handler(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody, Request: req}, nil
+	})
+
+	var hooked []ConnectionTrace
+
+	rt := NewConnectionTracingWrapper(WithTraceHook(func(_ *http.Request, trace ConnectionTrace) {
+		hooked = append(hooked, trace)
+	})).Wrap(next)
+
+	res, err := rt.RoundTrip(testutils.MockRequest(t, http.MethodGet, nil))
+	require.NoError(t, err)
+
+	trace, ok := ResponseConnectionTrace(res)
+	require.True(t, ok)
+
+	require.Len(t, hooked, 1)
+	assert.Equal(t, hooked[0], trace)
+	assert.False(t, trace.Start.IsZero())
+	assert.Equal(t, trace.Duration, trace.Total, "responses without body are complete with the headers")
+
+	_, ok = ResponseConnectionTrace(&http.Response{Request: testutils.MockRequest(t, http.MethodGet, nil)})
+	assert.False(t, ok)
+}
+
+func TestWithConnectionTracing(t *testing.T) {
+	t.Parallel()
+
+	var requests atomic.Int32
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if requests.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+
+			return
+		}
+
+		_, _ = io.WriteString(w, "ok")
+	}))
+	defer srv.Close()
+
+	var (
+		mu     sync.Mutex
+		traces []ConnectionTrace
+	)
+
+	c := NewClient(
+		WithTransport{RoundTripper: &http.Transport{}},
+		WithWrapper{TransportWrapper: NewRetryWrapper(
+			WithBackoffGenerator(NoBackoffGenerator()),
+			WithMaxRetries(1),
+		)},
+		WithConnectionTracing{
+			Hook: func(_ *http.Request, trace ConnectionTrace) {
+				mu.Lock()
+				defer mu.Unlock()
+
+				traces = append(traces, trace)
+			},
+		},
+	)
+	defer c.Close()
+
+	res, err := c.Get(context.Background(), srv.URL)
+	require.NoError(t, err)
+
+	_, err = io.Copy(io.Discard, res.Body)
+	require.NoError(t, err)
+	require.NoError(t, res.Body.Close())
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	require.Len(t, traces, 2)
+
+	first, second := traces[0], traces[1]
+
+	assert.False(t, first.Reused)
+	assert.Positive(t, first.Connect)
+	assert.Positive(t, first.GetConn)
+	assert.Positive(t, first.TimeToFirstByte)
+	assert.GreaterOrEqual(t, first.Duration, first.TimeToFirstByte)
+	assert.Equal(t, srv.Listener.Addr().String(), first.RemoteAddr)
+
+	assert.True(t, second.Reused)
+	assert.Zero(t, second.Connect)
+
+	trace, ok := ResponseConnectionTrace(res)
+	require.True(t, ok)
+
+	// the total is only known once the body has been read
+	assert.Zero(t, second.Total)
+	assert.GreaterOrEqual(t, trace.Total, trace.Duration)
+
+	trace.Total = 0
+	assert.Equal(t, second, trace)
+
+	assert.Equal(t, "connection tracing", c.Wrappers()[0].Name)
+}
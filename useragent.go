@@ -0,0 +1,133 @@
+package client
+
+import (
+	"fmt"
+	"net/http"
+	"path"
+	"runtime"
+	"runtime/debug"
+	"strings"
+)
+
+// NewUserAgentWrapper returns a TransportWrapper which identifies
+// requests with a structured User-Agent of the form
+// "product/version (go1.23.0; linux/amd64)". If a request already
+// carries a User-Agent the structured value is appended to it rather
+// than replacing it. Unless configured with WithUserAgent the product
+// and version are taken from the build info of the running binary.
+func NewUserAgentWrapper(opts ...UserAgentOption) *UserAgentWrapper {
+	var cfg UserAgentConfig
+
+	cfg.Option(opts...)
+	cfg.Default()
+
+	return &UserAgentWrapper{
+		cfg:       cfg,
+		userAgent: cfg.String(),
+	}
+}
+
+type UserAgentWrapper struct {
+	cfg       UserAgentConfig
+	rt        http.RoundTripper
+	userAgent string
+}
+
+func (w *UserAgentWrapper) Wrap(rt http.RoundTripper) http.RoundTripper {
+	w.rt = rt
+
+	return w
+}
+
+func (w *UserAgentWrapper) RoundTrip(req *http.Request) (*http.Response, error) {
+	ua := w.userAgent
+
+	if existing := req.Header.Get("User-Agent"); existing != "" {
+		if strings.Contains(existing, ua) {
+			return w.rt.RoundTrip(req)
+		}
+
+		ua = existing + " " + ua
+	}
+
+	out := req.Clone(req.Context())
+	out.Header.Set("User-Agent", ua)
+
+	return w.rt.RoundTrip(out)
+}
+
+type UserAgentConfig struct {
+	Product string
+	Version string
+	// Comments are added to the parenthesized
+	// platform information.
+	Comments []string
+}
+
+func (c *UserAgentConfig) Option(opts ...UserAgentOption) {
+	for _, opt := range opts {
+		opt.ConfigureUserAgent(c)
+	}
+}
+
+func (c *UserAgentConfig) Default() {
+	if c.Product != "" && c.Version != "" {
+		return
+	}
+
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		info = &debug.BuildInfo{}
+	}
+
+	if c.Product == "" {
+		c.Product = path.Base(info.Main.Path)
+
+		if info.Main.Path == "" {
+			c.Product = "mt-sre-client"
+		}
+	}
+
+	if c.Version == "" {
+		c.Version = info.Main.Version
+
+		if c.Version == "" || c.Version == "(devel)" {
+			c.Version = "devel"
+		}
+	}
+}
+
+// String formats the configuration as a User-Agent value.
+func (c *UserAgentConfig) String() string {
+	comments := append([]string{
+		runtime.Version(),
+		runtime.GOOS + "/" + runtime.GOARCH,
+	}, c.Comments...)
+
+	return fmt.Sprintf("%s/%s (%s)", c.Product, c.Version, strings.Join(comments, "; "))
+}
+
+type UserAgentOption interface {
+	ConfigureUserAgent(*UserAgentConfig)
+}
+
+// WithUserAgent configures the product and version reported by a
+// UserAgentWrapper. Empty fields are populated from build info.
+type WithUserAgent struct {
+	Product string
+	Version string
+}
+
+func (ua WithUserAgent) ConfigureUserAgent(c *UserAgentConfig) {
+	c.Product = ua.Product
+	c.Version = ua.Version
+}
+
+// WithUserAgentComments adds the given comments to the parenthesized
+// section of the User-Agent e.g. a cluster or component identifier.
+// This option can be provided multiple times.
+type WithUserAgentComments []string
+
+func (uc WithUserAgentComments) ConfigureUserAgent(c *UserAgentConfig) {
+	c.Comments = append(c.Comments, uc...)
+}
@@ -0,0 +1,93 @@
+package client
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"runtime"
+	"testing"
+
+	"github.com/mt-sre/client/internal/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserAgentWrapperInterfaces(t *testing.T) {
+	t.Parallel()
+
+	require.Implements(t, new(http.RoundTripper), new(UserAgentWrapper))
+
+	require.Implements(t, new(TransportWrapper), new(UserAgentWrapper))
+}
+
+func TestUserAgentWrapper(t *testing.T) {
+	t.Parallel()
+
+	platform := "(" + runtime.Version() + "; " + runtime.GOOS + "/" + runtime.GOARCH
+
+	for name, tc := range map[string]struct {
+		Options           []UserAgentOption
+		ExistingUserAgent string
+		Expected          string
+	}{
+		"product and version": {
+			Options:  []UserAgentOption{WithUserAgent{Product: "ocm-agent", Version: "v1.2.3"}},
+			Expected: "ocm-agent/v1.2.3 " + platform + ")",
+		},
+		"with comments": {
+			Options: []UserAgentOption{
+				WithUserAgent{Product: "ocm-agent", Version: "v1.2.3"},
+				WithUserAgentComments{"cluster-a"},
+			},
+			Expected: "ocm-agent/v1.2.3 " + platform + "; cluster-a)",
+		},
+		"appends to existing": {
+			Options:           []UserAgentOption{WithUserAgent{Product: "ocm-agent", Version: "v1.2.3"}},
+			ExistingUserAgent: "kubectl/v1.30.0",
+			Expected:          "kubectl/v1.30.0 ocm-agent/v1.2.3 " + platform + ")",
+		},
+	} {
+		tc := tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			req := testutils.MockRequest(t, http.MethodGet, nil)
+			if tc.ExistingUserAgent != "" {
+				req.Header.Set("User-Agent", tc.ExistingUserAgent)
+			}
+
+			var mrt testutils.MockRoundTripper
+			mrt.
+				On("RoundTrip", mock.Anything).
+				Return(&http.Response{
+					StatusCode: http.StatusOK,
+					Body:       io.NopCloser(bytes.NewBuffer(nil)),
+				}, nil).
+				Once()
+
+			rt := NewUserAgentWrapper(tc.Options...).Wrap(&mrt)
+
+			res, err := rt.RoundTrip(req)
+			require.NoError(t, err)
+			require.NoError(t, res.Body.Close())
+
+			sent := mrt.Calls[0].Arguments.Get(0).(*http.Request)
+
+			assert.Equal(t, tc.Expected, sent.Header.Get("User-Agent"))
+			assert.Equal(t, tc.ExistingUserAgent, req.Header.Get("User-Agent"), "original request must not be modified")
+		})
+	}
+}
+
+func TestUserAgentConfigDefault(t *testing.T) {
+	t.Parallel()
+
+	var cfg UserAgentConfig
+
+	cfg.Default()
+
+	assert.NotEmpty(t, cfg.Product)
+	assert.NotEmpty(t, cfg.Version)
+}
@@ -0,0 +1,168 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"slices"
+)
+
+// NewResponseValidationWrapper returns a TransportWrapper which passes
+// responses with a status code in the 2xx range to the validators
+// configured with WithValidator. Responses failing validation are
+// closed and a *ResponseValidationError is returned in their place.
+// The ResponseValidationWrapper should be applied before RetryWrapper
+// so that validation errors marked with MarkRetryable are retried.
+func NewResponseValidationWrapper(opts ...ResponseValidationOption) *ResponseValidationWrapper {
+	var cfg ResponseValidationConfig
+
+	cfg.Option(opts...)
+
+	return &ResponseValidationWrapper{
+		cfg: cfg,
+	}
+}
+
+type ResponseValidationWrapper struct {
+	cfg ResponseValidationConfig
+	rt  http.RoundTripper
+}
+
+func (w *ResponseValidationWrapper) Wrap(rt http.RoundTripper) http.RoundTripper {
+	w.rt = rt
+
+	return w
+}
+
+func (w *ResponseValidationWrapper) RoundTrip(req *http.Request) (*http.Response, error) {
+	res, err := w.rt.RoundTrip(req)
+	if err != nil || res.StatusCode < 200 || res.StatusCode > 299 {
+		return res, err
+	}
+
+	for _, validate := range w.cfg.Validators {
+		if err := validate(res); err != nil {
+			res.Body.Close()
+
+			verr := &ResponseValidationError{
+				StatusCode: res.StatusCode,
+				Err:        err,
+			}
+
+			var marked *retryableError
+
+			if errors.As(err, &marked) {
+				verr.Err = marked.err
+				verr.Retryable = true
+			}
+
+			return nil, verr
+		}
+	}
+
+	return res, nil
+}
+
+// ResponseValidator inspects a response and returns an error if
+// it must not be passed to the caller. Errors are fatal unless
+// marked with MarkRetryable. Validators consuming the response
+// body must replace it for subsequent readers.
+type ResponseValidator func(res *http.Response) error
+
+// ResponseValidationError is returned when a response failed validation.
+type ResponseValidationError struct {
+	StatusCode int
+	Err        error
+	// Retryable is set if the error was marked with MarkRetryable.
+	Retryable bool
+}
+
+func (e *ResponseValidationError) Error() string {
+	return fmt.Sprintf("invalid response with status %d: %v", e.StatusCode, e.Err)
+}
+
+func (e *ResponseValidationError) Unwrap() error {
+	return e.Err
+}
+
+// MarkRetryable marks an error returned by a ResponseValidator
+// as retryable causing a RetryWrapper to retry the request.
+func MarkRetryable(err error) error {
+	return &retryableError{err: err}
+}
+
+type retryableError struct {
+	err error
+}
+
+func (e *retryableError) Error() string {
+	return e.err.Error()
+}
+
+func (e *retryableError) Unwrap() error {
+	return e.err
+}
+
+// ExpectContentType returns a ResponseValidator which fails responses
+// whose media type is not one of the given types, e.g. HTML error
+// pages served with 200 by misconfigured load balancers.
+func ExpectContentType(types ...string) ResponseValidator {
+	return func(res *http.Response) error {
+		mediaType, _, _ := mime.ParseMediaType(res.Header.Get("Content-Type"))
+
+		if !slices.Contains(types, mediaType) {
+			return fmt.Errorf("unexpected content type %q", res.Header.Get("Content-Type"))
+		}
+
+		return nil
+	}
+}
+
+type ResponseValidationConfig struct {
+	Validators []ResponseValidator
+}
+
+func (c *ResponseValidationConfig) Option(opts ...ResponseValidationOption) {
+	for _, opt := range opts {
+		opt.ConfigureResponseValidation(c)
+	}
+}
+
+type ResponseValidationOption interface {
+	ConfigureResponseValidation(*ResponseValidationConfig)
+}
+
+// WithValidator adds a ResponseValidator to a ResponseValidationWrapper
+// instance. This option can be provided multiple times.
+type WithValidator ResponseValidator
+
+func (v WithValidator) ConfigureResponseValidation(c *ResponseValidationConfig) {
+	c.Validators = append(c.Validators, ResponseValidator(v))
+}
+
+// responseValidationWrapperName names the wrapper
+// registered by WithResponseValidator.
+const responseValidationWrapperName = "response validation"
+
+// WithResponseValidator configures a Client instance to validate
+// responses using a ResponseValidationWrapper. The wrapper is
+// registered beneath wrappers of the default priority such as a
+// RetryWrapper. This option can be provided multiple times.
+type WithResponseValidator ResponseValidator
+
+func (v WithResponseValidator) ConfigureClient(c *ClientConfig) {
+	for _, nw := range c.registered {
+		if w, ok := nw.Wrapper.(*ResponseValidationWrapper); ok && nw.Name == responseValidationWrapperName {
+			WithValidator(v).ConfigureResponseValidation(&w.cfg)
+
+			return
+		}
+	}
+
+	c.register(NamedWrapper{
+		Name:     responseValidationWrapperName,
+		Priority: -1,
+		Wrapper:  NewResponseValidationWrapper(WithValidator(v)),
+	})
+}
@@ -0,0 +1,191 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/mt-sre/client/internal/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResponseValidationWrapperInterfaces(t *testing.T) {
+	t.Parallel()
+
+	require.Implements(t, new(http.RoundTripper), new(ResponseValidationWrapper))
+
+	require.Implements(t, new(TransportWrapper), new(ResponseValidationWrapper))
+}
+
+func TestResponseValidationWrapper(t *testing.T) {
+	t.Parallel()
+
+	errInvalid := errors.New("invalid")
+
+	for name, tc := range map[string]struct {
+		Validators        []ResponseValidator
+		StatusCode        int
+		ContentType       string
+		ExpectedErr       error
+		ExpectedRetryable bool
+	}{
+		"valid": {
+			Validators:  []ResponseValidator{ExpectContentType("application/json")},
+			StatusCode:  http.StatusOK,
+			ContentType: "application/json; charset=utf-8",
+		},
+		"unexpected content type": {
+			Validators:  []ResponseValidator{ExpectContentType("application/json")},
+			StatusCode:  http.StatusOK,
+			ContentType: "text/html",
+			ExpectedErr: &ResponseValidationError{},
+		},
+		"retryable": {
+			Validators: []ResponseValidator{
+				func(*http.Response) error { return MarkRetryable(errInvalid) },
+			},
+			StatusCode:        http.StatusOK,
+			ExpectedErr:       errInvalid,
+			ExpectedRetryable: true,
+		},
+		"later validator fails": {
+			Validators: []ResponseValidator{
+				func(*http.Response) error { return nil },
+				func(*http.Response) error { return errInvalid },
+			},
+			StatusCode:  http.StatusOK,
+			ExpectedErr: errInvalid,
+		},
+		"non-2xx not validated": {
+			Validators:  []ResponseValidator{ExpectContentType("application/json")},
+			StatusCode:  http.StatusNotFound,
+			ContentType: "text/html",
+		},
+	} {
+		tc := tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			mrt := &testutils.MockRoundTripper{}
+			mrt.
+				On("RoundTrip", mock.Anything).
+				Return(&http.Response{
+					StatusCode: tc.StatusCode,
+					Header:     http.Header{"Content-Type": {tc.ContentType}},
+					Body:       http.NoBody,
+				}, nil)
+
+			var opts []ResponseValidationOption
+
+			for _, v := range tc.Validators {
+				opts = append(opts, WithValidator(v))
+			}
+
+			rt := NewResponseValidationWrapper(opts...).Wrap(mrt)
+
+			res, err := rt.RoundTrip(testutils.MockRequest(t, http.MethodGet, nil))
+
+			if tc.ExpectedErr == nil {
+				require.NoError(t, err)
+				assert.Equal(t, tc.StatusCode, res.StatusCode)
+
+				return
+			}
+
+			assert.Nil(t, res)
+
+			var verr *ResponseValidationError
+
+			require.ErrorAs(t, err, &verr)
+			assert.Equal(t, tc.StatusCode, verr.StatusCode)
+			assert.Equal(t, tc.ExpectedRetryable, verr.Retryable)
+
+			if !errors.As(tc.ExpectedErr, new(*ResponseValidationError)) {
+				assert.ErrorIs(t, err, tc.ExpectedErr)
+			}
+		})
+	}
+}
+
+func TestClientResponseValidation(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		Validator        ResponseValidator
+		ExpectedAttempts int
+		ExpectedErr      bool
+	}{
+		"retryable": {
+			Validator: func(res *http.Response) error {
+				if err := ExpectContentType("application/json")(res); err != nil {
+					return MarkRetryable(err)
+				}
+
+				return nil
+			},
+			ExpectedAttempts: 2,
+		},
+		"fatal": {
+			Validator:        ExpectContentType("application/json"),
+			ExpectedAttempts: 1,
+			ExpectedErr:      true,
+		},
+	} {
+		tc := tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var attempts int
+
+			next := Handler(func(req *http.Request) (*http.Response, error) {
+				attempts++
+
+				contentType := "application/json"
+				if attempts == 1 {
+					contentType = "text/html"
+				}
+
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Header:     http.Header{"Content-Type": {contentType}},
+					Body:       http.NoBody,
+					Request:    req,
+				}, nil
+			})
+
+			c := NewClient(
+				WithTransport{RoundTripper: next},
+				WithWrapper{TransportWrapper: NewRetryWrapper(
+					WithBackoffGenerator(NoBackoffGenerator()),
+					WithMaxRetries(2),
+				)},
+				WithResponseValidator(tc.Validator),
+				WithResponseValidator(func(*http.Response) error { return nil }),
+			)
+			defer c.Close()
+
+			require.Len(t, c.Wrappers(), 2)
+			assert.Equal(t, "response validation", c.Wrappers()[0].Name)
+
+			res, err := c.Get(context.Background(), "https://api.example.com")
+
+			assert.Equal(t, tc.ExpectedAttempts, attempts)
+
+			if tc.ExpectedErr {
+				var verr *ResponseValidationError
+
+				assert.ErrorAs(t, err, &verr)
+
+				return
+			}
+
+			require.NoError(t, err)
+			require.NoError(t, res.Body.Close())
+		})
+	}
+}
@@ -0,0 +1,228 @@
+// Package webhook delivers webhooks signed with HMAC-SHA256. Deliveries
+// carry an idempotency key which stays the same across retries so that
+// receivers can deduplicate them, and are retried with jittered
+// exponential backoff by a client.RetryWrapper. Deliveries which fail
+// permanently are handed to a dead-letter callback.
+//
+//	sender := webhook.NewSender(
+//		webhook.WithSecret(secret),
+//		webhook.WithDeadLetter(func(d webhook.Delivery, err error) {
+//			queue.Store(d)
+//		}),
+//	)
+//	defer sender.Close()
+//
+//	err := sender.Send(ctx, "https://hooks.example.com/events", event)
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"slices"
+
+	"github.com/mt-sre/client"
+)
+
+// Delivery describes a single webhook delivery.
+type Delivery struct {
+	// ID is sent as the Idempotency-Key header of every attempt.
+	ID      string
+	URL     string
+	Payload []byte
+}
+
+// DeadLetterFunc receives deliveries which failed permanently
+// along with the error returned by Send.
+type DeadLetterFunc func(d Delivery, err error)
+
+// NewSender returns a Sender configured with the provided options.
+func NewSender(opts ...SenderOption) *Sender {
+	var cfg SenderConfig
+
+	cfg.Option(opts...)
+	cfg.Default()
+
+	clientOpts := slices.Clone(cfg.ClientOptions)
+
+	// deliveries are signed anew for every attempt
+	if len(cfg.Secret) > 0 {
+		clientOpts = append(clientOpts, client.WithNamedWrapper{
+			Name: "signing",
+			Wrapper: client.NewSigningWrapper(
+				client.WithSigner{Signer: &client.HMACSigner{Key: cfg.Secret}},
+			),
+		})
+	}
+
+	clientOpts = append(clientOpts,
+		client.WithNamedWrapper{
+			Name: client.RetryWrapperName,
+			Wrapper: client.NewRetryWrapper(
+				client.WithBackoffGenerator(cfg.Backoff),
+				client.WithMaxRetries(cfg.MaxRetries),
+				client.WithIdempotencyKey{},
+				client.WithErrorOnExhaustion{},
+			),
+		},
+	)
+
+	return &Sender{
+		cfg:    cfg,
+		client: client.NewClient(clientOpts...),
+	}
+}
+
+// Sender delivers webhooks.
+type Sender struct {
+	cfg    SenderConfig
+	client *client.Client
+}
+
+// Close releases the resources held by the Sender.
+func (s *Sender) Close() {
+	s.client.Close()
+}
+
+// Send POSTs payload to the given URL. Payloads of type []byte are
+// sent as is while other values are encoded as JSON. Responses with
+// a status code outside of the 2xx range which are not retried are
+// reported as *client.HTTPError and exhausted retries as
+// *client.RetriesExhaustedError. Failed deliveries are passed to the
+// configured DeadLetterFunc unless the context was canceled.
+func (s *Sender) Send(ctx context.Context, url string, payload any) error {
+	body, ok := payload.([]byte)
+	if !ok {
+		var err error
+
+		if body, err = json.Marshal(payload); err != nil {
+			return fmt.Errorf("encoding payload: %w", err)
+		}
+	}
+
+	id, err := newDeliveryID()
+	if err != nil {
+		return fmt.Errorf("generating delivery id: %w", err)
+	}
+
+	d := Delivery{
+		ID:      id,
+		URL:     url,
+		Payload: body,
+	}
+
+	if err := s.send(ctx, d); err != nil {
+		if s.cfg.DeadLetter != nil && ctx.Err() == nil {
+			s.cfg.DeadLetter(d, err)
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+func (s *Sender) send(ctx context.Context, d Delivery) error {
+	res, err := s.client.Post(ctx, d.URL, bytes.NewReader(d.Payload),
+		client.WithRequestHeaders{
+			"Content-Type":              {"application/json"},
+			client.IdempotencyKeyHeader: {d.ID},
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return client.NewHTTPError(res)
+	}
+
+	return res.Body.Close()
+}
+
+func newDeliveryID() (string, error) {
+	var id [16]byte
+
+	if _, err := rand.Read(id[:]); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(id[:]), nil
+}
+
+type SenderConfig struct {
+	// Secret is the key used to sign deliveries with a
+	// client.HMACSigner. Deliveries are not signed without it.
+	Secret []byte
+	// MaxRetries limits the number of retries of each
+	// delivery. Defaults to 5.
+	MaxRetries uint64
+	// Backoff generates the delays between attempts. Defaults
+	// to a jittered exponential backoff.
+	Backoff client.BackoffGenerator
+	// DeadLetter receives deliveries which failed permanently.
+	DeadLetter DeadLetterFunc
+	// ClientOptions configure the client used to send deliveries.
+	ClientOptions []client.ClientOption
+}
+
+func (c *SenderConfig) Option(opts ...SenderOption) {
+	for _, opt := range opts {
+		opt.ConfigureSender(c)
+	}
+}
+
+func (c *SenderConfig) Default() {
+	if c.MaxRetries == 0 {
+		c.MaxRetries = 5
+	}
+
+	if c.Backoff == nil {
+		c.Backoff = client.ExponentialBackoffGenerator(
+			client.WithRandomizationFactor(0.5),
+		)
+	}
+}
+
+type SenderOption interface {
+	ConfigureSender(*SenderConfig)
+}
+
+// WithSecret sets the key used to sign deliveries.
+type WithSecret []byte
+
+func (s WithSecret) ConfigureSender(c *SenderConfig) {
+	c.Secret = []byte(s)
+}
+
+// WithMaxRetries limits the number of retries of each delivery.
+type WithMaxRetries uint64
+
+func (r WithMaxRetries) ConfigureSender(c *SenderConfig) {
+	c.MaxRetries = uint64(r)
+}
+
+// WithBackoff sets the BackoffGenerator used to delay retries.
+type WithBackoff client.BackoffGenerator
+
+func (b WithBackoff) ConfigureSender(c *SenderConfig) {
+	c.Backoff = client.BackoffGenerator(b)
+}
+
+// WithDeadLetter registers a DeadLetterFunc receiving
+// deliveries which failed permanently.
+type WithDeadLetter DeadLetterFunc
+
+func (d WithDeadLetter) ConfigureSender(c *SenderConfig) {
+	c.DeadLetter = DeadLetterFunc(d)
+}
+
+// WithClientOptions configures the client used to send deliveries.
+type WithClientOptions []client.ClientOption
+
+func (o WithClientOptions) ConfigureSender(c *SenderConfig) {
+	c.ClientOptions = append(c.ClientOptions, o...)
+}
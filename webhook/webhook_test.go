@@ -0,0 +1,164 @@
+package webhook
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/mt-sre/client"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSenderSend(t *testing.T) {
+	t.Parallel()
+
+	secret := []byte("secret")
+
+	for name, tc := range map[string]struct {
+		Payload            any
+		Statuses           []int
+		ExpectedBody       string
+		ExpectedAttempts   int
+		ExpectedStatus     int
+		ExpectedExhaustion bool
+	}{
+		"success": {
+			Payload:          map[string]string{"event": "created"},
+			Statuses:         []int{http.StatusOK},
+			ExpectedBody:     `{"event":"created"}`,
+			ExpectedAttempts: 1,
+		},
+		"raw payload": {
+			Payload:          []byte(`{"raw":true}`),
+			Statuses:         []int{http.StatusAccepted},
+			ExpectedBody:     `{"raw":true}`,
+			ExpectedAttempts: 1,
+		},
+		"retried": {
+			Payload:          map[string]string{"event": "created"},
+			Statuses:         []int{http.StatusInternalServerError, http.StatusServiceUnavailable, http.StatusOK},
+			ExpectedBody:     `{"event":"created"}`,
+			ExpectedAttempts: 3,
+		},
+		"rejected": {
+			Payload:          map[string]string{"event": "created"},
+			Statuses:         []int{http.StatusBadRequest},
+			ExpectedBody:     `{"event":"created"}`,
+			ExpectedAttempts: 1,
+			ExpectedStatus:   http.StatusBadRequest,
+		},
+		"retries exhausted": {
+			Payload:            map[string]string{"event": "created"},
+			Statuses:           []int{http.StatusBadGateway, http.StatusBadGateway, http.StatusBadGateway},
+			ExpectedBody:       `{"event":"created"}`,
+			ExpectedAttempts:   3,
+			ExpectedExhaustion: true,
+		},
+	} {
+		tc := tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var (
+				mu   sync.Mutex
+				keys []string
+			)
+
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, err := io.ReadAll(r.Body)
+				require.NoError(t, err)
+
+				assert.Equal(t, tc.ExpectedBody, string(body))
+				assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+
+				mac := hmac.New(sha256.New, secret)
+				sum := sha256.Sum256(body)
+				fmt.Fprintf(mac, "%s\n%s\n%s\n%s", r.Header.Get("X-Signature-Timestamp"), r.Method, r.URL.RequestURI(), hex.EncodeToString(sum[:]))
+
+				assert.Equal(t, "sha256="+hex.EncodeToString(mac.Sum(nil)), r.Header.Get("X-Signature"))
+
+				mu.Lock()
+				keys = append(keys, r.Header.Get(client.IdempotencyKeyHeader))
+				attempt := len(keys) - 1
+				mu.Unlock()
+
+				w.WriteHeader(tc.Statuses[attempt])
+			}))
+			defer srv.Close()
+
+			var deadLetters []Delivery
+
+			sender := NewSender(
+				WithSecret(secret),
+				WithMaxRetries(2),
+				WithBackoff(client.NoBackoffGenerator()),
+				WithDeadLetter(func(d Delivery, _ error) {
+					deadLetters = append(deadLetters, d)
+				}),
+			)
+			defer sender.Close()
+
+			err := sender.Send(context.Background(), srv.URL+"/hooks", tc.Payload)
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			require.Len(t, keys, tc.ExpectedAttempts)
+
+			for _, key := range keys {
+				assert.NotEmpty(t, key)
+				assert.Equal(t, keys[0], key)
+			}
+
+			switch {
+			case tc.ExpectedStatus != 0:
+				assert.True(t, client.IsStatus(err, tc.ExpectedStatus), err)
+			case tc.ExpectedExhaustion:
+				var exhausted *client.RetriesExhaustedError
+
+				assert.ErrorAs(t, err, &exhausted)
+			default:
+				require.NoError(t, err)
+				assert.Empty(t, deadLetters)
+
+				return
+			}
+
+			require.Len(t, deadLetters, 1)
+			assert.Equal(t, keys[0], deadLetters[0].ID)
+			assert.Equal(t, srv.URL+"/hooks", deadLetters[0].URL)
+			assert.Equal(t, tc.ExpectedBody, string(deadLetters[0].Payload))
+		})
+	}
+}
+
+func TestSenderSendCanceled(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	var deadLetters int
+
+	sender := NewSender(WithDeadLetter(func(Delivery, error) {
+		deadLetters++
+	}))
+	defer sender.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	assert.ErrorIs(t, sender.Send(ctx, srv.URL, "payload"), context.Canceled)
+	assert.Zero(t, deadLetters)
+}
@@ -0,0 +1,308 @@
+package websocket
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
+)
+
+// MessageType is the type of a data message.
+type MessageType int
+
+const (
+	TextMessage   MessageType = MessageType(opText)
+	BinaryMessage MessageType = MessageType(opBinary)
+)
+
+// DefaultReadLimit is the maximum size in bytes
+// of received messages unless configured otherwise.
+const DefaultReadLimit = 32 << 20
+
+// Close status codes defined by RFC 6455 section 7.4.1.
+const (
+	CloseNormalClosure   = 1000
+	CloseGoingAway       = 1001
+	CloseProtocolError   = 1002
+	CloseUnsupportedData = 1003
+	CloseNoStatus        = 1005
+	CloseInvalidPayload  = 1007
+	CloseMessageTooBig   = 1009
+)
+
+var (
+	// ErrReadLimit is returned when a received
+	// message exceeds the configured read limit.
+	ErrReadLimit = errors.New("websocket: read limit exceeded")
+	// ErrPongTimeout is returned by KeepAlive when
+	// a ping is not answered before the next one is due.
+	ErrPongTimeout = errors.New("websocket: pong timeout")
+)
+
+// CloseError is returned by ReadMessage once
+// the server has closed the connection.
+type CloseError struct {
+	Code   int
+	Reason string
+}
+
+func (e *CloseError) Error() string {
+	if e.Reason == "" {
+		return fmt.Sprintf("websocket: closed with status %d", e.Code)
+	}
+
+	return fmt.Sprintf("websocket: closed with status %d: %s", e.Code, e.Reason)
+}
+
+// Conn is a client WebSocket connection. A single goroutine may
+// call ReadMessage while others write messages concurrently.
+type Conn struct {
+	conn        io.ReadWriteCloser
+	r           *bufio.Reader
+	header      http.Header
+	subprotocol string
+	readLimit   int64
+
+	writeMu   sync.Mutex
+	closeSent bool
+	closeOnce sync.Once
+	closeErr  error
+
+	// lastPong holds the time a pong was last received in unix nanoseconds.
+	lastPong atomic.Int64
+}
+
+// Subprotocol returns the subprotocol selected by the server.
+func (c *Conn) Subprotocol() string {
+	return c.subprotocol
+}
+
+// ResponseHeader returns the headers of the handshake response.
+func (c *Conn) ResponseHeader() http.Header {
+	return c.header
+}
+
+// ReadMessage returns the next data message received. Pings are
+// answered automatically. Once the server closes the connection a
+// *CloseError is returned.
+func (c *Conn) ReadMessage() (MessageType, []byte, error) {
+	var (
+		typ     MessageType
+		msg     []byte
+		started bool
+	)
+
+	for {
+		h, err := readFrameHeader(c.r)
+		if err != nil {
+			return 0, nil, c.fail(CloseProtocolError, err)
+		}
+
+		if h.masked {
+			return 0, nil, c.fail(CloseProtocolError, fmt.Errorf("%w: masked server frame", errProtocol))
+		}
+
+		if h.opcode.isControl() {
+			if err := c.handleControl(h); err != nil {
+				return 0, nil, err
+			}
+
+			continue
+		}
+
+		switch h.opcode {
+		case opContinuation:
+			if !started {
+				return 0, nil, c.fail(CloseProtocolError, fmt.Errorf("%w: unexpected continuation frame", errProtocol))
+			}
+		case opText, opBinary:
+			if started {
+				return 0, nil, c.fail(CloseProtocolError, fmt.Errorf("%w: incomplete fragmented message", errProtocol))
+			}
+
+			typ = MessageType(h.opcode)
+			started = true
+		default:
+			return 0, nil, c.fail(CloseProtocolError, fmt.Errorf("%w: unknown opcode %d", errProtocol, h.opcode))
+		}
+
+		// checked before allocating the payload as its length
+		// is chosen by the peer
+		if h.length > c.limit()-int64(len(msg)) {
+			return 0, nil, c.fail(CloseMessageTooBig, ErrReadLimit)
+		}
+
+		payload, err := readPayload(c.r, h)
+		if err != nil {
+			return 0, nil, c.fail(CloseProtocolError, err)
+		}
+
+		msg = append(msg, payload...)
+
+		if !h.fin {
+			continue
+		}
+
+		if typ == TextMessage && !utf8.Valid(msg) {
+			return 0, nil, c.fail(CloseInvalidPayload, fmt.Errorf("%w: invalid UTF-8 in text message", errProtocol))
+		}
+
+		return typ, msg, nil
+	}
+}
+
+func (c *Conn) limit() int64 {
+	if c.readLimit <= 0 {
+		return DefaultReadLimit
+	}
+
+	return c.readLimit
+}
+
+func (c *Conn) handleControl(h frameHeader) error {
+	payload, err := readPayload(c.r, h)
+	if err != nil {
+		return c.fail(CloseProtocolError, err)
+	}
+
+	switch h.opcode {
+	case opPing:
+		if err := c.writeFrame(opPong, payload); err != nil {
+			return err
+		}
+	case opPong:
+		c.lastPong.Store(time.Now().UnixNano())
+	case opClose:
+		closeErr := &CloseError{Code: CloseNoStatus}
+
+		if len(payload) >= 2 {
+			closeErr.Code = int(binary.BigEndian.Uint16(payload))
+			closeErr.Reason = string(payload[2:])
+		}
+
+		_ = c.writeClose(closeErr.Code, "")
+		_ = c.close()
+
+		return closeErr
+	default:
+		return c.fail(CloseProtocolError, fmt.Errorf("%w: unknown opcode %d", errProtocol, h.opcode))
+	}
+
+	return nil
+}
+
+// fail closes the connection with the given status code and returns err.
+func (c *Conn) fail(code int, err error) error {
+	if !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+		_ = c.writeClose(code, "")
+	}
+
+	_ = c.close()
+
+	return err
+}
+
+// WriteMessage sends a single data message.
+func (c *Conn) WriteMessage(typ MessageType, data []byte) error {
+	if typ != TextMessage && typ != BinaryMessage {
+		return fmt.Errorf("websocket: invalid message type %d", typ)
+	}
+
+	return c.writeFrame(opcode(typ), data)
+}
+
+// Ping sends a ping with the given application data
+// which must not be longer than 125 bytes.
+func (c *Conn) Ping(data []byte) error {
+	if len(data) > maxControlPayload {
+		return fmt.Errorf("websocket: ping payload exceeds %d bytes", maxControlPayload)
+	}
+
+	return c.writeFrame(opPing, data)
+}
+
+// KeepAlive sends a ping every interval until the context is canceled
+// or writing fails. If a ping has not been answered by a pong when the
+// next one is due the connection is closed and ErrPongTimeout is
+// returned. Pongs are only processed while ReadMessage is being called.
+func (c *Conn) KeepAlive(ctx context.Context, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var sent time.Time
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+
+		if !sent.IsZero() && c.lastPong.Load() < sent.UnixNano() {
+			return c.fail(CloseGoingAway, ErrPongTimeout)
+		}
+
+		sent = time.Now()
+
+		if err := c.Ping(nil); err != nil {
+			return err
+		}
+	}
+}
+
+// Close sends a normal closure message and
+// closes the underlying connection.
+func (c *Conn) Close() error {
+	_ = c.writeClose(CloseNormalClosure, "")
+
+	return c.close()
+}
+
+func (c *Conn) close() error {
+	c.closeOnce.Do(func() {
+		c.closeErr = c.conn.Close()
+	})
+
+	return c.closeErr
+}
+
+// writeClose sends a close frame unless one was already sent.
+func (c *Conn) writeClose(code int, reason string) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	if c.closeSent {
+		return nil
+	}
+
+	c.closeSent = true
+
+	var payload []byte
+
+	// CloseNoStatus must not be sent
+	if code != CloseNoStatus {
+		payload = binary.BigEndian.AppendUint16(payload, uint16(code))
+		payload = append(payload, reason...)
+	}
+
+	return writeFrame(c.conn, opClose, payload, true)
+}
+
+func (c *Conn) writeFrame(op opcode, payload []byte) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	if c.closeSent {
+		return net.ErrClosed
+	}
+
+	return writeFrame(c.conn, op, payload, true)
+}
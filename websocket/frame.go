@@ -0,0 +1,166 @@
+package websocket
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// opcode identifies the type of a frame as defined by RFC 6455 section 5.2.
+type opcode byte
+
+const (
+	opContinuation opcode = 0x0
+	opText         opcode = 0x1
+	opBinary       opcode = 0x2
+	opClose        opcode = 0x8
+	opPing         opcode = 0x9
+	opPong         opcode = 0xA
+)
+
+func (o opcode) isControl() bool {
+	return o&0x8 != 0
+}
+
+const (
+	finBit  = 0x80
+	rsvBits = 0x70
+	maskBit = 0x80
+
+	// maxControlPayload is the largest payload
+	// permitted for control frames.
+	maxControlPayload = 125
+)
+
+var errProtocol = errors.New("websocket protocol error")
+
+type frameHeader struct {
+	fin    bool
+	opcode opcode
+	masked bool
+	mask   [4]byte
+	length int64
+}
+
+// readFrameHeader reads the header of the next frame from r.
+func readFrameHeader(r io.Reader) (frameHeader, error) {
+	var (
+		h   frameHeader
+		buf [8]byte
+	)
+
+	if _, err := io.ReadFull(r, buf[:2]); err != nil {
+		return h, err
+	}
+
+	if buf[0]&rsvBits != 0 {
+		return h, fmt.Errorf("%w: reserved bits set", errProtocol)
+	}
+
+	h.fin = buf[0]&finBit != 0
+	h.opcode = opcode(buf[0] & 0xF)
+	h.masked = buf[1]&maskBit != 0
+	h.length = int64(buf[1] & 0x7F)
+
+	switch h.length {
+	case 126:
+		if _, err := io.ReadFull(r, buf[:2]); err != nil {
+			return h, err
+		}
+
+		h.length = int64(binary.BigEndian.Uint16(buf[:2]))
+	case 127:
+		if _, err := io.ReadFull(r, buf[:8]); err != nil {
+			return h, err
+		}
+
+		length := binary.BigEndian.Uint64(buf[:8])
+		if length > 1<<63-1 {
+			return h, fmt.Errorf("%w: invalid payload length", errProtocol)
+		}
+
+		h.length = int64(length)
+	}
+
+	if h.masked {
+		if _, err := io.ReadFull(r, h.mask[:]); err != nil {
+			return h, err
+		}
+	}
+
+	if h.opcode.isControl() && (!h.fin || h.length > maxControlPayload) {
+		return h, fmt.Errorf("%w: invalid control frame", errProtocol)
+	}
+
+	return h, nil
+}
+
+// readPayload reads the payload of the frame described by h from r.
+func readPayload(r io.Reader, h frameHeader) ([]byte, error) {
+	payload := make([]byte, h.length)
+
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return nil, err
+	}
+
+	if h.masked {
+		maskBytes(h.mask, payload)
+	}
+
+	return payload, nil
+}
+
+// writeFrame writes a single unfragmented frame to w. Frames
+// sent by clients must be masked with a random key.
+func writeFrame(w io.Writer, op opcode, payload []byte, masked bool) error {
+	buf := make([]byte, 0, 14+len(payload))
+	buf = append(buf, finBit|byte(op))
+
+	var maskFlag byte
+
+	if masked {
+		maskFlag = maskBit
+	}
+
+	switch length := len(payload); {
+	case length <= maxControlPayload:
+		buf = append(buf, maskFlag|byte(length))
+	case length <= 0xFFFF:
+		buf = append(buf, maskFlag|126)
+		buf = binary.BigEndian.AppendUint16(buf, uint16(length))
+	default:
+		buf = append(buf, maskFlag|127)
+		buf = binary.BigEndian.AppendUint64(buf, uint64(length))
+	}
+
+	start := len(buf)
+
+	if masked {
+		var key [4]byte
+
+		if _, err := rand.Read(key[:]); err != nil {
+			return fmt.Errorf("generating mask: %w", err)
+		}
+
+		buf = append(buf, key[:]...)
+		start += len(key)
+
+		buf = append(buf, payload...)
+		maskBytes(key, buf[start:])
+	} else {
+		buf = append(buf, payload...)
+	}
+
+	_, err := w.Write(buf)
+
+	return err
+}
+
+// maskBytes applies the masking algorithm of RFC 6455 section 5.3 to b.
+func maskBytes(key [4]byte, b []byte) {
+	for i := range b {
+		b[i] ^= key[i%4]
+	}
+}
@@ -0,0 +1,233 @@
+// Package websocket dials WebSocket connections through the transport
+// chain of a client.Client so that authentication, proxy and TLS
+// settings as well as default headers apply to the opening handshake.
+//
+//	conn, err := websocket.Dial(ctx, c, "wss://api.example.com/events")
+//	if err != nil {
+//		return err
+//	}
+//	defer conn.Close()
+//
+//	go conn.KeepAlive(ctx, 30*time.Second)
+//
+//	for {
+//		typ, msg, err := conn.ReadMessage()
+//		...
+//	}
+package websocket
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/mt-sre/client"
+)
+
+// acceptGUID is appended to the handshake key to
+// compute the Sec-WebSocket-Accept header.
+const acceptGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+var (
+	// ErrBadHandshake is returned when the server
+	// response does not complete the opening handshake.
+	ErrBadHandshake = errors.New("websocket: bad handshake")
+	// ErrUpgradeNotSupported is returned when the transport chain
+	// does not expose the upgraded connection for writing, for
+	// instance because the client has an overall timeout set.
+	ErrUpgradeNotSupported = errors.New("websocket: transport does not support protocol upgrades")
+)
+
+// Doer sends HTTP requests. It is implemented
+// by *client.Client and client.ClientInterface.
+type Doer interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// Dial performs the opening handshake of RFC 6455 against the ws or
+// wss URL using c. The context governs the handshake only. Responses
+// other than 101 Switching Protocols are reported as *client.HTTPError.
+func Dial(ctx context.Context, c Doer, rawURL string, opts ...DialOption) (*Conn, error) {
+	var cfg DialConfig
+
+	cfg.Option(opts...)
+	cfg.Default()
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("parsing url: %w", err)
+	}
+
+	switch u.Scheme {
+	case "ws":
+		u.Scheme = "http"
+	case "wss":
+		u.Scheme = "https"
+	}
+
+	key, err := newKey()
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+
+	for key, vals := range cfg.Header {
+		req.Header[http.CanonicalHeaderKey(key)] = slices.Clone(vals)
+	}
+
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Sec-WebSocket-Key", key)
+	req.Header.Set("Sec-WebSocket-Version", "13")
+
+	if len(cfg.Subprotocols) > 0 {
+		req.Header.Set("Sec-WebSocket-Protocol", strings.Join(cfg.Subprotocols, ", "))
+	}
+
+	res, err := c.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode != http.StatusSwitchingProtocols {
+		return nil, client.NewHTTPError(res)
+	}
+
+	rwc, ok := res.Body.(io.ReadWriteCloser)
+	if !ok {
+		res.Body.Close()
+
+		return nil, ErrUpgradeNotSupported
+	}
+
+	if err := verifyHandshake(res, key, cfg.Subprotocols); err != nil {
+		rwc.Close()
+
+		return nil, err
+	}
+
+	return &Conn{
+		conn:        rwc,
+		r:           bufio.NewReader(rwc),
+		header:      res.Header,
+		subprotocol: res.Header.Get("Sec-WebSocket-Protocol"),
+		readLimit:   cfg.ReadLimit,
+	}, nil
+}
+
+func newKey() (string, error) {
+	var key [16]byte
+
+	if _, err := rand.Read(key[:]); err != nil {
+		return "", fmt.Errorf("generating key: %w", err)
+	}
+
+	return base64.StdEncoding.EncodeToString(key[:]), nil
+}
+
+func acceptKey(key string) string {
+	sum := sha1.Sum([]byte(key + acceptGUID))
+
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+func verifyHandshake(res *http.Response, key string, subprotocols []string) error {
+	if !strings.EqualFold(res.Header.Get("Upgrade"), "websocket") {
+		return fmt.Errorf("%w: unexpected Upgrade header %q", ErrBadHandshake, res.Header.Get("Upgrade"))
+	}
+
+	if !hasToken(res.Header, "Connection", "upgrade") {
+		return fmt.Errorf("%w: missing Connection upgrade", ErrBadHandshake)
+	}
+
+	if res.Header.Get("Sec-WebSocket-Accept") != acceptKey(key) {
+		return fmt.Errorf("%w: invalid Sec-WebSocket-Accept", ErrBadHandshake)
+	}
+
+	if proto := res.Header.Get("Sec-WebSocket-Protocol"); proto != "" && !slices.Contains(subprotocols, proto) {
+		return fmt.Errorf("%w: unexpected subprotocol %q", ErrBadHandshake, proto)
+	}
+
+	return nil
+}
+
+func hasToken(header http.Header, key, token string) bool {
+	for _, val := range header.Values(key) {
+		for _, elem := range strings.Split(val, ",") {
+			if strings.EqualFold(strings.TrimSpace(elem), token) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+type DialConfig struct {
+	// Header is added to the handshake request in addition
+	// to the default headers of the client.
+	Header http.Header
+	// Subprotocols are offered to the server in order of preference.
+	Subprotocols []string
+	// ReadLimit is the maximum size in bytes of a received
+	// message. Defaults to DefaultReadLimit.
+	ReadLimit int64
+}
+
+func (c *DialConfig) Option(opts ...DialOption) {
+	for _, opt := range opts {
+		opt.ConfigureDial(c)
+	}
+}
+
+func (c *DialConfig) Default() {
+	if c.ReadLimit <= 0 {
+		c.ReadLimit = DefaultReadLimit
+	}
+}
+
+type DialOption interface {
+	ConfigureDial(*DialConfig)
+}
+
+// WithHeader adds the given headers to the handshake request.
+type WithHeader http.Header
+
+func (h WithHeader) ConfigureDial(c *DialConfig) {
+	if c.Header == nil {
+		c.Header = make(http.Header)
+	}
+
+	for key, vals := range h {
+		c.Header[http.CanonicalHeaderKey(key)] = slices.Clone(vals)
+	}
+}
+
+// WithSubprotocols offers the given subprotocols to the server.
+type WithSubprotocols []string
+
+func (s WithSubprotocols) ConfigureDial(c *DialConfig) {
+	c.Subprotocols = append(c.Subprotocols, s...)
+}
+
+// WithReadLimit limits the size in bytes of received messages.
+// Larger messages cause the connection to be closed. Values of
+// zero or less select DefaultReadLimit.
+type WithReadLimit int64
+
+func (l WithReadLimit) ConfigureDial(c *DialConfig) {
+	c.ReadLimit = int64(l)
+}
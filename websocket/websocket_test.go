@@ -0,0 +1,326 @@
+package websocket
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mt-sre/client"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newEchoServer returns a server completing the opening handshake
+// and echoing data messages. Pings are answered unless ignorePings
+// is set and close frames are echoed before closing the connection.
+func newEchoServer(t *testing.T, ignorePings bool) *httptest.Server {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+
+			return
+		}
+
+		conn, rw, err := http.NewResponseController(w).Hijack()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		rw.WriteString("HTTP/1.1 101 Switching Protocols\r\n")
+		rw.WriteString("Upgrade: websocket\r\n")
+		rw.WriteString("Connection: Upgrade\r\n")
+		rw.WriteString("Sec-WebSocket-Accept: " + acceptKey(r.Header.Get("Sec-WebSocket-Key")) + "\r\n")
+
+		if protos := r.Header.Get("Sec-WebSocket-Protocol"); protos != "" {
+			rw.WriteString("Sec-WebSocket-Protocol: " + strings.Split(protos, ",")[0] + "\r\n")
+		}
+
+		rw.WriteString("\r\n")
+		rw.Flush()
+
+		for {
+			h, err := readFrameHeader(rw)
+			if err != nil || !h.masked {
+				return
+			}
+
+			payload, err := readPayload(rw, h)
+			if err != nil {
+				return
+			}
+
+			switch h.opcode {
+			case opPing:
+				if ignorePings {
+					continue
+				}
+
+				writeFrame(conn, opPong, payload, false)
+			case opClose:
+				writeFrame(conn, opClose, payload, false)
+
+				return
+			default:
+				writeFrame(conn, h.opcode, payload, false)
+			}
+		}
+	}))
+
+	t.Cleanup(srv.Close)
+
+	return srv
+}
+
+func newClient() *client.Client {
+	return client.NewClient(client.WithDefaultHeaders{
+		"Authorization": {"Bearer token"},
+	})
+}
+
+func TestDial(t *testing.T) {
+	t.Parallel()
+
+	srv := newEchoServer(t, false)
+
+	c := newClient()
+	defer c.Close()
+
+	conn, err := Dial(context.Background(), c, "ws"+strings.TrimPrefix(srv.URL, "http"),
+		WithSubprotocols{"v1", "v2"},
+	)
+	require.NoError(t, err)
+
+	assert.Equal(t, "v1", conn.Subprotocol())
+
+	for _, msg := range []struct {
+		Type MessageType
+		Data []byte
+	}{
+		{Type: TextMessage, Data: []byte("hello")},
+		{Type: BinaryMessage, Data: make([]byte, 70000)},
+	} {
+		require.NoError(t, conn.WriteMessage(msg.Type, msg.Data))
+
+		typ, data, err := conn.ReadMessage()
+		require.NoError(t, err)
+
+		assert.Equal(t, msg.Type, typ)
+		assert.Equal(t, msg.Data, data)
+	}
+
+	require.NoError(t, conn.Close())
+}
+
+func TestDialErrors(t *testing.T) {
+	t.Parallel()
+
+	srv := newEchoServer(t, false)
+
+	_, err := Dial(context.Background(), client.NewClient(), srv.URL)
+	assert.True(t, client.IsStatus(err, http.StatusUnauthorized))
+
+	bad := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Upgrade", "websocket")
+		w.Header().Set("Connection", "Upgrade")
+		w.Header().Set("Sec-WebSocket-Accept", "invalid")
+		w.WriteHeader(http.StatusSwitchingProtocols)
+	}))
+	defer bad.Close()
+
+	_, err = Dial(context.Background(), client.NewClient(), bad.URL)
+	assert.ErrorIs(t, err, ErrBadHandshake)
+}
+
+func TestConnReadMessage(t *testing.T) {
+	t.Parallel()
+
+	frame := func(fin bool, op opcode, payload string) []byte {
+		var buf strings.Builder
+
+		writeFrame(&buf, op, []byte(payload), false)
+
+		b := []byte(buf.String())
+		if !fin {
+			b[0] &^= finBit
+		}
+
+		return b
+	}
+
+	closePayload := string(binary.BigEndian.AppendUint16(nil, CloseGoingAway)) + "bye"
+
+	for name, tc := range map[string]struct {
+		Frames       [][]byte
+		ReadLimit    int64
+		ExpectedType MessageType
+		ExpectedData string
+		ExpectedErr  error
+		ExpectedSent []opcode
+	}{
+		"fragmented with interleaved ping": {
+			Frames: [][]byte{
+				frame(false, opText, "hel"),
+				frame(true, opPing, "p"),
+				frame(true, opContinuation, "lo"),
+			},
+			ExpectedType: TextMessage,
+			ExpectedData: "hello",
+			ExpectedSent: []opcode{opPong},
+		},
+		"close": {
+			Frames:       [][]byte{frame(true, opClose, closePayload)},
+			ExpectedErr:  &CloseError{Code: CloseGoingAway, Reason: "bye"},
+			ExpectedSent: []opcode{opClose},
+		},
+		"read limit": {
+			Frames:       [][]byte{frame(true, opBinary, "too long")},
+			ReadLimit:    4,
+			ExpectedErr:  ErrReadLimit,
+			ExpectedSent: []opcode{opClose},
+		},
+		"oversized frame header": {
+			Frames:       [][]byte{{finBit | byte(opBinary), 127, 0x3f, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}},
+			ExpectedErr:  ErrReadLimit,
+			ExpectedSent: []opcode{opClose},
+		},
+		"unexpected continuation": {
+			Frames:       [][]byte{frame(true, opContinuation, "x")},
+			ExpectedErr:  errProtocol,
+			ExpectedSent: []opcode{opClose},
+		},
+		"invalid utf-8": {
+			Frames:       [][]byte{frame(true, opText, "\xff")},
+			ExpectedErr:  errProtocol,
+			ExpectedSent: []opcode{opClose},
+		},
+	} {
+		tc := tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var in []byte
+
+			for _, f := range tc.Frames {
+				in = append(in, f...)
+			}
+
+			rwc := &fakeConn{Reader: strings.NewReader(string(in))}
+
+			conn := &Conn{
+				conn:      rwc,
+				r:         bufio.NewReader(rwc),
+				readLimit: tc.ReadLimit,
+			}
+
+			typ, data, err := conn.ReadMessage()
+
+			if tc.ExpectedErr != nil {
+				var closeErr *CloseError
+
+				if errors.As(tc.ExpectedErr, &closeErr) {
+					assert.Equal(t, tc.ExpectedErr, err)
+				} else {
+					assert.ErrorIs(t, err, tc.ExpectedErr)
+				}
+
+				assert.True(t, rwc.closed)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tc.ExpectedType, typ)
+				assert.Equal(t, tc.ExpectedData, string(data))
+			}
+
+			r := bufio.NewReader(strings.NewReader(rwc.out.String()))
+
+			var sent []opcode
+
+			for {
+				h, err := readFrameHeader(r)
+				if err != nil {
+					break
+				}
+
+				require.True(t, h.masked)
+
+				_, err = readPayload(r, h)
+				require.NoError(t, err)
+
+				sent = append(sent, h.opcode)
+			}
+
+			assert.Equal(t, tc.ExpectedSent, sent)
+		})
+	}
+}
+
+func TestConnKeepAlive(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		IgnorePings bool
+		ExpectedErr error
+	}{
+		"answered": {
+			ExpectedErr: context.DeadlineExceeded,
+		},
+		"unanswered": {
+			IgnorePings: true,
+			ExpectedErr: ErrPongTimeout,
+		},
+	} {
+		tc := tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			srv := newEchoServer(t, tc.IgnorePings)
+
+			c := newClient()
+			defer c.Close()
+
+			conn, err := Dial(context.Background(), c, srv.URL)
+			require.NoError(t, err)
+
+			defer conn.Close()
+
+			go func() {
+				for {
+					if _, _, err := conn.ReadMessage(); err != nil {
+						return
+					}
+				}
+			}()
+
+			ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+			defer cancel()
+
+			assert.ErrorIs(t, conn.KeepAlive(ctx, 20*time.Millisecond), tc.ExpectedErr)
+		})
+	}
+}
+
+type fakeConn struct {
+	*strings.Reader
+	out    strings.Builder
+	closed bool
+}
+
+func (c *fakeConn) Write(p []byte) (int, error) {
+	return c.out.Write(p)
+}
+
+func (c *fakeConn) Close() error {
+	c.closed = true
+
+	return nil
+}
@@ -0,0 +1,88 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+
+	"golang.org/x/text/encoding/htmlindex"
+)
+
+// xmlAccept is sent by XML requests to ask for an XML response.
+const xmlAccept = "application/xml, text/xml;q=0.9"
+
+var xmlCodec = bodyCodec{
+	accept:  xmlAccept,
+	accepts: isXMLMediaType,
+	decode:  decodeXMLBody,
+}
+
+// DecodeXML decodes the XML body of res into a value of type T and
+// closes the body. Documents declaring an encoding other than UTF-8
+// are transcoded. Errors are reported as with DecodeJSON.
+func DecodeXML[T any](res *http.Response) (T, error) {
+	var v T
+
+	err := decodeResponse(res, func(r io.Reader) error {
+		return decodeXMLBody(r, &v)
+	})
+
+	return v, err
+}
+
+// GetXML performs a HTTP GET request against the provided URL asking
+// for XML and decodes the response body into v. Responses which are
+// not of an XML media type are rejected.
+func (c *Client) GetXML(ctx context.Context, url string, v any, opts ...RequestOption) error {
+	return c.doNegotiated(ctx, http.MethodGet, url, nil, xmlCodec, v, opts...)
+}
+
+// PostXML performs a HTTP POST request against the provided URL with
+// in encoded as XML as the request body and decodes the response body
+// into out unless it is nil.
+func (c *Client) PostXML(ctx context.Context, url string, in, out any, opts ...RequestOption) error {
+	body, err := xml.Marshal(in)
+	if err != nil {
+		return fmt.Errorf("encoding request body: %w", err)
+	}
+
+	opts = append(opts, withDefaultRequestHeaders{
+		"Content-Type": []string{"application/xml; charset=utf-8"},
+	})
+
+	return c.doNegotiated(ctx, http.MethodPost, url, bytes.NewReader(append([]byte(xml.Header), body...)), xmlCodec, out, opts...)
+}
+
+// decodeXMLBody decodes r into v transcoding documents
+// which declare an encoding other than UTF-8.
+func decodeXMLBody(r io.Reader, v any) error {
+	dec := xml.NewDecoder(r)
+	dec.CharsetReader = xmlCharsetReader
+
+	return dec.Decode(v)
+}
+
+// isXMLMediaType reports whether contentType denotes XML
+// including structured syntax suffixes such as "+xml".
+func isXMLMediaType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+
+	return mediaType == "application/xml" || mediaType == "text/xml" || strings.HasSuffix(mediaType, "+xml")
+}
+
+func xmlCharsetReader(charset string, input io.Reader) (io.Reader, error) {
+	enc, err := htmlindex.Get(charset)
+	if err != nil {
+		return nil, fmt.Errorf("looking up charset %q: %w", charset, err)
+	}
+
+	return enc.NewDecoder().Reader(input), nil
+}
@@ -0,0 +1,145 @@
+package client
+
+import (
+	"context"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type xmlTestAccount struct {
+	XMLName xml.Name `xml:"account"`
+	ID      string   `xml:"id,attr"`
+	Owner   string   `xml:"owner"`
+}
+
+func TestClientGetXML(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		ContentType string
+		Body        string
+		Expected    xmlTestAccount
+		ExpectError bool
+	}{
+		"application/xml": {
+			ContentType: "application/xml",
+			Body:        `<account id="42"><owner>Ada</owner></account>`,
+			Expected:    xmlTestAccount{XMLName: xml.Name{Local: "account"}, ID: "42", Owner: "Ada"},
+		},
+		"latin1 document": {
+			ContentType: "text/xml",
+			Body:        "<?xml version=\"1.0\" encoding=\"ISO-8859-1\"?><account id=\"7\"><owner>Jos\xe9</owner></account>",
+			Expected:    xmlTestAccount{XMLName: xml.Name{Local: "account"}, ID: "7", Owner: "José"},
+		},
+		"structured suffix": {
+			ContentType: "application/atom+xml; charset=utf-8",
+			Body:        `<account id="1"><owner>Grace</owner></account>`,
+			Expected:    xmlTestAccount{XMLName: xml.Name{Local: "account"}, ID: "1", Owner: "Grace"},
+		},
+		"html": {
+			ContentType: "text/html",
+			Body:        `<html><body>Service Unavailable</body></html>`,
+			ExpectError: true,
+		},
+	} {
+		tc := tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, xmlAccept, r.Header.Get("Accept"))
+
+				w.Header().Set("Content-Type", tc.ContentType)
+				io.WriteString(w, tc.Body)
+			}))
+			defer srv.Close()
+
+			var account xmlTestAccount
+
+			err := NewClient().GetXML(context.Background(), srv.URL, &account)
+			if tc.ExpectError {
+				require.Error(t, err)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.Expected, account)
+		})
+	}
+}
+
+func TestClientPostXML(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		Options             []RequestOption
+		ExpectedContentType string
+		ExpectedAccept      string
+	}{
+		"default headers": {
+			ExpectedContentType: "application/xml; charset=utf-8",
+			ExpectedAccept:      xmlCodec.accept,
+		},
+		"explicit headers": {
+			Options: []RequestOption{
+				WithRequestHeaders{
+					"Content-Type": {"text/xml"},
+					"Accept":       {"application/xml"},
+				},
+			},
+			ExpectedContentType: "text/xml",
+			ExpectedAccept:      "application/xml",
+		},
+	} {
+		tc := tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, []string{tc.ExpectedContentType}, r.Header.Values("Content-Type"))
+				assert.Equal(t, []string{tc.ExpectedAccept}, r.Header.Values("Accept"))
+
+				body, err := io.ReadAll(r.Body)
+				require.NoError(t, err)
+
+				assert.True(t, strings.HasPrefix(string(body), xml.Header))
+				assert.Contains(t, string(body), `<account id="42"><owner>Ada</owner></account>`)
+
+				w.Header().Set("Content-Type", "application/xml")
+				io.WriteString(w, `<account id="43"><owner>Ada</owner></account>`)
+			}))
+			defer srv.Close()
+
+			var created xmlTestAccount
+
+			err := NewClient().PostXML(context.Background(), srv.URL, xmlTestAccount{ID: "42", Owner: "Ada"}, &created, tc.Options...)
+			require.NoError(t, err)
+
+			assert.Equal(t, "43", created.ID)
+		})
+	}
+}
+
+func TestDecodeXML(t *testing.T) {
+	t.Parallel()
+
+	res := &http.Response{
+		StatusCode: http.StatusOK,
+		Body:       io.NopCloser(strings.NewReader(`<account id="42"><owner>Ada</owner></account>`)),
+	}
+
+	account, err := DecodeXML[xmlTestAccount](res)
+	require.NoError(t, err)
+
+	assert.Equal(t, "Ada", account.Owner)
+}